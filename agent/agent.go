@@ -2,6 +2,7 @@ package agent
 
 import (
 	"bufio"
+	"bytes"
 	"context"
 	"crypto/rand"
 	"crypto/rsa"
@@ -67,6 +68,10 @@ const (
 	MagicSSHSessionTypeVSCode = "vscode"
 	// MagicSSHSessionTypeJetBrains is set in the SSH config by the JetBrains extension to identify itself.
 	MagicSSHSessionTypeJetBrains = "jetbrains"
+	// MagicSSHSessionUserIDEnvironmentVariable is set by `coder ssh` to the ID of the connecting user.
+	// This is stripped from any commands being executed. Any SSH client can set it, so it's only stored
+	// as the client reported user of terminal recordings.
+	MagicSSHSessionUserIDEnvironmentVariable = "CODER_SSH_SESSION_USER_ID"
 )
 
 type Options struct {
@@ -80,6 +85,18 @@ type Options struct {
 	Logger                 slog.Logger
 	AgentPorts             map[int]string
 	SSHMaxTimeout          time.Duration
+	// ReconnectingPTYBufferSize is the size in bytes of the in-memory
+	// output buffer replayed to clients reconnecting to a PTY. The size
+	// set on the agent in the template takes precedence. Defaults to
+	// 64KiB.
+	ReconnectingPTYBufferSize int
+	// ReconnectingPTYScrollbackSize caps the size in bytes of the file
+	// that PTY output is spilled to, which clients can request instead
+	// of the in-memory buffer when reconnecting. Zero disables the file.
+	ReconnectingPTYScrollbackSize int64
+	// NetcheckInterval is how often the network of the workspace is
+	// probed and reported to coderd. Defaults to 10 minutes.
+	NetcheckInterval time.Duration
 }
 
 type Client interface {
@@ -87,18 +104,27 @@ type Client interface {
 	Listen(ctx context.Context) (net.Conn, error)
 	ReportStats(ctx context.Context, log slog.Logger, statsChan <-chan *agentsdk.Stats, setInterval func(time.Duration)) (io.Closer, error)
 	PostLifecycle(ctx context.Context, state agentsdk.PostLifecycleRequest) error
+	PostMetadata(ctx context.Context, key string, req agentsdk.PostMetadataRequest) error
 	PostAppHealth(ctx context.Context, req agentsdk.PostAppHealthsRequest) error
 	PostStartup(ctx context.Context, req agentsdk.PostStartupRequest) error
 	PatchStartupLogs(ctx context.Context, req agentsdk.PatchStartupLogs) error
+	PostTerminalRecording(ctx context.Context, recordingType codersdk.TerminalRecordingType, clientReportedUserID uuid.UUID, recording io.Reader) error
+	PostNetcheck(ctx context.Context, req codersdk.WorkspaceAgentNetcheck) error
 }
 
 func New(options Options) io.Closer {
 	if options.ReconnectingPTYTimeout == 0 {
 		options.ReconnectingPTYTimeout = 5 * time.Minute
 	}
+	if options.ReconnectingPTYBufferSize == 0 {
+		options.ReconnectingPTYBufferSize = 64 << 10
+	}
 	if options.Filesystem == nil {
 		options.Filesystem = afero.NewOsFs()
 	}
+	if options.NetcheckInterval == 0 {
+		options.NetcheckInterval = 10 * time.Minute
+	}
 	if options.TempDir == "" {
 		options.TempDir = os.TempDir()
 	}
@@ -115,21 +141,25 @@ func New(options Options) io.Closer {
 	}
 	ctx, cancelFunc := context.WithCancel(context.Background())
 	a := &agent{
-		reconnectingPTYTimeout: options.ReconnectingPTYTimeout,
-		logger:                 options.Logger,
-		closeCancel:            cancelFunc,
-		closed:                 make(chan struct{}),
-		envVars:                options.EnvironmentVariables,
-		client:                 options.Client,
-		exchangeToken:          options.ExchangeToken,
-		filesystem:             options.Filesystem,
-		logDir:                 options.LogDir,
-		tempDir:                options.TempDir,
-		lifecycleUpdate:        make(chan struct{}, 1),
-		lifecycleReported:      make(chan codersdk.WorkspaceAgentLifecycle, 1),
-		ignorePorts:            options.AgentPorts,
-		connStatsChan:          make(chan *agentsdk.Stats, 1),
-		sshMaxTimeout:          options.SSHMaxTimeout,
+		reconnectingPTYTimeout:        options.ReconnectingPTYTimeout,
+		reconnectingPTYBufferSize:     options.ReconnectingPTYBufferSize,
+		reconnectingPTYScrollbackSize: options.ReconnectingPTYScrollbackSize,
+		logger:                        options.Logger,
+		closeCancel:                   cancelFunc,
+		closed:                        make(chan struct{}),
+		envVars:                       options.EnvironmentVariables,
+		client:                        options.Client,
+		exchangeToken:                 options.ExchangeToken,
+		filesystem:                    options.Filesystem,
+		logDir:                        options.LogDir,
+		tempDir:                       options.TempDir,
+		lifecycleUpdate:               make(chan struct{}, 1),
+		lifecycleReported:             make(chan codersdk.WorkspaceAgentLifecycle, 1),
+		ignorePorts:                   options.AgentPorts,
+		connStatsChan:                 make(chan *agentsdk.Stats, 1),
+		sshMaxTimeout:                 options.SSHMaxTimeout,
+		reportMetadataInterval:        time.Second,
+		netcheckInterval:              options.NetcheckInterval,
 	}
 	a.init(ctx)
 	return a
@@ -147,14 +177,21 @@ type agent struct {
 	// are used by the agent, that the user does not care about.
 	ignorePorts map[int]string
 
-	reconnectingPTYs       sync.Map
-	reconnectingPTYTimeout time.Duration
+	reconnectingPTYs              sync.Map
+	reconnectingPTYTimeout        time.Duration
+	reconnectingPTYBufferSize     int
+	reconnectingPTYScrollbackSize int64
 
 	connCloseWait sync.WaitGroup
 	closeCancel   context.CancelFunc
 	closeMutex    sync.Mutex
 	closed        chan struct{}
 
+	// recordingUploadMutex guards recordingUploadsClosed. Uploads start when
+	// sessions end, which can happen while Close holds closeMutex.
+	recordingUploadMutex   sync.Mutex
+	recordingUploadsClosed bool
+
 	envVars map[string]string
 	// metadata is atomic because values can change after reconnection.
 	metadata      atomic.Value
@@ -167,6 +204,13 @@ type agent struct {
 	lifecycleMu       sync.RWMutex // Protects following.
 	lifecycleState    codersdk.WorkspaceAgentLifecycle
 
+	// reportMetadataInterval is the base interval at which metadata
+	// scripts are checked for staleness.
+	reportMetadataInterval time.Duration
+	// netcheckInterval is the interval at which the network of the
+	// workspace is probed and reported.
+	netcheckInterval time.Duration
+
 	network       *tailnet.Conn
 	connStatsChan chan *agentsdk.Stats
 	latestStat    atomic.Pointer[agentsdk.Stats]
@@ -183,6 +227,8 @@ type agent struct {
 // failure, you'll want the agent to reconnect.
 func (a *agent) runLoop(ctx context.Context) {
 	go a.reportLifecycleLoop(ctx)
+	go a.reportMetadataLoop(ctx)
+	go a.reportNetcheckLoop(ctx)
 
 	for retrier := retry.New(100*time.Millisecond, 10*time.Second); retrier.Wait(ctx); {
 		a.logger.Info(ctx, "connecting to coderd")
@@ -205,6 +251,243 @@ func (a *agent) runLoop(ctx context.Context) {
 	}
 }
 
+// collectMetadata runs the metadata script and returns its result. Script
+// failures are reported through the result rather than as an error.
+func (a *agent) collectMetadata(ctx context.Context, md codersdk.WorkspaceAgentMetadataDescription) *codersdk.WorkspaceAgentMetadataResult {
+	var out bytes.Buffer
+	result := &codersdk.WorkspaceAgentMetadataResult{
+		// CollectedAt is overridden by coderd with the time of receipt
+		// to avoid problems with clock skew.
+		CollectedAt: time.Now(),
+	}
+	cmd, err := a.createCommand(ctx, md.Script, nil)
+	if err != nil {
+		result.Error = fmt.Sprintf("create cmd: %+v", err)
+		return result
+	}
+
+	cmd.Stdout = &out
+	cmd.Stderr = &out
+
+	// We split up Start and Wait instead of calling Run so that we can
+	// return a more precise error.
+	err = cmd.Start()
+	if err != nil {
+		result.Error = fmt.Sprintf("start cmd: %+v", err)
+		return result
+	}
+
+	// This error isn't mutually exclusive with useful output.
+	err = cmd.Wait()
+
+	const bufLimit = 10 << 10
+	if out.Len() > bufLimit {
+		result.Error = fmt.Sprintf("output truncated from %v to %v bytes", out.Len(), bufLimit)
+		out.Truncate(bufLimit)
+	}
+	if err != nil {
+		result.Error = fmt.Sprintf("run cmd: %+v", err)
+	}
+	result.Value = out.String()
+	return result
+}
+
+type metadataResultAndKey struct {
+	result *codersdk.WorkspaceAgentMetadataResult
+	key    string
+}
+
+// trySingleflight runs a function for a key only if it isn't already
+// running for that key. Unlike singleflight.Group, callers never block
+// waiting for an in-flight call to finish.
+type trySingleflight struct {
+	m sync.Map
+}
+
+func (t *trySingleflight) Do(key string, fn func()) {
+	_, loaded := t.m.LoadOrStore(key, struct{}{})
+	if loaded {
+		return
+	}
+	defer t.m.Delete(key)
+	fn()
+}
+
+// reportMetadataLoop runs every metadata script on its own interval and
+// reports the results to coderd.
+func (a *agent) reportMetadataLoop(ctx context.Context) {
+	const metadataLimit = 128
+
+	var (
+		baseTicker       = time.NewTicker(a.reportMetadataInterval)
+		lastCollectedAts = make(map[string]time.Time)
+		metadataResults  = make(chan metadataResultAndKey, metadataLimit)
+	)
+	defer baseTicker.Stop()
+
+	// A script that takes many multiples of the base interval to run
+	// would otherwise cause a build-up of goroutines.
+	var flight trySingleflight
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case mr := <-metadataResults:
+			lastCollectedAts[mr.key] = mr.result.CollectedAt
+			err := a.client.PostMetadata(ctx, mr.key, agentsdk.PostMetadataRequest{
+				CollectedAt: mr.result.CollectedAt,
+				Age:         int64(time.Since(mr.result.CollectedAt).Seconds()),
+				Value:       mr.result.Value,
+				Error:       mr.result.Error,
+			})
+			if err != nil {
+				a.logger.Error(ctx, "report metadata", slog.F("key", mr.key), slog.Error(err))
+			}
+			continue
+		case <-baseTicker.C:
+		}
+
+		if len(metadataResults) > 0 {
+			// The collection loop below expects the channel to be empty
+			// before spinning up the collection goroutines.
+			a.logger.Debug(ctx, "metadata collection backpressured", slog.F("queue_len", len(metadataResults)))
+			continue
+		}
+
+		rawMetadata := a.metadata.Load()
+		if rawMetadata == nil {
+			continue
+		}
+		metadata, valid := rawMetadata.(agentsdk.Metadata)
+		if !valid {
+			a.logger.Error(ctx, "metadata is the wrong type", slog.F("type", fmt.Sprintf("%T", rawMetadata)))
+			continue
+		}
+
+		if len(metadata.Metadata) > metadataLimit {
+			a.logger.Error(ctx, "metadata limit exceeded", slog.F("limit", metadataLimit), slog.F("got", len(metadata.Metadata)))
+			continue
+		}
+
+		// The metadata may change on reconnect, so purge stale keys to
+		// prevent the map from growing boundlessly.
+		for key := range lastCollectedAts {
+			if slices.IndexFunc(metadata.Metadata, func(md codersdk.WorkspaceAgentMetadataDescription) bool {
+				return md.Key == key
+			}) < 0 {
+				delete(lastCollectedAts, key)
+			}
+		}
+
+		for _, md := range metadata.Metadata {
+			collectedAt, ok := lastCollectedAts[md.Key]
+			if ok {
+				// An interval of zero means the script is only run
+				// once on startup.
+				if md.Interval == 0 {
+					continue
+				}
+				if collectedAt.Add(time.Duration(md.Interval) * time.Second).After(time.Now()) {
+					continue
+				}
+			}
+
+			md := md
+			go flight.Do(md.Key, func() {
+				timeout := md.Timeout
+				if timeout <= 0 {
+					timeout = md.Interval
+				}
+				if timeout <= 0 {
+					timeout = 5
+				}
+				collectCtx, cancel := context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+				defer cancel()
+
+				// A timed out script is still reported so that the
+				// error is visible to the user.
+				result := a.collectMetadata(collectCtx, md)
+				select {
+				case <-ctx.Done():
+				case metadataResults <- metadataResultAndKey{
+					key:    md.Key,
+					result: result,
+				}:
+				}
+			})
+		}
+	}
+}
+
+// reportNetcheckLoop periodically probes UDP reachability, the NAT and the
+// latency to each DERP region from the workspace and reports the result to
+// coderd. Together with a netcheck run by the client, this shows both sides
+// of a connection that failed to go direct.
+func (a *agent) reportNetcheckLoop(ctx context.Context) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+		}
+
+		// The DERP map is received from coderd with the metadata.
+		metadata, ok := a.metadata.Load().(agentsdk.Metadata)
+		if !ok || metadata.DERPMap == nil {
+			timer.Reset(time.Second)
+			continue
+		}
+
+		netcheckCtx, cancel := context.WithTimeout(ctx, 30*time.Second)
+		report := a.netcheck(netcheckCtx, metadata.DERPMap)
+		cancel()
+		if ctx.Err() != nil {
+			return
+		}
+
+		err := a.client.PostNetcheck(ctx, report)
+		if err != nil {
+			if xerrors.Is(err, context.Canceled) {
+				return
+			}
+			a.logger.Warn(ctx, "post netcheck", slog.Error(err))
+		}
+		timer.Reset(a.netcheckInterval)
+	}
+}
+
+// netcheck runs a netcheck against the DERP map. A failed netcheck is
+// reported through the result rather than as an error.
+func (a *agent) netcheck(ctx context.Context, derpMap *tailcfg.DERPMap) codersdk.WorkspaceAgentNetcheck {
+	report, err := tailnet.Netcheck(ctx, a.logger.Named("netcheck"), derpMap)
+	if err != nil {
+		return codersdk.WorkspaceAgentNetcheck{
+			NATType: tailnet.NATType(nil),
+			Error:   err.Error(),
+		}
+	}
+	netcheck := codersdk.WorkspaceAgentNetcheck{
+		UDP:             report.UDP,
+		IPv4:            report.IPv4,
+		IPv6:            report.IPv6,
+		GlobalV4:        report.GlobalV4,
+		GlobalV6:        report.GlobalV6,
+		NATType:         tailnet.NATType(report),
+		PreferredDERP:   report.PreferredDERP,
+		RegionLatencyMS: make(map[int]float64, len(report.RegionLatency)),
+	}
+	if varies, ok := report.MappingVariesByDestIP.Get(); ok {
+		netcheck.MappingVariesByDestIP = &varies
+	}
+	for regionID, latency := range report.RegionLatency {
+		netcheck.RegionLatencyMS[regionID] = float64(latency.Microseconds()) / 1000
+	}
+	return netcheck
+}
+
 // reportLifecycleLoop reports the current lifecycle state once.
 // Only the latest state is reported, intermediate states may be
 // lost if the agent can't communicate with the API.
@@ -421,6 +704,23 @@ func (a *agent) trackConnGoroutine(fn func()) error {
 	return nil
 }
 
+// trackRecordingUpload runs a terminal recording upload in a goroutine that
+// Close waits for. Sessions that end while the agent is closing are still
+// uploaded.
+func (a *agent) trackRecordingUpload(fn func()) error {
+	a.recordingUploadMutex.Lock()
+	defer a.recordingUploadMutex.Unlock()
+	if a.recordingUploadsClosed {
+		return xerrors.New("track recording upload: agent is closed")
+	}
+	a.connCloseWait.Add(1)
+	go func() {
+		defer a.connCloseWait.Done()
+		fn()
+	}()
+	return nil
+}
+
 func (a *agent) createTailnet(ctx context.Context, derpMap *tailcfg.DERPMap) (_ *tailnet.Conn, err error) {
 	network, err := tailnet.NewConn(&tailnet.Options{
 		Addresses: []netip.Prefix{netip.PrefixFrom(codersdk.WorkspaceAgentIP, 128)},
@@ -1044,6 +1344,22 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		magicType = strings.TrimPrefix(kv, MagicSSHSessionTypeEnvironmentVariable+"=")
 		env = append(env[:index], env[index+1:]...)
 	}
+	var clientReportedUserID uuid.UUID
+	userEnv := env[:0]
+	for _, kv := range env {
+		value, ok := strings.CutPrefix(kv, MagicSSHSessionUserIDEnvironmentVariable+"=")
+		if !ok {
+			userEnv = append(userEnv, kv)
+			continue
+		}
+		parsed, err := uuid.Parse(value)
+		if err != nil {
+			a.logger.Warn(ctx, "invalid magic ssh session user id specified", slog.F("user_id", value))
+			continue
+		}
+		clientReportedUserID = parsed
+	}
+	env = userEnv
 	switch magicType {
 	case MagicSSHSessionTypeVSCode:
 		a.connCountVSCode.Add(1)
@@ -1101,6 +1417,9 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 		if err != nil {
 			return xerrors.Errorf("start command: %w", err)
 		}
+		recorder := a.startTerminalRecording(ctx, a.logger, codersdk.TerminalRecordingTypeSSH, clientReportedUserID, session.RawCommand(), sshPty.Window.Width, sshPty.Window.Height)
+		// Deferred first so the recording ends after all output is copied.
+		defer recorder.Close()
 		var wg sync.WaitGroup
 		defer func() {
 			defer wg.Wait()
@@ -1118,6 +1437,7 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 				if resizeErr != nil {
 					a.logger.Warn(ctx, "failed to resize tty", slog.Error(resizeErr))
 				}
+				recorder.Resize(win.Width, win.Height)
 			}
 		}()
 		// We don't add input copy to wait group because
@@ -1130,7 +1450,7 @@ func (a *agent) handleSSHSession(session ssh.Session) (retErr error) {
 			// Ensure data is flushed to session on command exit, if we
 			// close the session too soon, we might lose data.
 			defer wg.Done()
-			_, _ = io.Copy(session, ptty.Output())
+			_, _ = io.Copy(io.MultiWriter(session, recorder), ptty.Output())
 		}()
 		err = process.Wait()
 		var exitErr *exec.ExitError
@@ -1205,11 +1525,18 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 		}
 		cmd.Env = append(cmd.Env, "TERM=xterm-256color")
 
-		// Default to buffer 64KiB.
-		circularBuffer, err := circbuf.NewBuffer(64 << 10)
+		circularBuffer, err := circbuf.NewBuffer(int64(a.reconnectingPTYBufferSize))
 		if err != nil {
 			return xerrors.Errorf("create circular buffer: %w", err)
 		}
+		var scrollback *scrollbackFile
+		if a.reconnectingPTYScrollbackSize > 0 {
+			scrollback, err = newScrollbackFile(a.filesystem, a.tempDir, a.reconnectingPTYScrollbackSize)
+			if err != nil {
+				// Reconnecting clients get the in-memory buffer instead.
+				logger.Warn(ctx, "create scrollback file", slog.Error(err))
+			}
+		}
 
 		ptty, process, err := pty.Start(cmd)
 		if err != nil {
@@ -1227,7 +1554,13 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			// Timeouts created with an after func can be reset!
 			timeout:        time.AfterFunc(a.reconnectingPTYTimeout, cancelFunc),
 			circularBuffer: circularBuffer,
+			scrollback:     scrollback,
+			recorder:       a.startTerminalRecording(ctx, logger, codersdk.TerminalRecordingTypeReconnectingPTY, msg.UserID, msg.Command, int(msg.Width), int(msg.Height)),
+			command:        msg.Command,
+			startedAt:      time.Now(),
+			kill:           cancelFunc,
 		}
+		rpty.lastActivity.Store(rpty.startedAt)
 		a.reconnectingPTYs.Store(msg.ID, rpty)
 		go func() {
 			// CommandContext isn't respected for Windows PTYs right now,
@@ -1260,6 +1593,12 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 					logger.Error(ctx, "write to circular buffer", slog.Error(err))
 					break
 				}
+				_, err = rpty.scrollback.Write(part)
+				if err != nil {
+					logger.Warn(ctx, "write to scrollback file", slog.Error(err))
+				}
+				_, _ = rpty.recorder.Write(part)
+				rpty.lastActivity.Store(time.Now())
 				rpty.activeConnsMutex.Lock()
 				for _, conn := range rpty.activeConns {
 					_, _ = conn.Write(part)
@@ -1271,6 +1610,11 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			// ID from memory.
 			_ = process.Kill()
 			rpty.Close()
+			rpty.recorder.Close()
+			err := rpty.scrollback.Close()
+			if err != nil {
+				logger.Warn(ctx, "close scrollback file", slog.Error(err))
+			}
 			a.reconnectingPTYs.Delete(msg.ID)
 		}); err != nil {
 			return xerrors.Errorf("start routine: %w", err)
@@ -1282,10 +1626,22 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 		// We can continue after this, it's not fatal!
 		logger.Error(ctx, "resize", slog.Error(err))
 	}
-	// Write any previously stored data for the TTY.
-	rpty.circularBufferMutex.RLock()
-	prevBuf := slices.Clone(rpty.circularBuffer.Bytes())
-	rpty.circularBufferMutex.RUnlock()
+	rpty.recorder.Resize(int(msg.Width), int(msg.Height))
+	// Write any previously stored data for the TTY. The scrollback file
+	// holds everything in the circular buffer and more, so it's used
+	// instead when the client asks for it.
+	var prevBuf []byte
+	if msg.Scrollback {
+		prevBuf, ok, err = rpty.scrollback.Bytes()
+		if err != nil {
+			logger.Warn(ctx, "read scrollback file", slog.Error(err))
+		}
+	}
+	if !msg.Scrollback || !ok {
+		rpty.circularBufferMutex.RLock()
+		prevBuf = slices.Clone(rpty.circularBuffer.Bytes())
+		rpty.circularBufferMutex.RUnlock()
+	}
 	// Note that there is a small race here between writing buffered
 	// data and storing conn in activeConns. This is likely a very minor
 	// edge case, but we should look into ways to avoid it. Holding
@@ -1345,6 +1701,9 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			logger.Warn(ctx, "write to pty", slog.Error(err))
 			return nil
 		}
+		if req.Data != "" {
+			rpty.lastActivity.Store(time.Now())
+		}
 		// Check if a resize needs to happen!
 		if req.Height == 0 || req.Width == 0 {
 			continue
@@ -1354,6 +1713,7 @@ func (a *agent) handleReconnectingPTY(ctx context.Context, logger slog.Logger, m
 			// We can continue after this, it's not fatal!
 			logger.Error(ctx, "resize", slog.Error(err))
 		}
+		rpty.recorder.Resize(int(req.Width), int(req.Height))
 	}
 }
 
@@ -1538,6 +1898,9 @@ lifecycleWaitLoop:
 	if a.network != nil {
 		_ = a.network.Close()
 	}
+	a.recordingUploadMutex.Lock()
+	a.recordingUploadsClosed = true
+	a.recordingUploadMutex.Unlock()
 	a.connCloseWait.Wait()
 
 	return nil
@@ -1551,6 +1914,31 @@ type reconnectingPTY struct {
 	circularBufferMutex sync.RWMutex
 	timeout             *time.Timer
 	ptty                pty.PTY
+	// scrollback is nil unless output is spilled to disk.
+	scrollback *scrollbackFile
+	// recorder is nil unless terminal sessions are recorded.
+	recorder *terminalRecorder
+
+	command   string
+	startedAt time.Time
+	// lastActivity is the time of the last input or output.
+	lastActivity atomic.Time
+	// kill terminates the process, which closes the PTY.
+	kill context.CancelFunc
+}
+
+// session describes the reconnecting PTY for the agent API.
+func (r *reconnectingPTY) session(id uuid.UUID) codersdk.WorkspaceAgentReconnectingPTYSession {
+	r.activeConnsMutex.Lock()
+	connections := len(r.activeConns)
+	r.activeConnsMutex.Unlock()
+	return codersdk.WorkspaceAgentReconnectingPTYSession{
+		ID:             id,
+		Command:        r.command,
+		StartedAt:      r.startedAt,
+		LastActivityAt: r.lastActivity.Load(),
+		Connections:    connections,
+	}
 }
 
 // Close ends all connections to the reconnecting
@@ -1,6 +1,7 @@
 package agent_test
 
 import (
+	"archive/tar"
 	"bufio"
 	"bytes"
 	"context"
@@ -33,6 +34,7 @@ import (
 	"github.com/stretchr/testify/require"
 	"go.uber.org/goleak"
 	"golang.org/x/crypto/ssh"
+	"golang.org/x/exp/maps"
 	"golang.org/x/xerrors"
 	"tailscale.com/net/speedtest"
 	"tailscale.com/tailcfg"
@@ -40,6 +42,7 @@ import (
 	"cdr.dev/slog"
 	"cdr.dev/slog/sloggers/slogtest"
 	"github.com/coder/coder/agent"
+	"github.com/coder/coder/agent/asciicast"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/codersdk/agentsdk"
@@ -706,6 +709,168 @@ func TestAgent_SCP(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestAgent_Files(t *testing.T) {
+	t.Parallel()
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, fs, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+	// The agent uses the in-memory filesystem, so this directory only
+	// provides an absolute path.
+	root := t.TempDir()
+	filePath := filepath.Join(root, "dir", "file.txt")
+
+	_, err := conn.StatFile(ctx, filePath)
+	var sdkErr *codersdk.Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+
+	// Parent directories are created.
+	info, err := conn.WriteFile(ctx, filePath, 0, 0o600, strings.NewReader("hello"))
+	require.NoError(t, err)
+	require.Equal(t, "file.txt", info.Name)
+	require.Equal(t, filePath, info.Path)
+	require.EqualValues(t, 5, info.Size)
+	require.False(t, info.IsDir)
+	require.Equal(t, os.FileMode(0o600), os.FileMode(info.Mode).Perm())
+
+	// Resuming an upload discards anything after the offset.
+	_, err = conn.WriteFile(ctx, filePath, 4, 0o600, strings.NewReader("p world"))
+	require.NoError(t, err)
+	data, err := afero.ReadFile(fs, filePath)
+	require.NoError(t, err)
+	require.Equal(t, "hellp world", string(data))
+	_, err = conn.WriteFile(ctx, filePath, 100, 0o600, strings.NewReader("!"))
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	read := func(offset int64) string {
+		rc, err := conn.ReadFile(ctx, filePath, offset)
+		require.NoError(t, err)
+		defer rc.Close()
+		data, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		return string(data)
+	}
+	require.Equal(t, "hellp world", read(0))
+	require.Equal(t, "world", read(6))
+
+	list, err := conn.ListFiles(ctx, root)
+	require.NoError(t, err)
+	require.Equal(t, root, list.Path)
+	require.Len(t, list.Files, 1)
+	require.Equal(t, "dir", list.Files[0].Name)
+	require.True(t, list.Files[0].IsDir)
+
+	t.Run("Directory", func(t *testing.T) {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0o755}))
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}))
+		_, err := tw.Write([]byte("a"))
+		require.NoError(t, err)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "b.txt", Typeflag: tar.TypeReg, Mode: 0o755, Size: 2}))
+		_, err = tw.Write([]byte("bb"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		dirPath := filepath.Join(root, "upload")
+		err = conn.UploadDirectory(ctx, dirPath, &archive)
+		require.NoError(t, err)
+		data, err := afero.ReadFile(fs, filepath.Join(dirPath, "sub", "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(data))
+
+		rc, err := conn.DownloadDirectory(ctx, dirPath)
+		require.NoError(t, err)
+		defer rc.Close()
+		files := map[string]string{}
+		tr := tar.NewReader(rc)
+		for {
+			header, err := tr.Next()
+			if xerrors.Is(err, io.EOF) {
+				break
+			}
+			require.NoError(t, err)
+			data, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			files[header.Name] = string(data)
+		}
+		require.Equal(t, map[string]string{
+			"b.txt":     "bb",
+			"sub/":      "",
+			"sub/a.txt": "a",
+		}, files)
+
+		// Files can't be downloaded as archives.
+		_, err = conn.DownloadDirectory(ctx, filePath)
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+	})
+
+	t.Run("PathTraversal", func(t *testing.T) {
+		var archive bytes.Buffer
+		tw := tar.NewWriter(&archive)
+		require.NoError(t, tw.WriteHeader(&tar.Header{Name: "../escape.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: 1}))
+		_, err := tw.Write([]byte("x"))
+		require.NoError(t, err)
+		require.NoError(t, tw.Close())
+
+		err = conn.UploadDirectory(ctx, filepath.Join(root, "traversal"), &archive)
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+		_, err = fs.Stat(filepath.Join(root, "escape.txt"))
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
+
+func TestAgent_Exec(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands below use a POSIX shell")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, _, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+
+	var stdout, stderr bytes.Buffer
+	res, err := conn.Exec(ctx, codersdk.WorkspaceAgentExecRequest{
+		Command: "cat; echo \"$GREETING\" >&2; exit 3",
+		Env:     map[string]string{"GREETING": "hi"},
+	}, strings.NewReader("from stdin"), &stdout, &stderr)
+	require.NoError(t, err)
+	require.Equal(t, 3, res.ExitCode)
+	require.False(t, res.TimedOut)
+	require.Equal(t, "from stdin", stdout.String())
+	require.Equal(t, "hi\n", stderr.String())
+
+	// Output larger than a single frame arrives intact.
+	stdout.Reset()
+	res, err = conn.Exec(ctx, codersdk.WorkspaceAgentExecRequest{
+		Command: "head -c 100000 /dev/zero",
+	}, nil, &stdout, nil)
+	require.NoError(t, err)
+	require.Equal(t, 0, res.ExitCode)
+	require.Equal(t, 100000, stdout.Len())
+
+	res, err = conn.Exec(ctx, codersdk.WorkspaceAgentExecRequest{
+		Command:       "sleep 30",
+		TimeoutMillis: 100,
+	}, nil, nil, nil)
+	require.NoError(t, err)
+	require.True(t, res.TimedOut)
+	require.NotEqual(t, 0, res.ExitCode)
+	require.Less(t, res.Duration, 10*time.Second)
+
+	_, err = conn.Exec(ctx, codersdk.WorkspaceAgentExecRequest{}, nil, nil, nil)
+	require.ErrorContains(t, err, "command is required")
+}
+
 func TestAgent_EnvironmentVariables(t *testing.T) {
 	t.Parallel()
 	key := "EXAMPLE"
@@ -865,6 +1030,111 @@ func TestAgent_StartupScript(t *testing.T) {
 	})
 }
 
+func TestAgent_Metadata(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Once", func(t *testing.T) {
+		t.Parallel()
+		script := "echo -n hello"
+		if runtime.GOOS == "windows" {
+			script = "powershell " + script
+		}
+		//nolint:dogsled
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{
+				{
+					Key:      "greeting",
+					Interval: 0,
+					Script:   script,
+				},
+			},
+		}, 0)
+
+		var gotMd map[string]agentsdk.PostMetadataRequest
+		require.Eventually(t, func() bool {
+			gotMd = client.getMetadata()
+			return len(gotMd) == 1
+		}, testutil.WaitShort, testutil.IntervalMedium)
+
+		collectedAt := gotMd["greeting"].CollectedAt
+
+		require.Never(t, func() bool {
+			gotMd = client.getMetadata()
+			if len(gotMd) != 1 {
+				panic("unexpected number of metadata")
+			}
+			return !gotMd["greeting"].CollectedAt.Equal(collectedAt)
+		}, testutil.WaitShort, testutil.IntervalMedium)
+	})
+
+	t.Run("Many", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			// Shell scripting in Windows is a pain, and we have already tested
+			// that the OS logic works in the simpler "Once" test above.
+			t.Skip()
+		}
+
+		dir := t.TempDir()
+
+		const reportInterval = 1
+		var (
+			greetingPath = filepath.Join(dir, "greeting")
+			script       = "echo hello | tee -a " + greetingPath
+		)
+		//nolint:dogsled
+		_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+			Metadata: []codersdk.WorkspaceAgentMetadataDescription{
+				{
+					Key:      "greeting",
+					Interval: reportInterval,
+					Script:   script,
+				},
+				{
+					Key:      "bad",
+					Interval: reportInterval,
+					Script:   "exit 1",
+				},
+			},
+		}, 0)
+
+		require.Eventually(t, func() bool {
+			return len(client.getMetadata()) == 2
+		}, testutil.WaitShort, testutil.IntervalMedium)
+
+		md := client.getMetadata()
+		require.Equal(t, "hello\n", md["greeting"].Value)
+		require.Equal(t, "run cmd: exit status 1", md["bad"].Error)
+
+		// The script runs again once its interval has elapsed.
+		require.Eventually(t, func() bool {
+			greetingByt, err := os.ReadFile(greetingPath)
+			require.NoError(t, err)
+			return strings.Count(string(greetingByt), "hello") > 1
+		}, testutil.WaitLong, testutil.IntervalMedium)
+	})
+}
+
+func TestAgent_Netcheck(t *testing.T) {
+	t.Parallel()
+
+	//nolint:dogsled
+	_, client, _, _, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+
+	var netchecks []codersdk.WorkspaceAgentNetcheck
+	require.Eventually(t, func() bool {
+		netchecks = client.getNetchecks()
+		return len(netchecks) > 0
+	}, testutil.WaitLong, testutil.IntervalMedium)
+
+	netcheck := netchecks[0]
+	require.Empty(t, netcheck.Error)
+	require.True(t, netcheck.UDP)
+	require.Equal(t, 1, netcheck.PreferredDERP)
+	require.Contains(t, netcheck.RegionLatencyMS, 1)
+	require.NotEmpty(t, netcheck.NATType)
+}
+
 func TestAgent_Lifecycle(t *testing.T) {
 	t.Parallel()
 
@@ -1257,6 +1527,217 @@ func TestAgent_ReconnectingPTY(t *testing.T) {
 	expectLine(matchEchoOutput)
 }
 
+func TestAgent_ReconnectingPTYScrollback(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, fs, _ := setupAgent(t, agentsdk.Metadata{}, 0, func(o *agent.Options) {
+		o.ReconnectingPTYBufferSize = 256
+		o.ReconnectingPTYScrollbackSize = 4096
+	})
+	scrollbackFiles := func() []string {
+		files, err := afero.Glob(fs, filepath.Join(os.TempDir(), "coder-reconnecting-pty-*"))
+		require.NoError(t, err)
+		return files
+	}
+
+	// readUntil reads until the output contains the last line and returns
+	// everything read.
+	readUntil := func(r io.Reader) string {
+		var output strings.Builder
+		buf := make([]byte, 1024)
+		for !strings.Contains(output.String(), "line-1000\r") {
+			n, err := r.Read(buf)
+			require.NoError(t, err)
+			output.Write(buf[:n])
+		}
+		return output.String()
+	}
+
+	id := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	// More output than fits in the buffer and the scrollback file, so the
+	// file is compacted.
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data: "for i in $(seq 1 1000); do echo line-$i; done\r\n",
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+	readUntil(netConn)
+	_ = netConn.Close()
+	require.Len(t, scrollbackFiles(), 1)
+
+	// Without scrollback, only the buffer is replayed.
+	netConn, err = conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+	output := readUntil(netConn)
+	require.NotContains(t, output, "line-900\r")
+	_ = netConn.Close()
+
+	netConn, err = conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash", codersdk.ReconnectingPTYWithScrollback())
+	require.NoError(t, err)
+	defer netConn.Close()
+	output = readUntil(netConn)
+	require.Contains(t, output, "line-900\r")
+	require.NotContains(t, output, "line-1\r")
+
+	// The file is removed when the session ends.
+	err = conn.KillReconnectingPTYSession(ctx, id)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		return len(scrollbackFiles()) == 0
+	}, testutil.WaitLong, testutil.IntervalFast)
+}
+
+func TestAgent_ReconnectingPTYSessions(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, _, _, _, _ := setupAgent(t, agentsdk.Metadata{}, 0)
+	id := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, id, 100, 100, "/bin/bash")
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	var sessions []codersdk.WorkspaceAgentReconnectingPTYSession
+	require.Eventually(t, func() bool {
+		res, err := conn.ReconnectingPTYSessions(ctx)
+		if !assert.NoError(t, err) {
+			return false
+		}
+		sessions = res.Sessions
+		return len(sessions) == 1 && sessions[0].Connections == 1
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.Equal(t, id, sessions[0].ID)
+	require.Equal(t, "/bin/bash", sessions[0].Command)
+	require.False(t, sessions[0].StartedAt.IsZero())
+	require.False(t, sessions[0].LastActivityAt.Before(sessions[0].StartedAt))
+
+	err = conn.KillReconnectingPTYSession(ctx, uuid.New())
+	var sdkErr *codersdk.Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+
+	err = conn.KillReconnectingPTYSession(ctx, id)
+	require.NoError(t, err)
+	// Killing the session disconnects its clients.
+	_, err = io.Copy(io.Discard, netConn)
+	require.NoError(t, err)
+	require.Eventually(t, func() bool {
+		res, err := conn.ReconnectingPTYSessions(ctx)
+		return assert.NoError(t, err) && len(res.Sessions) == 0
+	}, testutil.WaitLong, testutil.IntervalFast)
+}
+
+func TestAgent_TerminalRecording(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+		RecordTerminalSessions: true,
+	}, 0)
+	userID := uuid.New()
+	netConn, err := conn.ReconnectingPTY(ctx, uuid.New(), 100, 80, "/bin/bash", codersdk.ReconnectingPTYWithUserID(userID))
+	require.NoError(t, err)
+	defer netConn.Close()
+
+	data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+		Data:   "echo recorded\r\nexit\r\n",
+		Height: 24,
+		Width:  120,
+	})
+	require.NoError(t, err)
+	_, err = netConn.Write(data)
+	require.NoError(t, err)
+
+	var recordings []terminalRecording
+	require.Eventually(t, func() bool {
+		recordings = client.getRecordings()
+		return len(recordings) > 0
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.Len(t, recordings, 1)
+	require.Equal(t, codersdk.TerminalRecordingTypeReconnectingPTY, recordings[0].recordingType)
+	require.Equal(t, userID, recordings[0].clientReportedUserID)
+	info, err := asciicast.Read(bytes.NewReader(recordings[0].data))
+	require.NoError(t, err)
+	require.Equal(t, 80, info.Header.Width)
+	require.Equal(t, 100, info.Header.Height)
+	require.Equal(t, "/bin/bash", info.Header.Title)
+	require.Contains(t, string(recordings[0].data), `"r","120x24"`)
+	require.Contains(t, string(recordings[0].data), "recorded")
+}
+
+func TestAgent_TerminalRecordingSSH(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("ConPTY appears to be inconsistent on Windows.")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	//nolint:dogsled
+	conn, client, _, _, _ := setupAgent(t, agentsdk.Metadata{
+		RecordTerminalSessions: true,
+	}, 0)
+	sshClient, err := conn.SSHClient(ctx)
+	require.NoError(t, err)
+	defer sshClient.Close()
+	userID := uuid.New()
+
+	// The user ID is stripped from the environment of the command.
+	session, err := sshClient.NewSession()
+	require.NoError(t, err)
+	defer session.Close()
+	err = session.Setenv(agent.MagicSSHSessionUserIDEnvironmentVariable, userID.String())
+	require.NoError(t, err)
+	output, err := session.Output("echo user-${" + agent.MagicSSHSessionUserIDEnvironmentVariable + "}-id")
+	require.NoError(t, err)
+	require.Equal(t, "user--id", strings.TrimSpace(string(output)))
+
+	session, err = sshClient.NewSession()
+	require.NoError(t, err)
+	defer session.Close()
+	err = session.Setenv(agent.MagicSSHSessionUserIDEnvironmentVariable, userID.String())
+	require.NoError(t, err)
+	err = session.RequestPty("xterm", 24, 80, ssh.TerminalModes{})
+	require.NoError(t, err)
+	err = session.Run("true")
+	require.NoError(t, err)
+
+	var recordings []terminalRecording
+	require.Eventually(t, func() bool {
+		recordings = client.getRecordings()
+		return len(recordings) > 0
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.Len(t, recordings, 1)
+	require.Equal(t, codersdk.TerminalRecordingTypeSSH, recordings[0].recordingType)
+	require.Equal(t, userID, recordings[0].clientReportedUserID)
+}
+
 func TestAgent_Dial(t *testing.T) {
 	t.Parallel()
 
@@ -1481,7 +1962,7 @@ func (c closeFunc) Close() error {
 	return c()
 }
 
-func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Duration) (
+func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Duration, opts ...func(*agent.Options)) (
 	*codersdk.WorkspaceAgentConn,
 	*client,
 	<-chan *agentsdk.Stats,
@@ -1505,12 +1986,16 @@ func setupAgent(t *testing.T, metadata agentsdk.Metadata, ptyTimeout time.Durati
 		statsChan:   statsCh,
 		coordinator: coordinator,
 	}
-	closer := agent.New(agent.Options{
+	options := agent.Options{
 		Client:                 c,
 		Filesystem:             fs,
 		Logger:                 slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
 		ReconnectingPTYTimeout: ptyTimeout,
-	})
+	}
+	for _, opt := range opts {
+		opt(&options)
+	}
+	closer := agent.New(options)
 	t.Cleanup(func() {
 		_ = closer.Close()
 	})
@@ -1594,6 +2079,16 @@ type client struct {
 	lifecycleStates []codersdk.WorkspaceAgentLifecycle
 	startup         agentsdk.PostStartupRequest
 	logs            []agentsdk.StartupLog
+	// reportedMetadata is the latest metadata result per key.
+	reportedMetadata map[string]agentsdk.PostMetadataRequest
+	recordings       []terminalRecording
+	netchecks        []codersdk.WorkspaceAgentNetcheck
+}
+
+type terminalRecording struct {
+	recordingType        codersdk.TerminalRecordingType
+	clientReportedUserID uuid.UUID
+	data                 []byte
 }
 
 func (c *client) Metadata(_ context.Context) (agentsdk.Metadata, error) {
@@ -1661,6 +2156,22 @@ func (c *client) PostLifecycle(_ context.Context, req agentsdk.PostLifecycleRequ
 	return nil
 }
 
+func (c *client) getMetadata() map[string]agentsdk.PostMetadataRequest {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.reportedMetadata)
+}
+
+func (c *client) PostMetadata(_ context.Context, key string, req agentsdk.PostMetadataRequest) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.reportedMetadata == nil {
+		c.reportedMetadata = make(map[string]agentsdk.PostMetadataRequest)
+	}
+	c.reportedMetadata[key] = req
+	return nil
+}
+
 func (*client) PostAppHealth(_ context.Context, _ agentsdk.PostAppHealthsRequest) error {
 	return nil
 }
@@ -1694,6 +2205,40 @@ func (c *client) PatchStartupLogs(_ context.Context, logs agentsdk.PatchStartupL
 	return nil
 }
 
+func (c *client) getRecordings() []terminalRecording {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.recordings
+}
+
+func (c *client) PostTerminalRecording(_ context.Context, recordingType codersdk.TerminalRecordingType, clientReportedUserID uuid.UUID, recording io.Reader) error {
+	data, err := io.ReadAll(recording)
+	if err != nil {
+		return err
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.recordings = append(c.recordings, terminalRecording{
+		recordingType:        recordingType,
+		clientReportedUserID: clientReportedUserID,
+		data:                 data,
+	})
+	return nil
+}
+
+func (c *client) getNetchecks() []codersdk.WorkspaceAgentNetcheck {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.netchecks
+}
+
+func (c *client) PostNetcheck(_ context.Context, req codersdk.WorkspaceAgentNetcheck) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.netchecks = append(c.netchecks, req)
+	return nil
+}
+
 // tempDirUnixSocket returns a temporary directory that can safely hold unix
 // sockets (probably).
 //
@@ -2,13 +2,18 @@ package agent
 
 import (
 	"net/http"
+	"os"
+	"sort"
 	"sync"
 	"time"
 
 	"github.com/go-chi/chi"
+	"github.com/google/uuid"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
 )
 
 func (a *agent) apiHandler() http.Handler {
@@ -26,17 +31,55 @@ func (a *agent) apiHandler() http.Handler {
 		cpy[k] = b
 	}
 
-	lp := &listeningPortsHandler{ignorePorts: cpy}
+	lp := &listeningPortsHandler{
+		logger:      a.logger.Named("listening_ports"),
+		ignorePorts: cpy,
+		socketDirs:  a.listeningSocketDirs,
+		agentPID:    os.Getpid(),
+	}
 	r.Get("/api/v0/listening-ports", lp.handler)
+	r.Get("/api/v0/reconnecting-pty-sessions", a.reconnectingPTYSessionsHandler)
+	r.Delete("/api/v0/reconnecting-pty-sessions/{id}", a.killReconnectingPTYSessionHandler)
+	r.Get("/api/v0/files/stat", a.statFileHandler)
+	r.Get("/api/v0/files/list", a.listFilesHandler)
+	r.Get("/api/v0/files/read", a.readFileHandler)
+	r.Post("/api/v0/files/write", a.writeFileHandler)
+	r.Get("/api/v0/files/download", a.downloadDirectoryHandler)
+	r.Post("/api/v0/files/upload", a.uploadDirectoryHandler)
+	r.Get("/api/v0/exec", a.execHandler)
 
 	return r
 }
 
 type listeningPortsHandler struct {
+	logger      slog.Logger
 	mut         sync.Mutex
 	ports       []codersdk.WorkspaceAgentListeningPort
 	mtime       time.Time
 	ignorePorts map[int]string
+	// socketDirs returns the directories Unix sockets are reported from.
+	// Sockets elsewhere usually belong to the system, e.g. in /run.
+	socketDirs func() []string
+	// agentPID is used to hide the UDP sockets of the agent itself, which
+	// are used by tailnet.
+	agentPID int
+	// scanUDP and scanUnixSockets override how UDP ports and Unix sockets
+	// are listed, they're only set in tests.
+	scanUDP         func() ([]codersdk.WorkspaceAgentListeningPort, error)
+	scanUnixSockets func() ([]codersdk.WorkspaceAgentListeningPort, error)
+}
+
+// listeningSocketDirs returns the home directory of the user and the
+// workspace directory.
+func (a *agent) listeningSocketDirs() []string {
+	dirs := []string{}
+	if homedir, err := userHomeDir(); err == nil {
+		dirs = append(dirs, homedir)
+	}
+	if metadata, ok := a.metadata.Load().(agentsdk.Metadata); ok && metadata.Directory != "" {
+		dirs = append(dirs, metadata.Directory)
+	}
+	return dirs
 }
 
 // handler returns a list of listening ports. This is tested by coderd's
@@ -55,3 +98,58 @@ func (lp *listeningPortsHandler) handler(rw http.ResponseWriter, r *http.Request
 		Ports: ports,
 	})
 }
+
+// reconnectingPTYSessionsHandler lists the active reconnecting PTY sessions,
+// most recently active first.
+func (a *agent) reconnectingPTYSessionsHandler(rw http.ResponseWriter, r *http.Request) {
+	sessions := []codersdk.WorkspaceAgentReconnectingPTYSession{}
+	a.reconnectingPTYs.Range(func(key, value any) bool {
+		id, ok := key.(uuid.UUID)
+		if !ok {
+			return true
+		}
+		rpty, ok := value.(*reconnectingPTY)
+		if !ok {
+			return true
+		}
+		sessions = append(sessions, rpty.session(id))
+		return true
+	})
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].LastActivityAt.After(sessions[j].LastActivityAt)
+	})
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.WorkspaceAgentReconnectingPTYSessionsResponse{
+		Sessions: sessions,
+	})
+}
+
+// killReconnectingPTYSessionHandler terminates the process of a reconnecting
+// PTY session, which disconnects all of its clients.
+func (a *agent) killReconnectingPTYSessionHandler(rw http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(chi.URLParam(r, "id"))
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Session id must be a valid UUID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	value, ok := a.reconnectingPTYs.Load(id)
+	if !ok {
+		httpapi.Write(r.Context(), rw, http.StatusNotFound, codersdk.Response{
+			Message: "Session not found.",
+		})
+		return
+	}
+	rpty, ok := value.(*reconnectingPTY)
+	if !ok {
+		httpapi.Write(r.Context(), rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Found invalid type in reconnecting pty map.",
+		})
+		return
+	}
+	rpty.kill()
+
+	rw.WriteHeader(http.StatusNoContent)
+}
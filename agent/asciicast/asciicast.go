@@ -0,0 +1,175 @@
+// Package asciicast records terminal sessions in the asciicast v2 format.
+// See https://docs.asciinema.org/manual/asciicast/v2/.
+package asciicast
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/xerrors"
+)
+
+// ContentType is the media type of asciicast recordings.
+const ContentType = "application/x-asciicast"
+
+const (
+	eventOutput = "o"
+	eventResize = "r"
+)
+
+// Header is the first line of a recording.
+type Header struct {
+	Version   int               `json:"version"`
+	Width     int               `json:"width"`
+	Height    int               `json:"height"`
+	Timestamp int64             `json:"timestamp,omitempty"`
+	Title     string            `json:"title,omitempty"`
+	Env       map[string]string `json:"env,omitempty"`
+}
+
+// Writer writes output and resize events of a terminal session. It is safe
+// for concurrent use.
+type Writer struct {
+	mu    sync.Mutex
+	w     io.Writer
+	start time.Time
+	// partial holds the start of a UTF-8 character that was split across
+	// writes. Events must be valid UTF-8, so it's prepended to the next
+	// write instead of being written on its own.
+	partial []byte
+	err     error
+}
+
+// NewWriter writes the header of a recording to w and returns a Writer for
+// its events. Event times are relative to the header timestamp.
+func NewWriter(w io.Writer, header Header) (*Writer, error) {
+	start := time.Now()
+	header.Version = 2
+	if header.Timestamp == 0 {
+		header.Timestamp = start.Unix()
+	}
+	data, err := json.Marshal(header)
+	if err != nil {
+		return nil, xerrors.Errorf("marshal header: %w", err)
+	}
+	_, err = w.Write(append(data, '\n'))
+	if err != nil {
+		return nil, xerrors.Errorf("write header: %w", err)
+	}
+	return &Writer{
+		w:     w,
+		start: start,
+	}, nil
+}
+
+// Write records p as terminal output.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	data := append(w.partial, p...)
+	// Hold back an incomplete trailing character until the rest of it is
+	// written. Characters are at most utf8.UTFMax bytes long.
+	end := len(data)
+	for i := 1; i < utf8.UTFMax && i <= len(data); i++ {
+		if !utf8.RuneStart(data[len(data)-i]) {
+			continue
+		}
+		if !utf8.FullRune(data[len(data)-i:]) {
+			end = len(data) - i
+		}
+		break
+	}
+	w.partial = append([]byte(nil), data[end:]...)
+	if end == 0 {
+		return len(p), w.err
+	}
+	err := w.event(eventOutput, string(data[:end]))
+	if err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size.
+func (w *Writer) Resize(width, height int) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.event(eventResize, fmt.Sprintf("%dx%d", width, height))
+}
+
+func (w *Writer) event(kind, data string) error {
+	if w.err != nil {
+		return w.err
+	}
+	event, err := json.Marshal([]interface{}{
+		time.Since(w.start).Seconds(),
+		kind,
+		data,
+	})
+	if err != nil {
+		return xerrors.Errorf("marshal event: %w", err)
+	}
+	_, err = w.w.Write(append(event, '\n'))
+	if err != nil {
+		w.err = xerrors.Errorf("write event: %w", err)
+	}
+	return w.err
+}
+
+// Info summarizes a recording.
+type Info struct {
+	Header Header
+	// Duration is the time of the last event.
+	Duration time.Duration
+}
+
+// Read validates the recording in r and returns its header and duration.
+func Read(r io.Reader) (Info, error) {
+	scanner := bufio.NewScanner(r)
+	// Events may contain a lot of output.
+	scanner.Buffer(nil, 1<<20)
+	if !scanner.Scan() {
+		if err := scanner.Err(); err != nil {
+			return Info{}, xerrors.Errorf("read header: %w", err)
+		}
+		return Info{}, xerrors.New("recording is empty")
+	}
+	var info Info
+	err := json.Unmarshal(scanner.Bytes(), &info.Header)
+	if err != nil {
+		return Info{}, xerrors.Errorf("decode header: %w", err)
+	}
+	if info.Header.Version != 2 {
+		return Info{}, xerrors.Errorf("unsupported version %d", info.Header.Version)
+	}
+	if info.Header.Timestamp == 0 {
+		return Info{}, xerrors.New("header has no timestamp")
+	}
+	for line := 2; scanner.Scan(); line++ {
+		var (
+			event   []json.RawMessage
+			seconds float64
+		)
+		err = json.Unmarshal(scanner.Bytes(), &event)
+		if err == nil && len(event) != 3 {
+			err = xerrors.Errorf("expected 3 fields, got %d", len(event))
+		}
+		if err == nil {
+			err = json.Unmarshal(event[0], &seconds)
+		}
+		if err != nil {
+			return Info{}, xerrors.Errorf("decode event on line %d: %w", line, err)
+		}
+		info.Duration = time.Duration(seconds * float64(time.Second))
+	}
+	if err := scanner.Err(); err != nil {
+		return Info{}, xerrors.Errorf("read events: %w", err)
+	}
+	return info, nil
+}
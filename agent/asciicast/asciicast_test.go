@@ -0,0 +1,67 @@
+package asciicast_test
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+)
+
+func TestWriter(t *testing.T) {
+	t.Parallel()
+
+	var buf bytes.Buffer
+	w, err := asciicast.NewWriter(&buf, asciicast.Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello\r\n"))
+	require.NoError(t, err)
+	require.NoError(t, w.Resize(120, 40))
+	// A character split across writes is recorded once it's complete.
+	smile := []byte("🙂")
+	_, err = w.Write(smile[:2])
+	require.NoError(t, err)
+	_, err = w.Write(smile[2:])
+	require.NoError(t, err)
+
+	info, err := asciicast.Read(bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	require.Equal(t, 2, info.Header.Version)
+	require.Equal(t, 80, info.Header.Width)
+	require.NotZero(t, info.Header.Timestamp)
+
+	var events [][]interface{}
+	scanner := bufio.NewScanner(&buf)
+	scanner.Scan()
+	for scanner.Scan() {
+		var event []interface{}
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.Len(t, events, 3)
+	require.Equal(t, []interface{}{"o", "hello\r\n"}, events[0][1:])
+	require.Equal(t, []interface{}{"r", "120x40"}, events[1][1:])
+	require.Equal(t, []interface{}{"o", "🙂"}, events[2][1:])
+}
+
+func TestRead(t *testing.T) {
+	t.Parallel()
+
+	for _, recording := range []string{
+		"",
+		"not json\n",
+		`{"version":1,"width":80,"height":24,"timestamp":1}` + "\n",
+		`{"version":2,"width":80,"height":24}` + "\n",
+		`{"version":2,"width":80,"height":24,"timestamp":1}` + "\n[1.5,\"o\"]\n",
+	} {
+		_, err := asciicast.Read(bytes.NewReader([]byte(recording)))
+		require.Error(t, err, recording)
+	}
+
+	info, err := asciicast.Read(bytes.NewReader([]byte(`{"version":2,"width":80,"height":24,"timestamp":1}` + "\n[1.5,\"o\",\"hi\"]\n")))
+	require.NoError(t, err)
+	require.EqualValues(t, 1500, info.Duration.Milliseconds())
+}
@@ -0,0 +1,178 @@
+package agent
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os/exec"
+	"sort"
+	"time"
+
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/codersdk"
+)
+
+// execHandler runs a command without a TTY. The connection is upgraded to a
+// WebSocket so stdin can be streamed while output is sent, see
+// codersdk.WorkspaceAgentExecFrame for the protocol.
+func (a *agent) execHandler(rw http.ResponseWriter, r *http.Request) {
+	// The connection is hijacked, so the deadlines of the API server would
+	// otherwise stay in place for the lifetime of the command.
+	disableTransferTimeouts(rw)
+	hijacker := &hijackRecorder{ResponseWriter: rw}
+	conn, err := websocket.Accept(hijacker, r, nil)
+	if err != nil {
+		a.logger.Warn(r.Context(), "accept exec websocket", slog.Error(err))
+		return
+	}
+	conn.SetReadLimit(codersdk.WorkspaceAgentExecReadLimit)
+
+	// Hijacked connections aren't closed with the API server, so the
+	// session is tracked and its connection closed when the agent is.
+	done := make(chan struct{})
+	err = a.trackConnGoroutine(func() {
+		defer close(done)
+		go func() {
+			select {
+			case <-a.closed:
+				_ = hijacker.conn.Close()
+			case <-done:
+			}
+		}()
+		a.handleExec(r.Context(), conn)
+	})
+	if err != nil {
+		_ = conn.Close(websocket.StatusGoingAway, "agent is closed")
+		return
+	}
+	<-done
+}
+
+func (a *agent) handleExec(ctx context.Context, conn *websocket.Conn) {
+	// The request context isn't canceled when the client goes away after
+	// the connection is hijacked, so failed reads cancel this instead. It's
+	// canceled after the connection is closed, since canceling the context
+	// of a finished read interrupts the close handshake.
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	defer conn.Close(websocket.StatusNormalClosure, "")
+
+	var req codersdk.WorkspaceAgentExecRequest
+	err := wsjson.Read(ctx, conn, &req)
+	if err != nil {
+		a.logger.Warn(ctx, "read exec request", slog.Error(err))
+		return
+	}
+	exit := func(frame codersdk.WorkspaceAgentExecFrame) {
+		frame.Type = codersdk.WorkspaceAgentExecFrameExit
+		err := wsjson.Write(ctx, conn, frame)
+		if err != nil {
+			a.logger.Debug(ctx, "write exec exit frame", slog.Error(err))
+		}
+	}
+	if req.Command == "" {
+		exit(codersdk.WorkspaceAgentExecFrame{Error: "command is required"})
+		return
+	}
+
+	cmdCtx := ctx
+	if req.TimeoutMillis > 0 {
+		var cancelCmd context.CancelFunc
+		cmdCtx, cancelCmd = context.WithTimeout(ctx, time.Duration(req.TimeoutMillis)*time.Millisecond)
+		defer cancelCmd()
+	}
+	env := make([]string, 0, len(req.Env))
+	for k, v := range req.Env {
+		env = append(env, fmt.Sprintf("%s=%s", k, v))
+	}
+	sort.Strings(env)
+	cmd, err := a.createCommand(cmdCtx, req.Command, env)
+	if err != nil {
+		exit(codersdk.WorkspaceAgentExecFrame{Error: fmt.Sprintf("create command: %s", err)})
+		return
+	}
+	cmd.Stdout = codersdk.WorkspaceAgentExecWriter(ctx, conn, codersdk.WorkspaceAgentExecFrameStdout)
+	cmd.Stderr = codersdk.WorkspaceAgentExecWriter(ctx, conn, codersdk.WorkspaceAgentExecFrameStderr)
+	// A pipe is used instead of a reader so Wait doesn't block on a client
+	// that never closes stdin.
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		exit(codersdk.WorkspaceAgentExecFrame{Error: fmt.Sprintf("create stdin pipe: %s", err)})
+		return
+	}
+
+	start := time.Now()
+	err = cmd.Start()
+	if err != nil {
+		exit(codersdk.WorkspaceAgentExecFrame{Error: fmt.Sprintf("start command: %s", err)})
+		return
+	}
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		for {
+			var frame codersdk.WorkspaceAgentExecFrame
+			err := wsjson.Read(ctx, conn, &frame)
+			if err != nil {
+				// The client is gone, so kill the command.
+				cancel()
+				return
+			}
+			switch frame.Type {
+			case codersdk.WorkspaceAgentExecFrameStdin:
+				// The command may have closed its stdin, which
+				// isn't a reason to stop reading frames.
+				_, _ = stdin.Write(frame.Data)
+			case codersdk.WorkspaceAgentExecFrameStdinClose:
+				_ = stdin.Close()
+			}
+		}
+	}()
+
+	err = cmd.Wait()
+	duration := time.Since(start)
+	var exitErr *exec.ExitError
+	if err != nil && !errors.As(err, &exitErr) {
+		a.logger.Debug(ctx, "wait for exec command", slog.Error(err))
+	}
+	exit(codersdk.WorkspaceAgentExecFrame{
+		ExitCode:       cmd.ProcessState.ExitCode(),
+		DurationMillis: duration.Milliseconds(),
+		TimedOut:       errors.Is(cmdCtx.Err(), context.DeadlineExceeded),
+	})
+	// The client closes the connection after the exit frame. Reading the
+	// close frame here instead of in the deferred close keeps the reader
+	// above from racing the close handshake.
+	select {
+	case <-readDone:
+	case <-ctx.Done():
+	case <-time.After(5 * time.Second):
+	}
+}
+
+// hijackRecorder keeps the connection hijacked from a response writer, so it
+// can be closed without a WebSocket close handshake.
+type hijackRecorder struct {
+	http.ResponseWriter
+	conn net.Conn
+}
+
+func (h *hijackRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	hijacker, ok := h.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, xerrors.New("response writer does not support hijacking")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, nil, err
+	}
+	h.conn = conn
+	return conn, rw, nil
+}
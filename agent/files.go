@@ -0,0 +1,413 @@
+package agent
+
+import (
+	"archive/tar"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+// resolveFilePath returns the absolute path of a path sent to the file
+// transfer API. Relative paths are relative to the home directory of the
+// user the agent runs as.
+func resolveFilePath(path string) (string, error) {
+	if path == "" {
+		return "", xerrors.New("path is required")
+	}
+	if path == "~" || strings.HasPrefix(path, "~/") {
+		path = strings.TrimPrefix(strings.TrimPrefix(path, "~"), "/")
+	}
+	if filepath.IsAbs(path) {
+		return filepath.Clean(path), nil
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", xerrors.Errorf("get home dir: %w", err)
+	}
+	return filepath.Join(home, path), nil
+}
+
+// filePathParam resolves the path query parameter and writes an error
+// response if it's invalid.
+func filePathParam(rw http.ResponseWriter, r *http.Request) (string, bool) {
+	path, err := resolveFilePath(r.URL.Query().Get("path"))
+	if err != nil {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid path.",
+			Detail:  err.Error(),
+		})
+		return "", false
+	}
+	return path, true
+}
+
+// writeFileError writes the response for a failed filesystem operation.
+func writeFileError(rw http.ResponseWriter, r *http.Request, message string, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case xerrors.Is(err, os.ErrNotExist):
+		status = http.StatusNotFound
+	case xerrors.Is(err, os.ErrPermission):
+		status = http.StatusForbidden
+	}
+	httpapi.Write(r.Context(), rw, status, codersdk.Response{
+		Message: message,
+		Detail:  err.Error(),
+	})
+}
+
+// disableTransferTimeouts removes the API server's read and write timeouts
+// for the request, so large files can be transferred.
+func disableTransferTimeouts(rw http.ResponseWriter) {
+	rc := http.NewResponseController(rw)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+}
+
+func convertFileInfo(path string, info os.FileInfo) codersdk.WorkspaceAgentFileInfo {
+	return codersdk.WorkspaceAgentFileInfo{
+		Name:    info.Name(),
+		Path:    path,
+		Size:    info.Size(),
+		Mode:    uint32(info.Mode()),
+		IsDir:   info.IsDir(),
+		ModTime: info.ModTime(),
+	}
+}
+
+func (a *agent) statFileHandler(rw http.ResponseWriter, r *http.Request) {
+	path, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	info, err := a.filesystem.Stat(path)
+	if err != nil {
+		writeFileError(rw, r, "Failed to stat file.", err)
+		return
+	}
+
+	httpapi.Write(r.Context(), rw, http.StatusOK, convertFileInfo(path, info))
+}
+
+func (a *agent) listFilesHandler(rw http.ResponseWriter, r *http.Request) {
+	path, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	infos, err := afero.ReadDir(a.filesystem, path)
+	if err != nil {
+		writeFileError(rw, r, "Failed to list directory.", err)
+		return
+	}
+
+	files := make([]codersdk.WorkspaceAgentFileInfo, 0, len(infos))
+	for _, info := range infos {
+		files = append(files, convertFileInfo(filepath.Join(path, info.Name()), info))
+	}
+	httpapi.Write(r.Context(), rw, http.StatusOK, codersdk.WorkspaceAgentListFilesResponse{
+		Path:  path,
+		Files: files,
+	})
+}
+
+// readFileHandler serves the contents of a file. Range requests are
+// supported so clients can resume downloads.
+func (a *agent) readFileHandler(rw http.ResponseWriter, r *http.Request) {
+	path, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	file, err := a.filesystem.Open(path)
+	if err != nil {
+		writeFileError(rw, r, "Failed to open file.", err)
+		return
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		writeFileError(rw, r, "Failed to stat file.", err)
+		return
+	}
+	if info.IsDir() {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Path is a directory, download it as an archive instead.",
+		})
+		return
+	}
+
+	disableTransferTimeouts(rw)
+	http.ServeContent(rw, r, info.Name(), info.ModTime(), file)
+}
+
+// writeFileHandler writes the request body to a file, starting at the offset
+// query parameter. Anything after the offset is discarded, which lets
+// clients resume uploads.
+func (a *agent) writeFileHandler(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	path, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	var (
+		offset int64
+		mode   uint64 = 0o644
+		err    error
+	)
+	if raw := r.URL.Query().Get("offset"); raw != "" {
+		offset, err = strconv.ParseInt(raw, 10, 64)
+		if err != nil || offset < 0 {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param 'offset' must be a non-negative integer.",
+			})
+			return
+		}
+	}
+	if raw := r.URL.Query().Get("mode"); raw != "" {
+		mode, err = strconv.ParseUint(raw, 8, 32)
+		if err != nil || os.FileMode(mode)&^os.ModePerm != 0 {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param 'mode' must be octal permission bits.",
+			})
+			return
+		}
+	}
+
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	} else {
+		info, err := a.filesystem.Stat(path)
+		if err != nil {
+			writeFileError(rw, r, "Failed to stat file.", err)
+			return
+		}
+		if offset > info.Size() {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Offset is past the end of the file.",
+				Detail:  xerrors.Errorf("offset %d, file size %d", offset, info.Size()).Error(),
+			})
+			return
+		}
+	}
+	err = a.filesystem.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		writeFileError(rw, r, "Failed to create parent directory.", err)
+		return
+	}
+	file, err := a.filesystem.OpenFile(path, flags, os.FileMode(mode))
+	if err != nil {
+		writeFileError(rw, r, "Failed to open file.", err)
+		return
+	}
+	defer file.Close()
+	if offset > 0 {
+		err = file.Truncate(offset)
+		if err == nil {
+			_, err = file.Seek(offset, io.SeekStart)
+		}
+		if err != nil {
+			writeFileError(rw, r, "Failed to seek to offset.", err)
+			return
+		}
+	}
+
+	disableTransferTimeouts(rw)
+	_, err = io.Copy(file, r.Body)
+	if err != nil {
+		writeFileError(rw, r, "Failed to write file.", err)
+		return
+	}
+	err = file.Close()
+	if err != nil {
+		writeFileError(rw, r, "Failed to close file.", err)
+		return
+	}
+	info, err := a.filesystem.Stat(path)
+	if err != nil {
+		writeFileError(rw, r, "Failed to stat file.", err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertFileInfo(path, info))
+}
+
+// downloadDirectoryHandler streams a directory as a tar archive. Only
+// regular files and directories are included.
+func (a *agent) downloadDirectoryHandler(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	root, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	info, err := a.filesystem.Stat(root)
+	if err != nil {
+		writeFileError(rw, r, "Failed to stat directory.", err)
+		return
+	}
+	if !info.IsDir() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Path is not a directory.",
+		})
+		return
+	}
+
+	// The directory is walked before the status is sent, so errors reading
+	// it are reported to the client.
+	var entries []*directoryEntry
+	err = afero.Walk(a.filesystem, root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == root || (!info.IsDir() && !info.Mode().IsRegular()) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, path)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		entries = append(entries, &directoryEntry{path: path, header: header})
+		return nil
+	})
+	if err != nil {
+		writeFileError(rw, r, "Failed to read directory.", err)
+		return
+	}
+
+	disableTransferTimeouts(rw)
+	rw.Header().Set("Content-Type", "application/x-tar")
+	rw.WriteHeader(http.StatusOK)
+	tw := tar.NewWriter(rw)
+	for _, entry := range entries {
+		err = a.writeDirectoryEntry(tw, entry)
+		if err != nil {
+			break
+		}
+	}
+	if err == nil {
+		err = tw.Close()
+	}
+	if err != nil {
+		// The status has already been sent. Aborting closes the connection
+		// without ending the response, so the client sees the archive as
+		// truncated rather than complete.
+		a.logger.Warn(ctx, "write directory archive", slog.F("path", root), slog.Error(err))
+		panic(http.ErrAbortHandler)
+	}
+}
+
+// directoryEntry is a file or directory to include in a directory archive.
+type directoryEntry struct {
+	path   string
+	header *tar.Header
+}
+
+// writeDirectoryEntry writes entry and, for files, its contents to tw.
+func (a *agent) writeDirectoryEntry(tw *tar.Writer, entry *directoryEntry) error {
+	if entry.header.Typeflag == tar.TypeDir {
+		return tw.WriteHeader(entry.header)
+	}
+	file, err := a.filesystem.Open(entry.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	err = tw.WriteHeader(entry.header)
+	if err != nil {
+		return err
+	}
+	// The file may have changed since it was walked. Only the size in the
+	// header is copied, and a file that shrank fails the copy.
+	_, err = io.CopyN(tw, file, entry.header.Size)
+	return err
+}
+
+// uploadDirectoryHandler extracts a tar archive into a directory. Only
+// regular files and directories are extracted.
+func (a *agent) uploadDirectoryHandler(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	root, ok := filePathParam(rw, r)
+	if !ok {
+		return
+	}
+	err := a.filesystem.MkdirAll(root, 0o755)
+	if err != nil {
+		writeFileError(rw, r, "Failed to create directory.", err)
+		return
+	}
+
+	disableTransferTimeouts(rw)
+	tr := tar.NewReader(r.Body)
+	for {
+		header, err := tr.Next()
+		if xerrors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to read archive.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Archive contains a path outside of the directory.",
+				Detail:  header.Name,
+			})
+			return
+		}
+		path := filepath.Join(root, name)
+		mode := header.FileInfo().Mode().Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = a.filesystem.MkdirAll(path, mode)
+		case tar.TypeReg:
+			err = a.writeArchiveFile(path, mode, tr)
+		default:
+			continue
+		}
+		if err != nil {
+			writeFileError(rw, r, "Failed to extract archive.", err)
+			return
+		}
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+func (a *agent) writeArchiveFile(path string, mode os.FileMode, r io.Reader) error {
+	err := a.filesystem.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := a.filesystem.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
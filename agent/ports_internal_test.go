@@ -0,0 +1,102 @@
+//go:build linux
+
+package agent
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestListeningPortsHandler(t *testing.T) {
+	t.Parallel()
+
+	udpConn, err := net.ListenPacket("udp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer udpConn.Close()
+	udpPort := uint16(udpConn.LocalAddr().(*net.UDPAddr).Port)
+
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "app.sock")
+	unixListener, err := net.Listen("unix", socketPath)
+	require.NoError(t, err)
+	defer unixListener.Close()
+
+	// Sockets outside of the socket directories aren't reported.
+	otherPath := filepath.Join(t.TempDir(), "other.sock")
+	otherListener, err := net.Listen("unix", otherPath)
+	require.NoError(t, err)
+	defer otherListener.Close()
+
+	lp := &listeningPortsHandler{
+		socketDirs: func() []string {
+			return []string{dir}
+		},
+	}
+	ports, err := lp.getListeningPorts()
+	require.NoError(t, err)
+
+	var foundUDP, foundUnix bool
+	for _, port := range ports {
+		switch {
+		case port.Network == "udp" && port.Port == udpPort:
+			foundUDP = true
+			require.NotEmpty(t, port.ProcessName)
+		case port.Network == "unix" && port.Path == socketPath:
+			foundUnix = true
+			require.NotEmpty(t, port.ProcessName)
+			require.Zero(t, port.Port)
+		case port.Network == "unix" && port.Path == otherPath:
+			t.Fatalf("unexpected socket outside of socket directories: %s", otherPath)
+		}
+	}
+	require.True(t, foundUDP, "udp port %d not found in %v", udpPort, ports)
+	require.True(t, foundUnix, "unix socket %s not found in %v", socketPath, ports)
+
+	// The sockets of the agent itself are hidden.
+	lp = &listeningPortsHandler{
+		agentPID: os.Getpid(),
+	}
+	ports, err = lp.getListeningPorts()
+	require.NoError(t, err)
+	for _, port := range ports {
+		require.False(t, port.Network == "udp" && port.Port == udpPort, "agent udp port %d was reported", udpPort)
+	}
+}
+
+func TestListeningPortsHandler_ScanErrors(t *testing.T) {
+	t.Parallel()
+
+	tcpListener, err := net.Listen("tcp4", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer tcpListener.Close()
+	tcpPort := uint16(tcpListener.Addr().(*net.TCPAddr).Port)
+
+	// TCP ports are still reported when UDP ports and Unix sockets can't be
+	// listed, e.g. when IPv6 is disabled.
+	lp := &listeningPortsHandler{
+		scanUDP: func() ([]codersdk.WorkspaceAgentListeningPort, error) {
+			return nil, xerrors.New("open /proc/net/udp6: no such file or directory")
+		},
+		scanUnixSockets: func() ([]codersdk.WorkspaceAgentListeningPort, error) {
+			return nil, xerrors.New("open /proc/net/unix: permission denied")
+		},
+	}
+	ports, err := lp.getListeningPorts()
+	require.NoError(t, err)
+
+	var foundTCP bool
+	for _, port := range ports {
+		require.Equal(t, "tcp", port.Network)
+		if port.Port == tcpPort {
+			foundTCP = true
+		}
+	}
+	require.True(t, foundTCP, "tcp port %d not found in %v", tcpPort, ports)
+}
@@ -3,11 +3,14 @@
 package agent
 
 import (
+	"context"
+	"errors"
 	"time"
 
 	"github.com/cakturk/go-netstat/netstat"
 	"golang.org/x/xerrors"
 
+	"cdr.dev/slog"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -28,7 +31,69 @@ func (lp *listeningPortsHandler) getListeningPorts() ([]codersdk.WorkspaceAgentL
 	if err != nil {
 		return nil, xerrors.Errorf("scan listening ports: %w", err)
 	}
+	ports := lp.filterSocks("tcp", tabs)
 
+	// Failing to list UDP ports or Unix sockets, e.g. because /proc/net/udp6
+	// doesn't exist when IPv6 is disabled, doesn't hide the TCP ports.
+	scanUDP := lp.getListeningUDPPorts
+	if lp.scanUDP != nil {
+		scanUDP = lp.scanUDP
+	}
+	udpPorts, err := scanUDP()
+	if err != nil {
+		lp.logger.Warn(context.Background(), "scan udp sockets", slog.Error(err))
+	}
+	ports = append(ports, udpPorts...)
+
+	scanUnixSockets := lp.getListeningUnixSockets
+	if lp.scanUnixSockets != nil {
+		scanUnixSockets = lp.scanUnixSockets
+	}
+	sockets, err := scanUnixSockets()
+	if err != nil {
+		lp.logger.Warn(context.Background(), "scan unix sockets", slog.Error(err))
+	}
+	ports = append(ports, sockets...)
+
+	lp.ports = ports
+	lp.mtime = time.Now()
+
+	// copy
+	ports = make([]codersdk.WorkspaceAgentListeningPort, len(lp.ports))
+	copy(ports, lp.ports)
+	return ports, nil
+}
+
+// getListeningUDPPorts returns the UDP ports of IPv4 and IPv6 sockets. UDP
+// sockets don't listen, so the sockets that aren't connected to a remote
+// address are reported. The ports of the sockets that could be listed are
+// returned along with any error.
+func (lp *listeningPortsHandler) getListeningUDPPorts() ([]codersdk.WorkspaceAgentListeningPort, error) {
+	unconnected := func(s *netstat.SockTabEntry) bool {
+		return s.RemoteAddr == nil || s.RemoteAddr.Port == 0
+	}
+	tabs, err4 := netstat.UDPSocks(unconnected)
+	if err4 != nil {
+		err4 = xerrors.Errorf("scan udp sockets: %w", err4)
+	}
+	tabs6, err6 := netstat.UDP6Socks(unconnected)
+	if err6 != nil {
+		err6 = xerrors.Errorf("scan udp6 sockets: %w", err6)
+	}
+	udpTabs := make([]netstat.SockTabEntry, 0, len(tabs)+len(tabs6))
+	for _, tab := range append(tabs, tabs6...) {
+		// The agent uses UDP for tailnet, which isn't of interest.
+		if tab.Process != nil && tab.Process.Pid == lp.agentPID {
+			continue
+		}
+		udpTabs = append(udpTabs, tab)
+	}
+	return lp.filterSocks("udp", udpTabs), errors.Join(err4, err6)
+}
+
+// filterSocks converts the sockets to listening ports of the given network,
+// leaving out reserved, ignored and duplicate ports.
+func (lp *listeningPortsHandler) filterSocks(network string, tabs []netstat.SockTabEntry) []codersdk.WorkspaceAgentListeningPort {
 	seen := make(map[uint16]struct{}, len(tabs))
 	ports := []codersdk.WorkspaceAgentListeningPort{}
 	for _, tab := range tabs {
@@ -54,16 +119,9 @@ func (lp *listeningPortsHandler) getListeningPorts() ([]codersdk.WorkspaceAgentL
 		}
 		ports = append(ports, codersdk.WorkspaceAgentListeningPort{
 			ProcessName: procName,
-			Network:     "tcp",
+			Network:     network,
 			Port:        tab.LocalAddr.Port,
 		})
 	}
-
-	lp.ports = ports
-	lp.mtime = time.Now()
-
-	// copy
-	ports = make([]codersdk.WorkspaceAgentListeningPort, len(lp.ports))
-	copy(ports, lp.ports)
-	return ports, nil
+	return ports
 }
@@ -0,0 +1,138 @@
+//go:build linux
+
+package agent
+
+import (
+	"bufio"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/codersdk"
+)
+
+// unixSocketAcceptConn is the __SO_ACCEPTCON flag in /proc/net/unix, which
+// is set on listening sockets.
+const unixSocketAcceptConn = 0x10000
+
+// getListeningUnixSockets returns the listening Unix sockets in the socket
+// directories of the handler.
+func (lp *listeningPortsHandler) getListeningUnixSockets() ([]codersdk.WorkspaceAgentListeningPort, error) {
+	var dirs []string
+	if lp.socketDirs != nil {
+		dirs = lp.socketDirs()
+	}
+	if len(dirs) == 0 {
+		return nil, nil
+	}
+
+	f, err := os.Open("/proc/net/unix")
+	if err != nil {
+		return nil, xerrors.Errorf("open /proc/net/unix: %w", err)
+	}
+	defer f.Close()
+
+	// Num RefCount Protocol Flags Type St Inode Path
+	pathsByInode := map[string]string{}
+	seen := map[string]struct{}{}
+	scanner := bufio.NewScanner(f)
+	scanner.Scan() // Skip the header.
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 {
+			// Unbound sockets have no path.
+			continue
+		}
+		flags, err := strconv.ParseUint(fields[3], 16, 32)
+		if err != nil || flags&unixSocketAcceptConn == 0 {
+			continue
+		}
+		path := fields[7]
+		if _, ok := seen[path]; ok || !inDirs(path, dirs) {
+			continue
+		}
+		seen[path] = struct{}{}
+		pathsByInode[fields[6]] = path
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, xerrors.Errorf("read /proc/net/unix: %w", err)
+	}
+
+	processNames := unixSocketProcessNames(pathsByInode)
+	sockets := make([]codersdk.WorkspaceAgentListeningPort, 0, len(pathsByInode))
+	for inode, path := range pathsByInode {
+		sockets = append(sockets, codersdk.WorkspaceAgentListeningPort{
+			ProcessName: processNames[inode],
+			Network:     "unix",
+			Path:        path,
+		})
+	}
+	sort.Slice(sockets, func(i, j int) bool {
+		return sockets[i].Path < sockets[j].Path
+	})
+	return sockets, nil
+}
+
+// unixSocketProcessNames returns the names of the processes that have the
+// socket inodes open. Processes of other users can't be inspected, so their
+// sockets have no name.
+func unixSocketProcessNames(inodes map[string]string) map[string]string {
+	names := map[string]string{}
+	if len(inodes) == 0 {
+		return names
+	}
+	procs, err := os.ReadDir("/proc")
+	if err != nil {
+		return names
+	}
+	for _, proc := range procs {
+		if _, err := strconv.Atoi(proc.Name()); err != nil {
+			continue
+		}
+		fds, err := os.ReadDir(filepath.Join("/proc", proc.Name(), "fd"))
+		if err != nil {
+			continue
+		}
+		for _, fd := range fds {
+			link, err := os.Readlink(filepath.Join("/proc", proc.Name(), "fd", fd.Name()))
+			if err != nil {
+				continue
+			}
+			inode, ok := strings.CutPrefix(link, "socket:[")
+			if !ok {
+				continue
+			}
+			inode = strings.TrimSuffix(inode, "]")
+			if _, ok := inodes[inode]; !ok {
+				continue
+			}
+			if _, ok := names[inode]; ok {
+				continue
+			}
+			comm, err := os.ReadFile(filepath.Join("/proc", proc.Name(), "comm"))
+			if err != nil {
+				continue
+			}
+			names[inode] = strings.TrimSpace(string(comm))
+		}
+		if len(names) == len(inodes) {
+			break
+		}
+	}
+	return names
+}
+
+// inDirs returns whether the path is in one of the directories.
+func inDirs(path string, dirs []string) bool {
+	for _, dir := range dirs {
+		rel, err := filepath.Rel(dir, path)
+		if err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
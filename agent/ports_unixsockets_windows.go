@@ -0,0 +1,11 @@
+//go:build windows && amd64
+
+package agent
+
+import "github.com/coder/coder/codersdk"
+
+// getListeningUnixSockets is only implemented on Linux, where sockets are
+// listed in /proc/net/unix.
+func (*listeningPortsHandler) getListeningUnixSockets() ([]codersdk.WorkspaceAgentListeningPort, error) {
+	return nil, nil
+}
@@ -0,0 +1,189 @@
+package agent
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/retry"
+)
+
+// terminalRecordingUploadTimeout bounds how long a recording is retried for,
+// including while the agent is closing.
+const terminalRecordingUploadTimeout = 2 * time.Minute
+
+// terminalRecorder records the output of a terminal session to a temporary
+// file and uploads it to coderd when the session ends. A failing recorder
+// never fails the session it records, errors are logged instead.
+type terminalRecorder struct {
+	agent         *agent
+	logger        slog.Logger
+	recordingType codersdk.TerminalRecordingType
+	// clientReportedUserID is the user the client claimed to connect as,
+	// it's unset for clients that don't send it. It isn't verified.
+	clientReportedUserID uuid.UUID
+
+	mu     sync.Mutex
+	file   *countingFile
+	writer *asciicast.Writer
+	closed bool
+
+	closeOnce sync.Once
+}
+
+// startTerminalRecording returns a recorder for a terminal session, or nil if
+// recording is disabled for the workspace.
+func (a *agent) startTerminalRecording(ctx context.Context, logger slog.Logger, recordingType codersdk.TerminalRecordingType, clientReportedUserID uuid.UUID, command string, width, height int) *terminalRecorder {
+	metadata, ok := a.metadata.Load().(agentsdk.Metadata)
+	if !ok || !metadata.RecordTerminalSessions {
+		return nil
+	}
+	logger = logger.Named("recording")
+
+	f, err := os.CreateTemp(a.tempDir, "coder-recording-*.cast")
+	if err != nil {
+		logger.Error(ctx, "create recording file", slog.Error(err))
+		return nil
+	}
+	file := &countingFile{File: f}
+	writer, err := asciicast.NewWriter(file, asciicast.Header{
+		Width:  width,
+		Height: height,
+		Title:  command,
+	})
+	if err != nil {
+		logger.Error(ctx, "start recording", slog.Error(err))
+		_ = file.Close()
+		_ = os.Remove(file.Name())
+		return nil
+	}
+	return &terminalRecorder{
+		agent:                a,
+		logger:               logger,
+		recordingType:        recordingType,
+		clientReportedUserID: clientReportedUserID,
+		file:                 file,
+		writer:               writer,
+	}
+}
+
+// Write records terminal output. It always succeeds so it can be used with
+// io.MultiWriter.
+func (r *terminalRecorder) Write(p []byte) (int, error) {
+	if r == nil {
+		return len(p), nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return len(p), nil
+	}
+	// Every byte of output takes at most 6 bytes once it's encoded in an
+	// event, e.g. "\u001b".
+	if r.file.written+int64(6*len(p)+64) > agentsdk.TerminalRecordingMaxSize {
+		r.logger.Warn(context.Background(), "terminal recording exceeded the maximum size, output is no longer recorded",
+			slog.F("max_size", agentsdk.TerminalRecordingMaxSize))
+		r.closed = true
+		return len(p), nil
+	}
+	_, err := r.writer.Write(p)
+	if err != nil {
+		r.logger.Error(context.Background(), "write to recording", slog.Error(err))
+		r.closed = true
+	}
+	return len(p), nil
+}
+
+// Resize records a change of the terminal size.
+func (r *terminalRecorder) Resize(width, height int) {
+	if r == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return
+	}
+	err := r.writer.Resize(width, height)
+	if err != nil {
+		r.logger.Error(context.Background(), "write resize to recording", slog.Error(err))
+		r.closed = true
+	}
+}
+
+// Close ends the recording and uploads it in the background. Uploads are
+// retried until they succeed or terminalRecordingUploadTimeout passes, the
+// agent waits for them when it's closed.
+func (r *terminalRecorder) Close() {
+	if r == nil {
+		return
+	}
+	r.closeOnce.Do(func() {
+		r.mu.Lock()
+		r.closed = true
+		r.mu.Unlock()
+
+		err := r.agent.trackRecordingUpload(func() {
+			defer os.Remove(r.file.Name())
+			err := r.upload()
+			if err != nil {
+				r.logger.Error(context.Background(), "upload terminal recording", slog.Error(err))
+			}
+		})
+		if err != nil {
+			r.logger.Warn(context.Background(), "terminal recording not uploaded", slog.Error(err))
+			_ = r.file.Close()
+			_ = os.Remove(r.file.Name())
+		}
+	})
+}
+
+func (r *terminalRecorder) upload() error {
+	defer r.file.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), terminalRecordingUploadTimeout)
+	defer cancel()
+
+	var err error
+	for retrier := retry.New(time.Second, 30*time.Second); retrier.Wait(ctx); {
+		_, err = r.file.Seek(0, io.SeekStart)
+		if err != nil {
+			return xerrors.Errorf("seek recording: %w", err)
+		}
+		err = r.agent.client.PostTerminalRecording(ctx, r.recordingType, r.clientReportedUserID, r.file.File)
+		if err == nil {
+			r.logger.Debug(ctx, "uploaded terminal recording")
+			return nil
+		}
+		var sdkErr *codersdk.Error
+		if errors.As(err, &sdkErr) && sdkErr.StatusCode() < http.StatusInternalServerError {
+			// The recording was rejected, retrying won't help.
+			return err
+		}
+		r.logger.Warn(ctx, "upload terminal recording, retrying", slog.Error(err))
+	}
+	return xerrors.Errorf("recording wasn't uploaded in %s: %w", terminalRecordingUploadTimeout, err)
+}
+
+// countingFile counts the bytes written to a file.
+type countingFile struct {
+	*os.File
+	written int64
+}
+
+func (f *countingFile) Write(p []byte) (int, error) {
+	n, err := f.File.Write(p)
+	f.written += int64(n)
+	return n, err
+}
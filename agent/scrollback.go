@@ -0,0 +1,127 @@
+package agent
+
+import (
+	"io"
+	"sync"
+
+	"github.com/spf13/afero"
+	"golang.org/x/xerrors"
+)
+
+// scrollbackFile keeps the output of a reconnecting PTY on disk so clients
+// can replay more than fits in the in-memory buffer when they reconnect.
+// The file is capped at maxSize bytes. Once it grows past the cap, the
+// oldest output is discarded so that half of the cap remains.
+//
+// A nil *scrollbackFile is valid and does nothing, which is the case when
+// scrollback is disabled.
+type scrollbackFile struct {
+	mu      sync.Mutex
+	fs      afero.Fs
+	file    afero.File
+	maxSize int64
+	size    int64
+	// err is set when writing to the file failed. Replaying a file with
+	// missing output would be confusing, so it is no longer used.
+	err    error
+	closed bool
+}
+
+func newScrollbackFile(fs afero.Fs, dir string, maxSize int64) (*scrollbackFile, error) {
+	if maxSize <= 0 {
+		return nil, xerrors.Errorf("max size must be positive, got %d", maxSize)
+	}
+	file, err := afero.TempFile(fs, dir, "coder-reconnecting-pty-*.log")
+	if err != nil {
+		return nil, xerrors.Errorf("create scrollback file: %w", err)
+	}
+	return &scrollbackFile{
+		fs:      fs,
+		file:    file,
+		maxSize: maxSize,
+	}, nil
+}
+
+// Write appends PTY output to the file.
+func (s *scrollbackFile) Write(p []byte) (int, error) {
+	if s == nil {
+		return len(p), nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return 0, s.err
+	}
+	n, err := s.file.WriteAt(p, s.size)
+	s.size += int64(n)
+	if err == nil && s.size > s.maxSize {
+		err = s.compact()
+	}
+	if err != nil {
+		s.err = xerrors.Errorf("write scrollback: %w", err)
+		return n, s.err
+	}
+	return n, nil
+}
+
+// compact moves the newest half of the cap to the start of the file and
+// drops everything before it. s.mu must be held.
+func (s *scrollbackFile) compact() error {
+	keep := s.maxSize / 2
+	buf := make([]byte, keep)
+	_, err := s.file.ReadAt(buf, s.size-keep)
+	if err != nil && !xerrors.Is(err, io.EOF) {
+		return err
+	}
+	_, err = s.file.WriteAt(buf, 0)
+	if err != nil {
+		return err
+	}
+	err = s.file.Truncate(keep)
+	if err != nil {
+		return err
+	}
+	s.size = keep
+	return nil
+}
+
+// Bytes returns the stored output. ok is false if there is nothing to
+// replay, in which case the in-memory buffer should be used.
+func (s *scrollbackFile) Bytes() (data []byte, ok bool, err error) {
+	if s == nil {
+		return nil, false, nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.err != nil {
+		return nil, false, nil
+	}
+	data = make([]byte, s.size)
+	_, err = s.file.ReadAt(data, 0)
+	if err != nil && !xerrors.Is(err, io.EOF) {
+		return nil, false, xerrors.Errorf("read scrollback: %w", err)
+	}
+	return data, true, nil
+}
+
+// Close closes and removes the file.
+func (s *scrollbackFile) Close() error {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed {
+		return nil
+	}
+	s.closed = true
+	if s.err == nil {
+		s.err = xerrors.New("scrollback file closed")
+	}
+	_ = s.file.Close()
+	err := s.fs.Remove(s.file.Name())
+	if err != nil {
+		return xerrors.Errorf("remove scrollback file: %w", err)
+	}
+	return nil
+}
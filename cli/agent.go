@@ -35,6 +35,9 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 		pprofAddress  string
 		noReap        bool
 		sshMaxTimeout time.Duration
+
+		reconnectingPTYBufferSize     int64
+		reconnectingPTYScrollbackSize int64
 	)
 	cmd := &clibase.Cmd{
 		Use:   "agent",
@@ -204,8 +207,10 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 				EnvironmentVariables: map[string]string{
 					"GIT_ASKPASS": executablePath,
 				},
-				AgentPorts:    agentPorts,
-				SSHMaxTimeout: sshMaxTimeout,
+				AgentPorts:                    agentPorts,
+				SSHMaxTimeout:                 sshMaxTimeout,
+				ReconnectingPTYBufferSize:     int(reconnectingPTYBufferSize),
+				ReconnectingPTYScrollbackSize: reconnectingPTYScrollbackSize,
 			})
 			<-ctx.Done()
 			return closer.Close()
@@ -248,6 +253,20 @@ func (r *RootCmd) workspaceAgent() *clibase.Cmd {
 			Description: "Specify the max timeout for a SSH connection.",
 			Value:       clibase.DurationOf(&sshMaxTimeout),
 		},
+		{
+			Flag:        "reconnecting-pty-buffer-size",
+			Default:     "65536",
+			Env:         "CODER_AGENT_RECONNECTING_PTY_BUFFER_SIZE",
+			Description: "The size in bytes of the output buffer replayed when reconnecting to a web terminal.",
+			Value:       clibase.Int64Of(&reconnectingPTYBufferSize),
+		},
+		{
+			Flag:        "reconnecting-pty-scrollback-size",
+			Default:     "0",
+			Env:         "CODER_AGENT_RECONNECTING_PTY_SCROLLBACK_SIZE",
+			Description: "The maximum size in bytes of the file that web terminal output is spilled to, so reconnecting clients can request more output than fits in the buffer. 0 disables the file.",
+			Value:       clibase.Int64Of(&reconnectingPTYScrollbackSize),
+		},
 	}
 
 	return cmd
@@ -0,0 +1,476 @@
+package cli
+
+import (
+	"archive/tar"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) cp() *clibase.Cmd {
+	var resume bool
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "cp <source> <destination>",
+		Short:       "Copy files and directories to or from a workspace",
+		Long: "Paths in a workspace are written as <workspace>:<path> and are relative to the\n" +
+			"home directory unless they are absolute. Directories are copied recursively.\n" + formatExamples(
+			example{
+				Description: "Copy a local file to the home directory of a workspace",
+				Command:     "coder cp ./notes.txt my-workspace:~/",
+			},
+			example{
+				Description: "Copy a directory from a workspace agent to the current directory",
+				Command:     "coder cp my-workspace.main:/var/log/app .",
+			},
+			example{
+				Description: "Resume an interrupted download",
+				Command:     "coder cp --resume my-workspace:backup.tar.gz .",
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			src := parseCopyPath(inv.Args[0])
+			dst := parseCopyPath(inv.Args[1])
+			if (src.workspace == "") == (dst.workspace == "") {
+				return xerrors.New("exactly one of the source and destination must be in a workspace, e.g. my-workspace:~/file.txt")
+			}
+			workspaceName := src.workspace
+			if workspaceName == "" {
+				workspaceName = dst.workspace
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, inv, client, codersdk.Me, workspaceName)
+			if err != nil {
+				return err
+			}
+			err = cliui.Agent(ctx, inv.Stderr, cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+			})
+			if err != nil && !xerrors.Is(err, cliui.AgentStartError) {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+			logger, ok := LoggerFromContext(ctx)
+			if !ok {
+				logger = slog.Make(sloghuman.Sink(inv.Stderr))
+			}
+			if r.verbose {
+				logger = logger.Leveled(slog.LevelDebug)
+			}
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{
+				Logger: logger,
+			})
+			if err != nil {
+				return xerrors.Errorf("dial workspace agent: %w", err)
+			}
+			defer conn.Close()
+			if !conn.AwaitReachable(ctx) {
+				return xerrors.Errorf("workspace agent not reachable: %w", ctx.Err())
+			}
+
+			progress := newTransferProgress(inv)
+			if src.workspace != "" {
+				err = copyFromWorkspace(ctx, conn, progress, src.path, dst.path, resume)
+			} else {
+				err = copyToWorkspace(ctx, conn, progress, src.path, dst.path, resume)
+			}
+			if err != nil {
+				return err
+			}
+			progress.finish()
+			return nil
+		},
+	}
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "resume",
+			Description: "Continue an interrupted transfer of a file from where it stopped, keeping the part that was already copied. Directories are always copied in full.",
+			Value:       clibase.BoolOf(&resume),
+		},
+	}
+	return cmd
+}
+
+type copyPath struct {
+	// workspace is empty for local paths.
+	workspace string
+	path      string
+}
+
+// parseCopyPath parses a cp argument. Paths in a workspace are written as
+// <workspace>:<path>, everything else is a local path.
+func parseCopyPath(arg string) copyPath {
+	workspace, p, ok := strings.Cut(arg, ":")
+	if !ok || workspace == "" || strings.ContainsAny(workspace, `/\`) {
+		return copyPath{path: arg}
+	}
+	// Windows paths start with a drive letter, e.g. C:\Users.
+	if runtime.GOOS == "windows" && len(workspace) == 1 {
+		return copyPath{path: arg}
+	}
+	if p == "" {
+		p = "~"
+	}
+	return copyPath{workspace: workspace, path: p}
+}
+
+func copyToWorkspace(ctx context.Context, conn *codersdk.WorkspaceAgentConn, progress *transferProgress, local, remote string, resume bool) error {
+	info, err := os.Stat(local)
+	if err != nil {
+		return xerrors.Errorf("stat source: %w", err)
+	}
+	// Copying into an existing directory keeps the name of the source.
+	remoteInfo, err := conn.StatFile(ctx, remote)
+	if err == nil && remoteInfo.IsDir {
+		remote = path.Join(filepath.ToSlash(remoteInfo.Path), filepath.Base(local))
+	}
+
+	if info.IsDir() {
+		total, err := directorySize(local)
+		if err != nil {
+			return xerrors.Errorf("measure source: %w", err)
+		}
+		progress.start(filepath.Base(local), total)
+		reader, writer := io.Pipe()
+		go func() {
+			_ = writer.CloseWithError(writeDirectoryArchive(writer, local, progress))
+		}()
+		err = conn.UploadDirectory(ctx, remote, reader)
+		_ = reader.Close()
+		if err != nil {
+			return xerrors.Errorf("upload directory: %w", err)
+		}
+		return nil
+	}
+
+	file, err := os.Open(local)
+	if err != nil {
+		return xerrors.Errorf("open source: %w", err)
+	}
+	defer file.Close()
+	var offset int64
+	if resume {
+		remoteInfo, err := conn.StatFile(ctx, remote)
+		if err == nil && !remoteInfo.IsDir && remoteInfo.Size <= info.Size() {
+			offset = remoteInfo.Size
+		}
+	}
+	progress.start(info.Name(), info.Size())
+	progress.add(offset)
+	if resume && offset == info.Size() {
+		return nil
+	}
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return xerrors.Errorf("seek source: %w", err)
+	}
+	_, err = conn.WriteFile(ctx, remote, offset, info.Mode(), progress.reader(file))
+	if err != nil {
+		return xerrors.Errorf("upload file: %w", err)
+	}
+	return nil
+}
+
+func copyFromWorkspace(ctx context.Context, conn *codersdk.WorkspaceAgentConn, progress *transferProgress, remote, local string, resume bool) error {
+	remoteInfo, err := conn.StatFile(ctx, remote)
+	if err != nil {
+		return xerrors.Errorf("stat source: %w", err)
+	}
+	// Copying into an existing directory keeps the name of the source.
+	if info, err := os.Stat(local); err == nil && info.IsDir() {
+		local = filepath.Join(local, remoteInfo.Name)
+	}
+
+	if remoteInfo.IsDir {
+		// The size of the directory isn't known up front.
+		progress.start(remoteInfo.Name, 0)
+		archive, err := conn.DownloadDirectory(ctx, remote)
+		if err != nil {
+			return xerrors.Errorf("download directory: %w", err)
+		}
+		defer archive.Close()
+		return extractDirectoryArchive(archive, local, progress)
+	}
+
+	var offset int64
+	if resume {
+		if info, err := os.Stat(local); err == nil && !info.IsDir() && info.Size() <= remoteInfo.Size {
+			offset = info.Size()
+		}
+	}
+	progress.start(remoteInfo.Name, remoteInfo.Size)
+	progress.add(offset)
+	if resume && offset == remoteInfo.Size {
+		return nil
+	}
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+	file, err := os.OpenFile(local, flags, os.FileMode(remoteInfo.Mode).Perm())
+	if err != nil {
+		return xerrors.Errorf("open destination: %w", err)
+	}
+	defer file.Close()
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return xerrors.Errorf("seek destination: %w", err)
+	}
+	content, err := conn.ReadFile(ctx, remote, offset)
+	if err != nil {
+		return xerrors.Errorf("download file: %w", err)
+	}
+	defer content.Close()
+	_, err = io.Copy(file, progress.reader(content))
+	if err != nil {
+		return xerrors.Errorf("download file: %w", err)
+	}
+	return file.Close()
+}
+
+// directorySize returns the total size of the regular files in a directory.
+func directorySize(root string) (int64, error) {
+	var size int64
+	err := filepath.Walk(root, func(_ string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size, err
+}
+
+// writeDirectoryArchive writes the regular files and directories in root as
+// a tar archive with paths relative to root.
+func writeDirectoryArchive(w io.Writer, root string, progress *transferProgress) error {
+	tw := tar.NewWriter(w)
+	err := filepath.Walk(root, func(p string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if p == root || (!info.IsDir() && !info.Mode().IsRegular()) {
+			return nil
+		}
+		rel, err := filepath.Rel(root, p)
+		if err != nil {
+			return err
+		}
+		header, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		header.Name = filepath.ToSlash(rel)
+		if info.IsDir() {
+			header.Name += "/"
+		}
+		err = tw.WriteHeader(header)
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		file, err := os.Open(p)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		_, err = io.CopyN(tw, progress.reader(file), header.Size)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	return tw.Close()
+}
+
+// extractDirectoryArchive extracts the regular files and directories in a tar
+// archive into root. Archives that end without the end-of-archive blocks are
+// rejected, since the agent stops writing the archive if it fails partway.
+func extractDirectoryArchive(r io.Reader, root string, progress *transferProgress) error {
+	err := os.MkdirAll(root, 0o755)
+	if err != nil {
+		return xerrors.Errorf("create destination: %w", err)
+	}
+	trailer := &archiveTrailerReader{r: r}
+	tr := tar.NewReader(trailer)
+	for {
+		header, err := tr.Next()
+		if xerrors.Is(err, io.EOF) {
+			// The tar reader also returns io.EOF when the stream ends
+			// between entries.
+			if !trailer.complete() {
+				return xerrors.New("read archive: archive is truncated")
+			}
+			return nil
+		}
+		if err != nil {
+			return xerrors.Errorf("read archive: %w", err)
+		}
+		name := filepath.FromSlash(header.Name)
+		if !filepath.IsLocal(name) {
+			return xerrors.Errorf("archive contains a path outside of the directory: %q", header.Name)
+		}
+		p := filepath.Join(root, name)
+		mode := header.FileInfo().Mode().Perm()
+		switch header.Typeflag {
+		case tar.TypeDir:
+			err = os.MkdirAll(p, mode)
+		case tar.TypeReg:
+			err = extractArchiveFile(p, mode, progress.reader(tr))
+		default:
+			continue
+		}
+		if err != nil {
+			return xerrors.Errorf("extract %q: %w", header.Name, err)
+		}
+	}
+}
+
+// archiveTrailerReader counts the zero bytes at the end of what has been read
+// from r, so the end-of-archive blocks of a tar archive can be detected.
+type archiveTrailerReader struct {
+	r     io.Reader
+	zeros int
+}
+
+func (t *archiveTrailerReader) Read(p []byte) (int, error) {
+	n, err := t.r.Read(p)
+	for _, b := range p[:n] {
+		if b != 0 {
+			t.zeros = 0
+			continue
+		}
+		t.zeros++
+	}
+	return n, err
+}
+
+// complete returns whether the archive ended with the two zero blocks that
+// mark the end of a tar archive.
+func (t *archiveTrailerReader) complete() bool {
+	return t.zeros >= 2*512
+}
+
+func extractArchiveFile(p string, mode os.FileMode, r io.Reader) error {
+	err := os.MkdirAll(filepath.Dir(p), 0o755)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(p, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	_, err = io.Copy(file, r)
+	if err != nil {
+		return err
+	}
+	return file.Close()
+}
+
+// transferProgress reports the progress of a copy on stderr. The line is
+// only updated in place when stderr is a terminal, otherwise a summary is
+// printed when the copy finishes.
+type transferProgress struct {
+	w        io.Writer
+	tty      bool
+	name     string
+	total    int64
+	done     int64
+	started  time.Time
+	reported time.Time
+}
+
+func newTransferProgress(inv *clibase.Invocation) *transferProgress {
+	return &transferProgress{
+		w:   inv.Stderr,
+		tty: isTTYErr(inv),
+	}
+}
+
+// start begins reporting a transfer. A total of zero means the size is
+// unknown.
+func (p *transferProgress) start(name string, total int64) {
+	p.name = name
+	p.total = total
+	p.started = time.Now()
+}
+
+func (p *transferProgress) add(n int64) {
+	p.done += n
+	if p.tty && time.Since(p.reported) > 100*time.Millisecond {
+		p.reported = time.Now()
+		_, _ = fmt.Fprintf(p.w, "\r\033[K%s", p.line())
+	}
+}
+
+func (p *transferProgress) reader(r io.Reader) io.Reader {
+	return &progressReader{r: r, progress: p}
+}
+
+func (p *transferProgress) finish() {
+	if p.tty {
+		_, _ = fmt.Fprint(p.w, "\r\033[K")
+	}
+	_, _ = fmt.Fprintf(p.w, "%s in %s\n", p.line(), time.Since(p.started).Round(time.Millisecond))
+}
+
+func (p *transferProgress) line() string {
+	if p.total <= 0 {
+		return fmt.Sprintf("%s  %s", p.name, formatByteSize(p.done))
+	}
+	return fmt.Sprintf("%s  %s / %s  %d%%", p.name, formatByteSize(p.done), formatByteSize(p.total), p.done*100/p.total)
+}
+
+type progressReader struct {
+	r        io.Reader
+	progress *transferProgress
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.progress.add(int64(n))
+	return n, err
+}
+
+// formatByteSize formats a size in bytes with binary units, e.g. 1.5 MiB.
+func formatByteSize(size int64) string {
+	const unit = 1024
+	if size < unit {
+		return fmt.Sprintf("%d B", size)
+	}
+	div, exp := int64(unit), 0
+	for n := size / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(size)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"archive/tar"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestExtractDirectoryArchive(t *testing.T) {
+	t.Parallel()
+
+	var archive bytes.Buffer
+	tw := tar.NewWriter(&archive)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/", Typeflag: tar.TypeDir, Mode: 0o755}))
+	content := strings.Repeat("a", 600)
+	require.NoError(t, tw.WriteHeader(&tar.Header{Name: "sub/a.txt", Typeflag: tar.TypeReg, Mode: 0o644, Size: int64(len(content))}))
+	_, err := tw.Write([]byte(content))
+	require.NoError(t, err)
+	require.NoError(t, tw.Flush())
+	// Everything before the end-of-archive blocks.
+	entries := bytes.Clone(archive.Bytes())
+	require.NoError(t, tw.Close())
+
+	extract := func(t *testing.T, data []byte) (string, error) {
+		root := filepath.Join(t.TempDir(), "root")
+		return root, extractDirectoryArchive(bytes.NewReader(data), root, &transferProgress{w: io.Discard})
+	}
+
+	t.Run("Complete", func(t *testing.T) {
+		t.Parallel()
+		root, err := extract(t, archive.Bytes())
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(root, "sub", "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, content, string(data))
+	})
+
+	t.Run("TruncatedBetweenEntries", func(t *testing.T) {
+		t.Parallel()
+		_, err := extract(t, entries)
+		require.ErrorContains(t, err, "archive is truncated")
+	})
+
+	t.Run("TruncatedInEntry", func(t *testing.T) {
+		t.Parallel()
+		_, err := extract(t, entries[:len(entries)-len(content)])
+		require.ErrorIs(t, err, io.ErrUnexpectedEOF)
+	})
+}
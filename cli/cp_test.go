@@ -0,0 +1,99 @@
+package cli_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestCp(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	// The agent runs on this machine, so the workspace paths are local too.
+	run := func(t *testing.T, args ...string) error {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		inv, root := clitest.New(t, append([]string{"cp"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		return inv.WithContext(ctx).Run()
+	}
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		src := filepath.Join(dir, "src.txt")
+		require.NoError(t, os.WriteFile(src, []byte("hello world"), 0o600))
+		remoteDir := filepath.Join(dir, "remote")
+		require.NoError(t, os.Mkdir(remoteDir, 0o755))
+
+		// Copying into a directory keeps the name.
+		err := run(t, src, workspace.Name+":"+remoteDir)
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(remoteDir, "src.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(data))
+
+		// Resume a download that stopped halfway.
+		dst := filepath.Join(dir, "dst.txt")
+		require.NoError(t, os.WriteFile(dst, []byte("hello"), 0o600))
+		err = run(t, "--resume", workspace.Name+":"+filepath.Join(remoteDir, "src.txt"), dst)
+		require.NoError(t, err)
+		data, err = os.ReadFile(dst)
+		require.NoError(t, err)
+		require.Equal(t, "hello world", string(data))
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		src := filepath.Join(dir, "src")
+		require.NoError(t, os.MkdirAll(filepath.Join(src, "sub"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(src, "sub", "a.txt"), []byte("a"), 0o600))
+
+		remote := filepath.Join(dir, "remote")
+		err := run(t, src, workspace.Name+":"+remote)
+		require.NoError(t, err)
+		data, err := os.ReadFile(filepath.Join(remote, "sub", "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(data))
+
+		local := filepath.Join(dir, "local")
+		err = run(t, workspace.Name+":"+remote, local)
+		require.NoError(t, err)
+		data, err = os.ReadFile(filepath.Join(local, "sub", "a.txt"))
+		require.NoError(t, err)
+		require.Equal(t, "a", string(data))
+	})
+
+	t.Run("NoWorkspace", func(t *testing.T) {
+		t.Parallel()
+
+		err := run(t, "a.txt", "b.txt")
+		require.ErrorContains(t, err, "exactly one of the source and destination")
+	})
+}
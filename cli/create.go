@@ -323,6 +323,12 @@ PromptParamLoop:
 	richParameters := make([]codersdk.WorkspaceBuildParameter, 0)
 PromptRichParamLoop:
 	for _, templateVersionParameter := range templateVersionParameters {
+		// Ephemeral parameters are only passed to start builds with
+		// --build-option, so the default value applies here.
+		if templateVersionParameter.Ephemeral {
+			continue
+		}
+
 		if !disclaimerPrinted {
 			_, _ = fmt.Fprintln(inv.Stdout, cliui.Styles.Paragraph.Render("This template has customizable parameters. Values can be changed after create, but may have unintended side effects (like data loss).")+"\r\n")
 			disclaimerPrinted = true
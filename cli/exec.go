@@ -0,0 +1,169 @@
+package cli
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+// execOutput is printed by exec when --json is set.
+type execOutput struct {
+	Stdout         string `json:"stdout"`
+	Stderr         string `json:"stderr"`
+	ExitCode       int    `json:"exit_code"`
+	DurationMillis int64  `json:"duration_ms"`
+	TimedOut       bool   `json:"timed_out"`
+}
+
+// exitError makes the CLI exit with the given code without printing an
+// error.
+type exitError struct {
+	code int
+}
+
+func (e *exitError) Error() string {
+	return fmt.Sprintf("exit code %d", e.code)
+}
+
+func (r *RootCmd) exec() *clibase.Cmd {
+	var (
+		env     []string
+		timeout time.Duration
+		jsonOut bool
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "exec <workspace> -- <command>",
+		Short:       "Run a command in a workspace without a terminal",
+		Long: "The command is run with the shell of the workspace user. Stdin is forwarded\n" +
+			"unless it's a terminal, and the CLI exits with the exit code of the command.\n" + formatExamples(
+			example{
+				Description: "Run a command with an extra environment variable",
+				Command:     "coder exec --env DEBUG=1 my-workspace -- make test",
+			},
+			example{
+				Description: "Pipe a file into a command and print the result as JSON",
+				Command:     "coder exec --json my-workspace -- wc -l < main.go",
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireRangeArgs(2, -1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			req := codersdk.WorkspaceAgentExecRequest{
+				Command:       strings.Join(inv.Args[1:], " "),
+				Env:           map[string]string{},
+				TimeoutMillis: timeout.Milliseconds(),
+			}
+			for _, kv := range env {
+				k, v, ok := strings.Cut(kv, "=")
+				if !ok || k == "" {
+					return xerrors.Errorf("invalid environment variable %q, must be KEY=VALUE", kv)
+				}
+				req.Env[k] = v
+			}
+
+			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, inv, client, codersdk.Me, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			err = cliui.Agent(ctx, inv.Stderr, cliui.AgentOptions{
+				WorkspaceName: workspace.Name,
+				Fetch: func(ctx context.Context) (codersdk.WorkspaceAgent, error) {
+					return client.WorkspaceAgent(ctx, workspaceAgent.ID)
+				},
+			})
+			if err != nil && !xerrors.Is(err, cliui.AgentStartError) {
+				return xerrors.Errorf("await agent: %w", err)
+			}
+			logger, ok := LoggerFromContext(ctx)
+			if !ok {
+				logger = slog.Make(sloghuman.Sink(inv.Stderr))
+			}
+			if r.verbose {
+				logger = logger.Leveled(slog.LevelDebug)
+			}
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{
+				Logger: logger,
+			})
+			if err != nil {
+				return xerrors.Errorf("dial workspace agent: %w", err)
+			}
+			defer conn.Close()
+			if !conn.AwaitReachable(ctx) {
+				return xerrors.Errorf("workspace agent not reachable: %w", ctx.Err())
+			}
+
+			var stdin io.Reader
+			if !isTTY(inv) {
+				stdin = inv.Stdin
+			}
+			stdout, stderr := inv.Stdout, inv.Stderr
+			var stdoutBuf, stderrBuf bytes.Buffer
+			if jsonOut {
+				stdout, stderr = &stdoutBuf, &stderrBuf
+			}
+			res, err := conn.Exec(ctx, req, stdin, stdout, stderr)
+			if err != nil {
+				return xerrors.Errorf("exec: %w", err)
+			}
+
+			if jsonOut {
+				enc := json.NewEncoder(inv.Stdout)
+				enc.SetIndent("", "  ")
+				err = enc.Encode(execOutput{
+					Stdout:         stdoutBuf.String(),
+					Stderr:         stderrBuf.String(),
+					ExitCode:       res.ExitCode,
+					DurationMillis: res.Duration.Milliseconds(),
+					TimedOut:       res.TimedOut,
+				})
+				if err != nil {
+					return xerrors.Errorf("write json: %w", err)
+				}
+			} else if res.TimedOut {
+				_, _ = fmt.Fprintf(inv.Stderr, "Command timed out after %s.\n", timeout)
+			}
+			if res.ExitCode != 0 {
+				return &exitError{code: res.ExitCode}
+			}
+			return nil
+		},
+	}
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "env",
+			Description: "Set an environment variable for the command, as KEY=VALUE. Can be repeated.",
+			Value:       clibase.StringArrayOf(&env),
+		},
+		{
+			Flag:        "timeout",
+			Description: "Kill the command if it runs for longer than this. Zero means no timeout.",
+			Default:     "0s",
+			Value:       clibase.DurationOf(&timeout),
+		},
+		{
+			Flag:        "json",
+			Description: "Buffer the output and print it, the exit code and the duration as a JSON object.",
+			Value:       clibase.BoolOf(&jsonOut),
+		},
+	}
+	return cmd
+}
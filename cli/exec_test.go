@@ -0,0 +1,87 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestExec(t *testing.T) {
+	t.Parallel()
+	if runtime.GOOS == "windows" {
+		t.Skip("the commands below use a POSIX shell")
+	}
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent").Leveled(slog.LevelDebug),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	run := func(t *testing.T, stdin string, args ...string) (string, string, error) {
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		inv, root := clitest.New(t, append([]string{"exec"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		var stdout, stderr bytes.Buffer
+		inv.Stdin = strings.NewReader(stdin)
+		inv.Stdout = &stdout
+		inv.Stderr = &stderr
+		err := inv.WithContext(ctx).Run()
+		return stdout.String(), stderr.String(), err
+	}
+
+	t.Run("Stream", func(t *testing.T) {
+		t.Parallel()
+
+		stdout, stderr, err := run(t, "input", "--env", "GREETING=hi", workspace.Name, "--", "cat;", "echo", "$GREETING", ">&2")
+		require.NoError(t, err)
+		require.Equal(t, "input", stdout)
+		require.Contains(t, stderr, "hi\n")
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		stdout, _, err := run(t, "", "--json", workspace.Name, "--", "echo", "out;", "echo", "err", ">&2;", "exit", "2")
+		require.ErrorContains(t, err, "exit code 2")
+
+		var out struct {
+			Stdout   string `json:"stdout"`
+			Stderr   string `json:"stderr"`
+			ExitCode int    `json:"exit_code"`
+			TimedOut bool   `json:"timed_out"`
+		}
+		require.NoError(t, json.Unmarshal([]byte(stdout), &out))
+		require.Equal(t, "out\n", out.Stdout)
+		require.Equal(t, "err\n", out.Stderr)
+		require.Equal(t, 2, out.ExitCode)
+		require.False(t, out.TimedOut)
+	})
+
+	t.Run("InvalidEnv", func(t *testing.T) {
+		t.Parallel()
+
+		_, _, err := run(t, "", "--env", "NOVALUE", workspace.Name, "--", "true")
+		require.ErrorContains(t, err, "must be KEY=VALUE")
+	})
+}
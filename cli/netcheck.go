@@ -0,0 +1,402 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jedib0t/go-pretty/v6/table"
+	"golang.org/x/xerrors"
+	"tailscale.com/types/opt"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/tailnet"
+)
+
+// diagnosePings is the number of pings sent to find out whether a direct
+// connection can be established.
+const diagnosePings = 5
+
+func (r *RootCmd) netcheck() *clibase.Cmd {
+	var timeout time.Duration
+	formatter := cliui.NewOutputFormatter(
+		&connDiagnosticsFormat{},
+		cliui.JSONFormat(),
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "netcheck <workspace>",
+		Short:       "Diagnose the network connection to a workspace",
+		Long: "Reports the STUN results and NAT type of this machine, the latency to each DERP region, the endpoints " +
+			"reported by the workspace agent and whether a direct connection was established. Attach the JSON output " +
+			"to support tickets.\n\n" + formatExamples(
+			example{
+				Description: "Diagnose why connections to a workspace are relayed through DERP",
+				Command:     "coder netcheck my-workspace",
+			},
+			example{
+				Description: "Write the diagnostics as JSON",
+				Command:     "coder netcheck my-workspace -o json > netcheck.json",
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			_, workspaceAgent, err := getWorkspaceAndAgent(ctx, inv, client, codersdk.Me, inv.Args[0])
+			if err != nil {
+				return err
+			}
+
+			var logger slog.Logger
+			if r.verbose {
+				logger = slog.Make(sloghuman.Sink(inv.Stderr)).Leveled(slog.LevelDebug)
+			}
+			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, &codersdk.DialWorkspaceAgentOptions{Logger: logger})
+			if err != nil {
+				return err
+			}
+			defer conn.Close()
+
+			cliui.Infof(inv.Stderr, "Checking the connection to the workspace...\n")
+			diags := diagnoseAgentConn(ctx, logger, conn, timeout)
+			out, err := formatter.Format(ctx, diags)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
+		},
+	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:          "timeout",
+			FlagShorthand: "t",
+			Default:       "10s",
+			Description:   "Specifies how long to wait for the workspace to become reachable.",
+			Value:         clibase.DurationOf(&timeout),
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+type connDiagnostics struct {
+	Client      clientDiagnostics       `json:"client"`
+	Agent       agentDiagnostics        `json:"agent"`
+	DERPRegions []derpRegionDiagnostics `json:"derp_regions"`
+	Connection  connectionDiagnostics   `json:"connection"`
+}
+
+// clientDiagnostics are the netcheck results of this machine.
+type clientDiagnostics struct {
+	UDP                   bool     `json:"udp"`
+	IPv4                  bool     `json:"ipv4"`
+	IPv6                  bool     `json:"ipv6"`
+	GlobalV4              string   `json:"global_v4"`
+	GlobalV6              string   `json:"global_v6"`
+	NATType               string   `json:"nat_type"`
+	MappingVariesByDestIP opt.Bool `json:"mapping_varies_by_dest_ip"`
+	HairPinning           opt.Bool `json:"hair_pinning"`
+	PreferredDERP         int      `json:"preferred_derp"`
+	Endpoints             []string `json:"endpoints"`
+	Error                 string   `json:"error,omitempty"`
+}
+
+// agentDiagnostics is the node the agent reported through the coordinator.
+type agentDiagnostics struct {
+	PreferredDERP       int                `json:"preferred_derp"`
+	Endpoints           []string           `json:"endpoints"`
+	DERPLatency         map[string]float64 `json:"derp_latency"`
+	DERPForcedWebsocket map[int]string     `json:"derp_forced_websockets"`
+	Error               string             `json:"error,omitempty"`
+}
+
+type derpRegionDiagnostics struct {
+	ID   int    `json:"id"`
+	Code string `json:"code"`
+	Name string `json:"name"`
+	// Latencies are nil if the region couldn't be reached.
+	ClientLatencyMS *float64 `json:"client_latency_ms"`
+	AgentLatencyMS  *float64 `json:"agent_latency_ms"`
+}
+
+type connectionDiagnostics struct {
+	DirectAttempted bool `json:"direct_attempted"`
+	// Reason explains why a direct connection wasn't attempted or
+	// established.
+	Reason     string  `json:"reason,omitempty"`
+	P2P        bool    `json:"p2p"`
+	Endpoint   string  `json:"endpoint,omitempty"`
+	DERPRegion int     `json:"derp_region,omitempty"`
+	LatencyMS  float64 `json:"latency_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// diagnoseAgentConn pings the agent until a direct connection is established
+// or diagnosePings pings were sent, runs a netcheck and collects the node the
+// agent reported.
+func diagnoseAgentConn(ctx context.Context, logger slog.Logger, conn *codersdk.WorkspaceAgentConn, timeout time.Duration) connDiagnostics {
+	var diags connDiagnostics
+
+	reachableCtx, cancel := context.WithTimeout(ctx, timeout)
+	reachable := conn.AwaitReachable(reachableCtx)
+	cancel()
+	if !reachable {
+		diags.Connection.Error = "the workspace agent isn't reachable"
+	} else {
+		for i := 0; i < diagnosePings; i++ {
+			if i > 0 {
+				select {
+				case <-ctx.Done():
+				case <-time.After(time.Second):
+				}
+			}
+			pingCtx, cancel := context.WithTimeout(ctx, timeout)
+			dur, p2p, pong, err := conn.Ping(pingCtx)
+			cancel()
+			if err != nil {
+				diags.Connection.Error = err.Error()
+				continue
+			}
+			diags.Connection.Error = ""
+			diags.Connection.P2P = p2p
+			diags.Connection.Endpoint = pong.Endpoint
+			diags.Connection.DERPRegion = pong.DERPRegionID
+			diags.Connection.LatencyMS = float64(dur.Microseconds()) / 1000
+			if p2p {
+				break
+			}
+		}
+	}
+
+	derpMap := conn.DERPMap()
+	report, err := tailnet.Netcheck(ctx, logger, derpMap)
+	if err != nil {
+		diags.Client.Error = err.Error()
+	} else {
+		diags.Client.UDP = report.UDP
+		diags.Client.IPv4 = report.IPv4
+		diags.Client.IPv6 = report.IPv6
+		diags.Client.GlobalV4 = report.GlobalV4
+		diags.Client.GlobalV6 = report.GlobalV6
+		diags.Client.MappingVariesByDestIP = report.MappingVariesByDestIP
+		diags.Client.HairPinning = report.HairPinning
+		diags.Client.PreferredDERP = report.PreferredDERP
+	}
+	diags.Client.NATType = tailnet.NATType(report)
+	diags.Client.Endpoints = conn.Node().Endpoints
+
+	agentNode, ok := conn.PeerNode(codersdk.WorkspaceAgentIP)
+	if ok {
+		diags.Agent.PreferredDERP = agentNode.PreferredDERP
+		diags.Agent.Endpoints = agentNode.Endpoints
+		diags.Agent.DERPLatency = agentNode.DERPLatency
+		diags.Agent.DERPForcedWebsocket = agentNode.DERPForcedWebsocket
+	} else {
+		diags.Agent.Error = "the agent hasn't reported its node"
+	}
+
+	if derpMap != nil {
+		for _, region := range derpMap.Regions {
+			regionDiags := derpRegionDiagnostics{
+				ID:   region.RegionID,
+				Code: region.RegionCode,
+				Name: region.RegionName,
+			}
+			if report != nil {
+				if latency, ok := report.RegionLatency[region.RegionID]; ok {
+					ms := float64(latency.Microseconds()) / 1000
+					regionDiags.ClientLatencyMS = &ms
+				}
+			}
+			if agentNode != nil {
+				for _, family := range []string{"v4", "v6"} {
+					latency, ok := agentNode.DERPLatency[fmt.Sprintf("%d-%s", region.RegionID, family)]
+					if !ok {
+						continue
+					}
+					ms := latency * 1000
+					if regionDiags.AgentLatencyMS == nil || ms < *regionDiags.AgentLatencyMS {
+						regionDiags.AgentLatencyMS = &ms
+					}
+				}
+			}
+			diags.DERPRegions = append(diags.DERPRegions, regionDiags)
+		}
+		sort.Slice(diags.DERPRegions, func(i, j int) bool {
+			return diags.DERPRegions[i].ID < diags.DERPRegions[j].ID
+		})
+	}
+
+	switch {
+	case conn.BlockEndpoints():
+		diags.Connection.Reason = "direct connections are disabled on this client"
+	case agentNode == nil:
+		diags.Connection.Reason = "the agent hasn't reported its node"
+	case len(agentNode.Endpoints) == 0:
+		diags.Connection.Reason = "the agent reported no endpoints, direct connections may be disabled on the agent"
+	default:
+		diags.Connection.DirectAttempted = true
+		switch {
+		case diags.Connection.P2P:
+		case report != nil && !report.UDP:
+			diags.Connection.Reason = "UDP appears to be blocked on this network"
+		case diags.Client.NATType == tailnet.NATTypeHard:
+			diags.Connection.Reason = "this machine is behind a hard NAT, so the agent must be directly reachable"
+		default:
+			diags.Connection.Reason = "the agent couldn't be reached at its endpoints, its network may block UDP"
+		}
+	}
+	return diags
+}
+
+type connDiagnosticsFormat struct{}
+
+var _ cliui.OutputFormat = &connDiagnosticsFormat{}
+
+// ID implements OutputFormat.
+func (*connDiagnosticsFormat) ID() string {
+	return "text"
+}
+
+// AttachOptions implements OutputFormat.
+func (*connDiagnosticsFormat) AttachOptions(_ *clibase.OptionSet) {}
+
+// Format implements OutputFormat.
+func (*connDiagnosticsFormat) Format(_ context.Context, out interface{}) (string, error) {
+	diags, ok := out.(connDiagnostics)
+	if !ok {
+		return "", xerrors.Errorf("expected type %T, got %T", diags, out)
+	}
+
+	yesNo := func(v bool) string {
+		if v {
+			return "yes"
+		}
+		return "no"
+	}
+	optYesNo := func(v opt.Bool) string {
+		b, ok := v.Get()
+		if !ok {
+			return "unknown"
+		}
+		return yesNo(b)
+	}
+	orNone := func(v []string) string {
+		if len(v) == 0 {
+			return "(none)"
+		}
+		return strings.Join(v, ", ")
+	}
+	regionName := func(id int) string {
+		for _, region := range diags.DERPRegions {
+			if region.ID == id {
+				return fmt.Sprintf("%s (%d)", region.Name, id)
+			}
+		}
+		if id == 0 {
+			return "unknown"
+		}
+		return fmt.Sprint(id)
+	}
+	latency := func(ms *float64) string {
+		if ms == nil {
+			return "unreachable"
+		}
+		return fmt.Sprintf("%.1fms", *ms)
+	}
+
+	var sb strings.Builder
+	section := func(title string, rows [][2]string) {
+		tw := cliui.Table()
+		for _, row := range rows {
+			key := ""
+			if row[0] != "" {
+				key = row[0] + ":"
+			}
+			tw.AppendRow(table.Row{key, row[1]})
+		}
+		_, _ = fmt.Fprintf(&sb, "%s\n%s\n\n", cliui.Styles.Bold.Render(title), tw.Render())
+	}
+
+	client := [][2]string{}
+	if diags.Client.Error != "" {
+		client = append(client, [2]string{"Error", diags.Client.Error})
+	}
+	client = append(client,
+		[2]string{"UDP", yesNo(diags.Client.UDP)},
+		[2]string{"IPv4", yesNo(diags.Client.IPv4)},
+		[2]string{"IPv6", yesNo(diags.Client.IPv6)},
+		[2]string{"Public IPv4", diags.Client.GlobalV4},
+		[2]string{"Public IPv6", diags.Client.GlobalV6},
+		[2]string{"NAT type", diags.Client.NATType},
+		[2]string{"Mapping varies by destination", optYesNo(diags.Client.MappingVariesByDestIP)},
+		[2]string{"Hair pinning", optYesNo(diags.Client.HairPinning)},
+		[2]string{"Preferred DERP", regionName(diags.Client.PreferredDERP)},
+		[2]string{"Endpoints", orNone(diags.Client.Endpoints)},
+	)
+	section("Client", client)
+
+	agent := [][2]string{}
+	if diags.Agent.Error != "" {
+		agent = append(agent, [2]string{"Error", diags.Agent.Error})
+	} else {
+		agent = append(agent,
+			[2]string{"Preferred DERP", regionName(diags.Agent.PreferredDERP)},
+			[2]string{"Endpoints", orNone(diags.Agent.Endpoints)},
+		)
+		regionIDs := make([]int, 0, len(diags.Agent.DERPForcedWebsocket))
+		for id := range diags.Agent.DERPForcedWebsocket {
+			regionIDs = append(regionIDs, id)
+		}
+		sort.Ints(regionIDs)
+		for _, id := range regionIDs {
+			agent = append(agent, [2]string{
+				"WebSocket DERP " + regionName(id), diags.Agent.DERPForcedWebsocket[id],
+			})
+		}
+	}
+	section("Agent", agent)
+
+	tw := cliui.Table()
+	tw.AppendHeader(table.Row{"Region", "Client latency", "Agent latency"})
+	for _, region := range diags.DERPRegions {
+		tw.AppendRow(table.Row{regionName(region.ID), latency(region.ClientLatencyMS), latency(region.AgentLatencyMS)})
+	}
+	_, _ = fmt.Fprintf(&sb, "%s\n%s\n\n", cliui.Styles.Bold.Render("DERP regions"), tw.Render())
+
+	connection := [][2]string{
+		{"Direct connection attempted", yesNo(diags.Connection.DirectAttempted)},
+	}
+	switch {
+	case diags.Connection.Error != "":
+		connection = append(connection, [2]string{"Error", diags.Connection.Error})
+	case diags.Connection.P2P:
+		connection = append(connection, [2]string{"Path", "p2p via " + diags.Connection.Endpoint})
+	default:
+		connection = append(connection, [2]string{"Path", "proxied via DERP " + regionName(diags.Connection.DERPRegion)})
+	}
+	if diags.Connection.Error == "" {
+		connection = append(connection, [2]string{"Latency", fmt.Sprintf("%.1fms", diags.Connection.LatencyMS)})
+	}
+	if diags.Connection.Reason != "" {
+		connection = append(connection, [2]string{"Reason", diags.Connection.Reason})
+	}
+	section("Connection", connection)
+
+	return strings.TrimRight(sb.String(), "\n"), nil
+}
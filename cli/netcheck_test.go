@@ -0,0 +1,95 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/pty/ptytest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNetcheck(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(agentToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+
+	t.Run("JSON", func(t *testing.T) {
+		t.Parallel()
+
+		inv, root := clitest.New(t, "netcheck", workspace.Name, "-o", "json")
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		inv.Stdout = &stdout
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		var diags struct {
+			Client struct {
+				UDP       bool     `json:"udp"`
+				NATType   string   `json:"nat_type"`
+				Endpoints []string `json:"endpoints"`
+			} `json:"client"`
+			Agent struct {
+				PreferredDERP int      `json:"preferred_derp"`
+				Endpoints     []string `json:"endpoints"`
+			} `json:"agent"`
+			DERPRegions []struct {
+				ID              int      `json:"id"`
+				ClientLatencyMS *float64 `json:"client_latency_ms"`
+			} `json:"derp_regions"`
+			Connection struct {
+				DirectAttempted bool `json:"direct_attempted"`
+			} `json:"connection"`
+		}
+		require.NoError(t, json.Unmarshal(stdout.Bytes(), &diags), stdout.String())
+		require.True(t, diags.Client.UDP)
+		require.NotEmpty(t, diags.Client.NATType)
+		require.NotZero(t, diags.Agent.PreferredDERP)
+		require.NotEmpty(t, diags.Agent.Endpoints)
+		require.NotEmpty(t, diags.DERPRegions)
+		require.NotNil(t, diags.DERPRegions[0].ClientLatencyMS)
+		require.True(t, diags.Connection.DirectAttempted)
+	})
+
+	t.Run("PingDiagnose", func(t *testing.T) {
+		t.Parallel()
+
+		inv, root := clitest.New(t, "ping", workspace.Name, "-n", "3", "--diagnose")
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+		cmdDone := tGo(t, func() {
+			err := inv.WithContext(ctx).Run()
+			require.NoError(t, err)
+		})
+
+		pty.ExpectMatch("pong from " + workspace.Name)
+		pty.ExpectMatch("NAT type")
+		pty.ExpectMatch("DERP regions")
+		pty.ExpectMatch("Direct connection attempted")
+		<-cmdDone
+	})
+}
@@ -4,7 +4,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"strings"
 
+	"github.com/google/uuid"
+	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
 	"gopkg.in/yaml.v3"
 
@@ -95,3 +98,89 @@ func getWorkspaceBuildParameterValueFromMapOrInput(inv *clibase.Invocation, para
 		Value: parameterValue,
 	}, nil
 }
+
+// buildFlags are the flags for passing ephemeral parameters, which only
+// apply to a single build, when starting a workspace.
+type buildFlags struct {
+	buildOptions         bool
+	buildOptionArguments []string
+}
+
+func (bf *buildFlags) cliOptions() []clibase.Option {
+	return []clibase.Option{
+		{
+			Flag:        "build-option",
+			Env:         "CODER_BUILD_OPTION",
+			Description: `Build option value in the format "name=value". Build options are ephemeral parameters that only apply to this build.`,
+			Value:       clibase.StringArrayOf(&bf.buildOptionArguments),
+		},
+		{
+			Flag:        "build-options",
+			Description: "Prompt for one-time build options defined with ephemeral parameters.",
+			Value:       clibase.BoolOf(&bf.buildOptions),
+		},
+	}
+}
+
+// ephemeralBuildParameters returns the values of the ephemeral parameters of
+// the template version passed with --build-option. Values of the remaining
+// ephemeral parameters are prompted for if --build-options is set.
+func ephemeralBuildParameters(inv *clibase.Invocation, client *codersdk.Client, templateVersionID uuid.UUID, bf buildFlags) ([]codersdk.WorkspaceBuildParameter, error) {
+	if !bf.buildOptions && len(bf.buildOptionArguments) == 0 {
+		return nil, nil
+	}
+
+	templateVersionParameters, err := client.TemplateVersionRichParameters(inv.Context(), templateVersionID)
+	if err != nil {
+		return nil, xerrors.Errorf("get template version rich parameters: %w", err)
+	}
+
+	buildParameters := make([]codersdk.WorkspaceBuildParameter, 0)
+	for _, arg := range bf.buildOptionArguments {
+		name, value, ok := strings.Cut(arg, "=")
+		if !ok {
+			return nil, xerrors.Errorf("build option %q must be in the format \"name=value\"", arg)
+		}
+		i := slices.IndexFunc(templateVersionParameters, func(p codersdk.TemplateVersionParameter) bool {
+			return p.Name == name
+		})
+		if i < 0 {
+			return nil, xerrors.Errorf("build option %q doesn't exist in the template", name)
+		}
+		if !templateVersionParameters[i].Ephemeral {
+			return nil, xerrors.Errorf("parameter %q is not a build option, use \"coder update --always-prompt\" to change it", name)
+		}
+		buildParameters = append(buildParameters, codersdk.WorkspaceBuildParameter{
+			Name:  name,
+			Value: value,
+		})
+	}
+
+	if !bf.buildOptions {
+		return buildParameters, nil
+	}
+	var prompted bool
+	for _, templateVersionParameter := range templateVersionParameters {
+		if !templateVersionParameter.Ephemeral {
+			continue
+		}
+		if slices.ContainsFunc(buildParameters, func(p codersdk.WorkspaceBuildParameter) bool {
+			return p.Name == templateVersionParameter.Name
+		}) {
+			continue
+		}
+		value, err := cliui.RichParameter(inv, templateVersionParameter)
+		if err != nil {
+			return nil, err
+		}
+		buildParameters = append(buildParameters, codersdk.WorkspaceBuildParameter{
+			Name:  templateVersionParameter.Name,
+			Value: value,
+		})
+		prompted = true
+	}
+	if !prompted && len(bf.buildOptionArguments) == 0 {
+		_, _ = fmt.Fprintln(inv.Stdout, cliui.Styles.Warn.Render("This template has no build options."))
+	}
+	return buildParameters, nil
+}
@@ -20,6 +20,7 @@ func (r *RootCmd) ping() *clibase.Cmd {
 		pingNum     int64
 		pingTimeout time.Duration
 		pingWait    time.Duration
+		diagnose    bool
 	)
 
 	client := new(codersdk.Client)
@@ -61,6 +62,7 @@ func (r *RootCmd) ping() *clibase.Cmd {
 			n := 0
 			didP2p := false
 			start := time.Now()
+		pingLoop:
 			for {
 				if n > 0 {
 					time.Sleep(time.Second)
@@ -74,7 +76,7 @@ func (r *RootCmd) ping() *clibase.Cmd {
 					if xerrors.Is(err, context.DeadlineExceeded) {
 						_, _ = fmt.Fprintf(inv.Stdout, "ping to %q timed out \n", workspaceName)
 						if n == int(pingNum) {
-							return nil
+							break pingLoop
 						}
 						continue
 					}
@@ -88,7 +90,7 @@ func (r *RootCmd) ping() *clibase.Cmd {
 
 					_, _ = fmt.Fprintf(inv.Stdout, "ping to %q failed %s\n", workspaceName, err.Error())
 					if n == int(pingNum) {
-						return nil
+						break pingLoop
 					}
 					continue
 				}
@@ -126,9 +128,21 @@ func (r *RootCmd) ping() *clibase.Cmd {
 				)
 
 				if n == int(pingNum) {
-					return nil
+					break pingLoop
 				}
 			}
+
+			if !diagnose {
+				return nil
+			}
+			_, _ = fmt.Fprintln(inv.Stdout)
+			diags := diagnoseAgentConn(ctx, logger, conn, pingTimeout)
+			out, err := (&connDiagnosticsFormat{}).Format(ctx, diags)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
 		},
 	}
 
@@ -153,6 +167,11 @@ func (r *RootCmd) ping() *clibase.Cmd {
 			Description:   "Specifies the number of pings to perform.",
 			Value:         clibase.Int64Of(&pingNum),
 		},
+		{
+			Flag:        "diagnose",
+			Description: "Diagnose the connection after the pings, as with \"coder netcheck\".",
+			Value:       clibase.BoolOf(&diagnose),
+		},
 	}
 	return cmd
 }
@@ -6,12 +6,14 @@ import (
 	"net"
 	"os"
 	"os/signal"
+	"path/filepath"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
 
 	"github.com/pion/udp"
+	gossh "golang.org/x/crypto/ssh"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/agent"
@@ -24,6 +26,9 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 	var (
 		tcpForwards []string // <port>:<port>
 		udpForwards []string // <port>:<port>
+		auto        bool
+		includes    []string
+		excludes    []string
 	)
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
@@ -31,6 +36,14 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 		Short:   "Forward ports from machine to a workspace",
 		Aliases: []string{"tunnel"},
 		Long: formatExamples(
+			example{
+				Description: "Pick listening ports and Unix sockets of the workspace to forward from a list",
+				Command:     "coder port-forward <workspace>",
+			},
+			example{
+				Description: "Forward ports as the workspace starts listening on them, except those of postgres",
+				Command:     "coder port-forward <workspace> --auto --exclude postgres",
+			},
 			example{
 				Description: "Port forward a single TCP port from 1234 in the workspace to port 5678 on your local machine",
 				Command:     "coder port-forward <workspace> --tcp 5678:1234",
@@ -60,7 +73,20 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			if err != nil {
 				return xerrors.Errorf("parse port-forward specs: %w", err)
 			}
-			if len(specs) == 0 {
+			var filter portForwardFilter
+			filter.include, err = parsePortFilters(includes)
+			if err != nil {
+				return xerrors.Errorf("parse --include: %w", err)
+			}
+			filter.exclude, err = parsePortFilters(excludes)
+			if err != nil {
+				return xerrors.Errorf("parse --exclude: %w", err)
+			}
+			if auto && len(specs) > 0 {
+				return xerrors.New("--auto can't be combined with --tcp or --udp")
+			}
+			// Without any ports, listening ports are offered interactively.
+			if len(specs) == 0 && !auto && !isTTY(inv) {
 				err = inv.Command.HelpHandler(inv)
 				if err != nil {
 					return xerrors.Errorf("generate help output: %w", err)
@@ -92,12 +118,36 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 				return xerrors.Errorf("await agent: %w", err)
 			}
 
+			if len(specs) == 0 && !auto {
+				specs, err = selectListeningPorts(inv, client, workspace, workspaceAgent, filter)
+				if err != nil {
+					return err
+				}
+			}
+
 			conn, err := client.DialWorkspaceAgent(ctx, workspaceAgent.ID, nil)
 			if err != nil {
 				return err
 			}
 			defer conn.Close()
 
+			// Unix sockets are forwarded over SSH.
+			var sshClient *gossh.Client
+			for _, spec := range specs {
+				if spec.dialNetwork != "unix" {
+					continue
+				}
+				if !conn.AwaitReachable(ctx) {
+					return xerrors.Errorf("workspace agent not reachable: %w", ctx.Err())
+				}
+				sshClient, err = conn.SSHClient(ctx)
+				if err != nil {
+					return xerrors.Errorf("ssh client: %w", err)
+				}
+				defer sshClient.Close()
+				break
+			}
+
 			// Start all listeners.
 			var (
 				wg                = new(sync.WaitGroup)
@@ -114,7 +164,8 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			defer closeAllListeners()
 
 			for i, spec := range specs {
-				l, err := listenAndPortForward(ctx, inv, conn, wg, spec)
+				_, _ = fmt.Fprintf(inv.Stderr, "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+				l, err := listenAndPortForward(ctx, inv, conn, sshClient, wg, spec)
 				if err != nil {
 					return err
 				}
@@ -143,7 +194,15 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			}()
 
 			conn.AwaitReachable(ctx)
-			_, _ = fmt.Fprintln(inv.Stderr, "Ready!")
+			if auto {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					autoPortForwardListeningPorts(ctx, inv, conn, wg, filter)
+				}()
+			} else {
+				_, _ = fmt.Fprintln(inv.Stderr, "Ready!")
+			}
 			wg.Wait()
 			return closeErr
 		},
@@ -163,14 +222,96 @@ func (r *RootCmd) portForward() *clibase.Cmd {
 			Description: "Forward UDP port(s) from the workspace to the local machine. The UDP connection has TCP-like semantics to support stateful UDP protocols.",
 			Value:       clibase.StringArrayOf(&udpForwards),
 		},
+		{
+			Flag:        "auto",
+			Env:         "CODER_PORT_FORWARD_AUTO",
+			Description: "Forward TCP and UDP ports to the same local port as the workspace starts listening on them, or to a free port if it's in use. Forwards are removed when the ports close.",
+			Value:       clibase.BoolOf(&auto),
+		},
+		{
+			Flag:        "include",
+			Env:         "CODER_PORT_FORWARD_INCLUDE",
+			Description: "Only offer or auto-forward listening ports that match a port (8080), port range (3000-3999) or process name (node, python*).",
+			Value:       clibase.StringArrayOf(&includes),
+		},
+		{
+			Flag:        "exclude",
+			Env:         "CODER_PORT_FORWARD_EXCLUDE",
+			Description: "Don't offer or auto-forward listening ports that match a port (8080), port range (3000-3999) or process name (node, python*).",
+			Value:       clibase.StringArrayOf(&excludes),
+		},
 	}
 
 	return cmd
 }
 
-func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
-	_, _ = fmt.Fprintf(inv.Stderr, "Forwarding '%v://%v' locally to '%v://%v' in the workspace\n", spec.listenNetwork, spec.listenAddress, spec.dialNetwork, spec.dialAddress)
+// selectListeningPorts prompts for the listening ports of the agent to
+// forward. Ports are forwarded to the same local port, and Unix sockets to a
+// socket with the same name in a temporary directory.
+func selectListeningPorts(inv *clibase.Invocation, client *codersdk.Client, workspace codersdk.Workspace, workspaceAgent codersdk.WorkspaceAgent, filter portForwardFilter) ([]portForwardSpec, error) {
+	res, err := client.WorkspaceAgentListeningPorts(inv.Context(), workspaceAgent.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("list listening ports: %w", err)
+	}
+	if len(res.Ports) == 0 {
+		return nil, xerrors.New("no listening ports were found in the workspace, specify them with --tcp or --udp")
+	}
+
+	labels := make([]string, 0, len(res.Ports))
+	specsByLabel := make(map[string]portForwardSpec, len(res.Ports))
+	for _, port := range res.Ports {
+		if !filter.allows(port) {
+			continue
+		}
+		var (
+			label string
+			spec  portForwardSpec
+		)
+		switch port.Network {
+		case "tcp", "udp":
+			label = fmt.Sprintf("%s %d", port.Network, port.Port)
+			spec = portForwardSpec{
+				listenNetwork: port.Network,
+				listenAddress: fmt.Sprintf("127.0.0.1:%d", port.Port),
+				dialNetwork:   port.Network,
+				dialAddress:   fmt.Sprintf("127.0.0.1:%d", port.Port),
+			}
+		case "unix":
+			label = fmt.Sprintf("unix %s", port.Path)
+			spec = portForwardSpec{
+				listenNetwork: "unix",
+				listenAddress: filepath.Join(os.TempDir(), "coder-port-forward", workspace.Name, filepath.Base(port.Path)),
+				dialNetwork:   "unix",
+				dialAddress:   port.Path,
+			}
+		default:
+			continue
+		}
+		if port.ProcessName != "" {
+			label += fmt.Sprintf(" (%s)", port.ProcessName)
+		}
+		labels = append(labels, label)
+		specsByLabel[label] = spec
+	}
+
+	if len(labels) == 0 {
+		return nil, xerrors.New("no listening ports matched the filters")
+	}
+	selected, err := cliui.MultiSelect(inv, labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(selected) == 0 {
+		return nil, xerrors.New("no ports were selected")
+	}
+	specs := make([]portForwardSpec, 0, len(selected))
+	for _, label := range selected {
+		specs = append(specs, specsByLabel[label])
+	}
+	return specs, nil
+}
 
+func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, sshClient *gossh.Client, wg *sync.WaitGroup, spec portForwardSpec) (net.Listener, error) {
 	var (
 		l   net.Listener
 		err error
@@ -195,6 +336,14 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 			IP:   net.ParseIP(host),
 			Port: portInt,
 		})
+	case "unix":
+		err = os.MkdirAll(filepath.Dir(spec.listenAddress), 0o700)
+		if err != nil {
+			return nil, xerrors.Errorf("create socket directory: %w", err)
+		}
+		// Remove the socket of a previous run.
+		_ = os.Remove(spec.listenAddress)
+		l, err = net.Listen(spec.listenNetwork, spec.listenAddress)
 	default:
 		return nil, xerrors.Errorf("unknown listen network %q", spec.listenNetwork)
 	}
@@ -219,7 +368,15 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 
 			go func(netConn net.Conn) {
 				defer netConn.Close()
-				remoteConn, err := conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				var (
+					remoteConn net.Conn
+					err        error
+				)
+				if spec.dialNetwork == "unix" {
+					remoteConn, err = sshClient.Dial(spec.dialNetwork, spec.dialAddress)
+				} else {
+					remoteConn, err = conn.DialContext(ctx, spec.dialNetwork, spec.dialAddress)
+				}
 				if err != nil {
 					_, _ = fmt.Fprintf(inv.Stderr, "Failed to dial '%v://%v' in workspace: %s\n", spec.dialNetwork, spec.dialAddress, err)
 					return
@@ -235,10 +392,10 @@ func listenAndPortForward(ctx context.Context, inv *clibase.Invocation, conn *co
 }
 
 type portForwardSpec struct {
-	listenNetwork string // tcp, udp
+	listenNetwork string // tcp, udp, unix
 	listenAddress string // <ip>:<port> or path
 
-	dialNetwork string // tcp, udp
+	dialNetwork string // tcp, udp, unix
 	dialAddress string // <ip>:<port> or path
 }
 
@@ -6,6 +6,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/codersdk"
 )
 
 func Test_parsePortForwards(t *testing.T) {
@@ -88,3 +90,65 @@ func Test_parsePortForwards(t *testing.T) {
 		})
 	}
 }
+
+func Test_portForwardFilter(t *testing.T) {
+	t.Parallel()
+
+	include, err := parsePortFilters([]string{"3000-3999,8080", "python*"})
+	require.NoError(t, err)
+	exclude, err := parsePortFilters([]string{"3306"})
+	require.NoError(t, err)
+	filter := portForwardFilter{include: include, exclude: exclude}
+
+	tests := []struct {
+		name string
+		port codersdk.WorkspaceAgentListeningPort
+		want bool
+	}{
+		{
+			name: "Port in range",
+			port: codersdk.WorkspaceAgentListeningPort{Network: "tcp", Port: 3000, ProcessName: "node"},
+			want: true,
+		},
+		{
+			name: "Single port",
+			port: codersdk.WorkspaceAgentListeningPort{Network: "udp", Port: 8080},
+			want: true,
+		},
+		{
+			name: "Process name",
+			port: codersdk.WorkspaceAgentListeningPort{Network: "tcp", Port: 5000, ProcessName: "python3"},
+			want: true,
+		},
+		{
+			name: "Not included",
+			port: codersdk.WorkspaceAgentListeningPort{Network: "tcp", Port: 5000, ProcessName: "node"},
+			want: false,
+		},
+		{
+			name: "Excluded",
+			port: codersdk.WorkspaceAgentListeningPort{Network: "tcp", Port: 3306, ProcessName: "mysqld"},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, filter.allows(tt.port))
+		})
+	}
+
+	t.Run("NoFilters", func(t *testing.T) {
+		t.Parallel()
+		require.True(t, portForwardFilter{}.allows(codersdk.WorkspaceAgentListeningPort{Network: "tcp", Port: 8080}))
+	})
+
+	t.Run("BadFilters", func(t *testing.T) {
+		t.Parallel()
+		_, err := parsePortFilters([]string{"9000-8000"})
+		require.Error(t, err)
+		_, err = parsePortFilters([]string{"node["})
+		require.Error(t, err)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"strings"
 	"sync"
 	"testing"
 
@@ -273,6 +274,49 @@ func TestPortForward(t *testing.T) {
 		err := <-errC
 		require.ErrorIs(t, err, context.Canceled)
 	})
+
+	t.Run("Auto", func(t *testing.T) {
+		t.Parallel()
+
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err, "create TCP listener")
+		p1 := setupTestListener(t, l)
+
+		// Only forward the test listener, as the agent may see other ports
+		// of this machine.
+		inv, root := clitest.New(t, "port-forward", workspace.Name, "--auto", "--include", p1)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+		inv.Stderr = pty.Output()
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		errC := make(chan error)
+		go func() {
+			errC <- inv.WithContext(ctx).Run()
+		}()
+
+		// The port is in use locally, since the agent runs on the same
+		// machine, so it's forwarded to a free port.
+		pty.ExpectMatch(p1)
+		line := pty.ReadLine(ctx)
+		fields := strings.Fields(line)
+		require.NotEmpty(t, fields, "table row %q", line)
+		localAddress := fields[len(fields)-1]
+
+		d := net.Dialer{Timeout: testutil.WaitShort}
+		c1, err := d.DialContext(ctx, "tcp", localAddress)
+		require.NoError(t, err, "open connection to 'local' listener")
+		defer c1.Close()
+		testDial(t, c1)
+
+		// The forward is removed when the port closes.
+		_ = l.Close()
+		pty.ExpectMatch("Waiting for ports to forward...")
+
+		cancel()
+		err = <-errC
+		require.ErrorIs(t, err, context.Canceled)
+	})
 }
 
 // runAgent creates a fake workspace and starts an agent locally for that
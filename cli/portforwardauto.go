@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+// autoPortForwardInterval is how often the listening ports of the agent are
+// checked for changes with --auto. The agent caches the ports for a second.
+const autoPortForwardInterval = 2 * time.Second
+
+// portFilter matches listening ports by port, port range or process name.
+type portFilter struct {
+	start, end uint16
+	// process is a glob matched against the process name.
+	process string
+}
+
+func (f portFilter) matches(port codersdk.WorkspaceAgentListeningPort) bool {
+	if f.process != "" {
+		ok, _ := path.Match(f.process, port.ProcessName)
+		return ok
+	}
+	return port.Network != "unix" && port.Port >= f.start && port.Port <= f.end
+}
+
+// parsePortFilters parses comma-separated ports (8080), port ranges
+// (3000-3999) and process names (node, python*).
+func parsePortFilters(specs []string) ([]portFilter, error) {
+	filters := []portFilter{}
+	for _, specEntry := range specs {
+		for _, spec := range strings.Split(specEntry, ",") {
+			spec = strings.TrimSpace(spec)
+			if spec == "" {
+				continue
+			}
+			first, _, _ := strings.Cut(spec, "-")
+			if _, err := strconv.ParseUint(first, 10, 16); err != nil {
+				if _, err := path.Match(spec, ""); err != nil {
+					return nil, xerrors.Errorf("invalid process name pattern %q: %w", spec, err)
+				}
+				filters = append(filters, portFilter{process: spec})
+				continue
+			}
+			if !strings.Contains(spec, "-") {
+				port, err := parsePort(spec)
+				if err != nil {
+					return nil, err
+				}
+				filters = append(filters, portFilter{start: port, end: port})
+				continue
+			}
+			ports, err := parsePortRange(spec)
+			if err != nil {
+				return nil, err
+			}
+			filters = append(filters, portFilter{start: ports[0], end: ports[len(ports)-1]})
+		}
+	}
+	return filters, nil
+}
+
+// portForwardFilter decides which listening ports are forwarded without
+// being specified explicitly.
+type portForwardFilter struct {
+	include []portFilter
+	exclude []portFilter
+}
+
+// allows returns whether the port matches an include filter, or there are
+// none, and matches no exclude filter.
+func (f portForwardFilter) allows(port codersdk.WorkspaceAgentListeningPort) bool {
+	matchesAny := func(filters []portFilter) bool {
+		for _, filter := range filters {
+			if filter.matches(port) {
+				return true
+			}
+		}
+		return false
+	}
+	if len(f.include) > 0 && !matchesAny(f.include) {
+		return false
+	}
+	return !matchesAny(f.exclude)
+}
+
+type autoPortForward struct {
+	port     codersdk.WorkspaceAgentListeningPort
+	listener net.Listener
+	local    string
+	err      error
+}
+
+type autoPortForwardRow struct {
+	Network string `table:"network"`
+	Port    uint16 `table:"port,default_sort"`
+	Process string `table:"process"`
+	Local   string `table:"local address"`
+}
+
+// autoPortForwardListeningPorts forwards the TCP and UDP ports the agent is
+// listening on until the context is canceled. New ports are forwarded to the
+// same local port if it's free, and forwards are removed when the ports
+// close. A table of the forwards is printed whenever they change.
+func autoPortForwardListeningPorts(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, wg *sync.WaitGroup, filter portForwardFilter) {
+	forwards := map[string]*autoPortForward{}
+	defer func() {
+		for _, forward := range forwards {
+			if forward.listener != nil {
+				_ = forward.listener.Close()
+			}
+		}
+	}()
+
+	ticker := time.NewTicker(autoPortForwardInterval)
+	defer ticker.Stop()
+	printedLines := 0
+	first := true
+	for {
+		res, err := conn.ListeningPorts(ctx)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			_, _ = fmt.Fprintf(inv.Stderr, "Failed to list listening ports: %s\n", err)
+		}
+
+		changed := first
+		first = false
+		current := map[string]struct{}{}
+		for _, port := range res.Ports {
+			if port.Network != "tcp" && port.Network != "udp" {
+				continue
+			}
+			if !filter.allows(port) {
+				continue
+			}
+			key := fmt.Sprintf("%s:%d", port.Network, port.Port)
+			current[key] = struct{}{}
+			previous, ok := forwards[key]
+			if ok && previous.listener != nil {
+				continue
+			}
+			// Forwards that failed are retried, since the local port may
+			// have been busy only briefly.
+			forward := &autoPortForward{port: port}
+			forward.listener, forward.local, forward.err = listenAutoPortForward(ctx, inv, conn, wg, port)
+			forwards[key] = forward
+			if !ok || forward.err == nil || forward.err.Error() != previous.err.Error() {
+				changed = true
+			}
+		}
+		// Ports aren't removed if listing failed, as they may still be open.
+		if err == nil {
+			for key, forward := range forwards {
+				if _, ok := current[key]; ok {
+					continue
+				}
+				if forward.listener != nil {
+					_ = forward.listener.Close()
+				}
+				delete(forwards, key)
+				changed = true
+			}
+		}
+
+		if changed {
+			printedLines = printAutoPortForwards(inv, forwards, printedLines)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// listenAutoPortForward forwards the port to the same local port, or to a
+// free port if it's in use.
+func listenAutoPortForward(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, wg *sync.WaitGroup, port codersdk.WorkspaceAgentListeningPort) (net.Listener, string, error) {
+	spec := portForwardSpec{
+		listenNetwork: port.Network,
+		listenAddress: fmt.Sprintf("127.0.0.1:%d", port.Port),
+		dialNetwork:   port.Network,
+		dialAddress:   fmt.Sprintf("127.0.0.1:%d", port.Port),
+	}
+	l, err := listenAndPortForward(ctx, inv, conn, nil, wg, spec)
+	if err != nil {
+		spec.listenAddress = "127.0.0.1:0"
+		l, err = listenAndPortForward(ctx, inv, conn, nil, wg, spec)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return l, l.Addr().String(), nil
+}
+
+// printAutoPortForwards prints a table of the forwards. On a terminal, the
+// previously printed table is replaced. The number of printed lines is
+// returned.
+func printAutoPortForwards(inv *clibase.Invocation, forwards map[string]*autoPortForward, printedLines int) int {
+	rows := make([]autoPortForwardRow, 0, len(forwards))
+	for _, forward := range forwards {
+		local := forward.local
+		if forward.err != nil {
+			local = fmt.Sprintf("error: %s", forward.err)
+		}
+		rows = append(rows, autoPortForwardRow{
+			Network: forward.port.Network,
+			Port:    forward.port.Port,
+			Process: forward.port.ProcessName,
+			Local:   local,
+		})
+	}
+
+	out := "Waiting for ports to forward..."
+	if len(rows) > 0 {
+		table, err := cliui.DisplayTable(rows, "", nil)
+		if err != nil {
+			out = fmt.Sprintf("Failed to render table: %s", err)
+		} else {
+			out = table
+		}
+	}
+	if isTTYOut(inv) && printedLines > 0 {
+		// Move the cursor to the start of the previous table and clear
+		// everything below it.
+		_, _ = fmt.Fprintf(inv.Stdout, "\033[%dA\033[J", printedLines)
+	}
+	_, _ = fmt.Fprintln(inv.Stdout, out)
+	return strings.Count(out, "\n") + 1
+}
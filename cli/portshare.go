@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) port() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Annotations: workspaceCommand,
+		Use:         "port",
+		Short:       "Share listening ports of a workspace with other users",
+		Long: "Shared ports are accessed with port URLs on the wildcard access URL, e.g.\n" +
+			"https://8080--main--my-workspace--me.apps.example.com. The template limits\n" +
+			"the most permissive level ports can be shared at.",
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.portShares(),
+			r.portShare(),
+			r.portUnshare(),
+		},
+	}
+	return cmd
+}
+
+type portShareRow struct {
+	Agent      string `table:"agent,default_sort"`
+	Port       int32  `table:"port"`
+	ShareLevel string `table:"share level"`
+	URL        string `table:"url"`
+}
+
+func (r *RootCmd) portShares() *clibase.Cmd {
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "list <workspace>",
+		Short: "List the shared ports of a workspace",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			res, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+			if err != nil {
+				return xerrors.Errorf("list port shares: %w", err)
+			}
+			if len(res.Shares) == 0 {
+				cliui.Infof(inv.Stderr, "No ports are shared in this workspace.")
+				return nil
+			}
+			appHost, err := client.AppHost(ctx)
+			if err != nil {
+				return xerrors.Errorf("get app host: %w", err)
+			}
+
+			rows := make([]portShareRow, 0, len(res.Shares))
+			for _, share := range res.Shares {
+				rows = append(rows, portShareRow{
+					Agent:      share.AgentName,
+					Port:       share.Port,
+					ShareLevel: string(share.ShareLevel),
+					URL:        portShareURL(client, appHost.Host, workspace, share),
+				})
+			}
+			out, err := cliui.DisplayTable(rows, "", nil)
+			if err != nil {
+				return xerrors.Errorf("render table: %w", err)
+			}
+			_, err = fmt.Fprintln(inv.Stdout, out)
+			return err
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) portShare() *clibase.Cmd {
+	var (
+		agentName  string
+		shareLevel string
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "share <workspace> <port>",
+		Short: "Share a listening port of a workspace",
+		Long: formatExamples(
+			example{
+				Description: "Share port 8080 with every signed in user",
+				Command:     "coder port share my-workspace 8080",
+			},
+			example{
+				Description: "Share port 3000 of the \"frontend\" agent with anyone",
+				Command:     "coder port share --agent frontend --level public my-workspace 3000",
+			},
+		),
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+			port, err := parseSharedPort(inv.Args[1])
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			agentName, err = portShareAgentName(workspace, agentName)
+			if err != nil {
+				return err
+			}
+
+			share, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+				AgentName:  agentName,
+				Port:       port,
+				ShareLevel: codersdk.WorkspaceAppSharingLevel(shareLevel),
+			})
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "Shared port %d of agent %q at the %q level.\n", share.Port, share.AgentName, share.ShareLevel)
+
+			appHost, err := client.AppHost(ctx)
+			if err != nil {
+				return xerrors.Errorf("get app host: %w", err)
+			}
+			if u := portShareURL(client, appHost.Host, workspace, share); u != "" {
+				_, _ = fmt.Fprintf(inv.Stdout, "It's available at %s\n", u)
+			}
+			return nil
+		},
+	}
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "agent",
+			Description: "The agent the port is listening on. Required if the workspace has more than one agent.",
+			Value:       clibase.StringOf(&agentName),
+		},
+		{
+			Flag:        "level",
+			Description: "Who can access the port.",
+			Default:     string(codersdk.WorkspaceAppSharingLevelAuthenticated),
+			Value: clibase.EnumOf(&shareLevel,
+				string(codersdk.WorkspaceAppSharingLevelOwner),
+				string(codersdk.WorkspaceAppSharingLevelAuthenticated),
+				string(codersdk.WorkspaceAppSharingLevelPublic),
+			),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) portUnshare() *clibase.Cmd {
+	var agentName string
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "unshare <workspace> <port>",
+		Short: "Stop sharing a port of a workspace",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+			port, err := parseSharedPort(inv.Args[1])
+			if err != nil {
+				return err
+			}
+			workspace, err := namedWorkspace(ctx, client, inv.Args[0])
+			if err != nil {
+				return err
+			}
+			agentName, err = portShareAgentName(workspace, agentName)
+			if err != nil {
+				return err
+			}
+
+			err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.DeleteWorkspaceAgentPortShareRequest{
+				AgentName: agentName,
+				Port:      port,
+			})
+			if err != nil {
+				return err
+			}
+			_, _ = fmt.Fprintf(inv.Stdout, "Stopped sharing port %d of agent %q.\n", port, agentName)
+			return nil
+		},
+	}
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "agent",
+			Description: "The agent the port is listening on. Required if the workspace has more than one agent.",
+			Value:       clibase.StringOf(&agentName),
+		},
+	}
+	return cmd
+}
+
+func parseSharedPort(s string) (int32, error) {
+	port, err := strconv.ParseUint(s, 10, 16)
+	if err != nil {
+		return 0, xerrors.Errorf("invalid port %q: %w", s, err)
+	}
+	return int32(port), nil
+}
+
+// portShareAgentName returns the agent name to share a port of. The name may
+// only be omitted if the workspace has a single agent.
+func portShareAgentName(workspace codersdk.Workspace, agentName string) (string, error) {
+	if agentName != "" {
+		return agentName, nil
+	}
+	names := []string{}
+	for _, resource := range workspace.LatestBuild.Resources {
+		for _, agent := range resource.Agents {
+			names = append(names, agent.Name)
+		}
+	}
+	switch len(names) {
+	case 0:
+		return "", xerrors.Errorf("workspace %q has no agents", workspace.Name)
+	case 1:
+		return names[0], nil
+	default:
+		return "", xerrors.Errorf("workspace %q has multiple agents, use --agent to pick one of: %s", workspace.Name, strings.Join(names, ", "))
+	}
+}
+
+// portShareURL returns the URL a shared port is accessed at, or an empty
+// string if the deployment has no wildcard access URL.
+func portShareURL(client *codersdk.Client, appHost string, workspace codersdk.Workspace, share codersdk.WorkspaceAgentPortShare) string {
+	if appHost == "" {
+		return ""
+	}
+	subdomain := httpapi.ApplicationURL{
+		AppSlugOrPort: strconv.Itoa(int(share.Port)),
+		AgentName:     share.AgentName,
+		WorkspaceName: workspace.Name,
+		Username:      workspace.OwnerName,
+	}.String()
+	return fmt.Sprintf("%s://%s", client.URL.Scheme, strings.Replace(appHost, "*", subdomain, 1))
+}
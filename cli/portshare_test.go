@@ -0,0 +1,65 @@
+package cli_test
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPortShare(t *testing.T) {
+	t.Parallel()
+
+	client, workspace, _ := setupWorkspaceForAgent(t, func(agents []*proto.Agent) []*proto.Agent {
+		agents[0].Name = "dev"
+		return agents
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	run := func(args ...string) (string, error) {
+		inv, root := clitest.New(t, append([]string{"port"}, args...)...)
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		inv.Stdout = &stdout
+		err := inv.WithContext(ctx).Run()
+		return stdout.String(), err
+	}
+
+	// The template only allows sharing with the owner by default.
+	_, err := run("share", workspace.Name, "8080")
+	require.ErrorContains(t, err, "only allows sharing ports up to")
+
+	_, err = client.UpdateTemplateMeta(ctx, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		Name:              workspace.TemplateName,
+		MaxPortShareLevel: codersdk.WorkspaceAppSharingLevelPublic,
+	})
+	require.NoError(t, err)
+
+	out, err := run("share", "--level", "public", workspace.Name, "8080")
+	require.NoError(t, err)
+	require.Contains(t, out, `Shared port 8080 of agent "dev" at the "public" level.`)
+
+	out, err = run("list", workspace.Name)
+	require.NoError(t, err)
+	require.Contains(t, out, "8080")
+	require.Contains(t, out, "public")
+
+	_, err = run("share", "--agent", "unknown", workspace.Name, "8080")
+	require.ErrorContains(t, err, `Agent "unknown" not found`)
+
+	out, err = run("unshare", workspace.Name, "8080")
+	require.NoError(t, err)
+	require.Contains(t, out, `Stopped sharing port 8080 of agent "dev".`)
+
+	res, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Empty(t, res.Shares)
+}
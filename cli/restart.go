@@ -10,6 +10,7 @@ import (
 )
 
 func (r *RootCmd) restart() *clibase.Cmd {
+	var bflags buildFlags
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
 		Annotations: workspaceCommand,
@@ -19,9 +20,7 @@ func (r *RootCmd) restart() *clibase.Cmd {
 			clibase.RequireNArgs(1),
 			r.InitClient(client),
 		),
-		Options: clibase.OptionSet{
-			cliui.SkipPromptOption(),
-		},
+		Options: append(bflags.cliOptions(), cliui.SkipPromptOption()),
 		Handler: func(inv *clibase.Invocation) error {
 			ctx := inv.Context()
 			out := inv.Stdout
@@ -38,6 +37,10 @@ func (r *RootCmd) restart() *clibase.Cmd {
 			if err != nil {
 				return err
 			}
+			buildParameters, err := ephemeralBuildParameters(inv, client, workspace.LatestBuild.TemplateVersionID, bflags)
+			if err != nil {
+				return err
+			}
 
 			build, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
 				Transition: codersdk.WorkspaceTransitionStop,
@@ -51,7 +54,8 @@ func (r *RootCmd) restart() *clibase.Cmd {
 			}
 
 			build, err = client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
-				Transition: codersdk.WorkspaceTransitionStart,
+				Transition:          codersdk.WorkspaceTransitionStart,
+				RichParameterValues: buildParameters,
 			})
 			if err != nil {
 				return err
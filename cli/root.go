@@ -90,8 +90,12 @@ func (r *RootCmd) Core() []*clibase.Cmd {
 
 		// Workspace Commands
 		r.configSSH(),
+		r.cp(),
+		r.exec(),
 		r.rename(),
+		r.netcheck(),
 		r.ping(),
+		r.port(),
 		r.create(),
 		r.deleteWorkspace(),
 		r.list(),
@@ -136,6 +140,11 @@ func (r *RootCmd) RunMain(subcommands []*clibase.Cmd) {
 			//nolint:revive
 			os.Exit(1)
 		}
+		var exitErr *exitError
+		if errors.As(err, &exitErr) {
+			//nolint:revive
+			os.Exit(exitErr.code)
+		}
 		f := prettyErrorFormatter{w: os.Stderr}
 		f.format(err)
 		//nolint:revive
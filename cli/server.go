@@ -938,7 +938,13 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 			defer shutdownConns()
 
 			// Ensures that old database entries are cleaned up over time!
-			purger := dbpurge.New(ctx, logger, options.Database)
+			purger := dbpurge.New(ctx, logger, options.Database, dbpurge.Options{
+				AuditLogRetention:            cfg.Retention.AuditLogs.Value(),
+				ProvisionerJobLogRetention:   cfg.Retention.ProvisionerJobLogs.Value(),
+				WorkspaceBuildStateRetention: cfg.Retention.WorkspaceBuildState.Value(),
+				ArchiveDir:                   cfg.Retention.ArchiveDir.String(),
+				Registerer:                   options.PrometheusRegistry,
+			})
 			defer purger.Close()
 
 			// Wrap the server in middleware that redirects to the access URL if
@@ -1017,7 +1023,7 @@ func (r *RootCmd) Server(newAPI func(context.Context, *coderd.Options) (*coderd.
 
 			autobuildPoller := time.NewTicker(cfg.AutobuildPollInterval.Value())
 			defer autobuildPoller.Stop()
-			autobuildExecutor := executor.New(ctx, options.Database, logger, autobuildPoller.C)
+			autobuildExecutor := executor.New(ctx, options.Database, &coderAPI.TemplateScheduleStore, &coderAPI.Auditor, logger, autobuildPoller.C)
 			autobuildExecutor.Run()
 
 			// Currently there is no way to ask the server to shut
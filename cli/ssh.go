@@ -45,6 +45,8 @@ func (r *RootCmd) ssh() *clibase.Cmd {
 		identityAgent  string
 		wsPollInterval time.Duration
 		noWait         bool
+		listSessions   bool
+		attach         string
 	)
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
@@ -59,6 +61,10 @@ func (r *RootCmd) ssh() *clibase.Cmd {
 			ctx, cancel := context.WithCancel(inv.Context())
 			defer cancel()
 
+			if (listSessions || attach != "") && stdio {
+				return xerrors.New("--list-sessions and --attach can't be used with --stdio")
+			}
+
 			workspace, workspaceAgent, err := getWorkspaceAndAgent(ctx, inv, client, codersdk.Me, inv.Args[0])
 			if err != nil {
 				return err
@@ -94,9 +100,17 @@ func (r *RootCmd) ssh() *clibase.Cmd {
 			}
 			defer conn.Close()
 			conn.AwaitReachable(ctx)
+			if listSessions {
+				return listReconnectingPTYSessions(ctx, inv, conn)
+			}
+
 			stopPolling := tryPollWorkspaceAutostop(ctx, client, workspace)
 			defer stopPolling()
 
+			if attach != "" {
+				return attachReconnectingPTYSession(ctx, inv, conn, attach)
+			}
+
 			if stdio {
 				rawSSH, err := conn.SSH(ctx)
 				if err != nil {
@@ -188,6 +202,17 @@ func (r *RootCmd) ssh() *clibase.Cmd {
 				}()
 			}
 
+			// The agent stores the connecting user with recordings of the
+			// session as the client reported user.
+			me, err := client.User(ctx, codersdk.Me)
+			if err != nil {
+				return xerrors.Errorf("get current user: %w", err)
+			}
+			err = sshSession.Setenv(agent.MagicSSHSessionUserIDEnvironmentVariable, me.ID.String())
+			if err != nil {
+				return xerrors.Errorf("set session user: %w", err)
+			}
+
 			err = sshSession.RequestPty("xterm-256color", 128, 128, gossh.TerminalModes{})
 			if err != nil {
 				return err
@@ -268,6 +293,16 @@ func (r *RootCmd) ssh() *clibase.Cmd {
 			Description: "Specifies whether to wait for a workspace to become ready before logging in (only applicable when the login before ready option has not been enabled). Note that the workspace agent may still be in the process of executing the startup script and the workspace may be in an incomplete state.",
 			Value:       clibase.BoolOf(&noWait),
 		},
+		{
+			Flag:        "list-sessions",
+			Description: "List the reconnecting terminal sessions running in the workspace, e.g. web terminals, instead of starting a shell.",
+			Value:       clibase.BoolOf(&listSessions),
+		},
+		{
+			Flag:        "attach",
+			Description: "Attach to a running reconnecting terminal session by ID instead of starting a shell. The session keeps running when you disconnect.",
+			Value:       clibase.StringOf(&attach),
+		},
 	}
 	return cmd
 }
@@ -207,6 +207,56 @@ func TestSSH(t *testing.T) {
 
 		<-cmdDone
 	})
+	t.Run("Sessions", func(t *testing.T) {
+		t.Parallel()
+		if runtime.GOOS == "windows" {
+			t.Skip("ConPTY appears to be inconsistent on Windows.")
+		}
+
+		client, workspace, agentToken := setupWorkspaceForAgent(t, nil)
+		agentClient := agentsdk.New(client.URL)
+		agentClient.SetSessionToken(agentToken)
+		agentCloser := agent.New(agent.Options{
+			Client: agentClient,
+			Logger: slogtest.Make(t, nil).Named("agent"),
+		})
+		defer func() {
+			_ = agentCloser.Close()
+		}()
+		resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// Start a session like the web terminal does.
+		id := uuid.New()
+		webConn, err := client.WorkspaceAgentReconnectingPTY(ctx, resources[0].Agents[0].ID, id, 24, 80, "/bin/sh")
+		require.NoError(t, err)
+		defer webConn.Close()
+		// The session exists once the shell has printed something.
+		_, err = webConn.Read(make([]byte, 1))
+		require.NoError(t, err)
+
+		inv, root := clitest.New(t, "ssh", "--list-sessions", workspace.Name)
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+		clitest.Start(t, inv.WithContext(ctx))
+		pty.ExpectMatch(id.String())
+		pty.ExpectMatch("/bin/sh")
+
+		inv, root = clitest.New(t, "ssh", "--attach", id.String(), workspace.Name)
+		clitest.SetupConfig(t, client, root)
+		pty = ptytest.New(t).Attach(inv)
+		cmdDone := tGo(t, func() {
+			err := inv.WithContext(ctx).Run()
+			assert.NoError(t, err)
+		})
+		pty.WriteLine("echo attached-$((1+1))")
+		pty.ExpectMatch("attached-2")
+		// Exiting the shell ends the session for every client.
+		pty.WriteLine("exit")
+		<-cmdDone
+	})
 	t.Run("ForwardAgent", func(t *testing.T) {
 		if runtime.GOOS == "windows" {
 			t.Skip("Test not supported on windows")
@@ -0,0 +1,165 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mattn/go-isatty"
+	"golang.org/x/term"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+type reconnectingPTYSessionRow struct {
+	ID           string `table:"id,default_sort"`
+	Command      string `table:"command"`
+	Started      string `table:"started"`
+	LastActivity string `table:"last activity"`
+	Connections  int    `table:"connections"`
+}
+
+// listReconnectingPTYSessions prints the reconnecting PTY sessions running in
+// the workspace, e.g. web terminals.
+func listReconnectingPTYSessions(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn) error {
+	res, err := conn.ReconnectingPTYSessions(ctx)
+	if err != nil {
+		return xerrors.Errorf("list sessions: %w", err)
+	}
+	if len(res.Sessions) == 0 {
+		cliui.Infof(inv.Stderr, "No sessions are running in this workspace.")
+		return nil
+	}
+
+	now := time.Now()
+	rows := make([]reconnectingPTYSessionRow, 0, len(res.Sessions))
+	for _, session := range res.Sessions {
+		command := session.Command
+		if command == "" {
+			command = "(shell)"
+		}
+		rows = append(rows, reconnectingPTYSessionRow{
+			ID:           session.ID.String(),
+			Command:      command,
+			Started:      durationDisplay(now.Sub(session.StartedAt).Truncate(time.Second)) + " ago",
+			LastActivity: durationDisplay(now.Sub(session.LastActivityAt).Truncate(time.Second)) + " ago",
+			Connections:  session.Connections,
+		})
+	}
+	out, err := cliui.DisplayTable(rows, "", nil)
+	if err != nil {
+		return xerrors.Errorf("render table: %w", err)
+	}
+	_, err = fmt.Fprintln(inv.Stdout, out)
+	return err
+}
+
+// attachReconnectingPTYSession connects the terminal to an existing
+// reconnecting PTY session. The session keeps running when the connection
+// ends.
+func attachReconnectingPTYSession(ctx context.Context, inv *clibase.Invocation, conn *codersdk.WorkspaceAgentConn, rawID string) error {
+	id, err := uuid.Parse(rawID)
+	if err != nil {
+		return xerrors.Errorf("session id must be a valid UUID: %w", err)
+	}
+	// Connecting to an unknown ID starts a new session, so check that the
+	// session exists first.
+	res, err := conn.ReconnectingPTYSessions(ctx)
+	if err != nil {
+		return xerrors.Errorf("list sessions: %w", err)
+	}
+	found := false
+	for _, session := range res.Sessions {
+		if session.ID == id {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return xerrors.Errorf("session %q is not running, use --list-sessions to see running sessions", id)
+	}
+
+	width, height := 80, 24
+	stdoutFile, validOut := inv.Stdout.(*os.File)
+	stdinFile, validIn := inv.Stdin.(*os.File)
+	isTTY := validOut && validIn && isatty.IsTerminal(stdoutFile.Fd())
+	if isTTY {
+		if w, h, err := term.GetSize(int(stdoutFile.Fd())); err == nil {
+			width, height = w, h
+		}
+	}
+
+	ptyConn, err := conn.ReconnectingPTY(ctx, id, uint16(height), uint16(width), "", codersdk.ReconnectingPTYWithScrollback())
+	if err != nil {
+		return xerrors.Errorf("connect to session: %w", err)
+	}
+	defer ptyConn.Close()
+
+	// Input and resizes are written concurrently.
+	var encoderMu sync.Mutex
+	encoder := json.NewEncoder(ptyConn)
+	send := func(req codersdk.ReconnectingPTYRequest) error {
+		encoderMu.Lock()
+		defer encoderMu.Unlock()
+		return encoder.Encode(req)
+	}
+
+	if isTTY {
+		state, err := term.MakeRaw(int(stdinFile.Fd()))
+		if err != nil {
+			return err
+		}
+		defer func() {
+			_ = term.Restore(int(stdinFile.Fd()), state)
+		}()
+
+		windowChange := listenWindowSize(ctx)
+		go func() {
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-windowChange:
+				}
+				width, height, err := term.GetSize(int(stdoutFile.Fd()))
+				if err != nil {
+					continue
+				}
+				_ = send(codersdk.ReconnectingPTYRequest{
+					Height: uint16(height),
+					Width:  uint16(width),
+				})
+			}
+		}()
+	}
+
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			n, err := inv.Stdin.Read(buf)
+			if n > 0 {
+				if send(codersdk.ReconnectingPTYRequest{Data: string(buf[:n])}) != nil {
+					return
+				}
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// The agent closes the connection when the session ends.
+	_, err = io.Copy(inv.Stdout, ptyConn)
+	if err != nil && ctx.Err() == nil {
+		return xerrors.Errorf("read session output: %w", err)
+	}
+	return nil
+}
@@ -10,6 +10,7 @@ import (
 )
 
 func (r *RootCmd) start() *clibase.Cmd {
+	var bflags buildFlags
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
 		Annotations: workspaceCommand,
@@ -19,16 +20,19 @@ func (r *RootCmd) start() *clibase.Cmd {
 			clibase.RequireNArgs(1),
 			r.InitClient(client),
 		),
-		Options: clibase.OptionSet{
-			cliui.SkipPromptOption(),
-		},
+		Options: append(bflags.cliOptions(), cliui.SkipPromptOption()),
 		Handler: func(inv *clibase.Invocation) error {
 			workspace, err := namedWorkspace(inv.Context(), client, inv.Args[0])
 			if err != nil {
 				return err
 			}
+			buildParameters, err := ephemeralBuildParameters(inv, client, workspace.LatestBuild.TemplateVersionID, bflags)
+			if err != nil {
+				return err
+			}
 			build, err := client.CreateWorkspaceBuild(inv.Context(), workspace.ID, codersdk.CreateWorkspaceBuildRequest{
-				Transition: codersdk.WorkspaceTransitionStart,
+				Transition:          codersdk.WorkspaceTransitionStart,
+				RichParameterValues: buildParameters,
 			})
 			if err != nil {
 				return err
@@ -164,6 +164,9 @@ type createValidTemplateVersionArgs struct {
 	FileID        uuid.UUID
 	ParameterFile string
 
+	// GitSource is fetched by the server instead of using FileID.
+	GitSource *codersdk.TemplateVersionGitSource
+
 	VariablesFile string
 	Variables     []string
 
@@ -194,6 +197,7 @@ func createValidTemplateVersion(inv *clibase.Invocation, args createValidTemplat
 		Name:               args.Name,
 		StorageMethod:      codersdk.ProvisionerStorageMethodFile,
 		FileID:             args.FileID,
+		GitSource:          args.GitSource,
 		Provisioner:        codersdk.ProvisionerType(args.Provisioner),
 		ParameterValues:    parameters,
 		ProvisionerTags:    args.ProvisionerTags,
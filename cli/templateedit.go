@@ -3,6 +3,7 @@ package cli
 import (
 	"fmt"
 	"net/http"
+	"strings"
 	"time"
 
 	"golang.org/x/xerrors"
@@ -20,7 +21,12 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 		icon                         string
 		defaultTTL                   time.Duration
 		maxTTL                       time.Duration
+		inactivityTTL                time.Duration
+		lockedTTL                    time.Duration
+		failureTTL                   time.Duration
 		allowUserCancelWorkspaceJobs bool
+		recordTerminalSessions       bool
+		maxPortShareLevel            string
 	)
 	client := new(codersdk.Client)
 
@@ -32,17 +38,28 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 		),
 		Short: "Edit the metadata of a template by name.",
 		Handler: func(inv *clibase.Invocation) error {
+			// Advanced scheduling flags that were set, which need an
+			// enterprise license.
+			var advancedFlags []string
 			if maxTTL != 0 {
+				advancedFlags = append(advancedFlags, "--max-ttl")
+			}
+			for _, flag := range []string{"inactivity-ttl", "locked-ttl", "failure-ttl"} {
+				if inv.ParsedFlags().Changed(flag) {
+					advancedFlags = append(advancedFlags, "--"+flag)
+				}
+			}
+			if len(advancedFlags) > 0 {
 				entitlements, err := client.Entitlements(inv.Context())
 				var sdkErr *codersdk.Error
 				if xerrors.As(err, &sdkErr) && sdkErr.StatusCode() == http.StatusNotFound {
-					return xerrors.Errorf("your deployment appears to be an AGPL deployment, so you cannot set --max-ttl")
+					return xerrors.Errorf("your deployment appears to be an AGPL deployment, so you cannot set %s", strings.Join(advancedFlags, ", "))
 				} else if err != nil {
 					return xerrors.Errorf("get entitlements: %w", err)
 				}
 
 				if !entitlements.Features[codersdk.FeatureAdvancedTemplateScheduling].Enabled {
-					return xerrors.Errorf("your license is not entitled to use advanced template scheduling, so you cannot set --max-ttl")
+					return xerrors.Errorf("your license is not entitled to use advanced template scheduling, so you cannot set %s", strings.Join(advancedFlags, ", "))
 				}
 			}
 
@@ -55,6 +72,21 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 				return xerrors.Errorf("get workspace template: %w", err)
 			}
 
+			// These fields are always written by coderd, so keep the current
+			// values of the ones whose flags weren't set.
+			if !inv.ParsedFlags().Changed("inactivity-ttl") {
+				inactivityTTL = time.Duration(template.InactivityTTLMillis) * time.Millisecond
+			}
+			if !inv.ParsedFlags().Changed("locked-ttl") {
+				lockedTTL = time.Duration(template.LockedTTLMillis) * time.Millisecond
+			}
+			if !inv.ParsedFlags().Changed("failure-ttl") {
+				failureTTL = time.Duration(template.FailureTTLMillis) * time.Millisecond
+			}
+			if !inv.ParsedFlags().Changed("record-terminal-sessions") {
+				recordTerminalSessions = template.RecordTerminalSessions
+			}
+
 			// NOTE: coderd will ignore empty fields.
 			req := codersdk.UpdateTemplateMeta{
 				Name:                         name,
@@ -63,7 +95,12 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 				Icon:                         icon,
 				DefaultTTLMillis:             defaultTTL.Milliseconds(),
 				MaxTTLMillis:                 maxTTL.Milliseconds(),
+				InactivityTTLMillis:          inactivityTTL.Milliseconds(),
+				LockedTTLMillis:              lockedTTL.Milliseconds(),
+				FailureTTLMillis:             failureTTL.Milliseconds(),
 				AllowUserCancelWorkspaceJobs: allowUserCancelWorkspaceJobs,
+				RecordTerminalSessions:       recordTerminalSessions,
+				MaxPortShareLevel:            codersdk.WorkspaceAppSharingLevel(maxPortShareLevel),
 			}
 
 			_, err = client.UpdateTemplateMeta(inv.Context(), template.ID, req)
@@ -106,12 +143,41 @@ func (r *RootCmd) templateEdit() *clibase.Cmd {
 			Description: "Edit the template maximum time before shutdown - workspaces created from this template must shutdown within the given duration after starting. This is an enterprise-only feature.",
 			Value:       clibase.DurationOf(&maxTTL),
 		},
+		{
+			Flag:        "inactivity-ttl",
+			Description: "Edit the time after which workspaces created from this template that have not been used are locked. 0 disables locking. This is an enterprise-only feature.",
+			Value:       clibase.DurationOf(&inactivityTTL),
+		},
+		{
+			Flag:        "locked-ttl",
+			Description: "Edit the time after which locked workspaces created from this template are deleted. 0 disables deletion. This is an enterprise-only feature.",
+			Value:       clibase.DurationOf(&lockedTTL),
+		},
+		{
+			Flag:        "failure-ttl",
+			Description: "Edit the time after which workspaces created from this template whose start build failed are stopped. 0 disables stopping. This is an enterprise-only feature.",
+			Value:       clibase.DurationOf(&failureTTL),
+		},
 		{
 			Flag:        "allow-user-cancel-workspace-jobs",
 			Description: "Allow users to cancel in-progress workspace jobs.",
 			Default:     "true",
 			Value:       clibase.BoolOf(&allowUserCancelWorkspaceJobs),
 		},
+		{
+			Flag:        "record-terminal-sessions",
+			Description: "Record SSH and web terminal sessions in workspaces created from this template.",
+			Value:       clibase.BoolOf(&recordTerminalSessions),
+		},
+		{
+			Flag:        "max-port-share-level",
+			Description: "Edit the most permissive level workspace owners may share listening ports at.",
+			Value: clibase.EnumOf(&maxPortShareLevel,
+				string(codersdk.WorkspaceAppSharingLevelOwner),
+				string(codersdk.WorkspaceAppSharingLevelAuthenticated),
+				string(codersdk.WorkspaceAppSharingLevelPublic),
+			),
+		},
 		cliui.SkipPromptOption(),
 	}
 
@@ -240,6 +240,40 @@ func TestTemplateEdit(t *testing.T) {
 		assert.Equal(t, "", updated.Icon)
 		assert.Equal(t, "", updated.DisplayName)
 	})
+	t.Run("RecordTerminalSessionsUnchanged", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		inv, root := clitest.New(t, "templates", "edit", template.Name, "--record-terminal-sessions")
+		clitest.SetupConfig(t, client, root)
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		// Editing another field must not turn recording off.
+		inv, root = clitest.New(t, "templates", "edit", template.Name, "--description", "foo")
+		clitest.SetupConfig(t, client, root)
+		err = inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		updated, err := client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		assert.Equal(t, "foo", updated.Description)
+		assert.True(t, updated.RecordTerminalSessions)
+
+		inv, root = clitest.New(t, "templates", "edit", template.Name, "--record-terminal-sessions=false")
+		clitest.SetupConfig(t, client, root)
+		err = inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		updated, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		assert.False(t, updated.RecordTerminalSessions)
+	})
 	t.Run("MaxTTL", func(t *testing.T) {
 		t.Parallel()
 		t.Run("BlockedAGPL", func(t *testing.T) {
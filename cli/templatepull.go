@@ -15,7 +15,10 @@ import (
 )
 
 func (r *RootCmd) templatePull() *clibase.Cmd {
-	var tarMode bool
+	var (
+		tarMode bool
+		zipMode bool
+	)
 
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
@@ -36,6 +39,10 @@ func (r *RootCmd) templatePull() *clibase.Cmd {
 				dest = inv.Args[1]
 			}
 
+			if tarMode && zipMode {
+				return xerrors.Errorf("either tar or zip can be selected")
+			}
+
 			// TODO(JonA): Do we need to add a flag for organization?
 			organization, err := CurrentOrganization(inv, client)
 			if err != nil {
@@ -67,17 +74,26 @@ func (r *RootCmd) templatePull() *clibase.Cmd {
 
 			latest := versions[0]
 
-			// Download the tar archive.
-			raw, ctype, err := client.Download(ctx, latest.Job.FileID)
+			// Download the archive. Templates are stored as tar, the server
+			// converts them to zip if requested.
+			var (
+				format          string
+				wantContentType = codersdk.ContentTypeTar
+			)
+			if zipMode {
+				format = codersdk.FormatZip
+				wantContentType = codersdk.ContentTypeZip
+			}
+			raw, ctype, err := client.DownloadWithFormat(ctx, latest.Job.FileID, format)
 			if err != nil {
 				return xerrors.Errorf("download template: %w", err)
 			}
 
-			if ctype != codersdk.ContentTypeTar {
-				return xerrors.Errorf("unexpected Content-Type %q, expecting %q", ctype, codersdk.ContentTypeTar)
+			if ctype != wantContentType {
+				return xerrors.Errorf("unexpected Content-Type %q, expecting %q", ctype, wantContentType)
 			}
 
-			if tarMode {
+			if tarMode || zipMode {
 				_, err = inv.Stdout.Write(raw)
 				return err
 			}
@@ -121,6 +137,12 @@ func (r *RootCmd) templatePull() *clibase.Cmd {
 
 			Value: clibase.BoolOf(&tarMode),
 		},
+		{
+			Description: "Output the template as a zip archive to stdout.",
+			Flag:        "zip",
+
+			Value: clibase.BoolOf(&zipMode),
+		},
 		cliui.SkipPromptOption(),
 	}
 
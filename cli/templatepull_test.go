@@ -1,10 +1,14 @@
 package cli_test
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
 	"crypto/sha256"
 	"encoding/hex"
+	"errors"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -40,6 +44,45 @@ func dirSum(t *testing.T, dir string) string {
 	return hex.EncodeToString(sum.Sum(nil))
 }
 
+// tarFiles returns the contents of the regular files in a tar archive.
+func tarFiles(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		require.NoError(t, err)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		files[header.Name] = content
+	}
+}
+
+// zipFiles returns the contents of the files in a zip archive.
+func zipFiles(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	reader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	require.NoError(t, err)
+	for _, file := range reader.File {
+		if file.FileInfo().IsDir() {
+			continue
+		}
+		content, err := file.Open()
+		require.NoError(t, err)
+		files[file.Name], err = io.ReadAll(content)
+		require.NoError(t, err)
+		_ = content.Close()
+	}
+	return files
+}
+
 func TestTemplatePull(t *testing.T) {
 	t.Parallel()
 
@@ -89,6 +132,34 @@ func TestTemplatePull(t *testing.T) {
 		require.True(t, bytes.Equal(expected, buf.Bytes()), "tar files differ")
 	})
 
+	// StdoutZip tests that 'templates pull --zip' writes the latest template
+	// to stdout as a zip archive.
+	t.Run("StdoutZip", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		source := genTemplateVersionSource()
+		expected, err := echo.Tar(source)
+		require.NoError(t, err)
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, source)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		inv, root := clitest.New(t, "templates", "pull", "--zip", template.Name)
+		clitest.SetupConfig(t, client, root)
+
+		var buf bytes.Buffer
+		inv.Stdout = &buf
+
+		err = inv.Run()
+		require.NoError(t, err)
+
+		require.Equal(t, tarFiles(t, expected), zipFiles(t, buf.Bytes()))
+	})
+
 	// ToDir tests that 'templates pull' pulls down the latest template
 	// and writes it to the correct directory.
 	t.Run("ToDir", func(t *testing.T) {
@@ -2,12 +2,17 @@ package cli
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"io"
+	"net/url"
+	"path"
 	"path/filepath"
+	"strings"
 	"time"
 
 	"github.com/briandowns/spinner"
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/clibase"
@@ -17,6 +22,10 @@ import (
 	"github.com/coder/coder/provisionersdk"
 )
 
+// zipSignature is the local file header signature that zip archives start
+// with.
+var zipSignature = []byte("PK\x03\x04")
+
 // templateUploadFlags is shared by `templates create` and `templates push`.
 type templateUploadFlags struct {
 	directory string
@@ -26,7 +35,7 @@ func (pf *templateUploadFlags) option() clibase.Option {
 	return clibase.Option{
 		Flag:          "directory",
 		FlagShorthand: "d",
-		Description:   "Specify the directory to create from, use '-' to read tar from stdin.",
+		Description:   "Specify the directory to create from, use '-' to read a tar or zip archive from stdin.",
 		Default:       ".",
 		Value:         clibase.StringOf(&pf.directory),
 	}
@@ -38,8 +47,16 @@ func (pf *templateUploadFlags) stdin() bool {
 
 func (pf *templateUploadFlags) upload(inv *clibase.Invocation, client *codersdk.Client) (*codersdk.UploadResponse, error) {
 	var content io.Reader
+	contentType := codersdk.ContentTypeTar
 	if pf.stdin() {
-		content = inv.Stdin
+		// Zip archives are detected by their signature, they are converted
+		// to tar by the server.
+		reader := bufio.NewReader(inv.Stdin)
+		signature, _ := reader.Peek(len(zipSignature))
+		if bytes.Equal(signature, zipSignature) {
+			contentType = codersdk.ContentTypeZip
+		}
+		content = reader
 	} else {
 		prettyDir := prettyDirectoryPath(pf.directory)
 		_, err := cliui.Prompt(inv, cliui.PromptOptions{
@@ -66,7 +83,7 @@ func (pf *templateUploadFlags) upload(inv *clibase.Invocation, client *codersdk.
 	spin.Start()
 	defer spin.Stop()
 
-	resp, err := client.Upload(inv.Context(), codersdk.ContentTypeTar, bufio.NewReader(content))
+	resp, err := client.Upload(inv.Context(), contentType, bufio.NewReader(content))
 	if err != nil {
 		return nil, xerrors.Errorf("upload: %w", err)
 	}
@@ -89,6 +106,46 @@ func (pf *templateUploadFlags) templateName(args []string) (string, error) {
 	return filepath.Base(pf.directory), nil
 }
 
+// parseTemplateGitSource parses a repository in the form <url>[@<ref>]. The
+// ref separator is only looked for in the URL path so that credentials in
+// the URL aren't mistaken for a ref.
+func parseTemplateGitSource(raw, directory string) (*codersdk.TemplateVersionGitSource, error) {
+	repoURL, ref := raw, ""
+	if scheme := strings.Index(raw, "://"); scheme >= 0 {
+		if slash := strings.Index(raw[scheme+3:], "/"); slash >= 0 {
+			pathStart := scheme + 3 + slash
+			if at := strings.LastIndex(raw[pathStart:], "@"); at >= 0 {
+				repoURL, ref = raw[:pathStart+at], raw[pathStart+at+1:]
+			}
+		}
+	}
+	parsed, err := url.Parse(repoURL)
+	if err != nil {
+		return nil, xerrors.Errorf("parse git url: %w", err)
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, xerrors.Errorf("git url %q must use http or https", repoURL)
+	}
+	return &codersdk.TemplateVersionGitSource{
+		URL:       repoURL,
+		Ref:       ref,
+		Directory: directory,
+	}, nil
+}
+
+// templateNameFromGitSource infers a template name from the repository name,
+// or the last element of the directory if one is specified.
+func templateNameFromGitSource(source *codersdk.TemplateVersionGitSource) string {
+	if dir := strings.Trim(source.Directory, "/"); dir != "" && dir != "." {
+		return path.Base(dir)
+	}
+	parsed, err := url.Parse(source.URL)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(path.Base(strings.TrimSuffix(parsed.Path, "/")), ".git")
+}
+
 func (r *RootCmd) templatePush() *clibase.Cmd {
 	var (
 		versionName     string
@@ -97,7 +154,10 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 		variablesFile   string
 		variables       []string
 		alwaysPrompt    bool
+		activate        bool
 		provisionerTags []string
+		gitRepository   string
+		gitDirectory    string
 		uploadFlags     templateUploadFlags
 	)
 	client := new(codersdk.Client)
@@ -114,9 +174,25 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 				return err
 			}
 
-			name, err := uploadFlags.templateName(inv.Args)
-			if err != nil {
-				return err
+			var (
+				name      string
+				gitSource *codersdk.TemplateVersionGitSource
+			)
+			if gitRepository != "" {
+				gitSource, err = parseTemplateGitSource(gitRepository, gitDirectory)
+				if err != nil {
+					return err
+				}
+				if len(inv.Args) > 0 {
+					name = inv.Args[0]
+				} else {
+					name = templateNameFromGitSource(gitSource)
+				}
+			} else {
+				name, err = uploadFlags.templateName(inv.Args)
+				if err != nil {
+					return err
+				}
 			}
 
 			template, err := client.TemplateByName(inv.Context(), organization.ID, name)
@@ -124,9 +200,15 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 				return err
 			}
 
-			resp, err := uploadFlags.upload(inv, client)
-			if err != nil {
-				return err
+			// The server fetches git sources itself, so there is nothing to
+			// upload.
+			var fileID uuid.UUID
+			if gitSource == nil {
+				resp, err := uploadFlags.upload(inv, client)
+				if err != nil {
+					return err
+				}
+				fileID = resp.ID
 			}
 
 			tags, err := ParseProvisionerTags(provisionerTags)
@@ -139,7 +221,8 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 				Client:          client,
 				Organization:    organization,
 				Provisioner:     database.ProvisionerType(provisioner),
-				FileID:          resp.ID,
+				FileID:          fileID,
+				GitSource:       gitSource,
 				ParameterFile:   parameterFile,
 				VariablesFile:   variablesFile,
 				Variables:       variables,
@@ -155,6 +238,12 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 				return xerrors.Errorf("job failed: %s", job.Job.Status)
 			}
 
+			if !activate {
+				_, _ = fmt.Fprintf(inv.Stdout, "Pushed inactive version %s at %s!\n", cliui.Styles.Keyword.Render(job.Name), cliui.Styles.DateTimeStamp.Render(time.Now().Format(time.Stamp)))
+				_, _ = fmt.Fprintf(inv.Stdout, "Run %s to make it the active version.\n", cliui.Styles.Code.Render(fmt.Sprintf("coder templates versions promote %s %s", template.Name, job.Name)))
+				return nil
+			}
+
 			err = client.UpdateActiveTemplateVersion(inv.Context(), template.ID, codersdk.UpdateActiveTemplateVersion{
 				ID: job.ID,
 			})
@@ -207,6 +296,22 @@ func (r *RootCmd) templatePush() *clibase.Cmd {
 			Description: "Always prompt all parameters. Does not pull parameter values from active template version.",
 			Value:       clibase.BoolOf(&alwaysPrompt),
 		},
+		{
+			Flag:        "activate",
+			Description: "Whether the new template version will be promoted to the active version. Inactive versions can be promoted later with \"coder templates versions promote\".",
+			Default:     "true",
+			Value:       clibase.BoolOf(&activate),
+		},
+		{
+			Flag:        "git",
+			Description: "Push the template from a git repository instead of a directory. Append '@ref' to the URL to select a branch, tag or commit. The repository is fetched by the Coder server, authenticating with a matching git auth provider if one is configured.",
+			Value:       clibase.StringOf(&gitRepository),
+		},
+		{
+			Flag:        "git-directory",
+			Description: "Specify the directory within the git repository that contains the template.",
+			Value:       clibase.StringOf(&gitDirectory),
+		},
 		cliui.SkipPromptOption(),
 		uploadFlags.option(),
 	}
@@ -0,0 +1,65 @@
+package cli
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/codersdk"
+)
+
+func TestParseTemplateGitSource(t *testing.T) {
+	t.Parallel()
+	for _, testCase := range []struct {
+		Name      string
+		Raw       string
+		Directory string
+		Expected  codersdk.TemplateVersionGitSource
+		Template  string
+		Error     bool
+	}{{
+		Name:     "NoRef",
+		Raw:      "https://github.com/coder/templates.git",
+		Expected: codersdk.TemplateVersionGitSource{URL: "https://github.com/coder/templates.git"},
+		Template: "templates",
+	}, {
+		Name:     "Ref",
+		Raw:      "https://github.com/coder/templates@v1.0.0",
+		Expected: codersdk.TemplateVersionGitSource{URL: "https://github.com/coder/templates", Ref: "v1.0.0"},
+		Template: "templates",
+	}, {
+		Name:     "UserInfo",
+		Raw:      "https://git@example.com/coder/templates.git",
+		Expected: codersdk.TemplateVersionGitSource{URL: "https://git@example.com/coder/templates.git"},
+		Template: "templates",
+	}, {
+		Name:     "UserInfoAndRef",
+		Raw:      "https://git@example.com/coder/templates.git@main",
+		Expected: codersdk.TemplateVersionGitSource{URL: "https://git@example.com/coder/templates.git", Ref: "main"},
+		Template: "templates",
+	}, {
+		Name:      "Directory",
+		Raw:       "https://github.com/coder/templates@main",
+		Directory: "aws/linux/",
+		Expected:  codersdk.TemplateVersionGitSource{URL: "https://github.com/coder/templates", Ref: "main", Directory: "aws/linux/"},
+		Template:  "linux",
+	}, {
+		Name:  "SSH",
+		Raw:   "git@github.com:coder/templates.git",
+		Error: true,
+	}} {
+		testCase := testCase
+		t.Run(testCase.Name, func(t *testing.T) {
+			t.Parallel()
+			source, err := parseTemplateGitSource(testCase.Raw, testCase.Directory)
+			if testCase.Error {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, testCase.Expected, *source)
+			assert.Equal(t, testCase.Template, templateNameFromGitSource(source))
+		})
+	}
+}
@@ -1,8 +1,10 @@
 package cli_test
 
 import (
+	"archive/zip"
 	"bytes"
 	"context"
+	"io"
 	"os"
 	"path/filepath"
 	"testing"
@@ -203,6 +205,35 @@ func TestTemplatePush(t *testing.T) {
 		assert.NotEqual(t, template.ActiveVersionID, templateVersions[1].ID)
 	})
 
+	t.Run("NoActivate", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		source := clitest.CreateTemplateVersionSource(t, &echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		inv, root := clitest.New(t, "templates", "push", template.Name, "-y", "--activate=false", "--name", "draft", "--directory", source, "--test.provisioner", string(database.ProvisionerTypeEcho))
+		clitest.SetupConfig(t, client, root)
+		pty := ptytest.New(t).Attach(inv)
+
+		waiter := clitest.StartWithWaiter(t, inv)
+		pty.ExpectMatch("coder templates versions promote " + template.Name + " draft")
+		waiter.RequireSuccess()
+
+		// The new version exists, but the active version is unchanged.
+		draft, err := client.TemplateVersionByName(context.Background(), template.ID, "draft")
+		require.NoError(t, err)
+		template, err = client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		require.Equal(t, version.ID, template.ActiveVersionID)
+		require.NotEqual(t, draft.ID, template.ActiveVersionID)
+	})
+
 	t.Run("Stdin", func(t *testing.T) {
 		t.Parallel()
 		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
@@ -244,6 +275,52 @@ func TestTemplatePush(t *testing.T) {
 		assert.NotEqual(t, template.ActiveVersionID, templateVersions[1].ID)
 	})
 
+	t.Run("StdinZip", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		source, err := echo.Tar(&echo.Responses{
+			Parse:          echo.ParseComplete,
+			ProvisionApply: echo.ProvisionComplete,
+		})
+		require.NoError(t, err)
+		files := tarFiles(t, source)
+		var buf bytes.Buffer
+		writer := zip.NewWriter(&buf)
+		for name, content := range files {
+			file, err := writer.Create(name)
+			require.NoError(t, err)
+			_, err = file.Write(content)
+			require.NoError(t, err)
+		}
+		require.NoError(t, writer.Close())
+
+		inv, root := clitest.New(
+			t, "templates", "push", "--directory", "-",
+			"--test.provisioner", string(database.ProvisionerTypeEcho),
+			template.Name,
+		)
+		clitest.SetupConfig(t, client, root)
+		inv.Stdin = &buf
+		inv.Stdout = io.Discard
+		require.NoError(t, inv.Run())
+
+		// The zip archive is stored as tar.
+		template, err = client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		require.NotEqual(t, version.ID, template.ActiveVersionID)
+		active, err := client.TemplateVersion(context.Background(), template.ActiveVersionID)
+		require.NoError(t, err)
+		data, contentType, err := client.Download(context.Background(), active.Job.FileID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ContentTypeTar, contentType)
+		require.Equal(t, files, tarFiles(t, data))
+	})
+
 	t.Run("Variables", func(t *testing.T) {
 		t.Parallel()
 
@@ -1,11 +1,16 @@
 package cli
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
+	"reflect"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/pkg/diff"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/cli/clibase"
@@ -23,12 +28,24 @@ func (r *RootCmd) templateVersions() *clibase.Cmd {
 				Description: "List versions of a specific template",
 				Command:     "coder templates versions list my-template",
 			},
+			example{
+				Description: "Show the changes between two versions of a template",
+				Command:     "coder templates versions diff my-template old-version new-version",
+			},
+			example{
+				Description: "Promote a version to be the active version of a template",
+				Command:     "coder templates versions promote my-template my-version",
+			},
 		),
 		Handler: func(inv *clibase.Invocation) error {
 			return inv.Command.HelpHandler(inv)
 		},
 		Children: []*clibase.Cmd{
 			r.templateVersionsList(),
+			r.templateVersionsDiff(),
+			r.templateVersionsPromote(),
+			r.setArchiveTemplateVersion(true),
+			r.setArchiveTemplateVersion(false),
 		},
 	}
 
@@ -36,6 +53,7 @@ func (r *RootCmd) templateVersions() *clibase.Cmd {
 }
 
 func (r *RootCmd) templateVersionsList() *clibase.Cmd {
+	var includeArchived bool
 	formatter := cliui.NewOutputFormatter(
 		cliui.TableFormat([]templateVersionRow{}, nil),
 		cliui.JSONFormat(),
@@ -59,7 +77,8 @@ func (r *RootCmd) templateVersionsList() *clibase.Cmd {
 				return xerrors.Errorf("get template by name: %w", err)
 			}
 			req := codersdk.TemplateVersionsByTemplateRequest{
-				TemplateID: template.ID,
+				TemplateID:      template.ID,
+				IncludeArchived: includeArchived,
 			}
 
 			versions, err := client.TemplateVersionsByTemplate(inv.Context(), req)
@@ -78,10 +97,259 @@ func (r *RootCmd) templateVersionsList() *clibase.Cmd {
 		},
 	}
 
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "include-archived",
+			Description: "Include archived versions in the result list.",
+			Value:       clibase.BoolOf(&includeArchived),
+		},
+	}
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) templateVersionsDiff() *clibase.Cmd {
+	formatter := cliui.NewOutputFormatter(
+		&templateVersionDiffFormat{},
+		cliui.JSONFormat(),
+	)
+	client := new(codersdk.Client)
+
+	cmd := &clibase.Cmd{
+		Use: "diff <template> <base version> <head version>",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(3),
+			r.InitClient(client),
+		),
+		Short: "Show the changes between two versions of the specified template",
+		Long:  "Compares the source files, parameters, variables and git auth providers of two template versions.",
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), organization.ID, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			base, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[1])
+			if err != nil {
+				return xerrors.Errorf("get base template version by name: %w", err)
+			}
+			head, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[2])
+			if err != nil {
+				return xerrors.Errorf("get head template version by name: %w", err)
+			}
+
+			versionDiff, err := client.TemplateVersionDiff(inv.Context(), base.ID, head.ID)
+			if err != nil {
+				return xerrors.Errorf("diff template versions: %w", err)
+			}
+
+			out, err := formatter.Format(inv.Context(), versionDiff)
+			if err != nil {
+				return err
+			}
+			_, err = fmt.Fprint(inv.Stdout, out)
+			return err
+		},
+	}
+
 	formatter.AttachOptions(&cmd.Options)
 	return cmd
 }
 
+type templateVersionDiffFormat struct{}
+
+var _ cliui.OutputFormat = &templateVersionDiffFormat{}
+
+// ID implements OutputFormat.
+func (*templateVersionDiffFormat) ID() string {
+	return "diff"
+}
+
+// AttachOptions implements OutputFormat.
+func (*templateVersionDiffFormat) AttachOptions(_ *clibase.OptionSet) {}
+
+// Format implements OutputFormat. Files are rendered using the unified diff
+// returned by the server. Parameters, variables and git auth providers are
+// rendered as JSON documents under a pseudo path, so that the whole output is
+// a single unified diff.
+func (*templateVersionDiffFormat) Format(_ context.Context, out interface{}) (string, error) {
+	versionDiff, ok := out.(codersdk.TemplateVersionDiff)
+	if !ok {
+		return "", xerrors.Errorf("expected type %T, got %T", versionDiff, out)
+	}
+
+	var buf bytes.Buffer
+	for _, file := range versionDiff.Files {
+		if file.Binary {
+			oldName, newName := diffPaths(file.Path, file.Change)
+			_, _ = fmt.Fprintf(&buf, "Binary files %s and %s differ\n", oldName, newName)
+			continue
+		}
+		if file.TooLarge {
+			oldName, newName := diffPaths(file.Path, file.Change)
+			_, _ = fmt.Fprintf(&buf, "Files %s and %s differ, they are too large to diff\n", oldName, newName)
+			continue
+		}
+		_, _ = buf.WriteString(file.Diff)
+	}
+
+	writeJSON := func(path string, change codersdk.TemplateVersionDiffChange, oldValue, newValue any) error {
+		oldText, err := diffJSON(oldValue)
+		if err != nil {
+			return err
+		}
+		newText, err := diffJSON(newValue)
+		if err != nil {
+			return err
+		}
+		oldName, newName := diffPaths(path, change)
+		return diff.Text(oldName, newName, oldText, newText, &buf)
+	}
+	for _, p := range versionDiff.ParameterSchemas {
+		if err := writeJSON("parameter-schemas/"+p.Name, p.Change, p.Old, p.New); err != nil {
+			return "", err
+		}
+	}
+	for _, p := range versionDiff.RichParameters {
+		if err := writeJSON("rich-parameters/"+p.Name, p.Change, p.Old, p.New); err != nil {
+			return "", err
+		}
+	}
+	for _, v := range versionDiff.Variables {
+		if err := writeJSON("variables/"+v.Name, v.Change, v.Old, v.New); err != nil {
+			return "", err
+		}
+	}
+	for _, g := range versionDiff.GitAuthProviders {
+		var oldValue, newValue any
+		if g.Change == codersdk.TemplateVersionDiffChangeRemoved {
+			oldValue = g.ID
+		} else {
+			newValue = g.ID
+		}
+		if err := writeJSON("git-auth-providers/"+g.ID, g.Change, oldValue, newValue); err != nil {
+			return "", err
+		}
+	}
+	return buf.String(), nil
+}
+
+// diffPaths returns the names of both sides of a changed path in the style
+// of git.
+func diffPaths(path string, change codersdk.TemplateVersionDiffChange) (string, string) {
+	switch change {
+	case codersdk.TemplateVersionDiffChangeAdded:
+		return "/dev/null", "b/" + path
+	case codersdk.TemplateVersionDiffChangeRemoved:
+		return "a/" + path, "/dev/null"
+	default:
+		return "a/" + path, "b/" + path
+	}
+}
+
+// diffJSON renders v as indented JSON, or as an empty string if v is nil.
+// The result is always a string, since the diff package reads from disk when
+// given nil.
+func diffJSON(v any) (string, error) {
+	if v == nil || reflect.ValueOf(v).Kind() == reflect.Ptr && reflect.ValueOf(v).IsNil() {
+		return "", nil
+	}
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(data) + "\n", nil
+}
+
+func (r *RootCmd) templateVersionsPromote() *clibase.Cmd {
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use: "promote <template> <version>",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Short: "Promote a version to be the active version of the specified template",
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), organization.ID, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			version, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[1])
+			if err != nil {
+				return xerrors.Errorf("get template version by name: %w", err)
+			}
+
+			err = client.UpdateActiveTemplateVersion(inv.Context(), template.ID, codersdk.UpdateActiveTemplateVersion{
+				ID: version.ID,
+			})
+			if err != nil {
+				return xerrors.Errorf("update active template version: %w", err)
+			}
+
+			_, _ = fmt.Fprintf(inv.Stdout, "Promoted version %s to be the active version of %s!\n", cliui.Styles.Keyword.Render(version.Name), cliui.Styles.Keyword.Render(template.Name))
+			return nil
+		},
+	}
+
+	return cmd
+}
+
+func (r *RootCmd) setArchiveTemplateVersion(archive bool) *clibase.Cmd {
+	presentVerb := "archive"
+	pastVerb := "archived"
+	if !archive {
+		presentVerb = "unarchive"
+		pastVerb = "unarchived"
+	}
+
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use: presentVerb + " <template> <version>",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(2),
+			r.InitClient(client),
+		),
+		Short: strings.ToUpper(presentVerb[:1]) + presentVerb[1:] + " a version of the specified template",
+		Long:  "Archived versions are hidden from version lists and cannot be used for new workspace builds.",
+		Handler: func(inv *clibase.Invocation) error {
+			organization, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), organization.ID, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("get template by name: %w", err)
+			}
+			version, err := client.TemplateVersionByName(inv.Context(), template.ID, inv.Args[1])
+			if err != nil {
+				return xerrors.Errorf("get template version by name: %w", err)
+			}
+
+			if archive {
+				_, err = client.ArchiveTemplateVersion(inv.Context(), version.ID)
+			} else {
+				_, err = client.UnarchiveTemplateVersion(inv.Context(), version.ID)
+			}
+			if err != nil {
+				return xerrors.Errorf("%s template version: %w", presentVerb, err)
+			}
+
+			_, _ = fmt.Fprintf(inv.Stdout, "Version %s of %s was %s!\n", cliui.Styles.Keyword.Render(version.Name), cliui.Styles.Keyword.Render(template.Name), pastVerb)
+			return nil
+		},
+	}
+
+	return cmd
+}
+
 type templateVersionRow struct {
 	// For json format:
 	TemplateVersion codersdk.TemplateVersion `table:"-"`
@@ -92,6 +360,7 @@ type templateVersionRow struct {
 	CreatedBy string    `json:"-" table:"created by"`
 	Status    string    `json:"-" table:"status"`
 	Active    string    `json:"-" table:"active"`
+	Archived  bool      `json:"-" table:"archived"`
 }
 
 // templateVersionsToRows converts a list of template versions to a list of rows
@@ -110,6 +379,7 @@ func templateVersionsToRows(activeVersionID uuid.UUID, templateVersions ...coder
 			CreatedBy: templateVersion.CreatedBy.Username,
 			Status:    strings.Title(string(templateVersion.Job.Status)),
 			Active:    activeStatus,
+			Archived:  templateVersion.Archived,
 		}
 	}
 
@@ -1,12 +1,17 @@
 package cli_test
 
 import (
+	"bytes"
+	"context"
 	"testing"
 
 	"github.com/stretchr/testify/require"
 
 	"github.com/coder/coder/cli/clitest"
 	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
 	"github.com/coder/coder/pty/ptytest"
 )
 
@@ -36,4 +41,104 @@ func TestTemplateVersions(t *testing.T) {
 		pty.ExpectMatch(version.CreatedBy.Username)
 		pty.ExpectMatch("Active")
 	})
+
+	t.Run("Diff", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		responses := func(defaultValue string) *echo.Responses {
+			return &echo.Responses{
+				Parse: echo.ParseComplete,
+				ProvisionPlan: []*proto.Provision_Response{{
+					Type: &proto.Provision_Response_Complete{
+						Complete: &proto.Provision_Complete{
+							Parameters: []*proto.RichParameter{
+								{Name: "region", Type: "string", DefaultValue: defaultValue},
+							},
+						},
+					},
+				}},
+				ProvisionApply: echo.ProvisionComplete,
+			}
+		}
+		base := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, responses("us"))
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, base.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, base.ID)
+		head := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, responses("eu"), template.ID)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, head.ID)
+
+		inv, root := clitest.New(t, "templates", "versions", "diff", template.Name, base.Name, head.Name)
+		clitest.SetupConfig(t, client, root)
+		var stdout bytes.Buffer
+		inv.Stdout = &stdout
+
+		err := inv.Run()
+		require.NoError(t, err)
+
+		out := stdout.String()
+		require.Contains(t, out, "--- a/rich-parameters/region")
+		require.Contains(t, out, "+++ b/rich-parameters/region")
+		require.Contains(t, out, `-  "default_value": "us",`)
+		require.Contains(t, out, `+  "default_value": "eu",`)
+	})
+
+	t.Run("Promote", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		draft := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, draft.ID)
+
+		inv, root := clitest.New(t, "templates", "versions", "promote", template.Name, draft.Name)
+		clitest.SetupConfig(t, client, root)
+
+		err := inv.Run()
+		require.NoError(t, err)
+
+		template, err = client.Template(context.Background(), template.ID)
+		require.NoError(t, err)
+		require.Equal(t, draft.ID, template.ActiveVersionID)
+	})
+
+	t.Run("ArchiveUnarchive", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		old := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+		_ = coderdtest.AwaitTemplateVersionJob(t, client, old.ID)
+
+		inv, root := clitest.New(t, "templates", "versions", "archive", template.Name, old.Name)
+		clitest.SetupConfig(t, client, root)
+		err := inv.Run()
+		require.NoError(t, err)
+
+		versions, err := client.TemplateVersionsByTemplate(context.Background(), codersdk.TemplateVersionsByTemplateRequest{
+			TemplateID: template.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+
+		// The active version cannot be archived.
+		inv, root = clitest.New(t, "templates", "versions", "archive", template.Name, version.Name)
+		clitest.SetupConfig(t, client, root)
+		err = inv.Run()
+		require.Error(t, err)
+
+		inv, root = clitest.New(t, "templates", "versions", "unarchive", template.Name, old.Name)
+		clitest.SetupConfig(t, client, root)
+		err = inv.Run()
+		require.NoError(t, err)
+
+		versions, err = client.TemplateVersionsByTemplate(context.Background(), codersdk.TemplateVersionsByTemplateRequest{
+			TemplateID: template.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+	})
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/google/uuid"
 	"golang.org/x/exp/slices"
 	"golang.org/x/xerrors"
 
@@ -23,6 +24,10 @@ func (r *RootCmd) tokens() *clibase.Cmd {
 				Description: "Create a token for automation",
 				Command:     "coder tokens create",
 			},
+			example{
+				Description: "Create a token that can only start and stop the dev workspace, which uses the docker template",
+				Command:     "coder tokens create --scope read:template:docker --scope read:workspace:dev --scope update:workspace:dev",
+			},
 			example{
 				Description: "List your tokens",
 				Command:     "coder tokens ls",
@@ -49,6 +54,7 @@ func (r *RootCmd) createToken() *clibase.Cmd {
 	var (
 		tokenLifetime time.Duration
 		name          string
+		scopes        []string
 	)
 	client := new(codersdk.Client)
 	cmd := &clibase.Cmd{
@@ -59,10 +65,20 @@ func (r *RootCmd) createToken() *clibase.Cmd {
 			r.InitClient(client),
 		),
 		Handler: func(inv *clibase.Invocation) error {
-			res, err := client.CreateToken(inv.Context(), codersdk.Me, codersdk.CreateTokenRequest{
+			req := codersdk.CreateTokenRequest{
 				Lifetime:  tokenLifetime,
 				TokenName: name,
-			})
+			}
+			for _, scope := range scopes {
+				resource, err := parseTokenScope(inv, client, scope)
+				if err != nil {
+					return err
+				}
+				req.Scope = codersdk.APIKeyScopeResources
+				req.Resources = append(req.Resources, resource)
+			}
+
+			res, err := client.CreateToken(inv.Context(), codersdk.Me, req)
 			if err != nil {
 				return xerrors.Errorf("create tokens: %w", err)
 			}
@@ -95,11 +111,59 @@ func (r *RootCmd) createToken() *clibase.Cmd {
 			Description:   "Specify a human-readable name.",
 			Value:         clibase.StringOf(&name),
 		},
+		{
+			Flag: "scope",
+			Env:  "CODER_TOKEN_SCOPE",
+			Description: "Limit the token to an action on a resource, formatted as <action>:<resource_type>:<resource>. " +
+				"Templates and workspaces may be referenced by name, other resources by ID. " +
+				"All resources of the same type must be granted the same actions. " +
+				"Without a scope, the token can do everything you can.",
+			Value: clibase.StringArrayOf(&scopes),
+		},
 	}
 
 	return cmd
 }
 
+// parseTokenScope parses a scope in the format <action>:<resource_type>:<resource>.
+func parseTokenScope(inv *clibase.Invocation, client *codersdk.Client, scope string) (codersdk.APIKeyScopeResource, error) {
+	parts := strings.SplitN(scope, ":", 3)
+	if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+		return codersdk.APIKeyScopeResource{}, xerrors.Errorf("invalid scope %q, must be formatted as <action>:<resource_type>:<resource>", scope)
+	}
+	action, resourceType, resource := parts[0], parts[1], parts[2]
+
+	id, err := uuid.Parse(resource)
+	if err != nil {
+		switch resourceType {
+		case "template":
+			org, err := CurrentOrganization(inv, client)
+			if err != nil {
+				return codersdk.APIKeyScopeResource{}, xerrors.Errorf("get current organization: %w", err)
+			}
+			template, err := client.TemplateByName(inv.Context(), org.ID, resource)
+			if err != nil {
+				return codersdk.APIKeyScopeResource{}, xerrors.Errorf("get template %q: %w", resource, err)
+			}
+			id = template.ID
+		case "workspace":
+			workspace, err := namedWorkspace(inv.Context(), client, resource)
+			if err != nil {
+				return codersdk.APIKeyScopeResource{}, xerrors.Errorf("get workspace %q: %w", resource, err)
+			}
+			id = workspace.ID
+		default:
+			return codersdk.APIKeyScopeResource{}, xerrors.Errorf("%s %q must be referenced by ID", resourceType, resource)
+		}
+	}
+
+	return codersdk.APIKeyScopeResource{
+		ResourceType: resourceType,
+		ResourceID:   id,
+		Action:       action,
+	}, nil
+}
+
 // tokenListRow is the type provided to the OutputFormatter.
 type tokenListRow struct {
 	// For JSON format:
@@ -108,6 +172,7 @@ type tokenListRow struct {
 	// For table format:
 	ID        string    `json:"-" table:"id,default_sort"`
 	TokenName string    `json:"token_name" table:"name"`
+	Scope     string    `json:"-" table:"scope"`
 	LastUsed  time.Time `json:"-" table:"last used"`
 	ExpiresAt time.Time `json:"-" table:"expires at"`
 	CreatedAt time.Time `json:"-" table:"created at"`
@@ -119,6 +184,7 @@ func tokenListRowFromToken(token codersdk.APIKeyWithOwner) tokenListRow {
 		APIKey:    token.APIKey,
 		ID:        token.ID,
 		TokenName: token.TokenName,
+		Scope:     formatTokenScope(token.APIKey),
 		LastUsed:  token.LastUsed,
 		ExpiresAt: token.ExpiresAt,
 		CreatedAt: token.CreatedAt,
@@ -126,9 +192,22 @@ func tokenListRowFromToken(token codersdk.APIKeyWithOwner) tokenListRow {
 	}
 }
 
+// formatTokenScope formats the scope of a token, listing the actions of
+// tokens limited to resources in the format accepted by --scope.
+func formatTokenScope(token codersdk.APIKey) string {
+	if token.Scope != codersdk.APIKeyScopeResources {
+		return string(token.Scope)
+	}
+	resources := make([]string, 0, len(token.Resources))
+	for _, resource := range token.Resources {
+		resources = append(resources, fmt.Sprintf("%s:%s:%s", resource.Action, resource.ResourceType, resource.ResourceID))
+	}
+	return strings.Join(resources, ", ")
+}
+
 func (r *RootCmd) listTokens() *clibase.Cmd {
 	// we only display the 'owner' column if the --all argument is passed in
-	defaultCols := []string{"id", "name", "last used", "expires at", "created at"}
+	defaultCols := []string{"id", "name", "scope", "last used", "expires at", "created at"}
 	if slices.Contains(os.Args, "-a") || slices.Contains(os.Args, "--all") {
 		defaultCols = append(defaultCols, "owner")
 	}
@@ -159,6 +159,45 @@ const docTemplate = `{
                 }
             }
         },
+        "/applications/reconnecting-pty-ticket": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Applications"
+                ],
+                "summary": "Issue signed app ticket for reconnecting PTY",
+                "operationId": "issue-signed-app-ticket-for-reconnecting-pty",
+                "parameters": [
+                    {
+                        "description": "Issue reconnecting PTY ticket request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.IssueReconnectingPTYTicketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.IssueReconnectingPTYTicketResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/audit": {
             "get": {
                 "security": [
@@ -212,6 +251,119 @@ const docTemplate = `{
                 }
             }
         },
+        "/audit/export": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Export audit logs",
+                "operationId": "export-audit-logs",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Search query",
+                        "name": "q",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "csv",
+                            "ndjson"
+                        ],
+                        "type": "string",
+                        "description": "Export format",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only export audit logs after this time",
+                        "name": "after",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "date-time",
+                        "description": "Only export audit logs before this time",
+                        "name": "before",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/audit/filter-rules": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Get audit filter rules",
+                "operationId": "get-audit-filter-rules",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.AuditFilterRules"
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Update audit filter rules",
+                "operationId": "update-audit-filter-rules",
+                "parameters": [
+                    {
+                        "description": "Audit filter rules",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.AuditFilterRules"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.AuditFilterRules"
+                        }
+                    }
+                }
+            }
+        },
         "/audit/testgenerate": {
             "post": {
                 "security": [
@@ -359,6 +511,41 @@ const docTemplate = `{
                 }
             }
         },
+        "/debug/workspace-agents/{workspaceagent}/netcheck": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Debug"
+                ],
+                "summary": "Debug Info Workspace Agent Netcheck",
+                "operationId": "debug-info-workspace-agent-netcheck",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentNetcheck"
+                        }
+                    }
+                }
+            }
+        },
         "/deployment/config": {
             "get": {
                 "security": [
@@ -510,7 +697,7 @@ const docTemplate = `{
                     {
                         "type": "string",
                         "default": "application/x-tar",
-                        "description": "Content-Type must be ` + "`" + `application/x-tar` + "`" + `",
+                        "description": "Content-Type must be ` + "`" + `application/x-tar` + "`" + ` or ` + "`" + `application/zip` + "`" + `",
                         "name": "Content-Type",
                         "in": "header",
                         "required": true
@@ -553,6 +740,15 @@ const docTemplate = `{
                         "name": "fileID",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "enum": [
+                            "zip"
+                        ],
+                        "type": "string",
+                        "description": "Convert the file to a different format",
+                        "name": "format",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -1056,61 +1252,44 @@ const docTemplate = `{
                 }
             }
         },
-        "/organizations/{organization}/members/{user}/roles": {
-            "put": {
+        "/organizations/{organization}/members/roles/custom": {
+            "get": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
-                "consumes": [
-                    "application/json"
-                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Members"
                 ],
-                "summary": "Assign role to organization member",
-                "operationId": "assign-role-to-organization-member",
+                "summary": "Get custom organization roles",
+                "operationId": "get-custom-organization-roles",
                 "parameters": [
                     {
                         "type": "string",
+                        "format": "uuid",
                         "description": "Organization ID",
                         "name": "organization",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "User ID, name, or me",
-                        "name": "user",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Update roles request",
-                        "name": "request",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/codersdk.UpdateRoles"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.OrganizationMember"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.CustomRole"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/organizations/{organization}/members/{user}/workspaces": {
-            "post": {
+            },
+            "put": {
                 "security": [
                     {
                         "CoderSessionToken": []
@@ -1123,10 +1302,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workspaces"
+                    "Members"
                 ],
-                "summary": "Create user workspace by organization",
-                "operationId": "create-user-workspace-by-organization",
+                "summary": "Upsert custom organization role",
+                "operationId": "upsert-custom-organization-role",
                 "parameters": [
                     {
                         "type": "string",
@@ -1137,19 +1316,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Username, UUID, or me",
-                        "name": "user",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Create workspace request",
+                        "description": "Custom role",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/codersdk.CreateWorkspaceRequest"
+                            "$ref": "#/definitions/codersdk.CustomRole"
                         }
                     }
                 ],
@@ -1157,27 +1329,24 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.Workspace"
+                            "$ref": "#/definitions/codersdk.CustomRole"
                         }
                     }
                 }
             }
         },
-        "/organizations/{organization}/provisionerdaemons": {
-            "get": {
+        "/organizations/{organization}/members/roles/custom/{role}": {
+            "delete": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
-                    "Enterprise"
+                    "Members"
                 ],
-                "summary": "Get provisioner daemons",
-                "operationId": "get-provisioner-daemons",
+                "summary": "Delete custom organization role",
+                "operationId": "delete-custom-organization-role",
                 "parameters": [
                     {
                         "type": "string",
@@ -1186,37 +1355,183 @@ const docTemplate = `{
                         "name": "organization",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/codersdk.ProvisionerDaemon"
-                            }
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
         },
-        "/organizations/{organization}/provisionerdaemons/serve": {
-            "get": {
+        "/organizations/{organization}/members/{user}/roles": {
+            "put": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
                 "tags": [
-                    "Enterprise"
+                    "Members"
                 ],
-                "summary": "Serve provisioner daemon",
-                "operationId": "serve-provisioner-daemon",
+                "summary": "Assign role to organization member",
+                "operationId": "assign-role-to-organization-member",
                 "parameters": [
                     {
                         "type": "string",
-                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "organization",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "User ID, name, or me",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Update roles request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.UpdateRoles"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.OrganizationMember"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization}/members/{user}/workspaces": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Create user workspace by organization",
+                "operationId": "create-user-workspace-by-organization",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "organization",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username, UUID, or me",
+                        "name": "user",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create workspace request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateWorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Workspace"
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization}/provisionerdaemons": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Get provisioner daemons",
+                "operationId": "get-provisioner-daemons",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Organization ID",
+                        "name": "organization",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.ProvisionerDaemon"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/organizations/{organization}/provisionerdaemons/serve": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Serve provisioner daemon",
+                "operationId": "serve-provisioner-daemon",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
                         "description": "Organization ID",
                         "name": "organization",
                         "in": "path",
@@ -1698,6 +2013,31 @@ const docTemplate = `{
                 }
             }
         },
+        "/regions": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Get site-wide regions for workspace connections",
+                "operationId": "get-site-wide-regions-for-workspace-connections",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.RegionsResponse"
+                        }
+                    }
+                }
+            }
+        },
         "/replicas": {
             "get": {
                 "security": [
@@ -2117,6 +2457,12 @@ const docTemplate = `{
                         "description": "Page offset",
                         "name": "offset",
                         "in": "query"
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Include archived versions",
+                        "name": "include_archived",
+                        "in": "query"
                     }
                 ],
                 "responses": {
@@ -2302,6 +2648,41 @@ const docTemplate = `{
                 }
             }
         },
+        "/templateversions/{templateversion}/archive": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Archive template version by ID",
+                "operationId": "archive-template-version-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version ID",
+                        "name": "templateversion",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersion"
+                        }
+                    }
+                }
+            }
+        },
         "/templateversions/{templateversion}/cancel": {
             "patch": {
                 "security": [
@@ -2337,6 +2718,49 @@ const docTemplate = `{
                 }
             }
         },
+        "/templateversions/{templateversion}/diff": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Templates"
+                ],
+                "summary": "Get template version diff",
+                "operationId": "get-template-version-diff",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version ID",
+                        "name": "templateversion",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version ID to compare against",
+                        "name": "base",
+                        "in": "query",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiff"
+                        }
+                    }
+                }
+            }
+        },
         "/templateversions/{templateversion}/dry-run": {
             "post": {
                 "security": [
@@ -2826,8 +3250,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/templateversions/{templateversion}/variables": {
-            "get": {
+        "/templateversions/{templateversion}/unarchive": {
+            "post": {
                 "security": [
                     {
                         "CoderSessionToken": []
@@ -2839,8 +3263,8 @@ const docTemplate = `{
                 "tags": [
                     "Templates"
                 ],
-                "summary": "Get template variables by template version",
-                "operationId": "get-template-variables-by-template-version",
+                "summary": "Unarchive template version by ID",
+                "operationId": "unarchive-template-version-by-id",
                 "parameters": [
                     {
                         "type": "string",
@@ -2855,26 +3279,154 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/codersdk.TemplateVersionVariable"
-                            }
+                            "$ref": "#/definitions/codersdk.TemplateVersion"
                         }
                     }
                 }
             }
         },
-        "/updatecheck": {
+        "/templateversions/{templateversion}/variables": {
             "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "General"
+                    "Templates"
                 ],
-                "summary": "Update check",
-                "operationId": "update-check",
-                "responses": {
+                "summary": "Get template variables by template version",
+                "operationId": "get-template-variables-by-template-version",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Template version ID",
+                        "name": "templateversion",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.TemplateVersionVariable"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/terminal-recordings": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Get terminal recordings",
+                "operationId": "get-terminal-recordings",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Agent ID",
+                        "name": "agent_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace owner ID",
+                        "name": "user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page limit",
+                        "name": "limit",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Page offset",
+                        "name": "offset",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.TerminalRecording"
+                            }
+                        }
+                    }
+                }
+            }
+        },
+        "/terminal-recordings/{recording}": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "description": "The recording is in the asciicast v2 format.",
+                "tags": [
+                    "Audit"
+                ],
+                "summary": "Download terminal recording",
+                "operationId": "download-terminal-recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Recording ID",
+                        "name": "recording",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                }
+            }
+        },
+        "/updatecheck": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "General"
+                ],
+                "summary": "Update check",
+                "operationId": "update-check",
+                "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
@@ -3185,6 +3737,99 @@ const docTemplate = `{
                 }
             }
         },
+        "/users/roles/custom": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Get custom site roles",
+                "operationId": "get-custom-site-roles",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.CustomRole"
+                            }
+                        }
+                    }
+                }
+            },
+            "put": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Upsert custom site role",
+                "operationId": "upsert-custom-site-role",
+                "parameters": [
+                    {
+                        "description": "Custom role",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CustomRole"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CustomRole"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/roles/custom/{role}": {
+            "delete": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "tags": [
+                    "Members"
+                ],
+                "summary": "Delete custom site role",
+                "operationId": "delete-custom-site-role",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Role name",
+                        "name": "role",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {
+                        "description": "No Content"
+                    }
+                }
+            }
+        },
         "/users/{user}": {
             "get": {
                 "security": [
@@ -4288,7 +4933,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaceagents/me/report-lifecycle": {
+        "/workspaceagents/me/metadata/{key}": {
             "post": {
                 "security": [
                     {
@@ -4301,17 +4946,25 @@ const docTemplate = `{
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent lifecycle state",
-                "operationId": "submit-workspace-agent-lifecycle-state",
+                "summary": "Submit workspace agent metadata",
+                "operationId": "submit-workspace-agent-metadata",
                 "parameters": [
                     {
-                        "description": "Workspace agent lifecycle request",
+                        "description": "Workspace agent metadata request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.PostLifecycleRequest"
+                            "$ref": "#/definitions/agentsdk.PostMetadataRequest"
                         }
+                    },
+                    {
+                        "type": "string",
+                        "format": "string",
+                        "description": "metadata key",
+                        "name": "key",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
@@ -4324,7 +4977,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaceagents/me/report-stats": {
+        "/workspaceagents/me/netcheck": {
             "post": {
                 "security": [
                     {
@@ -4334,36 +4987,33 @@ const docTemplate = `{
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent stats",
-                "operationId": "submit-workspace-agent-stats",
+                "summary": "Submit workspace agent netcheck",
+                "operationId": "submit-workspace-agent-netcheck",
                 "parameters": [
                     {
-                        "description": "Stats request",
+                        "description": "Netcheck report",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.Stats"
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentNetcheck"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/agentsdk.StatsResponse"
-                        }
+                    "204": {
+                        "description": "Success"
                     }
+                },
+                "x-apidocgen": {
+                    "skip": true
                 }
             }
         },
-        "/workspaceagents/me/startup": {
+        "/workspaceagents/me/report-lifecycle": {
             "post": {
                 "security": [
                     {
@@ -4373,28 +5023,25 @@ const docTemplate = `{
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Submit workspace agent startup",
-                "operationId": "submit-workspace-agent-startup",
+                "summary": "Submit workspace agent lifecycle state",
+                "operationId": "submit-workspace-agent-lifecycle-state",
                 "parameters": [
                     {
-                        "description": "Startup request",
+                        "description": "Workspace agent lifecycle request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.PostStartupRequest"
+                            "$ref": "#/definitions/agentsdk.PostLifecycleRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK"
+                    "204": {
+                        "description": "Success"
                     }
                 },
                 "x-apidocgen": {
@@ -4402,8 +5049,8 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaceagents/me/startup-logs": {
-            "patch": {
+        "/workspaceagents/me/report-stats": {
+            "post": {
                 "security": [
                     {
                         "CoderSessionToken": []
@@ -4418,16 +5065,16 @@ const docTemplate = `{
                 "tags": [
                     "Agents"
                 ],
-                "summary": "Patch workspace agent startup logs",
-                "operationId": "patch-workspace-agent-startup-logs",
+                "summary": "Submit workspace agent stats",
+                "operationId": "submit-workspace-agent-stats",
                 "parameters": [
                     {
-                        "description": "Startup logs",
+                        "description": "Stats request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/agentsdk.PatchStartupLogs"
+                            "$ref": "#/definitions/agentsdk.Stats"
                         }
                     }
                 ],
@@ -4435,12 +5082,150 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.Response"
+                            "$ref": "#/definitions/agentsdk.StatsResponse"
                         }
                     }
-                },
-                "x-apidocgen": {
-                    "skip": true
+                }
+            }
+        },
+        "/workspaceagents/me/startup": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Submit workspace agent startup",
+                "operationId": "submit-workspace-agent-startup",
+                "parameters": [
+                    {
+                        "description": "Startup request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/agentsdk.PostStartupRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK"
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceagents/me/startup-logs": {
+            "patch": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Patch workspace agent startup logs",
+                "operationId": "patch-workspace-agent-startup-logs",
+                "parameters": [
+                    {
+                        "description": "Startup logs",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/agentsdk.PatchStartupLogs"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceagents/me/terminal-recordings": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/x-asciicast"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Upload terminal recording",
+                "operationId": "upload-terminal-recording",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "default": "application/x-asciicast",
+                        "description": "Content-Type must be ` + "`" + `application/x-asciicast` + "`" + `",
+                        "name": "Content-Type",
+                        "in": "header",
+                        "required": true
+                    },
+                    {
+                        "enum": [
+                            "ssh",
+                            "reconnecting_pty"
+                        ],
+                        "type": "string",
+                        "description": "Recording type",
+                        "name": "type",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "ID of the user the client reported as connecting to the session, it is stored but not verified",
+                        "name": "client_reported_user_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "file",
+                        "description": "Recording in the asciicast v2 format",
+                        "name": "file",
+                        "in": "formData",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created"
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
                 }
             }
         },
@@ -4663,6 +5448,41 @@ const docTemplate = `{
                 }
             }
         },
+        "/workspaceagents/{workspaceagent}/watch-metadata": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "text/event-stream"
+                ],
+                "tags": [
+                    "Agents"
+                ],
+                "summary": "Watch for workspace agent metadata updates",
+                "operationId": "watch-for-workspace-agent-metadata-updates",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace agent ID",
+                        "name": "workspaceagent",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
         "/workspacebuilds/{workspacebuild}": {
             "get": {
                 "security": [
@@ -4894,7 +5714,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/workspaces": {
+        "/workspaceproxies": {
             "get": {
                 "security": [
                     {
@@ -4905,112 +5725,371 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Workspaces"
-                ],
-                "summary": "List workspaces",
-                "operationId": "list-workspaces",
-                "parameters": [
-                    {
-                        "type": "string",
-                        "description": "Filter by owner username",
-                        "name": "owner",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter by template name",
-                        "name": "template",
-                        "in": "query"
-                    },
-                    {
-                        "type": "string",
-                        "description": "Filter with partial-match by workspace name",
-                        "name": "name",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "pending",
-                            "running",
-                            "stopping",
-                            "stopped",
-                            "failed",
-                            "canceling",
-                            "canceled",
-                            "deleted",
-                            "deleting"
-                        ],
-                        "type": "string",
-                        "description": "Filter by workspace status",
-                        "name": "status",
-                        "in": "query"
-                    },
-                    {
-                        "enum": [
-                            "connected",
-                            "connecting",
-                            "disconnected",
-                            "timeout"
-                        ],
-                        "type": "string",
-                        "description": "Filter by agent status",
-                        "name": "has_agent",
-                        "in": "query"
-                    }
+                    "Enterprise"
                 ],
+                "summary": "Get workspace proxies",
+                "operationId": "get-workspace-proxies",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.WorkspacesResponse"
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.WorkspaceProxy"
+                            }
                         }
                     }
                 }
-            }
-        },
-        "/workspaces/{workspace}": {
-            "get": {
+            },
+            "post": {
                 "security": [
                     {
                         "CoderSessionToken": []
                     }
                 ],
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Workspaces"
+                    "Enterprise"
                 ],
-                "summary": "Get workspace metadata by ID",
-                "operationId": "get-workspace-metadata-by-id",
+                "summary": "Create workspace proxy",
+                "operationId": "create-workspace-proxy",
                 "parameters": [
                     {
-                        "type": "string",
-                        "format": "uuid",
-                        "description": "Workspace ID",
-                        "name": "workspace",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "boolean",
-                        "description": "Return data instead of HTTP 404 if the workspace is deleted",
-                        "name": "include_deleted",
-                        "in": "query"
+                        "description": "Create workspace proxy request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateWorkspaceProxyRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.Workspace"
+                            "$ref": "#/definitions/codersdk.CreateWorkspaceProxyResponse"
                         }
                     }
                 }
-            },
-            "patch": {
-                "security": [
-                    {
+            }
+        },
+        "/workspaceproxies/me/exchange-api-key": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Exchange encrypted API key for workspace proxy",
+                "operationId": "exchange-encrypted-api-key-for-workspace-proxy",
+                "parameters": [
+                    {
+                        "description": "Exchange API key request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.ExchangeAPIKeyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.ExchangeAPIKeyResponse"
+                        }
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceproxies/me/issue-ticket": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Issue signed workspace app ticket",
+                "operationId": "issue-signed-workspace-app-ticket",
+                "parameters": [
+                    {
+                        "description": "Issue signed app ticket request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.IssueSignedAppTicketRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.IssueSignedAppTicketResponse"
+                        }
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceproxies/me/register": {
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Register workspace proxy",
+                "operationId": "register-workspace-proxy",
+                "parameters": [
+                    {
+                        "description": "Register workspace proxy request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.RegisterWorkspaceProxyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/wsproxysdk.RegisterWorkspaceProxyResponse"
+                        }
+                    }
+                },
+                "x-apidocgen": {
+                    "skip": true
+                }
+            }
+        },
+        "/workspaceproxies/{workspaceproxy}": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Get workspace proxy",
+                "operationId": "get-workspace-proxy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Proxy ID or name",
+                        "name": "workspaceproxy",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceProxy"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Enterprise"
+                ],
+                "summary": "Delete workspace proxy",
+                "operationId": "delete-workspace-proxy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Proxy ID or name",
+                        "name": "workspaceproxy",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "List workspaces",
+                "operationId": "list-workspaces",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Filter by owner username",
+                        "name": "owner",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter by template name",
+                        "name": "template",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Filter with partial-match by workspace name",
+                        "name": "name",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "pending",
+                            "running",
+                            "stopping",
+                            "stopped",
+                            "failed",
+                            "canceling",
+                            "canceled",
+                            "deleted",
+                            "deleting"
+                        ],
+                        "type": "string",
+                        "description": "Filter by workspace status",
+                        "name": "status",
+                        "in": "query"
+                    },
+                    {
+                        "enum": [
+                            "connected",
+                            "connecting",
+                            "disconnected",
+                            "timeout"
+                        ],
+                        "type": "string",
+                        "description": "Filter by agent status",
+                        "name": "has_agent",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspacesResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{workspace}": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Get workspace metadata by ID",
+                "operationId": "get-workspace-metadata-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Return data instead of HTTP 404 if the workspace is deleted",
+                        "name": "include_deleted",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Workspace"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
                         "CoderSessionToken": []
                     }
                 ],
@@ -5134,20 +6213,194 @@ const docTemplate = `{
                     },
                     {
                         "type": "string",
-                        "format": "date-time",
-                        "description": "Since timestamp",
-                        "name": "since",
-                        "in": "query"
+                        "format": "date-time",
+                        "description": "Since timestamp",
+                        "name": "since",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/codersdk.WorkspaceBuild"
+                            }
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Builds"
+                ],
+                "summary": "Create workspace build",
+                "operationId": "create-workspace-build",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Create workspace build request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.CreateWorkspaceBuildRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.WorkspaceBuild"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{workspace}/extend": {
+            "put": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Extend workspace deadline by ID",
+                "operationId": "extend-workspace-deadline-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Extend deadline update request",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.PutExtendWorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{workspace}/lock": {
+            "put": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Update workspace lock by ID",
+                "operationId": "update-workspace-lock-by-id",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Lock or unlock a workspace",
+                        "name": "request",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.UpdateWorkspaceLock"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/codersdk.Response"
+                        }
+                    }
+                }
+            }
+        },
+        "/workspaces/{workspace}/port-shares": {
+            "get": {
+                "security": [
+                    {
+                        "CoderSessionToken": []
+                    }
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Workspaces"
+                ],
+                "summary": "Get workspace port shares",
+                "operationId": "get-workspace-port-shares",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "format": "uuid",
+                        "description": "Workspace ID",
+                        "name": "workspace",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/codersdk.WorkspaceBuild"
-                            }
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortSharesResponse"
                         }
                     }
                 }
@@ -5165,10 +6418,10 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Builds"
+                    "Workspaces"
                 ],
-                "summary": "Create workspace build",
-                "operationId": "create-workspace-build",
+                "summary": "Upsert workspace port share",
+                "operationId": "upsert-workspace-port-share",
                 "parameters": [
                     {
                         "type": "string",
@@ -5179,12 +6432,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Create workspace build request",
+                        "description": "Upsert port share request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/codersdk.CreateWorkspaceBuildRequest"
+                            "$ref": "#/definitions/codersdk.UpsertWorkspaceAgentPortShareRequest"
                         }
                     }
                 ],
@@ -5192,14 +6445,12 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/codersdk.WorkspaceBuild"
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentPortShare"
                         }
                     }
                 }
-            }
-        },
-        "/workspaces/{workspace}/extend": {
-            "put": {
+            },
+            "delete": {
                 "security": [
                     {
                         "CoderSessionToken": []
@@ -5208,14 +6459,11 @@ const docTemplate = `{
                 "consumes": [
                     "application/json"
                 ],
-                "produces": [
-                    "application/json"
-                ],
                 "tags": [
                     "Workspaces"
                 ],
-                "summary": "Extend workspace deadline by ID",
-                "operationId": "extend-workspace-deadline-by-id",
+                "summary": "Delete workspace port share",
+                "operationId": "delete-workspace-port-share",
                 "parameters": [
                     {
                         "type": "string",
@@ -5226,21 +6474,18 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Extend deadline update request",
+                        "description": "Delete port share request",
                         "name": "request",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/codersdk.PutExtendWorkspaceRequest"
+                            "$ref": "#/definitions/codersdk.DeleteWorkspaceAgentPortShareRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "$ref": "#/definitions/codersdk.Response"
-                        }
+                    "204": {
+                        "description": "No Content"
                     }
                 }
             }
@@ -5422,9 +6667,20 @@ const docTemplate = `{
                     "description": "GitAuthConfigs stores the number of Git configurations\nthe Coder deployment has. If this number is \u003e0, we\nset up special configuration in the workspace.",
                     "type": "integer"
                 },
+                "metadata": {
+                    "description": "Metadata describes the dynamic metadata the agent should collect\nand report back to coderd.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentMetadataDescription"
+                    }
+                },
                 "motd_file": {
                     "type": "string"
                 },
+                "record_terminal_sessions": {
+                    "description": "RecordTerminalSessions is true if the deployment or the template\nrequires terminal sessions to be recorded.",
+                    "type": "boolean"
+                },
                 "shutdown_script": {
                     "type": "string"
                 },
@@ -5473,6 +6729,24 @@ const docTemplate = `{
                 }
             }
         },
+        "agentsdk.PostMetadataRequest": {
+            "type": "object",
+            "properties": {
+                "age": {
+                    "description": "Age is the number of seconds since the metadata was collected.\nIt is provided in addition to CollectedAt to protect against clock skew.",
+                    "type": "integer"
+                },
+                "collected_at": {
+                    "type": "string"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
         "agentsdk.PostStartupRequest": {
             "type": "object",
             "properties": {
@@ -5846,10 +7120,18 @@ const docTemplate = `{
                         }
                     ]
                 },
+                "resources": {
+                    "description": "Resources lists the actions a key with the resources scope may\nperform.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.APIKeyScopeResource"
+                    }
+                },
                 "scope": {
                     "enum": [
                         "all",
-                        "application_connect"
+                        "application_connect",
+                        "resources"
                     ],
                     "allOf": [
                         {
@@ -5874,13 +7156,36 @@ const docTemplate = `{
             "type": "string",
             "enum": [
                 "all",
-                "application_connect"
+                "application_connect",
+                "resources"
             ],
             "x-enum-varnames": [
                 "APIKeyScopeAll",
-                "APIKeyScopeApplicationConnect"
+                "APIKeyScopeApplicationConnect",
+                "APIKeyScopeResources"
             ]
         },
+        "codersdk.APIKeyScopeResource": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string",
+                    "enum": [
+                        "create",
+                        "read",
+                        "update",
+                        "delete"
+                    ]
+                },
+                "resource_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "resource_type": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.AddLicenseRequest": {
             "type": "object",
             "required": [
@@ -5969,6 +7274,98 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.AuditExportConfig": {
+            "type": "object",
+            "properties": {
+                "syslog_address": {
+                    "type": "string"
+                },
+                "syslog_facility": {
+                    "type": "string"
+                },
+                "syslog_tls": {
+                    "type": "boolean"
+                },
+                "webhook_batch_size": {
+                    "type": "integer"
+                },
+                "webhook_flush_interval": {
+                    "type": "integer"
+                },
+                "webhook_queue_size": {
+                    "type": "integer"
+                },
+                "webhook_secret": {
+                    "type": "string"
+                },
+                "webhook_url": {
+                    "$ref": "#/definitions/clibase.URL"
+                }
+            }
+        },
+        "codersdk.AuditFilterRule": {
+            "type": "object",
+            "properties": {
+                "actions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.AuditAction"
+                    }
+                },
+                "build_reasons": {
+                    "description": "BuildReasons matches workspace build audit logs, e.g. to filter builds\nstarted by autostart.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.BuildReason"
+                    }
+                },
+                "diff_fields": {
+                    "description": "DiffFields matches audit logs that changed any of the given fields.",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "export": {
+                    "description": "Export sends matching audit logs to external backends, such as the\nwebhook and syslog backends.",
+                    "type": "boolean"
+                },
+                "resource_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.ResourceType"
+                    }
+                },
+                "status_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "store": {
+                    "description": "Store keeps matching audit logs in the database.",
+                    "type": "boolean"
+                },
+                "user_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "string",
+                        "format": "uuid"
+                    }
+                }
+            }
+        },
+        "codersdk.AuditFilterRules": {
+            "type": "object",
+            "properties": {
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.AuditFilterRule"
+                    }
+                }
+            }
+        },
         "codersdk.AuditLog": {
             "type": "object",
             "properties": {
@@ -6154,12 +7551,18 @@ const docTemplate = `{
             "enum": [
                 "initiator",
                 "autostart",
-                "autostop"
+                "autostop",
+                "autolock",
+                "autodelete",
+                "failedstop"
             ],
             "x-enum-varnames": [
                 "BuildReasonInitiator",
                 "BuildReasonAutostart",
-                "BuildReasonAutostop"
+                "BuildReasonAutostop",
+                "BuildReasonAutolock",
+                "BuildReasonAutodelete",
+                "BuildReasonFailedStop"
             ]
         },
         "codersdk.CreateFirstUserRequest": {
@@ -6356,6 +7759,14 @@ const docTemplate = `{
                     "type": "string",
                     "format": "uuid"
                 },
+                "git_source": {
+                    "description": "GitSource creates the template version from a git repository, which is\ncloned by coderd.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionGitSource"
+                        }
+                    ]
+                },
                 "name": {
                     "type": "string"
                 },
@@ -6469,10 +7880,18 @@ const docTemplate = `{
                 "lifetime": {
                     "type": "integer"
                 },
+                "resources": {
+                    "description": "Resources must be set when Scope is \"resources\". All resources of\nthe same type must be granted the same actions.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.APIKeyScopeResource"
+                    }
+                },
                 "scope": {
                     "enum": [
                         "all",
-                        "application_connect"
+                        "application_connect",
+                        "resources"
                     ],
                     "allOf": [
                         {
@@ -6561,6 +7980,35 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.CreateWorkspaceProxyRequest": {
+            "type": "object",
+            "required": [
+                "name"
+            ],
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.CreateWorkspaceProxyResponse": {
+            "type": "object",
+            "properties": {
+                "proxy": {
+                    "$ref": "#/definitions/codersdk.WorkspaceProxy"
+                },
+                "proxy_token": {
+                    "description": "ProxyToken is the token the proxy uses to authenticate with the primary\ndeployment. It is only returned once.",
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.CreateWorkspaceRequest": {
             "type": "object",
             "required": [
@@ -6596,6 +8044,39 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.CustomRole": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "organization_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "organization_permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.Permission"
+                    }
+                },
+                "site_permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.Permission"
+                    }
+                },
+                "user_permissions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.Permission"
+                    }
+                }
+            }
+        },
         "codersdk.DAUEntry": {
             "type": "object",
             "properties": {
@@ -6678,6 +8159,21 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.DeleteWorkspaceAgentPortShareRequest": {
+            "type": "object",
+            "required": [
+                "agent_name",
+                "port"
+            ],
+            "properties": {
+                "agent_name": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                }
+            }
+        },
         "codersdk.DeploymentConfig": {
             "type": "object",
             "properties": {
@@ -6749,6 +8245,9 @@ const docTemplate = `{
                 "agent_stat_refresh_interval": {
                     "type": "integer"
                 },
+                "audit_export": {
+                    "$ref": "#/definitions/codersdk.AuditExportConfig"
+                },
                 "audit_logging": {
                     "type": "boolean"
                 },
@@ -6843,9 +8342,15 @@ const docTemplate = `{
                 "rate_limit": {
                     "$ref": "#/definitions/codersdk.RateLimitConfig"
                 },
+                "record_terminal_sessions": {
+                    "type": "boolean"
+                },
                 "redirect_to_access_url": {
                     "type": "boolean"
                 },
+                "retention": {
+                    "$ref": "#/definitions/codersdk.RetentionConfig"
+                },
                 "scim_api_key": {
                     "type": "string"
                 },
@@ -7104,6 +8609,27 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.IssueReconnectingPTYTicketRequest": {
+            "type": "object",
+            "required": [
+                "agent_id"
+            ],
+            "properties": {
+                "agent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "codersdk.IssueReconnectingPTYTicketResponse": {
+            "type": "object",
+            "properties": {
+                "ticket": {
+                    "description": "Ticket is a signed app ticket that can be passed to the reconnecting\nPTY endpoint of a workspace proxy in the \"coder_app_ticket\" query\nparameter.",
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.JobErrorCode": {
             "type": "string",
             "enum": [
@@ -7573,6 +9099,20 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.Permission": {
+            "type": "object",
+            "properties": {
+                "action": {
+                    "type": "string"
+                },
+                "negate": {
+                    "type": "boolean"
+                },
+                "resource_type": {
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.PprofConfig": {
             "type": "object",
             "properties": {
@@ -7779,6 +9319,21 @@ const docTemplate = `{
                 "ProvisionerStorageMethodFile"
             ]
         },
+        "codersdk.ProxyHealthStatus": {
+            "type": "string",
+            "enum": [
+                "reachable",
+                "unreachable",
+                "unhealthy",
+                "unregistered"
+            ],
+            "x-enum-varnames": [
+                "ProxyReachable",
+                "ProxyUnreachable",
+                "ProxyUnhealthy",
+                "ProxyUnregistered"
+            ]
+        },
         "codersdk.PutExtendWorkspaceRequest": {
             "type": "object",
             "required": [
@@ -7802,6 +9357,46 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.Region": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "healthy": {
+                    "type": "boolean"
+                },
+                "icon_url": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "path_app_url": {
+                    "description": "PathAppURL is the URL to the base path for path apps. Optional\nunless wildcard_hostname is set.\nE.g. https://us.example.com",
+                    "type": "string"
+                },
+                "wildcard_hostname": {
+                    "description": "WildcardHostname is the wildcard hostname for subdomain apps.\nE.g. *.us.example.com\nE.g. *--suffix.au.example.com\nOptional. Does not need to be on the same domain as PathAppURL.",
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.RegionsResponse": {
+            "type": "object",
+            "properties": {
+                "regions": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.Region"
+                    }
+                }
+            }
+        },
         "codersdk.Replica": {
             "type": "object",
             "properties": {
@@ -7848,7 +9443,10 @@ const docTemplate = `{
                 "git_ssh_key",
                 "api_key",
                 "group",
-                "license"
+                "license",
+                "workspace_proxy",
+                "audit_filter_rules",
+                "workspace_agent_port_share"
             ],
             "x-enum-varnames": [
                 "ResourceTypeTemplate",
@@ -7859,7 +9457,10 @@ const docTemplate = `{
                 "ResourceTypeGitSSHKey",
                 "ResourceTypeAPIKey",
                 "ResourceTypeGroup",
-                "ResourceTypeLicense"
+                "ResourceTypeLicense",
+                "ResourceTypeWorkspaceProxy",
+                "ResourceTypeAuditFilterRules",
+                "ResourceTypeWorkspaceAgentPortShare"
             ]
         },
         "codersdk.Response": {
@@ -7882,6 +9483,23 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.RetentionConfig": {
+            "type": "object",
+            "properties": {
+                "archive_dir": {
+                    "type": "string"
+                },
+                "audit_logs": {
+                    "type": "integer"
+                },
+                "provisioner_job_logs": {
+                    "type": "integer"
+                },
+                "workspace_build_state": {
+                    "type": "integer"
+                }
+            }
+        },
         "codersdk.Role": {
             "type": "object",
             "properties": {
@@ -8062,6 +9680,9 @@ const docTemplate = `{
                 "display_name": {
                     "type": "string"
                 },
+                "failure_ttl_ms": {
+                    "type": "integer"
+                },
                 "icon": {
                     "type": "string"
                 },
@@ -8069,6 +9690,26 @@ const docTemplate = `{
                     "type": "string",
                     "format": "uuid"
                 },
+                "inactivity_ttl_ms": {
+                    "description": "InactivityTTLMillis, LockedTTLMillis and FailureTTLMillis are enterprise\nfeatures. Their values are only used if your license is entitled to use\nthe advanced template scheduling feature.",
+                    "type": "integer"
+                },
+                "locked_ttl_ms": {
+                    "type": "integer"
+                },
+                "max_port_share_level": {
+                    "description": "MaxPortShareLevel is the most permissive level workspace owners may\nshare listening ports at.",
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                },
                 "max_ttl_ms": {
                     "description": "MaxTTLMillis is an enterprise feature. It's value is only used if your\nlicense is entitled to use the advanced template scheduling feature.",
                     "type": "integer"
@@ -8086,6 +9727,10 @@ const docTemplate = `{
                         "terraform"
                     ]
                 },
+                "record_terminal_sessions": {
+                    "description": "RecordTerminalSessions records SSH and web terminal sessions in\nworkspaces created from this template.",
+                    "type": "boolean"
+                },
                 "updated_at": {
                     "type": "string",
                     "format": "date-time"
@@ -8224,6 +9869,10 @@ const docTemplate = `{
         "codersdk.TemplateVersion": {
             "type": "object",
             "properties": {
+                "archived": {
+                    "description": "Archived versions are hidden from version pickers and cannot be used\nfor new workspace builds.",
+                    "type": "boolean"
+                },
                 "created_at": {
                     "type": "string",
                     "format": "date-time"
@@ -8231,6 +9880,10 @@ const docTemplate = `{
                 "created_by": {
                     "$ref": "#/definitions/codersdk.User"
                 },
+                "git_commit_sha": {
+                    "description": "GitCommitSHA is the commit the template version was created from, if\nit was created from a git repository.",
+                    "type": "string"
+                },
                 "id": {
                     "type": "string",
                     "format": "uuid"
@@ -8258,6 +9911,94 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.TemplateVersionDiff": {
+            "type": "object",
+            "properties": {
+                "base_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "files": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.TemplateVersionFileDiff"
+                    }
+                },
+                "git_auth_providers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.TemplateVersionGitAuthProviderDiff"
+                    }
+                },
+                "head_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "parameter_schemas": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.TemplateVersionParameterSchemaDiff"
+                    }
+                },
+                "rich_parameters": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.TemplateVersionRichParameterDiff"
+                    }
+                },
+                "variables": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.TemplateVersionVariableDiff"
+                    }
+                }
+            }
+        },
+        "codersdk.TemplateVersionDiffChange": {
+            "type": "string",
+            "enum": [
+                "added",
+                "removed",
+                "modified"
+            ],
+            "x-enum-varnames": [
+                "TemplateVersionDiffChangeAdded",
+                "TemplateVersionDiffChangeRemoved",
+                "TemplateVersionDiffChangeModified"
+            ]
+        },
+        "codersdk.TemplateVersionFileDiff": {
+            "type": "object",
+            "properties": {
+                "binary": {
+                    "description": "Binary is true if either side of the change isn't text. No diff is\nincluded for binary files.",
+                    "type": "boolean"
+                },
+                "change": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "modified"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiffChange"
+                        }
+                    ]
+                },
+                "diff": {
+                    "description": "Diff is the change in unified diff format.",
+                    "type": "string"
+                },
+                "path": {
+                    "type": "string"
+                },
+                "too_large": {
+                    "description": "TooLarge is true if either side of the change is larger than 10 MiB.\nNo diff is included for files that are too large.",
+                    "type": "boolean"
+                }
+            }
+        },
         "codersdk.TemplateVersionGitAuth": {
             "type": "object",
             "properties": {
@@ -8275,6 +10016,45 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.TemplateVersionGitAuthProviderDiff": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "enum": [
+                        "added",
+                        "removed"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiffChange"
+                        }
+                    ]
+                },
+                "id": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.TemplateVersionGitSource": {
+            "type": "object",
+            "required": [
+                "url"
+            ],
+            "properties": {
+                "directory": {
+                    "description": "Directory is the path of the template within the repository. Defaults\nto the root of the repository.",
+                    "type": "string"
+                },
+                "ref": {
+                    "description": "Ref is a branch, tag or commit SHA. Defaults to the default branch of\nthe repository.",
+                    "type": "string"
+                },
+                "url": {
+                    "description": "URL is the HTTP(S) URL of the repository.",
+                    "type": "string"
+                }
+            }
+        },
         "codersdk.TemplateVersionParameter": {
             "type": "object",
             "properties": {
@@ -8287,6 +10067,9 @@ const docTemplate = `{
                 "description_plaintext": {
                     "type": "string"
                 },
+                "ephemeral": {
+                    "type": "boolean"
+                },
                 "icon": {
                     "type": "string"
                 },
@@ -8359,6 +10142,58 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.TemplateVersionParameterSchemaDiff": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "modified"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiffChange"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new": {
+                    "$ref": "#/definitions/codersdk.ParameterSchema"
+                },
+                "old": {
+                    "$ref": "#/definitions/codersdk.ParameterSchema"
+                }
+            }
+        },
+        "codersdk.TemplateVersionRichParameterDiff": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "modified"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiffChange"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new": {
+                    "$ref": "#/definitions/codersdk.TemplateVersionParameter"
+                },
+                "old": {
+                    "$ref": "#/definitions/codersdk.TemplateVersionParameter"
+                }
+            }
+        },
         "codersdk.TemplateVersionVariable": {
             "type": "object",
             "properties": {
@@ -8385,11 +10220,106 @@ const docTemplate = `{
                         "bool"
                     ]
                 },
-                "value": {
-                    "type": "string"
+                "value": {
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.TemplateVersionVariableDiff": {
+            "type": "object",
+            "properties": {
+                "change": {
+                    "enum": [
+                        "added",
+                        "removed",
+                        "modified"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TemplateVersionDiffChange"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new": {
+                    "$ref": "#/definitions/codersdk.TemplateVersionVariable"
+                },
+                "old": {
+                    "$ref": "#/definitions/codersdk.TemplateVersionVariable"
+                }
+            }
+        },
+        "codersdk.TerminalRecording": {
+            "type": "object",
+            "properties": {
+                "agent_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "client_reported_user_id": {
+                    "description": "ClientReportedUserID is the user the client reported as connecting\nto the session. It isn't verified, any client that can reach the\nagent can set it.",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "ended_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "organization_id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "size": {
+                    "description": "Size is the size of the recording in bytes.",
+                    "type": "integer"
+                },
+                "started_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "type": {
+                    "enum": [
+                        "ssh",
+                        "reconnecting_pty"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.TerminalRecordingType"
+                        }
+                    ]
+                },
+                "user_id": {
+                    "description": "UserID is the owner of the workspace at the time of the recording.",
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "workspace_id": {
+                    "type": "string",
+                    "format": "uuid"
                 }
             }
         },
+        "codersdk.TerminalRecordingType": {
+            "type": "string",
+            "enum": [
+                "ssh",
+                "reconnecting_pty"
+            ],
+            "x-enum-varnames": [
+                "TerminalRecordingTypeSSH",
+                "TerminalRecordingTypeReconnectingPTY"
+            ]
+        },
         "codersdk.TokenConfig": {
             "type": "object",
             "properties": {
@@ -8526,6 +10456,14 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.UpdateWorkspaceLock": {
+            "type": "object",
+            "properties": {
+                "lock": {
+                    "type": "boolean"
+                }
+            }
+        },
         "codersdk.UpdateWorkspaceRequest": {
             "type": "object",
             "properties": {
@@ -8551,6 +10489,35 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.UpsertWorkspaceAgentPortShareRequest": {
+            "type": "object",
+            "required": [
+                "agent_name",
+                "port",
+                "share_level"
+            ],
+            "properties": {
+                "agent_name": {
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "share_level": {
+                    "description": "ShareLevel can't be more permissive than the max port share level of\nthe template.",
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                }
+            }
+        },
         "codersdk.User": {
             "type": "object",
             "required": [
@@ -8678,6 +10645,11 @@ const docTemplate = `{
                 "latest_build": {
                     "$ref": "#/definitions/codersdk.WorkspaceBuild"
                 },
+                "locked_at": {
+                    "description": "LockedAt being non-nil indicates a workspace that has been locked due\nto inactivity. A locked workspace cannot be started until it is\nunlocked.",
+                    "type": "string",
+                    "format": "date-time"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -8787,6 +10759,14 @@ const docTemplate = `{
                 "name": {
                     "type": "string"
                 },
+                "netcheck": {
+                    "description": "Netcheck is the latest netcheck the agent reported, describing the\nnetwork of the workspace.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAgentNetcheck"
+                        }
+                    ]
+                },
                 "operating_system": {
                     "type": "string"
                 },
@@ -8865,10 +10845,20 @@ const docTemplate = `{
             "type": "object",
             "properties": {
                 "network": {
-                    "description": "only \"tcp\" at the moment",
+                    "description": "\"tcp\", \"udp\" or \"unix\"",
+                    "type": "string",
+                    "enum": [
+                        "tcp",
+                        "udp",
+                        "unix"
+                    ]
+                },
+                "path": {
+                    "description": "only set for Unix sockets",
                     "type": "string"
                 },
                 "port": {
+                    "description": "zero for Unix sockets",
                     "type": "integer"
                 },
                 "process_name": {
@@ -8889,6 +10879,122 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.WorkspaceAgentMetadataDescription": {
+            "type": "object",
+            "properties": {
+                "display_name": {
+                    "type": "string"
+                },
+                "interval": {
+                    "description": "Interval is the number of seconds between script runs.",
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "script": {
+                    "type": "string"
+                },
+                "timeout": {
+                    "description": "Timeout is the number of seconds a single script run may take.",
+                    "type": "integer"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentNetcheck": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "type": "string"
+                },
+                "global_v4": {
+                    "description": "GlobalV4 and GlobalV6 are the public addresses of the agent as seen\nby the STUN servers.",
+                    "type": "string"
+                },
+                "global_v6": {
+                    "type": "string"
+                },
+                "ipv4": {
+                    "type": "boolean"
+                },
+                "ipv6": {
+                    "type": "boolean"
+                },
+                "mapping_varies_by_dest_ip": {
+                    "description": "MappingVariesByDestIP is whether the public port of the agent depends\non the destination, which prevents direct connections unless the\nclient is reachable. It's unset if unknown.",
+                    "type": "boolean"
+                },
+                "nat_type": {
+                    "description": "NATType is \"none\", \"easy\", \"hard\", \"udp_blocked\" or \"unknown\".",
+                    "type": "string"
+                },
+                "preferred_derp": {
+                    "type": "integer"
+                },
+                "region_latency_ms": {
+                    "description": "RegionLatencyMS is the latency to each reachable DERP region, keyed\nby region ID.",
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "number"
+                    }
+                },
+                "udp": {
+                    "description": "UDP is whether a UDP round trip to a STUN server completed.",
+                    "type": "boolean"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentPortShare": {
+            "type": "object",
+            "properties": {
+                "agent_name": {
+                    "description": "AgentName identifies the agent, so the share persists across\nworkspace builds.",
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "share_level": {
+                    "enum": [
+                        "owner",
+                        "authenticated",
+                        "public"
+                    ],
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/codersdk.WorkspaceAppSharingLevel"
+                        }
+                    ]
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "workspace_id": {
+                    "type": "string",
+                    "format": "uuid"
+                }
+            }
+        },
+        "codersdk.WorkspaceAgentPortSharesResponse": {
+            "type": "object",
+            "properties": {
+                "shares": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/codersdk.WorkspaceAgentPortShare"
+                    }
+                }
+            }
+        },
         "codersdk.WorkspaceAgentStartupLog": {
             "type": "object",
             "properties": {
@@ -9045,7 +11151,10 @@ const docTemplate = `{
                     "enum": [
                         "initiator",
                         "autostart",
-                        "autostop"
+                        "autostop",
+                        "autolock",
+                        "autodelete",
+                        "failedstop"
                     ],
                     "allOf": [
                         {
@@ -9168,6 +11277,62 @@ const docTemplate = `{
                 }
             }
         },
+        "codersdk.WorkspaceProxy": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "deleted": {
+                    "type": "boolean"
+                },
+                "display_name": {
+                    "type": "string"
+                },
+                "icon": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "string",
+                    "format": "uuid"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/codersdk.WorkspaceProxyStatus"
+                },
+                "updated_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "url": {
+                    "description": "URL is the access URL of the proxy. It is empty until the proxy has\nregistered with the primary deployment.",
+                    "type": "string"
+                },
+                "wildcard_hostname": {
+                    "description": "WildcardHostname is the wildcard hostname for subdomain apps on the\nproxy, e.g. \"*.us.example.com\".",
+                    "type": "string"
+                }
+            }
+        },
+        "codersdk.WorkspaceProxyStatus": {
+            "type": "object",
+            "properties": {
+                "checked_at": {
+                    "type": "string",
+                    "format": "date-time"
+                },
+                "error": {
+                    "description": "Error is the reason the proxy is not reachable or healthy, if any.",
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/codersdk.ProxyHealthStatus"
+                }
+            }
+        },
         "codersdk.WorkspaceQuota": {
             "type": "object",
             "properties": {
@@ -9493,6 +11658,112 @@ const docTemplate = `{
         },
         "url.Userinfo": {
             "type": "object"
+        },
+        "workspaceapps.AccessMethod": {
+            "type": "string",
+            "enum": [
+                "path",
+                "subdomain",
+                "terminal"
+            ],
+            "x-enum-varnames": [
+                "AccessMethodPath",
+                "AccessMethodSubdomain",
+                "AccessMethodTerminal"
+            ]
+        },
+        "workspaceapps.Request": {
+            "type": "object",
+            "properties": {
+                "access_method": {
+                    "$ref": "#/definitions/workspaceapps.AccessMethod"
+                },
+                "agent_name_or_id": {
+                    "description": "AgentNameOrID is not required if the workspace has only one agent.",
+                    "type": "string"
+                },
+                "app_slug_or_port": {
+                    "type": "string"
+                },
+                "base_path": {
+                    "description": "BasePath of the app. For path apps, this is the path prefix in the router\nfor this particular app. For subdomain apps, this should be \"/\". This is\nused for setting the cookie path.",
+                    "type": "string"
+                },
+                "username_or_id": {
+                    "type": "string"
+                },
+                "workspace_and_agent": {
+                    "description": "WorkspaceAndAgent xor WorkspaceNameOrID are required.",
+                    "type": "string"
+                },
+                "workspace_name_or_id": {
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.ExchangeAPIKeyRequest": {
+            "type": "object",
+            "properties": {
+                "encrypted_api_key": {
+                    "description": "EncryptedAPIKey is the API key smuggled to the proxy by the\napplication auth redirect flow.",
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.ExchangeAPIKeyResponse": {
+            "type": "object",
+            "properties": {
+                "api_key": {
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.IssueSignedAppTicketRequest": {
+            "type": "object",
+            "properties": {
+                "app_request": {
+                    "$ref": "#/definitions/workspaceapps.Request"
+                },
+                "request_url": {
+                    "description": "RequestURL is the absolute URL of the request on the proxy. It is used\nto redirect the user back to the proxy after logging in.",
+                    "type": "string"
+                },
+                "session_token": {
+                    "description": "SessionToken is the session token of the user accessing the app, if\nany. It is read from the app cookie on the proxy.",
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.IssueSignedAppTicketResponse": {
+            "type": "object",
+            "properties": {
+                "signed_ticket": {
+                    "description": "SignedTicket is a signed ticket that can be verified with the app\nsecurity key.",
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.RegisterWorkspaceProxyRequest": {
+            "type": "object",
+            "properties": {
+                "access_url": {
+                    "description": "AccessURL that hits the workspace proxy api.",
+                    "type": "string"
+                },
+                "wildcard_hostname": {
+                    "description": "WildcardHostname that the workspace proxy api is serving for subdomain\napps.",
+                    "type": "string"
+                }
+            }
+        },
+        "wsproxysdk.RegisterWorkspaceProxyResponse": {
+            "type": "object",
+            "properties": {
+                "app_security_key": {
+                    "description": "AppSecurityKey is the hex encoded key used to verify signed app\ntickets issued by the primary deployment.",
+                    "type": "string"
+                }
+            }
         }
     },
     "securityDefinitions": {
@@ -65,6 +65,18 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 		scope = database.APIKeyScope(createToken.Scope)
 	}
 
+	scopeResources, err := convertScopeResources(user.ID, scope, createToken.Resources)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid token scope.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "resources",
+				Detail: err.Error(),
+			}},
+		})
+		return
+	}
+
 	// default lifetime is 30 days
 	lifeTime := 30 * 24 * time.Hour
 	if createToken.Lifetime != 0 {
@@ -77,7 +89,7 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 		tokenName = createToken.TokenName
 	}
 
-	err := api.validateAPIKeyLifetime(lifeTime)
+	err = api.validateAPIKeyLifetime(lifeTime)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message: "Failed to validate create API key request.",
@@ -91,6 +103,7 @@ func (api *API) postToken(rw http.ResponseWriter, r *http.Request) {
 		LoginType:       database.LoginTypeToken,
 		ExpiresAt:       database.Now().Add(lifeTime),
 		Scope:           scope,
+		ScopeResources:  scopeResources,
 		LifetimeSeconds: int64(lifeTime.Seconds()),
 		TokenName:       tokenName,
 	})
@@ -180,7 +193,16 @@ func (api *API) apiKeyByID(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(key))
+	resources, err := api.apiKeyScopeResources(ctx, key)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key scope.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(key, resources))
 }
 
 // @Summary Get API key by token name
@@ -215,7 +237,16 @@ func (api *API) apiKeyByName(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(token))
+	resources, err := api.apiKeyScopeResources(ctx, token)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching API key scope.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertAPIKey(token, resources))
 }
 
 // @Summary Get user tokens
@@ -268,8 +299,27 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	var userIds []uuid.UUID
+	var scopedKeyIDs []string
 	for _, key := range keys {
 		userIds = append(userIds, key.UserID)
+		if key.Scope == database.APIKeyScopeResources {
+			scopedKeyIDs = append(scopedKeyIDs, key.ID)
+		}
+	}
+
+	resourcesByKeyID := map[string][]database.APIKeyScopeResource{}
+	if len(scopedKeyIDs) > 0 {
+		resources, err := api.Database.GetAPIKeyScopeResourcesByAPIKeyIDs(ctx, scopedKeyIDs)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching API key scopes.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		for _, resource := range resources {
+			resourcesByKeyID[resource.APIKeyID] = append(resourcesByKeyID[resource.APIKeyID], resource)
+		}
 	}
 
 	users, _ := api.Database.GetUsersByIDs(ctx, userIds)
@@ -282,12 +332,12 @@ func (api *API) tokens(rw http.ResponseWriter, r *http.Request) {
 	for _, key := range keys {
 		if user, exists := usersByID[key.UserID]; exists {
 			apiKeys = append(apiKeys, codersdk.APIKeyWithOwner{
-				APIKey:   convertAPIKey(key),
+				APIKey:   convertAPIKey(key, resourcesByKeyID[key.ID]),
 				Username: user.Username,
 			})
 		} else {
 			apiKeys = append(apiKeys, codersdk.APIKeyWithOwner{
-				APIKey:   convertAPIKey(key),
+				APIKey:   convertAPIKey(key, resourcesByKeyID[key.ID]),
 				Username: "",
 			})
 		}
@@ -395,7 +445,9 @@ type createAPIKeyParams struct {
 	ExpiresAt       time.Time
 	LifetimeSeconds int64
 	Scope           database.APIKeyScope
-	TokenName       string
+	// ScopeResources must be set for the resources scope.
+	ScopeResources []rbac.ScopeResource
+	TokenName      string
 }
 
 func (api *API) validateAPIKeyLifetime(lifetime time.Duration) error {
@@ -446,32 +498,61 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 	}
 	switch scope {
 	case database.APIKeyScopeAll, database.APIKeyScopeApplicationConnect:
+		if len(params.ScopeResources) > 0 {
+			return nil, nil, xerrors.Errorf("API key scope %q must not have resources", scope)
+		}
+	case database.APIKeyScopeResources:
+		if len(params.ScopeResources) == 0 {
+			return nil, nil, xerrors.Errorf("API key scope %q must have resources", scope)
+		}
 	default:
 		return nil, nil, xerrors.Errorf("invalid API key scope: %q", scope)
 	}
 
-	key, err := api.Database.InsertAPIKey(ctx, database.InsertAPIKeyParams{
-		ID:              keyID,
-		UserID:          params.UserID,
-		LifetimeSeconds: params.LifetimeSeconds,
-		IPAddress: pqtype.Inet{
-			IPNet: net.IPNet{
-				IP:   ip,
-				Mask: net.CIDRMask(bitlen, bitlen),
+	var key database.APIKey
+	err = api.Database.InTx(func(tx database.Store) error {
+		var err error
+		key, err = tx.InsertAPIKey(ctx, database.InsertAPIKeyParams{
+			ID:              keyID,
+			UserID:          params.UserID,
+			LifetimeSeconds: params.LifetimeSeconds,
+			IPAddress: pqtype.Inet{
+				IPNet: net.IPNet{
+					IP:   ip,
+					Mask: net.CIDRMask(bitlen, bitlen),
+				},
+				Valid: true,
 			},
-			Valid: true,
-		},
-		// Make sure in UTC time for common time zone
-		ExpiresAt:    params.ExpiresAt.UTC(),
-		CreatedAt:    database.Now(),
-		UpdatedAt:    database.Now(),
-		HashedSecret: hashed[:],
-		LoginType:    params.LoginType,
-		Scope:        scope,
-		TokenName:    params.TokenName,
-	})
+			// Make sure in UTC time for common time zone
+			ExpiresAt:    params.ExpiresAt.UTC(),
+			CreatedAt:    database.Now(),
+			UpdatedAt:    database.Now(),
+			HashedSecret: hashed[:],
+			LoginType:    params.LoginType,
+			Scope:        scope,
+			TokenName:    params.TokenName,
+		})
+		if err != nil {
+			return xerrors.Errorf("insert API key: %w", err)
+		}
+		if len(params.ScopeResources) == 0 {
+			return nil
+		}
+
+		insert := database.InsertAPIKeyScopeResourcesParams{APIKeyID: key.ID}
+		for _, resource := range params.ScopeResources {
+			insert.ResourceType = append(insert.ResourceType, resource.Type)
+			insert.ResourceID = append(insert.ResourceID, resource.ID)
+			insert.Action = append(insert.Action, string(resource.Action))
+		}
+		_, err = tx.InsertAPIKeyScopeResources(ctx, insert)
+		if err != nil {
+			return xerrors.Errorf("insert API key scope resources: %w", err)
+		}
+		return nil
+	}, nil)
 	if err != nil {
-		return nil, nil, xerrors.Errorf("insert API key: %w", err)
+		return nil, nil, err
 	}
 
 	api.Telemetry.Report(&telemetry.Snapshot{
@@ -489,3 +570,50 @@ func (api *API) createAPIKey(ctx context.Context, params createAPIKeyParams) (*h
 		Secure:   api.SecureAuthCookie,
 	}, &key, nil
 }
+
+// convertScopeResources validates the resources requested for a token. The
+// resources are only allowed, and required, for the resources scope.
+func convertScopeResources(userID uuid.UUID, scope database.APIKeyScope, resources []codersdk.APIKeyScopeResource) ([]rbac.ScopeResource, error) {
+	if scope != database.APIKeyScopeResources {
+		if len(resources) > 0 {
+			return nil, xerrors.Errorf("resources can only be set for the %q scope", database.APIKeyScopeResources)
+		}
+		return nil, nil
+	}
+	if len(resources) == 0 {
+		return nil, xerrors.Errorf("the %q scope requires at least one resource", database.APIKeyScopeResources)
+	}
+
+	converted := make([]rbac.ScopeResource, 0, len(resources))
+	perms := make([]rbac.Permission, 0, len(resources))
+	for _, resource := range resources {
+		converted = append(converted, rbac.ScopeResource{
+			Type:   resource.ResourceType,
+			ID:     resource.ResourceID,
+			Action: rbac.Action(resource.Action),
+		})
+		perms = append(perms, rbac.Permission{
+			ResourceType: resource.ResourceType,
+			Action:       rbac.Action(resource.Action),
+		})
+	}
+	err := rbac.ValidatePermissions(perms)
+	if err != nil {
+		return nil, err
+	}
+	// Ensure the scope can be built, so invalid keys are never created.
+	_, err = rbac.ResourceScope(userID, converted)
+	if err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+// apiKeyScopeResources returns the resources of a key with the resources
+// scope, or nothing for other scopes.
+func (api *API) apiKeyScopeResources(ctx context.Context, key database.APIKey) ([]database.APIKeyScopeResource, error) {
+	if key.Scope != database.APIKeyScopeResources {
+		return nil, nil
+	}
+	return api.Database.GetAPIKeyScopeResourcesByAPIKeyIDs(ctx, []string{key.ID})
+}
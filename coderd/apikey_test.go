@@ -84,6 +84,120 @@ func TestTokenScoped(t *testing.T) {
 	require.Equal(t, keys[0].Scope, codersdk.APIKeyScopeApplicationConnect)
 }
 
+func TestTokenScopedResources(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	other := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+	res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+		Scope: codersdk.APIKeyScopeResources,
+		Resources: []codersdk.APIKeyScopeResource{{
+			ResourceType: "template",
+			ResourceID:   template.ID,
+			Action:       "read",
+		}, {
+			// Listing templates requires reading the organization.
+			ResourceType: "organization",
+			ResourceID:   user.OrganizationID,
+			Action:       "read",
+		}},
+	})
+	require.NoError(t, err)
+
+	keys, err := client.Tokens(ctx, codersdk.Me, codersdk.TokensFilter{})
+	require.NoError(t, err)
+	require.Len(t, keys, 1)
+	require.Equal(t, codersdk.APIKeyScopeResources, keys[0].Scope)
+	require.ElementsMatch(t, []codersdk.APIKeyScopeResource{{
+		ResourceType: "template",
+		ResourceID:   template.ID,
+		Action:       "read",
+	}, {
+		ResourceType: "organization",
+		ResourceID:   user.OrganizationID,
+		Action:       "read",
+	}}, keys[0].Resources)
+
+	scoped := codersdk.New(client.URL)
+	scoped.SetSessionToken(res.Key)
+
+	_, err = scoped.User(ctx, codersdk.Me)
+	require.NoError(t, err)
+	_, err = scoped.Template(ctx, template.ID)
+	require.NoError(t, err)
+	_, err = scoped.Template(ctx, other.ID)
+	require.Error(t, err)
+	_, err = scoped.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{Description: "nope"})
+	require.Error(t, err)
+	templates, err := scoped.TemplatesByOrganization(ctx, user.OrganizationID)
+	require.NoError(t, err)
+	require.Len(t, templates, 1)
+	require.Equal(t, template.ID, templates[0].ID)
+
+	// Invalid scopes are rejected.
+	for _, req := range []codersdk.CreateTokenRequest{
+		// The resources scope requires resources.
+		{Scope: codersdk.APIKeyScopeResources},
+		// Other scopes must not have resources.
+		{Scope: codersdk.APIKeyScopeAll, Resources: []codersdk.APIKeyScopeResource{{
+			ResourceType: "template", ResourceID: template.ID, Action: "read",
+		}}},
+		{Scope: codersdk.APIKeyScopeResources, Resources: []codersdk.APIKeyScopeResource{{
+			ResourceType: "nope", ResourceID: template.ID, Action: "read",
+		}}},
+		{Scope: codersdk.APIKeyScopeResources, Resources: []codersdk.APIKeyScopeResource{{
+			ResourceType: "template", ResourceID: template.ID, Action: "*",
+		}}},
+		{Scope: codersdk.APIKeyScopeResources, Resources: []codersdk.APIKeyScopeResource{
+			{ResourceType: "template", ResourceID: template.ID, Action: "read"},
+			{ResourceType: "template", ResourceID: other.ID, Action: "update"},
+		}},
+	} {
+		_, err := client.CreateToken(ctx, codersdk.Me, req)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	}
+}
+
+// Starting and stopping a workspace also requires reading its template.
+func TestTokenScopedWorkspace(t *testing.T) {
+	t.Parallel()
+	ctx := testutil.Context(t, testutil.WaitLong)
+	client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+	user := coderdtest.CreateFirstUser(t, client)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	ws := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, ws.LatestBuild.ID)
+
+	res, err := client.CreateToken(ctx, codersdk.Me, codersdk.CreateTokenRequest{
+		Scope: codersdk.APIKeyScopeResources,
+		Resources: []codersdk.APIKeyScopeResource{
+			{ResourceType: "workspace", ResourceID: ws.ID, Action: "read"},
+			{ResourceType: "workspace", ResourceID: ws.ID, Action: "update"},
+			{ResourceType: "template", ResourceID: template.ID, Action: "read"},
+		},
+	})
+	require.NoError(t, err)
+	scoped := codersdk.New(client.URL)
+	scoped.SetSessionToken(res.Key)
+	_, err = scoped.WorkspaceByOwnerAndName(ctx, codersdk.Me, ws.Name, codersdk.WorkspaceOptions{})
+	require.NoError(t, err)
+	b, err := scoped.CreateWorkspaceBuild(ctx, ws.ID, codersdk.CreateWorkspaceBuildRequest{Transition: codersdk.WorkspaceTransitionStop})
+	require.NoError(t, err)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, b.ID)
+	_, err = scoped.CreateWorkspaceBuild(ctx, ws.ID, codersdk.CreateWorkspaceBuildRequest{Transition: codersdk.WorkspaceTransitionStart})
+	require.NoError(t, err)
+}
+
 func TestUserSetTokenDuration(t *testing.T) {
 	t.Parallel()
 
@@ -3,11 +3,14 @@ package coderd
 import (
 	"context"
 	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
 	"net/netip"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -17,9 +20,9 @@ import (
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
-	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/searchquery"
 	"github.com/coder/coder/codersdk"
 )
@@ -76,6 +79,236 @@ func (api *API) auditLogs(rw http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// auditLogExportPageSize is the number of audit logs read from the database at
+// a time while exporting.
+const auditLogExportPageSize = 1000
+
+// @Summary Export audit logs
+// @ID export-audit-logs
+// @Security CoderSessionToken
+// @Tags Audit
+// @Param q query string false "Search query"
+// @Param format query string false "Export format" Enums(csv,ndjson)
+// @Param after query string false "Only export audit logs after this time" format(date-time)
+// @Param before query string false "Only export audit logs before this time" format(date-time)
+// @Success 200
+// @Router /audit/export [get]
+func (api *API) exportAuditLogs(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	filter, errs := searchquery.AuditLogs(r.URL.Query().Get("q"))
+	if len(errs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid audit search query.",
+			Validations: errs,
+		})
+		return
+	}
+
+	parser := httpapi.NewQueryParamParser()
+	format := httpapi.ParseCustom(parser, r.URL.Query(), codersdk.AuditLogExportFormatCSV, "format", func(v string) (codersdk.AuditLogExportFormat, error) {
+		format := codersdk.AuditLogExportFormat(v)
+		if !format.Valid() {
+			return "", xerrors.Errorf("%q is not a valid format", v)
+		}
+		return format, nil
+	})
+	after := parser.Time(r.URL.Query(), time.Time{}, "after", time.RFC3339Nano)
+	before := parser.Time(r.URL.Query(), time.Time{}, "before", time.RFC3339Nano)
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid query parameters.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+	if after.After(filter.DateFrom) {
+		filter.DateFrom = after
+	}
+	// Audit logs are paged by offset, so audit logs created during the export
+	// would shift pages and duplicate rows. Only export what existed when the
+	// export started.
+	now := database.Now()
+	if before.IsZero() || before.After(now) {
+		before = now
+	}
+	if filter.DateTo.IsZero() || before.Before(filter.DateTo) {
+		filter.DateTo = before
+	}
+	filter.Limit = auditLogExportPageSize
+
+	// Fetch the first page before writing the response, so errors can still
+	// be returned with a status code.
+	dblogs, err := api.Database.GetAuditLogsOffset(ctx, filter)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	var writer auditLogExportWriter
+	switch format {
+	case codersdk.AuditLogExportFormatNDJSON:
+		rw.Header().Set("Content-Type", "application/x-ndjson")
+		writer = &auditLogNDJSONWriter{enc: json.NewEncoder(rw)}
+	default:
+		rw.Header().Set("Content-Type", "text/csv")
+		writer = &auditLogCSVWriter{w: csv.NewWriter(rw)}
+	}
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", "audit-logs."+string(format)))
+	// Errors after the status has been written are sent in a trailer, which
+	// the client checks once it has read the body.
+	rw.Header().Set("Trailer", codersdk.AuditLogExportErrorTrailer)
+	rw.WriteHeader(http.StatusOK)
+	flusher, _ := rw.(http.Flusher)
+	exportFailed := func(message string, err error) {
+		api.Logger.Warn(ctx, message, slog.Error(err))
+		rw.Header().Set(codersdk.AuditLogExportErrorTrailer, fmt.Sprintf("%s: %s", message, err))
+	}
+
+	for {
+		for _, dblog := range dblogs {
+			err = writer.Write(api.convertAuditLog(ctx, dblog))
+			if err != nil {
+				exportFailed("write audit log export", err)
+				return
+			}
+		}
+		err = writer.Flush()
+		if err != nil {
+			exportFailed("flush audit log export", err)
+			return
+		}
+		if flusher != nil {
+			flusher.Flush()
+		}
+		if len(dblogs) < auditLogExportPageSize {
+			return
+		}
+
+		filter.Offset += auditLogExportPageSize
+		dblogs, err = api.Database.GetAuditLogsOffset(ctx, filter)
+		if err != nil {
+			exportFailed("fetch audit logs for export", err)
+			return
+		}
+	}
+}
+
+// auditLogExportWriter writes audit logs in an export format.
+type auditLogExportWriter interface {
+	Write(alog codersdk.AuditLog) error
+	Flush() error
+}
+
+type auditLogNDJSONWriter struct {
+	enc *json.Encoder
+}
+
+func (w *auditLogNDJSONWriter) Write(alog codersdk.AuditLog) error {
+	return w.enc.Encode(alog)
+}
+
+func (*auditLogNDJSONWriter) Flush() error {
+	return nil
+}
+
+// auditLogCSVHeader are the columns of CSV audit log exports.
+var auditLogCSVHeader = []string{
+	"id", "time", "organization_id", "user_id", "username", "email", "ip",
+	"user_agent", "resource_type", "resource_id", "resource_target", "action",
+	"status_code", "request_id", "description", "diff", "additional_fields",
+}
+
+type auditLogCSVWriter struct {
+	w           *csv.Writer
+	wroteHeader bool
+}
+
+func (w *auditLogCSVWriter) Write(alog codersdk.AuditLog) error {
+	if !w.wroteHeader {
+		w.wroteHeader = true
+		err := w.w.Write(auditLogCSVHeader)
+		if err != nil {
+			return err
+		}
+	}
+
+	var userID uuid.UUID
+	username, email := "", ""
+	if alog.User != nil {
+		userID = alog.User.ID
+		username = alog.User.Username
+		email = alog.User.Email
+	}
+	var ip string
+	if alog.IP.IsValid() {
+		ip = alog.IP.String()
+	}
+	diff, err := json.Marshal(alog.Diff)
+	if err != nil {
+		return err
+	}
+	description := strings.NewReplacer(
+		"{user}", username,
+		"{target}", alog.ResourceTarget,
+	).Replace(alog.Description)
+
+	record := []string{
+		alog.ID.String(),
+		alog.Time.Format(time.RFC3339Nano),
+		alog.OrganizationID.String(),
+		userID.String(),
+		username,
+		email,
+		ip,
+		alog.UserAgent,
+		string(alog.ResourceType),
+		alog.ResourceID.String(),
+		alog.ResourceTarget,
+		string(alog.Action),
+		strconv.Itoa(int(alog.StatusCode)),
+		alog.RequestID.String(),
+		strings.TrimSpace(description),
+		string(diff),
+		string(alog.AdditionalFields),
+	}
+	for i, field := range record {
+		record[i] = escapeCSVFormula(field)
+	}
+	return w.w.Write(record)
+}
+
+// escapeCSVFormula prefixes fields that spreadsheets would evaluate as a
+// formula with a single quote. Most fields are controlled by users, e.g. the
+// user agent or the name of a workspace.
+func escapeCSVFormula(field string) string {
+	if field == "" {
+		return field
+	}
+	switch field[0] {
+	case '=', '+', '-', '@', '\t', '\r':
+		return "'" + field
+	}
+	return field
+}
+
+func (w *auditLogCSVWriter) Flush() error {
+	if !w.wroteHeader {
+		// Always write the header, even when there are no audit logs.
+		w.wroteHeader = true
+		err := w.w.Write(auditLogCSVHeader)
+		if err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
+
 // @Summary Generate fake audit log
 // @ID generate-fake-audit-log
 // @Security CoderSessionToken
@@ -186,8 +419,7 @@ func (api *API) convertAuditLog(ctx context.Context, dblog database.GetAuditLogs
 		}
 
 		for _, roleName := range dblog.UserRoles {
-			rbacRole, _ := rbac.RoleByName(roleName)
-			user.Roles = append(user.Roles, convertRole(rbacRole))
+			user.Roles = append(user.Roles, convertRoleName(roleName))
 		}
 	}
 
@@ -49,6 +49,14 @@ func (a *MockAuditor) Export(_ context.Context, alog database.AuditLog) error {
 	return nil
 }
 
+// Logs returns a copy of the exported audit logs. Unlike AuditLogs, it's safe
+// to call while logs are being exported.
+func (a *MockAuditor) Logs() []database.AuditLog {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	return append([]database.AuditLog(nil), a.AuditLogs...)
+}
+
 func (*MockAuditor) diff(any, any) Map {
 	return Map{}
 }
@@ -78,6 +78,12 @@ func ResourceTarget[T Auditable](tgt T) string {
 		return ""
 	case database.License:
 		return strconv.Itoa(int(typed.ID))
+	case database.WorkspaceProxy:
+		return typed.Name
+	case database.AuditFilterRules:
+		return ""
+	case database.WorkspaceAgentPortShare:
+		return fmt.Sprintf("%s:%d", typed.AgentName, typed.Port)
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -103,6 +109,14 @@ func ResourceID[T Auditable](tgt T) uuid.UUID {
 		return typed.UserID
 	case database.License:
 		return typed.UUID
+	case database.WorkspaceProxy:
+		return typed.ID
+	case database.AuditFilterRules:
+		return typed.ID
+	case database.WorkspaceAgentPortShare:
+		// Port shares don't have an ID, so they're logged against their
+		// workspace.
+		return typed.WorkspaceID
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
@@ -128,6 +142,12 @@ func ResourceType[T Auditable](tgt T) database.ResourceType {
 		return database.ResourceTypeApiKey
 	case database.License:
 		return database.ResourceTypeLicense
+	case database.WorkspaceProxy:
+		return database.ResourceTypeWorkspaceProxy
+	case database.AuditFilterRules:
+		return database.ResourceTypeAuditFilterRules
+	case database.WorkspaceAgentPortShare:
+		return database.ResourceTypeWorkspaceAgentPortShare
 	default:
 		panic(fmt.Sprintf("unknown resource %T", tgt))
 	}
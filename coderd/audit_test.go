@@ -2,18 +2,26 @@ package coderd_test
 
 import (
 	"context"
+	"database/sql"
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
+	"io"
+	"net/http"
 	"strconv"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/require"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/testutil"
 )
 
 func TestAuditLogs(t *testing.T) {
@@ -257,3 +265,143 @@ func TestAuditLogsFilter(t *testing.T) {
 		}
 	})
 }
+
+func TestAuditLogsExport(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	client := coderdtest.New(t, nil)
+	user := coderdtest.CreateFirstUser(t, client)
+
+	now := time.Now()
+	for _, params := range []codersdk.CreateTestAuditLogRequest{
+		{Action: codersdk.AuditActionCreate, ResourceType: codersdk.ResourceTypeTemplate, Time: now.Add(-2 * time.Hour)},
+		{Action: codersdk.AuditActionDelete, ResourceType: codersdk.ResourceTypeTemplate, Time: now.Add(-time.Hour)},
+		{Action: codersdk.AuditActionCreate, ResourceType: codersdk.ResourceTypeUser, Time: now.Add(-time.Minute)},
+	} {
+		params.ResourceID = user.UserID
+		err := client.CreateTestAuditLog(ctx, params)
+		require.NoError(t, err)
+	}
+
+	t.Run("CSV", func(t *testing.T) {
+		t.Parallel()
+
+		body, err := client.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{
+			SearchQuery: "resource_type:template",
+		})
+		require.NoError(t, err)
+		defer body.Close()
+
+		records, err := csv.NewReader(body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 3)
+		require.Equal(t, "id", records[0][0])
+		for _, record := range records[1:] {
+			require.Equal(t, string(codersdk.ResourceTypeTemplate), record[8])
+			require.Equal(t, user.UserID.String(), record[3])
+		}
+	})
+
+	t.Run("NDJSON", func(t *testing.T) {
+		t.Parallel()
+
+		body, err := client.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{
+			Format: codersdk.AuditLogExportFormatNDJSON,
+			After:  now.Add(-90 * time.Minute),
+		})
+		require.NoError(t, err)
+		defer body.Close()
+
+		var alogs []codersdk.AuditLog
+		dec := json.NewDecoder(body)
+		for dec.More() {
+			var alog codersdk.AuditLog
+			err = dec.Decode(&alog)
+			require.NoError(t, err)
+			alogs = append(alogs, alog)
+		}
+		require.Len(t, alogs, 2)
+		for _, alog := range alogs {
+			require.True(t, alog.Time.After(now.Add(-90*time.Minute)))
+		}
+	})
+
+	t.Run("InvalidFormat", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := client.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{
+			Format: "xml",
+		})
+		var sdkError *codersdk.Error
+		require.ErrorAs(t, err, &sdkError)
+		require.Equal(t, http.StatusBadRequest, sdkError.StatusCode())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+
+		member, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err := member.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{})
+		var sdkError *codersdk.Error
+		require.ErrorAs(t, err, &sdkError)
+		require.Equal(t, http.StatusForbidden, sdkError.StatusCode())
+	})
+
+	t.Run("CSVFormula", func(t *testing.T) {
+		t.Parallel()
+
+		db := dbfake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: db})
+		_ = coderdtest.CreateFirstUser(t, client)
+		dbgen.AuditLog(t, db, database.AuditLog{
+			Time:           database.Now().Add(-time.Minute),
+			UserAgent:      sql.NullString{String: "=HYPERLINK(\"https://example.com\")", Valid: true},
+			ResourceTarget: "-1+1",
+		})
+
+		body, err := client.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{})
+		require.NoError(t, err)
+		defer body.Close()
+
+		records, err := csv.NewReader(body).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, "'=HYPERLINK(\"https://example.com\")", records[1][7])
+		require.Equal(t, "'-1+1", records[1][10])
+	})
+
+	t.Run("FailsPartway", func(t *testing.T) {
+		t.Parallel()
+
+		// Fetching the second page fails, after the first has been sent.
+		db := dbfake.New()
+		client := coderdtest.New(t, &coderdtest.Options{Database: failAuditLogsPageStore{Store: db}})
+		_ = coderdtest.CreateFirstUser(t, client)
+		for i := 0; i < 1000; i++ {
+			dbgen.AuditLog(t, db, database.AuditLog{Time: database.Now().Add(-time.Minute)})
+		}
+
+		body, err := client.ExportAuditLogs(ctx, codersdk.AuditLogsExportRequest{
+			Format: codersdk.AuditLogExportFormatNDJSON,
+		})
+		require.NoError(t, err)
+		defer body.Close()
+
+		_, err = io.ReadAll(body)
+		require.ErrorContains(t, err, "fetch audit logs for export")
+	})
+}
+
+// failAuditLogsPageStore fails to fetch any page of audit logs after the
+// first.
+type failAuditLogsPageStore struct {
+	database.Store
+}
+
+func (s failAuditLogsPageStore) GetAuditLogsOffset(ctx context.Context, arg database.GetAuditLogsOffsetParams) ([]database.GetAuditLogsOffsetRow, error) {
+	if arg.Offset > 0 {
+		return nil, xerrors.New("database is gone")
+	}
+	return s.Store.GetAuditLogsOffset(ctx, arg)
+}
@@ -2,7 +2,12 @@ package executor
 
 import (
 	"context"
+	"database/sql"
 	"encoding/json"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -10,6 +15,7 @@ import (
 	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/provisionerdserver"
@@ -18,11 +24,13 @@ import (
 
 // Executor automatically starts or stops workspaces.
 type Executor struct {
-	ctx     context.Context
-	db      database.Store
-	log     slog.Logger
-	tick    <-chan time.Time
-	statsCh chan<- Stats
+	ctx                   context.Context
+	db                    database.Store
+	templateScheduleStore *atomic.Pointer[schedule.TemplateScheduleStore]
+	auditor               *atomic.Pointer[audit.Auditor]
+	log                   slog.Logger
+	tick                  <-chan time.Time
+	statsCh               chan<- Stats
 }
 
 // Stats contains information about one run of Executor.
@@ -33,13 +41,15 @@ type Stats struct {
 }
 
 // New returns a new autobuild executor.
-func New(ctx context.Context, db database.Store, log slog.Logger, tick <-chan time.Time) *Executor {
+func New(ctx context.Context, db database.Store, tss *atomic.Pointer[schedule.TemplateScheduleStore], auditor *atomic.Pointer[audit.Auditor], log slog.Logger, tick <-chan time.Time) *Executor {
 	le := &Executor{
 		//nolint:gocritic // Autostart has a limited set of permissions.
-		ctx:  dbauthz.AsAutostart(ctx),
-		db:   db,
-		tick: tick,
-		log:  log,
+		ctx:                   dbauthz.AsAutostart(ctx),
+		db:                    db,
+		templateScheduleStore: tss,
+		auditor:               auditor,
+		tick:                  tick,
+		log:                   log,
 	}
 	return le
 }
@@ -83,6 +93,7 @@ func (e *Executor) Run() {
 
 func (e *Executor) runOnce(t time.Time) Stats {
 	var err error
+	var statsMu sync.Mutex
 	stats := Stats{
 		Transitions: make(map[uuid.UUID]database.WorkspaceTransition),
 	}
@@ -102,21 +113,11 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	// NOTE: If a workspace build is created with a given TTL and then the user either
 	//       changes or unsets the TTL, the deadline for the workspace build will not
 	//       have changed. This behavior is as expected per #2229.
-	workspaceRows, err := e.db.GetWorkspaces(e.ctx, database.GetWorkspacesParams{
-		Deleted: false,
-	})
+	workspaces, err := e.db.GetWorkspacesEligibleForTransition(e.ctx, t)
 	if err != nil {
 		e.log.Error(e.ctx, "get workspaces for autostart or autostop", slog.Error(err))
 		return stats
 	}
-	workspaces := database.ConvertWorkspaceRows(workspaceRows)
-
-	var eligibleWorkspaceIDs []uuid.UUID
-	for _, ws := range workspaces {
-		if isEligibleForAutoStartStop(ws) {
-			eligibleWorkspaceIDs = append(eligibleWorkspaceIDs, ws.ID)
-		}
-	}
 
 	// We only use errgroup here for convenience of API, not for early
 	// cancellation. This means we only return nil errors in th eg.Go.
@@ -124,11 +125,15 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	// Limit the concurrency to avoid overloading the database.
 	eg.SetLimit(10)
 
-	for _, wsID := range eligibleWorkspaceIDs {
-		wsID := wsID
+	for _, ws := range workspaces {
+		wsID := ws.ID
 		log := e.log.With(slog.F("workspace_id", wsID))
 
 		eg.Go(func() error {
+			var (
+				lockedWorkspace *lockAudit
+				lifecycleBuild  *buildAudit
+			)
 			err := e.db.InTx(func(db database.Store) error {
 				// Re-check eligibility since the first check was outside the
 				// transaction and the workspace settings may have changed.
@@ -137,53 +142,97 @@ func (e *Executor) runOnce(t time.Time) Stats {
 					log.Error(e.ctx, "get workspace autostart failed", slog.Error(err))
 					return nil
 				}
-				if !isEligibleForAutoStartStop(ws) {
-					return nil
-				}
 
 				// Determine the workspace state based on its latest build.
-				priorHistory, err := db.GetLatestWorkspaceBuildByWorkspaceID(e.ctx, ws.ID)
+				latestBuild, err := db.GetLatestWorkspaceBuildByWorkspaceID(e.ctx, ws.ID)
 				if err != nil {
 					log.Warn(e.ctx, "get latest workspace build", slog.Error(err))
 					return nil
 				}
 
-				priorJob, err := db.GetProvisionerJobByID(e.ctx, priorHistory.JobID)
+				latestJob, err := db.GetProvisionerJobByID(e.ctx, latestBuild.JobID)
 				if err != nil {
 					log.Warn(e.ctx, "get last provisioner job for workspace %q: %w", slog.Error(err))
 					return nil
 				}
 
-				validTransition, nextTransition, err := getNextTransition(ws, priorHistory, priorJob)
+				templateSchedule, err := (*(e.templateScheduleStore.Load())).GetTemplateScheduleOptions(e.ctx, db, ws.TemplateID)
+				if err != nil {
+					log.Warn(e.ctx, "get template schedule options", slog.Error(err))
+					return nil
+				}
+
+				nextTransition, reason, err := getNextTransition(ws, latestBuild, latestJob, templateSchedule, currentTick)
 				if err != nil {
 					log.Debug(e.ctx, "skipping workspace", slog.Error(err))
 					return nil
 				}
 
-				if currentTick.Before(nextTransition) {
-					log.Debug(e.ctx, "skipping workspace: too early",
-						slog.F("next_transition_at", nextTransition),
-						slog.F("transition", validTransition),
-						slog.F("current_tick", currentTick),
+				if nextTransition != "" {
+					log.Info(e.ctx, "scheduling workspace transition",
+						slog.F("transition", nextTransition),
+						slog.F("reason", reason),
 					)
-					return nil
+
+					newBuild, err := build(e.ctx, db, ws, nextTransition, reason, latestBuild, latestJob)
+					if err != nil {
+						return xerrors.Errorf("transition workspace to %q: %w", nextTransition, err)
+					}
+					if reason == database.BuildReasonAutolock || reason == database.BuildReasonAutodelete {
+						lifecycleBuild = &buildAudit{Workspace: ws, Old: latestBuild, New: newBuild}
+					}
 				}
 
-				log.Info(e.ctx, "scheduling workspace transition", slog.F("transition", validTransition))
+				// The workspace is only locked once its stop build has been
+				// created, so it can't end up locked while it's still running.
+				// Both are written in the same transaction.
+				if reason == database.BuildReasonAutolock {
+					lockedAt := sql.NullTime{
+						Time:  database.Now(),
+						Valid: true,
+					}
+					err = db.UpdateWorkspaceLockedAt(e.ctx, database.UpdateWorkspaceLockedAtParams{
+						ID:       ws.ID,
+						LockedAt: lockedAt,
+					})
+					if err != nil {
+						return xerrors.Errorf("lock workspace: %w", err)
+					}
 
-				stats.Transitions[ws.ID] = validTransition
-				if err := build(e.ctx, db, ws, validTransition, priorHistory, priorJob); err != nil {
-					log.Error(e.ctx, "unable to transition workspace",
-						slog.F("transition", validTransition),
-						slog.Error(err),
-					)
+					newWorkspace := ws
+					newWorkspace.LockedAt = lockedAt
+					lockedWorkspace = &lockAudit{Old: ws, New: newWorkspace}
+				}
+
+				if nextTransition == "" {
 					return nil
 				}
 
+				statsMu.Lock()
+				stats.Transitions[ws.ID] = nextTransition
+				statsMu.Unlock()
 				return nil
 			}, nil)
 			if err != nil {
 				log.Error(e.ctx, "workspace scheduling failed", slog.Error(err))
+				return nil
+			}
+			if lockedWorkspace != nil {
+				log.Info(e.ctx, "locked inactive workspace",
+					slog.F("last_used_at", lockedWorkspace.Old.LastUsedAt),
+				)
+				audit.BuildAudit(e.ctx, &audit.BuildAuditParams[database.Workspace]{
+					Audit:  *e.auditor.Load(),
+					Log:    log,
+					UserID: lockedWorkspace.New.OwnerID,
+					Status: http.StatusOK,
+					Action: database.AuditActionWrite,
+					Old:    lockedWorkspace.Old,
+					New:    lockedWorkspace.New,
+				})
+			}
+			if lifecycleBuild != nil {
+				e.auditLifecycleBuild(log, *lifecycleBuild)
 			}
 			return nil
 		})
@@ -198,51 +247,167 @@ func (e *Executor) runOnce(t time.Time) Stats {
 	return stats
 }
 
-func isEligibleForAutoStartStop(ws database.Workspace) bool {
-	return !ws.Deleted && (ws.AutostartSchedule.String != "" || ws.Ttl.Int64 > 0)
+// lockAudit holds the state of a workspace before and after it was locked so
+// the transition can be audited once the transaction has been committed.
+type lockAudit struct {
+	Old database.Workspace
+	New database.Workspace
+}
+
+// buildAudit holds a build created by the executor and the build before it so
+// the build can be audited once the transaction has been committed.
+type buildAudit struct {
+	Workspace database.Workspace
+	Old       database.WorkspaceBuild
+	New       database.WorkspaceBuild
+}
+
+// auditLifecycleBuild audits a build created to lock or delete a workspace.
+// The outcome of the build is audited by provisionerd once it completes.
+func (e *Executor) auditLifecycleBuild(log slog.Logger, b buildAudit) {
+	action := database.AuditActionStop
+	if b.New.Transition == database.WorkspaceTransitionDelete {
+		action = database.AuditActionDelete
+	}
+	// Passed to the auditor so it can form a friendly string for the user to
+	// view in the UI.
+	additionalFields, err := json.Marshal(audit.AdditionalFields{
+		WorkspaceName: b.Workspace.Name,
+		BuildNumber:   strconv.FormatInt(int64(b.New.BuildNumber), 10),
+		BuildReason:   b.New.Reason,
+	})
+	if err != nil {
+		log.Error(e.ctx, "marshal resource info for lifecycle build", slog.Error(err))
+	}
+	audit.BuildAudit(e.ctx, &audit.BuildAuditParams[database.WorkspaceBuild]{
+		Audit:            *e.auditor.Load(),
+		Log:              log,
+		UserID:           b.Workspace.OwnerID,
+		JobID:            b.New.JobID,
+		Status:           http.StatusOK,
+		Action:           action,
+		AdditionalFields: additionalFields,
+		Old:              b.Old,
+		New:              b.New,
+	})
 }
 
+// getNextTransition returns the transition and build reason for the next
+// automated action on the workspace. An empty transition with a non-empty
+// reason indicates that the workspace should be updated without creating a
+// build, e.g. locking a workspace that is already stopped.
 func getNextTransition(
 	ws database.Workspace,
-	priorHistory database.WorkspaceBuild,
-	priorJob database.ProvisionerJob,
+	latestBuild database.WorkspaceBuild,
+	latestJob database.ProvisionerJob,
+	templateSchedule schedule.TemplateScheduleOptions,
+	currentTick time.Time,
 ) (
-	validTransition database.WorkspaceTransition,
-	nextTransition time.Time,
-	err error,
+	database.WorkspaceTransition,
+	database.BuildReason,
+	error,
 ) {
-	if !priorJob.CompletedAt.Valid || priorJob.Error.String != "" {
-		return "", time.Time{}, xerrors.Errorf("last workspace build did not complete successfully")
+	if !latestJob.CompletedAt.Valid {
+		return "", "", xerrors.Errorf("latest workspace build has not completed")
 	}
 
-	switch priorHistory.Transition {
-	case database.WorkspaceTransitionStart:
-		if priorHistory.Deadline.IsZero() {
-			return "", time.Time{}, xerrors.Errorf("latest workspace build has zero deadline")
-		}
-		// For stopping, do not truncate. This is inconsistent with autostart, but
-		// it ensures we will not stop too early.
-		return database.WorkspaceTransitionStop, priorHistory.Deadline, nil
-	case database.WorkspaceTransitionStop:
-		sched, err := schedule.Weekly(ws.AutostartSchedule.String)
-		if err != nil {
-			return "", time.Time{}, xerrors.Errorf("workspace has invalid autostart schedule: %w", err)
+	switch {
+	case isEligibleForDelete(ws, latestBuild, templateSchedule, currentTick):
+		return database.WorkspaceTransitionDelete, database.BuildReasonAutodelete, nil
+	case isEligibleForLock(ws, templateSchedule, currentTick):
+		// Only stop started workspaces, stopped workspaces are locked in place.
+		if latestBuild.Transition == database.WorkspaceTransitionStart {
+			return database.WorkspaceTransitionStop, database.BuildReasonAutolock, nil
 		}
-		// Round down to the nearest minute, as this is the finest granularity cron supports.
-		// Truncate is probably not necessary here, but doing it anyway to be sure.
-		nextTransition = sched.Next(priorHistory.CreatedAt).Truncate(time.Minute)
-		return database.WorkspaceTransitionStart, nextTransition, nil
+		return "", database.BuildReasonAutolock, nil
+	case isEligibleForFailedStop(latestBuild, latestJob, templateSchedule, currentTick):
+		return database.WorkspaceTransitionStop, database.BuildReasonFailedstop, nil
+	case isEligibleForAutostop(latestBuild, latestJob, currentTick):
+		return database.WorkspaceTransitionStop, database.BuildReasonAutostop, nil
+	case isEligibleForAutostart(ws, latestBuild, latestJob, currentTick):
+		return database.WorkspaceTransitionStart, database.BuildReasonAutostart, nil
 	default:
-		return "", time.Time{}, xerrors.Errorf("last transition not valid for autostart or autostop")
+		return "", "", xerrors.Errorf("last transition not valid for autostart, autostop, failed stop, lock or delete")
+	}
+}
+
+// isEligibleForAutostop returns true if the workspace was started successfully
+// and its deadline has passed.
+func isEligibleForAutostop(build database.WorkspaceBuild, job database.ProvisionerJob, currentTick time.Time) bool {
+	if job.Error.String != "" || build.Transition != database.WorkspaceTransitionStart {
+		return false
+	}
+	// For stopping, do not truncate. This is inconsistent with autostart, but
+	// it ensures we will not stop too early.
+	return !build.Deadline.IsZero() && !currentTick.Before(build.Deadline)
+}
+
+// isEligibleForAutostart returns true if the workspace was stopped
+// successfully, is not locked and its autostart schedule has elapsed since it
+// was stopped.
+func isEligibleForAutostart(ws database.Workspace, build database.WorkspaceBuild, job database.ProvisionerJob, currentTick time.Time) bool {
+	if job.Error.String != "" || build.Transition != database.WorkspaceTransitionStop {
+		return false
+	}
+	if ws.LockedAt.Valid || !ws.AutostartSchedule.Valid || ws.AutostartSchedule.String == "" {
+		return false
+	}
+	sched, err := schedule.Weekly(ws.AutostartSchedule.String)
+	if err != nil {
+		return false
 	}
+	// Round down to the nearest minute, as this is the finest granularity cron supports.
+	// Truncate is probably not necessary here, but doing it anyway to be sure.
+	nextTransition := sched.Next(build.CreatedAt).Truncate(time.Minute)
+	return !currentTick.Before(nextTransition)
+}
+
+// isEligibleForFailedStop returns true if the latest start build failed and
+// the failed job is older than the template's failure TTL.
+func isEligibleForFailedStop(build database.WorkspaceBuild, job database.ProvisionerJob, templateSchedule schedule.TemplateScheduleOptions, currentTick time.Time) bool {
+	if templateSchedule.FailureTTL <= 0 || build.Transition != database.WorkspaceTransitionStart {
+		return false
+	}
+	if !job.CompletedAt.Valid || !job.Error.Valid || job.Error.String == "" {
+		return false
+	}
+	return !currentTick.Before(job.CompletedAt.Time.Add(templateSchedule.FailureTTL))
+}
+
+// isEligibleForLock returns true if the template has an inactivity TTL and the
+// workspace has not been used within it.
+func isEligibleForLock(ws database.Workspace, templateSchedule schedule.TemplateScheduleOptions, currentTick time.Time) bool {
+	if templateSchedule.InactivityTTL <= 0 || ws.LockedAt.Valid {
+		return false
+	}
+	// Workspaces that have never been used count from their creation.
+	lastUsedAt := ws.LastUsedAt
+	if lastUsedAt.Before(ws.CreatedAt) {
+		lastUsedAt = ws.CreatedAt
+	}
+	return !currentTick.Before(lastUsedAt.Add(templateSchedule.InactivityTTL))
+}
+
+// isEligibleForDelete returns true if the template has a locked TTL and the
+// workspace has been locked for longer than it. Workspaces whose latest build
+// is already a deletion are skipped so that failed deletions are not retried
+// on every tick.
+func isEligibleForDelete(ws database.Workspace, build database.WorkspaceBuild, templateSchedule schedule.TemplateScheduleOptions, currentTick time.Time) bool {
+	if templateSchedule.LockedTTL <= 0 || !ws.LockedAt.Valid {
+		return false
+	}
+	if build.Transition == database.WorkspaceTransitionDelete {
+		return false
+	}
+	return !currentTick.Before(ws.LockedAt.Time.Add(templateSchedule.LockedTTL))
 }
 
 // TODO(cian): this function duplicates most of api.postWorkspaceBuilds. Refactor.
 // See: https://github.com/coder/coder/issues/1401
-func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) error {
+func build(ctx context.Context, store database.Store, workspace database.Workspace, trans database.WorkspaceTransition, buildReason database.BuildReason, priorHistory database.WorkspaceBuild, priorJob database.ProvisionerJob) (database.WorkspaceBuild, error) {
 	template, err := store.GetTemplateByID(ctx, workspace.TemplateID)
 	if err != nil {
-		return xerrors.Errorf("get workspace template: %w", err)
+		return database.WorkspaceBuild{}, xerrors.Errorf("get workspace template: %w", err)
 	}
 
 	priorBuildNumber := priorHistory.BuildNumber
@@ -254,27 +419,35 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		WorkspaceBuildID: workspaceBuildID,
 	})
 	if err != nil {
-		return xerrors.Errorf("marshal provision job: %w", err)
+		return database.WorkspaceBuild{}, xerrors.Errorf("marshal provision job: %w", err)
 	}
 	provisionerJobID := uuid.New()
 	now := database.Now()
 
-	var buildReason database.BuildReason
-	switch trans {
-	case database.WorkspaceTransitionStart:
-		buildReason = database.BuildReasonAutostart
-	case database.WorkspaceTransitionStop:
-		buildReason = database.BuildReasonAutostop
-	default:
-		return xerrors.Errorf("Unsupported transition: %q", trans)
+	// Deleting a workspace must use the version it was last built with, so
+	// that the provisioner destroys the resources that were created.
+	templateVersionID := template.ActiveVersionID
+	if trans == database.WorkspaceTransitionDelete {
+		templateVersionID = priorHistory.TemplateVersionID
 	}
 
 	lastBuildParameters, err := store.GetWorkspaceBuildParameters(ctx, priorHistory.ID)
 	if err != nil {
-		return xerrors.Errorf("fetch prior workspace build parameters: %w", err)
+		return database.WorkspaceBuild{}, xerrors.Errorf("fetch prior workspace build parameters: %w", err)
+	}
+	templateVersionParameters, err := store.GetTemplateVersionParameters(ctx, templateVersionID)
+	if err != nil {
+		return database.WorkspaceBuild{}, xerrors.Errorf("fetch template version parameters: %w", err)
+	}
+	ephemeralParameters := make(map[string]struct{})
+	for _, templateVersionParameter := range templateVersionParameters {
+		if templateVersionParameter.Ephemeral {
+			ephemeralParameters[templateVersionParameter.Name] = struct{}{}
+		}
 	}
 
-	return store.InTx(func(db database.Store) error {
+	var workspaceBuild database.WorkspaceBuild
+	err = store.InTx(func(db database.Store) error {
 		newProvisionerJob, err := store.InsertProvisionerJob(ctx, database.InsertProvisionerJobParams{
 			ID:             provisionerJobID,
 			CreatedAt:      now,
@@ -291,12 +464,12 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		if err != nil {
 			return xerrors.Errorf("insert provisioner job: %w", err)
 		}
-		workspaceBuild, err := store.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
+		workspaceBuild, err = store.InsertWorkspaceBuild(ctx, database.InsertWorkspaceBuildParams{
 			ID:                workspaceBuildID,
 			CreatedAt:         now,
 			UpdatedAt:         now,
 			WorkspaceID:       workspace.ID,
-			TemplateVersionID: template.ActiveVersionID,
+			TemplateVersionID: templateVersionID,
 			BuildNumber:       priorBuildNumber + 1,
 			ProvisionerState:  priorHistory.ProvisionerState,
 			InitiatorID:       workspace.OwnerID,
@@ -311,6 +484,11 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		names := make([]string, 0, len(lastBuildParameters))
 		values := make([]string, 0, len(lastBuildParameters))
 		for _, param := range lastBuildParameters {
+			// Ephemeral parameters only apply to the build they were
+			// passed to, so the template defaults are used instead.
+			if _, ok := ephemeralParameters[param.Name]; ok {
+				continue
+			}
 			names = append(names, param.Name)
 			values = append(values, param.Value)
 		}
@@ -324,4 +502,5 @@ func build(ctx context.Context, store database.Store, workspace database.Workspa
 		}
 		return nil
 	}, nil)
+	return workspaceBuild, err
 }
@@ -2,14 +2,19 @@ package executor_test
 
 import (
 	"context"
+	"database/sql"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"cdr.dev/slog/sloggers/slogtest"
 	"go.uber.org/goleak"
+	"golang.org/x/xerrors"
 
 	"github.com/google/uuid"
 
+	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/autobuild/executor"
 	"github.com/coder/coder/coderd/coderdtest"
 	"github.com/coder/coder/coderd/database"
@@ -18,6 +23,7 @@ import (
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/provisioner/echo"
 	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -605,6 +611,214 @@ func TestExecutorAutostartWithParameters(t *testing.T) {
 	mustWorkspaceParameters(t, client, workspace.LatestBuild.ID)
 }
 
+func TestExecutorInactiveWorkspaceLocked(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		auditor = audit.NewMock()
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore:    mockTemplateScheduleStore{},
+			Auditor:                  auditor,
+		})
+		// Given: we have a user with a running workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// And: the template locks workspaces after an hour of inactivity
+	mustUpdateTemplateMeta(t, client, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: time.Hour.Milliseconds(),
+	})
+	require.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	require.Nil(t, workspace.LockedAt)
+
+	// When: the autobuild executor ticks after the inactivity TTL has elapsed
+	go func() {
+		tickCh <- time.Now().Add(2 * time.Hour)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped and locked
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonAutolock, workspace.LatestBuild.Reason)
+	assert.NotNil(t, workspace.LockedAt)
+
+	// And: both the lock and the build should be audited
+	var lockAudited, buildAudited bool
+	for _, alog := range auditor.Logs() {
+		switch {
+		case alog.ResourceType == database.ResourceTypeWorkspace && alog.Action == database.AuditActionWrite:
+			lockAudited = true
+		case alog.ResourceType == database.ResourceTypeWorkspaceBuild && alog.Action == database.AuditActionStop &&
+			alog.ResourceID == workspace.LatestBuild.ID && strings.Contains(string(alog.AdditionalFields), string(database.BuildReasonAutolock)):
+			buildAudited = true
+		}
+	}
+	assert.True(t, lockAudited, "lock should be audited")
+	assert.True(t, buildAudited, "build should be audited")
+}
+
+func TestExecutorInactiveWorkspaceLockRolledBack(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh         = make(chan time.Time)
+		statsCh        = make(chan executor.Stats)
+		client, _, api = coderdtest.NewWithAPI(t, &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+			TemplateScheduleStore:    mockTemplateScheduleStore{},
+		})
+		// Given: we have a user with a running workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// And: creating the stop build fails, as if the database errored
+	executor.New(
+		context.Background(),
+		failAutolockBuildStore{Store: api.Database},
+		&api.TemplateScheduleStore,
+		&api.Auditor,
+		slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}),
+		tickCh,
+	).WithStatsChannel(statsCh).Run()
+	// And: the template locks workspaces after an hour of inactivity
+	mustUpdateTemplateMeta(t, client, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: time.Hour.Milliseconds(),
+	})
+
+	// When: the autobuild executor ticks after the inactivity TTL has elapsed
+	go func() {
+		tickCh <- time.Now().Add(2 * time.Hour)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should neither be stopped nor locked
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.WorkspaceTransitionStart, workspace.LatestBuild.Transition)
+	assert.Nil(t, workspace.LockedAt)
+}
+
+func TestExecutorLockedWorkspaceDeleted(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore:    mockTemplateScheduleStore{},
+		})
+		// Given: we have a user with a running workspace
+		workspace = mustProvisionWorkspace(t, client)
+	)
+	// And: the template locks inactive workspaces and deletes locked ones
+	mustUpdateTemplateMeta(t, client, workspace.TemplateID, codersdk.UpdateTemplateMeta{
+		InactivityTTLMillis: time.Hour.Milliseconds(),
+		LockedTTLMillis:     time.Hour.Milliseconds(),
+	})
+
+	// When: the workspace is locked due to inactivity
+	tickCh <- time.Now().Add(2 * time.Hour)
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	require.NotNil(t, workspace.LockedAt)
+
+	// And: the autobuild executor ticks after the locked TTL has elapsed
+	go func() {
+		tickCh <- workspace.LockedAt.Add(2 * time.Hour)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be deleted
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionDelete, stats.Transitions[workspace.ID])
+
+	require.Eventually(t, func() bool {
+		ws, err := client.DeletedWorkspace(context.Background(), workspace.ID)
+		return err == nil && ws.LatestBuild.Reason == codersdk.BuildReasonAutodelete
+	}, testutil.WaitShort, testutil.IntervalFast)
+}
+
+func TestExecutorFailedWorkspaceStopped(t *testing.T) {
+	t.Parallel()
+
+	var (
+		tickCh  = make(chan time.Time)
+		statsCh = make(chan executor.Stats)
+		client  = coderdtest.New(t, &coderdtest.Options{
+			AutobuildTicker:          tickCh,
+			IncludeProvisionerDaemon: true,
+			AutobuildStats:           statsCh,
+			TemplateScheduleStore:    mockTemplateScheduleStore{},
+		})
+		user    = coderdtest.CreateFirstUser(t, client)
+		version = coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+			Parse:         echo.ParseComplete,
+			ProvisionPlan: echo.ProvisionComplete,
+			ProvisionApply: []*proto.Provision_Response{{
+				Type: &proto.Provision_Response_Complete{
+					Complete: &proto.Provision_Complete{
+						Error: "failed to provision",
+					},
+				},
+			}},
+		})
+		_        = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		template = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	)
+	// Given: the template stops failed workspaces after an hour
+	mustUpdateTemplateMeta(t, client, template.ID, codersdk.UpdateTemplateMeta{
+		FailureTTLMillis: time.Hour.Milliseconds(),
+	})
+
+	// And: a workspace whose start build failed
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+	require.Equal(t, codersdk.WorkspaceStatusFailed, build.Status)
+
+	// When: the autobuild executor ticks before the failure TTL has elapsed
+	tickCh <- build.Job.CompletedAt.Add(30 * time.Minute)
+
+	// Then: nothing should happen
+	stats := <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 0)
+
+	// When: the autobuild executor ticks after the failure TTL has elapsed
+	go func() {
+		tickCh <- build.Job.CompletedAt.Add(2 * time.Hour)
+		close(tickCh)
+	}()
+
+	// Then: the workspace should be stopped
+	stats = <-statsCh
+	assert.NoError(t, stats.Error)
+	assert.Len(t, stats.Transitions, 1)
+	assert.Equal(t, database.WorkspaceTransitionStop, stats.Transitions[workspace.ID])
+
+	workspace = coderdtest.MustWorkspace(t, client, workspace.ID)
+	assert.Equal(t, codersdk.BuildReasonFailedStop, workspace.LatestBuild.Reason)
+}
+
 func mustProvisionWorkspace(t *testing.T, client *codersdk.Client, mut ...func(*codersdk.CreateWorkspaceRequest)) codersdk.Workspace {
 	t.Helper()
 	user := coderdtest.CreateFirstUser(t, client)
@@ -645,6 +859,12 @@ func mustProvisionWorkspaceWithParameters(t *testing.T, client *codersdk.Client,
 	return coderdtest.MustWorkspace(t, client, ws.ID)
 }
 
+func mustUpdateTemplateMeta(t *testing.T, client *codersdk.Client, templateID uuid.UUID, req codersdk.UpdateTemplateMeta) {
+	t.Helper()
+	_, err := client.UpdateTemplateMeta(context.Background(), templateID, req)
+	require.NoError(t, err)
+}
+
 func mustSchedule(t *testing.T, s string) *schedule.Schedule {
 	t.Helper()
 	sched, err := schedule.Weekly(s)
@@ -659,6 +879,57 @@ func mustWorkspaceParameters(t *testing.T, client *codersdk.Client, workspaceID
 	require.NotEmpty(t, buildParameters)
 }
 
+// mockTemplateScheduleStore persists all template schedule options, including
+// the ones ignored by the AGPL store.
+type mockTemplateScheduleStore struct{}
+
+var _ schedule.TemplateScheduleStore = mockTemplateScheduleStore{}
+
+func (mockTemplateScheduleStore) GetTemplateScheduleOptions(ctx context.Context, db database.Store, id uuid.UUID) (schedule.TemplateScheduleOptions, error) {
+	tpl, err := db.GetTemplateByID(ctx, id)
+	if err != nil {
+		return schedule.TemplateScheduleOptions{}, err
+	}
+	return schedule.TemplateScheduleOptions{
+		UserSchedulingEnabled: true,
+		DefaultTTL:            time.Duration(tpl.DefaultTTL),
+		MaxTTL:                time.Duration(tpl.MaxTTL),
+		InactivityTTL:         time.Duration(tpl.InactivityTTL),
+		LockedTTL:             time.Duration(tpl.LockedTTL),
+		FailureTTL:            time.Duration(tpl.FailureTTL),
+	}, nil
+}
+
+func (mockTemplateScheduleStore) SetTemplateScheduleOptions(ctx context.Context, db database.Store, tpl database.Template, opts schedule.TemplateScheduleOptions) (database.Template, error) {
+	return db.UpdateTemplateScheduleByID(ctx, database.UpdateTemplateScheduleByIDParams{
+		ID:            tpl.ID,
+		UpdatedAt:     database.Now(),
+		DefaultTTL:    int64(opts.DefaultTTL),
+		MaxTTL:        int64(opts.MaxTTL),
+		InactivityTTL: int64(opts.InactivityTTL),
+		LockedTTL:     int64(opts.LockedTTL),
+		FailureTTL:    int64(opts.FailureTTL),
+	})
+}
+
+// failAutolockBuildStore fails to insert builds that lock a workspace.
+type failAutolockBuildStore struct {
+	database.Store
+}
+
+func (s failAutolockBuildStore) InTx(fn func(database.Store) error, opts *sql.TxOptions) error {
+	return s.Store.InTx(func(tx database.Store) error {
+		return fn(failAutolockBuildStore{Store: tx})
+	}, opts)
+}
+
+func (s failAutolockBuildStore) InsertWorkspaceBuild(ctx context.Context, arg database.InsertWorkspaceBuildParams) (database.WorkspaceBuild, error) {
+	if arg.Reason == database.BuildReasonAutolock {
+		return database.WorkspaceBuild{}, xerrors.New("insert workspace build failed")
+	}
+	return s.Store.InsertWorkspaceBuild(ctx, arg)
+}
+
 func TestMain(m *testing.M) {
 	goleak.VerifyTestMain(m)
 }
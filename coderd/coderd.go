@@ -304,6 +304,12 @@ func New(options *Options) *API {
 	api.Auditor.Store(&options.Auditor)
 	api.TemplateScheduleStore.Store(&options.TemplateScheduleStore)
 	api.workspaceAgentCache = wsconncache.New(api.dialWorkspaceAgentTailnet, 0)
+	api.workspaceAppServer = &workspaceapps.Server{
+		Logger:             options.Logger.Named("workspaceapps"),
+		DashboardURL:       options.AccessURL,
+		RealIPConfig:       options.RealIPConfig,
+		WorkspaceConnCache: api.workspaceAgentCache,
+	}
 	api.TailnetCoordinator.Store(&options.TailnetCoordinator)
 
 	apiKeyMiddleware := httpmw.ExtractAPIKeyMW(httpmw.ExtractAPIKeyConfig{
@@ -321,6 +327,14 @@ func New(options *Options) *API {
 		DisableSessionExpiryRefresh: options.DeploymentValues.DisableSessionExpiryRefresh.Value(),
 		Optional:                    false,
 	})
+	// Same as apiKeyMiddleware but it allows the request to continue without an API key.
+	apiKeyMiddlewareOptional := httpmw.ExtractAPIKeyMW(httpmw.ExtractAPIKeyConfig{
+		DB:                          options.Database,
+		OAuth2Configs:               oauthConfigs,
+		RedirectToLogin:             false,
+		DisableSessionExpiryRefresh: options.DeploymentValues.DisableSessionExpiryRefresh.Value(),
+		Optional:                    true,
+	})
 
 	// API rate limit middleware. The counter is local and not shared between
 	// replicas or instances of this middleware.
@@ -364,6 +378,9 @@ func New(options *Options) *API {
 	)
 
 	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) { _, _ = w.Write([]byte("OK")) })
+	// This is used by clients to measure the latency to each region, see
+	// codersdk.Client.RegionLatency.
+	r.Get("/latency-check", latencyCheck)
 
 	apps := func(r chi.Router) {
 		// Workspace apps do their own auth.
@@ -425,6 +442,7 @@ func New(options *Options) *API {
 			)
 
 			r.Get("/", api.auditLogs)
+			r.Get("/export", api.exportAuditLogs)
 			r.Post("/testgenerate", api.generateFakeAuditLog)
 		})
 		r.Route("/files", func(r chi.Router) {
@@ -460,6 +478,11 @@ func New(options *Options) *API {
 				})
 				r.Route("/members", func(r chi.Router) {
 					r.Get("/roles", api.assignableOrgRoles)
+					r.Route("/roles/custom", func(r chi.Router) {
+						r.Get("/", api.customOrgRoles)
+						r.Put("/", api.putCustomOrgRole)
+						r.Delete("/{role}", api.deleteCustomOrgRole)
+					})
 					r.Route("/{user}", func(r chi.Router) {
 						r.Use(
 							httpmw.ExtractUserParam(options.Database, false),
@@ -502,6 +525,8 @@ func New(options *Options) *API {
 			r.Get("/", api.templateVersion)
 			r.Patch("/", api.patchTemplateVersion)
 			r.Patch("/cancel", api.patchCancelTemplateVersion)
+			r.Post("/archive", api.postArchiveTemplateVersion)
+			r.Post("/unarchive", api.postUnarchiveTemplateVersion)
 			r.Get("/schema", api.templateVersionSchema)
 			r.Get("/parameters", api.templateVersionParameters)
 			r.Get("/rich-parameters", api.templateVersionRichParameters)
@@ -509,6 +534,7 @@ func New(options *Options) *API {
 			r.Get("/variables", api.templateVersionVariables)
 			r.Get("/resources", api.templateVersionResources)
 			r.Get("/logs", api.templateVersionLogs)
+			r.Get("/diff", api.templateVersionDiff)
 			r.Route("/dry-run", func(r chi.Router) {
 				r.Post("/", api.postTemplateVersionDryRun)
 				r.Get("/{jobID}", api.templateVersionDryRun)
@@ -550,6 +576,11 @@ func New(options *Options) *API {
 				// These routes query information about site wide roles.
 				r.Route("/roles", func(r chi.Router) {
 					r.Get("/", api.assignableSiteRoles)
+					r.Route("/custom", func(r chi.Router) {
+						r.Get("/", api.customSiteRoles)
+						r.Put("/", api.putCustomSiteRole)
+						r.Delete("/{role}", api.deleteCustomSiteRole)
+					})
 				})
 				r.Route("/{user}", func(r chi.Router) {
 					r.Use(httpmw.ExtractUserParam(options.Database, false))
@@ -596,6 +627,11 @@ func New(options *Options) *API {
 				})
 			})
 		})
+		r.Route("/terminal-recordings", func(r chi.Router) {
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.terminalRecordings)
+			r.Get("/{recording}", api.terminalRecording)
+		})
 		r.Route("/workspaceagents", func(r chi.Router) {
 			r.Post("/azure-instance-identity", api.postWorkspaceAuthAzureInstanceIdentity)
 			r.Post("/aws-instance-identity", api.postWorkspaceAuthAWSInstanceIdentity)
@@ -603,6 +639,7 @@ func New(options *Options) *API {
 			r.Route("/me", func(r chi.Router) {
 				r.Use(httpmw.ExtractWorkspaceAgent(options.Database))
 				r.Get("/metadata", api.workspaceAgentMetadata)
+				r.Post("/metadata/{key}", api.workspaceAgentPostMetadata)
 				r.Post("/startup", api.postWorkspaceAgentStartup)
 				r.Patch("/startup-logs", api.patchWorkspaceAgentStartupLogs)
 				r.Post("/app-health", api.postWorkspaceAppHealth)
@@ -611,19 +648,38 @@ func New(options *Options) *API {
 				r.Get("/coordinate", api.workspaceAgentCoordinate)
 				r.Post("/report-stats", api.workspaceAgentReportStats)
 				r.Post("/report-lifecycle", api.workspaceAgentReportLifecycle)
+				r.Post("/netcheck", api.workspaceAgentPostNetcheck)
+				r.Post("/terminal-recordings", api.postWorkspaceAgentTerminalRecording)
 			})
 			r.Route("/{workspaceagent}", func(r chi.Router) {
-				r.Use(
-					apiKeyMiddleware,
-					httpmw.ExtractWorkspaceAgentParam(options.Database),
-					httpmw.ExtractWorkspaceParam(options.Database),
-				)
-				r.Get("/", api.workspaceAgent)
-				r.Get("/pty", api.workspaceAgentPTY)
-				r.Get("/startup-logs", api.workspaceAgentStartupLogs)
-				r.Get("/listening-ports", api.workspaceAgentListeningPorts)
-				r.Get("/connection", api.workspaceAgentConnection)
-				r.Get("/coordinate", api.workspaceAgentClientCoordinate)
+				r.Group(func(r chi.Router) {
+					r.Use(
+						apiKeyMiddleware,
+						httpmw.ExtractWorkspaceAgentParam(options.Database),
+						httpmw.ExtractWorkspaceParam(options.Database),
+					)
+					r.Get("/", api.workspaceAgent)
+					r.Get("/pty", api.workspaceAgentPTY)
+					r.Get("/startup-logs", api.workspaceAgentStartupLogs)
+					r.Get("/listening-ports", api.workspaceAgentListeningPorts)
+					r.Get("/watch-metadata", api.watchWorkspaceAgentMetadata)
+				})
+				// External workspace proxies connect to agents on behalf of
+				// users, so these routes also accept proxy authentication.
+				r.Group(func(r chi.Router) {
+					r.Use(
+						apiKeyMiddlewareOptional,
+						httpmw.ExtractWorkspaceProxy(httpmw.ExtractWorkspaceProxyConfig{
+							DB:       options.Database,
+							Optional: true,
+						}),
+						httpmw.RequireAPIKeyOrWorkspaceProxyAuth(),
+						httpmw.ExtractWorkspaceAgentParam(options.Database),
+						httpmw.ExtractWorkspaceParam(options.Database),
+					)
+					r.Get("/connection", api.workspaceAgentConnection)
+					r.Get("/coordinate", api.workspaceAgentClientCoordinate)
+				})
 			})
 		})
 		r.Route("/workspaces", func(r chi.Router) {
@@ -649,6 +705,12 @@ func New(options *Options) *API {
 				})
 				r.Get("/watch", api.watchWorkspace)
 				r.Put("/extend", api.putExtendWorkspace)
+				r.Put("/lock", api.putWorkspaceLock)
+				r.Route("/port-shares", func(r chi.Router) {
+					r.Get("/", api.workspaceAgentPortShares)
+					r.Post("/", api.postWorkspaceAgentPortShare)
+					r.Delete("/", api.deleteWorkspaceAgentPortShare)
+				})
 			})
 		})
 		r.Route("/workspacebuilds/{workspacebuild}", func(r chi.Router) {
@@ -682,6 +744,15 @@ func New(options *Options) *API {
 				// handler and the login page.
 				r.Get("/", api.workspaceApplicationAuth)
 			})
+			r.Route("/reconnecting-pty-ticket", func(r chi.Router) {
+				r.Use(apiKeyMiddleware)
+				r.Post("/", api.workspaceApplicationReconnectingPTYTicket)
+			})
+		})
+		r.Route("/regions", func(r chi.Router) {
+			// Don't leak the hostnames to unauthenticated users.
+			r.Use(apiKeyMiddleware)
+			r.Get("/", api.regions)
 		})
 		r.Route("/insights", func(r chi.Router) {
 			r.Use(apiKeyMiddleware)
@@ -704,6 +775,10 @@ func New(options *Options) *API {
 			)
 
 			r.Get("/coordinator", api.debugCoordinator)
+			r.Route("/workspace-agents/{workspaceagent}", func(r chi.Router) {
+				r.Use(httpmw.ExtractWorkspaceAgentParam(options.Database))
+				r.Get("/netcheck", api.debugWorkspaceAgentNetcheck)
+			})
 		})
 	})
 
@@ -737,6 +812,13 @@ type API struct {
 	TailnetCoordinator                atomic.Pointer[tailnet.Coordinator]
 	QuotaCommitter                    atomic.Pointer[proto.QuotaCommitter]
 	TemplateScheduleStore             atomic.Pointer[schedule.TemplateScheduleStore]
+	// RegionsFetcher is used by Enterprise code to add workspace proxy
+	// regions to the regions endpoint.
+	RegionsFetcher atomic.Pointer[func(ctx context.Context) ([]codersdk.Region, error)]
+	// WorkspaceProxyForHost is used by Enterprise code to allow app
+	// authentication redirects to workspace proxy hostnames. It returns the
+	// access URL of the proxy that serves the given host, if any.
+	WorkspaceProxyForHost atomic.Pointer[func(ctx context.Context, host string) (*url.URL, bool, error)]
 
 	HTTPAuth *HTTPAuthorizer
 
@@ -753,6 +835,7 @@ type API struct {
 
 	metricsCache          *metricscache.Cache
 	workspaceAgentCache   *wsconncache.Cache
+	workspaceAppServer    *workspaceapps.Server
 	updateChecker         *updatecheck.Checker
 	WorkspaceAppsProvider *workspaceapps.Provider
 
@@ -432,6 +432,8 @@ func randomRBACType() string {
 		rbac.ResourceDeploymentValues.Type,
 		rbac.ResourceReplicas.Type,
 		rbac.ResourceDebugInfo.Type,
+		rbac.ResourceWorkspaceProxy.Type,
+		rbac.ResourceTerminalRecording.Type,
 	}
 	return all[must(cryptorand.Intn(len(all)))]
 }
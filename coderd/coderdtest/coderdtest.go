@@ -161,7 +161,10 @@ func newWithCloser(t *testing.T, options *Options) (*codersdk.Client, io.Closer)
 	return client, closer
 }
 
-func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.CancelFunc, *url.URL, *coderd.Options) {
+// NewOptions returns the options for a test API. The returned function must be
+// called with the API once it's created to serve it and start the autobuild
+// executor.
+func NewOptions(t *testing.T, options *Options) (func(*coderd.API), context.CancelFunc, *url.URL, *coderd.Options) {
 	if options == nil {
 		options = &Options{}
 	}
@@ -210,13 +213,6 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 	}
 
 	ctx, cancelFunc := context.WithCancel(context.Background())
-	lifecycleExecutor := executor.New(
-		ctx,
-		options.Database,
-		slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
-		options.AutobuildTicker,
-	).WithStatsChannel(options.AutobuildStats)
-	lifecycleExecutor.Run()
 
 	var mutex sync.RWMutex
 	var handler http.Handler
@@ -274,10 +270,21 @@ func NewOptions(t *testing.T, options *Options) (func(http.Handler), context.Can
 		require.NoError(t, err)
 	}
 
-	return func(h http.Handler) {
+	return func(api *coderd.API) {
 			mutex.Lock()
 			defer mutex.Unlock()
-			handler = h
+			handler = api.RootHandler
+
+			// The executor uses the API's template schedule store and
+			// auditor, since they're swapped when entitlements change.
+			executor.New(
+				ctx,
+				options.Database,
+				&api.TemplateScheduleStore,
+				&api.Auditor,
+				slogtest.Make(t, nil).Named("autobuild.executor").Leveled(slog.LevelDebug),
+				options.AutobuildTicker,
+			).WithStatsChannel(options.AutobuildStats).Run()
 		}, cancelFunc, serverURL, &coderd.Options{
 			AgentConnectionUpdateFrequency: 150 * time.Millisecond,
 			// Force a long disconnection timeout to ensure
@@ -348,7 +355,7 @@ func NewWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 	setHandler, cancelFunc, serverURL, newOptions := NewOptions(t, options)
 	// We set the handler after server creation for the access URL.
 	coderAPI := coderd.New(newOptions)
-	setHandler(coderAPI.RootHandler)
+	setHandler(coderAPI)
 	var provisionerCloser io.Closer = nopcloser{}
 	if options.IncludeProvisionerDaemon {
 		provisionerCloser = NewProvisionerDaemon(t, coderAPI)
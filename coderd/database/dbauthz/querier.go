@@ -48,6 +48,17 @@ func (q *querier) GetAPIKeyByName(ctx context.Context, arg database.GetAPIKeyByN
 	return fetch(q.log, q.auth, q.db.GetAPIKeyByName)(ctx, arg)
 }
 
+// GetAPIKeyScopeResourcesByAPIKeyIDs requires read access to every API key.
+func (q *querier) GetAPIKeyScopeResourcesByAPIKeyIDs(ctx context.Context, ids []string) ([]database.APIKeyScopeResource, error) {
+	for _, id := range ids {
+		_, err := q.GetAPIKeyByID(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return q.db.GetAPIKeyScopeResourcesByAPIKeyIDs(ctx, ids)
+}
+
 func (q *querier) GetAPIKeysByLoginType(ctx context.Context, loginType database.LoginType) ([]database.APIKey, error) {
 	return fetchWithPostFilter(q.auth, q.db.GetAPIKeysByLoginType)(ctx, loginType)
 }
@@ -66,6 +77,19 @@ func (q *querier) InsertAPIKey(ctx context.Context, arg database.InsertAPIKeyPar
 		q.db.InsertAPIKey)(ctx, arg)
 }
 
+// InsertAPIKeyScopeResources requires the same access as creating the API key,
+// as resources are only scoped when the key is created.
+func (q *querier) InsertAPIKeyScopeResources(ctx context.Context, arg database.InsertAPIKeyScopeResourcesParams) ([]database.APIKeyScopeResource, error) {
+	key, err := q.db.GetAPIKeyByID(ctx, arg.APIKeyID)
+	if err != nil {
+		return nil, err
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceAPIKey.WithOwner(key.UserID.String())); err != nil {
+		return nil, err
+	}
+	return q.db.InsertAPIKeyScopeResources(ctx, arg)
+}
+
 func (q *querier) UpdateAPIKeyByID(ctx context.Context, arg database.UpdateAPIKeyByIDParams) error {
 	fetch := func(ctx context.Context, arg database.UpdateAPIKeyByIDParams) (database.APIKey, error) {
 		return q.db.GetAPIKeyByID(ctx, arg.ID)
@@ -280,6 +304,69 @@ func (q *querier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg dat
 	return q.db.GetWorkspaceAgentStartupLogsAfter(ctx, arg)
 }
 
+func (q *querier) GetWorkspaceAgentMetadata(ctx context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.authorizeContext(ctx, rbac.ActionRead, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceAgentMetadata(ctx, workspaceAgentID)
+}
+
+func (q *querier) GetWorkspaceAgentPortShare(ctx context.Context, arg database.GetWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	err = q.authorizeContext(ctx, rbac.ActionRead, workspace)
+	if err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	return q.db.GetWorkspaceAgentPortShare(ctx, arg)
+}
+
+func (q *querier) GetWorkspaceAgentPortSharesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]database.WorkspaceAgentPortShare, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = q.authorizeContext(ctx, rbac.ActionRead, workspace)
+	if err != nil {
+		return nil, err
+	}
+
+	return q.db.GetWorkspaceAgentPortSharesByWorkspaceID(ctx, workspaceID)
+}
+
+func (q *querier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg database.UpsertWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	workspace, err := q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	if err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	err = q.authorizeContext(ctx, rbac.ActionUpdate, workspace)
+	if err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	return q.db.UpsertWorkspaceAgentPortShare(ctx, arg)
+}
+
+func (q *querier) DeleteWorkspaceAgentPortShare(ctx context.Context, arg database.DeleteWorkspaceAgentPortShareParams) error {
+	fetch := func(ctx context.Context, arg database.DeleteWorkspaceAgentPortShareParams) (database.Workspace, error) {
+		return q.db.GetWorkspaceByID(ctx, arg.WorkspaceID)
+	}
+	return update(q.log, q.auth, fetch, q.db.DeleteWorkspaceAgentPortShare)(ctx, arg)
+}
+
 func (q *querier) GetLicenses(ctx context.Context) ([]database.License, error) {
 	fetch := func(ctx context.Context, _ interface{}) ([]database.License, error) {
 		return q.db.GetLicenses(ctx)
@@ -308,6 +395,13 @@ func (q *querier) InsertOrUpdateServiceBanner(ctx context.Context, value string)
 	return q.db.InsertOrUpdateServiceBanner(ctx, value)
 }
 
+func (q *querier) InsertOrUpdateAuditFilterRules(ctx context.Context, value string) error {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceDeploymentValues); err != nil {
+		return err
+	}
+	return q.db.InsertOrUpdateAuditFilterRules(ctx, value)
+}
+
 func (q *querier) GetLicenseByID(ctx context.Context, id int32) (database.License, error) {
 	return fetch(q.log, q.auth, q.db.GetLicenseByID)(ctx, id)
 }
@@ -348,6 +442,13 @@ func (q *querier) GetServiceBanner(ctx context.Context) (string, error) {
 	return q.db.GetServiceBanner(ctx)
 }
 
+func (q *querier) GetAuditFilterRules(ctx context.Context) (string, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceAuditLog); err != nil {
+		return "", err
+	}
+	return q.db.GetAuditFilterRules(ctx)
+}
+
 func (q *querier) GetProvisionerDaemons(ctx context.Context) ([]database.ProvisionerDaemon, error) {
 	fetch := func(ctx context.Context, _ interface{}) ([]database.ProvisionerDaemon, error) {
 		return q.db.GetProvisionerDaemons(ctx)
@@ -454,8 +555,34 @@ func (q *querier) canAssignRoles(ctx context.Context, orgID *uuid.UUID, added, r
 		}
 
 		// All roles should be valid roles
-		if _, err := rbac.RoleByName(r); err != nil {
-			return xerrors.Errorf("%q is not a supported role", r)
+		if rbac.IsBuiltInRole(r) {
+			if _, err := rbac.RoleByName(r); err != nil {
+				return xerrors.Errorf("%q is not a supported role", r)
+			}
+		}
+	}
+
+	// Added custom roles must exist. Removed ones may have been deleted
+	// since they were assigned.
+	var addedCustom []string
+	for _, r := range added {
+		if !rbac.IsBuiltInRole(r) {
+			addedCustom = append(addedCustom, r)
+		}
+	}
+	if len(addedCustom) > 0 {
+		customRoles, err := q.db.GetCustomRolesByName(ctx, addedCustom)
+		if err != nil {
+			return xerrors.Errorf("get custom roles: %w", err)
+		}
+		found := make(map[string]bool, len(customRoles))
+		for _, role := range customRoles {
+			found[rbac.CustomRoleName(role.Name, role.OrganizationID)] = true
+		}
+		for _, r := range addedCustom {
+			if !found[r] {
+				return xerrors.Errorf("%q is not a supported role", r)
+			}
 		}
 	}
 
@@ -869,6 +996,28 @@ func (q *querier) UpdateTemplateVersionByID(ctx context.Context, arg database.Up
 	return q.db.UpdateTemplateVersionByID(ctx, arg)
 }
 
+func (q *querier) UpdateTemplateVersionArchivedByID(ctx context.Context, arg database.UpdateTemplateVersionArchivedByIDParams) error {
+	// An actor is allowed to archive the template version if they are authorized to update the template.
+	tv, err := q.db.GetTemplateVersionByID(ctx, arg.ID)
+	if err != nil {
+		return err
+	}
+	var obj rbac.Objecter
+	if !tv.TemplateID.Valid {
+		obj = rbac.ResourceTemplate.InOrg(tv.OrganizationID)
+	} else {
+		tpl, err := q.db.GetTemplateByID(ctx, tv.TemplateID.UUID)
+		if err != nil {
+			return err
+		}
+		obj = tpl
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, obj); err != nil {
+		return err
+	}
+	return q.db.UpdateTemplateVersionArchivedByID(ctx, arg)
+}
+
 func (q *querier) UpdateTemplateVersionDescriptionByJobID(ctx context.Context, arg database.UpdateTemplateVersionDescriptionByJobIDParams) error {
 	// An actor is allowed to update the template version description if they are authorized to update the template.
 	tv, err := q.db.GetTemplateVersionByJobID(ctx, arg.JobID)
@@ -1027,6 +1176,19 @@ func (q *querier) GetUsersWithCount(ctx context.Context, arg database.GetUsersPa
 	return users, rowUsers[0].Count, nil
 }
 
+func (q *querier) GetTerminalRecordingByID(ctx context.Context, id uuid.UUID) (database.TerminalRecording, error) {
+	return fetch(q.log, q.auth, q.db.GetTerminalRecordingByID)(ctx, id)
+}
+
+func (q *querier) GetTerminalRecordings(ctx context.Context, arg database.GetTerminalRecordingsParams) ([]database.GetTerminalRecordingsRow, error) {
+	return fetchWithPostFilter(q.auth, q.db.GetTerminalRecordings)(ctx, arg)
+}
+
+func (q *querier) InsertTerminalRecording(ctx context.Context, arg database.InsertTerminalRecordingParams) (database.TerminalRecording, error) {
+	obj := rbac.ResourceTerminalRecording.InOrg(arg.OrganizationID)
+	return insert(q.log, q.auth, obj, q.db.InsertTerminalRecording)(ctx, arg)
+}
+
 func (q *querier) InsertUser(ctx context.Context, arg database.InsertUserParams) (database.User, error) {
 	// Always check if the assigned roles can actually be assigned by this actor.
 	impliedRoles := append([]string{rbac.RoleMember()}, arg.RBACRoles...)
@@ -1271,6 +1433,24 @@ func (q *querier) UpdateWorkspaceAgentStartupLogOverflowByID(ctx context.Context
 	return q.db.UpdateWorkspaceAgentStartupLogOverflowByID(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceAgentNetcheckByID(ctx context.Context, arg database.UpdateWorkspaceAgentNetcheckByIDParams) error {
+	agent, err := q.db.GetWorkspaceAgentByID(ctx, arg.ID)
+	if err != nil {
+		return err
+	}
+
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, agent.ID)
+	if err != nil {
+		return err
+	}
+
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, workspace); err != nil {
+		return err
+	}
+
+	return q.db.UpdateWorkspaceAgentNetcheckByID(ctx, arg)
+}
+
 func (q *querier) UpdateWorkspaceAgentStartupByID(ctx context.Context, arg database.UpdateWorkspaceAgentStartupByIDParams) error {
 	agent, err := q.db.GetWorkspaceAgentByID(ctx, arg.ID)
 	if err != nil {
@@ -1289,6 +1469,20 @@ func (q *querier) UpdateWorkspaceAgentStartupByID(ctx context.Context, arg datab
 	return q.db.UpdateWorkspaceAgentStartupByID(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	workspace, err := q.db.GetWorkspaceByAgentID(ctx, arg.WorkspaceAgentID)
+	if err != nil {
+		return err
+	}
+
+	err = q.authorizeContext(ctx, rbac.ActionUpdate, workspace)
+	if err != nil {
+		return err
+	}
+
+	return q.db.UpdateWorkspaceAgentMetadata(ctx, arg)
+}
+
 func (q *querier) GetWorkspaceAppByAgentIDAndSlug(ctx context.Context, arg database.GetWorkspaceAppByAgentIDAndSlugParams) (database.WorkspaceApp, error) {
 	// If we can fetch the workspace, we can fetch the apps. Use the authorized call.
 	if _, err := q.GetWorkspaceByAgentID(ctx, arg.AgentID); err != nil {
@@ -1570,6 +1764,13 @@ func (q *querier) UpdateWorkspaceTTLToBeWithinTemplateMax(ctx context.Context, a
 	return fetchAndExec(q.log, q.auth, rbac.ActionUpdate, fetch, q.db.UpdateWorkspaceTTLToBeWithinTemplateMax)(ctx, arg)
 }
 
+func (q *querier) UpdateWorkspaceLockedAt(ctx context.Context, arg database.UpdateWorkspaceLockedAtParams) error {
+	fetch := func(ctx context.Context, arg database.UpdateWorkspaceLockedAtParams) (database.Workspace, error) {
+		return q.db.GetWorkspaceByID(ctx, arg.ID)
+	}
+	return update(q.log, q.auth, fetch, q.db.UpdateWorkspaceLockedAt)(ctx, arg)
+}
+
 func (q *querier) UpdateWorkspaceTTL(ctx context.Context, arg database.UpdateWorkspaceTTLParams) error {
 	fetch := func(ctx context.Context, arg database.UpdateWorkspaceTTLParams) (database.Workspace, error) {
 		return q.db.GetWorkspaceByID(ctx, arg.ID)
@@ -1581,6 +1782,39 @@ func (q *querier) GetWorkspaceByWorkspaceAppID(ctx context.Context, workspaceApp
 	return fetch(q.log, q.auth, q.db.GetWorkspaceByWorkspaceAppID)(ctx, workspaceAppID)
 }
 
+func (q *querier) GetWorkspaceProxies(ctx context.Context) ([]database.WorkspaceProxy, error) {
+	return fetchWithPostFilter(q.auth, func(ctx context.Context, _ interface{}) ([]database.WorkspaceProxy, error) {
+		return q.db.GetWorkspaceProxies(ctx)
+	})(ctx, nil)
+}
+
+func (q *querier) GetWorkspaceProxyByID(ctx context.Context, id uuid.UUID) (database.WorkspaceProxy, error) {
+	return fetch(q.log, q.auth, q.db.GetWorkspaceProxyByID)(ctx, id)
+}
+
+func (q *querier) GetWorkspaceProxyByName(ctx context.Context, name string) (database.WorkspaceProxy, error) {
+	return fetch(q.log, q.auth, q.db.GetWorkspaceProxyByName)(ctx, name)
+}
+
+func (q *querier) InsertWorkspaceProxy(ctx context.Context, arg database.InsertWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	return insert(q.log, q.auth, rbac.ResourceWorkspaceProxy, q.db.InsertWorkspaceProxy)(ctx, arg)
+}
+
+func (q *querier) RegisterWorkspaceProxy(ctx context.Context, arg database.RegisterWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	fetch := func(ctx context.Context, arg database.RegisterWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+		return q.db.GetWorkspaceProxyByID(ctx, arg.ID)
+	}
+	return updateWithReturn(q.log, q.auth, fetch, q.db.RegisterWorkspaceProxy)(ctx, arg)
+}
+
+func (q *querier) UpdateWorkspaceProxyDeleted(ctx context.Context, arg database.UpdateWorkspaceProxyDeletedParams) error {
+	fetch := func(ctx context.Context, arg database.UpdateWorkspaceProxyDeletedParams) (database.WorkspaceProxy, error) {
+		return q.db.GetWorkspaceProxyByID(ctx, arg.ID)
+	}
+	// This function is always used to delete.
+	return deleteQ(q.log, q.auth, fetch, q.db.UpdateWorkspaceProxyDeleted)(ctx, arg)
+}
+
 func authorizedTemplateVersionFromJob(ctx context.Context, q *querier, job database.ProvisionerJob) (database.TemplateVersion, error) {
 	switch job.Type {
 	case database.ProvisionerJobTypeTemplateVersionDryRun:
@@ -1610,3 +1844,39 @@ func authorizedTemplateVersionFromJob(ctx context.Context, q *querier, job datab
 		return database.TemplateVersion{}, xerrors.Errorf("unknown job type: %q", job.Type)
 	}
 }
+
+// customRoleObject returns the rbac object for the custom roles of an
+// organization, or site wide custom roles if there is none.
+func customRoleObject(organizationID uuid.NullUUID) rbac.Object {
+	if organizationID.Valid {
+		return rbac.ResourceCustomRole.InOrg(organizationID.UUID)
+	}
+	return rbac.ResourceCustomRole
+}
+
+// GetCustomRoles requires the same permission as listing the roles that can
+// be assigned, as custom roles are listed alongside built-in ones.
+func (q *querier) GetCustomRoles(ctx context.Context, organizationID uuid.NullUUID) ([]database.CustomRole, error) {
+	object := rbac.ResourceRoleAssignment
+	if organizationID.Valid {
+		object = rbac.ResourceOrgRoleAssignment.InOrg(organizationID.UUID)
+	}
+	if err := q.authorizeContext(ctx, rbac.ActionRead, object); err != nil {
+		return nil, err
+	}
+	return q.db.GetCustomRoles(ctx, organizationID)
+}
+
+func (q *querier) UpsertCustomRole(ctx context.Context, arg database.UpsertCustomRoleParams) (database.CustomRole, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, customRoleObject(arg.OrganizationID)); err != nil {
+		return database.CustomRole{}, err
+	}
+	return q.db.UpsertCustomRole(ctx, arg)
+}
+
+func (q *querier) DeleteCustomRole(ctx context.Context, arg database.DeleteCustomRoleParams) error {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, customRoleObject(arg.OrganizationID)); err != nil {
+		return err
+	}
+	return q.db.DeleteCustomRole(ctx, arg)
+}
@@ -33,6 +33,17 @@ func (s *MethodTestSuite) TestAPIKey() {
 			UserID:    key.UserID,
 		}).Asserts(key, rbac.ActionRead).Returns(key)
 	}))
+	s.Run("GetAPIKeyScopeResourcesByAPIKeyIDs", s.Subtest(func(db database.Store, check *expects) {
+		key, _ := dbgen.APIKey(s.T(), db, database.APIKey{Scope: database.APIKeyScopeResources})
+		resources, err := db.InsertAPIKeyScopeResources(context.Background(), database.InsertAPIKeyScopeResourcesParams{
+			APIKeyID:     key.ID,
+			ResourceType: []string{rbac.ResourceTemplate.Type},
+			ResourceID:   []uuid.UUID{uuid.New()},
+			Action:       []string{string(rbac.ActionRead)},
+		})
+		require.NoError(s.T(), err)
+		check.Args([]string{key.ID}).Asserts(key, rbac.ActionRead).Returns(resources)
+	}))
 	s.Run("GetAPIKeysByLoginType", s.Subtest(func(db database.Store, check *expects) {
 		a, _ := dbgen.APIKey(s.T(), db, database.APIKey{LoginType: database.LoginTypePassword})
 		b, _ := dbgen.APIKey(s.T(), db, database.APIKey{LoginType: database.LoginTypePassword})
@@ -69,6 +80,15 @@ func (s *MethodTestSuite) TestAPIKey() {
 			Scope:     database.APIKeyScopeAll,
 		}).Asserts(rbac.ResourceAPIKey.WithOwner(u.ID.String()), rbac.ActionCreate)
 	}))
+	s.Run("InsertAPIKeyScopeResources", s.Subtest(func(db database.Store, check *expects) {
+		key, _ := dbgen.APIKey(s.T(), db, database.APIKey{Scope: database.APIKeyScopeResources})
+		check.Args(database.InsertAPIKeyScopeResourcesParams{
+			APIKeyID:     key.ID,
+			ResourceType: []string{rbac.ResourceWorkspace.Type},
+			ResourceID:   []uuid.UUID{uuid.New()},
+			Action:       []string{string(rbac.ActionUpdate)},
+		}).Asserts(rbac.ResourceAPIKey.WithOwner(key.UserID.String()), rbac.ActionCreate)
+	}))
 	s.Run("UpdateAPIKeyByID", s.Subtest(func(db database.Store, check *expects) {
 		a, _ := dbgen.APIKey(s.T(), db, database.APIKey{})
 		check.Args(database.UpdateAPIKeyByIDParams{
@@ -93,6 +113,35 @@ func (s *MethodTestSuite) TestAuditLogs() {
 	}))
 }
 
+func (s *MethodTestSuite) TestCustomRole() {
+	s.Run("Site/GetCustomRoles", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(uuid.NullUUID{}).Asserts(rbac.ResourceRoleAssignment, rbac.ActionRead)
+	}))
+	s.Run("Org/GetCustomRoles", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(uuid.NullUUID{UUID: o.ID, Valid: true}).Asserts(rbac.ResourceOrgRoleAssignment.InOrg(o.ID), rbac.ActionRead)
+	}))
+	s.Run("Site/UpsertCustomRole", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.UpsertCustomRoleParams{
+			Name:        "custom",
+			DisplayName: "Custom",
+		}).Asserts(rbac.ResourceCustomRole, rbac.ActionCreate)
+	}))
+	s.Run("Org/UpsertCustomRole", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.UpsertCustomRoleParams{
+			Name:           "custom",
+			DisplayName:    "Custom",
+			OrganizationID: uuid.NullUUID{UUID: o.ID, Valid: true},
+		}).Asserts(rbac.ResourceCustomRole.InOrg(o.ID), rbac.ActionCreate)
+	}))
+	s.Run("DeleteCustomRole", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteCustomRoleParams{
+			Name: "custom",
+		}).Asserts(rbac.ResourceCustomRole, rbac.ActionDelete)
+	}))
+}
+
 func (s *MethodTestSuite) TestFile() {
 	s.Run("GetFileByHashAndCreator", s.Subtest(func(db database.Store, check *expects) {
 		f := dbgen.File(s.T(), db, database.File{})
@@ -318,6 +367,9 @@ func (s *MethodTestSuite) TestLicense() {
 	s.Run("InsertOrUpdateServiceBanner", s.Subtest(func(db database.Store, check *expects) {
 		check.Args("value").Asserts(rbac.ResourceDeploymentValues, rbac.ActionCreate)
 	}))
+	s.Run("InsertOrUpdateAuditFilterRules", s.Subtest(func(db database.Store, check *expects) {
+		check.Args("value").Asserts(rbac.ResourceDeploymentValues, rbac.ActionUpdate)
+	}))
 	s.Run("GetLicenseByID", s.Subtest(func(db database.Store, check *expects) {
 		l, err := db.InsertLicense(context.Background(), database.InsertLicenseParams{
 			UUID: uuid.New(),
@@ -345,6 +397,11 @@ func (s *MethodTestSuite) TestLicense() {
 		require.NoError(s.T(), err)
 		check.Args().Asserts().Returns("value")
 	}))
+	s.Run("GetAuditFilterRules", s.Subtest(func(db database.Store, check *expects) {
+		err := db.InsertOrUpdateAuditFilterRules(context.Background(), "value")
+		require.NoError(s.T(), err)
+		check.Args().Asserts(rbac.ResourceAuditLog, rbac.ActionRead).Returns("value")
+	}))
 }
 
 func (s *MethodTestSuite) TestOrganization() {
@@ -715,7 +772,8 @@ func (s *MethodTestSuite) TestTemplate() {
 	s.Run("UpdateTemplateMetaByID", s.Subtest(func(db database.Store, check *expects) {
 		t1 := dbgen.Template(s.T(), db, database.Template{})
 		check.Args(database.UpdateTemplateMetaByIDParams{
-			ID: t1.ID,
+			ID:                  t1.ID,
+			MaxPortSharingLevel: database.AppSharingLevelOwner,
 		}).Asserts(t1, rbac.ActionUpdate)
 	}))
 	s.Run("UpdateTemplateVersionByID", s.Subtest(func(db database.Store, check *expects) {
@@ -730,6 +788,16 @@ func (s *MethodTestSuite) TestTemplate() {
 			UpdatedAt:  tv.UpdatedAt,
 		}).Asserts(t1, rbac.ActionUpdate).Returns(tv)
 	}))
+	s.Run("UpdateTemplateVersionArchivedByID", s.Subtest(func(db database.Store, check *expects) {
+		t1 := dbgen.Template(s.T(), db, database.Template{})
+		tv := dbgen.TemplateVersion(s.T(), db, database.TemplateVersion{
+			TemplateID: uuid.NullUUID{UUID: t1.ID, Valid: true},
+		})
+		check.Args(database.UpdateTemplateVersionArchivedByIDParams{
+			ID:       tv.ID,
+			Archived: true,
+		}).Asserts(t1, rbac.ActionUpdate).Returns()
+	}))
 	s.Run("UpdateTemplateVersionDescriptionByJobID", s.Subtest(func(db database.Store, check *expects) {
 		jobID := uuid.New()
 		t1 := dbgen.Template(s.T(), db, database.Template{})
@@ -756,6 +824,37 @@ func (s *MethodTestSuite) TestTemplate() {
 	}))
 }
 
+func (s *MethodTestSuite) TestTerminalRecording() {
+	s.Run("GetTerminalRecordingByID", s.Subtest(func(db database.Store, check *expects) {
+		r := dbgen.TerminalRecording(s.T(), db, database.TerminalRecording{})
+		check.Args(r.ID).Asserts(r, rbac.ActionRead).Returns(r)
+	}))
+	s.Run("GetTerminalRecordings", s.Subtest(func(db database.Store, check *expects) {
+		r := dbgen.TerminalRecording(s.T(), db, database.TerminalRecording{})
+		row := database.GetTerminalRecordingsRow{
+			ID:             r.ID,
+			CreatedAt:      r.CreatedAt,
+			StartedAt:      r.StartedAt,
+			EndedAt:        r.EndedAt,
+			OrganizationID: r.OrganizationID,
+			WorkspaceID:    r.WorkspaceID,
+			AgentID:        r.AgentID,
+			UserID:         r.UserID,
+			Type:           r.Type,
+			Size:           r.Size,
+		}
+		check.Args(database.GetTerminalRecordingsParams{}).Asserts(row, rbac.ActionRead).Returns(slice.New(row))
+	}))
+	s.Run("InsertTerminalRecording", s.Subtest(func(db database.Store, check *expects) {
+		o := dbgen.Organization(s.T(), db, database.Organization{})
+		check.Args(database.InsertTerminalRecordingParams{
+			ID:             uuid.New(),
+			OrganizationID: o.ID,
+			Type:           database.TerminalRecordingTypeSSH,
+		}).Asserts(rbac.ResourceTerminalRecording.InOrg(o.ID), rbac.ActionCreate)
+	}))
+}
+
 func (s *MethodTestSuite) TestUser() {
 	s.Run("DeleteAPIKeysByUserID", s.Subtest(func(db database.Store, check *expects) {
 		u := dbgen.User(s.T(), db, database.User{})
@@ -993,6 +1092,15 @@ func (s *MethodTestSuite) TestWorkspace() {
 			StartupLogsOverflowed: true,
 		}).Asserts(ws, rbac.ActionUpdate).Returns()
 	}))
+	s.Run("UpdateWorkspaceAgentNetcheckByID", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		check.Args(database.UpdateWorkspaceAgentNetcheckByIDParams{
+			ID: agt.ID,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
 	s.Run("UpdateWorkspaceAgentStartupByID", s.Subtest(func(db database.Store, check *expects) {
 		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
 		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
@@ -1002,6 +1110,54 @@ func (s *MethodTestSuite) TestWorkspace() {
 			ID: agt.ID,
 		}).Asserts(ws, rbac.ActionUpdate).Returns()
 	}))
+	s.Run("GetWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		check.Args(agt.ID).Asserts(ws, rbac.ActionRead).Returns([]database.WorkspaceAgentMetadatum{})
+	}))
+	s.Run("GetWorkspaceAgentPortShare", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		share := dbgen.WorkspaceAgentPortShare(s.T(), db, database.WorkspaceAgentPortShare{WorkspaceID: ws.ID})
+		check.Args(database.GetWorkspaceAgentPortShareParams{
+			WorkspaceID: ws.ID,
+			AgentName:   share.AgentName,
+			Port:        share.Port,
+		}).Asserts(ws, rbac.ActionRead).Returns(share)
+	}))
+	s.Run("GetWorkspaceAgentPortSharesByWorkspaceID", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		share := dbgen.WorkspaceAgentPortShare(s.T(), db, database.WorkspaceAgentPortShare{WorkspaceID: ws.ID})
+		check.Args(ws.ID).Asserts(ws, rbac.ActionRead).Returns([]database.WorkspaceAgentPortShare{share})
+	}))
+	s.Run("UpsertWorkspaceAgentPortShare", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.UpsertWorkspaceAgentPortShareParams{
+			WorkspaceID: ws.ID,
+			AgentName:   "main",
+			Port:        8080,
+			ShareLevel:  database.AppSharingLevelPublic,
+		}).Asserts(ws, rbac.ActionUpdate)
+	}))
+	s.Run("DeleteWorkspaceAgentPortShare", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		share := dbgen.WorkspaceAgentPortShare(s.T(), db, database.WorkspaceAgentPortShare{WorkspaceID: ws.ID})
+		check.Args(database.DeleteWorkspaceAgentPortShareParams{
+			WorkspaceID: ws.ID,
+			AgentName:   share.AgentName,
+			Port:        share.Port,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
+	s.Run("UpdateWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
+		res := dbgen.WorkspaceResource(s.T(), db, database.WorkspaceResource{JobID: build.JobID})
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{ResourceID: res.ID})
+		check.Args(database.UpdateWorkspaceAgentMetadataParams{
+			WorkspaceAgentID: agt.ID,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
 	s.Run("GetWorkspaceAgentStartupLogsAfter", s.Subtest(func(db database.Store, check *expects) {
 		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
 		build := dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{WorkspaceID: ws.ID, JobID: uuid.New()})
@@ -1200,6 +1356,12 @@ func (s *MethodTestSuite) TestWorkspace() {
 			ID: ws.ID,
 		}).Asserts(ws, rbac.ActionUpdate).Returns()
 	}))
+	s.Run("UpdateWorkspaceLockedAt", s.Subtest(func(db database.Store, check *expects) {
+		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
+		check.Args(database.UpdateWorkspaceLockedAtParams{
+			ID: ws.ID,
+		}).Asserts(ws, rbac.ActionUpdate).Returns()
+	}))
 	s.Run("UpdateWorkspaceTTL", s.Subtest(func(db database.Store, check *expects) {
 		ws := dbgen.Workspace(s.T(), db, database.Workspace{})
 		check.Args(database.UpdateWorkspaceTTLParams{
@@ -1225,3 +1387,37 @@ func (s *MethodTestSuite) TestExtraMethods() {
 		check.Args().Asserts(d, rbac.ActionRead)
 	}))
 }
+
+func (s *MethodTestSuite) TestWorkspaceProxy() {
+	s.Run("GetWorkspaceProxies", s.Subtest(func(db database.Store, check *expects) {
+		p1, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		p2, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		check.Args().Asserts(p1, rbac.ActionRead, p2, rbac.ActionRead).Returns(slice.New(p1, p2))
+	}))
+	s.Run("GetWorkspaceProxyByID", s.Subtest(func(db database.Store, check *expects) {
+		p, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		check.Args(p.ID).Asserts(p, rbac.ActionRead).Returns(p)
+	}))
+	s.Run("GetWorkspaceProxyByName", s.Subtest(func(db database.Store, check *expects) {
+		p, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		check.Args(p.Name).Asserts(p, rbac.ActionRead).Returns(p)
+	}))
+	s.Run("InsertWorkspaceProxy", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.InsertWorkspaceProxyParams{
+			ID: uuid.New(),
+		}).Asserts(rbac.ResourceWorkspaceProxy, rbac.ActionCreate)
+	}))
+	s.Run("RegisterWorkspaceProxy", s.Subtest(func(db database.Store, check *expects) {
+		p, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		check.Args(database.RegisterWorkspaceProxyParams{
+			ID: p.ID,
+		}).Asserts(p, rbac.ActionUpdate)
+	}))
+	s.Run("UpdateWorkspaceProxyDeleted", s.Subtest(func(db database.Store, check *expects) {
+		p, _ := dbgen.WorkspaceProxy(s.T(), db, database.WorkspaceProxy{})
+		check.Args(database.UpdateWorkspaceProxyDeletedParams{
+			ID:      p.ID,
+			Deleted: true,
+		}).Asserts(p, rbac.ActionDelete)
+	}))
+}
@@ -96,6 +96,13 @@ func (q *querier) GetLatestWorkspaceBuilds(ctx context.Context) ([]database.Work
 	return q.db.GetLatestWorkspaceBuilds(ctx)
 }
 
+func (q *querier) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]database.Workspace, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetWorkspacesEligibleForTransition(ctx, now)
+}
+
 // GetWorkspaceAgentByAuthToken is used in http middleware to get the workspace agent.
 // This should only be used by a system user in that middleware.
 func (q *querier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken uuid.UUID) (database.WorkspaceAgent, error) {
@@ -126,6 +133,13 @@ func (q *querier) GetAuthorizationUserRoles(ctx context.Context, userID uuid.UUI
 	return q.db.GetAuthorizationUserRoles(ctx, userID)
 }
 
+func (q *querier) GetCustomRolesByName(ctx context.Context, lookupRoles []string) ([]database.CustomRole, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.GetCustomRolesByName(ctx, lookupRoles)
+}
+
 func (q *querier) GetDERPMeshKey(ctx context.Context) (string, error) {
 	if err := q.authorizeContext(ctx, rbac.ActionRead, rbac.ResourceSystem); err != nil {
 		return "", err
@@ -287,6 +301,27 @@ func (q *querier) DeleteOldWorkspaceAgentStartupLogs(ctx context.Context) error
 	return q.db.DeleteOldWorkspaceAgentStartupLogs(ctx)
 }
 
+func (q *querier) DeleteOldAuditLogs(ctx context.Context, arg database.DeleteOldAuditLogsParams) ([]database.AuditLog, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.DeleteOldAuditLogs(ctx, arg)
+}
+
+func (q *querier) DeleteOldProvisionerJobLogs(ctx context.Context, arg database.DeleteOldProvisionerJobLogsParams) ([]database.ProvisionerJobLog, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionDelete, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.DeleteOldProvisionerJobLogs(ctx, arg)
+}
+
+func (q *querier) ClearOldWorkspaceBuildProvisionerState(ctx context.Context, arg database.ClearOldWorkspaceBuildProvisionerStateParams) ([]database.ClearOldWorkspaceBuildProvisionerStateRow, error) {
+	if err := q.authorizeContext(ctx, rbac.ActionUpdate, rbac.ResourceSystem); err != nil {
+		return nil, err
+	}
+	return q.db.ClearOldWorkspaceBuildProvisionerState(ctx, arg)
+}
+
 func (q *querier) GetDeploymentWorkspaceAgentStats(ctx context.Context, createdAfter time.Time) (database.GetDeploymentWorkspaceAgentStatsRow, error) {
 	return q.db.GetDeploymentWorkspaceAgentStats(ctx, createdAfter)
 }
@@ -381,6 +416,15 @@ func (q *querier) InsertWorkspaceAgentStartupLogs(ctx context.Context, arg datab
 	return q.db.InsertWorkspaceAgentStartupLogs(ctx, arg)
 }
 
+func (q *querier) InsertWorkspaceAgentMetadata(ctx context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	// We don't check for workspace ownership here since the agent metadata may
+	// be associated with an orphaned agent used by a dry run build.
+	if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
+		return err
+	}
+	return q.db.InsertWorkspaceAgentMetadata(ctx, arg)
+}
+
 // TODO: We need to create a ProvisionerDaemon resource type
 func (q *querier) InsertProvisionerDaemon(ctx context.Context, arg database.InsertProvisionerDaemonParams) (database.ProvisionerDaemon, error) {
 	// if err := q.authorizeContext(ctx, rbac.ActionCreate, rbac.ResourceSystem); err != nil {
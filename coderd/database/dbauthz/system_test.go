@@ -40,6 +40,9 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 		dbgen.WorkspaceBuild(s.T(), db, database.WorkspaceBuild{})
 		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionRead)
 	}))
+	s.Run("GetWorkspacesEligibleForTransition", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
 	s.Run("GetWorkspaceAgentByAuthToken", s.Subtest(func(db database.Store, check *expects) {
 		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{})
 		check.Args(agt.AuthToken).Asserts(rbac.ResourceSystem, rbac.ActionRead).Returns(agt)
@@ -133,6 +136,18 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 	s.Run("DeleteOldWorkspaceAgentStats", s.Subtest(func(db database.Store, check *expects) {
 		check.Args().Asserts(rbac.ResourceSystem, rbac.ActionDelete)
 	}))
+	s.Run("GetCustomRolesByName", s.Subtest(func(db database.Store, check *expects) {
+		check.Args([]string{"custom"}).Asserts(rbac.ResourceSystem, rbac.ActionRead)
+	}))
+	s.Run("DeleteOldAuditLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteOldAuditLogsParams{Before: time.Now(), LimitCount: 10}).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("DeleteOldProvisionerJobLogs", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.DeleteOldProvisionerJobLogsParams{Before: time.Now(), LimitCount: 10}).Asserts(rbac.ResourceSystem, rbac.ActionDelete)
+	}))
+	s.Run("ClearOldWorkspaceBuildProvisionerState", s.Subtest(func(db database.Store, check *expects) {
+		check.Args(database.ClearOldWorkspaceBuildProvisionerStateParams{Before: time.Now(), LimitCount: 10}).Asserts(rbac.ResourceSystem, rbac.ActionUpdate)
+	}))
 	s.Run("GetParameterSchemasCreatedAfter", s.Subtest(func(db database.Store, check *expects) {
 		_ = dbgen.ParameterSchema(s.T(), db, database.ParameterSchema{CreatedAt: time.Now().Add(-time.Hour)})
 		check.Args(time.Now()).Asserts(rbac.ResourceSystem, rbac.ActionRead)
@@ -269,6 +284,13 @@ func (s *MethodTestSuite) TestSystemFunctions() {
 			JobID: j.ID,
 		}).Asserts( /*rbac.ResourceSystem, rbac.ActionCreate*/ )
 	}))
+	s.Run("InsertWorkspaceAgentMetadata", s.Subtest(func(db database.Store, check *expects) {
+		agt := dbgen.WorkspaceAgent(s.T(), db, database.WorkspaceAgent{})
+		check.Args(database.InsertWorkspaceAgentMetadataParams{
+			WorkspaceAgentID: agt.ID,
+			Key:              "test",
+		}).Asserts(rbac.ResourceSystem, rbac.ActionCreate)
+	}))
 	s.Run("InsertProvisionerDaemon", s.Subtest(func(db database.Store, check *expects) {
 		// TODO: we need to create a ProvisionerDaemon resource
 		check.Args(database.InsertProvisionerDaemonParams{
@@ -106,7 +106,9 @@ type data struct {
 
 	// New tables
 	workspaceAgentStats       []database.WorkspaceAgentStat
+	apiKeyScopeResources      []database.APIKeyScopeResource
 	auditLogs                 []database.AuditLog
+	customRoles               []database.CustomRole
 	files                     []database.File
 	gitAuthLinks              []database.GitAuthLink
 	gitSSHKey                 []database.GitSSHKey
@@ -123,25 +125,30 @@ type data struct {
 	templateVersionParameters []database.TemplateVersionParameter
 	templateVersionVariables  []database.TemplateVersionVariable
 	templates                 []database.Template
+	terminalRecordings        []database.TerminalRecording
 	workspaceAgents           []database.WorkspaceAgent
 	workspaceAgentLogs        []database.WorkspaceAgentStartupLog
+	workspaceAgentMetadata    []database.WorkspaceAgentMetadatum
+	workspaceAgentPortShares  []database.WorkspaceAgentPortShare
 	workspaceApps             []database.WorkspaceApp
 	workspaceBuilds           []database.WorkspaceBuild
 	workspaceBuildParameters  []database.WorkspaceBuildParameter
+	workspaceProxies          []database.WorkspaceProxy
 	workspaceResourceMetadata []database.WorkspaceResourceMetadatum
 	workspaceResources        []database.WorkspaceResource
 	workspaces                []database.Workspace
 
 	// Locks is a map of lock names. Any keys within the map are currently
 	// locked.
-	locks           map[int64]struct{}
-	deploymentID    string
-	derpMeshKey     string
-	lastUpdateCheck []byte
-	serviceBanner   []byte
-	logoURL         string
-	appSigningKey   string
-	lastLicenseID   int32
+	locks            map[int64]struct{}
+	deploymentID     string
+	derpMeshKey      string
+	lastUpdateCheck  []byte
+	serviceBanner    []byte
+	logoURL          string
+	appSigningKey    string
+	auditFilterRules []byte
+	lastLicenseID    int32
 }
 
 func validateDatabaseTypeWithValid(v reflect.Value) (handled bool, err error) {
@@ -599,6 +606,36 @@ func (q *fakeQuerier) GetAPIKeysLastUsedAfter(_ context.Context, after time.Time
 	return apiKeys, nil
 }
 
+func (q *fakeQuerier) GetAPIKeyScopeResourcesByAPIKeyIDs(_ context.Context, ids []string) ([]database.APIKeyScopeResource, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	resources := make([]database.APIKeyScopeResource, 0)
+	for _, resource := range q.apiKeyScopeResources {
+		if !slices.Contains(ids, resource.APIKeyID) {
+			continue
+		}
+		// Emulate the cascading delete of the API key.
+		if !slices.ContainsFunc(q.apiKeys, func(key database.APIKey) bool { return key.ID == resource.APIKeyID }) {
+			continue
+		}
+		resources = append(resources, resource)
+	}
+	slices.SortFunc(resources, func(a, b database.APIKeyScopeResource) bool {
+		if a.APIKeyID != b.APIKeyID {
+			return a.APIKeyID < b.APIKeyID
+		}
+		if a.ResourceType != b.ResourceType {
+			return a.ResourceType < b.ResourceType
+		}
+		if a.ResourceID != b.ResourceID {
+			return a.ResourceID.String() < b.ResourceID.String()
+		}
+		return a.Action < b.Action
+	})
+	return resources, nil
+}
+
 func (q *fakeQuerier) GetAPIKeysByLoginType(_ context.Context, t database.LoginType) ([]database.APIKey, error) {
 	if err := validateDatabaseType(t); err != nil {
 		return nil, err
@@ -1027,6 +1064,88 @@ func (q *fakeQuerier) GetAuthorizationUserRoles(_ context.Context, userID uuid.U
 	}, nil
 }
 
+func (q *fakeQuerier) GetCustomRoles(_ context.Context, organizationID uuid.NullUUID) ([]database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	roles := make([]database.CustomRole, 0)
+	for _, role := range q.customRoles {
+		if role.OrganizationID == organizationID {
+			roles = append(roles, role)
+		}
+	}
+	sort.Slice(roles, func(i, j int) bool { return roles[i].Name < roles[j].Name })
+	return roles, nil
+}
+
+func (q *fakeQuerier) GetCustomRolesByName(_ context.Context, lookupRoles []string) ([]database.CustomRole, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	roles := make([]database.CustomRole, 0)
+	for _, role := range q.customRoles {
+		name := role.Name
+		if role.OrganizationID.Valid {
+			name += ":" + role.OrganizationID.UUID.String()
+		}
+		if slices.Contains(lookupRoles, name) {
+			roles = append(roles, role)
+		}
+	}
+	return roles, nil
+}
+
+func (q *fakeQuerier) UpsertCustomRole(_ context.Context, arg database.UpsertCustomRoleParams) (database.CustomRole, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.CustomRole{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	now := database.Now()
+	for i, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			role.DisplayName = arg.DisplayName
+			role.SitePermissions = arg.SitePermissions
+			role.OrgPermissions = arg.OrgPermissions
+			role.UserPermissions = arg.UserPermissions
+			role.UpdatedAt = now
+			q.customRoles[i] = role
+			return role, nil
+		}
+	}
+	role := database.CustomRole{
+		Name:            arg.Name,
+		DisplayName:     arg.DisplayName,
+		OrganizationID:  arg.OrganizationID,
+		SitePermissions: arg.SitePermissions,
+		OrgPermissions:  arg.OrgPermissions,
+		UserPermissions: arg.UserPermissions,
+		CreatedAt:       now,
+		UpdatedAt:       now,
+	}
+	q.customRoles = append(q.customRoles, role)
+	return role, nil
+}
+
+func (q *fakeQuerier) DeleteCustomRole(_ context.Context, arg database.DeleteCustomRoleParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, role := range q.customRoles {
+		if role.Name == arg.Name && role.OrganizationID == arg.OrganizationID {
+			q.customRoles = append(q.customRoles[:i], q.customRoles[i+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *fakeQuerier) GetWorkspaces(ctx context.Context, arg database.GetWorkspacesParams) ([]database.GetWorkspacesRow, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return nil, err
@@ -1037,6 +1156,51 @@ func (q *fakeQuerier) GetWorkspaces(ctx context.Context, arg database.GetWorkspa
 	return workspaceRows, err
 }
 
+func (q *fakeQuerier) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]database.Workspace, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	workspaces := []database.Workspace{}
+	for _, workspace := range q.workspaces {
+		if workspace.Deleted {
+			continue
+		}
+		build, err := q.getLatestWorkspaceBuildByWorkspaceIDNoLock(ctx, workspace.ID)
+		if err != nil {
+			return nil, xerrors.Errorf("get latest build: %w", err)
+		}
+		job, err := q.getProvisionerJobByIDNoLock(ctx, build.JobID)
+		if err != nil {
+			return nil, xerrors.Errorf("get provisioner job: %w", err)
+		}
+		template, err := q.getTemplateByIDNoLock(ctx, workspace.TemplateID)
+		if err != nil {
+			return nil, xerrors.Errorf("get template: %w", err)
+		}
+
+		switch {
+		case build.Transition == database.WorkspaceTransitionStart &&
+			!build.Deadline.IsZero() &&
+			build.Deadline.Before(now):
+		case build.Transition == database.WorkspaceTransitionStop &&
+			workspace.AutostartSchedule.Valid &&
+			workspace.AutostartSchedule.String != "":
+		case template.InactivityTTL > 0 && !workspace.LockedAt.Valid:
+		case template.LockedTTL > 0 && workspace.LockedAt.Valid:
+		case template.FailureTTL > 0 &&
+			build.Transition == database.WorkspaceTransitionStart &&
+			job.CompletedAt.Valid &&
+			job.Error.Valid &&
+			job.Error.String != "":
+		default:
+			continue
+		}
+		workspaces = append(workspaces, workspace)
+	}
+
+	return workspaces, nil
+}
+
 //nolint:gocyclo
 func (q *fakeQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg database.GetWorkspacesParams, prepared rbac.PreparedAuthorized) ([]database.GetWorkspacesRow, error) {
 	if err := validateDatabaseType(arg); err != nil {
@@ -1209,6 +1373,10 @@ func (q *fakeQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg database.
 			}
 		}
 
+		if arg.Locked != "" && workspace.LockedAt.Valid != (arg.Locked == "true") {
+			continue
+		}
+
 		if len(arg.TemplateIds) > 0 {
 			match := false
 			for _, id := range arg.TemplateIds {
@@ -1296,6 +1464,7 @@ func convertToWorkspaceRows(workspaces []database.Workspace, count int64) []data
 			AutostartSchedule: w.AutostartSchedule,
 			Ttl:               w.Ttl,
 			LastUsedAt:        w.LastUsedAt,
+			LockedAt:          w.LockedAt,
 			Count:             count,
 		}
 	}
@@ -1809,6 +1978,8 @@ func (q *fakeQuerier) UpdateTemplateMetaByID(_ context.Context, arg database.Upd
 		tpl.DisplayName = arg.DisplayName
 		tpl.Description = arg.Description
 		tpl.Icon = arg.Icon
+		tpl.RecordTerminalSessions = arg.RecordTerminalSessions
+		tpl.MaxPortSharingLevel = arg.MaxPortSharingLevel
 		q.templates[idx] = tpl
 		return tpl.DeepCopy(), nil
 	}
@@ -1831,6 +2002,9 @@ func (q *fakeQuerier) UpdateTemplateScheduleByID(_ context.Context, arg database
 		tpl.UpdatedAt = database.Now()
 		tpl.DefaultTTL = arg.DefaultTTL
 		tpl.MaxTTL = arg.MaxTTL
+		tpl.InactivityTTL = arg.InactivityTTL
+		tpl.LockedTTL = arg.LockedTTL
+		tpl.FailureTTL = arg.FailureTTL
 		q.templates[idx] = tpl
 		return tpl.DeepCopy(), nil
 	}
@@ -1948,6 +2122,16 @@ func (q *fakeQuerier) GetTemplateVersionsByTemplateID(_ context.Context, arg dat
 		}
 	}
 
+	if !arg.IncludeArchived {
+		unarchived := make([]database.TemplateVersion, 0, len(version))
+		for _, v := range version {
+			if !v.Archived {
+				unarchived = append(unarchived, v)
+			}
+		}
+		version = unarchived
+	}
+
 	if arg.OffsetOpt > 0 {
 		if int(arg.OffsetOpt) > len(version)-1 {
 			return nil, sql.ErrNoRows
@@ -2668,6 +2852,34 @@ func (q *fakeQuerier) InsertAPIKey(_ context.Context, arg database.InsertAPIKeyP
 	return key, nil
 }
 
+func (q *fakeQuerier) InsertAPIKeyScopeResources(_ context.Context, arg database.InsertAPIKeyScopeResourcesParams) ([]database.APIKeyScopeResource, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	if len(arg.ResourceType) != len(arg.ResourceID) || len(arg.ResourceType) != len(arg.Action) {
+		return nil, xerrors.New("resource types, IDs and actions must be the same length")
+	}
+	resources := make([]database.APIKeyScopeResource, 0, len(arg.ResourceType))
+	for i := range arg.ResourceType {
+		resource := database.APIKeyScopeResource{
+			APIKeyID:     arg.APIKeyID,
+			ResourceType: arg.ResourceType[i],
+			ResourceID:   arg.ResourceID[i],
+			Action:       arg.Action[i],
+		}
+		if slices.Contains(q.apiKeyScopeResources, resource) {
+			return nil, errDuplicateKey
+		}
+		q.apiKeyScopeResources = append(q.apiKeyScopeResources, resource)
+		resources = append(resources, resource)
+	}
+	return resources, nil
+}
+
 func (q *fakeQuerier) InsertFile(_ context.Context, arg database.InsertFileParams) (database.File, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.File{}, err
@@ -2775,6 +2987,7 @@ func (q *fakeQuerier) InsertTemplate(_ context.Context, arg database.InsertTempl
 		DisplayName:                  arg.DisplayName,
 		Icon:                         arg.Icon,
 		AllowUserCancelWorkspaceJobs: arg.AllowUserCancelWorkspaceJobs,
+		MaxPortSharingLevel:          database.AppSharingLevelOwner,
 	}
 	q.templates = append(q.templates, template)
 	return template.DeepCopy(), nil
@@ -2799,6 +3012,7 @@ func (q *fakeQuerier) InsertTemplateVersion(_ context.Context, arg database.Inse
 		Readme:         arg.Readme,
 		JobID:          arg.JobID,
 		CreatedBy:      arg.CreatedBy,
+		GitCommitSHA:   arg.GitCommitSHA,
 	}
 	q.templateVersions = append(q.templateVersions, version)
 	return version, nil
@@ -2829,6 +3043,7 @@ func (q *fakeQuerier) InsertTemplateVersionParameter(_ context.Context, arg data
 		ValidationMonotonic: arg.ValidationMonotonic,
 		Required:            arg.Required,
 		LegacyVariableName:  arg.LegacyVariableName,
+		Ephemeral:           arg.Ephemeral,
 	}
 	q.templateVersionParameters = append(q.templateVersionParameters, param)
 	return param, nil
@@ -3433,6 +3648,26 @@ func (q *fakeQuerier) UpdateTemplateVersionByID(_ context.Context, arg database.
 	return database.TemplateVersion{}, sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateTemplateVersionArchivedByID(_ context.Context, arg database.UpdateTemplateVersionArchivedByIDParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, templateVersion := range q.templateVersions {
+		if templateVersion.ID != arg.ID {
+			continue
+		}
+		templateVersion.Archived = arg.Archived
+		templateVersion.UpdatedAt = arg.UpdatedAt
+		q.templateVersions[index] = templateVersion
+		return nil
+	}
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) UpdateTemplateVersionDescriptionByJobID(_ context.Context, arg database.UpdateTemplateVersionDescriptionByJobIDParams) error {
 	if err := validateDatabaseType(arg); err != nil {
 		return err
@@ -3516,6 +3751,144 @@ func (q *fakeQuerier) UpdateWorkspaceAgentStartupByID(_ context.Context, arg dat
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) InsertWorkspaceAgentMetadata(_ context.Context, arg database.InsertWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	metadatum := database.WorkspaceAgentMetadatum{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		Script:           arg.Script,
+		DisplayName:      arg.DisplayName,
+		Key:              arg.Key,
+		Timeout:          arg.Timeout,
+		Interval:         arg.Interval,
+	}
+
+	q.workspaceAgentMetadata = append(q.workspaceAgentMetadata, metadatum)
+	return nil
+}
+
+func (q *fakeQuerier) UpdateWorkspaceAgentMetadata(_ context.Context, arg database.UpdateWorkspaceAgentMetadataParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	//nolint:gosimple
+	updated := database.WorkspaceAgentMetadatum{
+		WorkspaceAgentID: arg.WorkspaceAgentID,
+		Key:              arg.Key,
+		Value:            arg.Value,
+		Error:            arg.Error,
+		CollectedAt:      arg.CollectedAt,
+	}
+
+	for i, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == arg.WorkspaceAgentID && m.Key == arg.Key {
+			updated.DisplayName = m.DisplayName
+			updated.Script = m.Script
+			updated.Timeout = m.Timeout
+			updated.Interval = m.Interval
+			q.workspaceAgentMetadata[i] = updated
+			return nil
+		}
+	}
+
+	return nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentMetadata(_ context.Context, workspaceAgentID uuid.UUID) ([]database.WorkspaceAgentMetadatum, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	metadata := make([]database.WorkspaceAgentMetadatum, 0)
+	for _, m := range q.workspaceAgentMetadata {
+		if m.WorkspaceAgentID == workspaceAgentID {
+			metadata = append(metadata, m)
+		}
+	}
+	return metadata, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortShare(_ context.Context, arg database.GetWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port {
+			return share, nil
+		}
+	}
+	return database.WorkspaceAgentPortShare{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceAgentPortSharesByWorkspaceID(_ context.Context, workspaceID uuid.UUID) ([]database.WorkspaceAgentPortShare, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	shares := make([]database.WorkspaceAgentPortShare, 0)
+	for _, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == workspaceID {
+			shares = append(shares, share)
+		}
+	}
+	sort.Slice(shares, func(i, j int) bool {
+		if shares[i].AgentName != shares[j].AgentName {
+			return shares[i].AgentName < shares[j].AgentName
+		}
+		return shares[i].Port < shares[j].Port
+	})
+	return shares, nil
+}
+
+func (q *fakeQuerier) UpsertWorkspaceAgentPortShare(_ context.Context, arg database.UpsertWorkspaceAgentPortShareParams) (database.WorkspaceAgentPortShare, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.WorkspaceAgentPortShare{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for idx, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port {
+			share.ShareLevel = arg.ShareLevel
+			share.UpdatedAt = arg.CreatedAt
+			q.workspaceAgentPortShares[idx] = share
+			return share, nil
+		}
+	}
+	share := database.WorkspaceAgentPortShare{
+		WorkspaceID: arg.WorkspaceID,
+		AgentName:   arg.AgentName,
+		Port:        arg.Port,
+		ShareLevel:  arg.ShareLevel,
+		CreatedAt:   arg.CreatedAt,
+		UpdatedAt:   arg.CreatedAt,
+	}
+	q.workspaceAgentPortShares = append(q.workspaceAgentPortShares, share)
+	return share, nil
+}
+
+func (q *fakeQuerier) DeleteWorkspaceAgentPortShare(_ context.Context, arg database.DeleteWorkspaceAgentPortShareParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for idx, share := range q.workspaceAgentPortShares {
+		if share.WorkspaceID == arg.WorkspaceID && share.AgentName == arg.AgentName && share.Port == arg.Port {
+			q.workspaceAgentPortShares = append(q.workspaceAgentPortShares[:idx], q.workspaceAgentPortShares[idx+1:]...)
+			return nil
+		}
+	}
+	return nil
+}
+
 func (q *fakeQuerier) GetWorkspaceAgentStartupLogsAfter(_ context.Context, arg database.GetWorkspaceAgentStartupLogsAfterParams) ([]database.WorkspaceAgentStartupLog, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return nil, err
@@ -3731,6 +4104,26 @@ func (q *fakeQuerier) UpdateWorkspaceLastUsedAt(_ context.Context, arg database.
 	return sql.ErrNoRows
 }
 
+func (q *fakeQuerier) UpdateWorkspaceLockedAt(_ context.Context, arg database.UpdateWorkspaceLockedAtParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for index, workspace := range q.workspaces {
+		if workspace.ID != arg.ID {
+			continue
+		}
+		workspace.LockedAt = arg.LockedAt
+		q.workspaces[index] = workspace
+		return nil
+	}
+
+	return sql.ErrNoRows
+}
+
 func (q *fakeQuerier) GetDeploymentWorkspaceStats(ctx context.Context) (database.GetDeploymentWorkspaceStatsRow, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -4117,10 +4510,6 @@ func (q *fakeQuerier) GetAuditLogsOffset(_ context.Context, arg database.GetAudi
 
 	// q.auditLogs are already sorted by time DESC, so no need to sort after the fact.
 	for _, alog := range q.auditLogs {
-		if arg.Offset > 0 {
-			arg.Offset--
-			continue
-		}
 		if arg.Action != "" && !strings.Contains(string(alog.Action), arg.Action) {
 			continue
 		}
@@ -4159,12 +4548,19 @@ func (q *fakeQuerier) GetAuditLogsOffset(_ context.Context, arg database.GetAudi
 			}
 		}
 
+		// The offset applies to filtered audit logs, as it does in SQL.
+		if arg.Offset > 0 {
+			arg.Offset--
+			continue
+		}
+
 		user, err := q.getUserByIDNoLock(alog.UserID)
 		userValid := err == nil
 
 		logs = append(logs, database.GetAuditLogsOffsetRow{
 			ID:               alog.ID,
 			RequestID:        alog.RequestID,
+			Time:             alog.Time,
 			OrganizationID:   alog.OrganizationID,
 			Ip:               alog.Ip,
 			UserAgent:        alog.UserAgent,
@@ -4302,6 +4698,25 @@ func (q *fakeQuerier) GetLogoURL(_ context.Context) (string, error) {
 	return q.logoURL, nil
 }
 
+func (q *fakeQuerier) InsertOrUpdateAuditFilterRules(_ context.Context, data string) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	q.auditFilterRules = []byte(data)
+	return nil
+}
+
+func (q *fakeQuerier) GetAuditFilterRules(_ context.Context) (string, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	if q.auditFilterRules == nil {
+		return "", sql.ErrNoRows
+	}
+
+	return string(q.auditFilterRules), nil
+}
+
 func (q *fakeQuerier) GetAppSigningKey(_ context.Context) (string, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -4393,6 +4808,111 @@ func (*fakeQuerier) DeleteOldWorkspaceAgentStartupLogs(_ context.Context) error
 	return nil
 }
 
+func (q *fakeQuerier) DeleteOldAuditLogs(_ context.Context, arg database.DeleteOldAuditLogsParams) ([]database.AuditLog, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	old := make([]database.AuditLog, 0)
+	for _, alog := range q.auditLogs {
+		if alog.Time.Before(arg.Before) {
+			old = append(old, alog)
+		}
+	}
+	sort.Slice(old, func(i, j int) bool { return old[i].Time.Before(old[j].Time) })
+	if len(old) > int(arg.LimitCount) {
+		old = old[:arg.LimitCount]
+	}
+	deleted := make(map[uuid.UUID]struct{}, len(old))
+	for _, alog := range old {
+		deleted[alog.ID] = struct{}{}
+	}
+	kept := make([]database.AuditLog, 0, len(q.auditLogs)-len(old))
+	for _, alog := range q.auditLogs {
+		if _, ok := deleted[alog.ID]; !ok {
+			kept = append(kept, alog)
+		}
+	}
+	q.auditLogs = kept
+	return old, nil
+}
+
+func (q *fakeQuerier) DeleteOldProvisionerJobLogs(_ context.Context, arg database.DeleteOldProvisionerJobLogsParams) ([]database.ProvisionerJobLog, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	old := make([]database.ProvisionerJobLog, 0)
+	for _, log := range q.provisionerJobLogs {
+		if log.CreatedAt.Before(arg.Before) {
+			old = append(old, log)
+		}
+	}
+	sort.Slice(old, func(i, j int) bool { return old[i].ID < old[j].ID })
+	if len(old) > int(arg.LimitCount) {
+		old = old[:arg.LimitCount]
+	}
+	deleted := make(map[int64]struct{}, len(old))
+	for _, log := range old {
+		deleted[log.ID] = struct{}{}
+	}
+	kept := make([]database.ProvisionerJobLog, 0, len(q.provisionerJobLogs)-len(old))
+	for _, log := range q.provisionerJobLogs {
+		if _, ok := deleted[log.ID]; !ok {
+			kept = append(kept, log)
+		}
+	}
+	q.provisionerJobLogs = kept
+	return old, nil
+}
+
+func (q *fakeQuerier) ClearOldWorkspaceBuildProvisionerState(_ context.Context, arg database.ClearOldWorkspaceBuildProvisionerStateParams) ([]database.ClearOldWorkspaceBuildProvisionerStateRow, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	latest := make(map[uuid.UUID]int32)
+	for _, build := range q.workspaceBuilds {
+		if build.BuildNumber > latest[build.WorkspaceID] {
+			latest[build.WorkspaceID] = build.BuildNumber
+		}
+	}
+	indexes := make([]int, 0)
+	for i, build := range q.workspaceBuilds {
+		if build.CreatedAt.Before(arg.Before) && build.ProvisionerState != nil && build.BuildNumber < latest[build.WorkspaceID] {
+			indexes = append(indexes, i)
+		}
+	}
+	sort.Slice(indexes, func(i, j int) bool {
+		return q.workspaceBuilds[indexes[i]].CreatedAt.Before(q.workspaceBuilds[indexes[j]].CreatedAt)
+	})
+	if len(indexes) > int(arg.LimitCount) {
+		indexes = indexes[:arg.LimitCount]
+	}
+	rows := make([]database.ClearOldWorkspaceBuildProvisionerStateRow, 0, len(indexes))
+	for _, i := range indexes {
+		build := q.workspaceBuilds[i]
+		rows = append(rows, database.ClearOldWorkspaceBuildProvisionerStateRow{
+			ID:               build.ID,
+			WorkspaceID:      build.WorkspaceID,
+			BuildNumber:      build.BuildNumber,
+			ProvisionerState: build.ProvisionerState,
+		})
+		build.ProvisionerState = nil
+		q.workspaceBuilds[i] = build
+	}
+	return rows, nil
+}
+
 func (q *fakeQuerier) GetUserLinkByLinkedID(_ context.Context, id string) (database.UserLink, error) {
 	q.mutex.RLock()
 	defer q.mutex.RUnlock()
@@ -4679,6 +5199,101 @@ func (q *fakeQuerier) GetReplicasUpdatedAfter(_ context.Context, updatedAt time.
 	return replicas, nil
 }
 
+func (q *fakeQuerier) InsertWorkspaceProxy(_ context.Context, arg database.InsertWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for _, p := range q.workspaceProxies {
+		if !p.Deleted && strings.EqualFold(p.Name, arg.Name) {
+			return database.WorkspaceProxy{}, errDuplicateKey
+		}
+	}
+
+	p := database.WorkspaceProxy{
+		ID:                arg.ID,
+		Name:              arg.Name,
+		DisplayName:       arg.DisplayName,
+		Icon:              arg.Icon,
+		TokenHashedSecret: arg.TokenHashedSecret,
+		CreatedAt:         arg.CreatedAt,
+		UpdatedAt:         arg.UpdatedAt,
+		Deleted:           false,
+	}
+	q.workspaceProxies = append(q.workspaceProxies, p)
+	return p, nil
+}
+
+func (q *fakeQuerier) RegisterWorkspaceProxy(_ context.Context, arg database.RegisterWorkspaceProxyParams) (database.WorkspaceProxy, error) {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.workspaceProxies {
+		if p.ID == arg.ID {
+			p.Url = arg.Url
+			p.WildcardHostname = arg.WildcardHostname
+			p.UpdatedAt = database.Now()
+			q.workspaceProxies[i] = p
+			return p, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) UpdateWorkspaceProxyDeleted(_ context.Context, arg database.UpdateWorkspaceProxyDeletedParams) error {
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	for i, p := range q.workspaceProxies {
+		if p.ID == arg.ID {
+			p.Deleted = arg.Deleted
+			p.UpdatedAt = database.Now()
+			q.workspaceProxies[i] = p
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceProxies(_ context.Context) ([]database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	cpy := make([]database.WorkspaceProxy, 0, len(q.workspaceProxies))
+	for _, p := range q.workspaceProxies {
+		if !p.Deleted {
+			cpy = append(cpy, p)
+		}
+	}
+	return cpy, nil
+}
+
+func (q *fakeQuerier) GetWorkspaceProxyByID(_ context.Context, id uuid.UUID) (database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, proxy := range q.workspaceProxies {
+		if proxy.ID == id {
+			return proxy, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetWorkspaceProxyByName(_ context.Context, name string) (database.WorkspaceProxy, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, proxy := range q.workspaceProxies {
+		if proxy.Deleted {
+			continue
+		}
+		if strings.EqualFold(proxy.Name, name) {
+			return proxy, nil
+		}
+	}
+	return database.WorkspaceProxy{}, sql.ErrNoRows
+}
+
 func (q *fakeQuerier) GetGitAuthLink(_ context.Context, arg database.GetGitAuthLinkParams) (database.GitAuthLink, error) {
 	if err := validateDatabaseType(arg); err != nil {
 		return database.GitAuthLink{}, err
@@ -4820,3 +5435,113 @@ func (q *fakeQuerier) UpdateWorkspaceAgentStartupLogOverflowByID(_ context.Conte
 	}
 	return sql.ErrNoRows
 }
+
+func (q *fakeQuerier) UpdateWorkspaceAgentNetcheckByID(_ context.Context, arg database.UpdateWorkspaceAgentNetcheckByIDParams) error {
+	if err := validateDatabaseType(arg); err != nil {
+		return err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+	for i, agent := range q.workspaceAgents {
+		if agent.ID == arg.ID {
+			agent.Netcheck = arg.Netcheck
+			q.workspaceAgents[i] = agent
+			return nil
+		}
+	}
+	return sql.ErrNoRows
+}
+
+func (q *fakeQuerier) InsertTerminalRecording(_ context.Context, arg database.InsertTerminalRecordingParams) (database.TerminalRecording, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return database.TerminalRecording{}, err
+	}
+
+	q.mutex.Lock()
+	defer q.mutex.Unlock()
+
+	recording := database.TerminalRecording{
+		ID:                   arg.ID,
+		CreatedAt:            arg.CreatedAt,
+		StartedAt:            arg.StartedAt,
+		EndedAt:              arg.EndedAt,
+		OrganizationID:       arg.OrganizationID,
+		WorkspaceID:          arg.WorkspaceID,
+		AgentID:              arg.AgentID,
+		UserID:               arg.UserID,
+		ClientReportedUserID: arg.ClientReportedUserID,
+		Type:                 arg.Type,
+		Size:                 arg.Size,
+		Data:                 slices.Clone(arg.Data),
+	}
+	q.terminalRecordings = append(q.terminalRecordings, recording)
+	return recording, nil
+}
+
+func (q *fakeQuerier) GetTerminalRecordingByID(_ context.Context, id uuid.UUID) (database.TerminalRecording, error) {
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	for _, recording := range q.terminalRecordings {
+		if recording.ID == id {
+			return recording, nil
+		}
+	}
+	return database.TerminalRecording{}, sql.ErrNoRows
+}
+
+func (q *fakeQuerier) GetTerminalRecordings(_ context.Context, arg database.GetTerminalRecordingsParams) ([]database.GetTerminalRecordingsRow, error) {
+	if err := validateDatabaseType(arg); err != nil {
+		return nil, err
+	}
+
+	q.mutex.RLock()
+	defer q.mutex.RUnlock()
+
+	rows := make([]database.GetTerminalRecordingsRow, 0)
+	for _, recording := range q.terminalRecordings {
+		if arg.WorkspaceID != uuid.Nil && recording.WorkspaceID != arg.WorkspaceID {
+			continue
+		}
+		if arg.AgentID != uuid.Nil && recording.AgentID != arg.AgentID {
+			continue
+		}
+		if arg.UserID != uuid.Nil && recording.UserID != arg.UserID {
+			continue
+		}
+		rows = append(rows, database.GetTerminalRecordingsRow{
+			ID:                   recording.ID,
+			CreatedAt:            recording.CreatedAt,
+			StartedAt:            recording.StartedAt,
+			EndedAt:              recording.EndedAt,
+			OrganizationID:       recording.OrganizationID,
+			WorkspaceID:          recording.WorkspaceID,
+			AgentID:              recording.AgentID,
+			UserID:               recording.UserID,
+			ClientReportedUserID: recording.ClientReportedUserID,
+			Type:                 recording.Type,
+			Size:                 recording.Size,
+		})
+	}
+	slices.SortFunc(rows, func(a, b database.GetTerminalRecordingsRow) bool {
+		if a.StartedAt.Equal(b.StartedAt) {
+			return a.ID.String() < b.ID.String()
+		}
+		return a.StartedAt.After(b.StartedAt)
+	})
+
+	if arg.OffsetOpt > 0 {
+		if int(arg.OffsetOpt) > len(rows) {
+			return nil, nil
+		}
+		rows = rows[arg.OffsetOpt:]
+	}
+	if arg.LimitOpt > 0 && int(arg.LimitOpt) < len(rows) {
+		rows = rows[:arg.LimitOpt]
+	}
+	if len(rows) == 0 {
+		return nil, nil
+	}
+	return rows, nil
+}
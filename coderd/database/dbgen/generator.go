@@ -137,6 +137,18 @@ func WorkspaceAgent(t testing.TB, db database.Store, orig database.WorkspaceAgen
 	return workspace
 }
 
+func WorkspaceAgentPortShare(t testing.TB, db database.Store, orig database.WorkspaceAgentPortShare) database.WorkspaceAgentPortShare {
+	share, err := db.UpsertWorkspaceAgentPortShare(context.Background(), database.UpsertWorkspaceAgentPortShareParams{
+		WorkspaceID: takeFirst(orig.WorkspaceID, uuid.New()),
+		AgentName:   takeFirst(orig.AgentName, "main"),
+		Port:        takeFirst(orig.Port, 8080),
+		ShareLevel:  takeFirst(orig.ShareLevel, database.AppSharingLevelAuthenticated),
+		CreatedAt:   takeFirst(orig.CreatedAt, database.Now()),
+	})
+	require.NoError(t, err, "insert workspace agent port share")
+	return share
+}
+
 func Workspace(t testing.TB, db database.Store, orig database.Workspace) database.Workspace {
 	workspace, err := db.InsertWorkspace(context.Background(), database.InsertWorkspaceParams{
 		ID:                takeFirst(orig.ID, uuid.New()),
@@ -339,6 +351,25 @@ func File(t testing.TB, db database.Store, orig database.File) database.File {
 	return file
 }
 
+func TerminalRecording(t testing.TB, db database.Store, orig database.TerminalRecording) database.TerminalRecording {
+	recording, err := db.InsertTerminalRecording(context.Background(), database.InsertTerminalRecordingParams{
+		ID:                   takeFirst(orig.ID, uuid.New()),
+		CreatedAt:            takeFirst(orig.CreatedAt, database.Now()),
+		StartedAt:            takeFirst(orig.StartedAt, database.Now().Add(-time.Minute)),
+		EndedAt:              takeFirst(orig.EndedAt, database.Now()),
+		OrganizationID:       takeFirst(orig.OrganizationID, uuid.New()),
+		WorkspaceID:          takeFirst(orig.WorkspaceID, uuid.New()),
+		AgentID:              takeFirst(orig.AgentID, uuid.New()),
+		UserID:               takeFirst(orig.UserID, uuid.New()),
+		ClientReportedUserID: orig.ClientReportedUserID,
+		Type:                 takeFirst(orig.Type, database.TerminalRecordingTypeSSH),
+		Size:                 takeFirst(orig.Size, int64(len(orig.Data))),
+		Data:                 takeFirstSlice(orig.Data, []byte{}),
+	})
+	require.NoError(t, err, "insert terminal recording")
+	return recording
+}
+
 func UserLink(t testing.TB, db database.Store, orig database.UserLink) database.UserLink {
 	link, err := db.InsertUserLink(context.Background(), database.InsertUserLinkParams{
 		UserID:            takeFirst(orig.UserID, uuid.New()),
@@ -379,6 +410,7 @@ func TemplateVersion(t testing.TB, db database.Store, orig database.TemplateVers
 		Readme:         takeFirst(orig.Readme, namesgenerator.GetRandomName(1)),
 		JobID:          takeFirst(orig.JobID, uuid.New()),
 		CreatedBy:      takeFirst(orig.CreatedBy, uuid.New()),
+		GitCommitSHA:   orig.GitCommitSHA,
 	})
 	require.NoError(t, err, "insert template version")
 	return version
@@ -465,3 +497,21 @@ func WorkspaceAgentStat(t testing.TB, db database.Store, orig database.Workspace
 	require.NoError(t, err, "insert workspace agent stat")
 	return scheme
 }
+
+func WorkspaceProxy(t testing.TB, db database.Store, orig database.WorkspaceProxy) (database.WorkspaceProxy, string) {
+	secret, err := cryptorand.HexString(64)
+	require.NoError(t, err, "generate secret")
+	hashedSecret := sha256.Sum256([]byte(secret))
+
+	resource, err := db.InsertWorkspaceProxy(context.Background(), database.InsertWorkspaceProxyParams{
+		ID:                takeFirst(orig.ID, uuid.New()),
+		Name:              takeFirst(orig.Name, namesgenerator.GetRandomName(1)),
+		DisplayName:       takeFirst(orig.DisplayName, namesgenerator.GetRandomName(1)),
+		Icon:              takeFirst(orig.Icon, namesgenerator.GetRandomName(1)),
+		TokenHashedSecret: hashedSecret[:],
+		CreatedAt:         takeFirst(orig.CreatedAt, database.Now()),
+		UpdatedAt:         takeFirst(orig.UpdatedAt, database.Now()),
+	})
+	require.NoError(t, err, "insert proxy")
+	return resource, fmt.Sprintf("%s:%s", resource.ID, secret)
+}
@@ -1,27 +1,82 @@
 package dbpurge
 
 import (
+	"bufio"
 	"context"
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
+	"os"
+	"path/filepath"
 	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
 	"golang.org/x/sync/errgroup"
+	"golang.org/x/xerrors"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 )
 
+const (
+	defaultInterval  = 24 * time.Hour
+	defaultBatchSize = 1000
+)
+
+// Options configures the retention of tables that grow without bound.
+// A zero retention disables purging for that table.
+type Options struct {
+	AuditLogRetention            time.Duration
+	ProvisionerJobLogRetention   time.Duration
+	WorkspaceBuildStateRetention time.Duration
+	// ArchiveDir is a directory that purged rows are appended to as
+	// newline-delimited JSON before they're deleted. Archiving is
+	// disabled if empty.
+	ArchiveDir string
+	// BatchSize is the maximum number of rows purged in a single
+	// transaction. Defaults to 1000.
+	BatchSize int32
+	// Interval is how often to purge. Defaults to 24 hours.
+	Interval time.Duration
+	// Registerer is used to register the purged rows counter.
+	Registerer prometheus.Registerer
+}
+
 // New creates a new periodically purging database instance.
 // It is the caller's responsibility to call Close on the returned instance.
 //
 // This is for cleaning up old, unused resources from the database that take up space.
-func New(ctx context.Context, logger slog.Logger, db database.Store) io.Closer {
+func New(ctx context.Context, logger slog.Logger, db database.Store, opts Options) io.Closer {
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = defaultBatchSize
+	}
+	if opts.Interval <= 0 {
+		opts.Interval = defaultInterval
+	}
+	if opts.Registerer == nil {
+		opts.Registerer = prometheus.NewRegistry()
+	}
+	p := &purger{
+		db:   db,
+		opts: opts,
+		rowsPurged: promauto.With(opts.Registerer).NewCounterVec(prometheus.CounterOpts{
+			Namespace: "coderd",
+			Subsystem: "dbpurge",
+			Name:      "rows_purged_total",
+			Help:      "The total number of rows purged from the database, by table.",
+		}, []string{"table"}),
+	}
+
 	closed := make(chan struct{})
 	ctx, cancelFunc := context.WithCancel(ctx)
+	//nolint:gocritic // The purger is a system process.
+	ctx = dbauthz.AsSystemRestricted(ctx)
 	go func() {
 		defer close(closed)
-		ticker := time.NewTicker(24 * time.Hour)
+		ticker := time.NewTicker(opts.Interval)
 		defer ticker.Stop()
 		for {
 			select {
@@ -37,6 +92,15 @@ func New(ctx context.Context, logger slog.Logger, db database.Store) io.Closer {
 			eg.Go(func() error {
 				return db.DeleteOldWorkspaceAgentStats(ctx)
 			})
+			eg.Go(func() error {
+				return p.purgeAuditLogs(ctx)
+			})
+			eg.Go(func() error {
+				return p.purgeProvisionerJobLogs(ctx)
+			})
+			eg.Go(func() error {
+				return p.purgeWorkspaceBuildState(ctx)
+			})
 			err := eg.Wait()
 			if err != nil {
 				if errors.Is(err, context.Canceled) {
@@ -62,3 +126,113 @@ func (i *instance) Close() error {
 	<-i.closed
 	return nil
 }
+
+type purger struct {
+	db         database.Store
+	opts       Options
+	rowsPurged *prometheus.CounterVec
+}
+
+func (p *purger) purgeAuditLogs(ctx context.Context) error {
+	return p.purge(ctx, "audit_logs", p.opts.AuditLogRetention, func(tx database.Store, before time.Time) (int, error) {
+		logs, err := tx.DeleteOldAuditLogs(ctx, database.DeleteOldAuditLogsParams{
+			Before:     before,
+			LimitCount: p.opts.BatchSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(logs), archive(p.opts.ArchiveDir, "audit_logs", logs)
+	})
+}
+
+func (p *purger) purgeProvisionerJobLogs(ctx context.Context) error {
+	return p.purge(ctx, "provisioner_job_logs", p.opts.ProvisionerJobLogRetention, func(tx database.Store, before time.Time) (int, error) {
+		logs, err := tx.DeleteOldProvisionerJobLogs(ctx, database.DeleteOldProvisionerJobLogsParams{
+			Before:     before,
+			LimitCount: p.opts.BatchSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(logs), archive(p.opts.ArchiveDir, "provisioner_job_logs", logs)
+	})
+}
+
+func (p *purger) purgeWorkspaceBuildState(ctx context.Context) error {
+	return p.purge(ctx, "workspace_builds", p.opts.WorkspaceBuildStateRetention, func(tx database.Store, before time.Time) (int, error) {
+		builds, err := tx.ClearOldWorkspaceBuildProvisionerState(ctx, database.ClearOldWorkspaceBuildProvisionerStateParams{
+			Before:     before,
+			LimitCount: p.opts.BatchSize,
+		})
+		if err != nil {
+			return 0, err
+		}
+		return len(builds), archive(p.opts.ArchiveDir, "workspace_builds", builds)
+	})
+}
+
+// purge calls batch in its own transaction until it purges fewer rows
+// than the batch size. Keeping transactions small avoids holding locks
+// on busy tables for long.
+func (p *purger) purge(ctx context.Context, table string, retention time.Duration, batch func(tx database.Store, before time.Time) (int, error)) error {
+	if retention <= 0 {
+		return nil
+	}
+	before := time.Now().Add(-retention)
+	for {
+		var purged int
+		err := p.db.InTx(func(tx database.Store) error {
+			var err error
+			purged, err = batch(tx, before)
+			return err
+		}, nil)
+		if err != nil {
+			return xerrors.Errorf("purge %s: %w", table, err)
+		}
+		p.rowsPurged.WithLabelValues(table).Add(float64(purged))
+		if purged < int(p.opts.BatchSize) {
+			return nil
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+	}
+}
+
+// archive appends rows to a daily file for the table in dir. The file is
+// synced before returning so rows are never deleted without being
+// persisted first.
+func archive[T any](dir, table string, rows []T) error {
+	if dir == "" || len(rows) == 0 {
+		return nil
+	}
+	err := os.MkdirAll(dir, 0o700)
+	if err != nil {
+		return xerrors.Errorf("create archive dir: %w", err)
+	}
+	name := filepath.Join(dir, fmt.Sprintf("%s-%s.ndjson", table, time.Now().UTC().Format("2006-01-02")))
+	file, err := os.OpenFile(name, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return xerrors.Errorf("open archive: %w", err)
+	}
+	defer file.Close()
+
+	buf := bufio.NewWriter(file)
+	enc := json.NewEncoder(buf)
+	for _, row := range rows {
+		err = enc.Encode(row)
+		if err != nil {
+			return xerrors.Errorf("encode row: %w", err)
+		}
+	}
+	err = buf.Flush()
+	if err != nil {
+		return xerrors.Errorf("write archive: %w", err)
+	}
+	err = file.Sync()
+	if err != nil {
+		return xerrors.Errorf("sync archive: %w", err)
+	}
+	return file.Close()
+}
@@ -1,16 +1,25 @@
 package dbpurge_test
 
 import (
+	"bytes"
 	"context"
+	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
 	"go.uber.org/goleak"
 
 	"github.com/stretchr/testify/require"
 
 	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
 	"github.com/coder/coder/coderd/database/dbpurge"
+	"github.com/coder/coder/testutil"
 )
 
 func TestMain(m *testing.M) {
@@ -20,7 +29,93 @@ func TestMain(m *testing.M) {
 // Ensures no goroutines leak.
 func TestPurge(t *testing.T) {
 	t.Parallel()
-	purger := dbpurge.New(context.Background(), slogtest.Make(t, nil), dbfake.New())
+	purger := dbpurge.New(context.Background(), slogtest.Make(t, nil), dbfake.New(), dbpurge.Options{})
 	err := purger.Close()
 	require.NoError(t, err)
 }
+
+func TestRetention(t *testing.T) {
+	t.Parallel()
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	db := dbfake.New()
+	old := database.Now().Add(-48 * time.Hour)
+
+	oldLog := dbgen.AuditLog(t, db, database.AuditLog{Time: old})
+	_ = dbgen.AuditLog(t, db, database.AuditLog{Time: old.Add(time.Minute)})
+	newLog := dbgen.AuditLog(t, db, database.AuditLog{Time: database.Now()})
+
+	jobID := uuid.New()
+	_, err := db.InsertProvisionerJobLogs(ctx, database.InsertProvisionerJobLogsParams{
+		JobID:     jobID,
+		CreatedAt: []time.Time{old, database.Now()},
+		Source:    []database.LogSource{database.LogSourceProvisioner, database.LogSourceProvisioner},
+		Level:     []database.LogLevel{database.LogLevelInfo, database.LogLevelInfo},
+		Stage:     []string{"", ""},
+		Output:    []string{"old", "new"},
+	})
+	require.NoError(t, err)
+
+	workspaceID := uuid.New()
+	oldBuild := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:      workspaceID,
+		BuildNumber:      1,
+		CreatedAt:        old,
+		ProvisionerState: []byte("old"),
+	})
+	// The latest build's state is needed for the next build, so it must
+	// be kept no matter how old it is.
+	latestBuild := dbgen.WorkspaceBuild(t, db, database.WorkspaceBuild{
+		WorkspaceID:      workspaceID,
+		BuildNumber:      2,
+		CreatedAt:        old,
+		ProvisionerState: []byte("latest"),
+	})
+
+	archiveDir := t.TempDir()
+	purger := dbpurge.New(ctx, slogtest.Make(t, nil), db, dbpurge.Options{
+		AuditLogRetention:            24 * time.Hour,
+		ProvisionerJobLogRetention:   24 * time.Hour,
+		WorkspaceBuildStateRetention: 24 * time.Hour,
+		ArchiveDir:                   archiveDir,
+		BatchSize:                    1,
+		Interval:                     testutil.IntervalFast,
+		Registerer:                   prometheus.NewRegistry(),
+	})
+	defer purger.Close()
+
+	require.Eventually(t, func() bool {
+		logs, err := db.GetAuditLogsOffset(ctx, database.GetAuditLogsOffsetParams{Limit: 10})
+		if err != nil || len(logs) != 1 {
+			return false
+		}
+		jobLogs, err := db.GetProvisionerLogsAfterID(ctx, database.GetProvisionerLogsAfterIDParams{JobID: jobID})
+		if err != nil || len(jobLogs) != 1 {
+			return false
+		}
+		build, err := db.GetWorkspaceBuildByID(ctx, oldBuild.ID)
+		return err == nil && build.ProvisionerState == nil
+	}, testutil.WaitLong, testutil.IntervalFast)
+	require.NoError(t, purger.Close())
+
+	logs, err := db.GetAuditLogsOffset(ctx, database.GetAuditLogsOffsetParams{Limit: 10})
+	require.NoError(t, err)
+	require.Equal(t, newLog.ID, logs[0].ID)
+	build, err := db.GetWorkspaceBuildByID(ctx, latestBuild.ID)
+	require.NoError(t, err)
+	require.Equal(t, []byte("latest"), build.ProvisionerState)
+
+	day := time.Now().UTC().Format("2006-01-02")
+	for table, rows := range map[string]int{
+		"audit_logs":           2,
+		"provisioner_job_logs": 1,
+		"workspace_builds":     1,
+	} {
+		data, err := os.ReadFile(filepath.Join(archiveDir, table+"-"+day+".ndjson"))
+		require.NoError(t, err)
+		require.Len(t, bytes.Split(bytes.TrimSpace(data), []byte("\n")), rows, table)
+	}
+	data, err := os.ReadFile(filepath.Join(archiveDir, "audit_logs-"+day+".ndjson"))
+	require.NoError(t, err)
+	require.Contains(t, string(data), oldLog.ID.String())
+}
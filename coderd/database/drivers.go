@@ -25,6 +25,26 @@ func (a *Actions) Value() (driver.Value, error) {
 	return json.Marshal(a)
 }
 
+// CustomRolePermissions are the permissions of a custom role at one level.
+type CustomRolePermissions []rbac.Permission
+
+func (p *CustomRolePermissions) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case string:
+		return json.Unmarshal([]byte(v), &p)
+	case []byte:
+		return json.Unmarshal(v, &p)
+	}
+	return xerrors.Errorf("unexpected type %T", src)
+}
+
+func (p CustomRolePermissions) Value() (driver.Value, error) {
+	if p == nil {
+		return []byte("[]"), nil
+	}
+	return json.Marshal(p)
+}
+
 // TemplateACL is a map of ids to permissions.
 type TemplateACL map[string][]rbac.Action
 
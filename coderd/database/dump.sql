@@ -2,7 +2,8 @@
 
 CREATE TYPE api_key_scope AS ENUM (
     'all',
-    'application_connect'
+    'application_connect',
+    'resources'
 );
 
 CREATE TYPE app_sharing_level AS ENUM (
@@ -24,7 +25,10 @@ CREATE TYPE audit_action AS ENUM (
 CREATE TYPE build_reason AS ENUM (
     'initiator',
     'autostart',
-    'autostop'
+    'autostop',
+    'autolock',
+    'autodelete',
+    'failedstop'
 );
 
 CREATE TYPE log_level AS ENUM (
@@ -94,7 +98,15 @@ CREATE TYPE resource_type AS ENUM (
     'api_key',
     'group',
     'workspace_build',
-    'license'
+    'license',
+    'workspace_proxy',
+    'audit_filter_rules',
+    'workspace_agent_port_share'
+);
+
+CREATE TYPE terminal_recording_type AS ENUM (
+    'ssh',
+    'reconnecting_pty'
 );
 
 CREATE TYPE user_status AS ENUM (
@@ -127,6 +139,15 @@ CREATE TYPE workspace_transition AS ENUM (
     'delete'
 );
 
+CREATE TABLE api_key_scope_resources (
+    api_key_id text NOT NULL,
+    resource_type text NOT NULL,
+    resource_id uuid NOT NULL,
+    action text NOT NULL
+);
+
+COMMENT ON TABLE api_key_scope_resources IS 'The actions an API key with the resources scope may perform. Each action is limited to a single resource.';
+
 CREATE TABLE api_keys (
     id text NOT NULL,
     hashed_secret bytea NOT NULL,
@@ -162,6 +183,21 @@ CREATE TABLE audit_logs (
     resource_icon text NOT NULL
 );
 
+CREATE TABLE custom_roles (
+    name text NOT NULL,
+    display_name text NOT NULL,
+    organization_id uuid,
+    site_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    org_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    user_permissions jsonb DEFAULT '[]'::jsonb NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by admins in addition to the built-in roles.';
+
+COMMENT ON COLUMN custom_roles.organization_id IS 'Organization roles are only assignable in this organization. Site wide roles have no organization.';
+
 CREATE TABLE files (
     hash character varying(64) NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -354,6 +390,7 @@ CREATE TABLE template_version_parameters (
     validation_monotonic text DEFAULT ''::text NOT NULL,
     required boolean DEFAULT true NOT NULL,
     legacy_variable_name text DEFAULT ''::text NOT NULL,
+    ephemeral boolean DEFAULT false NOT NULL,
     CONSTRAINT validation_monotonic_order CHECK ((validation_monotonic = ANY (ARRAY['increasing'::text, 'decreasing'::text, ''::text])))
 );
 
@@ -385,6 +422,8 @@ COMMENT ON COLUMN template_version_parameters.required IS 'Is parameter required
 
 COMMENT ON COLUMN template_version_parameters.legacy_variable_name IS 'Name of the legacy variable for migration purposes';
 
+COMMENT ON COLUMN template_version_parameters.ephemeral IS 'Is parameter ephemeral? Ephemeral parameters only apply to a single build.';
+
 CREATE TABLE template_version_variables (
     template_version_id uuid NOT NULL,
     name text NOT NULL,
@@ -420,11 +459,17 @@ CREATE TABLE template_versions (
     readme character varying(1048576) NOT NULL,
     job_id uuid NOT NULL,
     created_by uuid NOT NULL,
-    git_auth_providers text[]
+    git_auth_providers text[],
+    archived boolean DEFAULT false NOT NULL,
+    git_commit_sha text DEFAULT ''::text NOT NULL
 );
 
 COMMENT ON COLUMN template_versions.git_auth_providers IS 'IDs of Git auth providers for a specific template version';
 
+COMMENT ON COLUMN template_versions.archived IS 'Archived versions are hidden from the version list and can''t be used for new builds.';
+
+COMMENT ON COLUMN template_versions.git_commit_sha IS 'SHA of the git commit the template version was created from, if it was created from a git repository.';
+
 CREATE TABLE templates (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -442,7 +487,12 @@ CREATE TABLE templates (
     group_acl jsonb DEFAULT '{}'::jsonb NOT NULL,
     display_name character varying(64) DEFAULT ''::character varying NOT NULL,
     allow_user_cancel_workspace_jobs boolean DEFAULT true NOT NULL,
-    max_ttl bigint DEFAULT '0'::bigint NOT NULL
+    max_ttl bigint DEFAULT '0'::bigint NOT NULL,
+    inactivity_ttl bigint DEFAULT 0 NOT NULL,
+    locked_ttl bigint DEFAULT 0 NOT NULL,
+    failure_ttl bigint DEFAULT 0 NOT NULL,
+    record_terminal_sessions boolean DEFAULT false NOT NULL,
+    max_port_sharing_level app_sharing_level DEFAULT 'owner'::app_sharing_level NOT NULL
 );
 
 COMMENT ON COLUMN templates.default_ttl IS 'The default duration for auto-stop for workspaces created from this template.';
@@ -451,6 +501,37 @@ COMMENT ON COLUMN templates.display_name IS 'Display name is a custom, human-fri
 
 COMMENT ON COLUMN templates.allow_user_cancel_workspace_jobs IS 'Allow users to cancel in-progress workspace jobs.';
 
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a workspace may go unused before it is locked. A value of 0 disables locking.';
+
+COMMENT ON COLUMN templates.locked_ttl IS 'The duration a workspace may remain locked before it is deleted. A value of 0 disables deletion.';
+
+COMMENT ON COLUMN templates.failure_ttl IS 'The duration after which a workspace whose latest start build failed is automatically stopped. A value of 0 disables this.';
+
+COMMENT ON COLUMN templates.record_terminal_sessions IS 'Record terminal sessions of workspaces created from this template.';
+
+COMMENT ON COLUMN templates.max_port_sharing_level IS 'The most permissive level workspace owners may share ports at.';
+
+CREATE TABLE terminal_recordings (
+    id uuid NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    started_at timestamp with time zone NOT NULL,
+    ended_at timestamp with time zone NOT NULL,
+    organization_id uuid NOT NULL,
+    workspace_id uuid NOT NULL,
+    agent_id uuid NOT NULL,
+    user_id uuid NOT NULL,
+    client_reported_user_id uuid,
+    type terminal_recording_type NOT NULL,
+    size bigint NOT NULL,
+    data bytea NOT NULL
+);
+
+COMMENT ON TABLE terminal_recordings IS 'Recordings of workspace terminal sessions in the asciicast v2 format.';
+
+COMMENT ON COLUMN terminal_recordings.user_id IS 'The owner of the workspace at the time of the recording.';
+
+COMMENT ON COLUMN terminal_recordings.client_reported_user_id IS 'The user the client reported as connecting to the session. This is not verified, any client that can reach the agent can set it.';
+
 CREATE TABLE user_links (
     user_id uuid NOT NULL,
     login_type login_type NOT NULL,
@@ -475,6 +556,31 @@ CREATE TABLE users (
     last_seen_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
 );
 
+CREATE UNLOGGED TABLE workspace_agent_metadata (
+    workspace_agent_id uuid NOT NULL,
+    display_name character varying(127) NOT NULL,
+    key character varying(127) NOT NULL,
+    script character varying(65535) NOT NULL,
+    value character varying(65535) DEFAULT ''::character varying NOT NULL,
+    error character varying(65535) DEFAULT ''::character varying NOT NULL,
+    timeout bigint NOT NULL,
+    "interval" bigint NOT NULL,
+    collected_at timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
+);
+
+CREATE TABLE workspace_agent_port_shares (
+    workspace_id uuid NOT NULL,
+    agent_name text NOT NULL,
+    port integer NOT NULL,
+    share_level app_sharing_level NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Listening ports that workspace owners share with other users.';
+
+COMMENT ON COLUMN workspace_agent_port_shares.agent_name IS 'Agents are matched by name so shares persist across workspace builds.';
+
 CREATE TABLE workspace_agent_startup_logs (
     agent_id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -542,6 +648,7 @@ CREATE TABLE workspace_agents (
     shutdown_script_timeout_seconds integer DEFAULT 0 NOT NULL,
     startup_logs_length integer DEFAULT 0 NOT NULL,
     startup_logs_overflowed boolean DEFAULT false NOT NULL,
+    netcheck jsonb,
     CONSTRAINT max_startup_logs_length CHECK ((startup_logs_length <= 1048576))
 );
 
@@ -569,6 +676,8 @@ COMMENT ON COLUMN workspace_agents.startup_logs_length IS 'Total length of start
 
 COMMENT ON COLUMN workspace_agents.startup_logs_overflowed IS 'Whether the startup logs overflowed in length';
 
+COMMENT ON COLUMN workspace_agents.netcheck IS 'The latest netcheck report of the agent, describing the network of the workspace.';
+
 CREATE TABLE workspace_apps (
     id uuid NOT NULL,
     created_at timestamp with time zone NOT NULL,
@@ -614,6 +723,25 @@ CREATE TABLE workspace_builds (
     max_deadline timestamp with time zone DEFAULT '0001-01-01 00:00:00+00'::timestamp with time zone NOT NULL
 );
 
+CREATE TABLE workspace_proxies (
+    id uuid NOT NULL,
+    name text NOT NULL,
+    display_name text NOT NULL,
+    icon text NOT NULL,
+    url text NOT NULL,
+    wildcard_hostname text NOT NULL,
+    created_at timestamp with time zone NOT NULL,
+    updated_at timestamp with time zone NOT NULL,
+    deleted boolean NOT NULL,
+    token_hashed_secret bytea NOT NULL
+);
+
+COMMENT ON COLUMN workspace_proxies.url IS 'Full url including scheme of the proxy api url: https://us.example.com';
+
+COMMENT ON COLUMN workspace_proxies.wildcard_hostname IS 'Hostname with the wildcard for subdomain based app hosting: *.us.example.com';
+
+COMMENT ON COLUMN workspace_proxies.token_hashed_secret IS 'Hashed secret is used to authenticate the workspace proxy using a session token.';
+
 CREATE TABLE workspace_resource_metadata (
     workspace_resource_id uuid NOT NULL,
     key character varying(1024) NOT NULL,
@@ -655,7 +783,8 @@ CREATE TABLE workspaces (
     name character varying(64) NOT NULL,
     autostart_schedule text,
     ttl bigint,
-    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL
+    last_used_at timestamp without time zone DEFAULT '0001-01-01 00:00:00'::timestamp without time zone NOT NULL,
+    locked_at timestamp with time zone
 );
 
 ALTER TABLE ONLY licenses ALTER COLUMN id SET DEFAULT nextval('licenses_id_seq'::regclass);
@@ -669,6 +798,9 @@ ALTER TABLE ONLY workspace_resource_metadata ALTER COLUMN id SET DEFAULT nextval
 ALTER TABLE ONLY workspace_agent_stats
     ADD CONSTRAINT agent_stats_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY api_key_scope_resources
+    ADD CONSTRAINT api_key_scope_resources_pkey PRIMARY KEY (api_key_id, resource_type, resource_id, action);
+
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_pkey PRIMARY KEY (id);
 
@@ -750,12 +882,21 @@ ALTER TABLE ONLY template_versions
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY terminal_recordings
+    ADD CONSTRAINT terminal_recordings_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_pkey PRIMARY KEY (user_id, login_type);
 
 ALTER TABLE ONLY users
     ADD CONSTRAINT users_pkey PRIMARY KEY (id);
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_pkey PRIMARY KEY (workspace_agent_id, key);
+
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_pkey PRIMARY KEY (workspace_id, agent_name, port);
+
 ALTER TABLE ONLY workspace_agent_startup_logs
     ADD CONSTRAINT workspace_agent_startup_logs_pkey PRIMARY KEY (id);
 
@@ -780,6 +921,9 @@ ALTER TABLE ONLY workspace_builds
 ALTER TABLE ONLY workspace_builds
     ADD CONSTRAINT workspace_builds_workspace_id_build_number_key UNIQUE (workspace_id, build_number);
 
+ALTER TABLE ONLY workspace_proxies
+    ADD CONSTRAINT workspace_proxies_pkey PRIMARY KEY (id);
+
 ALTER TABLE ONLY workspace_resource_metadata
     ADD CONSTRAINT workspace_resource_metadata_name UNIQUE (workspace_resource_id, key);
 
@@ -808,6 +952,8 @@ CREATE INDEX idx_audit_log_user_id ON audit_logs USING btree (user_id);
 
 CREATE INDEX idx_audit_logs_time_desc ON audit_logs USING btree ("time" DESC);
 
+CREATE UNIQUE INDEX idx_custom_roles_name_organization_id ON custom_roles USING btree (name, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid));
+
 CREATE INDEX idx_organization_member_organization_id_uuid ON organization_members USING btree (organization_id);
 
 CREATE INDEX idx_organization_member_user_id_uuid ON organization_members USING btree (user_id);
@@ -826,6 +972,8 @@ CREATE INDEX provisioner_jobs_started_at_idx ON provisioner_jobs USING btree (st
 
 CREATE UNIQUE INDEX templates_organization_id_name_idx ON templates USING btree (organization_id, lower((name)::text)) WHERE (deleted = false);
 
+CREATE INDEX terminal_recordings_workspace_id_started_at_idx ON terminal_recordings USING btree (workspace_id, started_at DESC);
+
 CREATE UNIQUE INDEX users_email_lower_idx ON users USING btree (lower(email)) WHERE (deleted = false);
 
 CREATE UNIQUE INDEX users_username_lower_idx ON users USING btree (lower(username)) WHERE (deleted = false);
@@ -836,13 +984,21 @@ CREATE INDEX workspace_agents_auth_token_idx ON workspace_agents USING btree (au
 
 CREATE INDEX workspace_agents_resource_id_idx ON workspace_agents USING btree (resource_id);
 
+CREATE UNIQUE INDEX workspace_proxies_lower_name_idx ON workspace_proxies USING btree (lower(name)) WHERE (deleted = false);
+
 CREATE INDEX workspace_resources_job_id_idx ON workspace_resources USING btree (job_id);
 
 CREATE UNIQUE INDEX workspaces_owner_id_lower_idx ON workspaces USING btree (owner_id, lower((name)::text)) WHERE (deleted = false);
 
+ALTER TABLE ONLY api_key_scope_resources
+    ADD CONSTRAINT api_key_scope_resources_api_key_id_fkey FOREIGN KEY (api_key_id) REFERENCES api_keys(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY api_keys
     ADD CONSTRAINT api_keys_user_id_uuid_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY custom_roles
+    ADD CONSTRAINT custom_roles_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY gitsshkeys
     ADD CONSTRAINT gitsshkeys_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id);
 
@@ -891,9 +1047,27 @@ ALTER TABLE ONLY templates
 ALTER TABLE ONLY templates
     ADD CONSTRAINT templates_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY terminal_recordings
+    ADD CONSTRAINT terminal_recordings_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY terminal_recordings
+    ADD CONSTRAINT terminal_recordings_organization_id_fkey FOREIGN KEY (organization_id) REFERENCES organizations(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY terminal_recordings
+    ADD CONSTRAINT terminal_recordings_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY terminal_recordings
+    ADD CONSTRAINT terminal_recordings_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY user_links
     ADD CONSTRAINT user_links_user_id_fkey FOREIGN KEY (user_id) REFERENCES users(id) ON DELETE CASCADE;
 
+ALTER TABLE ONLY workspace_agent_metadata
+    ADD CONSTRAINT workspace_agent_metadata_workspace_agent_id_fkey FOREIGN KEY (workspace_agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
+
+ALTER TABLE ONLY workspace_agent_port_shares
+    ADD CONSTRAINT workspace_agent_port_shares_workspace_id_fkey FOREIGN KEY (workspace_id) REFERENCES workspaces(id) ON DELETE CASCADE;
+
 ALTER TABLE ONLY workspace_agent_startup_logs
     ADD CONSTRAINT workspace_agent_startup_logs_agent_id_fkey FOREIGN KEY (agent_id) REFERENCES workspace_agents(id) ON DELETE CASCADE;
 
@@ -0,0 +1,8 @@
+ALTER TABLE ONLY workspaces DROP COLUMN IF EXISTS locked_at;
+
+ALTER TABLE ONLY templates
+	DROP COLUMN IF EXISTS inactivity_ttl,
+	DROP COLUMN IF EXISTS locked_ttl;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
@@ -0,0 +1,13 @@
+ALTER TABLE ONLY templates
+	ADD COLUMN IF NOT EXISTS inactivity_ttl bigint DEFAULT 0 NOT NULL,
+	ADD COLUMN IF NOT EXISTS locked_ttl bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN templates.inactivity_ttl IS 'The duration a workspace may go unused before it is locked. A value of 0 disables locking.';
+COMMENT ON COLUMN templates.locked_ttl IS 'The duration a workspace may remain locked before it is deleted. A value of 0 disables deletion.';
+
+ALTER TABLE ONLY workspaces ADD COLUMN IF NOT EXISTS locked_at timestamp with time zone;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autolock';
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'autodelete';
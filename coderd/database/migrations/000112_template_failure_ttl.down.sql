@@ -0,0 +1,4 @@
+ALTER TABLE ONLY templates DROP COLUMN IF EXISTS failure_ttl;
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
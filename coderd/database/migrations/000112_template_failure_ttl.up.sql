@@ -0,0 +1,7 @@
+ALTER TABLE ONLY templates ADD COLUMN IF NOT EXISTS failure_ttl bigint DEFAULT 0 NOT NULL;
+
+COMMENT ON COLUMN templates.failure_ttl IS 'The duration after which a workspace whose latest start build failed is automatically stopped. A value of 0 disables this.';
+
+-- It's not possible to drop enum values from enum types, so the UP has "IF NOT
+-- EXISTS".
+ALTER TYPE build_reason ADD VALUE IF NOT EXISTS 'failedstop';
@@ -0,0 +1,22 @@
+CREATE TABLE workspace_proxies (
+	id uuid NOT NULL,
+	name text NOT NULL,
+	display_name text NOT NULL,
+	icon text NOT NULL,
+	url text NOT NULL,
+	wildcard_hostname text NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	deleted boolean NOT NULL,
+	token_hashed_secret bytea NOT NULL,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON COLUMN workspace_proxies.url IS 'Full url including scheme of the proxy api url: https://us.example.com';
+COMMENT ON COLUMN workspace_proxies.wildcard_hostname IS 'Hostname with the wildcard for subdomain based app hosting: *.us.example.com';
+COMMENT ON COLUMN workspace_proxies.token_hashed_secret IS 'Hashed secret is used to authenticate the workspace proxy using a session token.';
+
+-- Proxy names must be unique among proxies that have not been deleted.
+CREATE UNIQUE INDEX workspace_proxies_lower_name_idx ON workspace_proxies USING btree (lower(name)) WHERE deleted = FALSE;
+
+ALTER TYPE resource_type ADD VALUE IF NOT EXISTS 'workspace_proxy';
@@ -0,0 +1,17 @@
+CREATE TABLE custom_roles (
+	name text NOT NULL,
+	display_name text NOT NULL,
+	organization_id uuid REFERENCES organizations (id) ON DELETE CASCADE,
+	site_permissions jsonb NOT NULL DEFAULT '[]'::jsonb,
+	org_permissions jsonb NOT NULL DEFAULT '[]'::jsonb,
+	user_permissions jsonb NOT NULL DEFAULT '[]'::jsonb,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL
+);
+
+COMMENT ON TABLE custom_roles IS 'Roles defined by admins in addition to the built-in roles.';
+COMMENT ON COLUMN custom_roles.organization_id IS 'Organization roles are only assignable in this organization. Site wide roles have no organization.';
+
+-- Custom role names are unique among site wide roles, and among the roles
+-- of each organization.
+CREATE UNIQUE INDEX idx_custom_roles_name_organization_id ON custom_roles USING btree (name, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000'::uuid));
@@ -0,0 +1,11 @@
+ALTER TYPE api_key_scope ADD VALUE IF NOT EXISTS 'resources';
+
+CREATE TABLE IF NOT EXISTS api_key_scope_resources (
+	api_key_id text NOT NULL REFERENCES api_keys (id) ON DELETE CASCADE,
+	resource_type text NOT NULL,
+	resource_id uuid NOT NULL,
+	action text NOT NULL,
+	PRIMARY KEY (api_key_id, resource_type, resource_id, action)
+);
+
+COMMENT ON TABLE api_key_scope_resources IS 'The actions an API key with the resources scope may perform. Each action is limited to a single resource.';
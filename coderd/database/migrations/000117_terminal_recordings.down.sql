@@ -0,0 +1,5 @@
+ALTER TABLE templates DROP COLUMN record_terminal_sessions;
+
+DROP TABLE terminal_recordings;
+
+DROP TYPE terminal_recording_type;
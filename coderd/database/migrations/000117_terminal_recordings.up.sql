@@ -0,0 +1,27 @@
+CREATE TYPE terminal_recording_type AS ENUM ('ssh', 'reconnecting_pty');
+
+CREATE TABLE terminal_recordings (
+	id uuid NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	started_at timestamp with time zone NOT NULL,
+	ended_at timestamp with time zone NOT NULL,
+	organization_id uuid NOT NULL REFERENCES organizations (id) ON DELETE CASCADE,
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_id uuid NOT NULL REFERENCES workspace_agents (id) ON DELETE CASCADE,
+	user_id uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+	client_reported_user_id uuid,
+	type terminal_recording_type NOT NULL,
+	size bigint NOT NULL,
+	data bytea NOT NULL,
+	PRIMARY KEY (id)
+);
+
+COMMENT ON TABLE terminal_recordings IS 'Recordings of workspace terminal sessions in the asciicast v2 format.';
+COMMENT ON COLUMN terminal_recordings.user_id IS 'The owner of the workspace at the time of the recording.';
+COMMENT ON COLUMN terminal_recordings.client_reported_user_id IS 'The user the client reported as connecting to the session. This is not verified, any client that can reach the agent can set it.';
+
+CREATE INDEX terminal_recordings_workspace_id_started_at_idx ON terminal_recordings USING btree (workspace_id, started_at DESC);
+
+ALTER TABLE templates ADD COLUMN record_terminal_sessions boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN templates.record_terminal_sessions IS 'Record terminal sessions of workspaces created from this template.';
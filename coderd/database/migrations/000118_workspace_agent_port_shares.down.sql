@@ -0,0 +1,3 @@
+ALTER TABLE templates DROP COLUMN max_port_sharing_level;
+
+DROP TABLE workspace_agent_port_shares;
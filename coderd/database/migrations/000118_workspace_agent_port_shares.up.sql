@@ -0,0 +1,16 @@
+CREATE TABLE workspace_agent_port_shares (
+	workspace_id uuid NOT NULL REFERENCES workspaces (id) ON DELETE CASCADE,
+	agent_name text NOT NULL,
+	port integer NOT NULL,
+	share_level app_sharing_level NOT NULL,
+	created_at timestamp with time zone NOT NULL,
+	updated_at timestamp with time zone NOT NULL,
+	PRIMARY KEY (workspace_id, agent_name, port)
+);
+
+COMMENT ON TABLE workspace_agent_port_shares IS 'Listening ports that workspace owners share with other users.';
+COMMENT ON COLUMN workspace_agent_port_shares.agent_name IS 'Agents are matched by name so shares persist across workspace builds.';
+
+ALTER TABLE templates ADD COLUMN max_port_sharing_level app_sharing_level NOT NULL DEFAULT 'owner';
+
+COMMENT ON COLUMN templates.max_port_sharing_level IS 'The most permissive level workspace owners may share ports at.';
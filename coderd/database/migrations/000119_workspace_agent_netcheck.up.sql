@@ -0,0 +1,4 @@
+ALTER TABLE workspace_agents
+	ADD COLUMN netcheck jsonb;
+
+COMMENT ON COLUMN workspace_agents.netcheck IS 'The latest netcheck report of the agent, describing the network of the workspace.';
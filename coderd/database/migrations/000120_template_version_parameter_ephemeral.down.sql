@@ -0,0 +1,2 @@
+ALTER TABLE template_version_parameters
+	DROP COLUMN ephemeral;
@@ -0,0 +1,4 @@
+ALTER TABLE template_version_parameters
+	ADD COLUMN ephemeral boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN template_version_parameters.ephemeral IS 'Is parameter ephemeral? Ephemeral parameters only apply to a single build.';
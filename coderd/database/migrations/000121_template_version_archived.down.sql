@@ -0,0 +1,2 @@
+ALTER TABLE template_versions
+	DROP COLUMN archived;
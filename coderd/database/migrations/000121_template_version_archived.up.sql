@@ -0,0 +1,4 @@
+ALTER TABLE template_versions
+	ADD COLUMN archived boolean NOT NULL DEFAULT false;
+
+COMMENT ON COLUMN template_versions.archived IS 'Archived versions are hidden from the version list and can''t be used for new builds.';
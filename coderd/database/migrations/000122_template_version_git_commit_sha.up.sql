@@ -0,0 +1,4 @@
+ALTER TABLE template_versions
+	ADD COLUMN git_commit_sha text NOT NULL DEFAULT '';
+
+COMMENT ON COLUMN template_versions.git_commit_sha IS 'SHA of the git commit the template version was created from, if it was created from a git repository.';
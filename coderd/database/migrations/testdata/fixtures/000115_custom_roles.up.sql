@@ -0,0 +1,19 @@
+INSERT INTO custom_roles (
+	name,
+	display_name,
+	organization_id,
+	site_permissions,
+	org_permissions,
+	user_permissions,
+	created_at,
+	updated_at
+) VALUES (
+	'workspace-reader',
+	'Workspace Reader',
+	NULL,
+	'[{"negate":false,"resource_type":"workspace","action":"read"}]'::jsonb,
+	'[]'::jsonb,
+	'[]'::jsonb,
+	'2023-04-20 12:00:00.000+02',
+	'2023-04-20 12:00:00.000+02'
+);
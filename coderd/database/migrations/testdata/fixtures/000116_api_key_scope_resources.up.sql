@@ -0,0 +1,11 @@
+INSERT INTO api_key_scope_resources (
+	api_key_id,
+	resource_type,
+	resource_id,
+	action
+) VALUES (
+	'WEG2T4MNno',
+	'template',
+	'a9a7f6d2-07a5-4bf6-8dc4-27f8b7f27e0b',
+	'read'
+);
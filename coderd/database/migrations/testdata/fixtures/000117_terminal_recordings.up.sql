@@ -0,0 +1,25 @@
+INSERT INTO terminal_recordings (
+	id,
+	created_at,
+	started_at,
+	ended_at,
+	organization_id,
+	workspace_id,
+	agent_id,
+	user_id,
+	type,
+	size,
+	data
+) VALUES (
+	'6c0e5d84-c4a6-4bd5-8d45-3f6cba2d1b3d',
+	'2023-04-20 12:00:05.000+02',
+	'2023-04-20 12:00:00.000+02',
+	'2023-04-20 12:00:01.000+02',
+	'bb640d07-ca8a-4869-b6bc-ae61ebb2fda1',
+	'3a9a1feb-e89d-457c-9d53-ac751b198ebe',
+	'45e89705-e09d-4850-bcec-f9a937f5d78d',
+	'30095c71-380b-457a-8995-97b8ee6e5307',
+	'ssh',
+	78,
+	convert_to(E'{"version":2,"width":80,"height":24,"timestamp":1681984800}\n[1.0,"o","hi"]\n', 'UTF8')
+);
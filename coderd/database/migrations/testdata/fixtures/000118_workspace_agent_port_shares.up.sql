@@ -0,0 +1,15 @@
+INSERT INTO workspace_agent_port_shares (
+	workspace_id,
+	agent_name,
+	port,
+	share_level,
+	created_at,
+	updated_at
+) VALUES (
+	'3a9a1feb-e89d-457c-9d53-ac751b198ebe',
+	'main',
+	8080,
+	'authenticated',
+	'2023-04-20 12:00:00.000+02',
+	'2023-04-20 12:00:00.000+02'
+);
@@ -4,6 +4,7 @@ import (
 	"sort"
 	"strconv"
 
+	"github.com/google/uuid"
 	"golang.org/x/exp/maps"
 
 	"github.com/coder/coder/coderd/rbac"
@@ -36,6 +37,34 @@ func (s WorkspaceStatus) Valid() bool {
 	}
 }
 
+// MorePermissiveThan returns true if s shares with a larger set of users
+// than other. Invalid levels are treated as "owner".
+func (s AppSharingLevel) MorePermissiveThan(other AppSharingLevel) bool {
+	rank := func(level AppSharingLevel) int {
+		switch level {
+		case AppSharingLevelAuthenticated:
+			return 1
+		case AppSharingLevelPublic:
+			return 2
+		default:
+			return 0
+		}
+	}
+	return rank(s) > rank(other)
+}
+
+// AuditFilterRulesID is the resource ID of the audit filter rules in audit
+// logs. There's only one set of rules, so the ID is fixed to keep their
+// history searchable.
+var AuditFilterRulesID = uuid.MustParse("9a1f3d52-6c0e-4b7a-8f21-5d4e3c2b1a09")
+
+// AuditFilterRules are the audit filter rules of the deployment, stored as
+// JSON in site configs. The ID is always AuditFilterRulesID.
+type AuditFilterRules struct {
+	ID    uuid.UUID `json:"id"`
+	Rules string    `json:"rules"`
+}
+
 type AuditableGroup struct {
 	Group
 	Members []GroupMember `json:"members"`
@@ -192,6 +221,18 @@ func (l License) RBACObject() rbac.Object {
 	return rbac.ResourceLicense.WithIDString(strconv.FormatInt(int64(l.ID), 10))
 }
 
+func (w WorkspaceProxy) RBACObject() rbac.Object {
+	return rbac.ResourceWorkspaceProxy.WithID(w.ID)
+}
+
+func (r TerminalRecording) RBACObject() rbac.Object {
+	return rbac.ResourceTerminalRecording.WithID(r.ID).InOrg(r.OrganizationID)
+}
+
+func (r GetTerminalRecordingsRow) RBACObject() rbac.Object {
+	return rbac.ResourceTerminalRecording.WithID(r.ID).InOrg(r.OrganizationID)
+}
+
 func ConvertUserRows(rows []GetUsersRow) []User {
 	users := make([]User, len(rows))
 	for i, r := range rows {
@@ -229,6 +270,7 @@ func ConvertWorkspaceRows(rows []GetWorkspacesRow) []Workspace {
 			AutostartSchedule: r.AutostartSchedule,
 			Ttl:               r.Ttl,
 			LastUsedAt:        r.LastUsedAt,
+			LockedAt:          r.LockedAt,
 		}
 	}
 
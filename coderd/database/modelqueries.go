@@ -78,6 +78,11 @@ func (q *sqlQuerier) GetAuthorizedTemplates(ctx context.Context, arg GetTemplate
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.LockedTTL,
+			&i.FailureTTL,
+			&i.RecordTerminalSessions,
+			&i.MaxPortSharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -208,6 +213,7 @@ func (q *sqlQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg GetWorkspa
 		arg.Name,
 		arg.HasAgent,
 		arg.AgentInactiveDisconnectTimeoutSeconds,
+		arg.Locked,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -230,6 +236,7 @@ func (q *sqlQuerier) GetAuthorizedWorkspaces(ctx context.Context, arg GetWorkspa
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.LockedAt,
 			&i.Count,
 		); err != nil {
 			return nil, err
@@ -22,6 +22,7 @@ type APIKeyScope string
 const (
 	APIKeyScopeAll                APIKeyScope = "all"
 	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	APIKeyScopeResources          APIKeyScope = "resources"
 )
 
 func (e *APIKeyScope) Scan(src interface{}) error {
@@ -62,7 +63,8 @@ func (ns NullAPIKeyScope) Value() (driver.Value, error) {
 func (e APIKeyScope) Valid() bool {
 	switch e {
 	case APIKeyScopeAll,
-		APIKeyScopeApplicationConnect:
+		APIKeyScopeApplicationConnect,
+		APIKeyScopeResources:
 		return true
 	}
 	return false
@@ -72,6 +74,7 @@ func AllAPIKeyScopeValues() []APIKeyScope {
 	return []APIKeyScope{
 		APIKeyScopeAll,
 		APIKeyScopeApplicationConnect,
+		APIKeyScopeResources,
 	}
 }
 
@@ -212,9 +215,12 @@ func AllAuditActionValues() []AuditAction {
 type BuildReason string
 
 const (
-	BuildReasonInitiator BuildReason = "initiator"
-	BuildReasonAutostart BuildReason = "autostart"
-	BuildReasonAutostop  BuildReason = "autostop"
+	BuildReasonInitiator  BuildReason = "initiator"
+	BuildReasonAutostart  BuildReason = "autostart"
+	BuildReasonAutostop   BuildReason = "autostop"
+	BuildReasonAutolock   BuildReason = "autolock"
+	BuildReasonAutodelete BuildReason = "autodelete"
+	BuildReasonFailedstop BuildReason = "failedstop"
 )
 
 func (e *BuildReason) Scan(src interface{}) error {
@@ -256,7 +262,10 @@ func (e BuildReason) Valid() bool {
 	switch e {
 	case BuildReasonInitiator,
 		BuildReasonAutostart,
-		BuildReasonAutostop:
+		BuildReasonAutostop,
+		BuildReasonAutolock,
+		BuildReasonAutodelete,
+		BuildReasonFailedstop:
 		return true
 	}
 	return false
@@ -267,6 +276,9 @@ func AllBuildReasonValues() []BuildReason {
 		BuildReasonInitiator,
 		BuildReasonAutostart,
 		BuildReasonAutostop,
+		BuildReasonAutolock,
+		BuildReasonAutodelete,
+		BuildReasonFailedstop,
 	}
 }
 
@@ -874,16 +886,19 @@ func AllProvisionerTypeValues() []ProvisionerType {
 type ResourceType string
 
 const (
-	ResourceTypeOrganization    ResourceType = "organization"
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeGitSshKey       ResourceType = "git_ssh_key"
-	ResourceTypeApiKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeLicense         ResourceType = "license"
+	ResourceTypeOrganization            ResourceType = "organization"
+	ResourceTypeTemplate                ResourceType = "template"
+	ResourceTypeTemplateVersion         ResourceType = "template_version"
+	ResourceTypeUser                    ResourceType = "user"
+	ResourceTypeWorkspace               ResourceType = "workspace"
+	ResourceTypeGitSshKey               ResourceType = "git_ssh_key"
+	ResourceTypeApiKey                  ResourceType = "api_key"
+	ResourceTypeGroup                   ResourceType = "group"
+	ResourceTypeWorkspaceBuild          ResourceType = "workspace_build"
+	ResourceTypeLicense                 ResourceType = "license"
+	ResourceTypeWorkspaceProxy          ResourceType = "workspace_proxy"
+	ResourceTypeAuditFilterRules        ResourceType = "audit_filter_rules"
+	ResourceTypeWorkspaceAgentPortShare ResourceType = "workspace_agent_port_share"
 )
 
 func (e *ResourceType) Scan(src interface{}) error {
@@ -932,7 +947,10 @@ func (e ResourceType) Valid() bool {
 		ResourceTypeApiKey,
 		ResourceTypeGroup,
 		ResourceTypeWorkspaceBuild,
-		ResourceTypeLicense:
+		ResourceTypeLicense,
+		ResourceTypeWorkspaceProxy,
+		ResourceTypeAuditFilterRules,
+		ResourceTypeWorkspaceAgentPortShare:
 		return true
 	}
 	return false
@@ -950,6 +968,67 @@ func AllResourceTypeValues() []ResourceType {
 		ResourceTypeGroup,
 		ResourceTypeWorkspaceBuild,
 		ResourceTypeLicense,
+		ResourceTypeWorkspaceProxy,
+		ResourceTypeAuditFilterRules,
+		ResourceTypeWorkspaceAgentPortShare,
+	}
+}
+
+type TerminalRecordingType string
+
+const (
+	TerminalRecordingTypeSSH             TerminalRecordingType = "ssh"
+	TerminalRecordingTypeReconnectingPTY TerminalRecordingType = "reconnecting_pty"
+)
+
+func (e *TerminalRecordingType) Scan(src interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		*e = TerminalRecordingType(s)
+	case string:
+		*e = TerminalRecordingType(s)
+	default:
+		return fmt.Errorf("unsupported scan type for TerminalRecordingType: %T", src)
+	}
+	return nil
+}
+
+type NullTerminalRecordingType struct {
+	TerminalRecordingType TerminalRecordingType
+	Valid                 bool // Valid is true if TerminalRecordingType is not NULL
+}
+
+// Scan implements the Scanner interface.
+func (ns *NullTerminalRecordingType) Scan(value interface{}) error {
+	if value == nil {
+		ns.TerminalRecordingType, ns.Valid = "", false
+		return nil
+	}
+	ns.Valid = true
+	return ns.TerminalRecordingType.Scan(value)
+}
+
+// Value implements the driver Valuer interface.
+func (ns NullTerminalRecordingType) Value() (driver.Value, error) {
+	if !ns.Valid {
+		return nil, nil
+	}
+	return ns.TerminalRecordingType, nil
+}
+
+func (e TerminalRecordingType) Valid() bool {
+	switch e {
+	case TerminalRecordingTypeSSH,
+		TerminalRecordingTypeReconnectingPTY:
+		return true
+	}
+	return false
+}
+
+func AllTerminalRecordingTypeValues() []TerminalRecordingType {
+	return []TerminalRecordingType{
+		TerminalRecordingTypeSSH,
+		TerminalRecordingTypeReconnectingPTY,
 	}
 }
 
@@ -1231,6 +1310,14 @@ type APIKey struct {
 	TokenName       string      `db:"token_name" json:"token_name"`
 }
 
+// The actions an API key with the resources scope may perform. Each action is limited to a single resource.
+type APIKeyScopeResource struct {
+	APIKeyID     string    `db:"api_key_id" json:"api_key_id"`
+	ResourceType string    `db:"resource_type" json:"resource_type"`
+	ResourceID   uuid.UUID `db:"resource_id" json:"resource_id"`
+	Action       string    `db:"action" json:"action"`
+}
+
 type AuditLog struct {
 	ID               uuid.UUID       `db:"id" json:"id"`
 	Time             time.Time       `db:"time" json:"time"`
@@ -1249,6 +1336,19 @@ type AuditLog struct {
 	ResourceIcon     string          `db:"resource_icon" json:"resource_icon"`
 }
 
+// Roles defined by admins in addition to the built-in roles.
+type CustomRole struct {
+	Name        string `db:"name" json:"name"`
+	DisplayName string `db:"display_name" json:"display_name"`
+	// Organization roles are only assignable in this organization. Site wide roles have no organization.
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+	CreatedAt       time.Time             `db:"created_at" json:"created_at"`
+	UpdatedAt       time.Time             `db:"updated_at" json:"updated_at"`
+}
+
 type File struct {
 	Hash      string    `db:"hash" json:"hash"`
 	CreatedAt time.Time `db:"created_at" json:"created_at"`
@@ -1426,6 +1526,16 @@ type Template struct {
 	// Allow users to cancel in-progress workspace jobs.
 	AllowUserCancelWorkspaceJobs bool  `db:"allow_user_cancel_workspace_jobs" json:"allow_user_cancel_workspace_jobs"`
 	MaxTTL                       int64 `db:"max_ttl" json:"max_ttl"`
+	// The duration a workspace may go unused before it is locked. A value of 0 disables locking.
+	InactivityTTL int64 `db:"inactivity_ttl" json:"inactivity_ttl"`
+	// The duration a workspace may remain locked before it is deleted. A value of 0 disables deletion.
+	LockedTTL int64 `db:"locked_ttl" json:"locked_ttl"`
+	// The duration after which a workspace whose latest start build failed is automatically stopped. A value of 0 disables this.
+	FailureTTL int64 `db:"failure_ttl" json:"failure_ttl"`
+	// Record terminal sessions of workspaces created from this template.
+	RecordTerminalSessions bool `db:"record_terminal_sessions" json:"record_terminal_sessions"`
+	// The most permissive level workspace owners may share ports at.
+	MaxPortSharingLevel AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
 }
 
 type TemplateVersion struct {
@@ -1440,6 +1550,10 @@ type TemplateVersion struct {
 	CreatedBy      uuid.UUID     `db:"created_by" json:"created_by"`
 	// IDs of Git auth providers for a specific template version
 	GitAuthProviders []string `db:"git_auth_providers" json:"git_auth_providers"`
+	// Archived versions are hidden from the version list and can't be used for new builds.
+	Archived bool `db:"archived" json:"archived"`
+	// SHA of the git commit the template version was created from, if it was created from a git repository.
+	GitCommitSHA string `db:"git_commit_sha" json:"git_commit_sha"`
 }
 
 type TemplateVersionParameter struct {
@@ -1472,6 +1586,8 @@ type TemplateVersionParameter struct {
 	Required bool `db:"required" json:"required"`
 	// Name of the legacy variable for migration purposes
 	LegacyVariableName string `db:"legacy_variable_name" json:"legacy_variable_name"`
+	// Is parameter ephemeral? Ephemeral parameters only apply to a single build.
+	Ephemeral bool `db:"ephemeral" json:"ephemeral"`
 }
 
 type TemplateVersionVariable struct {
@@ -1492,6 +1608,24 @@ type TemplateVersionVariable struct {
 	Sensitive bool `db:"sensitive" json:"sensitive"`
 }
 
+// Recordings of workspace terminal sessions in the asciicast v2 format.
+type TerminalRecording struct {
+	ID             uuid.UUID `db:"id" json:"id"`
+	CreatedAt      time.Time `db:"created_at" json:"created_at"`
+	StartedAt      time.Time `db:"started_at" json:"started_at"`
+	EndedAt        time.Time `db:"ended_at" json:"ended_at"`
+	OrganizationID uuid.UUID `db:"organization_id" json:"organization_id"`
+	WorkspaceID    uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentID        uuid.UUID `db:"agent_id" json:"agent_id"`
+	// The owner of the workspace at the time of the recording.
+	UserID uuid.UUID `db:"user_id" json:"user_id"`
+	// The user the client reported as connecting to the session. This is not verified, any client that can reach the agent can set it.
+	ClientReportedUserID uuid.NullUUID         `db:"client_reported_user_id" json:"client_reported_user_id"`
+	Type                 TerminalRecordingType `db:"type" json:"type"`
+	Size                 int64                 `db:"size" json:"size"`
+	Data                 []byte                `db:"data" json:"data"`
+}
+
 type User struct {
 	ID             uuid.UUID      `db:"id" json:"id"`
 	Email          string         `db:"email" json:"email"`
@@ -1528,6 +1662,7 @@ type Workspace struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	LockedAt          sql.NullTime   `db:"locked_at" json:"locked_at"`
 }
 
 type WorkspaceAgent struct {
@@ -1573,6 +1708,31 @@ type WorkspaceAgent struct {
 	StartupLogsLength int32 `db:"startup_logs_length" json:"startup_logs_length"`
 	// Whether the startup logs overflowed in length
 	StartupLogsOverflowed bool `db:"startup_logs_overflowed" json:"startup_logs_overflowed"`
+	// The latest netcheck report of the agent, describing the network of the workspace.
+	Netcheck pqtype.NullRawMessage `db:"netcheck" json:"netcheck"`
+}
+
+type WorkspaceAgentMetadatum struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+// Listening ports that workspace owners share with other users.
+type WorkspaceAgentPortShare struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	// Agents are matched by name so shares persist across workspace builds.
+	AgentName  string          `db:"agent_name" json:"agent_name"`
+	Port       int32           `db:"port" json:"port"`
+	ShareLevel AppSharingLevel `db:"share_level" json:"share_level"`
+	CreatedAt  time.Time       `db:"created_at" json:"created_at"`
+	UpdatedAt  time.Time       `db:"updated_at" json:"updated_at"`
 }
 
 type WorkspaceAgentStartupLog struct {
@@ -1645,6 +1805,22 @@ type WorkspaceBuildParameter struct {
 	Value string `db:"value" json:"value"`
 }
 
+type WorkspaceProxy struct {
+	ID          uuid.UUID `db:"id" json:"id"`
+	Name        string    `db:"name" json:"name"`
+	DisplayName string    `db:"display_name" json:"display_name"`
+	Icon        string    `db:"icon" json:"icon"`
+	// Full url including scheme of the proxy api url: https://us.example.com
+	Url string `db:"url" json:"url"`
+	// Hostname with the wildcard for subdomain based app hosting: *.us.example.com
+	WildcardHostname string    `db:"wildcard_hostname" json:"wildcard_hostname"`
+	CreatedAt        time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt        time.Time `db:"updated_at" json:"updated_at"`
+	Deleted          bool      `db:"deleted" json:"deleted"`
+	// Hashed secret is used to authenticate the workspace proxy using a session token.
+	TokenHashedSecret []byte `db:"token_hashed_secret" json:"token_hashed_secret"`
+}
+
 type WorkspaceResource struct {
 	ID           uuid.UUID           `db:"id" json:"id"`
 	CreatedAt    time.Time           `db:"created_at" json:"created_at"`
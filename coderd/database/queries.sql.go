@@ -111,6 +111,45 @@ func (q *sqlQuerier) GetAPIKeyByName(ctx context.Context, arg GetAPIKeyByNamePar
 	return i, err
 }
 
+const getAPIKeyScopeResourcesByAPIKeyIDs = `-- name: GetAPIKeyScopeResourcesByAPIKeyIDs :many
+SELECT
+	api_key_id, resource_type, resource_id, action
+FROM
+	api_key_scope_resources
+WHERE
+	api_key_id = ANY($1 :: text [ ])
+ORDER BY
+	api_key_id, resource_type, resource_id, action
+`
+
+func (q *sqlQuerier) GetAPIKeyScopeResourcesByAPIKeyIDs(ctx context.Context, ids []string) ([]APIKeyScopeResource, error) {
+	rows, err := q.db.QueryContext(ctx, getAPIKeyScopeResourcesByAPIKeyIDs, pq.Array(ids))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKeyScopeResource
+	for rows.Next() {
+		var i APIKeyScopeResource
+		if err := rows.Scan(
+			&i.APIKeyID,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Action,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAPIKeysByLoginType = `-- name: GetAPIKeysByLoginType :many
 SELECT id, hashed_secret, user_id, last_used, expires_at, created_at, updated_at, login_type, lifetime_seconds, ip_address, scope, token_name FROM api_keys WHERE login_type = $1
 `
@@ -310,6 +349,56 @@ func (q *sqlQuerier) InsertAPIKey(ctx context.Context, arg InsertAPIKeyParams) (
 	return i, err
 }
 
+const insertAPIKeyScopeResources = `-- name: InsertAPIKeyScopeResources :many
+INSERT INTO
+	api_key_scope_resources
+SELECT
+	$1 :: text AS api_key_id,
+	unnest($2 :: text [ ]) AS resource_type,
+	unnest($3 :: uuid [ ]) AS resource_id,
+	unnest($4 :: text [ ]) AS action RETURNING api_key_id, resource_type, resource_id, action
+`
+
+type InsertAPIKeyScopeResourcesParams struct {
+	APIKeyID     string      `db:"api_key_id" json:"api_key_id"`
+	ResourceType []string    `db:"resource_type" json:"resource_type"`
+	ResourceID   []uuid.UUID `db:"resource_id" json:"resource_id"`
+	Action       []string    `db:"action" json:"action"`
+}
+
+func (q *sqlQuerier) InsertAPIKeyScopeResources(ctx context.Context, arg InsertAPIKeyScopeResourcesParams) ([]APIKeyScopeResource, error) {
+	rows, err := q.db.QueryContext(ctx, insertAPIKeyScopeResources,
+		arg.APIKeyID,
+		pq.Array(arg.ResourceType),
+		pq.Array(arg.ResourceID),
+		pq.Array(arg.Action),
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []APIKeyScopeResource
+	for rows.Next() {
+		var i APIKeyScopeResource
+		if err := rows.Scan(
+			&i.APIKeyID,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.Action,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const updateAPIKeyByID = `-- name: UpdateAPIKeyByID :exec
 UPDATE
 	api_keys
@@ -338,6 +427,72 @@ func (q *sqlQuerier) UpdateAPIKeyByID(ctx context.Context, arg UpdateAPIKeyByIDP
 	return err
 }
 
+const deleteOldAuditLogs = `-- name: DeleteOldAuditLogs :many
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			audit_logs
+		WHERE
+			"time" < $1
+		ORDER BY
+			"time" ASC
+		LIMIT
+			$2
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING id, time, user_id, organization_id, ip, user_agent, resource_type, resource_id, resource_target, action, diff, status_code, additional_fields, request_id, resource_icon
+`
+
+type DeleteOldAuditLogsParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+// Deletes at most @limit_count audit logs older than @before, so purges never
+// hold locks on the table for long.
+func (q *sqlQuerier) DeleteOldAuditLogs(ctx context.Context, arg DeleteOldAuditLogsParams) ([]AuditLog, error) {
+	rows, err := q.db.QueryContext(ctx, deleteOldAuditLogs, arg.Before, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []AuditLog
+	for rows.Next() {
+		var i AuditLog
+		if err := rows.Scan(
+			&i.ID,
+			&i.Time,
+			&i.UserID,
+			&i.OrganizationID,
+			&i.Ip,
+			&i.UserAgent,
+			&i.ResourceType,
+			&i.ResourceID,
+			&i.ResourceTarget,
+			&i.Action,
+			&i.Diff,
+			&i.StatusCode,
+			&i.AdditionalFields,
+			&i.RequestID,
+			&i.ResourceIcon,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getAuditLogsOffset = `-- name: GetAuditLogsOffset :many
 SELECT
     audit_logs.id, audit_logs.time, audit_logs.user_id, audit_logs.organization_id, audit_logs.ip, audit_logs.user_agent, audit_logs.resource_type, audit_logs.resource_id, audit_logs.resource_target, audit_logs.action, audit_logs.diff, audit_logs.status_code, audit_logs.additional_fields, audit_logs.request_id, audit_logs.resource_icon,
@@ -617,6 +772,180 @@ func (q *sqlQuerier) InsertAuditLog(ctx context.Context, arg InsertAuditLogParam
 	return i, err
 }
 
+const deleteCustomRole = `-- name: DeleteCustomRole :exec
+DELETE FROM
+	custom_roles
+WHERE
+	name = $1
+	AND organization_id IS NOT DISTINCT FROM $2 :: uuid
+`
+
+type DeleteCustomRoleParams struct {
+	Name           string        `db:"name" json:"name"`
+	OrganizationID uuid.NullUUID `db:"organization_id" json:"organization_id"`
+}
+
+func (q *sqlQuerier) DeleteCustomRole(ctx context.Context, arg DeleteCustomRoleParams) error {
+	_, err := q.db.ExecContext(ctx, deleteCustomRole, arg.Name, arg.OrganizationID)
+	return err
+}
+
+const getCustomRoles = `-- name: GetCustomRoles :many
+SELECT
+	name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+FROM
+	custom_roles
+WHERE
+	organization_id IS NOT DISTINCT FROM $1 :: uuid
+ORDER BY
+	name ASC
+`
+
+// Lists the site wide roles when organization_id is null, or the roles of the
+// given organization otherwise.
+func (q *sqlQuerier) GetCustomRoles(ctx context.Context, organizationID uuid.NullUUID) ([]CustomRole, error) {
+	rows, err := q.db.QueryContext(ctx, getCustomRoles, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomRole
+	for rows.Next() {
+		var i CustomRole
+		if err := rows.Scan(
+			&i.Name,
+			&i.DisplayName,
+			&i.OrganizationID,
+			&i.SitePermissions,
+			&i.OrgPermissions,
+			&i.UserPermissions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getCustomRolesByName = `-- name: GetCustomRolesByName :many
+SELECT
+	name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+FROM
+	custom_roles
+WHERE
+	CASE
+		WHEN organization_id IS NULL THEN name
+		ELSE name || ':' || organization_id :: text
+	END = ANY($1 :: text[])
+`
+
+// Looks up custom roles by the names they're assigned by, which for
+// organization roles are suffixed with the organization ID.
+func (q *sqlQuerier) GetCustomRolesByName(ctx context.Context, lookupRoles []string) ([]CustomRole, error) {
+	rows, err := q.db.QueryContext(ctx, getCustomRolesByName, pq.Array(lookupRoles))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []CustomRole
+	for rows.Next() {
+		var i CustomRole
+		if err := rows.Scan(
+			&i.Name,
+			&i.DisplayName,
+			&i.OrganizationID,
+			&i.SitePermissions,
+			&i.OrgPermissions,
+			&i.UserPermissions,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const upsertCustomRole = `-- name: UpsertCustomRole :one
+INSERT INTO
+	custom_roles (
+		name,
+		display_name,
+		organization_id,
+		site_permissions,
+		org_permissions,
+		user_permissions,
+		created_at,
+		updated_at
+	)
+VALUES
+	(
+		$1,
+		$2,
+		$3,
+		$4,
+		$5,
+		$6,
+		now(),
+		now()
+	)
+ON CONFLICT (name, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000' :: uuid))
+DO UPDATE SET
+	display_name = $2,
+	site_permissions = $4,
+	org_permissions = $5,
+	user_permissions = $6,
+	updated_at = now()
+RETURNING name, display_name, organization_id, site_permissions, org_permissions, user_permissions, created_at, updated_at
+`
+
+type UpsertCustomRoleParams struct {
+	Name            string                `db:"name" json:"name"`
+	DisplayName     string                `db:"display_name" json:"display_name"`
+	OrganizationID  uuid.NullUUID         `db:"organization_id" json:"organization_id"`
+	SitePermissions CustomRolePermissions `db:"site_permissions" json:"site_permissions"`
+	OrgPermissions  CustomRolePermissions `db:"org_permissions" json:"org_permissions"`
+	UserPermissions CustomRolePermissions `db:"user_permissions" json:"user_permissions"`
+}
+
+func (q *sqlQuerier) UpsertCustomRole(ctx context.Context, arg UpsertCustomRoleParams) (CustomRole, error) {
+	row := q.db.QueryRowContext(ctx, upsertCustomRole,
+		arg.Name,
+		arg.DisplayName,
+		arg.OrganizationID,
+		arg.SitePermissions,
+		arg.OrgPermissions,
+		arg.UserPermissions,
+	)
+	var i CustomRole
+	err := row.Scan(
+		&i.Name,
+		&i.DisplayName,
+		&i.OrganizationID,
+		&i.SitePermissions,
+		&i.OrgPermissions,
+		&i.UserPermissions,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+	)
+	return i, err
+}
+
 const getFileByHashAndCreator = `-- name: GetFileByHashAndCreator :one
 SELECT
 	hash, created_at, created_by, mimetype, data, id
@@ -2286,6 +2615,64 @@ func (q *sqlQuerier) InsertProvisionerDaemon(ctx context.Context, arg InsertProv
 	return i, err
 }
 
+const deleteOldProvisionerJobLogs = `-- name: DeleteOldProvisionerJobLogs :many
+DELETE FROM
+	provisioner_job_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			provisioner_job_logs
+		WHERE
+			created_at < $1
+		ORDER BY
+			id ASC
+		LIMIT
+			$2
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING job_id, created_at, source, level, stage, output, id
+`
+
+type DeleteOldProvisionerJobLogsParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+// Deletes at most @limit_count provisioner job logs older than @before, so
+// purges never hold locks on the table for long.
+func (q *sqlQuerier) DeleteOldProvisionerJobLogs(ctx context.Context, arg DeleteOldProvisionerJobLogsParams) ([]ProvisionerJobLog, error) {
+	rows, err := q.db.QueryContext(ctx, deleteOldProvisionerJobLogs, arg.Before, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ProvisionerJobLog
+	for rows.Next() {
+		var i ProvisionerJobLog
+		if err := rows.Scan(
+			&i.JobID,
+			&i.CreatedAt,
+			&i.Source,
+			&i.Level,
+			&i.Stage,
+			&i.Output,
+			&i.ID,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getProvisionerLogsAfterID = `-- name: GetProvisionerLogsAfterID :many
 SELECT
 	job_id, created_at, source, level, stage, output, id
@@ -2734,15 +3121,227 @@ func (q *sqlQuerier) UpdateProvisionerJobWithCompleteByID(ctx context.Context, a
 	return err
 }
 
-const getQuotaAllowanceForUser = `-- name: GetQuotaAllowanceForUser :one
+const getWorkspaceProxies = `-- name: GetWorkspaceProxies :many
 SELECT
-	coalesce(SUM(quota_allowance), 0)::BIGINT
+	id, name, display_name, icon, url, wildcard_hostname, created_at, updated_at, deleted, token_hashed_secret
 FROM
-	group_members gm
-JOIN groups g ON
-	g.id = gm.group_id
+	workspace_proxies
 WHERE
-	user_id = $1
+	deleted = false
+`
+
+func (q *sqlQuerier) GetWorkspaceProxies(ctx context.Context) ([]WorkspaceProxy, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceProxies)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceProxy
+	for rows.Next() {
+		var i WorkspaceProxy
+		if err := rows.Scan(
+			&i.ID,
+			&i.Name,
+			&i.DisplayName,
+			&i.Icon,
+			&i.Url,
+			&i.WildcardHostname,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.Deleted,
+			&i.TokenHashedSecret,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const getWorkspaceProxyByID = `-- name: GetWorkspaceProxyByID :one
+SELECT
+	id, name, display_name, icon, url, wildcard_hostname, created_at, updated_at, deleted, token_hashed_secret
+FROM
+	workspace_proxies
+WHERE
+	id = $1
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceProxyByID(ctx context.Context, id uuid.UUID) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceProxyByID, id)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.TokenHashedSecret,
+	)
+	return i, err
+}
+
+const getWorkspaceProxyByName = `-- name: GetWorkspaceProxyByName :one
+SELECT
+	id, name, display_name, icon, url, wildcard_hostname, created_at, updated_at, deleted, token_hashed_secret
+FROM
+	workspace_proxies
+WHERE
+	lower(name) = lower($1)
+	AND deleted = false
+LIMIT
+	1
+`
+
+func (q *sqlQuerier) GetWorkspaceProxyByName(ctx context.Context, name string) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceProxyByName, name)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.TokenHashedSecret,
+	)
+	return i, err
+}
+
+const insertWorkspaceProxy = `-- name: InsertWorkspaceProxy :one
+INSERT INTO
+	workspace_proxies (
+		id,
+		name,
+		display_name,
+		icon,
+		token_hashed_secret,
+		url,
+		wildcard_hostname,
+		created_at,
+		updated_at,
+		deleted
+	)
+VALUES
+	($1, $2, $3, $4, $5, '', '', $6, $7, false) RETURNING id, name, display_name, icon, url, wildcard_hostname, created_at, updated_at, deleted, token_hashed_secret
+`
+
+type InsertWorkspaceProxyParams struct {
+	ID                uuid.UUID `db:"id" json:"id"`
+	Name              string    `db:"name" json:"name"`
+	DisplayName       string    `db:"display_name" json:"display_name"`
+	Icon              string    `db:"icon" json:"icon"`
+	TokenHashedSecret []byte    `db:"token_hashed_secret" json:"token_hashed_secret"`
+	CreatedAt         time.Time `db:"created_at" json:"created_at"`
+	UpdatedAt         time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceProxy(ctx context.Context, arg InsertWorkspaceProxyParams) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, insertWorkspaceProxy,
+		arg.ID,
+		arg.Name,
+		arg.DisplayName,
+		arg.Icon,
+		arg.TokenHashedSecret,
+		arg.CreatedAt,
+		arg.UpdatedAt,
+	)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.TokenHashedSecret,
+	)
+	return i, err
+}
+
+const registerWorkspaceProxy = `-- name: RegisterWorkspaceProxy :one
+UPDATE
+	workspace_proxies
+SET
+	url = $1,
+	wildcard_hostname = $2,
+	updated_at = Now()
+WHERE
+	id = $3
+RETURNING id, name, display_name, icon, url, wildcard_hostname, created_at, updated_at, deleted, token_hashed_secret
+`
+
+type RegisterWorkspaceProxyParams struct {
+	Url              string    `db:"url" json:"url"`
+	WildcardHostname string    `db:"wildcard_hostname" json:"wildcard_hostname"`
+	ID               uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) RegisterWorkspaceProxy(ctx context.Context, arg RegisterWorkspaceProxyParams) (WorkspaceProxy, error) {
+	row := q.db.QueryRowContext(ctx, registerWorkspaceProxy, arg.Url, arg.WildcardHostname, arg.ID)
+	var i WorkspaceProxy
+	err := row.Scan(
+		&i.ID,
+		&i.Name,
+		&i.DisplayName,
+		&i.Icon,
+		&i.Url,
+		&i.WildcardHostname,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Deleted,
+		&i.TokenHashedSecret,
+	)
+	return i, err
+}
+
+const updateWorkspaceProxyDeleted = `-- name: UpdateWorkspaceProxyDeleted :exec
+UPDATE
+	workspace_proxies
+SET
+	updated_at = Now(),
+	deleted = $1
+WHERE
+	id = $2
+`
+
+type UpdateWorkspaceProxyDeletedParams struct {
+	Deleted bool      `db:"deleted" json:"deleted"`
+	ID      uuid.UUID `db:"id" json:"id"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceProxyDeleted(ctx context.Context, arg UpdateWorkspaceProxyDeletedParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceProxyDeleted, arg.Deleted, arg.ID)
+	return err
+}
+
+const getQuotaAllowanceForUser = `-- name: GetQuotaAllowanceForUser :one
+SELECT
+	coalesce(SUM(quota_allowance), 0)::BIGINT
+FROM
+	group_members gm
+JOIN groups g ON
+	g.id = gm.group_id
+WHERE
+	user_id = $1
 `
 
 func (q *sqlQuerier) GetQuotaAllowanceForUser(ctx context.Context, userID uuid.UUID) (int64, error) {
@@ -2952,6 +3551,17 @@ func (q *sqlQuerier) GetAppSigningKey(ctx context.Context) (string, error) {
 	return value, err
 }
 
+const getAuditFilterRules = `-- name: GetAuditFilterRules :one
+SELECT value FROM site_configs WHERE key = 'audit_filter_rules'
+`
+
+func (q *sqlQuerier) GetAuditFilterRules(ctx context.Context) (string, error) {
+	row := q.db.QueryRowContext(ctx, getAuditFilterRules)
+	var value string
+	err := row.Scan(&value)
+	return value, err
+}
+
 const getDERPMeshKey = `-- name: GetDERPMeshKey :one
 SELECT value FROM site_configs WHERE key = 'derp_mesh_key'
 `
@@ -3034,6 +3644,16 @@ func (q *sqlQuerier) InsertDeploymentID(ctx context.Context, value string) error
 	return err
 }
 
+const insertOrUpdateAuditFilterRules = `-- name: InsertOrUpdateAuditFilterRules :exec
+INSERT INTO site_configs (key, value) VALUES ('audit_filter_rules', $1)
+ON CONFLICT (key) DO UPDATE SET value = $1 WHERE site_configs.key = 'audit_filter_rules'
+`
+
+func (q *sqlQuerier) InsertOrUpdateAuditFilterRules(ctx context.Context, value string) error {
+	_, err := q.db.ExecContext(ctx, insertOrUpdateAuditFilterRules, value)
+	return err
+}
+
 const insertOrUpdateLastUpdateCheck = `-- name: InsertOrUpdateLastUpdateCheck :exec
 INSERT INTO site_configs (key, value) VALUES ('last_update_check', $1)
 ON CONFLICT (key) DO UPDATE SET value = $1 WHERE site_configs.key = 'last_update_check'
@@ -3126,7 +3746,7 @@ func (q *sqlQuerier) GetTemplateAverageBuildTime(ctx context.Context, arg GetTem
 
 const getTemplateByID = `-- name: GetTemplateByID :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 FROM
 	templates
 WHERE
@@ -3156,13 +3776,18 @@ func (q *sqlQuerier) GetTemplateByID(ctx context.Context, id uuid.UUID) (Templat
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
 
 const getTemplateByOrganizationAndName = `-- name: GetTemplateByOrganizationAndName :one
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 FROM
 	templates
 WHERE
@@ -3200,12 +3825,17 @@ func (q *sqlQuerier) GetTemplateByOrganizationAndName(ctx context.Context, arg G
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
 
 const getTemplates = `-- name: GetTemplates :many
-SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl FROM templates
+SELECT id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level FROM templates
 ORDER BY (name, id) ASC
 `
 
@@ -3236,6 +3866,11 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.LockedTTL,
+			&i.FailureTTL,
+			&i.RecordTerminalSessions,
+			&i.MaxPortSharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -3252,7 +3887,7 @@ func (q *sqlQuerier) GetTemplates(ctx context.Context) ([]Template, error) {
 
 const getTemplatesWithFilter = `-- name: GetTemplatesWithFilter :many
 SELECT
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 FROM
 	templates
 WHERE
@@ -3320,6 +3955,11 @@ func (q *sqlQuerier) GetTemplatesWithFilter(ctx context.Context, arg GetTemplate
 			&i.DisplayName,
 			&i.AllowUserCancelWorkspaceJobs,
 			&i.MaxTTL,
+			&i.InactivityTTL,
+			&i.LockedTTL,
+			&i.FailureTTL,
+			&i.RecordTerminalSessions,
+			&i.MaxPortSharingLevel,
 		); err != nil {
 			return nil, err
 		}
@@ -3353,7 +3993,7 @@ INSERT INTO
 		allow_user_cancel_workspace_jobs
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14) RETURNING id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 `
 
 type InsertTemplateParams struct {
@@ -3409,6 +4049,11 @@ func (q *sqlQuerier) InsertTemplate(ctx context.Context, arg InsertTemplateParam
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
@@ -3422,7 +4067,7 @@ SET
 WHERE
 	id = $3
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 `
 
 type UpdateTemplateACLByIDParams struct {
@@ -3452,6 +4097,11 @@ func (q *sqlQuerier) UpdateTemplateACLByID(ctx context.Context, arg UpdateTempla
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
@@ -3507,21 +4157,25 @@ SET
 	name = $4,
 	icon = $5,
 	display_name = $6,
-	allow_user_cancel_workspace_jobs = $7
+	allow_user_cancel_workspace_jobs = $7,
+	record_terminal_sessions = $8,
+	max_port_sharing_level = $9
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 `
 
 type UpdateTemplateMetaByIDParams struct {
-	ID                           uuid.UUID `db:"id" json:"id"`
-	UpdatedAt                    time.Time `db:"updated_at" json:"updated_at"`
-	Description                  string    `db:"description" json:"description"`
-	Name                         string    `db:"name" json:"name"`
-	Icon                         string    `db:"icon" json:"icon"`
-	DisplayName                  string    `db:"display_name" json:"display_name"`
-	AllowUserCancelWorkspaceJobs bool      `db:"allow_user_cancel_workspace_jobs" json:"allow_user_cancel_workspace_jobs"`
+	ID                           uuid.UUID       `db:"id" json:"id"`
+	UpdatedAt                    time.Time       `db:"updated_at" json:"updated_at"`
+	Description                  string          `db:"description" json:"description"`
+	Name                         string          `db:"name" json:"name"`
+	Icon                         string          `db:"icon" json:"icon"`
+	DisplayName                  string          `db:"display_name" json:"display_name"`
+	AllowUserCancelWorkspaceJobs bool            `db:"allow_user_cancel_workspace_jobs" json:"allow_user_cancel_workspace_jobs"`
+	RecordTerminalSessions       bool            `db:"record_terminal_sessions" json:"record_terminal_sessions"`
+	MaxPortSharingLevel          AppSharingLevel `db:"max_port_sharing_level" json:"max_port_sharing_level"`
 }
 
 func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTemplateMetaByIDParams) (Template, error) {
@@ -3533,6 +4187,8 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		arg.Icon,
 		arg.DisplayName,
 		arg.AllowUserCancelWorkspaceJobs,
+		arg.RecordTerminalSessions,
+		arg.MaxPortSharingLevel,
 	)
 	var i Template
 	err := row.Scan(
@@ -3553,6 +4209,11 @@ func (q *sqlQuerier) UpdateTemplateMetaByID(ctx context.Context, arg UpdateTempl
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
@@ -3563,18 +4224,24 @@ UPDATE
 SET
 	updated_at = $2,
 	default_ttl = $3,
-	max_ttl = $4
+	max_ttl = $4,
+	inactivity_ttl = $5,
+	locked_ttl = $6,
+	failure_ttl = $7
 WHERE
 	id = $1
 RETURNING
-	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl
+	id, created_at, updated_at, organization_id, deleted, name, provisioner, active_version_id, description, default_ttl, created_by, icon, user_acl, group_acl, display_name, allow_user_cancel_workspace_jobs, max_ttl, inactivity_ttl, locked_ttl, failure_ttl, record_terminal_sessions, max_port_sharing_level
 `
 
 type UpdateTemplateScheduleByIDParams struct {
-	ID         uuid.UUID `db:"id" json:"id"`
-	UpdatedAt  time.Time `db:"updated_at" json:"updated_at"`
-	DefaultTTL int64     `db:"default_ttl" json:"default_ttl"`
-	MaxTTL     int64     `db:"max_ttl" json:"max_ttl"`
+	ID            uuid.UUID `db:"id" json:"id"`
+	UpdatedAt     time.Time `db:"updated_at" json:"updated_at"`
+	DefaultTTL    int64     `db:"default_ttl" json:"default_ttl"`
+	MaxTTL        int64     `db:"max_ttl" json:"max_ttl"`
+	InactivityTTL int64     `db:"inactivity_ttl" json:"inactivity_ttl"`
+	LockedTTL     int64     `db:"locked_ttl" json:"locked_ttl"`
+	FailureTTL    int64     `db:"failure_ttl" json:"failure_ttl"`
 }
 
 func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateTemplateScheduleByIDParams) (Template, error) {
@@ -3583,6 +4250,9 @@ func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateT
 		arg.UpdatedAt,
 		arg.DefaultTTL,
 		arg.MaxTTL,
+		arg.InactivityTTL,
+		arg.LockedTTL,
+		arg.FailureTTL,
 	)
 	var i Template
 	err := row.Scan(
@@ -3603,12 +4273,17 @@ func (q *sqlQuerier) UpdateTemplateScheduleByID(ctx context.Context, arg UpdateT
 		&i.DisplayName,
 		&i.AllowUserCancelWorkspaceJobs,
 		&i.MaxTTL,
+		&i.InactivityTTL,
+		&i.LockedTTL,
+		&i.FailureTTL,
+		&i.RecordTerminalSessions,
+		&i.MaxPortSharingLevel,
 	)
 	return i, err
 }
 
 const getTemplateVersionParameters = `-- name: GetTemplateVersionParameters :many
-SELECT template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, validation_monotonic, required, legacy_variable_name FROM template_version_parameters WHERE template_version_id = $1
+SELECT template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, validation_monotonic, required, legacy_variable_name, ephemeral FROM template_version_parameters WHERE template_version_id = $1
 `
 
 func (q *sqlQuerier) GetTemplateVersionParameters(ctx context.Context, templateVersionID uuid.UUID) ([]TemplateVersionParameter, error) {
@@ -3636,6 +4311,7 @@ func (q *sqlQuerier) GetTemplateVersionParameters(ctx context.Context, templateV
 			&i.ValidationMonotonic,
 			&i.Required,
 			&i.LegacyVariableName,
+			&i.Ephemeral,
 		); err != nil {
 			return nil, err
 		}
@@ -3667,7 +4343,8 @@ INSERT INTO
         validation_error,
         validation_monotonic,
         required,
-        legacy_variable_name
+        legacy_variable_name,
+        ephemeral
     )
 VALUES
     (
@@ -3685,8 +4362,9 @@ VALUES
         $12,
         $13,
         $14,
-        $15
-    ) RETURNING template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, validation_monotonic, required, legacy_variable_name
+        $15,
+        $16
+    ) RETURNING template_version_id, name, description, type, mutable, default_value, icon, options, validation_regex, validation_min, validation_max, validation_error, validation_monotonic, required, legacy_variable_name, ephemeral
 `
 
 type InsertTemplateVersionParameterParams struct {
@@ -3705,6 +4383,7 @@ type InsertTemplateVersionParameterParams struct {
 	ValidationMonotonic string          `db:"validation_monotonic" json:"validation_monotonic"`
 	Required            bool            `db:"required" json:"required"`
 	LegacyVariableName  string          `db:"legacy_variable_name" json:"legacy_variable_name"`
+	Ephemeral           bool            `db:"ephemeral" json:"ephemeral"`
 }
 
 func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg InsertTemplateVersionParameterParams) (TemplateVersionParameter, error) {
@@ -3724,6 +4403,7 @@ func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg Ins
 		arg.ValidationMonotonic,
 		arg.Required,
 		arg.LegacyVariableName,
+		arg.Ephemeral,
 	)
 	var i TemplateVersionParameter
 	err := row.Scan(
@@ -3742,13 +4422,14 @@ func (q *sqlQuerier) InsertTemplateVersionParameter(ctx context.Context, arg Ins
 		&i.ValidationMonotonic,
 		&i.Required,
 		&i.LegacyVariableName,
+		&i.Ephemeral,
 	)
 	return i, err
 }
 
 const getPreviousTemplateVersion = `-- name: GetPreviousTemplateVersion :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3783,13 +4464,15 @@ func (q *sqlQuerier) GetPreviousTemplateVersion(ctx context.Context, arg GetPrev
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionByID = `-- name: GetTemplateVersionByID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3810,13 +4493,15 @@ func (q *sqlQuerier) GetTemplateVersionByID(ctx context.Context, id uuid.UUID) (
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionByJobID = `-- name: GetTemplateVersionByJobID :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3837,13 +4522,15 @@ func (q *sqlQuerier) GetTemplateVersionByJobID(ctx context.Context, jobID uuid.U
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionByTemplateIDAndName = `-- name: GetTemplateVersionByTemplateIDAndName :one
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3870,13 +4557,15 @@ func (q *sqlQuerier) GetTemplateVersionByTemplateIDAndName(ctx context.Context,
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
 
 const getTemplateVersionsByIDs = `-- name: GetTemplateVersionsByIDs :many
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3903,6 +4592,8 @@ func (q *sqlQuerier) GetTemplateVersionsByIDs(ctx context.Context, ids []uuid.UU
 			&i.JobID,
 			&i.CreatedBy,
 			pq.Array(&i.GitAuthProviders),
+			&i.Archived,
+			&i.GitCommitSHA,
 		); err != nil {
 			return nil, err
 		}
@@ -3919,7 +4610,7 @@ func (q *sqlQuerier) GetTemplateVersionsByIDs(ctx context.Context, ids []uuid.UU
 
 const getTemplateVersionsByTemplateID = `-- name: GetTemplateVersionsByTemplateID :many
 SELECT
-	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 FROM
 	template_versions
 WHERE
@@ -3943,26 +4634,33 @@ WHERE
 		)
 		ELSE true
 	END
+	-- Archived versions are hidden unless explicitly requested.
+	AND CASE
+		WHEN $3 :: boolean THEN true
+		ELSE archived = false
+	END
 ORDER BY
     -- Deterministic and consistent ordering of all rows, even if they share
     -- a timestamp. This is to ensure consistent pagination.
-	(created_at, id) ASC OFFSET $3
+	(created_at, id) ASC OFFSET $4
 LIMIT
 	-- A null limit means "no limit", so 0 means return all
-	NULLIF($4 :: int, 0)
+	NULLIF($5 :: int, 0)
 `
 
 type GetTemplateVersionsByTemplateIDParams struct {
-	TemplateID uuid.UUID `db:"template_id" json:"template_id"`
-	AfterID    uuid.UUID `db:"after_id" json:"after_id"`
-	OffsetOpt  int32     `db:"offset_opt" json:"offset_opt"`
-	LimitOpt   int32     `db:"limit_opt" json:"limit_opt"`
+	TemplateID      uuid.UUID `db:"template_id" json:"template_id"`
+	AfterID         uuid.UUID `db:"after_id" json:"after_id"`
+	IncludeArchived bool      `db:"include_archived" json:"include_archived"`
+	OffsetOpt       int32     `db:"offset_opt" json:"offset_opt"`
+	LimitOpt        int32     `db:"limit_opt" json:"limit_opt"`
 }
 
 func (q *sqlQuerier) GetTemplateVersionsByTemplateID(ctx context.Context, arg GetTemplateVersionsByTemplateIDParams) ([]TemplateVersion, error) {
 	rows, err := q.db.QueryContext(ctx, getTemplateVersionsByTemplateID,
 		arg.TemplateID,
 		arg.AfterID,
+		arg.IncludeArchived,
 		arg.OffsetOpt,
 		arg.LimitOpt,
 	)
@@ -3984,6 +4682,8 @@ func (q *sqlQuerier) GetTemplateVersionsByTemplateID(ctx context.Context, arg Ge
 			&i.JobID,
 			&i.CreatedBy,
 			pq.Array(&i.GitAuthProviders),
+			&i.Archived,
+			&i.GitCommitSHA,
 		); err != nil {
 			return nil, err
 		}
@@ -3999,7 +4699,7 @@ func (q *sqlQuerier) GetTemplateVersionsByTemplateID(ctx context.Context, arg Ge
 }
 
 const getTemplateVersionsCreatedAfter = `-- name: GetTemplateVersionsCreatedAfter :many
-SELECT id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers FROM template_versions WHERE created_at > $1
+SELECT id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha FROM template_versions WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetTemplateVersionsCreatedAfter(ctx context.Context, createdAt time.Time) ([]TemplateVersion, error) {
@@ -4022,6 +4722,8 @@ func (q *sqlQuerier) GetTemplateVersionsCreatedAfter(ctx context.Context, create
 			&i.JobID,
 			&i.CreatedBy,
 			pq.Array(&i.GitAuthProviders),
+			&i.Archived,
+			&i.GitCommitSHA,
 		); err != nil {
 			return nil, err
 		}
@@ -4047,10 +4749,11 @@ INSERT INTO
 		"name",
 		readme,
 		job_id,
-		created_by
+		created_by,
+		git_commit_sha
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 `
 
 type InsertTemplateVersionParams struct {
@@ -4063,6 +4766,7 @@ type InsertTemplateVersionParams struct {
 	Readme         string        `db:"readme" json:"readme"`
 	JobID          uuid.UUID     `db:"job_id" json:"job_id"`
 	CreatedBy      uuid.UUID     `db:"created_by" json:"created_by"`
+	GitCommitSHA   string        `db:"git_commit_sha" json:"git_commit_sha"`
 }
 
 func (q *sqlQuerier) InsertTemplateVersion(ctx context.Context, arg InsertTemplateVersionParams) (TemplateVersion, error) {
@@ -4076,6 +4780,7 @@ func (q *sqlQuerier) InsertTemplateVersion(ctx context.Context, arg InsertTempla
 		arg.Readme,
 		arg.JobID,
 		arg.CreatedBy,
+		arg.GitCommitSHA,
 	)
 	var i TemplateVersion
 	err := row.Scan(
@@ -4089,6 +4794,8 @@ func (q *sqlQuerier) InsertTemplateVersion(ctx context.Context, arg InsertTempla
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
@@ -4101,7 +4808,7 @@ SET
 	updated_at = $3,
 	name = $4
 WHERE
-	id = $1 RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers
+	id = $1 RETURNING id, template_id, organization_id, created_at, updated_at, name, readme, job_id, created_by, git_auth_providers, archived, git_commit_sha
 `
 
 type UpdateTemplateVersionByIDParams struct {
@@ -4130,6 +4837,8 @@ func (q *sqlQuerier) UpdateTemplateVersionByID(ctx context.Context, arg UpdateTe
 		&i.JobID,
 		&i.CreatedBy,
 		pq.Array(&i.GitAuthProviders),
+		&i.Archived,
+		&i.GitCommitSHA,
 	)
 	return i, err
 }
@@ -4155,6 +4864,27 @@ func (q *sqlQuerier) UpdateTemplateVersionDescriptionByJobID(ctx context.Context
 	return err
 }
 
+const updateTemplateVersionArchivedByID = `-- name: UpdateTemplateVersionArchivedByID :exec
+UPDATE
+	template_versions
+SET
+	archived = $2,
+	updated_at = $3
+WHERE
+	id = $1
+`
+
+type UpdateTemplateVersionArchivedByIDParams struct {
+	ID        uuid.UUID `db:"id" json:"id"`
+	Archived  bool      `db:"archived" json:"archived"`
+	UpdatedAt time.Time `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateTemplateVersionArchivedByID(ctx context.Context, arg UpdateTemplateVersionArchivedByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateTemplateVersionArchivedByID, arg.ID, arg.Archived, arg.UpdatedAt)
+	return err
+}
+
 const updateTemplateVersionGitAuthProvidersByJobID = `-- name: UpdateTemplateVersionGitAuthProvidersByJobID :exec
 UPDATE
 	template_versions
@@ -4273,6 +5003,208 @@ func (q *sqlQuerier) InsertTemplateVersionVariable(ctx context.Context, arg Inse
 	return i, err
 }
 
+const getTerminalRecordingByID = `-- name: GetTerminalRecordingByID :one
+SELECT
+	id, created_at, started_at, ended_at, organization_id, workspace_id, agent_id, user_id, client_reported_user_id, type, size, data
+FROM
+	terminal_recordings
+WHERE
+	id = $1
+`
+
+func (q *sqlQuerier) GetTerminalRecordingByID(ctx context.Context, id uuid.UUID) (TerminalRecording, error) {
+	row := q.db.QueryRowContext(ctx, getTerminalRecordingByID, id)
+	var i TerminalRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.OrganizationID,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.UserID,
+		&i.ClientReportedUserID,
+		&i.Type,
+		&i.Size,
+		&i.Data,
+	)
+	return i, err
+}
+
+const getTerminalRecordings = `-- name: GetTerminalRecordings :many
+SELECT
+	id,
+	created_at,
+	started_at,
+	ended_at,
+	organization_id,
+	workspace_id,
+	agent_id,
+	user_id,
+	client_reported_user_id,
+	type,
+	size
+FROM
+	terminal_recordings
+WHERE
+	CASE
+		WHEN $1 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspace_id = $1
+		ELSE true
+	END
+	AND CASE
+		WHEN $2 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			agent_id = $2
+		ELSE true
+	END
+	AND CASE
+		WHEN $3 :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			user_id = $3
+		ELSE true
+	END
+ORDER BY
+	started_at DESC,
+	id
+OFFSET
+	$4
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF($5 :: int, 0)
+`
+
+type GetTerminalRecordingsParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentID     uuid.UUID `db:"agent_id" json:"agent_id"`
+	UserID      uuid.UUID `db:"user_id" json:"user_id"`
+	OffsetOpt   int32     `db:"offset_opt" json:"offset_opt"`
+	LimitOpt    int32     `db:"limit_opt" json:"limit_opt"`
+}
+
+type GetTerminalRecordingsRow struct {
+	ID                   uuid.UUID             `db:"id" json:"id"`
+	CreatedAt            time.Time             `db:"created_at" json:"created_at"`
+	StartedAt            time.Time             `db:"started_at" json:"started_at"`
+	EndedAt              time.Time             `db:"ended_at" json:"ended_at"`
+	OrganizationID       uuid.UUID             `db:"organization_id" json:"organization_id"`
+	WorkspaceID          uuid.UUID             `db:"workspace_id" json:"workspace_id"`
+	AgentID              uuid.UUID             `db:"agent_id" json:"agent_id"`
+	UserID               uuid.UUID             `db:"user_id" json:"user_id"`
+	ClientReportedUserID uuid.NullUUID         `db:"client_reported_user_id" json:"client_reported_user_id"`
+	Type                 TerminalRecordingType `db:"type" json:"type"`
+	Size                 int64                 `db:"size" json:"size"`
+}
+
+// The recording data is omitted, it's downloaded by ID.
+func (q *sqlQuerier) GetTerminalRecordings(ctx context.Context, arg GetTerminalRecordingsParams) ([]GetTerminalRecordingsRow, error) {
+	rows, err := q.db.QueryContext(ctx, getTerminalRecordings,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.UserID,
+		arg.OffsetOpt,
+		arg.LimitOpt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []GetTerminalRecordingsRow
+	for rows.Next() {
+		var i GetTerminalRecordingsRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.StartedAt,
+			&i.EndedAt,
+			&i.OrganizationID,
+			&i.WorkspaceID,
+			&i.AgentID,
+			&i.UserID,
+			&i.ClientReportedUserID,
+			&i.Type,
+			&i.Size,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
+const insertTerminalRecording = `-- name: InsertTerminalRecording :one
+INSERT INTO
+	terminal_recordings (
+		id,
+		created_at,
+		started_at,
+		ended_at,
+		organization_id,
+		workspace_id,
+		agent_id,
+		user_id,
+		client_reported_user_id,
+		type,
+		size,
+		data
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id, created_at, started_at, ended_at, organization_id, workspace_id, agent_id, user_id, client_reported_user_id, type, size, data
+`
+
+type InsertTerminalRecordingParams struct {
+	ID                   uuid.UUID             `db:"id" json:"id"`
+	CreatedAt            time.Time             `db:"created_at" json:"created_at"`
+	StartedAt            time.Time             `db:"started_at" json:"started_at"`
+	EndedAt              time.Time             `db:"ended_at" json:"ended_at"`
+	OrganizationID       uuid.UUID             `db:"organization_id" json:"organization_id"`
+	WorkspaceID          uuid.UUID             `db:"workspace_id" json:"workspace_id"`
+	AgentID              uuid.UUID             `db:"agent_id" json:"agent_id"`
+	UserID               uuid.UUID             `db:"user_id" json:"user_id"`
+	ClientReportedUserID uuid.NullUUID         `db:"client_reported_user_id" json:"client_reported_user_id"`
+	Type                 TerminalRecordingType `db:"type" json:"type"`
+	Size                 int64                 `db:"size" json:"size"`
+	Data                 []byte                `db:"data" json:"data"`
+}
+
+func (q *sqlQuerier) InsertTerminalRecording(ctx context.Context, arg InsertTerminalRecordingParams) (TerminalRecording, error) {
+	row := q.db.QueryRowContext(ctx, insertTerminalRecording,
+		arg.ID,
+		arg.CreatedAt,
+		arg.StartedAt,
+		arg.EndedAt,
+		arg.OrganizationID,
+		arg.WorkspaceID,
+		arg.AgentID,
+		arg.UserID,
+		arg.ClientReportedUserID,
+		arg.Type,
+		arg.Size,
+		arg.Data,
+	)
+	var i TerminalRecording
+	err := row.Scan(
+		&i.ID,
+		&i.CreatedAt,
+		&i.StartedAt,
+		&i.EndedAt,
+		&i.OrganizationID,
+		&i.WorkspaceID,
+		&i.AgentID,
+		&i.UserID,
+		&i.ClientReportedUserID,
+		&i.Type,
+		&i.Size,
+		&i.Data,
+	)
+	return i, err
+}
+
 const getUserLinkByLinkedID = `-- name: GetUserLinkByLinkedID :one
 SELECT
 	user_id, login_type, linked_id, oauth_access_token, oauth_refresh_token, oauth_expiry
@@ -5018,54 +5950,194 @@ func (q *sqlQuerier) UpdateUserRoles(ctx context.Context, arg UpdateUserRolesPar
 	row := q.db.QueryRowContext(ctx, updateUserRoles, pq.Array(arg.GrantedRoles), arg.ID)
 	var i User
 	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Username,
-		&i.HashedPassword,
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const updateUserStatus = `-- name: UpdateUserStatus :one
+UPDATE
+	users
+SET
+	status = $2,
+	updated_at = $3
+WHERE
+	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+`
+
+type UpdateUserStatusParams struct {
+	ID        uuid.UUID  `db:"id" json:"id"`
+	Status    UserStatus `db:"status" json:"status"`
+	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+}
+
+func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error) {
+	row := q.db.QueryRowContext(ctx, updateUserStatus, arg.ID, arg.Status, arg.UpdatedAt)
+	var i User
+	err := row.Scan(
+		&i.ID,
+		&i.Email,
+		&i.Username,
+		&i.HashedPassword,
+		&i.CreatedAt,
+		&i.UpdatedAt,
+		&i.Status,
+		&i.RBACRoles,
+		&i.LoginType,
+		&i.AvatarURL,
+		&i.Deleted,
+		&i.LastSeenAt,
+	)
+	return i, err
+}
+
+const deleteWorkspaceAgentPortShare = `-- name: DeleteWorkspaceAgentPortShare :exec
+DELETE FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+`
+
+type DeleteWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentName   string    `db:"agent_name" json:"agent_name"`
+	Port        int32     `db:"port" json:"port"`
+}
+
+func (q *sqlQuerier) DeleteWorkspaceAgentPortShare(ctx context.Context, arg DeleteWorkspaceAgentPortShareParams) error {
+	_, err := q.db.ExecContext(ctx, deleteWorkspaceAgentPortShare, arg.WorkspaceID, arg.AgentName, arg.Port)
+	return err
+}
+
+const getWorkspaceAgentPortShare = `-- name: GetWorkspaceAgentPortShare :one
+SELECT
+	workspace_id, agent_name, port, share_level, created_at, updated_at
+FROM
+	workspace_agent_port_shares
+WHERE
+	workspace_id = $1
+	AND agent_name = $2
+	AND port = $3
+`
+
+type GetWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	AgentName   string    `db:"agent_name" json:"agent_name"`
+	Port        int32     `db:"port" json:"port"`
+}
+
+func (q *sqlQuerier) GetWorkspaceAgentPortShare(ctx context.Context, arg GetWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
+	row := q.db.QueryRowContext(ctx, getWorkspaceAgentPortShare, arg.WorkspaceID, arg.AgentName, arg.Port)
+	var i WorkspaceAgentPortShare
+	err := row.Scan(
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.Port,
+		&i.ShareLevel,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Status,
-		&i.RBACRoles,
-		&i.LoginType,
-		&i.AvatarURL,
-		&i.Deleted,
-		&i.LastSeenAt,
 	)
 	return i, err
 }
 
-const updateUserStatus = `-- name: UpdateUserStatus :one
-UPDATE
-	users
-SET
-	status = $2,
-	updated_at = $3
+const getWorkspaceAgentPortSharesByWorkspaceID = `-- name: GetWorkspaceAgentPortSharesByWorkspaceID :many
+SELECT
+	workspace_id, agent_name, port, share_level, created_at, updated_at
+FROM
+	workspace_agent_port_shares
 WHERE
-	id = $1 RETURNING id, email, username, hashed_password, created_at, updated_at, status, rbac_roles, login_type, avatar_url, deleted, last_seen_at
+	workspace_id = $1
+ORDER BY
+	agent_name ASC,
+	port ASC
 `
 
-type UpdateUserStatusParams struct {
-	ID        uuid.UUID  `db:"id" json:"id"`
-	Status    UserStatus `db:"status" json:"status"`
-	UpdatedAt time.Time  `db:"updated_at" json:"updated_at"`
+func (q *sqlQuerier) GetWorkspaceAgentPortSharesByWorkspaceID(ctx context.Context, workspaceID uuid.UUID) ([]WorkspaceAgentPortShare, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentPortSharesByWorkspaceID, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentPortShare
+	for rows.Next() {
+		var i WorkspaceAgentPortShare
+		if err := rows.Scan(
+			&i.WorkspaceID,
+			&i.AgentName,
+			&i.Port,
+			&i.ShareLevel,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
 }
 
-func (q *sqlQuerier) UpdateUserStatus(ctx context.Context, arg UpdateUserStatusParams) (User, error) {
-	row := q.db.QueryRowContext(ctx, updateUserStatus, arg.ID, arg.Status, arg.UpdatedAt)
-	var i User
+const upsertWorkspaceAgentPortShare = `-- name: UpsertWorkspaceAgentPortShare :one
+INSERT INTO
+	workspace_agent_port_shares (
+		workspace_id,
+		agent_name,
+		port,
+		share_level,
+		created_at,
+		updated_at
+	)
+VALUES
+	($1, $2, $3, $4, $5, $5)
+ON CONFLICT (workspace_id, agent_name, port)
+DO UPDATE SET
+	share_level = $4,
+	updated_at = $5
+RETURNING workspace_id, agent_name, port, share_level, created_at, updated_at
+`
+
+type UpsertWorkspaceAgentPortShareParams struct {
+	WorkspaceID uuid.UUID       `db:"workspace_id" json:"workspace_id"`
+	AgentName   string          `db:"agent_name" json:"agent_name"`
+	Port        int32           `db:"port" json:"port"`
+	ShareLevel  AppSharingLevel `db:"share_level" json:"share_level"`
+	CreatedAt   time.Time       `db:"created_at" json:"created_at"`
+}
+
+func (q *sqlQuerier) UpsertWorkspaceAgentPortShare(ctx context.Context, arg UpsertWorkspaceAgentPortShareParams) (WorkspaceAgentPortShare, error) {
+	row := q.db.QueryRowContext(ctx, upsertWorkspaceAgentPortShare,
+		arg.WorkspaceID,
+		arg.AgentName,
+		arg.Port,
+		arg.ShareLevel,
+		arg.CreatedAt,
+	)
+	var i WorkspaceAgentPortShare
 	err := row.Scan(
-		&i.ID,
-		&i.Email,
-		&i.Username,
-		&i.HashedPassword,
+		&i.WorkspaceID,
+		&i.AgentName,
+		&i.Port,
+		&i.ShareLevel,
 		&i.CreatedAt,
 		&i.UpdatedAt,
-		&i.Status,
-		&i.RBACRoles,
-		&i.LoginType,
-		&i.AvatarURL,
-		&i.Deleted,
-		&i.LastSeenAt,
 	)
 	return i, err
 }
@@ -5085,7 +6157,7 @@ func (q *sqlQuerier) DeleteOldWorkspaceAgentStartupLogs(ctx context.Context) err
 
 const getWorkspaceAgentByAuthToken = `-- name: GetWorkspaceAgentByAuthToken :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck
 FROM
 	workspace_agents
 WHERE
@@ -5128,13 +6200,14 @@ func (q *sqlQuerier) GetWorkspaceAgentByAuthToken(ctx context.Context, authToken
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.Netcheck,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByID = `-- name: GetWorkspaceAgentByID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck
 FROM
 	workspace_agents
 WHERE
@@ -5175,13 +6248,14 @@ func (q *sqlQuerier) GetWorkspaceAgentByID(ctx context.Context, id uuid.UUID) (W
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.Netcheck,
 	)
 	return i, err
 }
 
 const getWorkspaceAgentByInstanceID = `-- name: GetWorkspaceAgentByInstanceID :one
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck
 FROM
 	workspace_agents
 WHERE
@@ -5224,10 +6298,53 @@ func (q *sqlQuerier) GetWorkspaceAgentByInstanceID(ctx context.Context, authInst
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.Netcheck,
 	)
 	return i, err
 }
 
+const getWorkspaceAgentMetadata = `-- name: GetWorkspaceAgentMetadata :many
+SELECT
+	workspace_agent_id, display_name, key, script, value, error, timeout, interval, collected_at
+FROM
+	workspace_agent_metadata
+WHERE
+	workspace_agent_id = $1
+`
+
+func (q *sqlQuerier) GetWorkspaceAgentMetadata(ctx context.Context, workspaceAgentID uuid.UUID) ([]WorkspaceAgentMetadatum, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspaceAgentMetadata, workspaceAgentID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []WorkspaceAgentMetadatum
+	for rows.Next() {
+		var i WorkspaceAgentMetadatum
+		if err := rows.Scan(
+			&i.WorkspaceAgentID,
+			&i.DisplayName,
+			&i.Key,
+			&i.Script,
+			&i.Value,
+			&i.Error,
+			&i.Timeout,
+			&i.Interval,
+			&i.CollectedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getWorkspaceAgentStartupLogsAfter = `-- name: GetWorkspaceAgentStartupLogsAfter :many
 SELECT
 	agent_id, created_at, output, id
@@ -5275,7 +6392,7 @@ func (q *sqlQuerier) GetWorkspaceAgentStartupLogsAfter(ctx context.Context, arg
 
 const getWorkspaceAgentsByResourceIDs = `-- name: GetWorkspaceAgentsByResourceIDs :many
 SELECT
-	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck
 FROM
 	workspace_agents
 WHERE
@@ -5322,6 +6439,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 			&i.ShutdownScriptTimeoutSeconds,
 			&i.StartupLogsLength,
 			&i.StartupLogsOverflowed,
+			&i.Netcheck,
 		); err != nil {
 			return nil, err
 		}
@@ -5337,7 +6455,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsByResourceIDs(ctx context.Context, ids []
 }
 
 const getWorkspaceAgentsCreatedAfter = `-- name: GetWorkspaceAgentsCreatedAfter :many
-SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed FROM workspace_agents WHERE created_at > $1
+SELECT id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck FROM workspace_agents WHERE created_at > $1
 `
 
 func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, createdAt time.Time) ([]WorkspaceAgent, error) {
@@ -5380,6 +6498,7 @@ func (q *sqlQuerier) GetWorkspaceAgentsCreatedAfter(ctx context.Context, created
 			&i.ShutdownScriptTimeoutSeconds,
 			&i.StartupLogsLength,
 			&i.StartupLogsOverflowed,
+			&i.Netcheck,
 		); err != nil {
 			return nil, err
 		}
@@ -5420,7 +6539,7 @@ INSERT INTO
 		shutdown_script_timeout_seconds
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15, $16, $17, $18, $19, $20, $21) RETURNING id, created_at, updated_at, name, first_connected_at, last_connected_at, disconnected_at, resource_id, auth_token, auth_instance_id, architecture, environment_variables, operating_system, startup_script, instance_metadata, resource_metadata, directory, version, last_connected_replica_id, connection_timeout_seconds, troubleshooting_url, motd_file, lifecycle_state, login_before_ready, startup_script_timeout_seconds, expanded_directory, shutdown_script, shutdown_script_timeout_seconds, startup_logs_length, startup_logs_overflowed, netcheck
 `
 
 type InsertWorkspaceAgentParams struct {
@@ -5503,10 +6622,46 @@ func (q *sqlQuerier) InsertWorkspaceAgent(ctx context.Context, arg InsertWorkspa
 		&i.ShutdownScriptTimeoutSeconds,
 		&i.StartupLogsLength,
 		&i.StartupLogsOverflowed,
+		&i.Netcheck,
 	)
 	return i, err
 }
 
+const insertWorkspaceAgentMetadata = `-- name: InsertWorkspaceAgentMetadata :exec
+INSERT INTO
+	workspace_agent_metadata (
+		workspace_agent_id,
+		display_name,
+		key,
+		script,
+		timeout,
+		interval
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6)
+`
+
+type InsertWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	DisplayName      string    `db:"display_name" json:"display_name"`
+	Key              string    `db:"key" json:"key"`
+	Script           string    `db:"script" json:"script"`
+	Timeout          int64     `db:"timeout" json:"timeout"`
+	Interval         int64     `db:"interval" json:"interval"`
+}
+
+func (q *sqlQuerier) InsertWorkspaceAgentMetadata(ctx context.Context, arg InsertWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, insertWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.DisplayName,
+		arg.Key,
+		arg.Script,
+		arg.Timeout,
+		arg.Interval,
+	)
+	return err
+}
+
 const insertWorkspaceAgentStartupLogs = `-- name: InsertWorkspaceAgentStartupLogs :many
 WITH new_length AS (
 	UPDATE workspace_agents SET
@@ -5614,6 +6769,56 @@ func (q *sqlQuerier) UpdateWorkspaceAgentLifecycleStateByID(ctx context.Context,
 	return err
 }
 
+const updateWorkspaceAgentMetadata = `-- name: UpdateWorkspaceAgentMetadata :exec
+UPDATE
+	workspace_agent_metadata
+SET
+	value = $3,
+	error = $4,
+	collected_at = $5
+WHERE
+	workspace_agent_id = $1
+	AND key = $2
+`
+
+type UpdateWorkspaceAgentMetadataParams struct {
+	WorkspaceAgentID uuid.UUID `db:"workspace_agent_id" json:"workspace_agent_id"`
+	Key              string    `db:"key" json:"key"`
+	Value            string    `db:"value" json:"value"`
+	Error            string    `db:"error" json:"error"`
+	CollectedAt      time.Time `db:"collected_at" json:"collected_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentMetadata(ctx context.Context, arg UpdateWorkspaceAgentMetadataParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentMetadata,
+		arg.WorkspaceAgentID,
+		arg.Key,
+		arg.Value,
+		arg.Error,
+		arg.CollectedAt,
+	)
+	return err
+}
+
+const updateWorkspaceAgentNetcheckByID = `-- name: UpdateWorkspaceAgentNetcheckByID :exec
+UPDATE
+	workspace_agents
+SET
+	netcheck = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceAgentNetcheckByIDParams struct {
+	ID       uuid.UUID             `db:"id" json:"id"`
+	Netcheck pqtype.NullRawMessage `db:"netcheck" json:"netcheck"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceAgentNetcheckByID(ctx context.Context, arg UpdateWorkspaceAgentNetcheckByIDParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceAgentNetcheckByID, arg.ID, arg.Netcheck)
+	return err
+}
+
 const updateWorkspaceAgentStartupByID = `-- name: UpdateWorkspaceAgentStartupByID :exec
 UPDATE
 	workspace_agents
@@ -6285,6 +7490,86 @@ func (q *sqlQuerier) InsertWorkspaceBuildParameters(ctx context.Context, arg Ins
 	return err
 }
 
+const clearOldWorkspaceBuildProvisionerState = `-- name: ClearOldWorkspaceBuildProvisionerState :many
+WITH old_builds AS (
+	SELECT
+		wb.id, wb.workspace_id, wb.build_number, wb.provisioner_state
+	FROM
+		workspace_builds wb
+	WHERE
+		wb.created_at < $1
+		AND wb.provisioner_state IS NOT NULL
+		AND EXISTS (
+			SELECT
+				1
+			FROM
+				workspace_builds newer
+			WHERE
+				newer.workspace_id = wb.workspace_id
+				AND newer.build_number > wb.build_number
+		)
+	ORDER BY
+		wb.created_at ASC
+	LIMIT
+		$2
+	FOR UPDATE OF wb SKIP LOCKED
+)
+UPDATE
+	workspace_builds
+SET
+	provisioner_state = NULL
+FROM
+	old_builds
+WHERE
+	workspace_builds.id = old_builds.id
+RETURNING
+	old_builds.id, old_builds.workspace_id, old_builds.build_number, old_builds.provisioner_state
+`
+
+type ClearOldWorkspaceBuildProvisionerStateParams struct {
+	Before     time.Time `db:"before" json:"before"`
+	LimitCount int32     `db:"limit_count" json:"limit_count"`
+}
+
+type ClearOldWorkspaceBuildProvisionerStateRow struct {
+	ID               uuid.UUID `db:"id" json:"id"`
+	WorkspaceID      uuid.UUID `db:"workspace_id" json:"workspace_id"`
+	BuildNumber      int32     `db:"build_number" json:"build_number"`
+	ProvisionerState []byte    `db:"provisioner_state" json:"provisioner_state"`
+}
+
+// Clears the provisioner state of at most @limit_count builds older than
+// @before that have been superseded by a newer build of the same workspace. The
+// state of the latest build is needed for the next build, so it's never
+// cleared. The cleared state is returned so it can be archived.
+func (q *sqlQuerier) ClearOldWorkspaceBuildProvisionerState(ctx context.Context, arg ClearOldWorkspaceBuildProvisionerStateParams) ([]ClearOldWorkspaceBuildProvisionerStateRow, error) {
+	rows, err := q.db.QueryContext(ctx, clearOldWorkspaceBuildProvisionerState, arg.Before, arg.LimitCount)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []ClearOldWorkspaceBuildProvisionerStateRow
+	for rows.Next() {
+		var i ClearOldWorkspaceBuildProvisionerStateRow
+		if err := rows.Scan(
+			&i.ID,
+			&i.WorkspaceID,
+			&i.BuildNumber,
+			&i.ProvisionerState,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const getLatestWorkspaceBuildByWorkspaceID = `-- name: GetLatestWorkspaceBuildByWorkspaceID :one
 SELECT
 	id, created_at, updated_at, workspace_id, template_version_id, build_number, transition, initiator_id, provisioner_state, job_id, deadline, reason, daily_cost, max_deadline
@@ -7226,7 +8511,7 @@ func (q *sqlQuerier) GetDeploymentWorkspaceStats(ctx context.Context) (GetDeploy
 
 const getWorkspaceByAgentID = `-- name: GetWorkspaceByAgentID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 FROM
 	workspaces
 WHERE
@@ -7269,13 +8554,14 @@ func (q *sqlQuerier) GetWorkspaceByAgentID(ctx context.Context, agentID uuid.UUI
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
 
 const getWorkspaceByID = `-- name: GetWorkspaceByID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 FROM
 	workspaces
 WHERE
@@ -7299,13 +8585,14 @@ func (q *sqlQuerier) GetWorkspaceByID(ctx context.Context, id uuid.UUID) (Worksp
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
 
 const getWorkspaceByOwnerIDAndName = `-- name: GetWorkspaceByOwnerIDAndName :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 FROM
 	workspaces
 WHERE
@@ -7336,13 +8623,14 @@ func (q *sqlQuerier) GetWorkspaceByOwnerIDAndName(ctx context.Context, arg GetWo
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
 
 const getWorkspaceByWorkspaceAppID = `-- name: GetWorkspaceByWorkspaceAppID :one
 SELECT
-	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 FROM
 	workspaces
 WHERE
@@ -7392,13 +8680,14 @@ func (q *sqlQuerier) GetWorkspaceByWorkspaceAppID(ctx context.Context, workspace
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
 
 const getWorkspaces = `-- name: GetWorkspaces :many
 SELECT
-	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, COUNT(*) OVER () as count
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, workspaces.locked_at, COUNT(*) OVER () as count
 FROM
 	workspaces
 LEFT JOIN LATERAL (
@@ -7558,17 +8847,23 @@ WHERE
 			) > 0
 		ELSE true
 	END
+	-- Filter by locked workspaces
+	AND CASE
+		WHEN $10 :: text != '' THEN
+			(workspaces.locked_at IS NOT NULL) = ($10 :: text = 'true')
+		ELSE true
+	END
 	-- Authorize Filter clause will be injected below in GetAuthorizedWorkspaces
 	-- @authorize_filter
 ORDER BY
 	last_used_at DESC
 LIMIT
 	CASE
-		WHEN $11 :: integer > 0 THEN
-			$11
+		WHEN $12 :: integer > 0 THEN
+			$12
 	END
 OFFSET
-	$10
+	$11
 `
 
 type GetWorkspacesParams struct {
@@ -7581,6 +8876,7 @@ type GetWorkspacesParams struct {
 	Name                                  string      `db:"name" json:"name"`
 	HasAgent                              string      `db:"has_agent" json:"has_agent"`
 	AgentInactiveDisconnectTimeoutSeconds int64       `db:"agent_inactive_disconnect_timeout_seconds" json:"agent_inactive_disconnect_timeout_seconds"`
+	Locked                                string      `db:"locked" json:"locked"`
 	Offset                                int32       `db:"offset_" json:"offset_"`
 	Limit                                 int32       `db:"limit_" json:"limit_"`
 }
@@ -7597,6 +8893,7 @@ type GetWorkspacesRow struct {
 	AutostartSchedule sql.NullString `db:"autostart_schedule" json:"autostart_schedule"`
 	Ttl               sql.NullInt64  `db:"ttl" json:"ttl"`
 	LastUsedAt        time.Time      `db:"last_used_at" json:"last_used_at"`
+	LockedAt          sql.NullTime   `db:"locked_at" json:"locked_at"`
 	Count             int64          `db:"count" json:"count"`
 }
 
@@ -7611,6 +8908,7 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 		arg.Name,
 		arg.HasAgent,
 		arg.AgentInactiveDisconnectTimeoutSeconds,
+		arg.Locked,
 		arg.Offset,
 		arg.Limit,
 	)
@@ -7633,6 +8931,7 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 			&i.AutostartSchedule,
 			&i.Ttl,
 			&i.LastUsedAt,
+			&i.LockedAt,
 			&i.Count,
 		); err != nil {
 			return nil, err
@@ -7648,6 +8947,102 @@ func (q *sqlQuerier) GetWorkspaces(ctx context.Context, arg GetWorkspacesParams)
 	return items, nil
 }
 
+const getWorkspacesEligibleForTransition = `-- name: GetWorkspacesEligibleForTransition :many
+SELECT
+	workspaces.id, workspaces.created_at, workspaces.updated_at, workspaces.owner_id, workspaces.organization_id, workspaces.template_id, workspaces.deleted, workspaces.name, workspaces.autostart_schedule, workspaces.ttl, workspaces.last_used_at, workspaces.locked_at
+FROM
+	workspaces
+LEFT JOIN
+	workspace_builds ON workspace_builds.workspace_id = workspaces.id
+INNER JOIN
+	provisioner_jobs ON workspace_builds.job_id = provisioner_jobs.id
+INNER JOIN
+	templates ON workspaces.template_id = templates.id
+WHERE
+	workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = workspaces.id
+	) AND
+	(
+		-- If the workspace build was a start transition, the workspace is
+		-- potentially eligible for autostop if it's past the deadline.
+		(
+			workspace_builds.transition = 'start'::workspace_transition AND
+			workspace_builds.deadline != '0001-01-01 00:00:00+00'::timestamptz AND
+			workspace_builds.deadline < $1 :: timestamptz
+		) OR
+		-- If the workspace build was a stop transition, the workspace is
+		-- potentially eligible for autostart if it has a schedule set.
+		(
+			workspace_builds.transition = 'stop'::workspace_transition AND
+			workspaces.autostart_schedule IS NOT NULL AND
+			workspaces.autostart_schedule != ''
+		) OR
+		-- If the template has an inactivity TTL the workspace may be eligible
+		-- to be locked.
+		(
+			templates.inactivity_ttl > 0 AND
+			workspaces.locked_at IS NULL
+		) OR
+		-- If the template has a locked TTL and the workspace is locked, it may
+		-- be eligible for deletion.
+		(
+			templates.locked_ttl > 0 AND
+			workspaces.locked_at IS NOT NULL
+		) OR
+		-- If the template has a failure TTL and the latest build was a failed
+		-- start, the workspace may be eligible to be stopped.
+		(
+			templates.failure_ttl > 0 AND
+			workspace_builds.transition = 'start'::workspace_transition AND
+			provisioner_jobs.completed_at IS NOT NULL AND
+			provisioner_jobs.error IS NOT NULL AND
+			provisioner_jobs.error != ''
+		)
+	) AND
+	workspaces.deleted = false
+`
+
+func (q *sqlQuerier) GetWorkspacesEligibleForTransition(ctx context.Context, now time.Time) ([]Workspace, error) {
+	rows, err := q.db.QueryContext(ctx, getWorkspacesEligibleForTransition, now)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var items []Workspace
+	for rows.Next() {
+		var i Workspace
+		if err := rows.Scan(
+			&i.ID,
+			&i.CreatedAt,
+			&i.UpdatedAt,
+			&i.OwnerID,
+			&i.OrganizationID,
+			&i.TemplateID,
+			&i.Deleted,
+			&i.Name,
+			&i.AutostartSchedule,
+			&i.Ttl,
+			&i.LastUsedAt,
+			&i.LockedAt,
+		); err != nil {
+			return nil, err
+		}
+		items = append(items, i)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return items, nil
+}
+
 const insertWorkspace = `-- name: InsertWorkspace :one
 INSERT INTO
 	workspaces (
@@ -7662,7 +9057,7 @@ INSERT INTO
 		ttl
 	)
 VALUES
-	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+	($1, $2, $3, $4, $5, $6, $7, $8, $9) RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 `
 
 type InsertWorkspaceParams struct {
@@ -7702,6 +9097,7 @@ func (q *sqlQuerier) InsertWorkspace(ctx context.Context, arg InsertWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
@@ -7714,7 +9110,7 @@ SET
 WHERE
 	id = $1
 	AND deleted = false
-RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at
+RETURNING id, created_at, updated_at, owner_id, organization_id, template_id, deleted, name, autostart_schedule, ttl, last_used_at, locked_at
 `
 
 type UpdateWorkspaceParams struct {
@@ -7737,6 +9133,7 @@ func (q *sqlQuerier) UpdateWorkspace(ctx context.Context, arg UpdateWorkspacePar
 		&i.AutostartSchedule,
 		&i.Ttl,
 		&i.LastUsedAt,
+		&i.LockedAt,
 	)
 	return i, err
 }
@@ -7798,6 +9195,25 @@ func (q *sqlQuerier) UpdateWorkspaceLastUsedAt(ctx context.Context, arg UpdateWo
 	return err
 }
 
+const updateWorkspaceLockedAt = `-- name: UpdateWorkspaceLockedAt :exec
+UPDATE
+	workspaces
+SET
+	locked_at = $2
+WHERE
+	id = $1
+`
+
+type UpdateWorkspaceLockedAtParams struct {
+	ID       uuid.UUID    `db:"id" json:"id"`
+	LockedAt sql.NullTime `db:"locked_at" json:"locked_at"`
+}
+
+func (q *sqlQuerier) UpdateWorkspaceLockedAt(ctx context.Context, arg UpdateWorkspaceLockedAtParams) error {
+	_, err := q.db.ExecContext(ctx, updateWorkspaceLockedAt, arg.ID, arg.LockedAt)
+	return err
+}
+
 const updateWorkspaceTTL = `-- name: UpdateWorkspaceTTL :exec
 UPDATE
 	workspaces
@@ -30,6 +30,25 @@ SELECT * FROM api_keys WHERE login_type = $1;
 -- name: GetAPIKeysByUserID :many
 SELECT * FROM api_keys WHERE login_type = $1 AND user_id = $2;
 
+-- name: GetAPIKeyScopeResourcesByAPIKeyIDs :many
+SELECT
+	*
+FROM
+	api_key_scope_resources
+WHERE
+	api_key_id = ANY(@ids :: text [ ])
+ORDER BY
+	api_key_id, resource_type, resource_id, action;
+
+-- name: InsertAPIKeyScopeResources :many
+INSERT INTO
+	api_key_scope_resources
+SELECT
+	@api_key_id :: text AS api_key_id,
+	unnest(@resource_type :: text [ ]) AS resource_type,
+	unnest(@resource_id :: uuid [ ]) AS resource_id,
+	unnest(@action :: text [ ]) AS action RETURNING *;
+
 -- name: InsertAPIKey :one
 INSERT INTO
 	api_keys (
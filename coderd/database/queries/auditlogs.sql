@@ -120,3 +120,24 @@ INSERT INTO
     )
 VALUES
 	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13, $14, $15) RETURNING *;
+
+-- name: DeleteOldAuditLogs :many
+-- Deletes at most @limit_count audit logs older than @before, so purges never
+-- hold locks on the table for long.
+DELETE FROM
+	audit_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			audit_logs
+		WHERE
+			"time" < @before
+		ORDER BY
+			"time" ASC
+		LIMIT
+			@limit_count
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING *;
@@ -0,0 +1,63 @@
+-- name: GetCustomRoles :many
+-- Lists the site wide roles when organization_id is null, or the roles of the
+-- given organization otherwise.
+SELECT
+	*
+FROM
+	custom_roles
+WHERE
+	organization_id IS NOT DISTINCT FROM sqlc.narg('organization_id') :: uuid
+ORDER BY
+	name ASC;
+
+-- name: GetCustomRolesByName :many
+-- Looks up custom roles by the names they're assigned by, which for
+-- organization roles are suffixed with the organization ID.
+SELECT
+	*
+FROM
+	custom_roles
+WHERE
+	CASE
+		WHEN organization_id IS NULL THEN name
+		ELSE name || ':' || organization_id :: text
+	END = ANY(@lookup_roles :: text[]);
+
+-- name: UpsertCustomRole :one
+INSERT INTO
+	custom_roles (
+		name,
+		display_name,
+		organization_id,
+		site_permissions,
+		org_permissions,
+		user_permissions,
+		created_at,
+		updated_at
+	)
+VALUES
+	(
+		@name,
+		@display_name,
+		@organization_id,
+		@site_permissions,
+		@org_permissions,
+		@user_permissions,
+		now(),
+		now()
+	)
+ON CONFLICT (name, COALESCE(organization_id, '00000000-0000-0000-0000-000000000000' :: uuid))
+DO UPDATE SET
+	display_name = @display_name,
+	site_permissions = @site_permissions,
+	org_permissions = @org_permissions,
+	user_permissions = @user_permissions,
+	updated_at = now()
+RETURNING *;
+
+-- name: DeleteCustomRole :exec
+DELETE FROM
+	custom_roles
+WHERE
+	name = @name
+	AND organization_id IS NOT DISTINCT FROM sqlc.narg('organization_id') :: uuid;
@@ -19,3 +19,24 @@ SELECT
 	unnest(@level :: log_level [ ]) AS LEVEL,
 	unnest(@stage :: VARCHAR(128) [ ]) AS stage,
 	unnest(@output :: VARCHAR(1024) [ ]) AS output RETURNING *;
+
+-- name: DeleteOldProvisionerJobLogs :many
+-- Deletes at most @limit_count provisioner job logs older than @before, so
+-- purges never hold locks on the table for long.
+DELETE FROM
+	provisioner_job_logs
+WHERE
+	id IN (
+		SELECT
+			id
+		FROM
+			provisioner_job_logs
+		WHERE
+			created_at < @before
+		ORDER BY
+			id ASC
+		LIMIT
+			@limit_count
+		FOR UPDATE SKIP LOCKED
+	)
+RETURNING *;
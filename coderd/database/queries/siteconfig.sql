@@ -36,3 +36,10 @@ SELECT value FROM site_configs WHERE key = 'app_signing_key';
 
 -- name: InsertAppSigningKey :exec
 INSERT INTO site_configs (key, value) VALUES ('app_signing_key', $1);
+
+-- name: GetAuditFilterRules :one
+SELECT value FROM site_configs WHERE key = 'audit_filter_rules';
+
+-- name: InsertOrUpdateAuditFilterRules :exec
+INSERT INTO site_configs (key, value) VALUES ('audit_filter_rules', $1)
+ON CONFLICT (key) DO UPDATE SET value = $1 WHERE site_configs.key = 'audit_filter_rules';
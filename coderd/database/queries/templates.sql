@@ -104,7 +104,9 @@ SET
 	name = $4,
 	icon = $5,
 	display_name = $6,
-	allow_user_cancel_workspace_jobs = $7
+	allow_user_cancel_workspace_jobs = $7,
+	record_terminal_sessions = $8,
+	max_port_sharing_level = $9
 WHERE
 	id = $1
 RETURNING
@@ -116,7 +118,10 @@ UPDATE
 SET
 	updated_at = $2,
 	default_ttl = $3,
-	max_ttl = $4
+	max_ttl = $4,
+	inactivity_ttl = $5,
+	locked_ttl = $6,
+	failure_ttl = $7
 WHERE
 	id = $1
 RETURNING
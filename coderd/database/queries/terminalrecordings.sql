@@ -0,0 +1,67 @@
+-- name: InsertTerminalRecording :one
+INSERT INTO
+	terminal_recordings (
+		id,
+		created_at,
+		started_at,
+		ended_at,
+		organization_id,
+		workspace_id,
+		agent_id,
+		user_id,
+		client_reported_user_id,
+		type,
+		size,
+		data
+	)
+VALUES
+	($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING *;
+
+-- name: GetTerminalRecordingByID :one
+SELECT
+	*
+FROM
+	terminal_recordings
+WHERE
+	id = $1;
+
+-- name: GetTerminalRecordings :many
+-- The recording data is omitted, it's downloaded by ID.
+SELECT
+	id,
+	created_at,
+	started_at,
+	ended_at,
+	organization_id,
+	workspace_id,
+	agent_id,
+	user_id,
+	client_reported_user_id,
+	type,
+	size
+FROM
+	terminal_recordings
+WHERE
+	CASE
+		WHEN @workspace_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			workspace_id = @workspace_id
+		ELSE true
+	END
+	AND CASE
+		WHEN @agent_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			agent_id = @agent_id
+		ELSE true
+	END
+	AND CASE
+		WHEN @user_id :: uuid != '00000000-0000-0000-0000-000000000000'::uuid THEN
+			user_id = @user_id
+		ELSE true
+	END
+ORDER BY
+	started_at DESC,
+	id
+OFFSET
+	@offset_opt
+LIMIT
+	-- A null limit means "no limit", so 0 means return all
+	NULLIF(@limit_opt :: int, 0);
@@ -144,3 +144,42 @@ SET
 WHERE
 	id = $1 RETURNING *;
 
+
+-- name: ClearOldWorkspaceBuildProvisionerState :many
+-- Clears the provisioner state of at most @limit_count builds older than
+-- @before that have been superseded by a newer build of the same workspace. The
+-- state of the latest build is needed for the next build, so it's never
+-- cleared. The cleared state is returned so it can be archived.
+WITH old_builds AS (
+	SELECT
+		wb.id, wb.workspace_id, wb.build_number, wb.provisioner_state
+	FROM
+		workspace_builds wb
+	WHERE
+		wb.created_at < @before
+		AND wb.provisioner_state IS NOT NULL
+		AND EXISTS (
+			SELECT
+				1
+			FROM
+				workspace_builds newer
+			WHERE
+				newer.workspace_id = wb.workspace_id
+				AND newer.build_number > wb.build_number
+		)
+	ORDER BY
+		wb.created_at ASC
+	LIMIT
+		@limit_count
+	FOR UPDATE OF wb SKIP LOCKED
+)
+UPDATE
+	workspace_builds
+SET
+	provisioner_state = NULL
+FROM
+	old_builds
+WHERE
+	workspace_builds.id = old_builds.id
+RETURNING
+	old_builds.id, old_builds.workspace_id, old_builds.build_number, old_builds.provisioner_state;
@@ -235,6 +235,12 @@ WHERE
 			) > 0
 		ELSE true
 	END
+	-- Filter by locked workspaces
+	AND CASE
+		WHEN @locked :: text != '' THEN
+			(workspaces.locked_at IS NOT NULL) = (@locked :: text = 'true')
+		ELSE true
+	END
 	-- Authorize Filter clause will be injected below in GetAuthorizedWorkspaces
 	-- @authorize_filter
 ORDER BY
@@ -317,6 +323,14 @@ SET
 WHERE
 	id = $1;
 
+-- name: UpdateWorkspaceLockedAt :exec
+UPDATE
+	workspaces
+SET
+	locked_at = $2
+WHERE
+	id = $1;
+
 -- name: UpdateWorkspaceTTLToBeWithinTemplateMax :exec
 UPDATE
 	workspaces
@@ -392,3 +406,62 @@ SELECT
 	failed_workspaces.count AS failed_workspaces,
 	stopped_workspaces.count AS stopped_workspaces
 FROM pending_workspaces, building_workspaces, running_workspaces, failed_workspaces, stopped_workspaces;
+
+-- name: GetWorkspacesEligibleForTransition :many
+SELECT
+	workspaces.*
+FROM
+	workspaces
+LEFT JOIN
+	workspace_builds ON workspace_builds.workspace_id = workspaces.id
+INNER JOIN
+	provisioner_jobs ON workspace_builds.job_id = provisioner_jobs.id
+INNER JOIN
+	templates ON workspaces.template_id = templates.id
+WHERE
+	workspace_builds.build_number = (
+		SELECT
+			MAX(build_number)
+		FROM
+			workspace_builds
+		WHERE
+			workspace_builds.workspace_id = workspaces.id
+	) AND
+	(
+		-- If the workspace build was a start transition, the workspace is
+		-- potentially eligible for autostop if it's past the deadline.
+		(
+			workspace_builds.transition = 'start'::workspace_transition AND
+			workspace_builds.deadline != '0001-01-01 00:00:00+00'::timestamptz AND
+			workspace_builds.deadline < @now :: timestamptz
+		) OR
+		-- If the workspace build was a stop transition, the workspace is
+		-- potentially eligible for autostart if it has a schedule set.
+		(
+			workspace_builds.transition = 'stop'::workspace_transition AND
+			workspaces.autostart_schedule IS NOT NULL AND
+			workspaces.autostart_schedule != ''
+		) OR
+		-- If the template has an inactivity TTL the workspace may be eligible
+		-- to be locked.
+		(
+			templates.inactivity_ttl > 0 AND
+			workspaces.locked_at IS NULL
+		) OR
+		-- If the template has a locked TTL and the workspace is locked, it may
+		-- be eligible for deletion.
+		(
+			templates.locked_ttl > 0 AND
+			workspaces.locked_at IS NOT NULL
+		) OR
+		-- If the template has a failure TTL and the latest build was a failed
+		-- start, the workspace may be eligible to be stopped.
+		(
+			templates.failure_ttl > 0 AND
+			workspace_builds.transition = 'start'::workspace_transition AND
+			provisioner_jobs.completed_at IS NOT NULL AND
+			provisioner_jobs.error IS NOT NULL AND
+			provisioner_jobs.error != ''
+		)
+	) AND
+	workspaces.deleted = false;
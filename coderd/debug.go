@@ -1,6 +1,13 @@
 package coderd
 
-import "net/http"
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+)
 
 // @Summary Debug Info Wireguard Coordinator
 // @ID debug-info-wireguard-coordinator
@@ -12,3 +19,30 @@ import "net/http"
 func (api *API) debugCoordinator(rw http.ResponseWriter, r *http.Request) {
 	(*api.TailnetCoordinator.Load()).ServeHTTPDebug(rw, r)
 }
+
+// @Summary Debug Info Workspace Agent Netcheck
+// @ID debug-info-workspace-agent-netcheck
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Debug
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceAgentNetcheck
+// @Router /debug/workspace-agents/{workspaceagent}/netcheck [get]
+func (api *API) debugWorkspaceAgentNetcheck(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgentParam(r)
+
+	if !workspaceAgent.Netcheck.Valid {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: "The workspace agent hasn't reported a netcheck yet.",
+		})
+		return
+	}
+	var netcheck codersdk.WorkspaceAgentNetcheck
+	err := json.Unmarshal(workspaceAgent.Netcheck.RawMessage, &netcheck)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, netcheck)
+}
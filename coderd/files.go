@@ -1,6 +1,8 @@
 package coderd
 
 import (
+	"archive/zip"
+	"bytes"
 	"crypto/sha256"
 	"database/sql"
 	"encoding/hex"
@@ -20,6 +22,11 @@ import (
 
 const (
 	tarMimeType = "application/x-tar"
+	zipMimeType = "application/zip"
+
+	// httpFileMaxBytes is the maximum size of an uploaded file, and of the
+	// contents of an uploaded zip archive.
+	httpFileMaxBytes = 10 * (10 << 20)
 )
 
 // @Summary Upload file
@@ -29,7 +36,7 @@ const (
 // @Produce json
 // @Accept application/x-tar
 // @Tags Files
-// @Param Content-Type header string true "Content-Type must be `application/x-tar`" default(application/x-tar)
+// @Param Content-Type header string true "Content-Type must be `application/x-tar` or `application/zip`" default(application/x-tar)
 // @Param file formData file true "File to be uploaded"
 // @Success 201 {object} codersdk.UploadResponse
 // @Router /files [post]
@@ -40,7 +47,7 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 	contentType := r.Header.Get("Content-Type")
 
 	switch contentType {
-	case tarMimeType:
+	case tarMimeType, zipMimeType:
 	default:
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
 			Message: fmt.Sprintf("Unsupported content type header %q.", contentType),
@@ -48,7 +55,7 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	r.Body = http.MaxBytesReader(rw, r.Body, 10*(10<<20))
+	r.Body = http.MaxBytesReader(rw, r.Body, httpFileMaxBytes)
 	data, err := io.ReadAll(r.Body)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -57,6 +64,28 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 		})
 		return
 	}
+
+	// Zip archives are stored as tar so that provisioners don't need to
+	// handle both formats.
+	if contentType == zipMimeType {
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Incomplete .zip archive file.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		data, err = createTarFromZip(zipReader, httpFileMaxBytes)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Failed to convert the .zip archive to .tar.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		contentType = tarMimeType
+	}
 	hashBytes := sha256.Sum256(data)
 	hash := hex.EncodeToString(hashBytes[:])
 	file, err := api.Database.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
@@ -104,6 +133,7 @@ func (api *API) postFile(rw http.ResponseWriter, r *http.Request) {
 // @Security CoderSessionToken
 // @Tags Files
 // @Param fileID path string true "File ID" format(uuid)
+// @Param format query string false "Convert the file to a different format" Enums(zip)
 // @Success 200
 // @Router /files/{fileID} [get]
 func (api *API) fileByID(rw http.ResponseWriter, r *http.Request) {
@@ -138,6 +168,32 @@ func (api *API) fileByID(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	switch format := r.URL.Query().Get("format"); format {
+	case "":
+	case codersdk.FormatZip:
+		if file.Mimetype != tarMimeType {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Only %q files can be converted to %q.", tarMimeType, format),
+			})
+			return
+		}
+		data, err := createZipFromTar(file.Data, httpFileMaxBytes)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error converting file to .zip.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		file.Mimetype = zipMimeType
+		file.Data = data
+	default:
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Unsupported format %q.", format),
+		})
+		return
+	}
+
 	rw.Header().Set("Content-Type", file.Mimetype)
 	rw.WriteHeader(http.StatusOK)
 	_, _ = rw.Write(file.Data)
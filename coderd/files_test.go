@@ -1,8 +1,12 @@
 package coderd_test
 
 import (
+	"archive/tar"
+	"archive/zip"
 	"bytes"
 	"context"
+	"errors"
+	"io"
 	"net/http"
 	"testing"
 
@@ -54,6 +58,62 @@ func TestPostFiles(t *testing.T) {
 		_, err = client.Upload(ctx, codersdk.ContentTypeTar, bytes.NewReader(data))
 		require.NoError(t, err)
 	})
+
+	t.Run("InsertZip", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Upload(ctx, codersdk.ContentTypeZip, bytes.NewReader(createZip(t, map[string]string{
+			"main.tf":             "resource \"null_resource\" \"a\" {}\n",
+			"scripts\\startup.sh": "#!/bin/sh\n",
+		})))
+		require.NoError(t, err)
+
+		// Zip archives are stored as tar.
+		data, contentType, err := client.Download(ctx, resp.ID)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ContentTypeTar, contentType)
+		require.Equal(t, map[string]string{
+			"main.tf":            "resource \"null_resource\" \"a\" {}\n",
+			"scripts/startup.sh": "#!/bin/sh\n",
+		}, readTar(t, data))
+	})
+
+	t.Run("ZipPathTraversal", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		for _, name := range []string{"../main.tf", "/etc/main.tf", "C:\\main.tf", "a/../../main.tf"} {
+			_, err := client.Upload(ctx, codersdk.ContentTypeZip, bytes.NewReader(createZip(t, map[string]string{
+				name: "resource \"null_resource\" \"a\" {}\n",
+			})))
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr, name)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), name)
+		}
+	})
+
+	t.Run("InvalidZip", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.Upload(ctx, codersdk.ContentTypeZip, bytes.NewReader(make([]byte, 1024)))
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
 }
 
 func TestDownload(t *testing.T) {
@@ -87,4 +147,84 @@ func TestDownload(t *testing.T) {
 		require.Len(t, data, 1024)
 		require.Equal(t, codersdk.ContentTypeTar, contentType)
 	})
+	t.Run("Zip", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		files := map[string]string{
+			"main.tf":            "resource \"null_resource\" \"a\" {}\n",
+			"scripts/startup.sh": "#!/bin/sh\n",
+		}
+		resp, err := client.Upload(ctx, codersdk.ContentTypeZip, bytes.NewReader(createZip(t, files)))
+		require.NoError(t, err)
+
+		data, contentType, err := client.DownloadWithFormat(ctx, resp.ID, codersdk.FormatZip)
+		require.NoError(t, err)
+		require.Equal(t, codersdk.ContentTypeZip, contentType)
+		zipReader, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		require.NoError(t, err)
+		actual := map[string]string{}
+		for _, file := range zipReader.File {
+			content, err := file.Open()
+			require.NoError(t, err)
+			data, err := io.ReadAll(content)
+			require.NoError(t, err)
+			actual[file.Name] = string(data)
+		}
+		require.Equal(t, files, actual)
+	})
+
+	t.Run("UnsupportedFormat", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		resp, err := client.Upload(ctx, codersdk.ContentTypeTar, bytes.NewReader(make([]byte, 1024)))
+		require.NoError(t, err)
+		_, _, err = client.DownloadWithFormat(ctx, resp.ID, "rar")
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+func createZip(t *testing.T, files map[string]string) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	writer := zip.NewWriter(&buf)
+	for name, content := range files {
+		file, err := writer.Create(name)
+		require.NoError(t, err)
+		_, err = file.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+	return buf.Bytes()
+}
+
+func readTar(t *testing.T, data []byte) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		files[header.Name] = string(content)
+	}
+	return files
 }
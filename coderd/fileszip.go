@@ -0,0 +1,170 @@
+package coderd
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"errors"
+	"io"
+	"path"
+	"strings"
+
+	"golang.org/x/xerrors"
+)
+
+// createTarFromZip converts a zip archive to a tar archive. Entries that
+// would be extracted outside of the archive root are rejected, and the
+// uncompressed size of the archive may not exceed maxSize.
+func createTarFromZip(zipReader *zip.Reader, maxSize int64) ([]byte, error) {
+	var buf bytes.Buffer
+	tarWriter := tar.NewWriter(&buf)
+	var totalSize int64
+	for _, file := range zipReader.File {
+		name, err := sanitizeArchivePath(file.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+
+		mode := file.Mode()
+		switch {
+		case mode.IsDir():
+			err = tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     name + "/",
+				Mode:     0o755,
+				ModTime:  file.Modified,
+			})
+			if err != nil {
+				return nil, err
+			}
+		case mode.IsRegular():
+			// The size in the zip header can't be trusted, so the actual
+			// amount of data read is limited as well.
+			if file.UncompressedSize64 > uint64(maxSize-totalSize) {
+				return nil, xerrors.Errorf("archive too big, must be <= %d bytes uncompressed", maxSize)
+			}
+			perm := int64(mode.Perm())
+			if perm == 0 {
+				perm = 0o644
+			}
+			err = tarWriter.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeReg,
+				Name:     name,
+				Size:     int64(file.UncompressedSize64),
+				Mode:     perm,
+				ModTime:  file.Modified,
+			})
+			if err != nil {
+				return nil, err
+			}
+			content, err := file.Open()
+			if err != nil {
+				return nil, xerrors.Errorf("open %q: %w", file.Name, err)
+			}
+			written, err := io.Copy(tarWriter, io.LimitReader(content, int64(file.UncompressedSize64)))
+			_ = content.Close()
+			if err != nil {
+				return nil, xerrors.Errorf("read %q: %w", file.Name, err)
+			}
+			if written != int64(file.UncompressedSize64) {
+				return nil, xerrors.Errorf("read %q: unexpected end of file", file.Name)
+			}
+			totalSize += written
+		default:
+			// Symlinks and other special files could point outside of the
+			// template, so they are dropped.
+			continue
+		}
+	}
+	err := tarWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// createZipFromTar converts a tar archive to a zip archive. Only directories
+// and regular files are included.
+func createZipFromTar(tarData []byte, maxSize int64) ([]byte, error) {
+	var buf bytes.Buffer
+	zipWriter := zip.NewWriter(&buf)
+	tarReader := tar.NewReader(bytes.NewReader(tarData))
+	var totalSize int64
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		name, err := sanitizeArchivePath(header.Name)
+		if err != nil {
+			return nil, err
+		}
+		if name == "" {
+			continue
+		}
+
+		switch header.Typeflag {
+		case tar.TypeDir:
+			fileHeader, err := zip.FileInfoHeader(header.FileInfo())
+			if err != nil {
+				return nil, err
+			}
+			fileHeader.Name = name + "/"
+			_, err = zipWriter.CreateHeader(fileHeader)
+			if err != nil {
+				return nil, err
+			}
+		case tar.TypeReg:
+			fileHeader, err := zip.FileInfoHeader(header.FileInfo())
+			if err != nil {
+				return nil, err
+			}
+			fileHeader.Name = name
+			fileHeader.Method = zip.Deflate
+			content, err := zipWriter.CreateHeader(fileHeader)
+			if err != nil {
+				return nil, err
+			}
+			written, err := io.Copy(content, io.LimitReader(tarReader, maxSize-totalSize+1))
+			if err != nil {
+				return nil, err
+			}
+			totalSize += written
+			if totalSize > maxSize {
+				return nil, xerrors.Errorf("archive too big, must be <= %d bytes uncompressed", maxSize)
+			}
+		}
+	}
+	err := zipWriter.Close()
+	if err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// sanitizeArchivePath returns the slash separated, relative form of an
+// archive entry name. Names that escape the archive root are rejected, and
+// the root itself is returned as an empty string.
+func sanitizeArchivePath(name string) (string, error) {
+	name = strings.ReplaceAll(name, "\\", "/")
+	// Archives created on Windows may contain drive letters.
+	if strings.HasPrefix(name, "/") || (len(name) > 1 && name[1] == ':') {
+		return "", xerrors.Errorf("archive entry %q must be a relative path", name)
+	}
+	for _, element := range strings.Split(name, "/") {
+		if element == ".." {
+			return "", xerrors.Errorf("archive entry %q must not contain %q", name, "..")
+		}
+	}
+	name = path.Clean(name)
+	if name == "." {
+		return "", nil
+	}
+	return name, nil
+}
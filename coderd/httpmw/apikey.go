@@ -22,6 +22,7 @@ import (
 	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/rbac/rolestore"
 	"github.com/coder/coder/codersdk"
 )
 
@@ -362,20 +363,61 @@ func ExtractAPIKey(rw http.ResponseWriter, r *http.Request, cfg ExtractAPIKeyCon
 		})
 	}
 
+	// nolint:gocritic // Custom roles are looked up as the system.
+	expandedRoles, err := rolestore.Expand(dbauthz.AsSystemRestricted(ctx), cfg.DB, roles.Roles)
+	if err != nil {
+		return write(http.StatusInternalServerError, codersdk.Response{
+			Message: internalErrorMessage,
+			Detail:  fmt.Sprintf("Internal error expanding user's roles. %s", err.Error()),
+		})
+	}
+
+	var scope rbac.ExpandableScope = rbac.ScopeName(key.Scope)
+	if key.Scope == database.APIKeyScopeResources {
+		// nolint:gocritic // The key's resources are looked up as the system.
+		resources, err := cfg.DB.GetAPIKeyScopeResourcesByAPIKeyIDs(dbauthz.AsSystemRestricted(ctx), []string{key.ID})
+		if err != nil {
+			return write(http.StatusInternalServerError, codersdk.Response{
+				Message: internalErrorMessage,
+				Detail:  fmt.Sprintf("Internal error fetching API key scope. %s", err.Error()),
+			})
+		}
+		scope, err = resourceScope(key.UserID, resources)
+		if err != nil {
+			return write(http.StatusInternalServerError, codersdk.Response{
+				Message: internalErrorMessage,
+				Detail:  fmt.Sprintf("Internal error expanding API key scope. %s", err.Error()),
+			})
+		}
+	}
+
 	// Actor is the user's authorization context.
 	authz := Authorization{
 		Username: roles.Username,
 		Actor: rbac.Subject{
 			ID:     key.UserID.String(),
-			Roles:  rbac.RoleNames(roles.Roles),
+			Roles:  expandedRoles,
 			Groups: roles.Groups,
-			Scope:  rbac.ScopeName(key.Scope),
+			Scope:  scope,
 		},
 	}
 
 	return &key, &authz, true
 }
 
+// resourceScope builds the scope of an API key with the resources scope.
+func resourceScope(userID uuid.UUID, resources []database.APIKeyScopeResource) (rbac.Scope, error) {
+	scoped := make([]rbac.ScopeResource, 0, len(resources))
+	for _, resource := range resources {
+		scoped = append(scoped, rbac.ScopeResource{
+			Type:   resource.ResourceType,
+			ID:     resource.ResourceID,
+			Action: rbac.Action(resource.Action),
+		})
+	}
+	return rbac.ResourceScope(userID, scoped)
+}
+
 // apiTokenFromRequest returns the api token from the request.
 // Find the session token from:
 // 1: The cookie
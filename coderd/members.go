@@ -81,6 +81,10 @@ func (api *API) updateOrganizationMemberRoles(ctx context.Context, args database
 			return database.OrganizationMember{}, xerrors.Errorf("Must only pass roles for org %q", args.OrgID.String())
 		}
 
+		// Custom roles are validated when they're assigned.
+		if !rbac.IsBuiltInRole(r) {
+			continue
+		}
 		if _, err := rbac.RoleByName(r); err != nil {
 			return database.OrganizationMember{}, xerrors.Errorf("%q is not a supported role", r)
 		}
@@ -103,8 +107,7 @@ func convertOrganizationMember(mem database.OrganizationMember) codersdk.Organiz
 	}
 
 	for _, roleName := range mem.Roles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedMember.Roles = append(convertedMember.Roles, convertRole(rbacRole))
+		convertedMember.Roles = append(convertedMember.Roles, convertRoleName(roleName))
 	}
 	return convertedMember
 }
@@ -891,6 +891,7 @@ func (server *Server) CompleteJob(ctx context.Context, completed *proto.Complete
 				ValidationMonotonic: richParameter.ValidationMonotonic,
 				Required:            richParameter.Required,
 				LegacyVariableName:  richParameter.LegacyVariableName,
+				Ephemeral:           richParameter.Ephemeral,
 			})
 			if err != nil {
 				return nil, xerrors.Errorf("insert parameter: %w", err)
@@ -1276,6 +1277,21 @@ func InsertWorkspaceResource(ctx context.Context, db database.Store, jobID uuid.
 		}
 		snapshot.WorkspaceAgents = append(snapshot.WorkspaceAgents, telemetry.ConvertWorkspaceAgent(dbAgent))
 
+		for _, md := range prAgent.Metadata {
+			p := database.InsertWorkspaceAgentMetadataParams{
+				WorkspaceAgentID: agentID,
+				DisplayName:      md.DisplayName,
+				Script:           md.Script,
+				Key:              md.Key,
+				Timeout:          md.Timeout,
+				Interval:         md.Interval,
+			}
+			err := db.InsertWorkspaceAgentMetadata(ctx, p)
+			if err != nil {
+				return xerrors.Errorf("insert agent metadata: %w, params: %+v", err, p)
+			}
+		}
+
 		for _, app := range prAgent.Apps {
 			slug := app.Slug
 			if slug == "" {
@@ -18,6 +18,11 @@ const (
 
 	orgAdmin  string = "organization-admin"
 	orgMember string = "organization-member"
+
+	// customSiteRole and customOrganizationRole stand in for any custom role
+	// in assignRoles, as custom roles aren't known until runtime.
+	customSiteRole         string = "custom-site-role"
+	customOrganizationRole string = "custom-organization-role"
 )
 
 // RoleNames is a list of user assignable role names. The role names must be
@@ -95,6 +100,8 @@ var builtInRoles = map[string]func(orgID string) Role{
 				ResourceRoleAssignment.Type: {ActionRead},
 				// All users can see the provisioner daemons.
 				ResourceProvisionerDaemon.Type: {ActionRead},
+				// All users can see the workspace proxies to pick a region.
+				ResourceWorkspaceProxy.Type: {ActionRead},
 			}),
 			Org: map[string][]Permission{},
 			User: Permissions(map[string][]Action{
@@ -115,6 +122,8 @@ var builtInRoles = map[string]func(orgID string) Role{
 				// are not in.
 				ResourceTemplate.Type: {ActionRead},
 				ResourceAuditLog.Type: {ActionRead},
+				// Terminal recordings are kept for compliance, like audit logs.
+				ResourceTerminalRecording.Type: {ActionRead},
 			}),
 			Org:  map[string][]Permission{},
 			User: []Permission{},
@@ -224,21 +233,24 @@ var assignRoles = map[string]map[string]bool{
 		orgMember: true,
 	},
 	owner: {
-		owner:         true,
-		auditor:       true,
-		member:        true,
-		orgAdmin:      true,
-		orgMember:     true,
-		templateAdmin: true,
-		userAdmin:     true,
+		owner:                  true,
+		auditor:                true,
+		member:                 true,
+		orgAdmin:               true,
+		orgMember:              true,
+		templateAdmin:          true,
+		userAdmin:              true,
+		customSiteRole:         true,
+		customOrganizationRole: true,
 	},
 	userAdmin: {
 		member:    true,
 		orgMember: true,
 	},
 	orgAdmin: {
-		orgAdmin:  true,
-		orgMember: true,
+		orgAdmin:               true,
+		orgMember:              true,
+		customOrganizationRole: true,
 	},
 }
 
@@ -253,6 +265,13 @@ func CanAssignRole(expandable ExpandableRoles, assignedRole string) bool {
 	if err != nil {
 		return false
 	}
+	if _, ok := builtInRoles[assigned]; !ok {
+		// Callers are responsible for checking the custom role exists.
+		assigned = customSiteRole
+		if assignedOrg != "" {
+			assigned = customOrganizationRole
+		}
+	}
 
 	for _, longRole := range roles {
 		role, orgID, err := roleSplit(longRole)
@@ -317,6 +336,49 @@ func rolesByNames(roleNames []string) ([]Role, error) {
 	return roles, nil
 }
 
+// IsBuiltInRole returns true if the role name, with or without an
+// organization ID, is a built-in role. Custom roles must not shadow these.
+func IsBuiltInRole(name string) bool {
+	roleName, _, err := roleSplit(name)
+	if err != nil {
+		return false
+	}
+	_, ok := builtInRoles[roleName]
+	return ok
+}
+
+// CustomRoleName returns the name custom roles are assigned by. Organization
+// roles are suffixed with the organization ID like built-in org roles.
+func CustomRoleName(name string, organizationID uuid.NullUUID) string {
+	if !organizationID.Valid {
+		return name
+	}
+	return roleName(name, organizationID.UUID.String())
+}
+
+// ValidatePermissions returns an error if a permission is for an unknown
+// resource type or action. Wildcards are allowed for both.
+func ValidatePermissions(perms []Permission) error {
+	resourceTypes := map[string]bool{WildcardSymbol: true}
+	for _, resourceType := range ResourceTypes() {
+		resourceTypes[resourceType] = true
+	}
+	actions := map[Action]bool{WildcardSymbol: true}
+	for _, action := range AllActions() {
+		actions[action] = true
+	}
+
+	for _, perm := range perms {
+		if !resourceTypes[perm.ResourceType] {
+			return xerrors.Errorf("unknown resource type %q", perm.ResourceType)
+		}
+		if !actions[perm.Action] {
+			return xerrors.Errorf("unknown action %q", perm.Action)
+		}
+	}
+	return nil
+}
+
 func IsOrgRole(roleName string) (string, bool) {
 	_, orgID, err := roleSplit(roleName)
 	if err == nil && orgID != "" {
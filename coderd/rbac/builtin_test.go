@@ -282,6 +282,33 @@ func TestRolePermissions(t *testing.T) {
 				false: {memberMe, otherOrgAdmin, otherOrgMember, templateAdmin},
 			},
 		},
+		{
+			Name:     "SiteCustomRoles",
+			Actions:  []rbac.Action{rbac.ActionCreate, rbac.ActionRead, rbac.ActionUpdate, rbac.ActionDelete},
+			Resource: rbac.ResourceCustomRole,
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner},
+				false: {memberMe, orgAdmin, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
+		{
+			Name:     "OrgCustomRoles",
+			Actions:  []rbac.Action{rbac.ActionCreate, rbac.ActionRead, rbac.ActionUpdate, rbac.ActionDelete},
+			Resource: rbac.ResourceCustomRole.InOrg(orgID),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgAdmin},
+				false: {memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
+		{
+			Name:     "TerminalRecordings",
+			Actions:  []rbac.Action{rbac.ActionRead},
+			Resource: rbac.ResourceTerminalRecording.WithID(uuid.New()).InOrg(orgID),
+			AuthorizeMap: map[bool][]authSubject{
+				true:  {owner, orgAdmin},
+				false: {memberMe, orgMemberMe, otherOrgAdmin, otherOrgMember, templateAdmin, userAdmin},
+			},
+		},
 	}
 
 	for _, c := range testCases {
@@ -455,3 +482,91 @@ func TestChangeSet(t *testing.T) {
 		})
 	}
 }
+
+func TestCanAssignRole(t *testing.T) {
+	t.Parallel()
+
+	orgID := uuid.New()
+	otherOrg := uuid.New()
+
+	testCases := []struct {
+		Name     string
+		Actor    rbac.RoleNames
+		Assigned string
+		Can      bool
+	}{
+		{Name: "OwnerBuiltIn", Actor: rbac.RoleNames{rbac.RoleOwner()}, Assigned: rbac.RoleTemplateAdmin(), Can: true},
+		{Name: "OwnerCustomSite", Actor: rbac.RoleNames{rbac.RoleOwner()}, Assigned: "custom", Can: true},
+		{Name: "OwnerCustomOrg", Actor: rbac.RoleNames{rbac.RoleOwner()}, Assigned: "custom:" + orgID.String(), Can: true},
+		{Name: "UserAdminCustomSite", Actor: rbac.RoleNames{rbac.RoleUserAdmin()}, Assigned: "custom", Can: false},
+		{Name: "OrgAdminCustomSite", Actor: rbac.RoleNames{rbac.RoleOrgAdmin(orgID)}, Assigned: "custom", Can: false},
+		{Name: "OrgAdminCustomOrg", Actor: rbac.RoleNames{rbac.RoleOrgAdmin(orgID)}, Assigned: "custom:" + orgID.String(), Can: true},
+		{Name: "OrgAdminCustomOtherOrg", Actor: rbac.RoleNames{rbac.RoleOrgAdmin(orgID)}, Assigned: "custom:" + otherOrg.String(), Can: false},
+		{Name: "MemberCustomSite", Actor: rbac.RoleNames{rbac.RoleMember()}, Assigned: "custom", Can: false},
+	}
+
+	for _, c := range testCases {
+		c := c
+		t.Run(c.Name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, c.Can, rbac.CanAssignRole(c.Actor, c.Assigned))
+		})
+	}
+}
+
+// Custom roles are expanded by the caller, and must be evaluated exactly
+// like built-in roles.
+func TestCustomRole(t *testing.T) {
+	t.Parallel()
+
+	auth := rbac.NewAuthorizer(prometheus.NewRegistry())
+	member, err := rbac.RoleByName(rbac.RoleMember())
+	require.NoError(t, err)
+	orgID := uuid.New()
+	subject := rbac.Subject{
+		ID: uuid.NewString(),
+		Roles: rbac.Roles{member, {
+			Name:        "workspace-reader",
+			DisplayName: "Workspace Reader",
+			Site: []rbac.Permission{{
+				ResourceType: rbac.ResourceWorkspace.Type,
+				Action:       rbac.ActionRead,
+			}},
+			Org:  map[string][]rbac.Permission{},
+			User: []rbac.Permission{},
+		}},
+		Scope: rbac.ScopeAll,
+	}
+	require.Equal(t, []string{rbac.RoleMember(), "workspace-reader"}, subject.SafeRoleNames())
+
+	ctx := context.Background()
+	workspace := rbac.ResourceWorkspace.WithID(uuid.New()).InOrg(orgID).WithOwner(uuid.NewString())
+	require.NoError(t, auth.Authorize(ctx, subject, rbac.ActionRead, workspace))
+	require.Error(t, auth.Authorize(ctx, subject, rbac.ActionDelete, workspace))
+
+	// The site wide permission makes SQL filters for workspaces match
+	// everything.
+	prepared, err := auth.Prepare(ctx, subject, rbac.ActionRead, rbac.ResourceWorkspace.Type)
+	require.NoError(t, err)
+	filter, err := prepared.CompileToSQL(ctx, rbac.ConfigWithoutACL())
+	require.NoError(t, err)
+	require.Equal(t, "true", filter)
+}
+
+func TestValidatePermissions(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, rbac.ValidatePermissions([]rbac.Permission{
+		{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionRead},
+		{ResourceType: rbac.WildcardSymbol, Action: rbac.WildcardSymbol, Negate: true},
+	}))
+	require.Error(t, rbac.ValidatePermissions([]rbac.Permission{
+		{ResourceType: "nope", Action: rbac.ActionRead},
+	}))
+	require.Error(t, rbac.ValidatePermissions([]rbac.Permission{
+		{ResourceType: rbac.ResourceSystem.Type, Action: rbac.ActionRead},
+	}))
+	require.Error(t, rbac.ValidatePermissions([]rbac.Permission{
+		{ResourceType: rbac.ResourceTemplate.Type, Action: "explode"},
+	}))
+}
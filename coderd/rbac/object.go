@@ -95,6 +95,16 @@ var (
 		Type: "assign_org_role",
 	}
 
+	// ResourceCustomRole is a role defined by an admin. Site wide roles have
+	// no org, organization roles are in the org they are defined in.
+	//	create = define or change a custom role
+	//	delete = remove a custom role
+	// Custom roles are read with ResourceRoleAssignment and
+	// ResourceOrgRoleAssignment like built-in roles.
+	ResourceCustomRole = Object{
+		Type: "custom_role",
+	}
+
 	// ResourceAPIKey is owned by a user.
 	//	create  = Create a new api key for user
 	//	update  = ??
@@ -155,6 +165,24 @@ var (
 		Type: "replicas",
 	}
 
+	// ResourceWorkspaceProxy is an external workspace proxy that serves
+	// workspace apps in a different region.
+	// 	create/delete = register or remove a workspace proxy
+	// 	read = list workspace proxies and their health
+	// 	update = re-register a workspace proxy
+	ResourceWorkspaceProxy = Object{
+		Type: "workspace_proxy",
+	}
+
+	// ResourceTerminalRecording is a recording of a workspace terminal
+	// session. Recordings belong to the workspace organization, not to the
+	// workspace owner, so members can't read or delete them.
+	// 	create = upload a recording, only done by workspace agents
+	// 	read = list and download recordings
+	ResourceTerminalRecording = Object{
+		Type: "terminal_recording",
+	}
+
 	// ResourceDebugInfo controls access to the debug routes `/api/v2/debug/*`.
 	ResourceDebugInfo = Object{
 		Type: "debug_info",
@@ -166,6 +194,36 @@ var (
 	}
 )
 
+// ResourceTypes returns the types of all resources that can be granted in
+// the permissions of a role. The system pseudo-resource is excluded.
+func ResourceTypes() []string {
+	return []string{
+		ResourceWorkspace.Type,
+		ResourceWorkspaceExecution.Type,
+		ResourceWorkspaceApplicationConnect.Type,
+		ResourceAuditLog.Type,
+		ResourceTemplate.Type,
+		ResourceGroup.Type,
+		ResourceFile.Type,
+		ResourceProvisionerDaemon.Type,
+		ResourceOrganization.Type,
+		ResourceRoleAssignment.Type,
+		ResourceOrgRoleAssignment.Type,
+		ResourceCustomRole.Type,
+		ResourceAPIKey.Type,
+		ResourceUser.Type,
+		ResourceUserData.Type,
+		ResourceOrganizationMember.Type,
+		ResourceLicense.Type,
+		ResourceDeploymentValues.Type,
+		ResourceDeploymentStats.Type,
+		ResourceReplicas.Type,
+		ResourceWorkspaceProxy.Type,
+		ResourceTerminalRecording.Type,
+		ResourceDebugInfo.Type,
+	}
+}
+
 // Object is used to create objects for authz checks when you have none in
 // hand to run the check on.
 // An example is if you want to list all workspaces, you can create a Object
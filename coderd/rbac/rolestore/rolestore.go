@@ -0,0 +1,70 @@
+// Package rolestore expands role names into roles, looking up the roles that
+// aren't built in from the custom roles in the database.
+package rolestore
+
+import (
+	"context"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/rbac"
+)
+
+// Expand returns the roles for the given role names. Built-in roles are
+// expanded by the rbac package, all other roles are looked up as custom roles.
+// Custom roles that don't exist are skipped, as they may have been deleted
+// after they were assigned.
+//
+// The context must be authorized to read custom roles by name, which is
+// usually the system context.
+func Expand(ctx context.Context, db database.Store, names []string) (rbac.Roles, error) {
+	roles := make(rbac.Roles, 0, len(names))
+	var lookup []string
+	for _, name := range names {
+		if !rbac.IsBuiltInRole(name) {
+			lookup = append(lookup, name)
+			continue
+		}
+		role, err := rbac.RoleByName(name)
+		if err != nil {
+			return nil, xerrors.Errorf("expand role %q: %w", name, err)
+		}
+		roles = append(roles, role)
+	}
+	if len(lookup) == 0 {
+		return roles, nil
+	}
+
+	customRoles, err := db.GetCustomRolesByName(ctx, lookup)
+	if err != nil {
+		return nil, xerrors.Errorf("get custom roles: %w", err)
+	}
+	for _, customRole := range customRoles {
+		roles = append(roles, ConvertDBRole(customRole))
+	}
+	return roles, nil
+}
+
+// ConvertDBRole converts a custom role into the role evaluated by rbac.
+// Organization roles only grant permissions in their organization.
+func ConvertDBRole(role database.CustomRole) rbac.Role {
+	converted := rbac.Role{
+		Name:        rbac.CustomRoleName(role.Name, role.OrganizationID),
+		DisplayName: role.DisplayName,
+		Site:        nonNil(role.SitePermissions),
+		Org:         map[string][]rbac.Permission{},
+		User:        nonNil(role.UserPermissions),
+	}
+	if role.OrganizationID.Valid {
+		converted.Org[role.OrganizationID.UUID.String()] = nonNil(role.OrgPermissions)
+	}
+	return converted
+}
+
+func nonNil(perms []rbac.Permission) []rbac.Permission {
+	if perms == nil {
+		return []rbac.Permission{}
+	}
+	return perms
+}
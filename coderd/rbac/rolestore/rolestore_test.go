@@ -0,0 +1,57 @@
+package rolestore_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/coderd/rbac/rolestore"
+)
+
+func TestExpand(t *testing.T) {
+	t.Parallel()
+
+	ctx := context.Background()
+	db := dbfake.New()
+	orgID := uuid.New()
+	_, err := db.UpsertCustomRole(ctx, database.UpsertCustomRoleParams{
+		Name:        "workspace-reader",
+		DisplayName: "Workspace Reader",
+		SitePermissions: database.CustomRolePermissions{{
+			ResourceType: rbac.ResourceWorkspace.Type,
+			Action:       rbac.ActionRead,
+		}},
+	})
+	require.NoError(t, err)
+	_, err = db.UpsertCustomRole(ctx, database.UpsertCustomRoleParams{
+		Name:           "template-editor",
+		DisplayName:    "Template Editor",
+		OrganizationID: uuid.NullUUID{UUID: orgID, Valid: true},
+		OrgPermissions: database.CustomRolePermissions{{
+			ResourceType: rbac.ResourceTemplate.Type,
+			Action:       rbac.ActionUpdate,
+		}},
+	})
+	require.NoError(t, err)
+
+	roles, err := rolestore.Expand(ctx, db, []string{
+		rbac.RoleMember(),
+		"workspace-reader",
+		"template-editor:" + orgID.String(),
+		"deleted",
+	})
+	require.NoError(t, err)
+	require.Equal(t, []string{rbac.RoleMember(), "workspace-reader", "template-editor:" + orgID.String()}, roles.Names())
+
+	require.Equal(t, []rbac.Permission{{ResourceType: rbac.ResourceWorkspace.Type, Action: rbac.ActionRead}}, roles[1].Site)
+	require.Empty(t, roles[1].Org)
+	require.Empty(t, roles[2].Site)
+	require.Equal(t, map[string][]rbac.Permission{
+		orgID.String(): {{ResourceType: rbac.ResourceTemplate.Type, Action: rbac.ActionUpdate}},
+	}, roles[2].Org)
+}
@@ -2,6 +2,7 @@ package rbac
 
 import (
 	"fmt"
+	"sort"
 
 	"github.com/google/uuid"
 
@@ -69,9 +70,94 @@ func WorkspaceAgentScope(workspaceID, ownerID uuid.UUID) Scope {
 const (
 	ScopeAll                ScopeName = "all"
 	ScopeApplicationConnect ScopeName = "application_connect"
+	// ScopeResources can't be expanded by name, as the resources it allows
+	// are stored with the API key. Use ResourceScope instead.
+	ScopeResources ScopeName = "resources"
 )
 
-// TODO: Support passing in scopeID list for allowlisting resources.
+// ScopeResource allows a single action on a single resource.
+type ScopeResource struct {
+	Type   string
+	ID     uuid.UUID
+	Action Action
+}
+
+// ResourceScope returns a scope that only allows the given actions on the
+// given resources. The owner of the scope may always read themselves, as
+// most clients start by fetching the authenticated user.
+//
+// The scope is a single role with an AllowIDList, so the actions allowed on
+// a resource type apply to every listed resource of that type. To avoid
+// granting more than was asked for, all resources of a type must be granted
+// the same actions.
+func ResourceScope(ownerID uuid.UUID, resources []ScopeResource) (Scope, error) {
+	resources = append([]ScopeResource{{
+		Type:   ResourceUser.Type,
+		ID:     ownerID,
+		Action: ActionRead,
+	}}, resources...)
+
+	actions := map[string]map[uuid.UUID]map[Action]bool{}
+	for _, resource := range resources {
+		if resource.Type == WildcardSymbol || string(resource.Action) == WildcardSymbol {
+			return Scope{}, xerrors.New("scoped resources must not use wildcards")
+		}
+		if resource.ID == uuid.Nil {
+			return Scope{}, xerrors.Errorf("scoped %s must have an ID", resource.Type)
+		}
+		if actions[resource.Type] == nil {
+			actions[resource.Type] = map[uuid.UUID]map[Action]bool{}
+		}
+		if actions[resource.Type][resource.ID] == nil {
+			actions[resource.Type][resource.ID] = map[Action]bool{}
+		}
+		actions[resource.Type][resource.ID][resource.Action] = true
+	}
+
+	sitePerms := map[string][]Action{}
+	allowList := []string{}
+	for resourceType, ids := range actions {
+		var typeActions map[Action]bool
+		for id, idActions := range ids {
+			if typeActions != nil && !sameActions(typeActions, idActions) {
+				return Scope{}, xerrors.Errorf("all scoped %s resources must be granted the same actions", resourceType)
+			}
+			typeActions = idActions
+			allowList = append(allowList, id.String())
+		}
+		for action := range typeActions {
+			sitePerms[resourceType] = append(sitePerms[resourceType], action)
+		}
+		sort.Slice(sitePerms[resourceType], func(i, j int) bool {
+			return sitePerms[resourceType][i] < sitePerms[resourceType][j]
+		})
+	}
+	sort.Strings(allowList)
+
+	return Scope{
+		Role: Role{
+			Name:        fmt.Sprintf("Scope_%s", ScopeResources),
+			DisplayName: "Specific actions on specific resources",
+			Site:        Permissions(sitePerms),
+			Org:         map[string][]Permission{},
+			User:        []Permission{},
+		},
+		AllowIDList: allowList,
+	}, nil
+}
+
+func sameActions(a, b map[Action]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for action := range a {
+		if !b[action] {
+			return false
+		}
+	}
+	return true
+}
+
 var builtinScopes = map[ScopeName]Scope{
 	// ScopeAll is a special scope that allows access to all resources. During
 	// authorize checks it is usually not used directly and skips scope checks.
@@ -0,0 +1,74 @@
+package rbac_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/rbac"
+)
+
+func TestResourceScope(t *testing.T) {
+	t.Parallel()
+
+	var (
+		ownerID    = uuid.New()
+		orgID      = uuid.New()
+		templateID = uuid.New()
+		otherID    = uuid.New()
+	)
+
+	t.Run("Authorize", func(t *testing.T) {
+		t.Parallel()
+
+		scope, err := rbac.ResourceScope(ownerID, []rbac.ScopeResource{
+			{Type: rbac.ResourceTemplate.Type, ID: templateID, Action: rbac.ActionRead},
+		})
+		require.NoError(t, err)
+		require.ElementsMatch(t, []string{ownerID.String(), templateID.String()}, scope.AllowIDList)
+
+		subject := rbac.Subject{
+			ID:    ownerID.String(),
+			Roles: rbac.RoleNames{rbac.RoleOwner(), rbac.RoleMember()},
+			Scope: scope,
+		}
+		auth := rbac.NewAuthorizer(prometheus.NewRegistry())
+		ctx := context.Background()
+
+		template := rbac.ResourceTemplate.InOrg(orgID).WithID(templateID)
+		require.NoError(t, auth.Authorize(ctx, subject, rbac.ActionRead, template))
+		require.Error(t, auth.Authorize(ctx, subject, rbac.ActionUpdate, template))
+		require.Error(t, auth.Authorize(ctx, subject, rbac.ActionRead, rbac.ResourceTemplate.InOrg(orgID).WithID(otherID)))
+		require.NoError(t, auth.Authorize(ctx, subject, rbac.ActionRead, rbac.ResourceUser.WithID(ownerID)))
+		require.Error(t, auth.Authorize(ctx, subject, rbac.ActionRead, rbac.ResourceUser.WithID(otherID)))
+
+		// The scope must also compile into SQL filters.
+		prepared, err := auth.Prepare(ctx, subject, rbac.ActionRead, rbac.ResourceTemplate.Type)
+		require.NoError(t, err)
+		_, err = prepared.CompileToSQL(ctx, rbac.ConfigWithACL())
+		require.NoError(t, err)
+	})
+
+	t.Run("DifferentActions", func(t *testing.T) {
+		t.Parallel()
+
+		// Both templates would be granted both actions.
+		_, err := rbac.ResourceScope(ownerID, []rbac.ScopeResource{
+			{Type: rbac.ResourceTemplate.Type, ID: templateID, Action: rbac.ActionRead},
+			{Type: rbac.ResourceTemplate.Type, ID: otherID, Action: rbac.ActionUpdate},
+		})
+		require.Error(t, err)
+	})
+
+	t.Run("Wildcard", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := rbac.ResourceScope(ownerID, []rbac.ScopeResource{
+			{Type: rbac.WildcardSymbol, ID: templateID, Action: rbac.ActionRead},
+		})
+		require.Error(t, err)
+	})
+}
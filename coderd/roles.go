@@ -1,9 +1,16 @@
 package coderd
 
 import (
+	"context"
 	"net/http"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac/rolestore"
 	"github.com/coder/coder/codersdk"
 
 	"github.com/coder/coder/coderd/httpapi"
@@ -28,6 +35,15 @@ func (api *API) assignableSiteRoles(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	roles := rbac.SiteRoles()
+	customRoles, err := api.customRoles(ctx, uuid.NullUUID{})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching custom roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	roles = append(roles, customRoles...)
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Actor.Roles, roles))
 }
 
@@ -52,9 +68,302 @@ func (api *API) assignableOrgRoles(rw http.ResponseWriter, r *http.Request) {
 	}
 
 	roles := rbac.OrganizationRoles(organization.ID)
+	customRoles, err := api.customRoles(ctx, uuid.NullUUID{UUID: organization.ID, Valid: true})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching custom roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	roles = append(roles, customRoles...)
 	httpapi.Write(ctx, rw, http.StatusOK, assignableRoles(actorRoles.Actor.Roles, roles))
 }
 
+// @Summary Get custom site roles
+// @ID get-custom-site-roles
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Members
+// @Success 200 {array} codersdk.CustomRole
+// @Router /users/roles/custom [get]
+func (api *API) customSiteRoles(rw http.ResponseWriter, r *http.Request) {
+	api.listCustomRoles(rw, r, uuid.NullUUID{})
+}
+
+// @Summary Upsert custom site role
+// @ID upsert-custom-site-role
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Members
+// @Param request body codersdk.CustomRole true "Custom role"
+// @Success 200 {object} codersdk.CustomRole
+// @Router /users/roles/custom [put]
+func (api *API) putCustomSiteRole(rw http.ResponseWriter, r *http.Request) {
+	api.upsertCustomRole(rw, r, uuid.NullUUID{})
+}
+
+// @Summary Delete custom site role
+// @ID delete-custom-site-role
+// @Security CoderSessionToken
+// @Tags Members
+// @Param role path string true "Role name"
+// @Success 204
+// @Router /users/roles/custom/{role} [delete]
+func (api *API) deleteCustomSiteRole(rw http.ResponseWriter, r *http.Request) {
+	api.deleteCustomRole(rw, r, uuid.NullUUID{})
+}
+
+// @Summary Get custom organization roles
+// @ID get-custom-organization-roles
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Members
+// @Param organization path string true "Organization ID" format(uuid)
+// @Success 200 {array} codersdk.CustomRole
+// @Router /organizations/{organization}/members/roles/custom [get]
+func (api *API) customOrgRoles(rw http.ResponseWriter, r *http.Request) {
+	organization := httpmw.OrganizationParam(r)
+	api.listCustomRoles(rw, r, uuid.NullUUID{UUID: organization.ID, Valid: true})
+}
+
+// @Summary Upsert custom organization role
+// @ID upsert-custom-organization-role
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Members
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param request body codersdk.CustomRole true "Custom role"
+// @Success 200 {object} codersdk.CustomRole
+// @Router /organizations/{organization}/members/roles/custom [put]
+func (api *API) putCustomOrgRole(rw http.ResponseWriter, r *http.Request) {
+	organization := httpmw.OrganizationParam(r)
+	api.upsertCustomRole(rw, r, uuid.NullUUID{UUID: organization.ID, Valid: true})
+}
+
+// @Summary Delete custom organization role
+// @ID delete-custom-organization-role
+// @Security CoderSessionToken
+// @Tags Members
+// @Param organization path string true "Organization ID" format(uuid)
+// @Param role path string true "Role name"
+// @Success 204
+// @Router /organizations/{organization}/members/roles/custom/{role} [delete]
+func (api *API) deleteCustomOrgRole(rw http.ResponseWriter, r *http.Request) {
+	organization := httpmw.OrganizationParam(r)
+	api.deleteCustomRole(rw, r, uuid.NullUUID{UUID: organization.ID, Valid: true})
+}
+
+func (api *API) listCustomRoles(rw http.ResponseWriter, r *http.Request, organizationID uuid.NullUUID) {
+	ctx := r.Context()
+	roles, err := api.Database.GetCustomRoles(ctx, organizationID)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching custom roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	converted := make([]codersdk.CustomRole, 0, len(roles))
+	for _, role := range roles {
+		converted = append(converted, convertCustomRole(role))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+func (api *API) upsertCustomRole(rw http.ResponseWriter, r *http.Request, organizationID uuid.NullUUID) {
+	ctx := r.Context()
+	var req codersdk.CustomRole
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	var validErrs []codersdk.ValidationError
+	if err := httpapi.NameValid(req.Name); err != nil {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "name", Detail: err.Error()})
+	} else if rbac.IsBuiltInRole(req.Name) {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "name", Detail: "must not be the name of a built-in role"})
+	}
+	if organizationID.Valid && req.OrganizationID != uuid.Nil && req.OrganizationID != organizationID.UUID {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "organization_id", Detail: "must match the organization in the path"})
+	}
+	if !organizationID.Valid && req.OrganizationID != uuid.Nil {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "organization_id", Detail: "must be empty for site wide roles"})
+	}
+	// Organization roles only grant permissions inside their organization,
+	// and site wide roles have no organization to grant permissions in.
+	if organizationID.Valid {
+		if len(req.SitePermissions) > 0 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "site_permissions", Detail: "organization roles can't grant site wide permissions"})
+		}
+		if len(req.UserPermissions) > 0 {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "user_permissions", Detail: "organization roles can't grant user permissions"})
+		}
+	} else if len(req.OrganizationPermissions) > 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "organization_permissions", Detail: "site wide roles can't grant organization permissions"})
+	}
+	sitePerms, err := convertPermissions(req.SitePermissions)
+	if err != nil {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "site_permissions", Detail: err.Error()})
+	}
+	orgPerms, err := convertPermissions(req.OrganizationPermissions)
+	if err != nil {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "organization_permissions", Detail: err.Error()})
+	}
+	userPerms, err := convertPermissions(req.UserPermissions)
+	if err != nil {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "user_permissions", Detail: err.Error()})
+	}
+	if len(validErrs) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Invalid custom role.",
+			Validations: validErrs,
+		})
+		return
+	}
+
+	displayName := req.DisplayName
+	if displayName == "" {
+		displayName = req.Name
+	}
+	role, err := api.Database.UpsertCustomRole(ctx, database.UpsertCustomRoleParams{
+		Name:            req.Name,
+		DisplayName:     displayName,
+		OrganizationID:  organizationID,
+		SitePermissions: sitePerms,
+		OrgPermissions:  orgPerms,
+		UserPermissions: userPerms,
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error saving custom role.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, convertCustomRole(role))
+}
+
+func (api *API) deleteCustomRole(rw http.ResponseWriter, r *http.Request, organizationID uuid.NullUUID) {
+	ctx := r.Context()
+	name := chi.URLParam(r, "role")
+
+	roles, err := api.Database.GetCustomRoles(ctx, organizationID)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching custom roles.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	found := false
+	for _, role := range roles {
+		if role.Name == name {
+			found = true
+			break
+		}
+	}
+	if !found {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+
+	// Users that are assigned a deleted role keep its name in their roles,
+	// but it no longer grants any permissions.
+	err = api.Database.DeleteCustomRole(ctx, database.DeleteCustomRoleParams{
+		Name:           name,
+		OrganizationID: organizationID,
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting custom role.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// customRoles returns the custom roles in the rbac representation, so they
+// can be listed alongside built-in roles.
+func (api *API) customRoles(ctx context.Context, organizationID uuid.NullUUID) ([]rbac.Role, error) {
+	dbRoles, err := api.Database.GetCustomRoles(ctx, organizationID)
+	if err != nil {
+		return nil, err
+	}
+	roles := make([]rbac.Role, 0, len(dbRoles))
+	for _, dbRole := range dbRoles {
+		roles = append(roles, rolestore.ConvertDBRole(dbRole))
+	}
+	return roles, nil
+}
+
+func convertPermissions(perms []codersdk.Permission) (database.CustomRolePermissions, error) {
+	converted := make(database.CustomRolePermissions, 0, len(perms))
+	for _, perm := range perms {
+		converted = append(converted, rbac.Permission{
+			Negate:       perm.Negate,
+			ResourceType: perm.ResourceType,
+			Action:       rbac.Action(perm.Action),
+		})
+	}
+	err := rbac.ValidatePermissions(converted)
+	if err != nil {
+		return nil, err
+	}
+	return converted, nil
+}
+
+func convertCustomRole(role database.CustomRole) codersdk.CustomRole {
+	convert := func(perms []rbac.Permission) []codersdk.Permission {
+		converted := make([]codersdk.Permission, 0, len(perms))
+		for _, perm := range perms {
+			converted = append(converted, codersdk.Permission{
+				Negate:       perm.Negate,
+				ResourceType: perm.ResourceType,
+				Action:       string(perm.Action),
+			})
+		}
+		return converted
+	}
+	return codersdk.CustomRole{
+		Name:                    role.Name,
+		DisplayName:             role.DisplayName,
+		OrganizationID:          role.OrganizationID.UUID,
+		SitePermissions:         convert(role.SitePermissions),
+		OrganizationPermissions: convert(role.OrgPermissions),
+		UserPermissions:         convert(role.UserPermissions),
+	}
+}
+
+// convertRoleName converts the name of an assigned role. Custom roles aren't
+// built in, so only their name is known.
+func convertRoleName(name string) codersdk.Role {
+	role, err := rbac.RoleByName(name)
+	if err != nil {
+		return codersdk.Role{Name: name, DisplayName: name}
+	}
+	return convertRole(role)
+}
+
 func convertRole(role rbac.Role) codersdk.Role {
 	return codersdk.Role{
 		DisplayName: role.DisplayName,
@@ -162,3 +162,134 @@ func convertRoles(assignableRoles map[string]bool) []codersdk.AssignableRoles {
 	}
 	return converted
 }
+
+func TestCustomRoles(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, nil)
+	admin := coderdtest.CreateFirstUser(t, client)
+	member, memberUser := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID)
+	orgAdmin, _ := coderdtest.CreateAnotherUser(t, client, admin.OrganizationID, rbac.RoleOrgAdmin(admin.OrganizationID))
+
+	can := func(ctx context.Context, t *testing.T, action, resourceType, orgID string) bool {
+		t.Helper()
+		resp, err := member.AuthCheck(ctx, codersdk.AuthorizationRequest{
+			Checks: map[string]codersdk.AuthorizationCheck{
+				"check": {
+					Object: codersdk.AuthorizationObject{
+						ResourceType:   resourceType,
+						OrganizationID: orgID,
+					},
+					Action: action,
+				},
+			},
+		})
+		require.NoError(t, err)
+		return resp["check"]
+	}
+
+	t.Run("Site", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		role, err := client.UpsertCustomSiteRole(ctx, codersdk.CustomRole{
+			Name:        "auditor-lite",
+			DisplayName: "Audit Log Viewer",
+			SitePermissions: []codersdk.Permission{{
+				ResourceType: rbac.ResourceAuditLog.Type,
+				Action:       string(rbac.ActionRead),
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, "Audit Log Viewer", role.DisplayName)
+
+		roles, err := client.CustomSiteRoles(ctx)
+		require.NoError(t, err)
+		require.Len(t, roles, 1)
+
+		require.False(t, can(ctx, t, "read", rbac.ResourceAuditLog.Type, ""))
+		user, err := client.UpdateUserRoles(ctx, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{role.Name},
+		})
+		require.NoError(t, err)
+		require.Contains(t, user.Roles, codersdk.Role{Name: role.Name, DisplayName: role.Name})
+		require.True(t, can(ctx, t, "read", rbac.ResourceAuditLog.Type, ""))
+
+		assignable, err := client.ListSiteRoles(ctx)
+		require.NoError(t, err)
+		require.Contains(t, assignable, codersdk.AssignableRoles{
+			Role:       codersdk.Role{Name: role.Name, DisplayName: role.DisplayName},
+			Assignable: true,
+		})
+
+		// Deleted roles stop granting permissions, even while assigned.
+		err = client.DeleteCustomSiteRole(ctx, role.Name)
+		require.NoError(t, err)
+		require.False(t, can(ctx, t, "read", rbac.ResourceAuditLog.Type, ""))
+	})
+
+	t.Run("Organization", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		orgID := admin.OrganizationID.String()
+		role, err := orgAdmin.UpsertCustomOrganizationRole(ctx, admin.OrganizationID, codersdk.CustomRole{
+			Name: "template-editor",
+			OrganizationPermissions: []codersdk.Permission{{
+				ResourceType: rbac.ResourceTemplate.Type,
+				Action:       string(rbac.ActionUpdate),
+			}},
+		})
+		require.NoError(t, err)
+		require.Equal(t, admin.OrganizationID, role.OrganizationID)
+
+		require.False(t, can(ctx, t, "update", rbac.ResourceTemplate.Type, orgID))
+		_, err = orgAdmin.UpdateOrganizationMemberRoles(ctx, admin.OrganizationID, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{role.Name + ":" + orgID},
+		})
+		require.NoError(t, err)
+		require.True(t, can(ctx, t, "update", rbac.ResourceTemplate.Type, orgID))
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		for _, role := range []codersdk.CustomRole{
+			{Name: rbac.RoleOwner()},
+			{Name: "bad", SitePermissions: []codersdk.Permission{{ResourceType: "nope", Action: "read"}}},
+			{Name: "bad", SitePermissions: []codersdk.Permission{{ResourceType: "workspace", Action: "nope"}}},
+			{Name: "bad", OrganizationPermissions: []codersdk.Permission{{ResourceType: "workspace", Action: "read"}}},
+		} {
+			_, err := client.UpsertCustomSiteRole(ctx, role)
+			var apiErr *codersdk.Error
+			require.ErrorAs(t, err, &apiErr)
+			require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		}
+
+		_, err := client.UpdateUserRoles(ctx, memberUser.ID.String(), codersdk.UpdateRoles{
+			Roles: []string{"does-not-exist"},
+		})
+		require.Error(t, err)
+
+		err = client.DeleteCustomSiteRole(ctx, "does-not-exist")
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		_, err := member.UpsertCustomSiteRole(ctx, codersdk.CustomRole{Name: "sneaky"})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+
+		// Organization admins can't define site wide roles.
+		_, err = orgAdmin.UpsertCustomSiteRole(ctx, codersdk.CustomRole{Name: "sneaky"})
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+	})
+}
@@ -17,6 +17,16 @@ type TemplateScheduleOptions struct {
 	//
 	// If set, users cannot disable automatic workspace shutdown.
 	MaxTTL time.Duration `json:"max_ttl"`
+	// If InactivityTTL is set, workspaces that have not been used for this
+	// long are locked. A locked workspace cannot be started until it is
+	// explicitly unlocked.
+	InactivityTTL time.Duration `json:"inactivity_ttl"`
+	// If LockedTTL is set, workspaces that have been locked for this long are
+	// deleted.
+	LockedTTL time.Duration `json:"locked_ttl"`
+	// If FailureTTL is set, workspaces whose latest start build failed are
+	// stopped once the failed job is older than this duration.
+	FailureTTL time.Duration `json:"failure_ttl"`
 }
 
 // TemplateScheduleStore provides an interface for retrieving template
@@ -43,9 +53,12 @@ func (*agplTemplateScheduleStore) GetTemplateScheduleOptions(ctx context.Context
 	return TemplateScheduleOptions{
 		UserSchedulingEnabled: true,
 		DefaultTTL:            time.Duration(tpl.DefaultTTL),
-		// Disregard the value in the database, since MaxTTL is an enterprise
-		// feature.
-		MaxTTL: 0,
+		// Disregard the values in the database, since MaxTTL, InactivityTTL,
+		// LockedTTL and FailureTTL are enterprise features.
+		MaxTTL:        0,
+		InactivityTTL: 0,
+		LockedTTL:     0,
+		FailureTTL:    0,
 	}, nil
 }
 
@@ -54,8 +67,11 @@ func (*agplTemplateScheduleStore) SetTemplateScheduleOptions(ctx context.Context
 		ID:         tpl.ID,
 		UpdatedAt:  database.Now(),
 		DefaultTTL: int64(opts.DefaultTTL),
-		// Don't allow changing it, but keep the value in the DB (to avoid
+		// Don't allow changing these, but keep the values in the DB (to avoid
 		// clearing settings if the license has an issue).
-		MaxTTL: tpl.MaxTTL,
+		MaxTTL:        tpl.MaxTTL,
+		InactivityTTL: tpl.InactivityTTL,
+		LockedTTL:     tpl.LockedTTL,
+		FailureTTL:    tpl.FailureTTL,
 	})
 }
@@ -3,6 +3,7 @@ package searchquery
 import (
 	"fmt"
 	"net/url"
+	"strconv"
 	"strings"
 	"time"
 
@@ -104,6 +105,13 @@ func Workspaces(query string, page codersdk.Pagination, agentInactiveDisconnectT
 	filter.Name = parser.String(values, "", "name")
 	filter.Status = string(httpapi.ParseCustom(parser, values, "", "status", httpapi.ParseEnum[database.WorkspaceStatus]))
 	filter.HasAgent = parser.String(values, "", "has-agent")
+	filter.Locked = httpapi.ParseCustom(parser, values, "", "locked", func(v string) (string, error) {
+		locked, err := strconv.ParseBool(v)
+		if err != nil {
+			return "", xerrors.Errorf("%q is not a valid boolean", v)
+		}
+		return strconv.FormatBool(locked), nil
+	})
 	parser.ErrorExcessParams(values)
 	return filter, parser.Errors
 }
@@ -115,6 +115,13 @@ func TestSearchWorkspace(t *testing.T) {
 				OwnerUsername: "foo",
 			},
 		},
+		{
+			Name:  "Locked",
+			Query: `locked:true`,
+			Expected: database.GetWorkspacesParams{
+				Locked: "true",
+			},
+		},
 
 		// Failures
 		{
@@ -137,6 +144,11 @@ func TestSearchWorkspace(t *testing.T) {
 			Query:                 `owner:name:extra`,
 			ExpectedErrorContains: "can only contain 1 ':'",
 		},
+		{
+			Name:                  "InvalidLocked",
+			Query:                 `locked:maybe`,
+			ExpectedErrorContains: `"maybe" is not a valid boolean`,
+		},
 		{
 			Name:                  "ExtraKeys",
 			Query:                 `foo:bar`,
@@ -463,6 +463,22 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 	if req.MaxTTLMillis != 0 && req.DefaultTTLMillis > req.MaxTTLMillis {
 		validErrs = append(validErrs, codersdk.ValidationError{Field: "default_ttl_ms", Detail: "Must be less than or equal to max_ttl_ms if max_ttl_ms is set."})
 	}
+	if req.InactivityTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "inactivity_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.LockedTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "locked_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	if req.FailureTTLMillis < 0 {
+		validErrs = append(validErrs, codersdk.ValidationError{Field: "failure_ttl_ms", Detail: "Must be a positive integer."})
+	}
+	maxPortShareLevel := template.MaxPortSharingLevel
+	if req.MaxPortShareLevel != "" {
+		maxPortShareLevel = database.AppSharingLevel(req.MaxPortShareLevel)
+		if !maxPortShareLevel.Valid() {
+			validErrs = append(validErrs, codersdk.ValidationError{Field: "max_port_share_level", Detail: "Must be one of owner, authenticated or public."})
+		}
+	}
 
 	if len(validErrs) > 0 {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -479,8 +495,13 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			req.DisplayName == template.DisplayName &&
 			req.Icon == template.Icon &&
 			req.AllowUserCancelWorkspaceJobs == template.AllowUserCancelWorkspaceJobs &&
+			req.RecordTerminalSessions == template.RecordTerminalSessions &&
+			maxPortShareLevel == template.MaxPortSharingLevel &&
 			req.DefaultTTLMillis == time.Duration(template.DefaultTTL).Milliseconds() &&
-			req.MaxTTLMillis == time.Duration(template.MaxTTL).Milliseconds() {
+			req.MaxTTLMillis == time.Duration(template.MaxTTL).Milliseconds() &&
+			req.InactivityTTLMillis == time.Duration(template.InactivityTTL).Milliseconds() &&
+			req.LockedTTLMillis == time.Duration(template.LockedTTL).Milliseconds() &&
+			req.FailureTTLMillis == time.Duration(template.FailureTTL).Milliseconds() {
 			return nil
 		}
 
@@ -509,6 +530,8 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 			Description:                  desc,
 			Icon:                         icon,
 			AllowUserCancelWorkspaceJobs: allowUserCancelWorkspaceJobs,
+			RecordTerminalSessions:       req.RecordTerminalSessions,
+			MaxPortSharingLevel:          maxPortShareLevel,
 		})
 		if err != nil {
 			return xerrors.Errorf("update template metadata: %w", err)
@@ -516,11 +539,21 @@ func (api *API) patchTemplateMeta(rw http.ResponseWriter, r *http.Request) {
 
 		defaultTTL := time.Duration(req.DefaultTTLMillis) * time.Millisecond
 		maxTTL := time.Duration(req.MaxTTLMillis) * time.Millisecond
-		if defaultTTL != time.Duration(template.DefaultTTL) || maxTTL != time.Duration(template.MaxTTL) {
+		inactivityTTL := time.Duration(req.InactivityTTLMillis) * time.Millisecond
+		lockedTTL := time.Duration(req.LockedTTLMillis) * time.Millisecond
+		failureTTL := time.Duration(req.FailureTTLMillis) * time.Millisecond
+		if defaultTTL != time.Duration(template.DefaultTTL) ||
+			maxTTL != time.Duration(template.MaxTTL) ||
+			inactivityTTL != time.Duration(template.InactivityTTL) ||
+			lockedTTL != time.Duration(template.LockedTTL) ||
+			failureTTL != time.Duration(template.FailureTTL) {
 			updated, err = (*api.TemplateScheduleStore.Load()).SetTemplateScheduleOptions(ctx, tx, updated, schedule.TemplateScheduleOptions{
 				UserSchedulingEnabled: true,
 				DefaultTTL:            defaultTTL,
 				MaxTTL:                maxTTL,
+				InactivityTTL:         inactivityTTL,
+				LockedTTL:             lockedTTL,
+				FailureTTL:            failureTTL,
 			})
 			if err != nil {
 				return xerrors.Errorf("set template schedule options: %w", err)
@@ -659,8 +692,13 @@ func (api *API) convertTemplate(
 		Icon:                         template.Icon,
 		DefaultTTLMillis:             time.Duration(template.DefaultTTL).Milliseconds(),
 		MaxTTLMillis:                 time.Duration(template.MaxTTL).Milliseconds(),
+		InactivityTTLMillis:          time.Duration(template.InactivityTTL).Milliseconds(),
+		LockedTTLMillis:              time.Duration(template.LockedTTL).Milliseconds(),
+		FailureTTLMillis:             time.Duration(template.FailureTTL).Milliseconds(),
 		CreatedByID:                  template.CreatedBy,
 		CreatedByName:                createdByName,
 		AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+		RecordTerminalSessions:       template.RecordTerminalSessions,
+		MaxPortShareLevel:            codersdk.WorkspaceAppSharingLevel(template.MaxPortSharingLevel),
 	}
 }
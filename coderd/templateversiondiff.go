@@ -0,0 +1,458 @@
+package coderd
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+	"github.com/pkg/diff"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+)
+
+// templateVersionDiffFileLimit is the maximum size of a single file that will
+// be diffed. Larger files are compared by their hash.
+const templateVersionDiffFileLimit = 10 << 20
+
+// @Summary Get template version diff
+// @ID get-template-version-diff
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param templateversion path string true "Template version ID" format(uuid)
+// @Param base query string true "Template version ID to compare against" format(uuid)
+// @Success 200 {object} codersdk.TemplateVersionDiff
+// @Router /templateversions/{templateversion}/diff [get]
+func (api *API) templateVersionDiff(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	head := httpmw.TemplateVersionParam(r)
+
+	baseID, err := uuid.Parse(r.URL.Query().Get("base"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "A valid base template version ID must be provided.",
+			Validations: []codersdk.ValidationError{
+				{Field: "base", Detail: "Must be a valid UUID"},
+			},
+		})
+		return
+	}
+	base, err := api.Database.GetTemplateVersionByID(ctx, baseID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Base template version not found.",
+			Validations: []codersdk.ValidationError{
+				{Field: "base", Detail: "template version not found"},
+			},
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching base template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if base.OrganizationID != head.OrganizationID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Template versions must belong to the same organization.",
+		})
+		return
+	}
+
+	baseSide, ok := api.fetchTemplateVersionDiffSide(ctx, rw, base)
+	if !ok {
+		return
+	}
+	headSide, ok := api.fetchTemplateVersionDiffSide(ctx, rw, head)
+	if !ok {
+		return
+	}
+
+	res, err := diffTemplateVersions(baseSide, headSide)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error computing template version diff.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	res.BaseID = base.ID
+	res.HeadID = head.ID
+
+	httpapi.Write(ctx, rw, http.StatusOK, res)
+}
+
+// templateVersionDiffSide is everything about a template version that is
+// compared in a diff.
+type templateVersionDiffSide struct {
+	files            map[string]templateVersionDiffFile
+	parameterSchemas []database.ParameterSchema
+	richParameters   []database.TemplateVersionParameter
+	variables        []database.TemplateVersionVariable
+	gitAuthProviders []string
+}
+
+func (api *API) fetchTemplateVersionDiffSide(ctx context.Context, rw http.ResponseWriter, version database.TemplateVersion) (templateVersionDiffSide, bool) {
+	job, err := api.Database.GetProvisionerJobByID(ctx, version.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job.",
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+	if !job.CompletedAt.Valid {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Template version %q hasn't finished importing.", version.Name),
+		})
+		return templateVersionDiffSide{}, false
+	}
+
+	file, err := api.Database.GetFileByID(ctx, job.FileID)
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return templateVersionDiffSide{}, false
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version source.",
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+	files, err := readTemplateVersionArchive(file.Data)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: fmt.Sprintf("Internal error reading the source of template version %q.", version.Name),
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+
+	schemas, err := api.Database.GetParameterSchemasByJobID(ctx, job.ID)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = nil
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error listing parameter schemas.",
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+	richParameters, err := api.Database.GetTemplateVersionParameters(ctx, version.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version parameters.",
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+	variables, err := api.Database.GetTemplateVersionVariables(ctx, version.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version variables.",
+			Detail:  err.Error(),
+		})
+		return templateVersionDiffSide{}, false
+	}
+
+	return templateVersionDiffSide{
+		files:            files,
+		parameterSchemas: schemas,
+		richParameters:   richParameters,
+		variables:        variables,
+		gitAuthProviders: version.GitAuthProviders,
+	}, true
+}
+
+// templateVersionDiffFile is a regular file in a template version archive.
+// The content of files larger than templateVersionDiffFileLimit isn't kept.
+type templateVersionDiffFile struct {
+	content  []byte
+	size     int64
+	hash     [sha256.Size]byte
+	tooLarge bool
+}
+
+// readTemplateVersionArchive returns the regular files in a template version
+// tar archive, keyed by path.
+func readTemplateVersionArchive(data []byte) (map[string]templateVersionDiffFile, error) {
+	files := map[string]templateVersionDiffFile{}
+	tarReader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := tarReader.Next()
+		if errors.Is(err, io.EOF) {
+			return files, nil
+		}
+		if err != nil {
+			return nil, xerrors.Errorf("read tar: %w", err)
+		}
+		//nolint:staticcheck // TypeRegA is still produced by some archivers.
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		name := strings.TrimPrefix(path.Clean(header.Name), "/")
+		if name == "." || strings.HasPrefix(name, "..") {
+			continue
+		}
+		if header.Size > templateVersionDiffFileLimit {
+			hash := sha256.New()
+			_, err = io.Copy(hash, tarReader)
+			if err != nil {
+				return nil, xerrors.Errorf("read %q: %w", name, err)
+			}
+			file := templateVersionDiffFile{
+				size:     header.Size,
+				tooLarge: true,
+			}
+			copy(file.hash[:], hash.Sum(nil))
+			files[name] = file
+			continue
+		}
+		content, err := io.ReadAll(tarReader)
+		if err != nil {
+			return nil, xerrors.Errorf("read %q: %w", name, err)
+		}
+		files[name] = templateVersionDiffFile{
+			content: content,
+			size:    int64(len(content)),
+			hash:    sha256.Sum256(content),
+		}
+	}
+}
+
+func diffTemplateVersions(base, head templateVersionDiffSide) (codersdk.TemplateVersionDiff, error) {
+	files, err := diffTemplateVersionFiles(base.files, head.files)
+	if err != nil {
+		return codersdk.TemplateVersionDiff{}, err
+	}
+
+	baseSchemas, err := convertParameterSchemasForDiff(base.parameterSchemas)
+	if err != nil {
+		return codersdk.TemplateVersionDiff{}, err
+	}
+	headSchemas, err := convertParameterSchemasForDiff(head.parameterSchemas)
+	if err != nil {
+		return codersdk.TemplateVersionDiff{}, err
+	}
+	parameterSchemas := make([]codersdk.TemplateVersionParameterSchemaDiff, 0)
+	for _, change := range diffNamed(baseSchemas, headSchemas, func(s codersdk.ParameterSchema) string {
+		return s.Name
+	}, func(a, b codersdk.ParameterSchema) bool {
+		// Schemas are recreated for every import, so identifiers always
+		// differ between versions.
+		a.ID, b.ID = uuid.Nil, uuid.Nil
+		a.JobID, b.JobID = uuid.Nil, uuid.Nil
+		a.CreatedAt, b.CreatedAt = time.Time{}, time.Time{}
+		return reflect.DeepEqual(a, b)
+	}) {
+		parameterSchemas = append(parameterSchemas, codersdk.TemplateVersionParameterSchemaDiff{
+			Name:   change.name,
+			Change: change.change,
+			Old:    change.old,
+			New:    change.new,
+		})
+	}
+
+	baseParameters, err := convertTemplateVersionParameters(base.richParameters)
+	if err != nil {
+		return codersdk.TemplateVersionDiff{}, xerrors.Errorf("convert base parameters: %w", err)
+	}
+	headParameters, err := convertTemplateVersionParameters(head.richParameters)
+	if err != nil {
+		return codersdk.TemplateVersionDiff{}, xerrors.Errorf("convert head parameters: %w", err)
+	}
+	richParameters := make([]codersdk.TemplateVersionRichParameterDiff, 0)
+	for _, change := range diffNamed(baseParameters, headParameters, func(p codersdk.TemplateVersionParameter) string {
+		return p.Name
+	}, func(a, b codersdk.TemplateVersionParameter) bool {
+		return reflect.DeepEqual(a, b)
+	}) {
+		richParameters = append(richParameters, codersdk.TemplateVersionRichParameterDiff{
+			Name:   change.name,
+			Change: change.change,
+			Old:    change.old,
+			New:    change.new,
+		})
+	}
+
+	// Variables are compared before redaction so that changes to sensitive
+	// values are still reported.
+	variables := make([]codersdk.TemplateVersionVariableDiff, 0)
+	for _, change := range diffNamed(base.variables, head.variables, func(v database.TemplateVersionVariable) string {
+		return v.Name
+	}, func(a, b database.TemplateVersionVariable) bool {
+		a.TemplateVersionID, b.TemplateVersionID = uuid.Nil, uuid.Nil
+		return reflect.DeepEqual(a, b)
+	}) {
+		variableDiff := codersdk.TemplateVersionVariableDiff{
+			Name:   change.name,
+			Change: change.change,
+		}
+		if change.old != nil {
+			old := convertTemplateVersionVariable(*change.old)
+			variableDiff.Old = &old
+		}
+		if change.new != nil {
+			n := convertTemplateVersionVariable(*change.new)
+			variableDiff.New = &n
+		}
+		variables = append(variables, variableDiff)
+	}
+
+	gitAuthProviders := make([]codersdk.TemplateVersionGitAuthProviderDiff, 0)
+	for _, change := range diffNamed(base.gitAuthProviders, head.gitAuthProviders, func(id string) string {
+		return id
+	}, func(a, b string) bool {
+		return a == b
+	}) {
+		gitAuthProviders = append(gitAuthProviders, codersdk.TemplateVersionGitAuthProviderDiff{
+			ID:     change.name,
+			Change: change.change,
+		})
+	}
+
+	return codersdk.TemplateVersionDiff{
+		Files:            files,
+		ParameterSchemas: parameterSchemas,
+		RichParameters:   richParameters,
+		Variables:        variables,
+		GitAuthProviders: gitAuthProviders,
+	}, nil
+}
+
+func convertParameterSchemasForDiff(schemas []database.ParameterSchema) ([]codersdk.ParameterSchema, error) {
+	converted := make([]codersdk.ParameterSchema, 0, len(schemas))
+	for _, schema := range schemas {
+		apiSchema, err := convertParameterSchema(schema)
+		if err != nil {
+			return nil, xerrors.Errorf("convert schema %q: %w", schema.Name, err)
+		}
+		converted = append(converted, apiSchema)
+	}
+	return converted, nil
+}
+
+type namedChange[T any] struct {
+	name   string
+	change codersdk.TemplateVersionDiffChange
+	old    *T
+	new    *T
+}
+
+// diffNamed matches the entries of base and head by name and returns the
+// entries that were added, removed or modified, sorted by name.
+func diffNamed[T any](base, head []T, name func(T) string, equal func(a, b T) bool) []namedChange[T] {
+	baseByName := make(map[string]T, len(base))
+	for _, b := range base {
+		baseByName[name(b)] = b
+	}
+	headByName := make(map[string]T, len(head))
+	for _, h := range head {
+		headByName[name(h)] = h
+	}
+
+	changes := make([]namedChange[T], 0)
+	for n, b := range baseByName {
+		b := b
+		h, ok := headByName[n]
+		if !ok {
+			changes = append(changes, namedChange[T]{name: n, change: codersdk.TemplateVersionDiffChangeRemoved, old: &b})
+			continue
+		}
+		if !equal(b, h) {
+			changes = append(changes, namedChange[T]{name: n, change: codersdk.TemplateVersionDiffChangeModified, old: &b, new: &h})
+		}
+	}
+	for n, h := range headByName {
+		h := h
+		if _, ok := baseByName[n]; !ok {
+			changes = append(changes, namedChange[T]{name: n, change: codersdk.TemplateVersionDiffChangeAdded, new: &h})
+		}
+	}
+	sort.Slice(changes, func(i, j int) bool {
+		return changes[i].name < changes[j].name
+	})
+	return changes
+}
+
+func diffTemplateVersionFiles(base, head map[string]templateVersionDiffFile) ([]codersdk.TemplateVersionFileDiff, error) {
+	paths := make([]string, 0, len(base)+len(head))
+	for p := range base {
+		paths = append(paths, p)
+	}
+	for p := range head {
+		if _, ok := base[p]; !ok {
+			paths = append(paths, p)
+		}
+	}
+	sort.Strings(paths)
+
+	files := make([]codersdk.TemplateVersionFileDiff, 0)
+	for _, p := range paths {
+		baseFile, inBase := base[p]
+		headFile, inHead := head[p]
+
+		fileDiff := codersdk.TemplateVersionFileDiff{
+			Path:   p,
+			Change: codersdk.TemplateVersionDiffChangeModified,
+		}
+		baseName, headName := "a/"+p, "b/"+p
+		switch {
+		case !inBase:
+			fileDiff.Change = codersdk.TemplateVersionDiffChangeAdded
+			baseName = "/dev/null"
+		case !inHead:
+			fileDiff.Change = codersdk.TemplateVersionDiffChangeRemoved
+			headName = "/dev/null"
+		case baseFile.size == headFile.size && baseFile.hash == headFile.hash:
+			continue
+		}
+
+		if baseFile.tooLarge || headFile.tooLarge {
+			fileDiff.TooLarge = true
+			files = append(files, fileDiff)
+			continue
+		}
+		if isBinary(baseFile.content) || isBinary(headFile.content) {
+			fileDiff.Binary = true
+			files = append(files, fileDiff)
+			continue
+		}
+
+		var buf bytes.Buffer
+		// Strings are passed explicitly, since a nil value makes the
+		// diff package read the file from disk.
+		err := diff.Text(baseName, headName, string(baseFile.content), string(headFile.content), &buf)
+		if err != nil {
+			return nil, xerrors.Errorf("diff %q: %w", p, err)
+		}
+		fileDiff.Diff = buf.String()
+		files = append(files, fileDiff)
+	}
+	return files, nil
+}
+
+func isBinary(content []byte) bool {
+	return !utf8.Valid(content) || bytes.IndexByte(content, 0) != -1
+}
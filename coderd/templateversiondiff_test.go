@@ -0,0 +1,199 @@
+package coderd_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTemplateVersionDiff(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		base := createTemplateVersionWithFiles(t, client, user.OrganizationID, richParameterResponses(
+			&proto.RichParameter{Name: "region", Description: "Region", Type: "string", DefaultValue: "us"},
+			&proto.RichParameter{Name: "size", Description: "Size", Type: "string", DefaultValue: "small"},
+		), map[string]string{
+			"main.tf":   "resource \"null_resource\" \"a\" {}\n",
+			"README.md": "# Template\n",
+		})
+		head := createTemplateVersionWithFiles(t, client, user.OrganizationID, richParameterResponses(
+			&proto.RichParameter{Name: "region", Description: "Region", Type: "string", DefaultValue: "eu"},
+			&proto.RichParameter{Name: "disk", Description: "Disk", Type: "number", DefaultValue: "10"},
+		), map[string]string{
+			"main.tf":    "resource \"null_resource\" \"b\" {}\n",
+			"startup.sh": "#!/bin/sh\n",
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, base.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, head.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		diff, err := client.TemplateVersionDiff(ctx, base.ID, head.ID)
+		require.NoError(t, err)
+		require.Equal(t, base.ID, diff.BaseID)
+		require.Equal(t, head.ID, diff.HeadID)
+
+		files := map[string]codersdk.TemplateVersionFileDiff{}
+		for _, file := range diff.Files {
+			files[file.Path] = file
+		}
+		require.Equal(t, codersdk.TemplateVersionDiffChangeRemoved, files["README.md"].Change)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeAdded, files["startup.sh"].Change)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeModified, files["main.tf"].Change)
+		require.Contains(t, files["main.tf"].Diff, "--- a/main.tf")
+		require.Contains(t, files["main.tf"].Diff, "-resource \"null_resource\" \"a\" {}")
+		require.Contains(t, files["main.tf"].Diff, "+resource \"null_resource\" \"b\" {}")
+
+		require.Len(t, diff.RichParameters, 3)
+		require.Equal(t, "disk", diff.RichParameters[0].Name)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeAdded, diff.RichParameters[0].Change)
+		require.Nil(t, diff.RichParameters[0].Old)
+		require.Equal(t, "region", diff.RichParameters[1].Name)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeModified, diff.RichParameters[1].Change)
+		require.Equal(t, "us", diff.RichParameters[1].Old.DefaultValue)
+		require.Equal(t, "eu", diff.RichParameters[1].New.DefaultValue)
+		require.Equal(t, "size", diff.RichParameters[2].Name)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeRemoved, diff.RichParameters[2].Change)
+		require.Nil(t, diff.RichParameters[2].New)
+	})
+
+	t.Run("Identical", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		diff, err := client.TemplateVersionDiff(ctx, version.ID, version.ID)
+		require.NoError(t, err)
+		require.Empty(t, diff.Files)
+		require.Empty(t, diff.ParameterSchemas)
+		require.Empty(t, diff.RichParameters)
+		require.Empty(t, diff.Variables)
+		require.Empty(t, diff.GitAuthProviders)
+	})
+
+	t.Run("TooLarge", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		// The files only differ past the size that is read for a diff.
+		large := strings.Repeat("a", 10<<20)
+		base := createTemplateVersionWithFiles(t, client, user.OrganizationID, nil, map[string]string{
+			"large.txt":     large + "base\n",
+			"unchanged.txt": large + "same\n",
+		})
+		head := createTemplateVersionWithFiles(t, client, user.OrganizationID, nil, map[string]string{
+			"large.txt":     large + "head\n",
+			"unchanged.txt": large + "same\n",
+		})
+		coderdtest.AwaitTemplateVersionJob(t, client, base.ID)
+		coderdtest.AwaitTemplateVersionJob(t, client, head.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		diff, err := client.TemplateVersionDiff(ctx, base.ID, head.ID)
+		require.NoError(t, err)
+		require.Len(t, diff.Files, 1)
+		require.Equal(t, "large.txt", diff.Files[0].Path)
+		require.Equal(t, codersdk.TemplateVersionDiffChangeModified, diff.Files[0].Change)
+		require.True(t, diff.Files[0].TooLarge)
+		require.Empty(t, diff.Files[0].Diff)
+	})
+
+	t.Run("BaseNotFound", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.TemplateVersionDiff(ctx, uuid.New(), version.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+}
+
+func richParameterResponses(parameters ...*proto.RichParameter) *echo.Responses {
+	return &echo.Responses{
+		Parse: echo.ParseComplete,
+		ProvisionPlan: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Parameters: parameters,
+				},
+			},
+		}},
+		ProvisionApply: echo.ProvisionComplete,
+	}
+}
+
+// createTemplateVersionWithFiles creates a template version from an echo
+// archive with additional source files.
+func createTemplateVersionWithFiles(t *testing.T, client *codersdk.Client, organizationID uuid.UUID, res *echo.Responses, files map[string]string) codersdk.TemplateVersion {
+	t.Helper()
+	data, err := echo.Tar(res)
+	require.NoError(t, err)
+
+	var buf bytes.Buffer
+	writer := tar.NewWriter(&buf)
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		require.NoError(t, writer.WriteHeader(header))
+		_, err = io.Copy(writer, reader)
+		require.NoError(t, err)
+	}
+	for name, content := range files {
+		require.NoError(t, writer.WriteHeader(&tar.Header{
+			Name: name,
+			Size: int64(len(content)),
+			Mode: 0o644,
+		}))
+		_, err = writer.Write([]byte(content))
+		require.NoError(t, err)
+	}
+	require.NoError(t, writer.Close())
+
+	file, err := client.Upload(context.Background(), codersdk.ContentTypeTar, bytes.NewReader(buf.Bytes()))
+	require.NoError(t, err)
+	version, err := client.CreateTemplateVersion(context.Background(), organizationID, codersdk.CreateTemplateVersionRequest{
+		FileID:        file.ID,
+		StorageMethod: codersdk.ProvisionerStorageMethodFile,
+		Provisioner:   codersdk.ProvisionerTypeEcho,
+	})
+	require.NoError(t, err)
+	return version
+}
@@ -0,0 +1,325 @@
+package coderd
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/gitauth"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisionersdk"
+)
+
+const (
+	// gitSourceFetchTimeout limits how long fetching a template version from
+	// a git repository may take.
+	gitSourceFetchTimeout = 5 * time.Minute
+	// gitSourceMaxBytes limits how much a fetch may write to disk.
+	gitSourceMaxBytes = 100 << 20
+	// gitSourceSizeInterval is how often the size of a fetch is checked.
+	gitSourceSizeInterval = 100 * time.Millisecond
+)
+
+// fetchTemplateVersionGitSource fetches the template referenced by source
+// and returns it as a tar archive, along with the SHA of the fetched commit.
+// If a git auth provider matches the repository URL, the user's token for
+// that provider is used to authenticate.
+func (api *API) fetchTemplateVersionGitSource(ctx context.Context, rw http.ResponseWriter, userID uuid.UUID, source codersdk.TemplateVersionGitSource) ([]byte, string, bool) {
+	repoURL, err := url.Parse(source.URL)
+	if err != nil || (repoURL.Scheme != "http" && repoURL.Scheme != "https") || repoURL.Host == "" {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid git repository URL.",
+			Validations: []codersdk.ValidationError{
+				{Field: "git_source.url", Detail: "Must be an HTTP(S) URL"},
+			},
+		})
+		return nil, "", false
+	}
+	ref := source.Ref
+	if ref == "" {
+		ref = "HEAD"
+	}
+	if strings.HasPrefix(ref, "-") {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid git ref.",
+			Validations: []codersdk.ValidationError{
+				{Field: "git_source.ref", Detail: "Must be a branch, tag or commit SHA"},
+			},
+		})
+		return nil, "", false
+	}
+	// Rooting the directory before cleaning it removes any "..". Symlinks
+	// can still point outside of the repository, so they are resolved after
+	// the checkout.
+	directory := path.Clean("/" + source.Directory)
+
+	var gitAuthConfig *gitauth.Config
+	for _, gitAuth := range api.GitAuthConfigs {
+		if gitAuth.Regex.MatchString(source.URL) {
+			gitAuthConfig = gitAuth
+		}
+	}
+	// Only explicitly allowed hosts may be fetched, otherwise coderd could be
+	// used to reach any URL, including internal ones. Git auth regexes are
+	// not enough, since they are often loose and were not written with this
+	// in mind.
+	if !api.templateGitHostAllowed(repoURL) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Fetching templates from %q is not allowed.", repoURL.Host),
+			Detail:  "Add the host to --template-git-allowed-hosts.",
+			Validations: []codersdk.ValidationError{
+				{Field: "git_source.url", Detail: "Host is not allowed"},
+			},
+		})
+		return nil, "", false
+	}
+	var authHeader string
+	if gitAuthConfig != nil {
+		authenticateURL, err := api.AccessURL.Parse(fmt.Sprintf("/gitauth/%s", gitAuthConfig.ID))
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to parse access URL.",
+				Detail:  err.Error(),
+			})
+			return nil, "", false
+		}
+		mustAuthenticate := codersdk.Response{
+			Message: fmt.Sprintf("You must authenticate with %s to fetch this repository.", gitAuthConfig.ID),
+			Detail:  fmt.Sprintf("Visit %s to authenticate.", authenticateURL.String()),
+		}
+
+		gitAuthLink, err := api.Database.GetGitAuthLink(ctx, database.GetGitAuthLinkParams{
+			ProviderID: gitAuthConfig.ID,
+			UserID:     userID,
+		})
+		if errors.Is(err, sql.ErrNoRows) {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, mustAuthenticate)
+			return nil, "", false
+		}
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to get git auth link.",
+				Detail:  err.Error(),
+			})
+			return nil, "", false
+		}
+		gitAuthLink, valid, err := gitAuthConfig.RefreshToken(ctx, api.Database, gitAuthLink)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Failed to refresh git auth token.",
+				Detail:  err.Error(),
+			})
+			return nil, "", false
+		}
+		if !valid {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, mustAuthenticate)
+			return nil, "", false
+		}
+		creds := formatGitAuthAccessToken(gitAuthConfig.Type, gitAuthLink.OAuthAccessToken)
+		authHeader = "Authorization: Basic " + base64.StdEncoding.EncodeToString([]byte(creds.Username+":"+creds.Password))
+	}
+
+	archive, sha, err := gitSourceArchive(ctx, repoURL.String(), ref, directory, authHeader)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to fetch the template from git.",
+			Detail:  err.Error(),
+		})
+		return nil, "", false
+	}
+	return archive, sha, true
+}
+
+// templateGitHostAllowed returns whether the deployment allows fetching
+// templates from the host of repoURL. Entries match either the hostname or
+// the host and port.
+func (api *API) templateGitHostAllowed(repoURL *url.URL) bool {
+	for _, host := range api.DeploymentValues.TemplateGitAllowedHosts.Value() {
+		if strings.EqualFold(host, repoURL.Host) || strings.EqualFold(host, repoURL.Hostname()) {
+			return true
+		}
+	}
+	return false
+}
+
+// gitSourceArchive shallowly fetches ref from the repository and archives
+// directory. The system and global git configuration are ignored, so
+// credential helpers and other settings of the user running coderd don't
+// apply. Proxy environment variables are still respected. Redirects are not
+// followed. The fetch is aborted once it writes more than gitSourceMaxBytes
+// to disk.
+func gitSourceArchive(ctx context.Context, repoURL, ref, directory, authHeader string) ([]byte, string, error) {
+	ctx, cancel := context.WithTimeout(ctx, gitSourceFetchTimeout)
+	defer cancel()
+
+	dir, err := os.MkdirTemp("", "coder-template-git-")
+	if err != nil {
+		return nil, "", xerrors.Errorf("create temp dir: %w", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Stopped before the directory is removed.
+	exceeded := watchDirectorySize(ctx, dir, gitSourceMaxBytes, gitSourceSizeInterval, cancel)
+	defer exceeded()
+
+	env := append(os.Environ(),
+		// Never wait for credentials on a terminal.
+		"GIT_TERMINAL_PROMPT=0",
+		// Don't pick up credential helpers or other settings of the user
+		// running coderd.
+		"GIT_CONFIG_NOSYSTEM=1",
+		"GIT_CONFIG_GLOBAL="+os.DevNull,
+		"GIT_ALLOW_PROTOCOL=http:https",
+	)
+	// Passed through the environment so the token doesn't show up in the
+	// process list.
+	config := [][2]string{
+		// An allowed host could otherwise redirect coderd anywhere.
+		{"http.followRedirects", "false"},
+	}
+	if authHeader != "" {
+		config = append(config, [2]string{"http.extraHeader", authHeader})
+	}
+	env = append(env, fmt.Sprintf("GIT_CONFIG_COUNT=%d", len(config)))
+	for i, entry := range config {
+		env = append(env,
+			fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, entry[0]),
+			fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, entry[1]),
+		)
+	}
+	run := func(args ...string) (string, error) {
+		var stdout, stderr bytes.Buffer
+		//nolint:gosec // Arguments are validated by the caller.
+		cmd := exec.CommandContext(ctx, "git", args...)
+		cmd.Dir = dir
+		cmd.Env = env
+		cmd.Stdout = &stdout
+		cmd.Stderr = &stderr
+		err := cmd.Run()
+		if err != nil {
+			if exceeded() {
+				return "", xerrors.Errorf("repository is larger than %d bytes", gitSourceMaxBytes)
+			}
+			return "", xerrors.Errorf("git %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return strings.TrimSpace(stdout.String()), nil
+	}
+
+	if _, err := run("init", "--quiet"); err != nil {
+		return nil, "", err
+	}
+	if _, err := run("fetch", "--quiet", "--depth", "1", "--", repoURL, ref); err != nil {
+		return nil, "", err
+	}
+	if _, err := run("checkout", "--quiet", "FETCH_HEAD"); err != nil {
+		return nil, "", err
+	}
+	sha, err := run("rev-parse", "HEAD")
+	if err != nil {
+		return nil, "", err
+	}
+
+	templateDir, err := resolveRepositoryDirectory(dir, directory)
+	if err != nil {
+		return nil, "", err
+	}
+	var archive bytes.Buffer
+	err = provisionersdk.Tar(&archive, templateDir, provisionersdk.TemplateArchiveLimit)
+	if err != nil {
+		return nil, "", xerrors.Errorf("archive %q: %w", strings.TrimPrefix(directory, "/"), err)
+	}
+	return archive.Bytes(), sha, nil
+}
+
+// watchDirectorySize calls cancel once the size of the files in dir exceeds
+// maxBytes. The size is polled, so it may exceed maxBytes briefly. The
+// returned function stops watching and reports whether the limit was
+// exceeded.
+func watchDirectorySize(ctx context.Context, dir string, maxBytes int64, interval time.Duration, cancel context.CancelFunc) func() bool {
+	var exceeded atomic.Bool
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+			if directorySize(dir) > maxBytes {
+				exceeded.Store(true)
+				cancel()
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() bool {
+		once.Do(func() {
+			close(stop)
+			<-done
+		})
+		return exceeded.Load()
+	}
+}
+
+// directorySize returns the total size of the regular files in dir. Errors
+// are ignored, since files are created and removed while git runs.
+func directorySize(dir string) int64 {
+	var size int64
+	_ = filepath.WalkDir(dir, func(_ string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.Type().IsRegular() {
+			return nil
+		}
+		info, err := entry.Info()
+		if err == nil {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// resolveRepositoryDirectory returns the path of directory within the
+// checkout at root with symlinks resolved. Directories that resolve to a
+// path outside of the checkout are rejected, since the repository controls
+// where its symlinks point.
+func resolveRepositoryDirectory(root, directory string) (string, error) {
+	resolvedRoot, err := filepath.EvalSymlinks(root)
+	if err != nil {
+		return "", xerrors.Errorf("resolve checkout: %w", err)
+	}
+	resolved, err := filepath.EvalSymlinks(filepath.Join(root, filepath.FromSlash(directory)))
+	if err != nil {
+		return "", xerrors.Errorf("directory %q does not exist in the repository", strings.TrimPrefix(directory, "/"))
+	}
+	rel, err := filepath.Rel(resolvedRoot, resolved)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+		return "", xerrors.Errorf("directory %q is outside of the repository", strings.TrimPrefix(directory, "/"))
+	}
+	return resolved, nil
+}
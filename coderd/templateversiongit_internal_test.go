@@ -0,0 +1,44 @@
+package coderd
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/testutil"
+)
+
+func TestWatchDirectorySize(t *testing.T) {
+	t.Parallel()
+	t.Run("Exceeded", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		exceeded := watchDirectorySize(ctx, dir, 1024, time.Millisecond, cancel)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, "objects"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "objects", "pack"), make([]byte, 2048), 0o600))
+
+		<-ctx.Done()
+		require.True(t, exceeded())
+	})
+
+	t.Run("WithinLimit", func(t *testing.T) {
+		t.Parallel()
+		dir := t.TempDir()
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		exceeded := watchDirectorySize(ctx, dir, 1024, time.Millisecond, cancel)
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), make([]byte, 512), 0o600))
+		time.Sleep(10 * time.Millisecond)
+
+		require.False(t, exceeded())
+		require.NoError(t, ctx.Err())
+	})
+}
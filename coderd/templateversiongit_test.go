@@ -0,0 +1,486 @@
+package coderd_test
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/cgi"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/oauth2"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/database/dbtestutil"
+	"github.com/coder/coder/coderd/gitauth"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/testutil"
+)
+
+func TestPostTemplateVersionsByOrganizationGitSource(t *testing.T) {
+	t.Parallel()
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true, DeploymentValues: gitAllowedHostValues(t, repo)})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+				Ref: "main",
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, repo.SHA, version.GitCommitSHA)
+		version = coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+		require.Equal(t, codersdk.ProvisionerJobSucceeded, version.Job.Status)
+
+		data, _, err := client.Download(ctx, version.Job.FileID)
+		require.NoError(t, err)
+		require.Contains(t, tarFileNames(t, data), "main.tf")
+	})
+
+	t.Run("Directory", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: gitAllowedHostValues(t, repo)})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL:       repo.URL,
+				Ref:       "main",
+				Directory: "../templates/docker",
+			},
+		})
+		require.NoError(t, err)
+
+		data, _, err := client.Download(ctx, version.Job.FileID)
+		require.NoError(t, err)
+		names := tarFileNames(t, data)
+		require.Contains(t, names, "docker.tf")
+		require.NotContains(t, names, "main.tf")
+	})
+
+	t.Run("DirectorySymlinkEscape", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: gitAllowedHostValues(t, repo)})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL:       repo.URL,
+				Ref:       "main",
+				Directory: "escape/etc",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, apiErr.Detail, "outside of the repository")
+	})
+
+	t.Run("ExclusiveWithFile", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			FileID:        uuid.New(),
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: "https://example.com/template.git",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("InvalidURL", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: "file:///etc",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "git_source.url", apiErr.Validations[0].Field)
+	})
+
+	t.Run("RefNotFound", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: gitAllowedHostValues(t, repo)})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+				Ref: "does-not-exist",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Equal(t, "Failed to fetch the template from git.", apiErr.Message)
+	})
+
+	t.Run("HostNotAllowed", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "git_source.url", apiErr.Validations[0].Field)
+		require.Zero(t, repo.Requests())
+	})
+
+	t.Run("GitAuthHostNotAllowed", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		// A loose regex must not allow fetching from hosts that aren't
+		// allowed.
+		client := coderdtest.New(t, &coderdtest.Options{
+			GitAuthConfigs: []*gitauth.Config{{
+				OAuth2Config: &testutil.OAuth2Config{},
+				ID:           "gitlab",
+				Regex:        regexp.MustCompile(`.*`),
+				Type:         codersdk.GitProviderGitLab,
+			}},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Len(t, apiErr.Validations, 1)
+		require.Equal(t, "git_source.url", apiErr.Validations[0].Field)
+		require.Zero(t, repo.Requests())
+	})
+
+	t.Run("Redirect", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		redirect := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, repo.URL+strings.TrimPrefix(r.URL.Path, "/template.git")+"?"+r.URL.RawQuery, http.StatusFound)
+		}))
+		t.Cleanup(redirect.Close)
+		values := coderdtest.DeploymentValues(t)
+		values.TemplateGitAllowedHosts = []string{redirect.Listener.Addr().String()}
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: values})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: redirect.URL + "/template.git",
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Equal(t, "Failed to fetch the template from git.", apiErr.Message)
+		require.Zero(t, repo.Requests())
+	})
+
+	t.Run("Forbidden", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{DeploymentValues: gitAllowedHostValues(t, repo)})
+		user := coderdtest.CreateFirstUser(t, client)
+		member, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := member.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusForbidden, apiErr.StatusCode())
+		require.Zero(t, repo.Requests())
+	})
+
+	t.Run("GitAuthRequired", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			DeploymentValues: gitAllowedHostValues(t, repo),
+			GitAuthConfigs: []*gitauth.Config{{
+				OAuth2Config: &testutil.OAuth2Config{},
+				ID:           "gitlab",
+				Regex:        regexp.MustCompile(regexp.QuoteMeta(repo.URL)),
+				Type:         codersdk.GitProviderGitLab,
+			}},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, apiErr.Detail, "/gitauth/gitlab")
+		require.Empty(t, repo.Authorization())
+	})
+
+	t.Run("GitAuth", func(t *testing.T) {
+		t.Parallel()
+		repo := newGitTemplateRepo(t)
+		db, pubsub := dbtestutil.NewDB(t)
+		client := coderdtest.New(t, &coderdtest.Options{
+			Database:         db,
+			Pubsub:           pubsub,
+			DeploymentValues: gitAllowedHostValues(t, repo),
+			GitAuthConfigs: []*gitauth.Config{{
+				OAuth2Config: &testutil.OAuth2Config{
+					Token: &oauth2.Token{AccessToken: "token"},
+				},
+				ID:    "gitlab",
+				Regex: regexp.MustCompile(regexp.QuoteMeta(repo.URL)),
+				Type:  codersdk.GitProviderGitLab,
+			}},
+		})
+		user := coderdtest.CreateFirstUser(t, client)
+		dbgen.GitAuthLink(t, db, database.GitAuthLink{
+			ProviderID:       "gitlab",
+			UserID:           user.UserID,
+			OAuthAccessToken: "token",
+		})
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		version, err := client.CreateTemplateVersion(ctx, user.OrganizationID, codersdk.CreateTemplateVersionRequest{
+			StorageMethod: codersdk.ProvisionerStorageMethodFile,
+			Provisioner:   codersdk.ProvisionerTypeEcho,
+			GitSource: &codersdk.TemplateVersionGitSource{
+				URL: repo.URL,
+			},
+		})
+		require.NoError(t, err)
+		require.Equal(t, repo.SHA, version.GitCommitSHA)
+		require.Equal(t, "Basic "+base64.StdEncoding.EncodeToString([]byte("oauth2:token")), repo.Authorization())
+	})
+}
+
+type gitTemplateRepo struct {
+	URL  string
+	Host string
+	SHA  string
+
+	mutex         sync.Mutex
+	requests      int
+	authorization string
+}
+
+// Requests returns the number of requests made to the repository.
+func (r *gitTemplateRepo) Requests() int {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.requests
+}
+
+// Authorization returns the last Authorization header the repository was
+// fetched with.
+func (r *gitTemplateRepo) Authorization() string {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	return r.authorization
+}
+
+// newGitTemplateRepo serves a git repository containing an echo template
+// at the root and a second template in templates/docker over smart HTTP.
+func newGitTemplateRepo(t *testing.T) *gitTemplateRepo {
+	t.Helper()
+	gitPath, err := exec.LookPath("git")
+	if err != nil {
+		t.Skip("git is not installed")
+	}
+
+	root := t.TempDir()
+	dir := filepath.Join(root, "template.git")
+	data, err := echo.Tar(&echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: echo.ProvisionComplete,
+	})
+	require.NoError(t, err)
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		content, err := io.ReadAll(reader)
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, filepath.Dir(header.Name)), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, header.Name), content, 0o600))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "main.tf"), []byte("resource \"null_resource\" \"a\" {}\n"), 0o600))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates", "docker"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "docker", "docker.tf"), []byte("resource \"null_resource\" \"b\" {}\n"), 0o600))
+	// A symlink that points outside of the repository once checked out.
+	require.NoError(t, os.Symlink(string(filepath.Separator), filepath.Join(dir, "escape")))
+
+	git := func(args ...string) string {
+		cmd := exec.Command(gitPath, args...)
+		cmd.Dir = dir
+		cmd.Env = append(os.Environ(),
+			"GIT_CONFIG_NOSYSTEM=1",
+			"GIT_CONFIG_GLOBAL="+os.DevNull,
+			"GIT_AUTHOR_NAME=Coder",
+			"GIT_AUTHOR_EMAIL=coder@coder.com",
+			"GIT_COMMITTER_NAME=Coder",
+			"GIT_COMMITTER_EMAIL=coder@coder.com",
+		)
+		out, err := cmd.CombinedOutput()
+		require.NoError(t, err, string(out))
+		return strings.TrimSpace(string(out))
+	}
+	git("init", "--quiet", "--initial-branch", "main")
+	git("add", "-A")
+	git("commit", "--quiet", "-m", "Initial commit")
+
+	repo := &gitTemplateRepo{
+		SHA: git("rev-parse", "HEAD"),
+	}
+	backend := &cgi.Handler{
+		Path: gitPath,
+		Args: []string{"http-backend"},
+		Env: []string{
+			"GIT_PROJECT_ROOT=" + root,
+			"GIT_HTTP_EXPORT_ALL=1",
+		},
+	}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		repo.mutex.Lock()
+		repo.requests++
+		repo.authorization = r.Header.Get("Authorization")
+		repo.mutex.Unlock()
+		backend.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	repo.URL = srv.URL + "/template.git"
+	repo.Host = srv.Listener.Addr().String()
+	return repo
+}
+
+// gitAllowedHostValues returns deployment values that allow fetching
+// templates from repo.
+func gitAllowedHostValues(t *testing.T, repo *gitTemplateRepo) *codersdk.DeploymentValues {
+	t.Helper()
+	values := coderdtest.DeploymentValues(t)
+	values.TemplateGitAllowedHosts = []string{repo.Host}
+	return values
+}
+
+func tarFileNames(t *testing.T, data []byte) []string {
+	t.Helper()
+	var names []string
+	reader := tar.NewReader(bytes.NewReader(data))
+	for {
+		header, err := reader.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		require.NoError(t, err)
+		names = append(names, header.Name)
+	}
+	return names
+}
@@ -9,6 +9,7 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"strconv"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
@@ -19,6 +20,7 @@ import (
 
 	"github.com/coder/coder/coderd/audit"
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/gitauth"
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
@@ -123,6 +125,109 @@ func (api *API) patchTemplateVersion(rw http.ResponseWriter, r *http.Request) {
 	httpapi.Write(ctx, rw, http.StatusOK, convertTemplateVersion(updatedTemplateVersion, convertProvisionerJob(job), user))
 }
 
+// @Summary Archive template version by ID
+// @ID archive-template-version-by-id
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param templateversion path string true "Template version ID" format(uuid)
+// @Success 200 {object} codersdk.TemplateVersion
+// @Router /templateversions/{templateversion}/archive [post]
+func (api *API) postArchiveTemplateVersion(rw http.ResponseWriter, r *http.Request) {
+	api.setTemplateVersionArchived(rw, r, true)
+}
+
+// @Summary Unarchive template version by ID
+// @ID unarchive-template-version-by-id
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Templates
+// @Param templateversion path string true "Template version ID" format(uuid)
+// @Success 200 {object} codersdk.TemplateVersion
+// @Router /templateversions/{templateversion}/unarchive [post]
+func (api *API) postUnarchiveTemplateVersion(rw http.ResponseWriter, r *http.Request) {
+	api.setTemplateVersionArchived(rw, r, false)
+}
+
+func (api *API) setTemplateVersionArchived(rw http.ResponseWriter, r *http.Request, archived bool) {
+	var (
+		ctx               = r.Context()
+		templateVersion   = httpmw.TemplateVersionParam(r)
+		auditor           = *api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.TemplateVersion](rw, &audit.RequestParams{
+			Audit:   auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = templateVersion
+
+	if archived && templateVersion.TemplateID.Valid {
+		template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching template.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		if template.ActiveVersionID == templateVersion.ID {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "The active version of a template cannot be archived.",
+				Detail:  "Promote another version of the template first.",
+			})
+			return
+		}
+	}
+
+	err := api.Database.UpdateTemplateVersionArchivedByID(ctx, database.UpdateTemplateVersionArchivedByIDParams{
+		ID:        templateVersion.ID,
+		Archived:  archived,
+		UpdatedAt: database.Now(),
+	})
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	templateVersion, err = api.Database.GetTemplateVersionByID(ctx, templateVersion.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template version.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = templateVersion
+
+	job, err := api.Database.GetProvisionerJobByID(ctx, templateVersion.JobID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching provisioner job.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	user, err := api.Database.GetUserByID(ctx, templateVersion.CreatedBy)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error on fetching user.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertTemplateVersion(templateVersion, convertProvisionerJob(job), user))
+}
+
 // @Summary Cancel template version by ID
 // @ID cancel-template-version-by-id
 // @Security CoderSessionToken
@@ -755,6 +860,7 @@ func (api *API) fetchTemplateVersionDryRunJob(rw http.ResponseWriter, r *http.Re
 // @Param after_id query string false "After ID" format(uuid)
 // @Param limit query int false "Page limit"
 // @Param offset query int false "Page offset"
+// @Param include_archived query bool false "Include archived versions"
 // @Success 200 {array} codersdk.TemplateVersion
 // @Router /templates/{template}/versions [get]
 func (api *API) templateVersionsByTemplate(rw http.ResponseWriter, r *http.Request) {
@@ -766,6 +872,21 @@ func (api *API) templateVersionsByTemplate(rw http.ResponseWriter, r *http.Reque
 		return
 	}
 
+	includeArchived := false
+	if s := r.URL.Query().Get("include_archived"); s != "" {
+		var err error
+		includeArchived, err = strconv.ParseBool(s)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid boolean value %q for \"include_archived\" query param.", s),
+				Validations: []codersdk.ValidationError{
+					{Field: "include_archived", Detail: "Must be a valid boolean"},
+				},
+			})
+			return
+		}
+	}
+
 	var err error
 	apiVersions := []codersdk.TemplateVersion{}
 	err = api.Database.InTx(func(store database.Store) error {
@@ -788,10 +909,11 @@ func (api *API) templateVersionsByTemplate(rw http.ResponseWriter, r *http.Reque
 		}
 
 		versions, err := store.GetTemplateVersionsByTemplateID(ctx, database.GetTemplateVersionsByTemplateIDParams{
-			TemplateID: template.ID,
-			AfterID:    paginationParams.AfterID,
-			LimitOpt:   int32(paginationParams.Limit),
-			OffsetOpt:  int32(paginationParams.Offset),
+			TemplateID:      template.ID,
+			AfterID:         paginationParams.AfterID,
+			IncludeArchived: includeArchived,
+			LimitOpt:        int32(paginationParams.Limit),
+			OffsetOpt:       int32(paginationParams.Offset),
 		})
 		if errors.Is(err, sql.ErrNoRows) {
 			httpapi.Write(ctx, rw, http.StatusOK, apiVersions)
@@ -1123,6 +1245,13 @@ func (api *API) patchActiveTemplateVersion(rw http.ResponseWriter, r *http.Reque
 		})
 		return
 	}
+	if version.Archived {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "The provided template version is archived.",
+			Detail:  "Unarchive the template version before promoting it.",
+		})
+		return
+	}
 
 	err = api.Database.InTx(func(store database.Store) error {
 		err = store.UpdateTemplateActiveVersionByID(ctx, database.UpdateTemplateActiveVersionByIDParams{
@@ -1186,8 +1315,11 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		return
 	}
 
+	// Creating a version requires the same permission as creating a template,
+	// or the create permission on the template the version is for.
+	var templateObject rbac.Objecter = rbac.ResourceTemplate.InOrg(organization.ID)
 	if req.TemplateID != uuid.Nil {
-		_, err := api.Database.GetTemplateByID(ctx, req.TemplateID)
+		template, err := api.Database.GetTemplateByID(ctx, req.TemplateID)
 		if errors.Is(err, sql.ErrNoRows) {
 			httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
 				Message: "Template does not exist.",
@@ -1201,6 +1333,7 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			})
 			return
 		}
+		templateObject = template
 	}
 
 	// Ensures the "owner" is properly applied.
@@ -1213,6 +1346,13 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		return
 	}
 
+	if req.GitSource != nil && (req.ExampleID != "" || req.FileID != uuid.Nil) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "You cannot specify a git_source with an example_id or a file_id.",
+		})
+		return
+	}
+
 	var file database.File
 	var err error
 	// if example id is specified we need to copy the embedded tar into a new file in the database
@@ -1245,41 +1385,43 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 		}
 
 		// upload a copy of the template tar as a file in the database
-		hashBytes := sha256.Sum256(tar)
-		hash := hex.EncodeToString(hashBytes[:])
-		// Check if the file already exists.
-		file, err := api.Database.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
-			Hash:      hash,
-			CreatedBy: apiKey.UserID,
-		})
-		if err != nil {
-			if !errors.Is(err, sql.ErrNoRows) {
-				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-					Message: "Internal error fetching file.",
-					Detail:  err.Error(),
-				})
-				return
-			}
+		file, ok := api.insertTemplateArchiveFile(ctx, rw, apiKey.UserID, tar)
+		if !ok {
+			return
+		}
+		req.FileID = file.ID
+	}
 
-			// If the example tar file doesn't exist, create it.
-			file, err = api.Database.InsertFile(ctx, database.InsertFileParams{
-				ID:        uuid.New(),
-				Hash:      hash,
-				CreatedBy: apiKey.UserID,
-				CreatedAt: database.Now(),
-				Mimetype:  tarMimeType,
-				Data:      tar,
-			})
-			if err != nil {
-				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-					Message: "Internal error creating file.",
-					Detail:  err.Error(),
-				})
-				return
-			}
+	// if a git source is specified, the repository is fetched and stored
+	// as a new file in the database
+	var gitCommitSHA string
+	if req.GitSource != nil {
+		// The version is authorized before anything is fetched, so users
+		// that can't create template versions can't make coderd send
+		// requests.
+		if !api.Authorize(r, rbac.ActionCreate, templateObject) {
+			httpapi.Forbidden(rw)
+			return
+		}
+		if !api.Authorize(r, rbac.ActionCreate, rbac.ResourceFile.WithOwner(apiKey.UserID.String())) {
+			httpapi.Forbidden(rw)
+			return
+		}
+		if !api.Authorize(r, rbac.ActionRead, rbac.ResourceFile.WithOwner(apiKey.UserID.String())) {
+			httpapi.Forbidden(rw)
+			return
 		}
 
+		tar, sha, ok := api.fetchTemplateVersionGitSource(ctx, rw, apiKey.UserID, *req.GitSource)
+		if !ok {
+			return
+		}
+		file, ok := api.insertTemplateArchiveFile(ctx, rw, apiKey.UserID, tar)
+		if !ok {
+			return
+		}
 		req.FileID = file.ID
+		gitCommitSHA = sha
 	}
 
 	if req.FileID != uuid.Nil {
@@ -1414,6 +1556,7 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 			Readme:         "",
 			JobID:          provisionerJob.ID,
 			CreatedBy:      apiKey.UserID,
+			GitCommitSHA:   gitCommitSHA,
 		})
 		if err != nil {
 			return xerrors.Errorf("insert template version: %w", err)
@@ -1440,6 +1583,46 @@ func (api *API) postTemplateVersionsByOrganization(rw http.ResponseWriter, r *ht
 	httpapi.Write(ctx, rw, http.StatusCreated, convertTemplateVersion(templateVersion, convertProvisionerJob(provisionerJob), user))
 }
 
+// insertTemplateArchiveFile stores a template tar archive created on behalf
+// of the user as a file, reusing an identical file if one exists.
+func (api *API) insertTemplateArchiveFile(ctx context.Context, rw http.ResponseWriter, userID uuid.UUID, tar []byte) (database.File, bool) {
+	hashBytes := sha256.Sum256(tar)
+	hash := hex.EncodeToString(hashBytes[:])
+	// Check if the file already exists.
+	file, err := api.Database.GetFileByHashAndCreator(ctx, database.GetFileByHashAndCreatorParams{
+		Hash:      hash,
+		CreatedBy: userID,
+	})
+	if err == nil {
+		return file, true
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching file.",
+			Detail:  err.Error(),
+		})
+		return database.File{}, false
+	}
+
+	// If the tar file doesn't exist, create it.
+	file, err = api.Database.InsertFile(ctx, database.InsertFileParams{
+		ID:        uuid.New(),
+		Hash:      hash,
+		CreatedBy: userID,
+		CreatedAt: database.Now(),
+		Mimetype:  tarMimeType,
+		Data:      tar,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error creating file.",
+			Detail:  err.Error(),
+		})
+		return database.File{}, false
+	}
+	return file, true
+}
+
 // templateVersionResources returns the workspace agent resources associated
 // with a template version. A template can specify more than one resource to be
 // provisioned, each resource can have an agent that dials back to coderd. The
@@ -1525,6 +1708,8 @@ func convertTemplateVersion(version database.TemplateVersion, job codersdk.Provi
 		Job:            job,
 		Readme:         version.Readme,
 		CreatedBy:      createdBy,
+		Archived:       version.Archived,
+		GitCommitSHA:   version.GitCommitSHA,
 	}
 }
 
@@ -1576,6 +1761,7 @@ func convertTemplateVersionParameter(param database.TemplateVersionParameter) (c
 		ValidationMonotonic:  codersdk.ValidationMonotonicOrder(param.ValidationMonotonic),
 		Required:             param.Required,
 		LegacyVariableName:   param.LegacyVariableName,
+		Ephemeral:            param.Ephemeral,
 	}, nil
 }
 
@@ -625,6 +625,77 @@ func TestTemplateVersionsByTemplate(t *testing.T) {
 		require.NoError(t, err)
 		require.Len(t, versions, 1)
 	})
+
+	t.Run("Archived", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		archived := coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.ArchiveTemplateVersion(ctx, archived.ID)
+		require.NoError(t, err)
+
+		versions, err := client.TemplateVersionsByTemplate(ctx, codersdk.TemplateVersionsByTemplateRequest{
+			TemplateID: template.ID,
+		})
+		require.NoError(t, err)
+		require.Len(t, versions, 1)
+		require.Equal(t, version.ID, versions[0].ID)
+
+		versions, err = client.TemplateVersionsByTemplate(ctx, codersdk.TemplateVersionsByTemplateRequest{
+			TemplateID:      template.ID,
+			IncludeArchived: true,
+		})
+		require.NoError(t, err)
+		require.Len(t, versions, 2)
+	})
+}
+
+func TestArchiveTemplateVersion(t *testing.T) {
+	t.Parallel()
+	t.Run("ActiveVersion", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		_ = coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.ArchiveTemplateVersion(ctx, version.ID)
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+		auditor := audit.NewMock()
+		client := coderdtest.New(t, &coderdtest.Options{Auditor: auditor})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		archived, err := client.ArchiveTemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+		require.True(t, archived.Archived)
+		require.Equal(t, database.AuditActionWrite, auditor.AuditLogs[len(auditor.AuditLogs)-1].Action)
+		require.Equal(t, database.ResourceTypeTemplateVersion, auditor.AuditLogs[len(auditor.AuditLogs)-1].ResourceType)
+
+		unarchived, err := client.UnarchiveTemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+		require.False(t, unarchived.Archived)
+	})
 }
 
 func TestTemplateVersionByName(t *testing.T) {
@@ -718,6 +789,28 @@ func TestPatchActiveTemplateVersion(t *testing.T) {
 		require.Len(t, auditor.AuditLogs, 5)
 		assert.Equal(t, database.AuditActionWrite, auditor.AuditLogs[4].Action)
 	})
+
+	t.Run("Archived", func(t *testing.T) {
+		t.Parallel()
+		client := coderdtest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		version = coderdtest.UpdateTemplateVersion(t, client, user.OrganizationID, nil, template.ID)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.ArchiveTemplateVersion(ctx, version.ID)
+		require.NoError(t, err)
+
+		err = client.UpdateActiveTemplateVersion(ctx, template.ID, codersdk.UpdateActiveTemplateVersion{
+			ID: version.ID,
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+	})
 }
 
 func TestTemplateVersionDryRun(t *testing.T) {
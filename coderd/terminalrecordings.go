@@ -0,0 +1,228 @@
+package coderd
+
+import (
+	"bytes"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+)
+
+// @Summary Upload terminal recording
+// @ID upload-terminal-recording
+// @Security CoderSessionToken
+// @Accept application/x-asciicast
+// @Tags Agents
+// @Param Content-Type header string true "Content-Type must be `application/x-asciicast`" default(application/x-asciicast)
+// @Param type query string true "Recording type" Enums(ssh,reconnecting_pty)
+// @Param client_reported_user_id query string false "ID of the user the client reported as connecting to the session, it is stored but not verified" format(uuid)
+// @Param file formData file true "Recording in the asciicast v2 format"
+// @Success 201
+// @Router /workspaceagents/me/terminal-recordings [post]
+// @x-apidocgen {"skip": true}
+func (api *API) postWorkspaceAgentTerminalRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	recordingType := database.TerminalRecordingType(r.URL.Query().Get("type"))
+	if !recordingType.Valid() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Invalid recording type %q.", recordingType),
+		})
+		return
+	}
+
+	// The agent only knows the user the client claimed to connect as, so
+	// it's kept apart from the user the recording is attributed to.
+	var clientReportedUserID uuid.NullUUID
+	if rawUserID := r.URL.Query().Get("client_reported_user_id"); rawUserID != "" {
+		var err error
+		clientReportedUserID.UUID, err = uuid.Parse(rawUserID)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Query param 'client_reported_user_id' must be a valid UUID.",
+				Validations: []codersdk.ValidationError{
+					{Field: "client_reported_user_id", Detail: "invalid UUID"},
+				},
+			})
+			return
+		}
+		clientReportedUserID.Valid = true
+	}
+
+	r.Body = http.MaxBytesReader(rw, r.Body, agentsdk.TerminalRecordingMaxSize)
+	data, err := io.ReadAll(r.Body)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to read recording from request.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	info, err := asciicast.Read(bytes.NewReader(data))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Recording is not a valid asciicast v2 recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	workspace, err := api.Database.GetWorkspaceByAgentID(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	startedAt := time.Unix(info.Header.Timestamp, 0)
+	// nolint:gocritic // Only agents can store recordings, users can't.
+	_, err = api.Database.InsertTerminalRecording(dbauthz.AsSystemRestricted(ctx), database.InsertTerminalRecordingParams{
+		ID:                   uuid.New(),
+		CreatedAt:            database.Now(),
+		StartedAt:            startedAt,
+		EndedAt:              startedAt.Add(info.Duration),
+		OrganizationID:       workspace.OrganizationID,
+		WorkspaceID:          workspace.ID,
+		AgentID:              workspaceAgent.ID,
+		UserID:               workspace.OwnerID,
+		ClientReportedUserID: clientReportedUserID,
+		Type:                 recordingType,
+		Size:                 int64(len(data)),
+		Data:                 data,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error saving recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusCreated)
+}
+
+// @Summary Get terminal recordings
+// @ID get-terminal-recordings
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Audit
+// @Param workspace_id query string false "Workspace ID" format(uuid)
+// @Param agent_id query string false "Agent ID" format(uuid)
+// @Param user_id query string false "Workspace owner ID" format(uuid)
+// @Param limit query int false "Page limit"
+// @Param offset query int false "Page offset"
+// @Success 200 {array} codersdk.TerminalRecording
+// @Router /terminal-recordings [get]
+func (api *API) terminalRecordings(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	page, ok := parsePagination(rw, r)
+	if !ok {
+		return
+	}
+	queryParams := r.URL.Query()
+	parser := httpapi.NewQueryParamParser()
+	filter := database.GetTerminalRecordingsParams{
+		WorkspaceID: parser.UUID(queryParams, uuid.Nil, "workspace_id"),
+		AgentID:     parser.UUID(queryParams, uuid.Nil, "agent_id"),
+		UserID:      parser.UUID(queryParams, uuid.Nil, "user_id"),
+		OffsetOpt:   int32(page.Offset),
+		LimitOpt:    int32(page.Limit),
+	}
+	if len(parser.Errors) > 0 {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message:     "Query parameters have invalid values.",
+			Validations: parser.Errors,
+		})
+		return
+	}
+
+	recordings, err := api.Database.GetTerminalRecordings(ctx, filter)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching terminal recordings.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	apiRecordings := make([]codersdk.TerminalRecording, 0, len(recordings))
+	for _, recording := range recordings {
+		apiRecordings = append(apiRecordings, convertTerminalRecording(recording))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, apiRecordings)
+}
+
+// @Summary Download terminal recording
+// @Description The recording is in the asciicast v2 format.
+// @ID download-terminal-recording
+// @Security CoderSessionToken
+// @Tags Audit
+// @Param recording path string true "Recording ID" format(uuid)
+// @Success 200
+// @Router /terminal-recordings/{recording} [get]
+func (api *API) terminalRecording(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	id, err := uuid.Parse(chi.URLParam(r, "recording"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Recording id must be a valid UUID.",
+		})
+		return
+	}
+
+	recording, err := api.Database.GetTerminalRecordingByID(ctx, id)
+	if errors.Is(err, sql.ErrNoRows) || dbauthz.IsNotAuthorizedError(err) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching terminal recording.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.Header().Set("Content-Type", asciicast.ContentType)
+	rw.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", recording.ID.String()+".cast"))
+	rw.WriteHeader(http.StatusOK)
+	_, _ = rw.Write(recording.Data)
+}
+
+func convertTerminalRecording(recording database.GetTerminalRecordingsRow) codersdk.TerminalRecording {
+	var clientReportedUserID *uuid.UUID
+	if recording.ClientReportedUserID.Valid {
+		clientReportedUserID = &recording.ClientReportedUserID.UUID
+	}
+	return codersdk.TerminalRecording{
+		ID:                   recording.ID,
+		CreatedAt:            recording.CreatedAt,
+		StartedAt:            recording.StartedAt,
+		EndedAt:              recording.EndedAt,
+		OrganizationID:       recording.OrganizationID,
+		WorkspaceID:          recording.WorkspaceID,
+		AgentID:              recording.AgentID,
+		UserID:               recording.UserID,
+		ClientReportedUserID: clientReportedUserID,
+		Type:                 codersdk.TerminalRecordingType(recording.Type),
+		Size:                 recording.Size,
+	}
+}
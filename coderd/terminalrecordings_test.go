@@ -0,0 +1,123 @@
+package coderd_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/agent/asciicast"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTerminalRecordings(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: echo.ProvisionApplyWithAgent(authToken),
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	// Recording is enabled by the template.
+	metadata, err := agentClient.Metadata(ctx)
+	require.NoError(t, err)
+	require.False(t, metadata.RecordTerminalSessions)
+	template, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		RecordTerminalSessions: true,
+	})
+	require.NoError(t, err)
+	require.True(t, template.RecordTerminalSessions)
+	metadata, err = agentClient.Metadata(ctx)
+	require.NoError(t, err)
+	require.True(t, metadata.RecordTerminalSessions)
+
+	var buf bytes.Buffer
+	w, err := asciicast.NewWriter(&buf, asciicast.Header{Width: 80, Height: 24})
+	require.NoError(t, err)
+	_, err = w.Write([]byte("hello\r\n"))
+	require.NoError(t, err)
+	recording := buf.Bytes()
+
+	err = agentClient.PostTerminalRecording(ctx, codersdk.TerminalRecordingTypeSSH, uuid.Nil, bytes.NewReader([]byte("invalid")))
+	var sdkErr *codersdk.Error
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusBadRequest, sdkErr.StatusCode())
+
+	// Recordings are stored with the workspace owner.
+	err = agentClient.PostTerminalRecording(ctx, codersdk.TerminalRecordingTypeSSH, uuid.Nil, bytes.NewReader(recording))
+	require.NoError(t, err)
+
+	recordings, err := client.TerminalRecordings(ctx, codersdk.TerminalRecordingsRequest{
+		WorkspaceID: workspace.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, recordings, 1)
+	require.Equal(t, codersdk.TerminalRecordingTypeSSH, recordings[0].Type)
+	require.Equal(t, user.UserID, recordings[0].UserID)
+	require.Nil(t, recordings[0].ClientReportedUserID)
+	require.EqualValues(t, len(recording), recordings[0].Size)
+
+	recordingID := recordings[0].ID
+	data, err := client.DownloadTerminalRecording(ctx, recordingID)
+	require.NoError(t, err)
+	require.Equal(t, recording, data)
+
+	// The user the client reports is stored separately, any client can
+	// claim to be any user so recordings stay attributed to the owner.
+	member, memberUser := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	forgedUserIDs := []uuid.UUID{memberUser.ID, uuid.New()}
+	for _, forgedUserID := range forgedUserIDs {
+		err = agentClient.PostTerminalRecording(ctx, codersdk.TerminalRecordingTypeReconnectingPTY, forgedUserID, bytes.NewReader(recording))
+		require.NoError(t, err)
+	}
+	recordings, err = client.TerminalRecordings(ctx, codersdk.TerminalRecordingsRequest{
+		UserID: memberUser.ID,
+	})
+	require.NoError(t, err)
+	require.Empty(t, recordings)
+	recordings, err = client.TerminalRecordings(ctx, codersdk.TerminalRecordingsRequest{
+		WorkspaceID: workspace.ID,
+	})
+	require.NoError(t, err)
+	require.Len(t, recordings, 3)
+	clientReportedUserIDs := make([]uuid.UUID, 0, len(forgedUserIDs))
+	for _, recording := range recordings {
+		require.Equal(t, user.UserID, recording.UserID)
+		if recording.ClientReportedUserID != nil {
+			require.Equal(t, codersdk.TerminalRecordingTypeReconnectingPTY, recording.Type)
+			clientReportedUserIDs = append(clientReportedUserIDs, *recording.ClientReportedUserID)
+		}
+	}
+	require.ElementsMatch(t, forgedUserIDs, clientReportedUserIDs)
+
+	// Members can't see recordings.
+	recordings, err = member.TerminalRecordings(ctx, codersdk.TerminalRecordingsRequest{})
+	require.NoError(t, err)
+	require.Empty(t, recordings)
+	_, err = member.DownloadTerminalRecording(ctx, recordingID)
+	require.ErrorAs(t, err, &sdkErr)
+	require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+}
@@ -868,6 +868,10 @@ func (api *API) updateSiteUserRoles(ctx context.Context, args database.UpdateUse
 			return database.User{}, xerrors.Errorf("Must only update site wide roles")
 		}
 
+		// Custom roles are validated when they're assigned.
+		if !rbac.IsBuiltInRole(r) {
+			continue
+		}
 		if _, err := rbac.RoleByName(r); err != nil {
 			return database.User{}, xerrors.Errorf("%q is not a supported role", r)
 		}
@@ -1054,8 +1058,7 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
-		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
+		convertedUser.Roles = append(convertedUser.Roles, convertRoleName(roleName))
 	}
 
 	return convertedUser
@@ -1091,8 +1094,8 @@ func findUser(id uuid.UUID, users []database.User) *database.User {
 	return nil
 }
 
-func convertAPIKey(k database.APIKey) codersdk.APIKey {
-	return codersdk.APIKey{
+func convertAPIKey(k database.APIKey, resources []database.APIKeyScopeResource) codersdk.APIKey {
+	key := codersdk.APIKey{
 		ID:              k.ID,
 		UserID:          k.UserID,
 		LastUsed:        k.LastUsed,
@@ -1104,4 +1107,12 @@ func convertAPIKey(k database.APIKey) codersdk.APIKey {
 		LifetimeSeconds: k.LifetimeSeconds,
 		TokenName:       k.TokenName,
 	}
+	for _, resource := range resources {
+		key.Resources = append(key.Resources, codersdk.APIKeyScopeResource{
+			ResourceType: resource.ResourceType,
+			ResourceID:   resource.ResourceID,
+			Action:       resource.Action,
+		})
+	}
+	return key
 }
@@ -12,13 +12,16 @@ import (
 	"net/netip"
 	"net/url"
 	"runtime/pprof"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
 
+	"github.com/go-chi/chi/v5"
 	"github.com/google/uuid"
+	"github.com/tabbed/pqtype"
 	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/mod/semver"
 	"golang.org/x/xerrors"
@@ -26,7 +29,6 @@ import (
 	"tailscale.com/tailcfg"
 
 	"cdr.dev/slog"
-	"github.com/coder/coder/agent"
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbauthz"
 	"github.com/coder/coder/coderd/gitauth"
@@ -136,6 +138,26 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
+	metadata, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agent metadata.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// The workspace owner may not be allowed to read the template, but the
+	// agent needs to know whether sessions are recorded.
+	// nolint:gocritic // Reading the template settings is a system function.
+	template, err := api.Database.GetTemplateByID(dbauthz.AsSystemRestricted(ctx), workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
 	vscodeProxyURI := strings.ReplaceAll(api.AppHostname, "*",
 		fmt.Sprintf("%s://{{port}}--%s--%s--%s",
 			api.AccessURL.Scheme,
@@ -148,17 +170,19 @@ func (api *API) workspaceAgentMetadata(rw http.ResponseWriter, r *http.Request)
 	}
 
 	httpapi.Write(ctx, rw, http.StatusOK, agentsdk.Metadata{
-		Apps:                  convertApps(dbApps),
-		DERPMap:               api.DERPMap,
-		GitAuthConfigs:        len(api.GitAuthConfigs),
-		EnvironmentVariables:  apiAgent.EnvironmentVariables,
-		StartupScript:         apiAgent.StartupScript,
-		Directory:             apiAgent.Directory,
-		VSCodePortProxyURI:    vscodeProxyURI,
-		MOTDFile:              workspaceAgent.MOTDFile,
-		StartupScriptTimeout:  time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
-		ShutdownScript:        apiAgent.ShutdownScript,
-		ShutdownScriptTimeout: time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Apps:                   convertApps(dbApps),
+		DERPMap:                api.DERPMap,
+		GitAuthConfigs:         len(api.GitAuthConfigs),
+		EnvironmentVariables:   apiAgent.EnvironmentVariables,
+		StartupScript:          apiAgent.StartupScript,
+		Directory:              apiAgent.Directory,
+		VSCodePortProxyURI:     vscodeProxyURI,
+		MOTDFile:               workspaceAgent.MOTDFile,
+		StartupScriptTimeout:   time.Duration(apiAgent.StartupScriptTimeoutSeconds) * time.Second,
+		ShutdownScript:         apiAgent.ShutdownScript,
+		ShutdownScriptTimeout:  time.Duration(apiAgent.ShutdownScriptTimeoutSeconds) * time.Second,
+		Metadata:               convertWorkspaceAgentMetadataDescriptions(metadata),
+		RecordTerminalSessions: api.DeploymentValues.RecordTerminalSessions.Value() || template.RecordTerminalSessions,
 	})
 }
 
@@ -433,7 +457,7 @@ func (api *API) workspaceAgentStartupLogs(rw http.ResponseWriter, r *http.Reques
 	}
 	go httpapi.Heartbeat(ctx, conn)
 
-	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageText)
+	ctx, wsNetConn := httpapi.WebsocketNetConn(ctx, conn, websocket.MessageText)
 	defer wsNetConn.Close() // Also closes conn.
 
 	// The Go stdlib JSON encoder appends a newline character after message write.
@@ -573,54 +597,7 @@ func (api *API) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	reconnect, err := uuid.Parse(r.URL.Query().Get("reconnect"))
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "Query param 'reconnect' must be a valid UUID.",
-			Validations: []codersdk.ValidationError{
-				{Field: "reconnect", Detail: "invalid UUID"},
-			},
-		})
-		return
-	}
-	height, err := strconv.ParseUint(r.URL.Query().Get("height"), 10, 16)
-	if err != nil {
-		height = 80
-	}
-	width, err := strconv.ParseUint(r.URL.Query().Get("width"), 10, 16)
-	if err != nil {
-		width = 80
-	}
-
-	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
-		CompressionMode: websocket.CompressionDisabled,
-	})
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "Failed to accept websocket.",
-			Detail:  err.Error(),
-		})
-		return
-	}
-
-	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageBinary)
-	defer wsNetConn.Close() // Also closes conn.
-
-	go httpapi.Heartbeat(ctx, conn)
-
-	agentConn, release, err := api.workspaceAgentCache.Acquire(workspaceAgent.ID)
-	if err != nil {
-		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial workspace agent: %s", err))
-		return
-	}
-	defer release()
-	ptNetConn, err := agentConn.ReconnectingPTY(ctx, reconnect, uint16(height), uint16(width), r.URL.Query().Get("command"))
-	if err != nil {
-		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial: %s", err))
-		return
-	}
-	defer ptNetConn.Close()
-	agent.Bicopy(ctx, wsNetConn, ptNetConn)
+	api.workspaceAppServer.ProxyReconnectingPTY(rw, r, workspaceAgent.ID, httpmw.APIKey(r).UserID)
 }
 
 // @Summary Get listening ports for workspace agent
@@ -712,10 +689,15 @@ func (api *API) workspaceAgentListeningPorts(rw http.ResponseWriter, r *http.Req
 	// common non-HTTP ports such as databases, FTP, SSH, etc.
 	filteredPorts := make([]codersdk.WorkspaceAgentListeningPort, 0, len(portsResponse.Ports))
 	for _, port := range portsResponse.Ports {
+		// Unix sockets have no port to filter by.
+		if port.Network == "unix" {
+			filteredPorts = append(filteredPorts, port)
+			continue
+		}
 		if port.Port < codersdk.WorkspaceAgentMinimumListeningPort {
 			continue
 		}
-		if _, ok := appPorts[port.Port]; ok {
+		if _, ok := appPorts[port.Port]; ok && port.Network == "tcp" {
 			continue
 		}
 		if _, ok := codersdk.WorkspaceAgentIgnoredListeningPorts[port.Port]; ok {
@@ -888,7 +870,7 @@ func (api *API) workspaceAgentCoordinate(rw http.ResponseWriter, r *http.Request
 		return
 	}
 
-	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageBinary)
+	ctx, wsNetConn := httpapi.WebsocketNetConn(ctx, conn, websocket.MessageBinary)
 	defer wsNetConn.Close()
 
 	// We use a custom heartbeat routine here instead of `httpapi.Heartbeat`
@@ -1078,18 +1060,23 @@ func (api *API) workspaceAgentCoordinate(rw http.ResponseWriter, r *http.Request
 func (api *API) workspaceAgentClientCoordinate(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
 
-	workspace := httpmw.WorkspaceParam(r)
-	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
-		httpapi.ResourceNotFound(rw)
-		return
-	}
-	// This is used by Enterprise code to control the functionality of this route.
-	override := api.WorkspaceClientCoordinateOverride.Load()
-	if override != nil {
-		overrideFunc := *override
-		if overrideFunc != nil && overrideFunc(rw) {
+	// Workspace proxies are trusted to only connect on behalf of users that
+	// have already been authorized with a signed app ticket, so authorization
+	// is only checked for users connecting directly.
+	if _, isProxy := httpmw.WorkspaceProxyOptional(r); !isProxy {
+		workspace := httpmw.WorkspaceParam(r)
+		if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+			httpapi.ResourceNotFound(rw)
 			return
 		}
+		// This is used by Enterprise code to control the functionality of this route.
+		override := api.WorkspaceClientCoordinateOverride.Load()
+		if override != nil {
+			overrideFunc := *override
+			if overrideFunc != nil && overrideFunc(rw) {
+				return
+			}
+		}
 	}
 
 	api.WebsocketWaitMutex.Lock()
@@ -1106,7 +1093,7 @@ func (api *API) workspaceAgentClientCoordinate(rw http.ResponseWriter, r *http.R
 		})
 		return
 	}
-	ctx, wsNetConn := websocketNetConn(ctx, conn, websocket.MessageBinary)
+	ctx, wsNetConn := httpapi.WebsocketNetConn(ctx, conn, websocket.MessageBinary)
 	defer wsNetConn.Close()
 
 	go httpapi.Heartbeat(ctx, conn)
@@ -1151,6 +1138,13 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 			return codersdk.WorkspaceAgent{}, xerrors.Errorf("unmarshal env vars: %w", err)
 		}
 	}
+	var netcheck *codersdk.WorkspaceAgentNetcheck
+	if dbAgent.Netcheck.Valid {
+		err := json.Unmarshal(dbAgent.Netcheck.RawMessage, &netcheck)
+		if err != nil {
+			return codersdk.WorkspaceAgent{}, xerrors.Errorf("unmarshal netcheck: %w", err)
+		}
+	}
 	troubleshootingURL := agentFallbackTroubleshootingURL
 	if dbAgent.TroubleshootingURL != "" {
 		troubleshootingURL = dbAgent.TroubleshootingURL
@@ -1179,6 +1173,7 @@ func convertWorkspaceAgent(derpMap *tailcfg.DERPMap, coordinator tailnet.Coordin
 		StartupScriptTimeoutSeconds:  dbAgent.StartupScriptTimeoutSeconds,
 		ShutdownScript:               dbAgent.ShutdownScript.String,
 		ShutdownScriptTimeoutSeconds: dbAgent.ShutdownScriptTimeoutSeconds,
+		Netcheck:                     netcheck,
 	}
 	node := coordinator.Node(dbAgent.ID)
 	if node != nil {
@@ -1397,6 +1392,255 @@ func (api *API) workspaceAgentReportLifecycle(rw http.ResponseWriter, r *http.Re
 	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
 }
 
+// @Summary Submit workspace agent netcheck
+// @ID submit-workspace-agent-netcheck
+// @Security CoderSessionToken
+// @Accept json
+// @Tags Agents
+// @Param request body codersdk.WorkspaceAgentNetcheck true "Netcheck report"
+// @Success 204 "Success"
+// @Router /workspaceagents/me/netcheck [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceAgentPostNetcheck(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+
+	var req codersdk.WorkspaceAgentNetcheck
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	// The clock of the workspace may be off.
+	req.CheckedAt = database.Now()
+
+	raw, err := json.Marshal(req)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	err = api.Database.UpdateWorkspaceAgentNetcheckByID(ctx, database.UpdateWorkspaceAgentNetcheckByIDParams{
+		ID: workspaceAgent.ID,
+		Netcheck: pqtype.NullRawMessage{
+			RawMessage: raw,
+			Valid:      true,
+		},
+	})
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// @Summary Submit workspace agent metadata
+// @ID submit-workspace-agent-metadata
+// @Security CoderSessionToken
+// @Accept json
+// @Tags Agents
+// @Param request body agentsdk.PostMetadataRequest true "Workspace agent metadata request"
+// @Param key path string true "metadata key" format(string)
+// @Success 204 "Success"
+// @Router /workspaceagents/me/metadata/{key} [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceAgentPostMetadata(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req agentsdk.PostMetadataRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	workspaceAgent := httpmw.WorkspaceAgent(r)
+	key := chi.URLParam(r, "key")
+
+	const (
+		maxValueLen = 32 << 10
+		maxErrorLen = maxValueLen
+	)
+
+	metadataError := req.Error
+
+	// We overwrite the error if the provided payload is too long.
+	if len(req.Value) > maxValueLen {
+		metadataError = fmt.Sprintf("value of %d bytes exceeded %d bytes", len(req.Value), maxValueLen)
+		req.Value = req.Value[:maxValueLen]
+	}
+
+	if len(req.Error) > maxErrorLen {
+		metadataError = fmt.Sprintf("error of %d bytes exceeded %d bytes", len(req.Error), maxErrorLen)
+	}
+
+	// A negative age would put the collection time in the future.
+	if req.Age < 0 {
+		req.Age = 0
+	}
+
+	datum := database.UpdateWorkspaceAgentMetadataParams{
+		WorkspaceAgentID: workspaceAgent.ID,
+		Key:              key,
+		Value:            req.Value,
+		Error:            metadataError,
+		// We ignore the CollectedAt from the agent to avoid bugs caused by
+		// clock skew.
+		CollectedAt: database.Now().Add(-time.Duration(req.Age) * time.Second),
+	}
+
+	err := api.Database.UpdateWorkspaceAgentMetadata(ctx, datum)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	api.Logger.Debug(
+		ctx, "accepted metadata report",
+		slog.F("agent", workspaceAgent.ID),
+		slog.F("collected_at", datum.CollectedAt),
+		slog.F("key", datum.Key),
+	)
+
+	err = api.Pubsub.Publish(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), []byte(datum.Key))
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusNoContent, nil)
+}
+
+// @Summary Watch for workspace agent metadata updates
+// @ID watch-for-workspace-agent-metadata-updates
+// @Security CoderSessionToken
+// @Produce text/event-stream
+// @Tags Agents
+// @Param workspaceagent path string true "Workspace agent ID" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceagents/{workspaceagent}/watch-metadata [get]
+func (api *API) watchWorkspaceAgentMetadata(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx            = r.Context()
+		workspaceAgent = httpmw.WorkspaceAgentParam(r)
+	)
+
+	sendEvent, senderClosed, err := httpapi.ServerSentEventSender(rw, r)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error setting up server-sent events.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	// Prevent handler from returning until the sender is closed.
+	defer func() {
+		<-senderClosed
+	}()
+
+	// Ignore all trace spans after this, they're not too useful.
+	ctx = trace.ContextWithSpan(ctx, tracing.NoopSpan)
+
+	// Updates are coalesced so a chatty agent can't overwhelm the
+	// database with reads.
+	refresh := make(chan struct{}, 1)
+	cancelSubscribe, err := api.Pubsub.Subscribe(watchWorkspaceAgentMetadataChannel(workspaceAgent.ID), func(_ context.Context, _ []byte) {
+		select {
+		case refresh <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		_ = sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeError,
+			Data: codersdk.Response{
+				Message: "Internal error subscribing to workspace agent metadata.",
+				Detail:  err.Error(),
+			},
+		})
+		return
+	}
+	defer cancelSubscribe()
+
+	sendMetadata := func() error {
+		md, err := api.Database.GetWorkspaceAgentMetadata(ctx, workspaceAgent.ID)
+		if err != nil {
+			_ = sendEvent(ctx, codersdk.ServerSentEvent{
+				Type: codersdk.ServerSentEventTypeError,
+				Data: codersdk.Response{
+					Message: "Internal error fetching workspace agent metadata.",
+					Detail:  err.Error(),
+				},
+			})
+			return err
+		}
+		return sendEvent(ctx, codersdk.ServerSentEvent{
+			Type: codersdk.ServerSentEventTypeData,
+			Data: convertWorkspaceAgentMetadata(md),
+		})
+	}
+
+	// Periodically resend the metadata so the age of each
+	// value stays accurate on the client.
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+
+	for {
+		err = sendMetadata()
+		if err != nil {
+			return
+		}
+
+		select {
+		case <-senderClosed:
+			return
+		case <-refresh:
+		case <-ticker.C:
+		}
+	}
+}
+
+func convertWorkspaceAgentMetadata(db []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadata {
+	// An empty array is easier for clients to handle than a null.
+	result := []codersdk.WorkspaceAgentMetadata{}
+	for _, datum := range db {
+		result = append(result, codersdk.WorkspaceAgentMetadata{
+			Result: codersdk.WorkspaceAgentMetadataResult{
+				Value:       datum.Value,
+				Error:       datum.Error,
+				CollectedAt: datum.CollectedAt,
+				Age:         int64(time.Since(datum.CollectedAt).Seconds()),
+			},
+			Description: codersdk.WorkspaceAgentMetadataDescription{
+				DisplayName: datum.DisplayName,
+				Key:         datum.Key,
+				Script:      datum.Script,
+				Interval:    datum.Interval,
+				Timeout:     datum.Timeout,
+			},
+		})
+	}
+	// Sorting prevents the order from jumping around between updates.
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Description.Key < result[j].Description.Key
+	})
+	return result
+}
+
+func convertWorkspaceAgentMetadataDescriptions(db []database.WorkspaceAgentMetadatum) []codersdk.WorkspaceAgentMetadataDescription {
+	result := []codersdk.WorkspaceAgentMetadataDescription{}
+	for _, datum := range db {
+		result = append(result, codersdk.WorkspaceAgentMetadataDescription{
+			DisplayName: datum.DisplayName,
+			Key:         datum.Key,
+			Script:      datum.Script,
+			Interval:    datum.Interval,
+			Timeout:     datum.Timeout,
+		})
+	}
+	return result
+}
+
+func watchWorkspaceAgentMetadataChannel(id uuid.UUID) string {
+	return "workspace_agent_metadata:" + id.String()
+}
+
 // @Summary Submit workspace agent application health
 // @ID submit-workspace-agent-application-health
 // @Security CoderSessionToken
@@ -1801,47 +2045,6 @@ func (api *API) gitAuthCallback(gitAuthConfig *gitauth.Config) http.HandlerFunc
 	}
 }
 
-// wsNetConn wraps net.Conn created by websocket.NetConn(). Cancel func
-// is called if a read or write error is encountered.
-type wsNetConn struct {
-	cancel context.CancelFunc
-	net.Conn
-}
-
-func (c *wsNetConn) Read(b []byte) (n int, err error) {
-	n, err = c.Conn.Read(b)
-	if err != nil {
-		c.cancel()
-	}
-	return n, err
-}
-
-func (c *wsNetConn) Write(b []byte) (n int, err error) {
-	n, err = c.Conn.Write(b)
-	if err != nil {
-		c.cancel()
-	}
-	return n, err
-}
-
-func (c *wsNetConn) Close() error {
-	defer c.cancel()
-	return c.Conn.Close()
-}
-
-// websocketNetConn wraps websocket.NetConn and returns a context that
-// is tied to the parent context and the lifetime of the conn. Any error
-// during read or write will cancel the context, but not close the
-// conn. Close should be called to release context resources.
-func websocketNetConn(ctx context.Context, conn *websocket.Conn, msgType websocket.MessageType) (context.Context, net.Conn) {
-	ctx, cancel := context.WithCancel(ctx)
-	nc := websocket.NetConn(ctx, conn, msgType)
-	return ctx, &wsNetConn{
-		cancel: cancel,
-		Conn:   nc,
-	}
-}
-
 func convertWorkspaceAgentStartupLogs(logs []database.WorkspaceAgentStartupLog) []codersdk.WorkspaceAgentStartupLog {
 	sdk := make([]codersdk.WorkspaceAgentStartupLog, 0, len(logs))
 	for _, log := range logs {
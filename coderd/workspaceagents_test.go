@@ -1257,3 +1257,219 @@ func TestWorkspaceAgent_LifecycleState(t *testing.T) {
 		}
 	})
 }
+
+func TestWorkspaceAgent_Metadata(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Metadata: []*proto.Agent_Metadata{
+								{
+									DisplayName: "First Meta",
+									Key:         "foo1",
+									Script:      "echo hi",
+									Interval:    10,
+									Timeout:     3,
+								},
+								{
+									DisplayName: "Second Meta",
+									Key:         "foo2",
+									Script:      "echo howdy",
+									Interval:    10,
+									Timeout:     3,
+								},
+							},
+							Id: uuid.NewString(),
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	build := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	var agentID uuid.UUID
+	for _, res := range build.Resources {
+		for _, a := range res.Agents {
+			agentID = a.ID
+		}
+	}
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+
+	ctx := testutil.Context(t, testutil.WaitMedium)
+
+	metadata, err := agentClient.Metadata(ctx)
+	require.NoError(t, err)
+	require.Len(t, metadata.Metadata, 2)
+
+	post := func(key string, mr agentsdk.PostMetadataRequest) {
+		err := agentClient.PostMetadata(ctx, key, mr)
+		require.NoError(t, err, "post metadata", t)
+	}
+
+	updates, errors := client.WatchWorkspaceAgentMetadata(ctx, agentID)
+
+	// waitUpdate receives updates until one satisfies cond. Updates
+	// are resent periodically, so stale ones are skipped.
+	waitUpdate := func(cond func([]codersdk.WorkspaceAgentMetadata) bool) []codersdk.WorkspaceAgentMetadata {
+		for {
+			select {
+			case <-ctx.Done():
+				t.Fatalf("timed out waiting for metadata update")
+				return nil
+			case err := <-errors:
+				t.Fatalf("error watching metadata: %v", err)
+				return nil
+			case update := <-updates:
+				if cond(update) {
+					return update
+				}
+			}
+		}
+	}
+
+	check := func(want agentsdk.PostMetadataRequest, got codersdk.WorkspaceAgentMetadata) {
+		require.Equal(t, want.Value, got.Result.Value)
+		require.Equal(t, want.Error, got.Result.Error)
+	}
+
+	// The initial update contains the empty metadata.
+	update := waitUpdate(func([]codersdk.WorkspaceAgentMetadata) bool { return true })
+	require.Len(t, update, 2)
+	require.Equal(t, "foo1", update[0].Description.Key)
+	require.Empty(t, update[0].Result.Value)
+
+	wantMetadata1 := agentsdk.PostMetadataRequest{
+		CollectedAt: time.Now(),
+		Value:       "bar",
+	}
+	post("foo1", wantMetadata1)
+
+	update = waitUpdate(func(md []codersdk.WorkspaceAgentMetadata) bool {
+		return md[0].Result.Value == wantMetadata1.Value
+	})
+	check(wantMetadata1, update[0])
+	// The second metadata is still empty.
+	require.Empty(t, update[1].Result.Value)
+
+	wantMetadata2 := agentsdk.PostMetadataRequest{
+		CollectedAt: time.Now(),
+		Error:       "script failed",
+	}
+	post("foo2", wantMetadata2)
+
+	update = waitUpdate(func(md []codersdk.WorkspaceAgentMetadata) bool {
+		return md[1].Result.Error == wantMetadata2.Error
+	})
+	check(wantMetadata1, update[0])
+	check(wantMetadata2, update[1])
+
+	// Values that are too long are truncated and reported as an error.
+	wantMetadata1.Value = strings.Repeat("a", 64<<10)
+	post("foo1", wantMetadata1)
+
+	update = waitUpdate(func(md []codersdk.WorkspaceAgentMetadata) bool {
+		return md[0].Result.Error != ""
+	})
+	require.Len(t, update[0].Result.Value, 32<<10)
+	require.Contains(t, update[0].Result.Error, "exceeded")
+
+	// Negative ages don't put the collection time in the future.
+	wantMetadata2 = agentsdk.PostMetadataRequest{
+		CollectedAt: time.Now(),
+		Age:         -3600,
+		Value:       "future",
+	}
+	post("foo2", wantMetadata2)
+
+	update = waitUpdate(func(md []codersdk.WorkspaceAgentMetadata) bool {
+		return md[1].Result.Value == wantMetadata2.Value
+	})
+	require.False(t, update[1].Result.CollectedAt.After(time.Now()), "collected at %s", update[1].Result.CollectedAt)
+}
+
+func TestWorkspaceAgent_Netcheck(t *testing.T) {
+	t.Parallel()
+
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: echo.ProvisionApplyWithAgent(authToken),
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	workspace, err := client.Workspace(ctx, workspace.ID)
+	require.NoError(t, err)
+	agentID := workspace.LatestBuild.Resources[0].Agents[0].ID
+	require.Nil(t, workspace.LatestBuild.Resources[0].Agents[0].Netcheck)
+
+	debugNetcheck := func() *http.Response {
+		res, err := client.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/debug/workspace-agents/%s/netcheck", agentID), nil)
+		require.NoError(t, err)
+		t.Cleanup(func() { _ = res.Body.Close() })
+		return res
+	}
+	res := debugNetcheck()
+	require.Equal(t, http.StatusNotFound, res.StatusCode)
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	varies := false
+	err = agentClient.PostNetcheck(ctx, codersdk.WorkspaceAgentNetcheck{
+		UDP:                   true,
+		IPv4:                  true,
+		GlobalV4:              "203.0.113.1:41641",
+		MappingVariesByDestIP: &varies,
+		NATType:               "easy",
+		PreferredDERP:         1,
+		RegionLatencyMS:       map[int]float64{1: 12.5},
+	})
+	require.NoError(t, err)
+
+	agent, err := client.WorkspaceAgent(ctx, agentID)
+	require.NoError(t, err)
+	require.NotNil(t, agent.Netcheck)
+	require.True(t, agent.Netcheck.UDP)
+	require.Equal(t, "easy", agent.Netcheck.NATType)
+	require.Equal(t, 1, agent.Netcheck.PreferredDERP)
+	require.Equal(t, map[int]float64{1: 12.5}, agent.Netcheck.RegionLatencyMS)
+	require.NotNil(t, agent.Netcheck.MappingVariesByDestIP)
+	require.False(t, *agent.Netcheck.MappingVariesByDestIP)
+	require.False(t, agent.Netcheck.CheckedAt.IsZero())
+
+	res = debugNetcheck()
+	require.Equal(t, http.StatusOK, res.StatusCode)
+	var netcheck codersdk.WorkspaceAgentNetcheck
+	require.NoError(t, json.NewDecoder(res.Body).Decode(&netcheck))
+	require.Equal(t, *agent.Netcheck, netcheck)
+}
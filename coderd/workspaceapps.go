@@ -1,25 +1,17 @@
 package coderd
 
 import (
-	"bytes"
-	"context"
 	"crypto/sha256"
 	"crypto/subtle"
 	"database/sql"
-	"encoding/base64"
-	"encoding/json"
 	"fmt"
 	"net/http"
-	"net/http/httputil"
 	"net/url"
-	"strconv"
 	"strings"
 	"time"
 
 	"github.com/go-chi/chi/v5"
-	"go.opentelemetry.io/otel/trace"
 	"golang.org/x/xerrors"
-	jose "gopkg.in/square/go-jose.v2"
 
 	"cdr.dev/slog"
 	"github.com/coder/coder/coderd/database"
@@ -27,41 +19,16 @@ import (
 	"github.com/coder/coder/coderd/httpapi"
 	"github.com/coder/coder/coderd/httpmw"
 	"github.com/coder/coder/coderd/rbac"
-	"github.com/coder/coder/coderd/tracing"
 	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/codersdk"
 	"github.com/coder/coder/site"
 )
 
-const (
-	// This needs to be a super unique query parameter because we don't want to
-	// conflict with query parameters that users may use.
-	//nolint:gosec
-	subdomainProxyAPIKeyParam = "coder_application_connect_api_key_35e783"
-	// appLogoutHostname is the hostname to use for the logout redirect. When
-	// the dashboard logs out, it will redirect to this subdomain of the app
-	// hostname, and the server will remove the cookie and redirect to the main
-	// login page.
-	// It is important that this URL can never match a valid app hostname.
-	appLogoutHostname = "coder-logout"
-)
-
-// nonCanonicalHeaders is a map from "canonical" headers to the actual header we
-// should send to the app in the workspace. Some headers (such as the websocket
-// upgrade headers from RFC 6455) are not canonical according to the HTTP/1
-// spec. Golang has said that they will not add custom cases for these headers,
-// so we need to do it ourselves.
-//
-// Some apps our customers use are sensitive to the case of these headers.
-//
-// https://github.com/golang/go/issues/18495
-var nonCanonicalHeaders = map[string]string{
-	"Sec-Websocket-Accept":     "Sec-WebSocket-Accept",
-	"Sec-Websocket-Extensions": "Sec-WebSocket-Extensions",
-	"Sec-Websocket-Key":        "Sec-WebSocket-Key",
-	"Sec-Websocket-Protocol":   "Sec-WebSocket-Protocol",
-	"Sec-Websocket-Version":    "Sec-WebSocket-Version",
-}
+// appLogoutHostname is the hostname to use for the logout redirect. When the
+// dashboard logs out, it will redirect to this subdomain of the app hostname,
+// and the server will remove the cookie and redirect to the main login page.
+// It is important that this URL can never match a valid app hostname.
+const appLogoutHostname = "coder-logout"
 
 // @Summary Get applications host
 // @ID get-applications-host
@@ -137,7 +104,7 @@ func (api *API) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	api.proxyWorkspaceApplication(rw, r, *ticket, chiPath)
+	api.workspaceAppServer.ProxyApp(rw, r, *ticket, chiPath)
 }
 
 // handleSubdomainApplications handles subdomain-based application proxy
@@ -213,9 +180,9 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 
 			// If the request has the special query param then we need to set a
 			// cookie and strip that query parameter.
-			if encryptedAPIKey := r.URL.Query().Get(subdomainProxyAPIKeyParam); encryptedAPIKey != "" {
+			if encryptedAPIKey := r.URL.Query().Get(workspaceapps.SubdomainProxyAPIKeyParam); encryptedAPIKey != "" {
 				// Exchange the encoded API key for a real one.
-				_, token, err := decryptAPIKey(r.Context(), api.Database, encryptedAPIKey)
+				_, token, err := workspaceapps.DecryptAPIKey(r.Context(), api.Database, encryptedAPIKey)
 				if err != nil {
 					site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
 						Status:      http.StatusBadRequest,
@@ -237,7 +204,7 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 					path = "/"
 				}
 				q := r.URL.Query()
-				q.Del(subdomainProxyAPIKeyParam)
+				q.Del(workspaceapps.SubdomainProxyAPIKeyParam)
 				rawQuery := q.Encode()
 				if rawQuery != "" {
 					path += "?" + q.Encode()
@@ -263,7 +230,7 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 			// app.
 			mws := chi.Middlewares(middlewares)
 			mws.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
-				api.proxyWorkspaceApplication(rw, r, *ticket, r.URL.Path)
+				api.workspaceAppServer.ProxyApp(rw, r, *ticket, r.URL.Path)
 			})).ServeHTTP(rw, r.WithContext(ctx))
 		})
 	}
@@ -285,7 +252,9 @@ func (api *API) handleSubdomainApplications(middlewares ...func(http.Handler) ht
 // @Router /applications/auth-redirect [get]
 func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-	if api.AppHostname == "" {
+	proxyForHost := api.WorkspaceProxyForHost.Load()
+	hasProxies := proxyForHost != nil && *proxyForHost != nil
+	if api.AppHostname == "" && !hasProxies {
 		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
 			Message: "The server does not accept subdomain-based application requests.",
 		})
@@ -320,20 +289,42 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 
 	// Ensure that the redirect URI is a subdomain of api.AppHostname and is a
 	// valid app subdomain.
-	subdomain, ok := httpapi.ExecuteHostnamePattern(api.AppHostnameRegex, u.Host)
-	if !ok {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "The redirect_uri query parameter must be a valid app subdomain.",
-		})
-		return
+	var subdomain string
+	ok := false
+	if api.AppHostnameRegex != nil {
+		subdomain, ok = httpapi.ExecuteHostnamePattern(api.AppHostnameRegex, u.Host)
 	}
-	_, err = httpapi.ParseSubdomainAppURL(subdomain)
-	if err != nil {
-		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-			Message: "The redirect_uri query parameter must be a valid app subdomain.",
-			Detail:  err.Error(),
-		})
-		return
+	if ok {
+		_, err = httpapi.ParseSubdomainAppURL(subdomain)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "The redirect_uri query parameter must be a valid app subdomain.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+	} else {
+		// Otherwise the redirect URI must be served by a workspace proxy,
+		// which accepts both path-based and subdomain-based apps.
+		var proxyURL *url.URL
+		if hasProxies {
+			proxyURL, ok, err = (*proxyForHost)(ctx, u.Host)
+			if err != nil {
+				httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+					Message: "Internal error fetching workspace proxies.",
+					Detail:  err.Error(),
+				})
+				return
+			}
+		}
+		if !ok {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "The redirect_uri query parameter must be a valid app subdomain.",
+			})
+			return
+		}
+		// Use the scheme of the proxy access URL instead.
+		u.Scheme = proxyURL.Scheme
 	}
 
 	// Create the application_connect-scoped API key with the same lifetime as
@@ -360,7 +351,7 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 	}
 
 	// Encrypt the API key.
-	encryptedAPIKey, err := encryptAPIKey(encryptedAPIKeyPayload{
+	encryptedAPIKey, err := workspaceapps.EncryptAPIKey(workspaceapps.EncryptedAPIKeyPayload{
 		APIKey: cookie.Value,
 	})
 	if err != nil {
@@ -374,7 +365,7 @@ func (api *API) workspaceApplicationAuth(rw http.ResponseWriter, r *http.Request
 	// Redirect to the redirect URI with the encrypted API key in the query
 	// parameters.
 	q := u.Query()
-	q.Set(subdomainProxyAPIKeyParam, encryptedAPIKey)
+	q.Set(workspaceapps.SubdomainProxyAPIKeyParam, encryptedAPIKey)
 	u.RawQuery = q.Encode()
 	http.Redirect(rw, r, u.String(), http.StatusTemporaryRedirect)
 }
@@ -561,237 +552,66 @@ func (api *API) setWorkspaceAppCookie(rw http.ResponseWriter, r *http.Request, t
 	return true
 }
 
-func (api *API) proxyWorkspaceApplication(rw http.ResponseWriter, r *http.Request, ticket workspaceapps.Ticket, path string) {
+// workspaceApplicationReconnectingPTYTicket issues a signed ticket that
+// authorizes the user to open a reconnecting PTY to the agent. It is used to
+// open terminals on external workspace proxies, which can't authenticate the
+// user's session token themselves.
+//
+// @Summary Issue signed app ticket for reconnecting PTY
+// @ID issue-signed-app-ticket-for-reconnecting-pty
+// @Security CoderSessionToken
+// @Tags Applications
+// @Accept json
+// @Produce json
+// @Param request body codersdk.IssueReconnectingPTYTicketRequest true "Issue reconnecting PTY ticket request"
+// @Success 200 {object} codersdk.IssueReconnectingPTYTicketResponse
+// @Router /applications/reconnecting-pty-ticket [post]
+func (api *API) workspaceApplicationReconnectingPTYTicket(rw http.ResponseWriter, r *http.Request) {
 	ctx := r.Context()
-
-	// Filter IP headers from untrusted origins.
-	httpmw.FilterUntrustedOriginHeaders(api.RealIPConfig, r)
-	// Ensure proper IP headers get sent to the forwarded application.
-	err := httpmw.EnsureXForwardedForHeader(r)
-	if err != nil {
-		httpapi.InternalServerError(rw, err)
+	apiKey := httpmw.APIKey(r)
+	if !api.Authorize(r, rbac.ActionCreate, apiKey) {
+		httpapi.ResourceNotFound(rw)
 		return
 	}
 
-	appURL, err := url.Parse(ticket.AppURL)
-	if err != nil {
-		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
-			Status:       http.StatusBadRequest,
-			Title:        "Bad Request",
-			Description:  fmt.Sprintf("Application has an invalid URL %q: %s", ticket.AppURL, err.Error()),
-			RetryEnabled: true,
-			DashboardURL: api.AccessURL.String(),
-		})
+	var req codersdk.IssueReconnectingPTYTicketRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
 		return
 	}
 
-	// Verify that the port is allowed. See the docs above
-	// `codersdk.MinimumListeningPort` for more details.
-	port := appURL.Port()
-	if port != "" {
-		portInt, err := strconv.Atoi(port)
-		if err != nil {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-				Message: fmt.Sprintf("App URL %q has an invalid port %q.", ticket.AppURL, port),
-				Detail:  err.Error(),
-			})
-			return
-		}
-
-		if portInt < codersdk.WorkspaceAgentMinimumListeningPort {
-			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
-				Message: fmt.Sprintf("Application port %d is not permitted. Coder reserves ports less than %d for internal use.", portInt, codersdk.WorkspaceAgentMinimumListeningPort),
-			})
-			return
-		}
-	}
-
-	// Ensure path and query parameter correctness.
-	if path == "" {
-		// Web applications typically request paths relative to the
-		// root URL. This allows for routing behind a proxy or subpath.
-		// See https://github.com/coder/code-server/issues/241 for examples.
-		http.Redirect(rw, r, r.URL.Path+"/", http.StatusTemporaryRedirect)
-		return
-	}
-	if path == "/" && r.URL.RawQuery == "" && appURL.RawQuery != "" {
-		// If the application defines a default set of query parameters,
-		// we should always respect them. The reverse proxy will merge
-		// query parameters for server-side requests, but sometimes
-		// client-side applications require the query parameters to render
-		// properly. With code-server, this is the "folder" param.
-		r.URL.RawQuery = appURL.RawQuery
-		http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
+	workspace, err := api.Database.GetWorkspaceByAgentID(ctx, req.AgentID)
+	if xerrors.Is(err, sql.ErrNoRows) || dbauthz.IsNotAuthorizedError(err) {
+		httpapi.ResourceNotFound(rw)
 		return
 	}
-
-	r.URL.Path = path
-	appURL.RawQuery = ""
-
-	proxy := httputil.NewSingleHostReverseProxy(appURL)
-	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
-		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
-			Status:       http.StatusBadGateway,
-			Title:        "Bad Gateway",
-			Description:  "Failed to proxy request to application: " + err.Error(),
-			RetryEnabled: true,
-			DashboardURL: api.AccessURL.String(),
-		})
-	}
-
-	conn, release, err := api.workspaceAgentCache.Acquire(ticket.AgentID)
 	if err != nil {
-		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
-			Status:       http.StatusBadGateway,
-			Title:        "Bad Gateway",
-			Description:  "Could not connect to workspace agent: " + err.Error(),
-			RetryEnabled: true,
-			DashboardURL: api.AccessURL.String(),
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace.",
+			Detail:  err.Error(),
 		})
 		return
 	}
-	defer release()
-	proxy.Transport = conn.HTTPTransport()
-
-	// This strips the session token from a workspace app request.
-	cookieHeaders := r.Header.Values("Cookie")[:]
-	r.Header.Del("Cookie")
-	for _, cookieHeader := range cookieHeaders {
-		r.Header.Add("Cookie", httpapi.StripCoderCookies(cookieHeader))
-	}
-
-	// Convert canonicalized headers to their non-canonicalized counterparts.
-	// See the comment on `nonCanonicalHeaders` for more information on why this
-	// is necessary.
-	for k, v := range r.Header {
-		if n, ok := nonCanonicalHeaders[k]; ok {
-			r.Header.Del(k)
-			r.Header[n] = v
-		}
-	}
-
-	// end span so we don't get long lived trace data
-	tracing.EndHTTPSpan(r, http.StatusOK, trace.SpanFromContext(ctx))
-
-	proxy.ServeHTTP(rw, r)
-}
-
-type encryptedAPIKeyPayload struct {
-	APIKey    string    `json:"api_key"`
-	ExpiresAt time.Time `json:"expires_at"`
-}
-
-// encryptAPIKey encrypts an API key with it's own hashed secret. This is used
-// for smuggling (application_connect scoped) API keys securely to app
-// hostnames.
-//
-// We encrypt API keys when smuggling them in query parameters to avoid them
-// getting accidentally logged in access logs or stored in browser history.
-func encryptAPIKey(data encryptedAPIKeyPayload) (string, error) {
-	if data.APIKey == "" {
-		return "", xerrors.New("API key is empty")
-	}
-	if data.ExpiresAt.IsZero() {
-		// Very short expiry as these keys are only used once as part of an
-		// automatic redirection flow.
-		data.ExpiresAt = database.Now().Add(time.Minute)
-	}
-
-	payload, err := json.Marshal(data)
-	if err != nil {
-		return "", xerrors.Errorf("marshal payload: %w", err)
-	}
-
-	// We use the hashed key secret as the encryption key. The hashed secret is
-	// stored in the API keys table. The HashedSecret is NEVER returned from the
-	// API.
-	//
-	// We chose to use the key secret as the private key for encryption instead
-	// of a shared key for a few reasons:
-	//   1. A single private key used to encrypt every API key would also be
-	//      stored in the database, which means that the risk factor is similar.
-	//   2. The secret essentially rotates for each key (for free!), since each
-	//      key has a different secret. This means that if someone acquires an
-	//      old database dump they can't decrypt new API keys.
-	//   3. These tokens are scoped only for application_connect access.
-	keyID, keySecret, err := httpmw.SplitAPIToken(data.APIKey)
-	if err != nil {
-		return "", xerrors.Errorf("split API key: %w", err)
-	}
-	// SHA256 the key secret so it matches the hashed secret in the database.
-	// The key length doesn't matter to the jose.Encrypter.
-	privateKey := sha256.Sum256([]byte(keySecret))
-
-	// JWEs seem to apply a nonce themselves.
-	encrypter, err := jose.NewEncrypter(
-		jose.A256GCM,
-		jose.Recipient{
-			Algorithm: jose.A256GCMKW,
-			KeyID:     keyID,
-			Key:       privateKey[:],
-		},
-		&jose.EncrypterOptions{
-			Compression: jose.DEFLATE,
-		},
-	)
-	if err != nil {
-		return "", xerrors.Errorf("initializer jose encrypter: %w", err)
-	}
-	encryptedObject, err := encrypter.Encrypt(payload)
-	if err != nil {
-		return "", xerrors.Errorf("encrypt jwe: %w", err)
-	}
-
-	encrypted := encryptedObject.FullSerialize()
-	return base64.RawURLEncoding.EncodeToString([]byte(encrypted)), nil
-}
-
-// decryptAPIKey undoes encryptAPIKey and is used in the subdomain app handler.
-func decryptAPIKey(ctx context.Context, db database.Store, encryptedAPIKey string) (database.APIKey, string, error) {
-	encrypted, err := base64.RawURLEncoding.DecodeString(encryptedAPIKey)
-	if err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("base64 decode encrypted API key: %w", err)
-	}
-
-	object, err := jose.ParseEncrypted(string(encrypted))
-	if err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("parse encrypted API key: %w", err)
-	}
-
-	// Lookup the API key so we can decrypt it.
-	keyID := object.Header.KeyID
-	//nolint:gocritic // needed to check API key
-	key, err := db.GetAPIKeyByID(dbauthz.AsSystemRestricted(ctx), keyID)
-	if err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("get API key by key ID: %w", err)
-	}
-
-	// Decrypt using the hashed secret.
-	decrypted, err := object.Decrypt(key.HashedSecret)
-	if err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("decrypt API key: %w", err)
-	}
-
-	// Unmarshal the payload.
-	var payload encryptedAPIKeyPayload
-	if err := json.Unmarshal(decrypted, &payload); err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("unmarshal decrypted payload: %w", err)
-	}
-
-	// Validate expiry.
-	if payload.ExpiresAt.Before(database.Now()) {
-		return database.APIKey{}, "", xerrors.New("encrypted API key expired")
+	if !api.Authorize(r, rbac.ActionCreate, workspace.ExecutionRBAC()) {
+		httpapi.ResourceNotFound(rw)
+		return
 	}
 
-	// Validate that the key matches the one we got from the DB.
-	gotKeyID, gotKeySecret, err := httpmw.SplitAPIToken(payload.APIKey)
+	ticket, err := api.WorkspaceAppsProvider.GenerateTicket(workspaceapps.Ticket{
+		AccessMethod:  workspaceapps.AccessMethodTerminal,
+		AgentNameOrID: req.AgentID.String(),
+		UserID:        workspace.OwnerID,
+		WorkspaceID:   workspace.ID,
+		AgentID:       req.AgentID,
+	})
 	if err != nil {
-		return database.APIKey{}, "", xerrors.Errorf("split API key: %w", err)
-	}
-	gotHashedSecret := sha256.Sum256([]byte(gotKeySecret))
-	if gotKeyID != key.ID || !bytes.Equal(key.HashedSecret, gotHashedSecret[:]) {
-		return database.APIKey{}, "", xerrors.New("encrypted API key does not match key in database")
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to generate ticket.",
+			Detail:  err.Error(),
+		})
+		return
 	}
 
-	return key, payload.APIKey, nil
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.IssueReconnectingPTYTicketResponse{
+		Ticket: ticket,
+	})
 }
@@ -0,0 +1,147 @@
+package workspaceapps
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"golang.org/x/xerrors"
+	jose "gopkg.in/square/go-jose.v2"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpmw"
+)
+
+const (
+	// SubdomainProxyAPIKeyParam is the query parameter used to smuggle
+	// encrypted API keys to app hostnames. This needs to be a super unique
+	// query parameter because we don't want to conflict with query parameters
+	// that users may use.
+	//nolint:gosec
+	SubdomainProxyAPIKeyParam = "coder_application_connect_api_key_35e783"
+)
+
+// EncryptedAPIKeyPayload is the payload that is encrypted by EncryptAPIKey.
+type EncryptedAPIKeyPayload struct {
+	APIKey    string    `json:"api_key"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// EncryptAPIKey encrypts an API key with it's own hashed secret. This is used
+// for smuggling (application_connect scoped) API keys securely to app
+// hostnames.
+//
+// We encrypt API keys when smuggling them in query parameters to avoid them
+// getting accidentally logged in access logs or stored in browser history.
+func EncryptAPIKey(data EncryptedAPIKeyPayload) (string, error) {
+	if data.APIKey == "" {
+		return "", xerrors.New("API key is empty")
+	}
+	if data.ExpiresAt.IsZero() {
+		// Very short expiry as these keys are only used once as part of an
+		// automatic redirection flow.
+		data.ExpiresAt = database.Now().Add(time.Minute)
+	}
+
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return "", xerrors.Errorf("marshal payload: %w", err)
+	}
+
+	// We use the hashed key secret as the encryption key. The hashed secret is
+	// stored in the API keys table. The HashedSecret is NEVER returned from the
+	// API.
+	//
+	// We chose to use the key secret as the private key for encryption instead
+	// of a shared key for a few reasons:
+	//   1. A single private key used to encrypt every API key would also be
+	//      stored in the database, which means that the risk factor is similar.
+	//   2. The secret essentially rotates for each key (for free!), since each
+	//      key has a different secret. This means that if someone acquires an
+	//      old database dump they can't decrypt new API keys.
+	//   3. These tokens are scoped only for application_connect access.
+	keyID, keySecret, err := httpmw.SplitAPIToken(data.APIKey)
+	if err != nil {
+		return "", xerrors.Errorf("split API key: %w", err)
+	}
+	// SHA256 the key secret so it matches the hashed secret in the database.
+	// The key length doesn't matter to the jose.Encrypter.
+	privateKey := sha256.Sum256([]byte(keySecret))
+
+	// JWEs seem to apply a nonce themselves.
+	encrypter, err := jose.NewEncrypter(
+		jose.A256GCM,
+		jose.Recipient{
+			Algorithm: jose.A256GCMKW,
+			KeyID:     keyID,
+			Key:       privateKey[:],
+		},
+		&jose.EncrypterOptions{
+			Compression: jose.DEFLATE,
+		},
+	)
+	if err != nil {
+		return "", xerrors.Errorf("initializer jose encrypter: %w", err)
+	}
+	encryptedObject, err := encrypter.Encrypt(payload)
+	if err != nil {
+		return "", xerrors.Errorf("encrypt jwe: %w", err)
+	}
+
+	encrypted := encryptedObject.FullSerialize()
+	return base64.RawURLEncoding.EncodeToString([]byte(encrypted)), nil
+}
+
+// DecryptAPIKey undoes EncryptAPIKey and is used in the subdomain app handler.
+func DecryptAPIKey(ctx context.Context, db database.Store, encryptedAPIKey string) (database.APIKey, string, error) {
+	encrypted, err := base64.RawURLEncoding.DecodeString(encryptedAPIKey)
+	if err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("base64 decode encrypted API key: %w", err)
+	}
+
+	object, err := jose.ParseEncrypted(string(encrypted))
+	if err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("parse encrypted API key: %w", err)
+	}
+
+	// Lookup the API key so we can decrypt it.
+	keyID := object.Header.KeyID
+	//nolint:gocritic // needed to check API key
+	key, err := db.GetAPIKeyByID(dbauthz.AsSystemRestricted(ctx), keyID)
+	if err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("get API key by key ID: %w", err)
+	}
+
+	// Decrypt using the hashed secret.
+	decrypted, err := object.Decrypt(key.HashedSecret)
+	if err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("decrypt API key: %w", err)
+	}
+
+	// Unmarshal the payload.
+	var payload EncryptedAPIKeyPayload
+	if err := json.Unmarshal(decrypted, &payload); err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("unmarshal decrypted payload: %w", err)
+	}
+
+	// Validate expiry.
+	if payload.ExpiresAt.Before(database.Now()) {
+		return database.APIKey{}, "", xerrors.New("encrypted API key expired")
+	}
+
+	// Validate that the key matches the one we got from the DB.
+	gotKeyID, gotKeySecret, err := httpmw.SplitAPIToken(payload.APIKey)
+	if err != nil {
+		return database.APIKey{}, "", xerrors.Errorf("split API key: %w", err)
+	}
+	gotHashedSecret := sha256.Sum256([]byte(gotKeySecret))
+	if gotKeyID != key.ID || !bytes.Equal(key.HashedSecret, gotHashedSecret[:]) {
+		return database.APIKey{}, "", xerrors.New("encrypted API key does not match key in database")
+	}
+
+	return key, payload.APIKey, nil
+}
@@ -1,4 +1,4 @@
-package coderd
+package workspaceapps_test
 
 import (
 	"context"
@@ -11,16 +11,17 @@ import (
 	"github.com/coder/coder/coderd/database"
 	"github.com/coder/coder/coderd/database/dbfake"
 	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/coderd/workspaceapps"
 	"github.com/coder/coder/testutil"
 )
 
 func TestAPIKeyEncryption(t *testing.T) {
 	t.Parallel()
 
-	generateAPIKey := func(t *testing.T, db database.Store) (keyID, keyToken string, hashedSecret []byte, data encryptedAPIKeyPayload) {
+	generateAPIKey := func(t *testing.T, db database.Store) (keyID, keyToken string, hashedSecret []byte, data workspaceapps.EncryptedAPIKeyPayload) {
 		key, token := dbgen.APIKey(t, db, database.APIKey{})
 
-		data = encryptedAPIKeyPayload{
+		data = workspaceapps.EncryptedAPIKeyPayload{
 			APIKey:    token,
 			ExpiresAt: database.Now().Add(24 * time.Hour),
 		}
@@ -33,13 +34,13 @@ func TestAPIKeyEncryption(t *testing.T) {
 		db := dbfake.New()
 		keyID, _, hashedSecret, data := generateAPIKey(t, db)
 
-		encrypted, err := encryptAPIKey(data)
+		encrypted, err := workspaceapps.EncryptAPIKey(data)
 		require.NoError(t, err)
 
 		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 		defer cancel()
 
-		key, token, err := decryptAPIKey(ctx, db, encrypted)
+		key, token, err := workspaceapps.DecryptAPIKey(ctx, db, encrypted)
 		require.NoError(t, err)
 		require.Equal(t, keyID, key.ID)
 		require.Equal(t, hashedSecret[:], key.HashedSecret)
@@ -55,13 +56,13 @@ func TestAPIKeyEncryption(t *testing.T) {
 			_, _, _, data := generateAPIKey(t, db)
 
 			data.ExpiresAt = database.Now().Add(-1 * time.Hour)
-			encrypted, err := encryptAPIKey(data)
+			encrypted, err := workspaceapps.EncryptAPIKey(data)
 			require.NoError(t, err)
 
 			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 			defer cancel()
 
-			_, _, err = decryptAPIKey(ctx, db, encrypted)
+			_, _, err = workspaceapps.DecryptAPIKey(ctx, db, encrypted)
 			require.Error(t, err)
 			require.ErrorContains(t, err, "expired")
 		})
@@ -76,18 +77,18 @@ func TestAPIKeyEncryption(t *testing.T) {
 				HashedSecret: hashedSecret[:],
 			})
 
-			data := encryptedAPIKeyPayload{
+			data := workspaceapps.EncryptedAPIKeyPayload{
 				APIKey:    token,
 				ExpiresAt: database.Now().Add(24 * time.Hour),
 			}
 
-			encrypted, err := encryptAPIKey(data)
+			encrypted, err := workspaceapps.EncryptAPIKey(data)
 			require.NoError(t, err)
 
 			ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
 			defer cancel()
 
-			_, _, err = decryptAPIKey(ctx, db, encrypted)
+			_, _, err = workspaceapps.DecryptAPIKey(ctx, db, encrypted)
 			require.Error(t, err)
 			require.ErrorContains(t, err, "error in crypto")
 		})
@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 	"time"
 
 	"github.com/google/uuid"
@@ -29,6 +28,11 @@ const (
 	// RedirectURIQueryParam is the query param for the app URL to be passed
 	// back to the API auth endpoint on the main access URL.
 	RedirectURIQueryParam = "redirect_uri"
+
+	// TicketQueryParam is the query param used to pass a signed ticket to
+	// endpoints that can't receive the ticket cookie, such as reconnecting
+	// PTYs served by external workspace proxies.
+	TicketQueryParam = "coder_app_ticket"
 )
 
 // ResolveRequest takes an app request, checks if it's valid and authenticated,
@@ -39,35 +43,11 @@ const (
 //
 // Upstream code should avoid any database calls ever.
 func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appReq Request) (*Ticket, bool) {
-	// nolint:gocritic // We need to make a number of database calls. Setting a system context here
-	//                 // is simpler than calling dbauthz.AsSystemRestricted on every call.
-	//                 // dangerousSystemCtx is only used for database calls. The actual authentication
-	//                 // logic is handled in Provider.authorizeWorkspaceApp which directly checks the actor's
-	//                 // permissions.
-	dangerousSystemCtx := dbauthz.AsSystemRestricted(r.Context())
-	err := appReq.Validate()
-	if err != nil {
-		p.writeWorkspaceApp500(rw, r, &appReq, err, "invalid app request")
+	appReq, ok := p.normalizeRequest(rw, r, appReq)
+	if !ok {
 		return nil, false
 	}
 
-	if appReq.WorkspaceAndAgent != "" {
-		// workspace.agent
-		workspaceAndAgent := strings.SplitN(appReq.WorkspaceAndAgent, ".", 2)
-		appReq.WorkspaceAndAgent = ""
-		appReq.WorkspaceNameOrID = workspaceAndAgent[0]
-		if len(workspaceAndAgent) > 1 {
-			appReq.AgentNameOrID = workspaceAndAgent[1]
-		}
-
-		// Sanity check.
-		err := appReq.Validate()
-		if err != nil {
-			p.writeWorkspaceApp500(rw, r, &appReq, err, "invalid app request")
-			return nil, false
-		}
-	}
-
 	// Get the existing ticket from the request.
 	ticketCookie, err := r.Cookie(codersdk.DevURLSessionTicketCookie)
 	if err == nil {
@@ -84,6 +64,44 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 	// There's no ticket or it's invalid, so we need to check auth using the
 	// session token, validate auth and access to the app, then generate a new
 	// ticket.
+	ticket, ticketStr, ok := p.CreateTicket(rw, r, appReq)
+	if !ok {
+		return nil, false
+	}
+
+	// Write the ticket cookie. We always want this to apply to the current
+	// hostname (even for subdomain apps, without any wildcard shenanigans,
+	// because the ticket is only valid for a single app).
+	http.SetCookie(rw, &http.Cookie{
+		Name:    codersdk.DevURLSessionTicketCookie,
+		Value:   ticketStr,
+		Path:    appReq.BasePath,
+		Expires: time.Unix(ticket.Expiry, 0),
+	})
+
+	return ticket, true
+}
+
+// CreateTicket checks that the request is authenticated and authorized to
+// access the app and returns a freshly signed ticket. Unlike ResolveRequest,
+// ticket cookies on the request are ignored and no cookie is written, which
+// allows tickets to be issued on behalf of external workspace proxies and for
+// reconnecting PTY connections.
+//
+// If the request is not allowed, an HTML error page or a redirect is written
+// to rw and false is returned.
+func (p *Provider) CreateTicket(rw http.ResponseWriter, r *http.Request, appReq Request) (*Ticket, string, bool) {
+	// nolint:gocritic // We need to make a number of database calls. Setting a system context here
+	//                 // is simpler than calling dbauthz.AsSystemRestricted on every call.
+	//                 // dangerousSystemCtx is only used for database calls. The actual authentication
+	//                 // logic is handled in Provider.authorizeWorkspaceApp which directly checks the actor's
+	//                 // permissions.
+	dangerousSystemCtx := dbauthz.AsSystemRestricted(r.Context())
+	appReq, ok := p.normalizeRequest(rw, r, appReq)
+	if !ok {
+		return nil, "", false
+	}
+
 	ticket := Ticket{
 		AccessMethod:      appReq.AccessMethod,
 		UsernameOrID:      appReq.UsernameOrID,
@@ -106,7 +124,11 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		Optional: true,
 	})
 	if !ok {
-		return nil, false
+		return nil, "", false
+	}
+
+	if appReq.AccessMethod == AccessMethodTerminal {
+		return p.createTerminalTicket(rw, r, appReq, ticket, authz)
 	}
 
 	// Get user.
@@ -123,10 +145,10 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 	}
 	if xerrors.Is(userErr, sql.ErrNoRows) {
 		p.writeWorkspaceApp404(rw, r, &appReq, fmt.Sprintf("user %q not found", appReq.UsernameOrID))
-		return nil, false
+		return nil, "", false
 	} else if userErr != nil {
 		p.writeWorkspaceApp500(rw, r, &appReq, userErr, "get user")
-		return nil, false
+		return nil, "", false
 	}
 	ticket.UserID = user.ID
 
@@ -146,10 +168,10 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 	}
 	if xerrors.Is(workspaceErr, sql.ErrNoRows) {
 		p.writeWorkspaceApp404(rw, r, &appReq, fmt.Sprintf("workspace %q not found", appReq.WorkspaceNameOrID))
-		return nil, false
+		return nil, "", false
 	} else if workspaceErr != nil {
 		p.writeWorkspaceApp500(rw, r, &appReq, workspaceErr, "get workspace")
-		return nil, false
+		return nil, "", false
 	}
 	ticket.WorkspaceID = workspace.ID
 
@@ -165,14 +187,14 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		build, err := p.Database.GetLatestWorkspaceBuildByWorkspaceID(dangerousSystemCtx, workspace.ID)
 		if err != nil {
 			p.writeWorkspaceApp500(rw, r, &appReq, err, "get latest workspace build")
-			return nil, false
+			return nil, "", false
 		}
 
 		// nolint:gocritic // We need to fetch the agent to authenticate the request. This is a system function.
 		resources, err := p.Database.GetWorkspaceResourcesByJobID(dangerousSystemCtx, build.JobID)
 		if err != nil {
 			p.writeWorkspaceApp500(rw, r, &appReq, err, "get workspace resources")
-			return nil, false
+			return nil, "", false
 		}
 		resourcesIDs := []uuid.UUID{}
 		for _, resource := range resources {
@@ -183,13 +205,13 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		agents, err := p.Database.GetWorkspaceAgentsByResourceIDs(dangerousSystemCtx, resourcesIDs)
 		if err != nil {
 			p.writeWorkspaceApp500(rw, r, &appReq, err, "get workspace agents")
-			return nil, false
+			return nil, "", false
 		}
 
 		if appReq.AgentNameOrID == "" {
 			if len(agents) != 1 {
 				p.writeWorkspaceApp404(rw, r, &appReq, "no agent specified, but multiple exist in workspace")
-				return nil, false
+				return nil, "", false
 			}
 
 			agent = agents[0]
@@ -210,10 +232,10 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 	}
 	if xerrors.Is(agentErr, sql.ErrNoRows) {
 		p.writeWorkspaceApp404(rw, r, &appReq, fmt.Sprintf("agent %q not found", appReq.AgentNameOrID))
-		return nil, false
+		return nil, "", false
 	} else if agentErr != nil {
 		p.writeWorkspaceApp500(rw, r, &appReq, agentErr, "get agent")
-		return nil, false
+		return nil, "", false
 	}
 
 	// Verify the agent belongs to the workspace.
@@ -222,16 +244,16 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		agentResource, err := p.Database.GetWorkspaceResourceByID(dangerousSystemCtx, agent.ResourceID)
 		if err != nil {
 			p.writeWorkspaceApp500(rw, r, &appReq, err, "get agent resource")
-			return nil, false
+			return nil, "", false
 		}
 		build, err := p.Database.GetWorkspaceBuildByJobID(dangerousSystemCtx, agentResource.JobID)
 		if err != nil {
 			p.writeWorkspaceApp500(rw, r, &appReq, err, "get agent workspace build")
-			return nil, false
+			return nil, "", false
 		}
 		if build.WorkspaceID != workspace.ID {
 			p.writeWorkspaceApp404(rw, r, &appReq, "agent does not belong to workspace")
-			return nil, false
+			return nil, "", false
 		}
 	}
 	ticket.AgentID = agent.ID
@@ -245,10 +267,19 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		//
 		// This is only supported for subdomain-based applications.
 		ticket.AppURL = fmt.Sprintf("http://127.0.0.1:%d", portUint)
+
+		// Ports are only accessible by the owner unless they've been
+		// shared.
+		sharingLevel, err := p.portSharingLevel(dangerousSystemCtx, workspace, agent.Name, int32(portUint))
+		if err != nil {
+			p.writeWorkspaceApp500(rw, r, &appReq, err, "get port sharing level")
+			return nil, "", false
+		}
+		appSharingLevel = sharingLevel
 	} else {
 		app, ok := p.lookupWorkspaceApp(rw, r, agent.ID, appReq.AppSlugOrPort)
 		if !ok {
-			return nil, false
+			return nil, "", false
 		}
 
 		if !app.Url.Valid {
@@ -259,7 +290,7 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 				RetryEnabled: true,
 				DashboardURL: p.AccessURL.String(),
 			})
-			return nil, false
+			return nil, "", false
 		}
 
 		if app.SharingLevel != "" {
@@ -271,20 +302,29 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 	// Verify the user has access to the app.
 	authed, ok := p.fetchWorkspaceApplicationAuth(rw, r, authz, appReq.AccessMethod, workspace, appSharingLevel)
 	if !ok {
-		return nil, false
+		return nil, "", false
 	}
 	if !authed {
 		if apiKey != nil {
 			// The request has a valid API key but insufficient permissions.
 			p.writeWorkspaceApp404(rw, r, &appReq, "insufficient permissions")
-			return nil, false
+			return nil, "", false
 		}
 
 		// Redirect to login as they don't have permission to access the app
 		// and they aren't signed in.
-		if appReq.AccessMethod == AccessMethodSubdomain {
+		//
+		// Requests that aren't for the primary access URL come from external
+		// workspace proxies, which can only receive API keys through the
+		// auth-redirect flow, even for path-based apps.
+		onPrimary := httpapi.HostnamesMatch(p.AccessURL.Hostname(), httpapi.RequestHost(r))
+		if appReq.AccessMethod == AccessMethodSubdomain || !onPrimary {
 			redirectURI := *r.URL
-			redirectURI.Scheme = p.AccessURL.Scheme
+			// Requests issued on behalf of workspace proxies have an
+			// absolute URL with the scheme of the proxy.
+			if redirectURI.Scheme == "" {
+				redirectURI.Scheme = p.AccessURL.Scheme
+			}
 			redirectURI.Host = httpapi.RequestHost(r)
 
 			u := *p.AccessURL
@@ -297,36 +337,86 @@ func (p *Provider) ResolveRequest(rw http.ResponseWriter, r *http.Request, appRe
 		} else {
 			httpmw.RedirectToLogin(rw, r, httpmw.SignedOutErrorMessage)
 		}
-		return nil, false
+		return nil, "", false
 	}
 
 	// As a sanity check, ensure the ticket we just made is valid for this
 	// request.
 	if !ticket.MatchesRequest(appReq) {
 		p.writeWorkspaceApp500(rw, r, &appReq, nil, "fresh ticket does not match request")
-		return nil, false
+		return nil, "", false
+	}
+
+	return p.signTicket(rw, r, appReq, ticket)
+}
+
+// createTerminalTicket resolves a reconnecting PTY request. The agent is
+// identified by ID only, and access requires the same permissions as the
+// terminal endpoint on coderd.
+func (p *Provider) createTerminalTicket(rw http.ResponseWriter, r *http.Request, appReq Request, ticket Ticket, authz *httpmw.Authorization) (*Ticket, string, bool) {
+	// nolint:gocritic // We need to fetch the agent and workspace to authorize the request.
+	dangerousSystemCtx := dbauthz.AsSystemRestricted(r.Context())
+	if authz == nil {
+		// Terminals are never public.
+		p.writeWorkspaceApp404(rw, r, &appReq, "unauthenticated terminal request")
+		return nil, "", false
+	}
+
+	// Validate guarantees that the agent is a UUID.
+	agentID := uuid.MustParse(appReq.AgentNameOrID)
+	workspace, err := p.Database.GetWorkspaceByAgentID(dangerousSystemCtx, agentID)
+	if xerrors.Is(err, sql.ErrNoRows) {
+		p.writeWorkspaceApp404(rw, r, &appReq, fmt.Sprintf("agent %q not found", appReq.AgentNameOrID))
+		return nil, "", false
+	} else if err != nil {
+		p.writeWorkspaceApp500(rw, r, &appReq, err, "get workspace by agent ID")
+		return nil, "", false
 	}
 
-	// Sign the ticket.
-	ticketExpiry := time.Now().Add(TicketExpiry)
-	ticket.Expiry = ticketExpiry.Unix()
+	err = p.Authorizer.Authorize(r.Context(), authz.Actor, rbac.ActionCreate, workspace.ExecutionRBAC())
+	if err != nil {
+		p.writeWorkspaceApp404(rw, r, &appReq, "insufficient permissions")
+		return nil, "", false
+	}
+
+	ticket.UserID = workspace.OwnerID
+	ticket.WorkspaceID = workspace.ID
+	ticket.AgentID = agentID
+	return p.signTicket(rw, r, appReq, ticket)
+}
+
+// signTicket sets the ticket expiry and signs it. If signing fails, an HTML
+// error page is written to rw and false is returned.
+func (p *Provider) signTicket(rw http.ResponseWriter, r *http.Request, appReq Request, ticket Ticket) (*Ticket, string, bool) {
+	ticket.Expiry = time.Now().Add(TicketExpiry).Unix()
 	ticketStr, err := p.GenerateTicket(ticket)
 	if err != nil {
 		p.writeWorkspaceApp500(rw, r, &appReq, err, "generate ticket")
-		return nil, false
+		return nil, "", false
 	}
 
-	// Write the ticket cookie. We always want this to apply to the current
-	// hostname (even for subdomain apps, without any wildcard shenanigans,
-	// because the ticket is only valid for a single app).
-	http.SetCookie(rw, &http.Cookie{
-		Name:    codersdk.DevURLSessionTicketCookie,
-		Value:   ticketStr,
-		Path:    appReq.BasePath,
-		Expires: ticketExpiry,
-	})
+	return &ticket, ticketStr, true
+}
+
+// normalizeRequest validates the request and returns it in the form that
+// tickets are matched against. If the request is invalid, an HTML error page
+// is written to rw and false is returned.
+func (p *Provider) normalizeRequest(rw http.ResponseWriter, r *http.Request, appReq Request) (Request, bool) {
+	err := appReq.Validate()
+	if err != nil {
+		p.writeWorkspaceApp500(rw, r, &appReq, err, "invalid app request")
+		return Request{}, false
+	}
+
+	appReq = appReq.Normalize()
+	// Sanity check.
+	err = appReq.Validate()
+	if err != nil {
+		p.writeWorkspaceApp500(rw, r, &appReq, err, "invalid app request")
+		return Request{}, false
+	}
 
-	return &ticket, true
+	return appReq, true
 }
 
 // lookupWorkspaceApp looks up the workspace application by slug in the given
@@ -357,6 +447,32 @@ func (p *Provider) lookupWorkspaceApp(rw http.ResponseWriter, r *http.Request, a
 	return app, true
 }
 
+// portSharingLevel returns the level a port of an agent is shared at. Shares
+// more permissive than the maximum of the template, which may have been
+// lowered after the port was shared, are limited to that maximum.
+func (p *Provider) portSharingLevel(ctx context.Context, workspace database.Workspace, agentName string, port int32) (database.AppSharingLevel, error) {
+	share, err := p.Database.GetWorkspaceAgentPortShare(ctx, database.GetWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   agentName,
+		Port:        port,
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		return database.AppSharingLevelOwner, nil
+	}
+	if err != nil {
+		return "", xerrors.Errorf("get port share: %w", err)
+	}
+
+	template, err := p.Database.GetTemplateByID(ctx, workspace.TemplateID)
+	if err != nil {
+		return "", xerrors.Errorf("get template: %w", err)
+	}
+	if share.ShareLevel.MorePermissiveThan(template.MaxPortSharingLevel) {
+		return template.MaxPortSharingLevel, nil
+	}
+	return share.ShareLevel, nil
+}
+
 func (p *Provider) authorizeWorkspaceApp(ctx context.Context, roles *httpmw.Authorization, accessMethod AccessMethod, sharingLevel database.AppSharingLevel, workspace database.Workspace) (bool, error) {
 	if accessMethod == "" {
 		accessMethod = AccessMethodPath
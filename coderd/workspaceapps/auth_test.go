@@ -519,6 +519,52 @@ func Test_ResolveRequest(t *testing.T) {
 		require.Equal(t, "http://127.0.0.1:9090", ticket.AppURL)
 	})
 
+	t.Run("PortSubdomainShared", func(t *testing.T) {
+		t.Parallel()
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitMedium)
+		defer cancel()
+
+		req := workspaceapps.Request{
+			AccessMethod:      workspaceapps.AccessMethodSubdomain,
+			BasePath:          "/",
+			UsernameOrID:      me.Username,
+			WorkspaceNameOrID: workspace.Name,
+			AgentNameOrID:     agentName,
+			AppSlugOrPort:     "9091",
+		}
+		resolve := func() bool {
+			rw := httptest.NewRecorder()
+			r := httptest.NewRequest("GET", "/app", nil)
+			r.Header.Set(codersdk.SessionTokenHeader, secondUserClient.SessionToken())
+			_, ok := api.WorkspaceAppsProvider.ResolveRequest(rw, r, req)
+			return ok
+		}
+		setMaxLevel := func(level codersdk.WorkspaceAppSharingLevel) {
+			_, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+				Name:              template.Name,
+				MaxPortShareLevel: level,
+			})
+			require.NoError(t, err)
+		}
+
+		// Ports aren't shared by default.
+		require.False(t, resolve())
+
+		setMaxLevel(codersdk.WorkspaceAppSharingLevelAuthenticated)
+		_, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, codersdk.UpsertWorkspaceAgentPortShareRequest{
+			AgentName:  agentName,
+			Port:       9091,
+			ShareLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+		})
+		require.NoError(t, err)
+		require.True(t, resolve())
+
+		// Lowering the maximum of the template limits existing shares.
+		setMaxLevel(codersdk.WorkspaceAppSharingLevelOwner)
+		require.False(t, resolve())
+	})
+
 	t.Run("InsufficientPermissions", func(t *testing.T) {
 		t.Parallel()
 
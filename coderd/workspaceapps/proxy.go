@@ -0,0 +1,234 @@
+package workspaceapps
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+	"strconv"
+
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/trace"
+	"nhooyr.io/websocket"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/wsconncache"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/site"
+)
+
+// nonCanonicalHeaders is a map from "canonical" headers to the actual header we
+// should send to the app in the workspace. Some headers (such as the websocket
+// upgrade headers from RFC 6455) are not canonical according to the HTTP/1
+// spec. Golang has said that they will not add custom cases for these headers,
+// so we need to do it ourselves.
+//
+// Some apps our customers use are sensitive to the case of these headers.
+//
+// https://github.com/golang/go/issues/18495
+var nonCanonicalHeaders = map[string]string{
+	"Sec-Websocket-Accept":     "Sec-WebSocket-Accept",
+	"Sec-Websocket-Extensions": "Sec-WebSocket-Extensions",
+	"Sec-Websocket-Key":        "Sec-WebSocket-Key",
+	"Sec-Websocket-Protocol":   "Sec-WebSocket-Protocol",
+	"Sec-Websocket-Version":    "Sec-WebSocket-Version",
+}
+
+// Server proxies authorized requests to workspace agents. It is shared by
+// coderd and external workspace proxies, which each hold their own agent
+// connection cache. Requests must be authorized (i.e. have a valid ticket)
+// before they are passed to the Server.
+type Server struct {
+	Logger slog.Logger
+	// DashboardURL is the URL of the primary deployment. It is used in links
+	// on error pages.
+	DashboardURL       *url.URL
+	RealIPConfig       *httpmw.RealIPConfig
+	WorkspaceConnCache *wsconncache.Cache
+}
+
+// ProxyApp proxies the request to the app described by the ticket. The path is
+// the path on the app that was requested, without the base path of the app.
+func (s *Server) ProxyApp(rw http.ResponseWriter, r *http.Request, ticket Ticket, path string) {
+	ctx := r.Context()
+
+	// Filter IP headers from untrusted origins.
+	httpmw.FilterUntrustedOriginHeaders(s.RealIPConfig, r)
+	// Ensure proper IP headers get sent to the forwarded application.
+	err := httpmw.EnsureXForwardedForHeader(r)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	appURL, err := url.Parse(ticket.AppURL)
+	if err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadRequest,
+			Title:        "Bad Request",
+			Description:  fmt.Sprintf("Application has an invalid URL %q: %s", ticket.AppURL, err.Error()),
+			RetryEnabled: true,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return
+	}
+
+	// Verify that the port is allowed. See the docs above
+	// `codersdk.MinimumListeningPort` for more details.
+	port := appURL.Port()
+	if port != "" {
+		portInt, err := strconv.Atoi(port)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("App URL %q has an invalid port %q.", ticket.AppURL, port),
+				Detail:  err.Error(),
+			})
+			return
+		}
+
+		if portInt < codersdk.WorkspaceAgentMinimumListeningPort {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Application port %d is not permitted. Coder reserves ports less than %d for internal use.", portInt, codersdk.WorkspaceAgentMinimumListeningPort),
+			})
+			return
+		}
+	}
+
+	// Ensure path and query parameter correctness.
+	if path == "" {
+		// Web applications typically request paths relative to the
+		// root URL. This allows for routing behind a proxy or subpath.
+		// See https://github.com/coder/code-server/issues/241 for examples.
+		http.Redirect(rw, r, r.URL.Path+"/", http.StatusTemporaryRedirect)
+		return
+	}
+	if path == "/" && r.URL.RawQuery == "" && appURL.RawQuery != "" {
+		// If the application defines a default set of query parameters,
+		// we should always respect them. The reverse proxy will merge
+		// query parameters for server-side requests, but sometimes
+		// client-side applications require the query parameters to render
+		// properly. With code-server, this is the "folder" param.
+		r.URL.RawQuery = appURL.RawQuery
+		http.Redirect(rw, r, r.URL.String(), http.StatusTemporaryRedirect)
+		return
+	}
+
+	r.URL.Path = path
+	appURL.RawQuery = ""
+
+	proxy := httputil.NewSingleHostReverseProxy(appURL)
+	proxy.ErrorHandler = func(w http.ResponseWriter, r *http.Request, err error) {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadGateway,
+			Title:        "Bad Gateway",
+			Description:  "Failed to proxy request to application: " + err.Error(),
+			RetryEnabled: true,
+			DashboardURL: s.DashboardURL.String(),
+		})
+	}
+
+	conn, release, err := s.WorkspaceConnCache.Acquire(ticket.AgentID)
+	if err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadGateway,
+			Title:        "Bad Gateway",
+			Description:  "Could not connect to workspace agent: " + err.Error(),
+			RetryEnabled: true,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return
+	}
+	defer release()
+	proxy.Transport = conn.HTTPTransport()
+
+	// This strips the session token from a workspace app request.
+	cookieHeaders := r.Header.Values("Cookie")[:]
+	r.Header.Del("Cookie")
+	for _, cookieHeader := range cookieHeaders {
+		r.Header.Add("Cookie", httpapi.StripCoderCookies(cookieHeader))
+	}
+
+	// Convert canonicalized headers to their non-canonicalized counterparts.
+	// See the comment on `nonCanonicalHeaders` for more information on why this
+	// is necessary.
+	for k, v := range r.Header {
+		if n, ok := nonCanonicalHeaders[k]; ok {
+			r.Header.Del(k)
+			r.Header[n] = v
+		}
+	}
+
+	// end span so we don't get long lived trace data
+	tracing.EndHTTPSpan(r, http.StatusOK, trace.SpanFromContext(ctx))
+
+	proxy.ServeHTTP(rw, r)
+}
+
+// ProxyReconnectingPTY accepts a websocket and pipes it to a reconnecting PTY
+// on the agent. The reconnect ID, terminal size, command and scrollback are
+// read from the query parameters. The user ID is the authenticated user that
+// is connecting.
+func (s *Server) ProxyReconnectingPTY(rw http.ResponseWriter, r *http.Request, agentID, userID uuid.UUID) {
+	ctx := r.Context()
+
+	reconnect, err := uuid.Parse(r.URL.Query().Get("reconnect"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Query param 'reconnect' must be a valid UUID.",
+			Validations: []codersdk.ValidationError{
+				{Field: "reconnect", Detail: "invalid UUID"},
+			},
+		})
+		return
+	}
+	height, err := strconv.ParseUint(r.URL.Query().Get("height"), 10, 16)
+	if err != nil {
+		height = 80
+	}
+	width, err := strconv.ParseUint(r.URL.Query().Get("width"), 10, 16)
+	if err != nil {
+		width = 80
+	}
+
+	conn, err := websocket.Accept(rw, r, &websocket.AcceptOptions{
+		CompressionMode: websocket.CompressionDisabled,
+		// Terminals on workspace proxies are opened from the dashboard,
+		// which is a different origin.
+		OriginPatterns: []string{s.DashboardURL.Host},
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Failed to accept websocket.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ctx, wsNetConn := httpapi.WebsocketNetConn(ctx, conn, websocket.MessageBinary)
+	defer wsNetConn.Close() // Also closes conn.
+
+	go httpapi.Heartbeat(ctx, conn)
+
+	agentConn, release, err := s.WorkspaceConnCache.Acquire(agentID)
+	if err != nil {
+		s.Logger.Debug(ctx, "dial workspace agent", slog.F("agent_id", agentID), slog.Error(err))
+		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial workspace agent: %s", err))
+		return
+	}
+	defer release()
+	opts := []codersdk.ReconnectingPTYOption{codersdk.ReconnectingPTYWithUserID(userID)}
+	if r.URL.Query().Get("scrollback") == "true" {
+		opts = append(opts, codersdk.ReconnectingPTYWithScrollback())
+	}
+	ptNetConn, err := agentConn.ReconnectingPTY(ctx, reconnect, uint16(height), uint16(width), r.URL.Query().Get("command"), opts...)
+	if err != nil {
+		_ = conn.Close(websocket.StatusInternalError, httpapi.WebsocketCloseSprintf("dial: %s", err))
+		return
+	}
+	defer ptNetConn.Close()
+	agent.Bicopy(ctx, wsNetConn, ptNetConn)
+}
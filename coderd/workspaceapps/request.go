@@ -3,6 +3,7 @@ package workspaceapps
 import (
 	"strings"
 
+	"github.com/google/uuid"
 	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/codersdk"
@@ -13,31 +14,68 @@ type AccessMethod string
 const (
 	AccessMethodPath      AccessMethod = "path"
 	AccessMethodSubdomain AccessMethod = "subdomain"
+	// AccessMethodTerminal is used for reconnecting PTY connections. Requests
+	// with this access method only specify the agent ID.
+	AccessMethodTerminal AccessMethod = "terminal"
 )
 
 type Request struct {
-	AccessMethod AccessMethod
+	AccessMethod AccessMethod `json:"access_method"`
 	// BasePath of the app. For path apps, this is the path prefix in the router
 	// for this particular app. For subdomain apps, this should be "/". This is
 	// used for setting the cookie path.
-	BasePath string
+	BasePath string `json:"base_path"`
 
-	UsernameOrID string
+	UsernameOrID string `json:"username_or_id"`
 	// WorkspaceAndAgent xor WorkspaceNameOrID are required.
-	WorkspaceAndAgent string // "workspace" or "workspace.agent"
-	WorkspaceNameOrID string
+	WorkspaceAndAgent string `json:"workspace_and_agent"` // "workspace" or "workspace.agent"
+	WorkspaceNameOrID string `json:"workspace_name_or_id"`
 	// AgentNameOrID is not required if the workspace has only one agent.
-	AgentNameOrID string
-	AppSlugOrPort string
+	AgentNameOrID string `json:"agent_name_or_id"`
+	AppSlugOrPort string `json:"app_slug_or_port"`
+}
+
+// Normalize replaces WorkspaceAndAgent with WorkspaceNameOrID and
+// AgentNameOrID. Tickets are always matched against normalized requests.
+func (r Request) Normalize() Request {
+	req := r
+	if req.WorkspaceAndAgent != "" {
+		// workspace.agent
+		workspaceAndAgent := strings.SplitN(req.WorkspaceAndAgent, ".", 2)
+		req.WorkspaceAndAgent = ""
+		req.WorkspaceNameOrID = workspaceAndAgent[0]
+		if len(workspaceAndAgent) > 1 {
+			req.AgentNameOrID = workspaceAndAgent[1]
+		}
+	}
+
+	return req
 }
 
 func (r Request) Validate() error {
-	if r.AccessMethod != AccessMethodPath && r.AccessMethod != AccessMethodSubdomain {
+	switch r.AccessMethod {
+	case AccessMethodPath, AccessMethodSubdomain, AccessMethodTerminal:
+	default:
 		return xerrors.Errorf("invalid access method: %q", r.AccessMethod)
 	}
 	if r.BasePath == "" {
 		return xerrors.New("base path is required")
 	}
+
+	if r.AccessMethod == AccessMethodTerminal {
+		if r.UsernameOrID != "" || r.WorkspaceAndAgent != "" || r.WorkspaceNameOrID != "" || r.AppSlugOrPort != "" {
+			return xerrors.New("dev error: cannot specify any fields other than AccessMethod, BasePath and AgentNameOrID for terminal access method")
+		}
+		if r.AgentNameOrID == "" {
+			return xerrors.New("agent name or ID is required")
+		}
+		if _, err := uuid.Parse(r.AgentNameOrID); err != nil {
+			return xerrors.Errorf("invalid agent ID %q: %w", r.AgentNameOrID, err)
+		}
+
+		return nil
+	}
+
 	if r.UsernameOrID == "" {
 		return xerrors.New("username or ID is required")
 	}
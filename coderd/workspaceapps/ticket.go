@@ -73,6 +73,13 @@ func (p *Provider) GenerateTicket(payload Ticket) (string, error) {
 }
 
 func (p *Provider) ParseTicket(ticketStr string) (Ticket, error) {
+	return ParseTicketWithKey(p.TicketSigningKey, ticketStr)
+}
+
+// ParseTicketWithKey verifies and parses a ticket generated by
+// Provider.GenerateTicket. External workspace proxies receive the signing key
+// when they register, so they can validate tickets without a database.
+func ParseTicketWithKey(signingKey []byte, ticketStr string) (Ticket, error) {
 	object, err := jose.ParseSigned(ticketStr)
 	if err != nil {
 		return Ticket{}, xerrors.Errorf("parse JWS: %w", err)
@@ -84,7 +91,7 @@ func (p *Provider) ParseTicket(ticketStr string) (Ticket, error) {
 		return Ticket{}, xerrors.Errorf("expected ticket signing algorithm to be %q, got %q", ticketSigningAlgorithm, object.Signatures[0].Header.Algorithm)
 	}
 
-	output, err := object.Verify(p.TicketSigningKey)
+	output, err := object.Verify(signingKey)
 	if err != nil {
 		return Ticket{}, xerrors.Errorf("verify JWS: %w", err)
 	}
@@ -331,15 +331,23 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	if workspace.LockedAt.Valid && createBuild.Transition == codersdk.WorkspaceTransitionStart {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Workspace is locked due to inactivity.",
+			Detail:  "Unlock the workspace before starting it.",
+		})
+		return
+	}
+
+	latestBuild, latestBuildErr := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if latestBuildErr != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching the latest workspace build.",
+			Detail:  latestBuildErr.Error(),
+		})
+		return
+	}
 	if createBuild.TemplateVersionID == uuid.Nil {
-		latestBuild, latestBuildErr := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
-		if latestBuildErr != nil {
-			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
-				Message: "Internal error fetching the latest workspace build.",
-				Detail:  latestBuildErr.Error(),
-			})
-			return
-		}
 		createBuild.TemplateVersionID = latestBuild.TemplateVersionID
 	}
 
@@ -362,6 +370,19 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	// Workspaces already on an archived version may keep using it, but no
+	// workspace can be moved onto one.
+	if templateVersion.Archived && templateVersion.ID != latestBuild.TemplateVersionID {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Template version is archived.",
+			Validations: []codersdk.ValidationError{{
+				Field:  "template_version_id",
+				Detail: "template version is archived",
+			}},
+		})
+		return
+	}
+
 	template, err := api.Database.GetTemplateByID(ctx, templateVersion.TemplateID.UUID)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
@@ -516,6 +537,19 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Ephemeral parameters only apply to the build they're passed to, so
+	// they're never carried over from the previous build.
+	for _, templateVersionParameter := range templateVersionParameters {
+		if !templateVersionParameter.Ephemeral {
+			continue
+		}
+		if i := slices.IndexFunc(apiLastBuildParameters, func(buildParameter codersdk.WorkspaceBuildParameter) bool {
+			return buildParameter.Name == templateVersionParameter.Name
+		}); i >= 0 {
+			apiLastBuildParameters = slices.Delete(apiLastBuildParameters, i, i+1)
+		}
+	}
+
 	err = codersdk.ValidateWorkspaceBuildParameters(templateVersionParameters, createBuild.RichParameterValues, apiLastBuildParameters)
 	if err != nil {
 		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
@@ -535,6 +569,12 @@ func (api *API) postWorkspaceBuilds(rw http.ResponseWriter, r *http.Request) {
 				})
 				return
 			}
+			if templateVersionParameter.Ephemeral && createBuild.Transition != codersdk.WorkspaceTransitionStart {
+				httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+					Message: fmt.Sprintf("Parameter %q is ephemeral, so it can only be set when starting a workspace.", templateVersionParameter.Name),
+				})
+				return
+			}
 			parameters = append(parameters, *buildParameter)
 			continue
 		}
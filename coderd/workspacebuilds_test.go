@@ -647,6 +647,10 @@ func TestWorkspaceBuildWithRichParameters(t *testing.T) {
 		immutableParameterName        = "immutable_parameter"
 		immutableParameterDescription = "This is immutable parameter"
 		immutableParameterValue       = "3"
+
+		ephemeralParameterName        = "ephemeral_parameter"
+		ephemeralParameterDescription = "This is ephemeral parameter"
+		ephemeralParameterValue       = "4"
 	)
 
 	initialBuildParameters := []codersdk.WorkspaceBuildParameter{
@@ -665,6 +669,7 @@ func TestWorkspaceBuildWithRichParameters(t *testing.T) {
 							{Name: firstParameterName, Description: firstParameterDescription, Mutable: true},
 							{Name: secondParameterName, Description: secondParameterDescription, Mutable: true},
 							{Name: immutableParameterName, Description: immutableParameterDescription, Mutable: false},
+							{Name: ephemeralParameterName, Description: ephemeralParameterDescription, Mutable: true, Ephemeral: true},
 						},
 					},
 				},
@@ -779,6 +784,85 @@ func TestWorkspaceBuildWithRichParameters(t *testing.T) {
 		})
 		require.Error(t, err)
 	})
+
+	t.Run("EphemeralParameterNotCarriedOver", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, echoResponses)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.RichParameterValues = initialBuildParameters
+		})
+
+		workspaceBuild := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		require.Equal(t, codersdk.WorkspaceStatusRunning, workspaceBuild.Status)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		// The ephemeral parameter applies to this build only.
+		ephemeralBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{
+				{Name: ephemeralParameterName, Value: ephemeralParameterValue},
+			},
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, ephemeralBuild.ID)
+
+		workspaceBuildParameters, err := client.WorkspaceBuildParameters(ctx, ephemeralBuild.ID)
+		require.NoError(t, err)
+		expected := append(initialBuildParameters, codersdk.WorkspaceBuildParameter{
+			Name:  ephemeralParameterName,
+			Value: ephemeralParameterValue,
+		})
+		require.ElementsMatch(t, expected, workspaceBuildParameters)
+
+		nextWorkspaceBuild, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStart,
+		})
+		require.NoError(t, err)
+		coderdtest.AwaitWorkspaceBuildJob(t, client, nextWorkspaceBuild.ID)
+
+		workspaceBuildParameters, err = client.WorkspaceBuildParameters(ctx, nextWorkspaceBuild.ID)
+		require.NoError(t, err)
+		require.ElementsMatch(t, initialBuildParameters, workspaceBuildParameters)
+	})
+
+	t.Run("EphemeralParameterOnlyOnStart", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdtest.New(t, &coderdtest.Options{IncludeProvisionerDaemon: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, echoResponses)
+		coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+			cwr.RichParameterValues = initialBuildParameters
+		})
+
+		workspaceBuild := coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+		require.Equal(t, codersdk.WorkspaceStatusRunning, workspaceBuild.Status)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+		defer cancel()
+
+		_, err := client.CreateWorkspaceBuild(ctx, workspace.ID, codersdk.CreateWorkspaceBuildRequest{
+			Transition: codersdk.WorkspaceTransitionStop,
+			RichParameterValues: []codersdk.WorkspaceBuildParameter{
+				{Name: ephemeralParameterName, Value: ephemeralParameterValue},
+			},
+		})
+		var apiErr *codersdk.Error
+		require.ErrorAs(t, err, &apiErr)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+		require.Contains(t, apiErr.Message, "ephemeral")
+	})
 }
 
 func TestWorkspaceBuildValidateRichParameters(t *testing.T) {
@@ -0,0 +1,287 @@
+package coderd
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+)
+
+// @Summary Get workspace port shares
+// @ID get-workspace-port-shares
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceAgentPortSharesResponse
+// @Router /workspaces/{workspace}/port-shares [get]
+func (api *API) workspaceAgentPortShares(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+	workspace := httpmw.WorkspaceParam(r)
+
+	shares, err := api.Database.GetWorkspaceAgentPortSharesByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port shares.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	resp := codersdk.WorkspaceAgentPortSharesResponse{
+		Shares: make([]codersdk.WorkspaceAgentPortShare, 0, len(shares)),
+	}
+	for _, share := range shares {
+		resp.Shares = append(resp.Shares, convertWorkspaceAgentPortShare(share))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, resp)
+}
+
+// @Summary Upsert workspace port share
+// @ID upsert-workspace-port-share
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Param request body codersdk.UpsertWorkspaceAgentPortShareRequest true "Upsert port share request"
+// @Success 200 {object} codersdk.WorkspaceAgentPortShare
+// @Router /workspaces/{workspace}/port-shares [post]
+func (api *API) postWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx         = r.Context()
+		workspace   = httpmw.WorkspaceParam(r)
+		auditor     = api.Auditor.Load()
+		auditParams = &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		}
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceAgentPortShare](rw, auditParams)
+	)
+	defer commitAudit()
+
+	var req codersdk.UpsertWorkspaceAgentPortShareRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if !validatePortShareRequest(rw, r, req.Port) {
+		return
+	}
+	shareLevel := database.AppSharingLevel(req.ShareLevel)
+	if !shareLevel.Valid() {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid share level.",
+			Validations: []codersdk.ValidationError{
+				{Field: "share_level", Detail: fmt.Sprintf("Share level %q must be one of owner, authenticated or public.", req.ShareLevel)},
+			},
+		})
+		return
+	}
+
+	// The limit of the template applies to every workspace owner, even those
+	// who can't read the template.
+	//nolint:gocritic
+	template, err := api.Database.GetTemplateByID(dbauthz.AsSystemRestricted(ctx), workspace.TemplateID)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching template.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if shareLevel.MorePermissiveThan(template.MaxPortSharingLevel) {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("The template only allows sharing ports up to the %q level.", template.MaxPortSharingLevel),
+		})
+		return
+	}
+
+	agentNames, err := api.latestBuildAgentNames(r, workspace)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching workspace agents.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if _, ok := agentNames[req.AgentName]; !ok {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: fmt.Sprintf("Agent %q not found in the latest build of the workspace.", req.AgentName),
+		})
+		return
+	}
+
+	oldShare, err := api.Database.GetWorkspaceAgentPortShare(ctx, database.GetWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   req.AgentName,
+		Port:        req.Port,
+	})
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	if err == nil {
+		// Sharing an already shared port changes its level.
+		auditParams.Action = database.AuditActionWrite
+		aReq.Old = oldShare
+	}
+
+	share, err := api.Database.UpsertWorkspaceAgentPortShare(ctx, database.UpsertWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   req.AgentName,
+		Port:        req.Port,
+		ShareLevel:  shareLevel,
+		CreatedAt:   database.Now(),
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error sharing port.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = share
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertWorkspaceAgentPortShare(share))
+}
+
+// @Summary Delete workspace port share
+// @ID delete-workspace-port-share
+// @Security CoderSessionToken
+// @Accept json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Param request body codersdk.DeleteWorkspaceAgentPortShareRequest true "Delete port share request"
+// @Success 204
+// @Router /workspaces/{workspace}/port-shares [delete]
+func (api *API) deleteWorkspaceAgentPortShare(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceAgentPortShare](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	defer commitAudit()
+
+	var req codersdk.DeleteWorkspaceAgentPortShareRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if !api.Authorize(r, rbac.ActionUpdate, workspace) {
+		httpapi.Forbidden(rw)
+		return
+	}
+
+	share, err := api.Database.GetWorkspaceAgentPortShare(ctx, database.GetWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   req.AgentName,
+		Port:        req.Port,
+	})
+	if errors.Is(err, sql.ErrNoRows) {
+		httpapi.Write(ctx, rw, http.StatusNotFound, codersdk.Response{
+			Message: fmt.Sprintf("Port %d of agent %q is not shared.", req.Port, req.AgentName),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error fetching port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = share
+
+	err = api.Database.DeleteWorkspaceAgentPortShare(ctx, database.DeleteWorkspaceAgentPortShareParams{
+		WorkspaceID: workspace.ID,
+		AgentName:   req.AgentName,
+		Port:        req.Port,
+	})
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error deleting port share.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	rw.WriteHeader(http.StatusNoContent)
+}
+
+// validatePortShareRequest writes an error response if the port can't be
+// accessed with a port URL.
+func validatePortShareRequest(rw http.ResponseWriter, r *http.Request, port int32) bool {
+	if port < codersdk.WorkspaceAgentMinimumListeningPort || port > 65535 {
+		httpapi.Write(r.Context(), rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid port.",
+			Validations: []codersdk.ValidationError{
+				{Field: "port", Detail: fmt.Sprintf("Port must be between %d and 65535.", codersdk.WorkspaceAgentMinimumListeningPort)},
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// latestBuildAgentNames returns the names of the agents in the latest build
+// of the workspace.
+func (api *API) latestBuildAgentNames(r *http.Request, workspace database.Workspace) (map[string]struct{}, error) {
+	ctx := r.Context()
+	build, err := api.Database.GetLatestWorkspaceBuildByWorkspaceID(ctx, workspace.ID)
+	if err != nil {
+		return nil, xerrors.Errorf("get latest workspace build: %w", err)
+	}
+	resources, err := api.Database.GetWorkspaceResourcesByJobID(ctx, build.JobID)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace resources: %w", err)
+	}
+	resourceIDs := make([]uuid.UUID, 0, len(resources))
+	for _, resource := range resources {
+		resourceIDs = append(resourceIDs, resource.ID)
+	}
+	agents, err := api.Database.GetWorkspaceAgentsByResourceIDs(ctx, resourceIDs)
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace agents: %w", err)
+	}
+	names := make(map[string]struct{}, len(agents))
+	for _, agent := range agents {
+		names[agent.Name] = struct{}{}
+	}
+	return names, nil
+}
+
+func convertWorkspaceAgentPortShare(share database.WorkspaceAgentPortShare) codersdk.WorkspaceAgentPortShare {
+	return codersdk.WorkspaceAgentPortShare{
+		WorkspaceID: share.WorkspaceID,
+		AgentName:   share.AgentName,
+		Port:        share.Port,
+		ShareLevel:  codersdk.WorkspaceAppSharingLevel(share.ShareLevel),
+		CreatedAt:   share.CreatedAt,
+		UpdatedAt:   share.UpdatedAt,
+	}
+}
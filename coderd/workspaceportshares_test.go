@@ -0,0 +1,129 @@
+package coderd_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceAgentPortShares(t *testing.T) {
+	t.Parallel()
+
+	auditor := audit.NewMock()
+	client := coderdtest.New(t, &coderdtest.Options{
+		IncludeProvisionerDaemon: true,
+		Auditor:                  auditor,
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	otherClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:          echo.ParseComplete,
+		ProvisionPlan:  echo.ProvisionComplete,
+		ProvisionApply: echo.ProvisionApplyWithAgent(uuid.NewString()),
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID)
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+
+	workspace, err := client.Workspace(ctx, workspace.ID)
+	require.NoError(t, err)
+	agentName := workspace.LatestBuild.Resources[0].Agents[0].Name
+	// The audit log is committed after the response is written.
+	requireAudited := func(action database.AuditAction) {
+		t.Helper()
+		require.Eventually(t, func() bool {
+			logs := auditor.Logs()
+			if len(logs) == 0 {
+				return false
+			}
+			last := logs[len(logs)-1]
+			return last.ResourceType == database.ResourceTypeWorkspaceAgentPortShare &&
+				last.Action == action &&
+				last.ResourceID == workspace.ID &&
+				last.ResourceTarget == agentName+":8080"
+		}, testutil.WaitShort, testutil.IntervalFast)
+	}
+
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelOwner, template.MaxPortShareLevel)
+	share := codersdk.UpsertWorkspaceAgentPortShareRequest{
+		AgentName:  agentName,
+		Port:       8080,
+		ShareLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	}
+
+	// The template doesn't allow sharing ports by default.
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	var apiErr *codersdk.Error
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusBadRequest, apiErr.StatusCode())
+
+	template, err = client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+		Name:              template.Name,
+		MaxPortShareLevel: codersdk.WorkspaceAppSharingLevelAuthenticated,
+	})
+	require.NoError(t, err)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelAuthenticated, template.MaxPortShareLevel)
+
+	created, err := client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	require.NoError(t, err)
+	require.Equal(t, workspace.ID, created.WorkspaceID)
+	require.Equal(t, agentName, created.AgentName)
+	require.EqualValues(t, 8080, created.Port)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelAuthenticated, created.ShareLevel)
+	requireAudited(database.AuditActionCreate)
+
+	// Sharing the same port again changes the level.
+	share.ShareLevel = codersdk.WorkspaceAppSharingLevelOwner
+	_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, share)
+	require.NoError(t, err)
+	requireAudited(database.AuditActionWrite)
+	res, err := client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Len(t, res.Shares, 1)
+	require.Equal(t, codersdk.WorkspaceAppSharingLevelOwner, res.Shares[0].ShareLevel)
+
+	for name, req := range map[string]codersdk.UpsertWorkspaceAgentPortShareRequest{
+		"AboveTemplateMax": {AgentName: agentName, Port: 8080, ShareLevel: codersdk.WorkspaceAppSharingLevelPublic},
+		"UnknownAgent":     {AgentName: "unknown", Port: 8080, ShareLevel: codersdk.WorkspaceAppSharingLevelOwner},
+		"InvalidPort":      {AgentName: agentName, Port: 1, ShareLevel: codersdk.WorkspaceAppSharingLevelOwner},
+		"InvalidLevel":     {AgentName: agentName, Port: 8080, ShareLevel: "everyone"},
+	} {
+		_, err = client.UpsertWorkspaceAgentPortShare(ctx, workspace.ID, req)
+		require.ErrorAs(t, err, &apiErr, name)
+		require.Equal(t, http.StatusBadRequest, apiErr.StatusCode(), name)
+	}
+
+	// Other users can't see or change the shares.
+	_, err = otherClient.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+
+	deleteReq := codersdk.DeleteWorkspaceAgentPortShareRequest{
+		AgentName: agentName,
+		Port:      8080,
+	}
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, deleteReq)
+	require.NoError(t, err)
+	requireAudited(database.AuditActionDelete)
+	res, err = client.WorkspaceAgentPortShares(ctx, workspace.ID)
+	require.NoError(t, err)
+	require.Empty(t, res.Shares)
+
+	err = client.DeleteWorkspaceAgentPortShare(ctx, workspace.ID, deleteReq)
+	require.ErrorAs(t, err, &apiErr)
+	require.Equal(t, http.StatusNotFound, apiErr.StatusCode())
+}
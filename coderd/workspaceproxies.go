@@ -0,0 +1,67 @@
+package coderd
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/codersdk"
+)
+
+// PrimaryRegion returns the region served by this deployment.
+func (api *API) PrimaryRegion() codersdk.Region {
+	wildcardHostname := api.AppHostname
+	if wildcardHostname != "" && api.AccessURL.Port() != "" {
+		wildcardHostname += fmt.Sprintf(":%s", api.AccessURL.Port())
+	}
+
+	return codersdk.Region{
+		ID:               uuid.Nil,
+		Name:             "primary",
+		DisplayName:      "Default",
+		IconURL:          "/emojis/1f3e1.png", // House with garden
+		Healthy:          true,
+		PathAppURL:       api.AccessURL.String(),
+		WildcardHostname: wildcardHostname,
+	}
+}
+
+// @Summary Get site-wide regions for workspace connections
+// @ID get-site-wide-regions-for-workspace-connections
+// @Security CoderSessionToken
+// @Produce json
+// @Tags General
+// @Success 200 {object} codersdk.RegionsResponse
+// @Router /regions [get]
+func (api *API) regions(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	regions := []codersdk.Region{api.PrimaryRegion()}
+	// This is used by Enterprise code to add workspace proxy regions.
+	fetcher := api.RegionsFetcher.Load()
+	if fetcher != nil && *fetcher != nil {
+		proxyRegions, err := (*fetcher)(ctx)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+				Message: "Internal error fetching workspace proxy regions.",
+				Detail:  err.Error(),
+			})
+			return
+		}
+		regions = append(regions, proxyRegions...)
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.RegionsResponse{
+		Regions: regions,
+	})
+}
+
+// latencyCheck is used by clients to measure the latency to a region. The
+// Timing-Allow-Origin header allows browsers to read detailed timing
+// information for cross-origin requests to workspace proxies.
+func latencyCheck(rw http.ResponseWriter, _ *http.Request) {
+	rw.Header().Set("Timing-Allow-Origin", "*")
+	rw.WriteHeader(http.StatusOK)
+}
@@ -830,6 +830,86 @@ func (api *API) putWorkspaceTTL(rw http.ResponseWriter, r *http.Request) {
 	rw.WriteHeader(http.StatusNoContent)
 }
 
+// @Summary Update workspace lock by ID
+// @ID update-workspace-lock-by-id
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Workspaces
+// @Param workspace path string true "Workspace ID" format(uuid)
+// @Param request body codersdk.UpdateWorkspaceLock true "Lock or unlock a workspace"
+// @Success 200 {object} codersdk.Response
+// @Router /workspaces/{workspace}/lock [put]
+func (api *API) putWorkspaceLock(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		workspace         = httpmw.WorkspaceParam(r)
+		auditor           = api.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.Workspace](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+	aReq.Old = workspace
+
+	var req codersdk.UpdateWorkspaceLock
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	lockedAt := sql.NullTime{}
+	if req.Lock {
+		lockedAt = sql.NullTime{
+			Time:  database.Now(),
+			Valid: true,
+		}
+	}
+
+	newWorkspace := workspace
+	err := api.Database.InTx(func(tx database.Store) error {
+		err := tx.UpdateWorkspaceLockedAt(ctx, database.UpdateWorkspaceLockedAtParams{
+			ID:       workspace.ID,
+			LockedAt: lockedAt,
+		})
+		if err != nil {
+			return xerrors.Errorf("update workspace locked at: %w", err)
+		}
+		newWorkspace.LockedAt = lockedAt
+
+		// Unlocking a workspace counts as using it. Otherwise an unused
+		// workspace would be locked again on the next autobuild tick.
+		if !req.Lock {
+			err = tx.UpdateWorkspaceLastUsedAt(ctx, database.UpdateWorkspaceLastUsedAtParams{
+				ID:         workspace.ID,
+				LastUsedAt: database.Now(),
+			})
+			if err != nil {
+				return xerrors.Errorf("update workspace last used at: %w", err)
+			}
+		}
+		return nil
+	}, nil)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Internal error updating workspace lock.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.New = newWorkspace
+
+	msg := "Workspace has been unlocked."
+	if req.Lock {
+		msg = "Workspace has been locked."
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: msg,
+	})
+}
+
 // @Summary Extend workspace deadline by ID
 // @ID extend-workspace-deadline-by-id
 // @Security CoderSessionToken
@@ -1141,6 +1221,11 @@ func convertWorkspace(
 		autostartSchedule = &workspace.AutostartSchedule.String
 	}
 
+	var lockedAt *time.Time
+	if workspace.LockedAt.Valid {
+		lockedAt = &workspace.LockedAt.Time
+	}
+
 	ttlMillis := convertWorkspaceTTLMillis(workspace.Ttl)
 	return codersdk.Workspace{
 		ID:                                   workspace.ID,
@@ -1160,6 +1245,7 @@ func convertWorkspace(
 		AutostartSchedule:                    autostartSchedule,
 		TTLMillis:                            ttlMillis,
 		LastUsedAt:                           workspace.LastUsedAt,
+		LockedAt:                             lockedAt,
 	}
 }
 
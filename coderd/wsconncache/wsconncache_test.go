@@ -242,6 +242,10 @@ func (*client) PostLifecycle(_ context.Context, _ agentsdk.PostLifecycleRequest)
 	return nil
 }
 
+func (*client) PostMetadata(_ context.Context, _ string, _ agentsdk.PostMetadataRequest) error {
+	return nil
+}
+
 func (*client) PostAppHealth(_ context.Context, _ agentsdk.PostAppHealthsRequest) error {
 	return nil
 }
@@ -253,3 +257,11 @@ func (*client) PostStartup(_ context.Context, _ agentsdk.PostStartupRequest) err
 func (*client) PatchStartupLogs(_ context.Context, _ agentsdk.PatchStartupLogs) error {
 	return nil
 }
+
+func (*client) PostTerminalRecording(_ context.Context, _ codersdk.TerminalRecordingType, _ uuid.UUID, _ io.Reader) error {
+	return nil
+}
+
+func (*client) PostNetcheck(_ context.Context, _ codersdk.WorkspaceAgentNetcheck) error {
+	return nil
+}
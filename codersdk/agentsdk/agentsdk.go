@@ -80,6 +80,12 @@ type Metadata struct {
 	MOTDFile              string                  `json:"motd_file"`
 	ShutdownScript        string                  `json:"shutdown_script"`
 	ShutdownScriptTimeout time.Duration           `json:"shutdown_script_timeout"`
+	// Metadata describes the dynamic metadata the agent should collect
+	// and report back to coderd.
+	Metadata []codersdk.WorkspaceAgentMetadataDescription `json:"metadata"`
+	// RecordTerminalSessions is true if the deployment or the template
+	// requires terminal sessions to be recorded.
+	RecordTerminalSessions bool `json:"record_terminal_sessions"`
 }
 
 // Metadata fetches metadata for the currently authenticated workspace agent.
@@ -482,6 +488,31 @@ func (c *Client) PostStats(ctx context.Context, stats *Stats) (StatsResponse, er
 	return interval, nil
 }
 
+type PostMetadataRequest struct {
+	CollectedAt time.Time `json:"collected_at"`
+	// Age is the number of seconds since the metadata was collected.
+	// It is provided in addition to CollectedAt to protect against clock skew.
+	Age   int64  `json:"age"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// PostMetadata reports the latest result of the metadata script identified
+// by key.
+func (c *Client) PostMetadata(ctx context.Context, key string, req PostMetadataRequest) error {
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/metadata/"+key, req)
+	if err != nil {
+		return xerrors.Errorf("execute request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusNoContent {
+		return codersdk.ReadBodyAsError(res)
+	}
+
+	return nil
+}
+
 type PostLifecycleRequest struct {
 	State codersdk.WorkspaceAgentLifecycle `json:"state"`
 }
@@ -516,6 +547,19 @@ func (c *Client) PostStartup(ctx context.Context, req PostStartupRequest) error
 	return nil
 }
 
+// PostNetcheck reports the netcheck results of the agent.
+func (c *Client) PostNetcheck(ctx context.Context, req codersdk.WorkspaceAgentNetcheck) error {
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/netcheck", req)
+	if err != nil {
+		return xerrors.Errorf("agent netcheck post request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
 type StartupLog struct {
 	CreatedAt time.Time `json:"created_at"`
 	Output    string    `json:"output"`
@@ -539,6 +583,33 @@ func (c *Client) PatchStartupLogs(ctx context.Context, req PatchStartupLogs) err
 	return nil
 }
 
+// TerminalRecordingMaxSize is the maximum size of a terminal recording.
+// Agents stop recording sessions that exceed it.
+const TerminalRecordingMaxSize = 50 << 20
+
+// PostTerminalRecording uploads a finished terminal recording in the
+// asciicast v2 format. The client reported user ID is the user the client
+// claimed to connect as, if any. It's stored alongside the recording but
+// isn't verified, recordings are attributed to the workspace owner.
+func (c *Client) PostTerminalRecording(ctx context.Context, recordingType codersdk.TerminalRecordingType, clientReportedUserID uuid.UUID, recording io.Reader) error {
+	query := url.Values{}
+	query.Set("type", string(recordingType))
+	if clientReportedUserID != uuid.Nil {
+		query.Set("client_reported_user_id", clientReportedUserID.String())
+	}
+	res, err := c.SDK.Request(ctx, http.MethodPost, "/api/v2/workspaceagents/me/terminal-recordings?"+query.Encode(), recording, func(r *http.Request) {
+		r.Header.Set("Content-Type", "application/x-asciicast")
+	})
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusCreated {
+		return codersdk.ReadBodyAsError(res)
+	}
+	return nil
+}
+
 type GitAuthResponse struct {
 	Username string `json:"username"`
 	Password string `json:"password"`
@@ -19,9 +19,12 @@ type APIKey struct {
 	CreatedAt       time.Time   `json:"created_at" validate:"required" format:"date-time"`
 	UpdatedAt       time.Time   `json:"updated_at" validate:"required" format:"date-time"`
 	LoginType       LoginType   `json:"login_type" validate:"required" enums:"password,github,oidc,token"`
-	Scope           APIKeyScope `json:"scope" validate:"required" enums:"all,application_connect"`
+	Scope           APIKeyScope `json:"scope" validate:"required" enums:"all,application_connect,resources"`
 	TokenName       string      `json:"token_name" validate:"required"`
 	LifetimeSeconds int64       `json:"lifetime_seconds" validate:"required"`
+	// Resources lists the actions a key with the resources scope may
+	// perform.
+	Resources []APIKeyScopeResource `json:"resources,omitempty"`
 }
 
 // LoginType is the type of login used to create the API key.
@@ -42,12 +45,26 @@ const (
 	// APIKeyScopeApplicationConnect is a scope that allows the user
 	// to connect to applications in a workspace.
 	APIKeyScopeApplicationConnect APIKeyScope = "application_connect"
+	// APIKeyScopeResources is a scope that only allows the user to
+	// perform specific actions on specific resources.
+	APIKeyScopeResources APIKeyScope = "resources"
 )
 
+// APIKeyScopeResource allows a single action on a single resource, e.g.
+// "read" on a template or "update" on a workspace.
+type APIKeyScopeResource struct {
+	ResourceType string    `json:"resource_type"`
+	ResourceID   uuid.UUID `json:"resource_id" format:"uuid"`
+	Action       string    `json:"action" enums:"create,read,update,delete"`
+}
+
 type CreateTokenRequest struct {
 	Lifetime  time.Duration `json:"lifetime"`
-	Scope     APIKeyScope   `json:"scope" enums:"all,application_connect"`
+	Scope     APIKeyScope   `json:"scope" enums:"all,application_connect,resources"`
 	TokenName string        `json:"token_name"`
+	// Resources must be set when Scope is "resources". All resources of
+	// the same type must be granted the same actions.
+	Resources []APIKeyScopeResource `json:"resources,omitempty"`
 }
 
 // GenerateAPIKeyResponse contains an API key for a user.
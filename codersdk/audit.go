@@ -3,26 +3,32 @@ package codersdk
 import (
 	"context"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
 	"net/netip"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"golang.org/x/xerrors"
 )
 
 type ResourceType string
 
 const (
-	ResourceTypeTemplate        ResourceType = "template"
-	ResourceTypeTemplateVersion ResourceType = "template_version"
-	ResourceTypeUser            ResourceType = "user"
-	ResourceTypeWorkspace       ResourceType = "workspace"
-	ResourceTypeWorkspaceBuild  ResourceType = "workspace_build"
-	ResourceTypeGitSSHKey       ResourceType = "git_ssh_key"
-	ResourceTypeAPIKey          ResourceType = "api_key"
-	ResourceTypeGroup           ResourceType = "group"
-	ResourceTypeLicense         ResourceType = "license"
+	ResourceTypeTemplate                ResourceType = "template"
+	ResourceTypeTemplateVersion         ResourceType = "template_version"
+	ResourceTypeUser                    ResourceType = "user"
+	ResourceTypeWorkspace               ResourceType = "workspace"
+	ResourceTypeWorkspaceBuild          ResourceType = "workspace_build"
+	ResourceTypeGitSSHKey               ResourceType = "git_ssh_key"
+	ResourceTypeAPIKey                  ResourceType = "api_key"
+	ResourceTypeGroup                   ResourceType = "group"
+	ResourceTypeLicense                 ResourceType = "license"
+	ResourceTypeWorkspaceProxy          ResourceType = "workspace_proxy"
+	ResourceTypeAuditFilterRules        ResourceType = "audit_filter_rules"
+	ResourceTypeWorkspaceAgentPortShare ResourceType = "workspace_agent_port_share"
 )
 
 func (r ResourceType) FriendlyString() string {
@@ -47,6 +53,12 @@ func (r ResourceType) FriendlyString() string {
 		return "group"
 	case ResourceTypeLicense:
 		return "license"
+	case ResourceTypeWorkspaceProxy:
+		return "workspace proxy"
+	case ResourceTypeAuditFilterRules:
+		return "audit filter rules"
+	case ResourceTypeWorkspaceAgentPortShare:
+		return "workspace port share"
 	default:
 		return "unknown"
 	}
@@ -126,6 +138,38 @@ type AuditLogResponse struct {
 	Count     int64      `json:"count"`
 }
 
+// AuditLogExportFormat is the file format audit logs are exported in.
+type AuditLogExportFormat string
+
+const (
+	AuditLogExportFormatCSV    AuditLogExportFormat = "csv"
+	AuditLogExportFormatNDJSON AuditLogExportFormat = "ndjson"
+)
+
+// AuditLogExportErrorTrailer is the HTTP trailer set when an export fails
+// after the response has started.
+const AuditLogExportErrorTrailer = "Coder-Audit-Log-Export-Error"
+
+func (f AuditLogExportFormat) Valid() bool {
+	switch f {
+	case AuditLogExportFormatCSV, AuditLogExportFormatNDJSON:
+		return true
+	default:
+		return false
+	}
+}
+
+// AuditLogsExportRequest selects the audit logs to export. SearchQuery uses the
+// same syntax as AuditLogsRequest.
+type AuditLogsExportRequest struct {
+	SearchQuery string               `json:"q,omitempty"`
+	Format      AuditLogExportFormat `json:"format,omitempty"`
+	// After and Before narrow the export to a precise time range, as the
+	// date_from and date_to search terms only accept dates.
+	After  time.Time `json:"after,omitempty" format:"date-time"`
+	Before time.Time `json:"before,omitempty" format:"date-time"`
+}
+
 type CreateTestAuditLogRequest struct {
 	Action           AuditAction     `json:"action,omitempty" enums:"create,write,delete,start,stop"`
 	ResourceType     ResourceType    `json:"resource_type,omitempty" enums:"template,template_version,user,workspace,workspace_build,git_ssh_key,auditable_group"`
@@ -164,6 +208,55 @@ func (c *Client) AuditLogs(ctx context.Context, req AuditLogsRequest) (AuditLogR
 	return logRes, nil
 }
 
+// ExportAuditLogs streams every audit log matching the request, newest first.
+// The caller must close the returned reader. Reading returns an error instead
+// of io.EOF if the export failed partway.
+func (c *Client) ExportAuditLogs(ctx context.Context, req AuditLogsExportRequest) (io.ReadCloser, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit/export", nil, func(r *http.Request) {
+		q := r.URL.Query()
+		q.Set("q", req.SearchQuery)
+		if req.Format != "" {
+			q.Set("format", string(req.Format))
+		}
+		if !req.After.IsZero() {
+			q.Set("after", req.After.Format(time.RFC3339Nano))
+		}
+		if !req.Before.IsZero() {
+			q.Set("before", req.Before.Format(time.RFC3339Nano))
+		}
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, ReadBodyAsError(res)
+	}
+	return &auditLogExportReader{res: res}, nil
+}
+
+// auditLogExportReader reads an audit log export, returning the error sent in
+// the trailer once the body has been read.
+// @typescript-ignore auditLogExportReader
+type auditLogExportReader struct {
+	res *http.Response
+}
+
+func (r *auditLogExportReader) Read(p []byte) (int, error) {
+	n, err := r.res.Body.Read(p)
+	if errors.Is(err, io.EOF) {
+		if message := r.res.Trailer.Get(AuditLogExportErrorTrailer); message != "" {
+			return n, xerrors.Errorf("export failed: %s", message)
+		}
+	}
+	return n, err
+}
+
+func (r *auditLogExportReader) Close() error {
+	return r.res.Body.Close()
+}
+
 // CreateTestAuditLog creates a fake audit log. Only owners of the organization
 // can perform this action. It's used for testing purposes.
 func (c *Client) CreateTestAuditLog(ctx context.Context, req CreateTestAuditLogRequest) error {
@@ -179,3 +272,61 @@ func (c *Client) CreateTestAuditLog(ctx context.Context, req CreateTestAuditLogR
 
 	return nil
 }
+
+// AuditFilterRule decides whether matching audit logs are stored in the
+// database, exported to external backends, both, or dropped entirely. An audit
+// log matches a rule when it matches every non-empty condition of the rule.
+type AuditFilterRule struct {
+	ResourceTypes []ResourceType `json:"resource_types,omitempty"`
+	Actions       []AuditAction  `json:"actions,omitempty"`
+	UserIDs       []uuid.UUID    `json:"user_ids,omitempty" format:"uuid"`
+	StatusCodes   []int32        `json:"status_codes,omitempty"`
+	// BuildReasons matches workspace build audit logs, e.g. to filter builds
+	// started by autostart.
+	BuildReasons []BuildReason `json:"build_reasons,omitempty"`
+	// DiffFields matches audit logs that changed any of the given fields.
+	DiffFields []string `json:"diff_fields,omitempty"`
+
+	// Store keeps matching audit logs in the database.
+	Store bool `json:"store"`
+	// Export sends matching audit logs to external backends, such as the
+	// webhook and syslog backends.
+	Export bool `json:"export"`
+}
+
+// AuditFilterRules are evaluated in order, and the first matching rule decides
+// what happens to an audit log. Audit logs that don't match any rule are
+// stored and exported.
+type AuditFilterRules struct {
+	Rules []AuditFilterRule `json:"rules"`
+}
+
+// AuditFilterRules returns the rules deciding which audit logs are stored and
+// exported.
+func (c *Client) AuditFilterRules(ctx context.Context) (AuditFilterRules, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/audit/filter-rules", nil)
+	if err != nil {
+		return AuditFilterRules{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AuditFilterRules{}, ReadBodyAsError(res)
+	}
+	var rules AuditFilterRules
+	return rules, json.NewDecoder(res.Body).Decode(&rules)
+}
+
+// UpdateAuditFilterRules replaces the rules deciding which audit logs are
+// stored and exported.
+func (c *Client) UpdateAuditFilterRules(ctx context.Context, rules AuditFilterRules) (AuditFilterRules, error) {
+	res, err := c.Request(ctx, http.MethodPut, "/api/v2/audit/filter-rules", rules)
+	if err != nil {
+		return AuditFilterRules{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return AuditFilterRules{}, ReadBodyAsError(res)
+	}
+	var updated AuditFilterRules
+	return updated, json.NewDecoder(res.Body).Decode(&updated)
+}
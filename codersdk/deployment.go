@@ -46,6 +46,7 @@ const (
 	FeatureExternalProvisionerDaemons FeatureName = "external_provisioner_daemons"
 	FeatureAppearance                 FeatureName = "appearance"
 	FeatureAdvancedTemplateScheduling FeatureName = "advanced_template_scheduling"
+	FeatureWorkspaceProxy             FeatureName = "workspace_proxy"
 )
 
 // FeatureNames must be kept in-sync with the Feature enum above.
@@ -60,6 +61,7 @@ var FeatureNames = []FeatureName{
 	FeatureExternalProvisionerDaemons,
 	FeatureAppearance,
 	FeatureAdvancedTemplateScheduling,
+	FeatureWorkspaceProxy,
 }
 
 // Humanize returns the feature name in a human-readable format.
@@ -144,6 +146,10 @@ type DeploymentValues struct {
 	AgentStatRefreshInterval        clibase.Duration                `json:"agent_stat_refresh_interval,omitempty" typescript:",notnull"`
 	AgentFallbackTroubleshootingURL clibase.URL                     `json:"agent_fallback_troubleshooting_url,omitempty" typescript:",notnull"`
 	AuditLogging                    clibase.Bool                    `json:"audit_logging,omitempty" typescript:",notnull"`
+	AuditExport                     AuditExportConfig               `json:"audit_export,omitempty" typescript:",notnull"`
+	Retention                       RetentionConfig                 `json:"retention,omitempty" typescript:",notnull"`
+	RecordTerminalSessions          clibase.Bool                    `json:"record_terminal_sessions,omitempty" typescript:",notnull"`
+	TemplateGitAllowedHosts         clibase.StringArray             `json:"template_git_allowed_hosts,omitempty" typescript:",notnull"`
 	BrowserOnly                     clibase.Bool                    `json:"browser_only,omitempty" typescript:",notnull"`
 	SCIMAPIKey                      clibase.String                  `json:"scim_api_key,omitempty" typescript:",notnull"`
 	Provisioner                     ProvisionerConfig               `json:"provisioner,omitempty" typescript:",notnull"`
@@ -322,6 +328,28 @@ type LoggingConfig struct {
 	Stackdriver clibase.String `json:"stackdriver" typescript:",notnull"`
 }
 
+// AuditExportConfig configures where audit logs are sent in addition to the
+// database.
+type AuditExportConfig struct {
+	WebhookURL           clibase.URL      `json:"webhook_url" typescript:",notnull"`
+	WebhookSecret        clibase.String   `json:"webhook_secret" typescript:",notnull"`
+	WebhookBatchSize     clibase.Int64    `json:"webhook_batch_size" typescript:",notnull"`
+	WebhookFlushInterval clibase.Duration `json:"webhook_flush_interval" typescript:",notnull"`
+	WebhookQueueSize     clibase.Int64    `json:"webhook_queue_size" typescript:",notnull"`
+	SyslogAddress        clibase.String   `json:"syslog_address" typescript:",notnull"`
+	SyslogTLS            clibase.Bool     `json:"syslog_tls" typescript:",notnull"`
+	SyslogFacility       clibase.String   `json:"syslog_facility" typescript:",notnull"`
+}
+
+// RetentionConfig configures how long rows are kept before they're purged. A
+// zero duration keeps rows forever.
+type RetentionConfig struct {
+	AuditLogs           clibase.Duration `json:"audit_logs" typescript:",notnull"`
+	ProvisionerJobLogs  clibase.Duration `json:"provisioner_job_logs" typescript:",notnull"`
+	WorkspaceBuildState clibase.Duration `json:"workspace_build_state" typescript:",notnull"`
+	ArchiveDir          clibase.String   `json:"archive_dir" typescript:",notnull"`
+}
+
 type DangerousConfig struct {
 	AllowPathAppSharing         clibase.Bool `json:"allow_path_app_sharing" typescript:",notnull"`
 	AllowPathAppSiteOwnerAccess clibase.Bool `json:"allow_path_app_site_owner_access" typescript:",notnull"`
@@ -403,6 +431,14 @@ func (c *DeploymentValues) Options() clibase.OptionSet {
 			Parent: &deploymentGroupIntrospection,
 			Name:   "Logging",
 		}
+		deploymentGroupAuditExport = clibase.Group{
+			Name:        "Audit Export",
+			Description: `Send audit logs to external systems such as a SIEM as they happen.`,
+		}
+		deploymentGroupRetention = clibase.Group{
+			Name:        "Retention",
+			Description: `Configure how long data is kept in the database before it's purged. Purges run once a day.`,
+		}
 		deploymentGroupOAuth2 = clibase.Group{
 			Name:        "OAuth2",
 			Description: `Configure login and user-provisioning with GitHub via oAuth2.`,
@@ -1239,6 +1275,23 @@ when required by your organization's security policy.`,
 			Value:       &c.AgentFallbackTroubleshootingURL,
 			YAML:        "agentFallbackTroubleshootingURL",
 		},
+		{
+			Name:        "Record Terminal Sessions",
+			Description: "Record the output of every SSH and web terminal session in all workspaces. Templates can also enable recording for their own workspaces.",
+			Flag:        "record-terminal-sessions",
+			Env:         "CODER_RECORD_TERMINAL_SESSIONS",
+			Default:     "false",
+			Value:       &c.RecordTerminalSessions,
+			YAML:        "recordTerminalSessions",
+		},
+		{
+			Name:        "Template Git Allowed Hosts",
+			Description: "Hosts that template versions may be fetched from with \"coder templates push --git\". Hosts of git auth providers must be listed too. e.g. github.com, gitlab.example.com:8443.",
+			Flag:        "template-git-allowed-hosts",
+			Env:         "CODER_TEMPLATE_GIT_ALLOWED_HOSTS",
+			Value:       &c.TemplateGitAllowedHosts,
+			YAML:        "templateGitAllowedHosts",
+		},
 		{
 			Name:        "Audit Logging",
 			Description: "Specifies whether audit logging is enabled.",
@@ -1249,6 +1302,128 @@ when required by your organization's security policy.`,
 			Value:       &c.AuditLogging,
 			YAML:        "auditLogging",
 		},
+		{
+			Name:        "Audit Webhook URL",
+			Description: "URL to send batches of audit logs to as JSON. Disabled when unset.",
+			Flag:        "audit-webhook-url",
+			Env:         "CODER_AUDIT_WEBHOOK_URL",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookURL,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookURL",
+		},
+		{
+			Name:        "Audit Webhook Secret",
+			Description: "Secret used to sign audit webhook requests. The hex-encoded HMAC-SHA256 of the body is sent in the X-Coder-Signature header.",
+			Flag:        "audit-webhook-secret",
+			Env:         "CODER_AUDIT_WEBHOOK_SECRET",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true").Mark(flagSecretKey, "true"),
+			Value:       &c.AuditExport.WebhookSecret,
+			Group:       &deploymentGroupAuditExport,
+		},
+		{
+			Name:        "Audit Webhook Batch Size",
+			Description: "Maximum number of audit logs sent in a single webhook request.",
+			Flag:        "audit-webhook-batch-size",
+			Env:         "CODER_AUDIT_WEBHOOK_BATCH_SIZE",
+			Default:     "100",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookBatchSize,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookBatchSize",
+		},
+		{
+			Name:        "Audit Webhook Flush Interval",
+			Description: "How often queued audit logs are sent to the webhook when a batch isn't full.",
+			Flag:        "audit-webhook-flush-interval",
+			Env:         "CODER_AUDIT_WEBHOOK_FLUSH_INTERVAL",
+			Default:     (5 * time.Second).String(),
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookFlushInterval,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookFlushInterval",
+		},
+		{
+			Name:        "Audit Webhook Queue Size",
+			Description: "Maximum number of undelivered audit logs kept for the webhook. Audit logs are queued on disk in the cache directory.",
+			Flag:        "audit-webhook-queue-size",
+			Env:         "CODER_AUDIT_WEBHOOK_QUEUE_SIZE",
+			Default:     "10000",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.WebhookQueueSize,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "webhookQueueSize",
+		},
+		{
+			Name:        "Audit Syslog Address",
+			Description: "The host:port of a syslog server to send audit logs to as RFC 5424 messages over TCP. Disabled when unset.",
+			Flag:        "audit-syslog-address",
+			Env:         "CODER_AUDIT_SYSLOG_ADDRESS",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.SyslogAddress,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "syslogAddress",
+		},
+		{
+			Name:        "Audit Syslog TLS",
+			Description: "Whether to connect to the syslog server with TLS.",
+			Flag:        "audit-syslog-tls",
+			Env:         "CODER_AUDIT_SYSLOG_TLS",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.SyslogTLS,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "syslogTLS",
+		},
+		{
+			Name:        "Audit Syslog Facility",
+			Description: "The syslog facility of audit log messages, e.g. \"auth\" or \"local0\".",
+			Flag:        "audit-syslog-facility",
+			Env:         "CODER_AUDIT_SYSLOG_FACILITY",
+			Default:     "local0",
+			Annotations: clibase.Annotations{}.Mark(flagEnterpriseKey, "true"),
+			Value:       &c.AuditExport.SyslogFacility,
+			Group:       &deploymentGroupAuditExport,
+			YAML:        "syslogFacility",
+		},
+		{
+			Name:        "Audit Logs Retention",
+			Description: "How long audit logs are kept before they're deleted. Audit logs are kept forever when zero.",
+			Flag:        "audit-logs-retention",
+			Env:         "CODER_AUDIT_LOGS_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.AuditLogs,
+			Group:       &deploymentGroupRetention,
+			YAML:        "auditLogs",
+		},
+		{
+			Name:        "Provisioner Job Logs Retention",
+			Description: "How long the logs of template imports and workspace builds are kept before they're deleted. Logs are kept forever when zero.",
+			Flag:        "provisioner-job-logs-retention",
+			Env:         "CODER_PROVISIONER_JOB_LOGS_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.ProvisionerJobLogs,
+			Group:       &deploymentGroupRetention,
+			YAML:        "provisionerJobLogs",
+		},
+		{
+			Name:        "Workspace Build State Retention",
+			Description: "How long the Terraform state of workspace builds is kept after a newer build of the workspace. The state of the latest build is always kept. State is kept forever when zero.",
+			Flag:        "workspace-build-state-retention",
+			Env:         "CODER_WORKSPACE_BUILD_STATE_RETENTION",
+			Default:     "0",
+			Value:       &c.Retention.WorkspaceBuildState,
+			Group:       &deploymentGroupRetention,
+			YAML:        "workspaceBuildState",
+		},
+		{
+			Name:        "Retention Archive Directory",
+			Description: "Directory to archive purged rows to as NDJSON before they're deleted, with one file per table and day. Rows aren't archived when unset.",
+			Flag:        "retention-archive-dir",
+			Env:         "CODER_RETENTION_ARCHIVE_DIR",
+			Value:       &c.Retention.ArchiveDir,
+			Group:       &deploymentGroupRetention,
+			YAML:        "archiveDir",
+		},
 		{
 			Name:        "Browser Only",
 			Description: "Whether Coder only allows connections to workspaces via the browser.",
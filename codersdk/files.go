@@ -6,12 +6,18 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 
 	"github.com/google/uuid"
 )
 
 const (
 	ContentTypeTar = "application/x-tar"
+	ContentTypeZip = "application/zip"
+
+	// FormatZip requests a tar file be converted to a zip archive when it is
+	// downloaded.
+	FormatZip = "zip"
 )
 
 // UploadResponse contains the hash to reference the uploaded file.
@@ -38,7 +44,17 @@ func (c *Client) Upload(ctx context.Context, contentType string, rd io.Reader) (
 
 // Download fetches a file by uploaded hash.
 func (c *Client) Download(ctx context.Context, id uuid.UUID) ([]byte, string, error) {
-	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/files/%s", id.String()), nil)
+	return c.DownloadWithFormat(ctx, id, "")
+}
+
+// DownloadWithFormat fetches a file by uploaded hash, converting it to the
+// given format. An empty format returns the file as it was stored.
+func (c *Client) DownloadWithFormat(ctx context.Context, id uuid.UUID, format string) ([]byte, string, error) {
+	path := fmt.Sprintf("/api/v2/files/%s", id.String())
+	if format != "" {
+		path += "?format=" + url.QueryEscape(format)
+	}
+	res, err := c.Request(ctx, http.MethodGet, path, nil)
 	if err != nil {
 		return nil, "", err
 	}
@@ -46,11 +46,15 @@ type CreateTemplateVersionRequest struct {
 	// TemplateID optionally associates a version with a template.
 	TemplateID      uuid.UUID                `json:"template_id,omitempty" format:"uuid"`
 	StorageMethod   ProvisionerStorageMethod `json:"storage_method" validate:"oneof=file,required" enums:"file"`
-	FileID          uuid.UUID                `json:"file_id,omitempty" validate:"required_without=ExampleID" format:"uuid"`
-	ExampleID       string                   `json:"example_id,omitempty" validate:"required_without=FileID"`
+	FileID          uuid.UUID                `json:"file_id,omitempty" validate:"required_without_all=ExampleID GitSource" format:"uuid"`
+	ExampleID       string                   `json:"example_id,omitempty" validate:"required_without_all=FileID GitSource"`
 	Provisioner     ProvisionerType          `json:"provisioner" validate:"oneof=terraform echo,required"`
 	ProvisionerTags map[string]string        `json:"tags"`
 
+	// GitSource creates the template version from a git repository, which is
+	// cloned by coderd.
+	GitSource *TemplateVersionGitSource `json:"git_source,omitempty"`
+
 	// ParameterValues allows for additional parameters to be provided
 	// during the dry-run provision stage.
 	ParameterValues []CreateParameterRequest `json:"parameter_values,omitempty"`
@@ -58,6 +62,20 @@ type CreateTemplateVersionRequest struct {
 	UserVariableValues []VariableValue `json:"user_variable_values,omitempty"`
 }
 
+// TemplateVersionGitSource is a git repository reference that a template
+// version is created from. Credentials are taken from the git auth provider
+// that matches the URL, if any.
+type TemplateVersionGitSource struct {
+	// URL is the HTTP(S) URL of the repository.
+	URL string `json:"url" validate:"required"`
+	// Ref is a branch, tag or commit SHA. Defaults to the default branch of
+	// the repository.
+	Ref string `json:"ref,omitempty"`
+	// Directory is the path of the template within the repository. Defaults
+	// to the root of the repository.
+	Directory string `json:"directory,omitempty"`
+}
+
 type VariableValue struct {
 	Name  string `json:"name"`
 	Value string `json:"value"`
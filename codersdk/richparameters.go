@@ -78,12 +78,16 @@ func ValidateWorkspaceBuildParameter(richParameter TemplateVersionParameter, bui
 		return nil
 	}
 
+	// The provider checks min and max unless they're disabled. Zero means
+	// unset for the other types, so it isn't mistaken for a bound.
 	validation := &provider.Validation{
-		Min:       int(richParameter.ValidationMin),
-		Max:       int(richParameter.ValidationMax),
-		Regex:     richParameter.ValidationRegex,
-		Error:     richParameter.ValidationError,
-		Monotonic: string(richParameter.ValidationMonotonic),
+		Min:         int(richParameter.ValidationMin),
+		MinDisabled: richParameter.Type != "number" && richParameter.ValidationMin == 0,
+		Max:         int(richParameter.ValidationMax),
+		MaxDisabled: richParameter.Type != "number" && richParameter.ValidationMax == 0,
+		Regex:       richParameter.ValidationRegex,
+		Error:       richParameter.ValidationError,
+		Monotonic:   string(richParameter.ValidationMonotonic),
 	}
 	return validation.Valid(richParameter.Type, value)
 }
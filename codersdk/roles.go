@@ -46,3 +46,93 @@ func (c *Client) ListOrganizationRoles(ctx context.Context, org uuid.UUID) ([]As
 	var roles []AssignableRoles
 	return roles, json.NewDecoder(res.Body).Decode(&roles)
 }
+
+// Permission grants an action on a resource type, or denies it when Negate
+// is set. "*" matches every resource type or action.
+type Permission struct {
+	Negate       bool   `json:"negate"`
+	ResourceType string `json:"resource_type"`
+	Action       string `json:"action"`
+}
+
+// CustomRole is a role defined by an admin. Site wide roles grant site and
+// user permissions, and are assigned by name. Organization roles grant
+// permissions in their organization, and are assigned as
+// "<name>:<organization_id>" like built-in organization roles.
+type CustomRole struct {
+	Name                    string       `json:"name"`
+	DisplayName             string       `json:"display_name"`
+	OrganizationID          uuid.UUID    `json:"organization_id,omitempty" format:"uuid"`
+	SitePermissions         []Permission `json:"site_permissions"`
+	OrganizationPermissions []Permission `json:"organization_permissions"`
+	UserPermissions         []Permission `json:"user_permissions"`
+}
+
+// CustomSiteRoles lists the custom site wide roles.
+func (c *Client) CustomSiteRoles(ctx context.Context) ([]CustomRole, error) {
+	return c.customRoles(ctx, "/api/v2/users/roles/custom")
+}
+
+// UpsertCustomSiteRole creates or replaces a custom site wide role.
+func (c *Client) UpsertCustomSiteRole(ctx context.Context, role CustomRole) (CustomRole, error) {
+	return c.upsertCustomRole(ctx, "/api/v2/users/roles/custom", role)
+}
+
+// DeleteCustomSiteRole deletes a custom site wide role.
+func (c *Client) DeleteCustomSiteRole(ctx context.Context, name string) error {
+	return c.deleteCustomRole(ctx, fmt.Sprintf("/api/v2/users/roles/custom/%s", name))
+}
+
+// CustomOrganizationRoles lists the custom roles of an organization.
+func (c *Client) CustomOrganizationRoles(ctx context.Context, org uuid.UUID) ([]CustomRole, error) {
+	return c.customRoles(ctx, fmt.Sprintf("/api/v2/organizations/%s/members/roles/custom", org.String()))
+}
+
+// UpsertCustomOrganizationRole creates or replaces a custom role of an
+// organization.
+func (c *Client) UpsertCustomOrganizationRole(ctx context.Context, org uuid.UUID, role CustomRole) (CustomRole, error) {
+	return c.upsertCustomRole(ctx, fmt.Sprintf("/api/v2/organizations/%s/members/roles/custom", org.String()), role)
+}
+
+// DeleteCustomOrganizationRole deletes a custom role of an organization.
+func (c *Client) DeleteCustomOrganizationRole(ctx context.Context, org uuid.UUID, name string) error {
+	return c.deleteCustomRole(ctx, fmt.Sprintf("/api/v2/organizations/%s/members/roles/custom/%s", org.String(), name))
+}
+
+func (c *Client) customRoles(ctx context.Context, path string) ([]CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var roles []CustomRole
+	return roles, json.NewDecoder(res.Body).Decode(&roles)
+}
+
+func (c *Client) upsertCustomRole(ctx context.Context, path string, role CustomRole) (CustomRole, error) {
+	res, err := c.Request(ctx, http.MethodPut, path, role)
+	if err != nil {
+		return CustomRole{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return CustomRole{}, ReadBodyAsError(res)
+	}
+	var resp CustomRole
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) deleteCustomRole(ctx context.Context, path string) error {
+	res, err := c.Request(ctx, http.MethodDelete, path, nil)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
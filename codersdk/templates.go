@@ -30,11 +30,23 @@ type Template struct {
 	DefaultTTLMillis int64                  `json:"default_ttl_ms"`
 	// MaxTTLMillis is an enterprise feature. It's value is only used if your
 	// license is entitled to use the advanced template scheduling feature.
-	MaxTTLMillis  int64     `json:"max_ttl_ms"`
-	CreatedByID   uuid.UUID `json:"created_by_id" format:"uuid"`
-	CreatedByName string    `json:"created_by_name"`
+	MaxTTLMillis int64 `json:"max_ttl_ms"`
+	// InactivityTTLMillis, LockedTTLMillis and FailureTTLMillis are enterprise
+	// features. Their values are only used if your license is entitled to use
+	// the advanced template scheduling feature.
+	InactivityTTLMillis int64     `json:"inactivity_ttl_ms"`
+	LockedTTLMillis     int64     `json:"locked_ttl_ms"`
+	FailureTTLMillis    int64     `json:"failure_ttl_ms"`
+	CreatedByID         uuid.UUID `json:"created_by_id" format:"uuid"`
+	CreatedByName       string    `json:"created_by_name"`
 
 	AllowUserCancelWorkspaceJobs bool `json:"allow_user_cancel_workspace_jobs"`
+	// RecordTerminalSessions records SSH and web terminal sessions in
+	// workspaces created from this template.
+	RecordTerminalSessions bool `json:"record_terminal_sessions"`
+	// MaxPortShareLevel is the most permissive level workspace owners may
+	// share listening ports at.
+	MaxPortShareLevel WorkspaceAppSharingLevel `json:"max_port_share_level" enums:"owner,authenticated,public"`
 }
 
 type TransitionStats struct {
@@ -86,8 +98,22 @@ type UpdateTemplateMeta struct {
 	// MaxTTLMillis can only be set if your license includes the advanced
 	// template scheduling feature. If you attempt to set this value while
 	// unlicensed, it will be ignored.
-	MaxTTLMillis                 int64 `json:"max_ttl_ms,omitempty"`
+	MaxTTLMillis int64 `json:"max_ttl_ms,omitempty"`
+	// InactivityTTLMillis is the duration a workspace may go unused before it
+	// is locked. LockedTTLMillis is the duration a workspace may remain locked
+	// before it is deleted. Both can only be set if your license includes the
+	// advanced template scheduling feature.
+	InactivityTTLMillis int64 `json:"inactivity_ttl_ms,omitempty"`
+	LockedTTLMillis     int64 `json:"locked_ttl_ms,omitempty"`
+	// FailureTTLMillis is the duration after which a workspace whose latest
+	// start build failed is automatically stopped. It can only be set if your
+	// license includes the advanced template scheduling feature.
+	FailureTTLMillis             int64 `json:"failure_ttl_ms,omitempty"`
 	AllowUserCancelWorkspaceJobs bool  `json:"allow_user_cancel_workspace_jobs,omitempty"`
+	RecordTerminalSessions       bool  `json:"record_terminal_sessions,omitempty"`
+	// MaxPortShareLevel is left unchanged if empty. Lowering it doesn't
+	// delete existing port shares, but they're limited to the new level.
+	MaxPortShareLevel WorkspaceAppSharingLevel `json:"max_port_share_level,omitempty" enums:"owner,authenticated,public"`
 }
 
 type TemplateExample struct {
@@ -184,13 +210,21 @@ func (c *Client) UpdateActiveTemplateVersion(ctx context.Context, template uuid.
 // TemplateVersionsByTemplateRequest defines the request parameters for
 // TemplateVersionsByTemplate.
 type TemplateVersionsByTemplateRequest struct {
-	TemplateID uuid.UUID `json:"template_id" validate:"required" format:"uuid"`
+	TemplateID      uuid.UUID `json:"template_id" validate:"required" format:"uuid"`
+	IncludeArchived bool      `json:"include_archived"`
 	Pagination
 }
 
 // TemplateVersionsByTemplate lists versions associated with a template.
 func (c *Client) TemplateVersionsByTemplate(ctx context.Context, req TemplateVersionsByTemplateRequest) ([]TemplateVersion, error) {
-	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/versions", req.TemplateID), nil, req.Pagination.asRequestOption())
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templates/%s/versions", req.TemplateID), nil, req.Pagination.asRequestOption(), func(r *http.Request) {
+		if !req.IncludeArchived {
+			return
+		}
+		q := r.URL.Query()
+		q.Set("include_archived", "true")
+		r.URL.RawQuery = q.Encode()
+	})
 	if err != nil {
 		return nil, err
 	}
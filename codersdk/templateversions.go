@@ -22,6 +22,12 @@ type TemplateVersion struct {
 	Job            ProvisionerJob `json:"job"`
 	Readme         string         `json:"readme"`
 	CreatedBy      User           `json:"created_by"`
+	// Archived versions are hidden from version pickers and cannot be used
+	// for new workspace builds.
+	Archived bool `json:"archived"`
+	// GitCommitSHA is the commit the template version was created from, if
+	// it was created from a git repository.
+	GitCommitSHA string `json:"git_commit_sha,omitempty"`
 }
 
 type TemplateVersionGitAuth struct {
@@ -55,6 +61,7 @@ type TemplateVersionParameter struct {
 	ValidationMonotonic  ValidationMonotonicOrder         `json:"validation_monotonic,omitempty" enums:"increasing,decreasing"`
 	Required             bool                             `json:"required"`
 	LegacyVariableName   string                           `json:"legacy_variable_name,omitempty"`
+	Ephemeral            bool                             `json:"ephemeral"`
 }
 
 // TemplateVersionParameterOption represents a selectable option for a template parameter.
@@ -76,6 +83,74 @@ type TemplateVersionVariable struct {
 	Sensitive    bool   `json:"sensitive"`
 }
 
+type TemplateVersionDiffChange string
+
+const (
+	TemplateVersionDiffChangeAdded    TemplateVersionDiffChange = "added"
+	TemplateVersionDiffChangeRemoved  TemplateVersionDiffChange = "removed"
+	TemplateVersionDiffChangeModified TemplateVersionDiffChange = "modified"
+)
+
+// TemplateVersionDiff describes the changes between a base template version
+// and a head template version. Only entries that differ are included.
+type TemplateVersionDiff struct {
+	BaseID           uuid.UUID                            `json:"base_id" format:"uuid"`
+	HeadID           uuid.UUID                            `json:"head_id" format:"uuid"`
+	Files            []TemplateVersionFileDiff            `json:"files"`
+	ParameterSchemas []TemplateVersionParameterSchemaDiff `json:"parameter_schemas"`
+	RichParameters   []TemplateVersionRichParameterDiff   `json:"rich_parameters"`
+	Variables        []TemplateVersionVariableDiff        `json:"variables"`
+	GitAuthProviders []TemplateVersionGitAuthProviderDiff `json:"git_auth_providers"`
+}
+
+// TemplateVersionFileDiff describes a change to a file in the template
+// source archive.
+type TemplateVersionFileDiff struct {
+	Path   string                    `json:"path"`
+	Change TemplateVersionDiffChange `json:"change" enums:"added,removed,modified"`
+	// Binary is true if either side of the change isn't text. No diff is
+	// included for binary files.
+	Binary bool `json:"binary"`
+	// TooLarge is true if either side of the change is larger than 10 MiB.
+	// No diff is included for files that are too large.
+	TooLarge bool `json:"too_large"`
+	// Diff is the change in unified diff format.
+	Diff string `json:"diff"`
+}
+
+// TemplateVersionParameterSchemaDiff describes a change to a legacy
+// parameter schema.
+type TemplateVersionParameterSchemaDiff struct {
+	Name   string                    `json:"name"`
+	Change TemplateVersionDiffChange `json:"change" enums:"added,removed,modified"`
+	Old    *ParameterSchema          `json:"old,omitempty"`
+	New    *ParameterSchema          `json:"new,omitempty"`
+}
+
+// TemplateVersionRichParameterDiff describes a change to a rich parameter.
+type TemplateVersionRichParameterDiff struct {
+	Name   string                    `json:"name"`
+	Change TemplateVersionDiffChange `json:"change" enums:"added,removed,modified"`
+	Old    *TemplateVersionParameter `json:"old,omitempty"`
+	New    *TemplateVersionParameter `json:"new,omitempty"`
+}
+
+// TemplateVersionVariableDiff describes a change to a Terraform-managed
+// variable. Values of sensitive variables are redacted.
+type TemplateVersionVariableDiff struct {
+	Name   string                    `json:"name"`
+	Change TemplateVersionDiffChange `json:"change" enums:"added,removed,modified"`
+	Old    *TemplateVersionVariable  `json:"old,omitempty"`
+	New    *TemplateVersionVariable  `json:"new,omitempty"`
+}
+
+// TemplateVersionGitAuthProviderDiff describes a git auth provider that was
+// added to or removed from a template version.
+type TemplateVersionGitAuthProviderDiff struct {
+	ID     string                    `json:"id"`
+	Change TemplateVersionDiffChange `json:"change" enums:"added,removed"`
+}
+
 type PatchTemplateVersionRequest struct {
 	Name string `json:"name"`
 }
@@ -107,6 +182,50 @@ func (c *Client) CancelTemplateVersion(ctx context.Context, version uuid.UUID) e
 	return nil
 }
 
+// TemplateVersionDiff returns the changes between the base and head template
+// versions.
+func (c *Client) TemplateVersionDiff(ctx context.Context, base, head uuid.UUID) (TemplateVersionDiff, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/diff", head), nil, func(r *http.Request) {
+		q := r.URL.Query()
+		q.Set("base", base.String())
+		r.URL.RawQuery = q.Encode()
+	})
+	if err != nil {
+		return TemplateVersionDiff{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateVersionDiff{}, ReadBodyAsError(res)
+	}
+	var diff TemplateVersionDiff
+	return diff, json.NewDecoder(res.Body).Decode(&diff)
+}
+
+// ArchiveTemplateVersion marks a template version as archived. Archived
+// versions are hidden from version pickers and cannot be used for new
+// workspace builds.
+func (c *Client) ArchiveTemplateVersion(ctx context.Context, version uuid.UUID) (TemplateVersion, error) {
+	return c.setTemplateVersionArchived(ctx, version, "archive")
+}
+
+// UnarchiveTemplateVersion restores an archived template version.
+func (c *Client) UnarchiveTemplateVersion(ctx context.Context, version uuid.UUID) (TemplateVersion, error) {
+	return c.setTemplateVersionArchived(ctx, version, "unarchive")
+}
+
+func (c *Client) setTemplateVersionArchived(ctx context.Context, version uuid.UUID, action string) (TemplateVersion, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/templateversions/%s/%s", version, action), nil)
+	if err != nil {
+		return TemplateVersion{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return TemplateVersion{}, ReadBodyAsError(res)
+	}
+	var templateVersion TemplateVersion
+	return templateVersion, json.NewDecoder(res.Body).Decode(&templateVersion)
+}
+
 // TemplateVersionParameters returns parameters a template version exposes.
 func (c *Client) TemplateVersionRichParameters(ctx context.Context, version uuid.UUID) ([]TemplateVersionParameter, error) {
 	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/templateversions/%s/rich-parameters", version), nil)
@@ -0,0 +1,90 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TerminalRecordingType is the kind of terminal session that was recorded.
+type TerminalRecordingType string
+
+const (
+	TerminalRecordingTypeSSH             TerminalRecordingType = "ssh"
+	TerminalRecordingTypeReconnectingPTY TerminalRecordingType = "reconnecting_pty"
+)
+
+// TerminalRecording describes a recorded terminal session. The recording
+// itself is downloaded with DownloadTerminalRecording.
+type TerminalRecording struct {
+	ID             uuid.UUID `json:"id" format:"uuid"`
+	CreatedAt      time.Time `json:"created_at" format:"date-time"`
+	StartedAt      time.Time `json:"started_at" format:"date-time"`
+	EndedAt        time.Time `json:"ended_at" format:"date-time"`
+	OrganizationID uuid.UUID `json:"organization_id" format:"uuid"`
+	WorkspaceID    uuid.UUID `json:"workspace_id" format:"uuid"`
+	AgentID        uuid.UUID `json:"agent_id" format:"uuid"`
+	// UserID is the owner of the workspace at the time of the recording.
+	UserID uuid.UUID `json:"user_id" format:"uuid"`
+	// ClientReportedUserID is the user the client reported as connecting
+	// to the session. It isn't verified, any client that can reach the
+	// agent can set it.
+	ClientReportedUserID *uuid.UUID            `json:"client_reported_user_id,omitempty" format:"uuid"`
+	Type                 TerminalRecordingType `json:"type" enums:"ssh,reconnecting_pty"`
+	// Size is the size of the recording in bytes.
+	Size int64 `json:"size"`
+}
+
+type TerminalRecordingsRequest struct {
+	Pagination
+	WorkspaceID uuid.UUID `json:"workspace_id,omitempty" format:"uuid"`
+	AgentID     uuid.UUID `json:"agent_id,omitempty" format:"uuid"`
+	UserID      uuid.UUID `json:"user_id,omitempty" format:"uuid"`
+}
+
+// TerminalRecordings lists terminal recordings, newest first.
+func (c *Client) TerminalRecordings(ctx context.Context, req TerminalRecordingsRequest) ([]TerminalRecording, error) {
+	res, err := c.Request(ctx, http.MethodGet, "/api/v2/terminal-recordings", nil,
+		req.Pagination.asRequestOption(),
+		func(r *http.Request) {
+			q := r.URL.Query()
+			if req.WorkspaceID != uuid.Nil {
+				q.Set("workspace_id", req.WorkspaceID.String())
+			}
+			if req.AgentID != uuid.Nil {
+				q.Set("agent_id", req.AgentID.String())
+			}
+			if req.UserID != uuid.Nil {
+				q.Set("user_id", req.UserID.String())
+			}
+			r.URL.RawQuery = q.Encode()
+		},
+	)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	var recordings []TerminalRecording
+	return recordings, json.NewDecoder(res.Body).Decode(&recordings)
+}
+
+// DownloadTerminalRecording fetches a recording in the asciicast v2 format.
+func (c *Client) DownloadTerminalRecording(ctx context.Context, id uuid.UUID) ([]byte, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/terminal-recordings/%s", id), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+	return io.ReadAll(res.Body)
+}
@@ -159,6 +159,30 @@ type WorkspaceAgentReconnectingPTYInit struct {
 	Height  uint16
 	Width   uint16
 	Command string
+	// Scrollback requests the output the agent stored on disk instead of
+	// only the in-memory buffer when connecting to an existing session.
+	Scrollback bool
+	// UserID is the user connecting to the session. The agent can't verify
+	// it, so it's only stored as the client reported user of recordings.
+	UserID uuid.UUID
+}
+
+// ReconnectingPTYOption configures the initialization of a reconnecting PTY.
+type ReconnectingPTYOption func(init *WorkspaceAgentReconnectingPTYInit)
+
+// ReconnectingPTYWithScrollback requests the scrollback stored on disk when
+// reconnecting. Agents without scrollback on disk replay the in-memory buffer.
+func ReconnectingPTYWithScrollback() ReconnectingPTYOption {
+	return func(init *WorkspaceAgentReconnectingPTYInit) {
+		init.Scrollback = true
+	}
+}
+
+// ReconnectingPTYWithUserID sets the user that is connecting to the session.
+func ReconnectingPTYWithUserID(userID uuid.UUID) ReconnectingPTYOption {
+	return func(init *WorkspaceAgentReconnectingPTYInit) {
+		init.UserID = userID
+	}
 }
 
 // ReconnectingPTYRequest is sent from the client to the server
@@ -173,7 +197,7 @@ type ReconnectingPTYRequest struct {
 // ReconnectingPTY spawns a new reconnecting terminal session.
 // `ReconnectingPTYRequest` should be JSON marshaled and written to the returned net.Conn.
 // Raw terminal output will be read from the returned net.Conn.
-func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID, height, width uint16, command string) (net.Conn, error) {
+func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID, height, width uint16, command string, opts ...ReconnectingPTYOption) (net.Conn, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 	if !c.AwaitReachable(ctx) {
@@ -183,12 +207,16 @@ func (c *WorkspaceAgentConn) ReconnectingPTY(ctx context.Context, id uuid.UUID,
 	if err != nil {
 		return nil, err
 	}
-	data, err := json.Marshal(WorkspaceAgentReconnectingPTYInit{
+	init := WorkspaceAgentReconnectingPTYInit{
 		ID:      id,
 		Height:  height,
 		Width:   width,
 		Command: command,
-	})
+	}
+	for _, opt := range opts {
+		opt(&init)
+	}
+	data, err := json.Marshal(init)
 	if err != nil {
 		_ = conn.Close()
 		return nil, err
@@ -283,9 +311,10 @@ type WorkspaceAgentListeningPortsResponse struct {
 }
 
 type WorkspaceAgentListeningPort struct {
-	ProcessName string `json:"process_name"` // may be empty
-	Network     string `json:"network"`      // only "tcp" at the moment
-	Port        uint16 `json:"port"`
+	ProcessName string `json:"process_name"`                 // may be empty
+	Network     string `json:"network" enums:"tcp,udp,unix"` // "tcp", "udp" or "unix"
+	Port        uint16 `json:"port"`                         // zero for Unix sockets
+	Path        string `json:"path,omitempty"`               // only set for Unix sockets
 }
 
 // ListeningPorts lists the ports that are currently in use by the workspace.
@@ -305,8 +334,58 @@ func (c *WorkspaceAgentConn) ListeningPorts(ctx context.Context) (WorkspaceAgent
 	return resp, json.NewDecoder(res.Body).Decode(&resp)
 }
 
+type WorkspaceAgentReconnectingPTYSessionsResponse struct {
+	Sessions []WorkspaceAgentReconnectingPTYSession `json:"sessions"`
+}
+
+// WorkspaceAgentReconnectingPTYSession is a reconnecting PTY that is running
+// in the workspace. Clients can attach to it with ReconnectingPTY and its ID.
+type WorkspaceAgentReconnectingPTYSession struct {
+	ID uuid.UUID `json:"id" format:"uuid"`
+	// Command is empty if the session runs the user's shell.
+	Command        string    `json:"command"`
+	StartedAt      time.Time `json:"started_at" format:"date-time"`
+	LastActivityAt time.Time `json:"last_activity_at" format:"date-time"`
+	// Connections is the number of clients attached to the session.
+	Connections int `json:"connections"`
+}
+
+// ReconnectingPTYSessions lists the reconnecting PTY sessions running in the
+// workspace, most recently active first.
+func (c *WorkspaceAgentConn) ReconnectingPTYSessions(ctx context.Context) (WorkspaceAgentReconnectingPTYSessionsResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/reconnecting-pty-sessions", nil)
+	if err != nil {
+		return WorkspaceAgentReconnectingPTYSessionsResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentReconnectingPTYSessionsResponse{}, ReadBodyAsError(res)
+	}
+
+	var resp WorkspaceAgentReconnectingPTYSessionsResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// KillReconnectingPTYSession terminates the process of a reconnecting PTY
+// session and disconnects its clients.
+func (c *WorkspaceAgentConn) KillReconnectingPTYSession(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodDelete, fmt.Sprintf("/api/v0/reconnecting-pty-sessions/%s", id), nil)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
 // apiRequest makes a request to the workspace agent's HTTP API server.
-func (c *WorkspaceAgentConn) apiRequest(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+func (c *WorkspaceAgentConn) apiRequest(ctx context.Context, method, path string, body io.Reader, opts ...RequestOption) (*http.Response, error) {
 	ctx, span := tracing.StartSpan(ctx)
 	defer span.End()
 	host := net.JoinHostPort(WorkspaceAgentIP.String(), strconv.Itoa(WorkspaceAgentHTTPAPIServerPort))
@@ -316,6 +395,9 @@ func (c *WorkspaceAgentConn) apiRequest(ctx context.Context, method, path string
 	if err != nil {
 		return nil, xerrors.Errorf("new http api request to %q: %w", url, err)
 	}
+	for _, opt := range opts {
+		opt(req)
+	}
 
 	return c.apiClient().Do(req)
 }
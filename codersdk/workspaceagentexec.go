@@ -0,0 +1,175 @@
+package codersdk
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+	"nhooyr.io/websocket"
+	"nhooyr.io/websocket/wsjson"
+
+	"github.com/coder/coder/coderd/tracing"
+)
+
+// WorkspaceAgentExecRequest is the first frame sent to the exec endpoint of
+// the agent.
+type WorkspaceAgentExecRequest struct {
+	// Command is run with the shell of the user the agent runs as, the same
+	// way a command passed to SSH is.
+	Command string `json:"command"`
+	// Env is added to the environment of the command, overriding existing
+	// variables.
+	Env map[string]string `json:"env,omitempty"`
+	// TimeoutMillis kills the command if it runs for longer. Zero disables
+	// the timeout.
+	TimeoutMillis int64 `json:"timeout_ms,omitempty"`
+}
+
+type WorkspaceAgentExecFrameType string
+
+const (
+	// Sent by the client.
+	WorkspaceAgentExecFrameStdin      WorkspaceAgentExecFrameType = "stdin"
+	WorkspaceAgentExecFrameStdinClose WorkspaceAgentExecFrameType = "stdin_close"
+	// Sent by the agent.
+	WorkspaceAgentExecFrameStdout WorkspaceAgentExecFrameType = "stdout"
+	WorkspaceAgentExecFrameStderr WorkspaceAgentExecFrameType = "stderr"
+	WorkspaceAgentExecFrameExit   WorkspaceAgentExecFrameType = "exit"
+)
+
+// WorkspaceAgentExecFrame is a message on an exec connection. After the
+// request, the client sends stdin frames, and the agent sends stdout and
+// stderr frames followed by a single exit frame.
+type WorkspaceAgentExecFrame struct {
+	Type WorkspaceAgentExecFrameType `json:"type"`
+	Data []byte                      `json:"data,omitempty"`
+	// The fields below are only set on exit frames.
+	ExitCode       int   `json:"exit_code,omitempty"`
+	DurationMillis int64 `json:"duration_ms,omitempty"`
+	TimedOut       bool  `json:"timed_out,omitempty"`
+	// Error is set if the command could not be run.
+	Error string `json:"error,omitempty"`
+}
+
+// WorkspaceAgentExecResult is the outcome of a command run with Exec.
+type WorkspaceAgentExecResult struct {
+	ExitCode int           `json:"exit_code"`
+	Duration time.Duration `json:"duration"`
+	// TimedOut is true if the command was killed because it exceeded its
+	// timeout.
+	TimedOut bool `json:"timed_out"`
+}
+
+// workspaceAgentExecChunkSize is the largest amount of output or input sent
+// in a single frame.
+const workspaceAgentExecChunkSize = 16 << 10
+
+// WorkspaceAgentExecReadLimit is the largest frame accepted on an exec
+// connection.
+const WorkspaceAgentExecReadLimit = 1 << 20
+
+// WorkspaceAgentExecWriter returns a writer that sends everything written to
+// it as frames of the given type, split so no frame exceeds the read limit.
+func WorkspaceAgentExecWriter(ctx context.Context, conn *websocket.Conn, frameType WorkspaceAgentExecFrameType) io.Writer {
+	return &execFrameWriter{ctx: ctx, conn: conn, frameType: frameType}
+}
+
+// @typescript-ignore execFrameWriter
+type execFrameWriter struct {
+	ctx       context.Context
+	conn      *websocket.Conn
+	frameType WorkspaceAgentExecFrameType
+}
+
+func (w *execFrameWriter) Write(p []byte) (int, error) {
+	written := 0
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > workspaceAgentExecChunkSize {
+			chunk = chunk[:workspaceAgentExecChunkSize]
+		}
+		err := wsjson.Write(w.ctx, w.conn, WorkspaceAgentExecFrame{
+			Type: w.frameType,
+			Data: chunk,
+		})
+		if err != nil {
+			return written, err
+		}
+		written += len(chunk)
+		p = p[len(chunk):]
+	}
+	return written, nil
+}
+
+// Exec runs a command in the workspace without a TTY. stdin is sent to the
+// command until it returns EOF, and may be nil. Output is written to stdout
+// and stderr as it arrives. A non-zero exit code is not an error.
+func (c *WorkspaceAgentConn) Exec(ctx context.Context, req WorkspaceAgentExecRequest, stdin io.Reader, stdout, stderr io.Writer) (WorkspaceAgentExecResult, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	if stdout == nil {
+		stdout = io.Discard
+	}
+	if stderr == nil {
+		stderr = io.Discard
+	}
+
+	host := net.JoinHostPort(WorkspaceAgentIP.String(), strconv.Itoa(WorkspaceAgentHTTPAPIServerPort))
+	//nolint:bodyclose
+	conn, _, err := websocket.Dial(ctx, fmt.Sprintf("ws://%s/api/v0/exec", host), &websocket.DialOptions{
+		HTTPClient: c.apiClient(),
+	})
+	if err != nil {
+		return WorkspaceAgentExecResult{}, xerrors.Errorf("dial exec: %w", err)
+	}
+	defer conn.Close(websocket.StatusNormalClosure, "")
+	conn.SetReadLimit(WorkspaceAgentExecReadLimit)
+
+	err = wsjson.Write(ctx, conn, req)
+	if err != nil {
+		return WorkspaceAgentExecResult{}, xerrors.Errorf("write request: %w", err)
+	}
+
+	// Canceling a write closes the connection without a close handshake,
+	// so stdin is sent with the context of the call.
+	go func() {
+		if stdin != nil {
+			// Errors are ignored, the command may exit before reading
+			// all of its input.
+			_, _ = io.Copy(WorkspaceAgentExecWriter(ctx, conn, WorkspaceAgentExecFrameStdin), stdin)
+		}
+		_ = wsjson.Write(ctx, conn, WorkspaceAgentExecFrame{
+			Type: WorkspaceAgentExecFrameStdinClose,
+		})
+	}()
+
+	for {
+		var frame WorkspaceAgentExecFrame
+		err = wsjson.Read(ctx, conn, &frame)
+		if err != nil {
+			return WorkspaceAgentExecResult{}, xerrors.Errorf("read frame: %w", err)
+		}
+		switch frame.Type {
+		case WorkspaceAgentExecFrameStdout:
+			_, err = stdout.Write(frame.Data)
+		case WorkspaceAgentExecFrameStderr:
+			_, err = stderr.Write(frame.Data)
+		case WorkspaceAgentExecFrameExit:
+			if frame.Error != "" {
+				return WorkspaceAgentExecResult{}, xerrors.New(frame.Error)
+			}
+			return WorkspaceAgentExecResult{
+				ExitCode: frame.ExitCode,
+				Duration: time.Duration(frame.DurationMillis) * time.Millisecond,
+				TimedOut: frame.TimedOut,
+			}, nil
+		}
+		if err != nil {
+			return WorkspaceAgentExecResult{}, xerrors.Errorf("write output: %w", err)
+		}
+	}
+}
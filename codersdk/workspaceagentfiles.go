@@ -0,0 +1,171 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/tracing"
+)
+
+// WorkspaceAgentFileInfo describes a file or directory in a workspace.
+type WorkspaceAgentFileInfo struct {
+	Name string `json:"name"`
+	// Path is the absolute path of the file.
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+	// Mode is the os.FileMode of the file, including permission bits.
+	Mode    uint32    `json:"mode"`
+	IsDir   bool      `json:"is_dir"`
+	ModTime time.Time `json:"mod_time" format:"date-time"`
+}
+
+type WorkspaceAgentListFilesResponse struct {
+	// Path is the absolute path of the listed directory.
+	Path  string                   `json:"path"`
+	Files []WorkspaceAgentFileInfo `json:"files"`
+}
+
+// StatFile returns information about a file or directory in the workspace.
+// Paths passed to the file methods are absolute, or relative to the home
+// directory of the user the agent runs as. A leading "~/" is also accepted.
+func (c *WorkspaceAgentConn) StatFile(ctx context.Context, path string) (WorkspaceAgentFileInfo, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/files/stat", nil, WithQueryParam("path", path))
+	if err != nil {
+		return WorkspaceAgentFileInfo{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentFileInfo{}, ReadBodyAsError(res)
+	}
+
+	var info WorkspaceAgentFileInfo
+	return info, json.NewDecoder(res.Body).Decode(&info)
+}
+
+// ListFiles lists the contents of a directory in the workspace, sorted by
+// name.
+func (c *WorkspaceAgentConn) ListFiles(ctx context.Context, path string) (WorkspaceAgentListFilesResponse, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/files/list", nil, WithQueryParam("path", path))
+	if err != nil {
+		return WorkspaceAgentListFilesResponse{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentListFilesResponse{}, ReadBodyAsError(res)
+	}
+
+	var resp WorkspaceAgentListFilesResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// ReadFile streams the contents of a file in the workspace, starting at
+// offset. A non-zero offset resumes an interrupted download. The caller must
+// close the returned reader.
+func (c *WorkspaceAgentConn) ReadFile(ctx context.Context, path string, offset int64) (io.ReadCloser, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/files/read", nil,
+		WithQueryParam("path", path),
+		func(r *http.Request) {
+			if offset > 0 {
+				r.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+			}
+		},
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("do request: %w", err)
+	}
+	switch {
+	case res.StatusCode == http.StatusOK && offset == 0,
+		res.StatusCode == http.StatusPartialContent && offset > 0:
+		return res.Body, nil
+	case res.StatusCode == http.StatusRequestedRangeNotSatisfiable:
+		_ = res.Body.Close()
+		return nil, xerrors.Errorf("offset %d is past the end of the file", offset)
+	case res.StatusCode == http.StatusOK:
+		_ = res.Body.Close()
+		return nil, xerrors.New("agent does not support resuming downloads")
+	default:
+		defer res.Body.Close()
+		return nil, ReadBodyAsError(res)
+	}
+}
+
+// WriteFile writes the contents of r to a file in the workspace, creating
+// it and its parent directories if they don't exist. Writing starts at
+// offset, and anything after the offset is discarded. A non-zero offset
+// resumes an interrupted upload and must not be larger than the file. mode
+// sets the permission bits of new files.
+func (c *WorkspaceAgentConn) WriteFile(ctx context.Context, path string, offset int64, mode os.FileMode, r io.Reader) (WorkspaceAgentFileInfo, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodPost, "/api/v0/files/write", r,
+		WithQueryParam("path", path),
+		WithQueryParam("offset", strconv.FormatInt(offset, 10)),
+		WithQueryParam("mode", strconv.FormatUint(uint64(mode.Perm()), 8)),
+		func(r *http.Request) {
+			r.Header.Set("Content-Type", "application/octet-stream")
+		},
+	)
+	if err != nil {
+		return WorkspaceAgentFileInfo{}, xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentFileInfo{}, ReadBodyAsError(res)
+	}
+
+	var info WorkspaceAgentFileInfo
+	return info, json.NewDecoder(res.Body).Decode(&info)
+}
+
+// DownloadDirectory streams a directory in the workspace as a tar archive.
+// Paths in the archive are relative to the directory. The caller must close
+// the returned reader.
+func (c *WorkspaceAgentConn) DownloadDirectory(ctx context.Context, path string) (io.ReadCloser, error) {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodGet, "/api/v0/files/download", nil, WithQueryParam("path", path))
+	if err != nil {
+		return nil, xerrors.Errorf("do request: %w", err)
+	}
+	if res.StatusCode != http.StatusOK {
+		defer res.Body.Close()
+		return nil, ReadBodyAsError(res)
+	}
+	return res.Body, nil
+}
+
+// UploadDirectory extracts the tar archive read from r into a directory in
+// the workspace, creating the directory if it doesn't exist. Existing files
+// are overwritten.
+func (c *WorkspaceAgentConn) UploadDirectory(ctx context.Context, path string, r io.Reader) error {
+	ctx, span := tracing.StartSpan(ctx)
+	defer span.End()
+	res, err := c.apiRequest(ctx, http.MethodPost, "/api/v0/files/upload", r,
+		WithQueryParam("path", path),
+		func(r *http.Request) {
+			r.Header.Set("Content-Type", "application/x-tar")
+		},
+	)
+	if err != nil {
+		return xerrors.Errorf("do request: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
@@ -106,6 +106,9 @@ type WorkspaceAgent struct {
 	StartupScriptTimeoutSeconds  int32  `json:"startup_script_timeout_seconds"`
 	ShutdownScript               string `json:"shutdown_script,omitempty"`
 	ShutdownScriptTimeoutSeconds int32  `json:"shutdown_script_timeout_seconds"`
+	// Netcheck is the latest netcheck the agent reported, describing the
+	// network of the workspace.
+	Netcheck *WorkspaceAgentNetcheck `json:"netcheck,omitempty"`
 }
 
 type DERPRegion struct {
@@ -113,6 +116,31 @@ type DERPRegion struct {
 	LatencyMilliseconds float64 `json:"latency_ms"`
 }
 
+// WorkspaceAgentNetcheck is the result of a netcheck run by the agent. It
+// shows why connections to the workspace can't be established directly.
+type WorkspaceAgentNetcheck struct {
+	// UDP is whether a UDP round trip to a STUN server completed.
+	UDP  bool `json:"udp"`
+	IPv4 bool `json:"ipv4"`
+	IPv6 bool `json:"ipv6"`
+	// GlobalV4 and GlobalV6 are the public addresses of the agent as seen
+	// by the STUN servers.
+	GlobalV4 string `json:"global_v4,omitempty"`
+	GlobalV6 string `json:"global_v6,omitempty"`
+	// MappingVariesByDestIP is whether the public port of the agent depends
+	// on the destination, which prevents direct connections unless the
+	// client is reachable. It's unset if unknown.
+	MappingVariesByDestIP *bool `json:"mapping_varies_by_dest_ip,omitempty"`
+	// NATType is "none", "easy", "hard", "udp_blocked" or "unknown".
+	NATType       string `json:"nat_type"`
+	PreferredDERP int    `json:"preferred_derp"`
+	// RegionLatencyMS is the latency to each reachable DERP region, keyed
+	// by region ID.
+	RegionLatencyMS map[int]float64 `json:"region_latency_ms"`
+	Error           string          `json:"error,omitempty"`
+	CheckedAt       time.Time       `json:"checked_at" format:"date-time"`
+}
+
 // WorkspaceAgentConnectionInfo returns required information for establishing
 // a connection with a workspace.
 // @typescript-ignore WorkspaceAgentConnectionInfo
@@ -177,10 +205,17 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 	if err != nil {
 		return nil, xerrors.Errorf("create cookie jar: %w", err)
 	}
-	jar.SetCookies(coordinateURL, []*http.Cookie{{
-		Name:  SessionTokenCookie,
-		Value: c.SessionToken(),
-	}})
+	headers := make(http.Header)
+	if c.SessionTokenHeader == "" {
+		jar.SetCookies(coordinateURL, []*http.Cookie{{
+			Name:  SessionTokenCookie,
+			Value: c.SessionToken(),
+		}})
+	} else {
+		// Custom token headers are used by clients that don't authenticate
+		// with an API key, such as external workspace proxies.
+		headers.Set(c.SessionTokenHeader, c.SessionToken())
+	}
 	httpClient := &http.Client{
 		Jar:       jar,
 		Transport: c.HTTPClient.Transport,
@@ -201,6 +236,7 @@ func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, opti
 			// nolint:bodyclose
 			ws, res, err := websocket.Dial(ctx, coordinateURL.String(), &websocket.DialOptions{
 				HTTPClient: httpClient,
+				HTTPHeader: headers,
 				// Need to disable compression to avoid a data-race.
 				CompressionMode: websocket.CompressionDisabled,
 			})
@@ -271,10 +307,110 @@ func (c *Client) WorkspaceAgent(ctx context.Context, id uuid.UUID) (WorkspaceAge
 	return workspaceAgent, json.NewDecoder(res.Body).Decode(&workspaceAgent)
 }
 
+// WorkspaceAgentMetadataResult is the result of running a single metadata
+// script inside a workspace agent.
+type WorkspaceAgentMetadataResult struct {
+	CollectedAt time.Time `json:"collected_at" format:"date-time"`
+	// Age is the number of seconds since the metadata was collected.
+	// It is provided in addition to CollectedAt to protect against clock skew.
+	Age   int64  `json:"age"`
+	Value string `json:"value"`
+	Error string `json:"error"`
+}
+
+// WorkspaceAgentMetadataDescription is a description of dynamic metadata the
+// agent should report back to coderd. It is provided via the `metadata`
+// block on the coder_agent resource.
+type WorkspaceAgentMetadataDescription struct {
+	DisplayName string `json:"display_name"`
+	Key         string `json:"key"`
+	Script      string `json:"script"`
+	// Interval is the number of seconds between script runs.
+	Interval int64 `json:"interval"`
+	// Timeout is the number of seconds a single script run may take.
+	Timeout int64 `json:"timeout"`
+}
+
+type WorkspaceAgentMetadata struct {
+	Result      WorkspaceAgentMetadataResult      `json:"result"`
+	Description WorkspaceAgentMetadataDescription `json:"description"`
+}
+
+// WatchWorkspaceAgentMetadata streams the latest metadata values of a
+// workspace agent. A full set of metadata is sent on every update. The
+// error channel receives at most one error and is closed when the stream
+// ends.
+func (c *Client) WatchWorkspaceAgentMetadata(ctx context.Context, id uuid.UUID) (<-chan []WorkspaceAgentMetadata, <-chan error) {
+	ctx, cancel := context.WithCancel(ctx)
+
+	metadataChan := make(chan []WorkspaceAgentMetadata, 256)
+
+	watch := func() error {
+		res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaceagents/%s/watch-metadata", id), nil)
+		if err != nil {
+			return err
+		}
+		if res.StatusCode != http.StatusOK {
+			return ReadBodyAsError(res)
+		}
+
+		nextEvent := ServerSentEventReader(ctx, res.Body)
+		defer res.Body.Close()
+
+		for {
+			sse, err := nextEvent()
+			if err != nil {
+				return err
+			}
+			if sse.Type == ServerSentEventTypePing {
+				continue
+			}
+
+			b, ok := sse.Data.([]byte)
+			if !ok {
+				return xerrors.Errorf("unexpected data type: %T", sse.Data)
+			}
+
+			switch sse.Type {
+			case ServerSentEventTypeData:
+				var met []WorkspaceAgentMetadata
+				err = json.Unmarshal(b, &met)
+				if err != nil {
+					return xerrors.Errorf("unmarshal metadata: %w", err)
+				}
+				select {
+				case metadataChan <- met:
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			case ServerSentEventTypeError:
+				var r Response
+				err = json.Unmarshal(b, &r)
+				if err != nil {
+					return xerrors.Errorf("unmarshal error: %w", err)
+				}
+				return xerrors.Errorf("%+v", r)
+			default:
+				return xerrors.Errorf("unexpected event type: %s", sse.Type)
+			}
+		}
+	}
+
+	errorChan := make(chan error, 1)
+	go func() {
+		defer close(errorChan)
+		defer close(metadataChan)
+		defer cancel()
+		errorChan <- watch()
+	}()
+
+	return metadataChan, errorChan
+}
+
 // WorkspaceAgentReconnectingPTY spawns a PTY that reconnects using the token provided.
 // It communicates using `agent.ReconnectingPTYRequest` marshaled as JSON.
 // Responses are PTY output that can be rendered.
-func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, reconnect uuid.UUID, height, width uint16, command string) (net.Conn, error) {
+func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, reconnect uuid.UUID, height, width uint16, command string, opts ...ReconnectingPTYOption) (net.Conn, error) {
 	serverURL, err := c.URL.Parse(fmt.Sprintf("/api/v2/workspaceagents/%s/pty", agentID))
 	if err != nil {
 		return nil, xerrors.Errorf("parse url: %w", err)
@@ -284,6 +420,13 @@ func (c *Client) WorkspaceAgentReconnectingPTY(ctx context.Context, agentID, rec
 	q.Set("height", strconv.Itoa(int(height)))
 	q.Set("width", strconv.Itoa(int(width)))
 	q.Set("command", command)
+	var init WorkspaceAgentReconnectingPTYInit
+	for _, opt := range opts {
+		opt(&init)
+	}
+	if init.Scrollback {
+		q.Set("scrollback", "true")
+	}
 	serverURL.RawQuery = q.Encode()
 
 	jar, err := cookiejar.New(nil)
@@ -415,3 +558,29 @@ type WorkspaceAgentStartupLog struct {
 	CreatedAt time.Time `json:"created_at" format:"date-time"`
 	Output    string    `json:"output"`
 }
+
+type IssueReconnectingPTYTicketRequest struct {
+	AgentID uuid.UUID `json:"agent_id" format:"uuid" validate:"required"`
+}
+
+type IssueReconnectingPTYTicketResponse struct {
+	// Ticket is a signed app ticket that can be passed to the reconnecting
+	// PTY endpoint of a workspace proxy in the "coder_app_ticket" query
+	// parameter.
+	Ticket string `json:"ticket"`
+}
+
+// IssueReconnectingPTYTicket issues a signed ticket that authorizes a
+// reconnecting PTY connection to the agent on a workspace proxy.
+func (c *Client) IssueReconnectingPTYTicket(ctx context.Context, req IssueReconnectingPTYTicketRequest) (IssueReconnectingPTYTicketResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost, "/api/v2/applications/reconnecting-pty-ticket", req)
+	if err != nil {
+		return IssueReconnectingPTYTicketResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return IssueReconnectingPTYTicketResponse{}, ReadBodyAsError(res)
+	}
+	var resp IssueReconnectingPTYTicketResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
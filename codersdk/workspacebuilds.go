@@ -46,6 +46,18 @@ const (
 	// "autostop" is used when a build to stop a workspace is triggered by Autostop.
 	// The initiator id/username in this case is the workspace owner and can be ignored.
 	BuildReasonAutostop BuildReason = "autostop"
+	// "autolock" is used when a build to stop a workspace is triggered
+	// because the workspace was locked due to inactivity.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonAutolock BuildReason = "autolock"
+	// "autodelete" is used when a build to delete a workspace is triggered
+	// because the workspace has been locked for longer than the template allows.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonAutodelete BuildReason = "autodelete"
+	// "failedstop" is used when a build to stop a workspace is triggered
+	// because its latest start build failed longer ago than the template allows.
+	// The initiator id/username in this case is the workspace owner and can be ignored.
+	BuildReasonFailedStop BuildReason = "failedstop"
 )
 
 // WorkspaceBuild is an at-point representation of a workspace state.
@@ -65,7 +77,7 @@ type WorkspaceBuild struct {
 	InitiatorID         uuid.UUID           `json:"initiator_id" format:"uuid"`
 	InitiatorUsername   string              `json:"initiator_name"`
 	Job                 ProvisionerJob      `json:"job"`
-	Reason              BuildReason         `db:"reason" json:"reason" enums:"initiator,autostart,autostop"`
+	Reason              BuildReason         `db:"reason" json:"reason" enums:"initiator,autostart,autostop,autolock,autodelete,failedstop"`
 	Resources           []WorkspaceResource `json:"resources"`
 	Deadline            NullTime            `json:"deadline,omitempty" format:"date-time"`
 	MaxDeadline         NullTime            `json:"max_deadline,omitempty" format:"date-time"`
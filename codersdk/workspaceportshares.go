@@ -0,0 +1,84 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+// WorkspaceAgentPortShare shares a listening port of a workspace agent with
+// other users. Shared ports are accessed with subdomain-based port URLs.
+type WorkspaceAgentPortShare struct {
+	WorkspaceID uuid.UUID `json:"workspace_id" format:"uuid"`
+	// AgentName identifies the agent, so the share persists across
+	// workspace builds.
+	AgentName  string                   `json:"agent_name"`
+	Port       int32                    `json:"port"`
+	ShareLevel WorkspaceAppSharingLevel `json:"share_level" enums:"owner,authenticated,public"`
+	CreatedAt  time.Time                `json:"created_at" format:"date-time"`
+	UpdatedAt  time.Time                `json:"updated_at" format:"date-time"`
+}
+
+type WorkspaceAgentPortSharesResponse struct {
+	Shares []WorkspaceAgentPortShare `json:"shares"`
+}
+
+type UpsertWorkspaceAgentPortShareRequest struct {
+	AgentName string `json:"agent_name" validate:"required"`
+	Port      int32  `json:"port" validate:"required"`
+	// ShareLevel can't be more permissive than the max port share level of
+	// the template.
+	ShareLevel WorkspaceAppSharingLevel `json:"share_level" validate:"required" enums:"owner,authenticated,public"`
+}
+
+type DeleteWorkspaceAgentPortShareRequest struct {
+	AgentName string `json:"agent_name" validate:"required"`
+	Port      int32  `json:"port" validate:"required"`
+}
+
+// WorkspaceAgentPortShares returns the shared ports of a workspace.
+func (c *Client) WorkspaceAgentPortShares(ctx context.Context, workspaceID uuid.UUID) (WorkspaceAgentPortSharesResponse, error) {
+	res, err := c.Request(ctx, http.MethodGet, fmt.Sprintf("/api/v2/workspaces/%s/port-shares", workspaceID), nil)
+	if err != nil {
+		return WorkspaceAgentPortSharesResponse{}, err
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortSharesResponse{}, ReadBodyAsError(res)
+	}
+	var resp WorkspaceAgentPortSharesResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// UpsertWorkspaceAgentPortShare shares a port of a workspace agent, or
+// changes the level it's shared at.
+func (c *Client) UpsertWorkspaceAgentPortShare(ctx context.Context, workspaceID uuid.UUID, req UpsertWorkspaceAgentPortShareRequest) (WorkspaceAgentPortShare, error) {
+	res, err := c.Request(ctx, http.MethodPost, fmt.Sprintf("/api/v2/workspaces/%s/port-shares", workspaceID), req)
+	if err != nil {
+		return WorkspaceAgentPortShare{}, xerrors.Errorf("share port: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceAgentPortShare{}, ReadBodyAsError(res)
+	}
+	var share WorkspaceAgentPortShare
+	return share, json.NewDecoder(res.Body).Decode(&share)
+}
+
+// DeleteWorkspaceAgentPortShare stops sharing a port of a workspace agent.
+func (c *Client) DeleteWorkspaceAgentPortShare(ctx context.Context, workspaceID uuid.UUID, req DeleteWorkspaceAgentPortShareRequest) error {
+	res, err := c.Request(ctx, http.MethodDelete, fmt.Sprintf("/api/v2/workspaces/%s/port-shares", workspaceID), req)
+	if err != nil {
+		return xerrors.Errorf("unshare port: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusNoContent {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
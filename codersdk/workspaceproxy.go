@@ -0,0 +1,272 @@
+package codersdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+)
+
+type ProxyHealthStatus string
+
+const (
+	// ProxyReachable means the proxy access url is reachable and returns a
+	// healthy status code.
+	ProxyReachable ProxyHealthStatus = "reachable"
+	// ProxyUnreachable means the proxy access url is not responding.
+	ProxyUnreachable ProxyHealthStatus = "unreachable"
+	// ProxyUnhealthy means the proxy access url is responding, but there is
+	// some problem with the proxy. This problem may or may not be preventing
+	// functionality.
+	ProxyUnhealthy ProxyHealthStatus = "unhealthy"
+	// ProxyUnregistered means the proxy has not registered a url yet. This
+	// means the proxy was created with the cli, but has not yet been started.
+	ProxyUnregistered ProxyHealthStatus = "unregistered"
+)
+
+type WorkspaceProxyStatus struct {
+	Status ProxyHealthStatus `json:"status" table:"status,default_sort"`
+	// Error is the reason the proxy is not reachable or healthy, if any.
+	Error     string    `json:"error,omitempty" table:"error"`
+	CheckedAt time.Time `json:"checked_at" table:"checked_at" format:"date-time"`
+}
+
+type WorkspaceProxy struct {
+	ID          uuid.UUID `json:"id" format:"uuid" table:"id"`
+	Name        string    `json:"name" table:"name,default_sort"`
+	DisplayName string    `json:"display_name" table:"display_name"`
+	Icon        string    `json:"icon" table:"icon"`
+	// URL is the access URL of the proxy. It is empty until the proxy has
+	// registered with the primary deployment.
+	URL string `json:"url" table:"url"`
+	// WildcardHostname is the wildcard hostname for subdomain apps on the
+	// proxy, e.g. "*.us.example.com".
+	WildcardHostname string               `json:"wildcard_hostname" table:"wildcard_hostname"`
+	CreatedAt        time.Time            `json:"created_at" format:"date-time" table:"created_at"`
+	UpdatedAt        time.Time            `json:"updated_at" format:"date-time" table:"updated_at"`
+	Deleted          bool                 `json:"deleted" table:"deleted"`
+	Status           WorkspaceProxyStatus `json:"status,omitempty" table:"status,recursive"`
+}
+
+type CreateWorkspaceProxyRequest struct {
+	Name        string `json:"name" validate:"required,username"`
+	DisplayName string `json:"display_name"`
+	Icon        string `json:"icon"`
+}
+
+type CreateWorkspaceProxyResponse struct {
+	Proxy WorkspaceProxy `json:"proxy" table:"proxy,recursive"`
+	// ProxyToken is the token the proxy uses to authenticate with the primary
+	// deployment. It is only returned once.
+	ProxyToken string `json:"proxy_token" table:"proxy token"`
+}
+
+func (c *Client) CreateWorkspaceProxy(ctx context.Context, req CreateWorkspaceProxyRequest) (CreateWorkspaceProxyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost,
+		"/api/v2/workspaceproxies",
+		req,
+	)
+	if err != nil {
+		return CreateWorkspaceProxyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return CreateWorkspaceProxyResponse{}, ReadBodyAsError(res)
+	}
+	var resp CreateWorkspaceProxyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+func (c *Client) WorkspaceProxies(ctx context.Context) ([]WorkspaceProxy, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		"/api/v2/workspaceproxies",
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+
+	var proxies []WorkspaceProxy
+	return proxies, json.NewDecoder(res.Body).Decode(&proxies)
+}
+
+// WorkspaceProxyByName returns the workspace proxy with the given name or ID.
+func (c *Client) WorkspaceProxyByName(ctx context.Context, name string) (WorkspaceProxy, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		fmt.Sprintf("/api/v2/workspaceproxies/%s", name),
+		nil,
+	)
+	if err != nil {
+		return WorkspaceProxy{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return WorkspaceProxy{}, ReadBodyAsError(res)
+	}
+	var resp WorkspaceProxy
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+// DeleteWorkspaceProxyByName deletes the workspace proxy with the given name
+// or ID.
+func (c *Client) DeleteWorkspaceProxyByName(ctx context.Context, name string) error {
+	res, err := c.Request(ctx, http.MethodDelete,
+		fmt.Sprintf("/api/v2/workspaceproxies/%s", name),
+		nil,
+	)
+	if err != nil {
+		return xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+
+	return nil
+}
+
+// Region is a location that serves workspace apps and terminals. The primary
+// deployment is always a region. Each workspace proxy is an additional region.
+type Region struct {
+	ID          uuid.UUID `json:"id" format:"uuid"`
+	Name        string    `json:"name"`
+	DisplayName string    `json:"display_name"`
+	IconURL     string    `json:"icon_url"`
+	Healthy     bool      `json:"healthy"`
+
+	// PathAppURL is the URL to the base path for path apps. Optional
+	// unless wildcard_hostname is set.
+	// E.g. https://us.example.com
+	PathAppURL string `json:"path_app_url"`
+
+	// WildcardHostname is the wildcard hostname for subdomain apps.
+	// E.g. *.us.example.com
+	// E.g. *--suffix.au.example.com
+	// Optional. Does not need to be on the same domain as PathAppURL.
+	WildcardHostname string `json:"wildcard_hostname"`
+}
+
+type RegionsResponse struct {
+	Regions []Region `json:"regions"`
+}
+
+func (c *Client) Regions(ctx context.Context) ([]Region, error) {
+	res, err := c.Request(ctx, http.MethodGet,
+		"/api/v2/regions",
+		nil,
+	)
+	if err != nil {
+		return nil, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, ReadBodyAsError(res)
+	}
+
+	var regions RegionsResponse
+	return regions.Regions, json.NewDecoder(res.Body).Decode(&regions)
+}
+
+// RegionLatency measures the round trip time of a request to the
+// latency check endpoint of the region.
+func (c *Client) RegionLatency(ctx context.Context, region Region) (time.Duration, error) {
+	if region.PathAppURL == "" {
+		return 0, xerrors.Errorf("region %q has no URL", region.Name)
+	}
+	u, err := url.Parse(region.PathAppURL)
+	if err != nil {
+		return 0, xerrors.Errorf("parse region URL %q: %w", region.PathAppURL, err)
+	}
+	u = u.JoinPath("/latency-check")
+
+	check := func() error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err != nil {
+			return xerrors.Errorf("create request: %w", err)
+		}
+		res, err := c.HTTPClient.Do(req)
+		if err != nil {
+			return xerrors.Errorf("do request: %w", err)
+		}
+		_ = res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return xerrors.Errorf("unexpected status code %d", res.StatusCode)
+		}
+		return nil
+	}
+
+	// Do one request first so the connection is established, as we only
+	// care about the round trip time of the request itself.
+	err = check()
+	if err != nil {
+		return 0, err
+	}
+	start := time.Now()
+	err = check()
+	if err != nil {
+		return 0, err
+	}
+	return time.Since(start), nil
+}
+
+// PreferredRegion returns the healthy region with the lowest latency. Regions
+// that fail the latency check are skipped. If no region can be reached, an
+// error is returned.
+func (c *Client) PreferredRegion(ctx context.Context) (Region, error) {
+	regions, err := c.Regions(ctx)
+	if err != nil {
+		return Region{}, xerrors.Errorf("get regions: %w", err)
+	}
+
+	type regionLatency struct {
+		region  Region
+		latency time.Duration
+	}
+	var (
+		mu        sync.Mutex
+		wg        sync.WaitGroup
+		latencies = make([]regionLatency, 0, len(regions))
+	)
+	for _, region := range regions {
+		if !region.Healthy {
+			continue
+		}
+		region := region
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			latency, err := c.RegionLatency(ctx, region)
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			latencies = append(latencies, regionLatency{region: region, latency: latency})
+		}()
+	}
+	wg.Wait()
+
+	if len(latencies) == 0 {
+		return Region{}, xerrors.New("no healthy regions could be reached")
+	}
+	sort.Slice(latencies, func(i, j int) bool {
+		return latencies[i].latency < latencies[j].latency
+	})
+	return latencies[0].region, nil
+}
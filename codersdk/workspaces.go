@@ -34,6 +34,10 @@ type Workspace struct {
 	AutostartSchedule                    *string        `json:"autostart_schedule,omitempty"`
 	TTLMillis                            *int64         `json:"ttl_ms,omitempty"`
 	LastUsedAt                           time.Time      `json:"last_used_at" format:"date-time"`
+	// LockedAt being non-nil indicates a workspace that has been locked due
+	// to inactivity. A locked workspace cannot be started until it is
+	// unlocked.
+	LockedAt *time.Time `json:"locked_at,omitempty" format:"date-time"`
 }
 
 type WorkspacesRequest struct {
@@ -244,6 +248,26 @@ func (c *Client) UpdateWorkspaceTTL(ctx context.Context, id uuid.UUID, req Updat
 	return nil
 }
 
+// UpdateWorkspaceLock is a request to lock or unlock a workspace.
+type UpdateWorkspaceLock struct {
+	Lock bool `json:"lock"`
+}
+
+// UpdateWorkspaceLock locks or unlocks the workspace by id. Unlocking a
+// workspace also marks it as used so it is not immediately locked again.
+func (c *Client) UpdateWorkspaceLock(ctx context.Context, id uuid.UUID, req UpdateWorkspaceLock) error {
+	path := fmt.Sprintf("/api/v2/workspaces/%s/lock", id.String())
+	res, err := c.Request(ctx, http.MethodPut, path, req)
+	if err != nil {
+		return xerrors.Errorf("update workspace lock: %w", err)
+	}
+	defer res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return ReadBodyAsError(res)
+	}
+	return nil
+}
+
 // PutExtendWorkspaceRequest is a request to extend the deadline of
 // the active workspace build.
 type PutExtendWorkspaceRequest struct {
@@ -0,0 +1,61 @@
+package backends
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/coder/coder/coderd/database"
+)
+
+// exportedAuditLog is the JSON representation of an audit log sent to
+// external systems. database.AuditLog isn't used directly as its nullable
+// columns don't marshal to anything a consumer would expect.
+type exportedAuditLog struct {
+	ID               uuid.UUID       `json:"id"`
+	Time             time.Time       `json:"time"`
+	UserID           uuid.UUID       `json:"user_id"`
+	OrganizationID   uuid.UUID       `json:"organization_id"`
+	IP               string          `json:"ip"`
+	UserAgent        string          `json:"user_agent"`
+	ResourceType     string          `json:"resource_type"`
+	ResourceID       uuid.UUID       `json:"resource_id"`
+	ResourceTarget   string          `json:"resource_target"`
+	ResourceIcon     string          `json:"resource_icon"`
+	Action           string          `json:"action"`
+	Diff             json.RawMessage `json:"diff"`
+	StatusCode       int32           `json:"status_code"`
+	AdditionalFields json.RawMessage `json:"additional_fields"`
+	RequestID        uuid.UUID       `json:"request_id"`
+}
+
+func newExportedAuditLog(alog database.AuditLog) exportedAuditLog {
+	e := exportedAuditLog{
+		ID:               alog.ID,
+		Time:             alog.Time,
+		UserID:           alog.UserID,
+		OrganizationID:   alog.OrganizationID,
+		UserAgent:        alog.UserAgent.String,
+		ResourceType:     string(alog.ResourceType),
+		ResourceID:       alog.ResourceID,
+		ResourceTarget:   alog.ResourceTarget,
+		ResourceIcon:     alog.ResourceIcon,
+		Action:           string(alog.Action),
+		Diff:             alog.Diff,
+		StatusCode:       alog.StatusCode,
+		AdditionalFields: alog.AdditionalFields,
+		RequestID:        alog.RequestID,
+	}
+	if alog.Ip.Valid {
+		e.IP = alog.Ip.IPNet.IP.String()
+	}
+	// An empty RawMessage is invalid JSON.
+	if len(e.Diff) == 0 {
+		e.Diff = json.RawMessage("{}")
+	}
+	if len(e.AdditionalFields) == 0 {
+		e.AdditionalFields = json.RawMessage("{}")
+	}
+	return e
+}
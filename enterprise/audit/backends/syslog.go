@@ -0,0 +1,270 @@
+package backends
+
+import (
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+)
+
+// syslogFacilities maps facility names to their RFC 5424 codes.
+var syslogFacilities = map[string]int{
+	"kern":     0,
+	"user":     1,
+	"mail":     2,
+	"daemon":   3,
+	"auth":     4,
+	"syslog":   5,
+	"lpr":      6,
+	"news":     7,
+	"uucp":     8,
+	"cron":     9,
+	"authpriv": 10,
+	"ftp":      11,
+	"local0":   16,
+	"local1":   17,
+	"local2":   18,
+	"local3":   19,
+	"local4":   20,
+	"local5":   21,
+	"local6":   22,
+	"local7":   23,
+}
+
+const (
+	// syslogSeverityInfo is the severity of every audit log message.
+	syslogSeverityInfo = 6
+	// syslogTimeFormat is RFC 3339 limited to microseconds, as RFC 5424
+	// requires.
+	syslogTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// SyslogOptions configures the syslog audit backend.
+type SyslogOptions struct {
+	Logger slog.Logger
+	// Address is the host:port of the syslog server.
+	Address string
+	// TLSConfig enables TLS when set, as described in RFC 5425.
+	TLSConfig *tls.Config
+	// Facility is the name of the syslog facility, e.g. "local0".
+	Facility string
+	// AppName identifies the sender. Defaults to "coder".
+	AppName string
+	// Hostname identifies the machine sending messages. Defaults to
+	// os.Hostname.
+	Hostname    string
+	DialTimeout time.Duration
+	// MaxQueueSize bounds the number of audit logs waiting to be written.
+	// Audit logs are dropped once the queue is full.
+	MaxQueueSize int
+}
+
+// SyslogBackend sends audit logs to a syslog server.
+type SyslogBackend struct {
+	opts     SyslogOptions
+	priority int
+
+	mu       sync.Mutex
+	queue    chan string
+	isClosed bool
+
+	// conn is only used by the writer goroutine.
+	conn   net.Conn
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+var _ audit.Backend = (*SyslogBackend)(nil)
+
+// NewSyslog starts a backend that writes RFC 5424 messages to a syslog server
+// over TCP, using octet-counting framing. Messages are written in the
+// background, so a slow or unreachable server doesn't delay requests. The
+// connection is opened lazily and re-established after a write fails. Close
+// must be called to flush pending audit logs.
+func NewSyslog(opts SyslogOptions) (*SyslogBackend, error) {
+	if opts.Address == "" {
+		return nil, xerrors.New("syslog address is required")
+	}
+	if opts.Facility == "" {
+		opts.Facility = "local0"
+	}
+	facility, ok := syslogFacilities[strings.ToLower(opts.Facility)]
+	if !ok {
+		return nil, xerrors.Errorf("unknown syslog facility %q", opts.Facility)
+	}
+	if opts.AppName == "" {
+		opts.AppName = "coder"
+	}
+	if opts.Hostname == "" {
+		hostname, err := os.Hostname()
+		if err != nil {
+			hostname = "-"
+		}
+		opts.Hostname = hostname
+	}
+	if opts.DialTimeout <= 0 {
+		opts.DialTimeout = 10 * time.Second
+	}
+	if opts.MaxQueueSize <= 0 {
+		opts.MaxQueueSize = 10000
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &SyslogBackend{
+		opts:     opts,
+		priority: facility*8 + syslogSeverityInfo,
+		queue:    make(chan string, opts.MaxQueueSize),
+		ctx:      ctx,
+		cancel:   cancel,
+		closed:   make(chan struct{}),
+	}
+	go b.run()
+	return b, nil
+}
+
+func (*SyslogBackend) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+// Export queues the audit log to be written. It doesn't wait for the audit
+// log to be sent, and drops it if the queue is full.
+func (b *SyslogBackend) Export(ctx context.Context, alog database.AuditLog) error {
+	msg, err := b.format(alog)
+	if err != nil {
+		return xerrors.Errorf("format audit log: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.isClosed {
+		return xerrors.New("syslog backend is closed")
+	}
+	select {
+	case b.queue <- msg:
+	default:
+		b.opts.Logger.Warn(ctx, "syslog queue is full, dropping audit log",
+			slog.F("audit_log_id", alog.ID),
+			slog.F("queue_size", b.opts.MaxQueueSize),
+		)
+	}
+	return nil
+}
+
+// Close stops accepting audit logs and waits for queued ones to be written.
+// Writing is aborted if the server doesn't respond within the dial timeout.
+func (b *SyslogBackend) Close() error {
+	b.mu.Lock()
+	if !b.isClosed {
+		b.isClosed = true
+		close(b.queue)
+	}
+	b.mu.Unlock()
+
+	select {
+	case <-b.closed:
+	case <-time.After(b.opts.DialTimeout):
+		b.cancel()
+		<-b.closed
+	}
+	b.cancel()
+	return nil
+}
+
+func (b *SyslogBackend) run() {
+	defer close(b.closed)
+	defer func() {
+		if b.conn != nil {
+			_ = b.conn.Close()
+		}
+	}()
+
+	for msg := range b.queue {
+		if b.ctx.Err() != nil {
+			// Closing timed out, drop what's left.
+			continue
+		}
+		err := b.write(msg)
+		if err != nil {
+			b.opts.Logger.Warn(b.ctx, "write audit log to syslog", slog.Error(err))
+		}
+	}
+}
+
+// write sends a message, retrying once on a fresh connection as the server
+// may have closed an idle one.
+func (b *SyslogBackend) write(msg string) error {
+	var err error
+	for i := 0; i < 2; i++ {
+		if b.conn == nil {
+			b.conn, err = b.dial(b.ctx)
+			if err != nil {
+				return xerrors.Errorf("dial syslog: %w", err)
+			}
+		}
+		_ = b.conn.SetWriteDeadline(time.Now().Add(b.opts.DialTimeout))
+		_, err = fmt.Fprintf(b.conn, "%d %s", len(msg), msg)
+		if err == nil {
+			return nil
+		}
+		_ = b.conn.Close()
+		b.conn = nil
+	}
+	return xerrors.Errorf("write syslog: %w", err)
+}
+
+func (b *SyslogBackend) dial(ctx context.Context) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: b.opts.DialTimeout}
+	if b.opts.TLSConfig != nil {
+		tlsDialer := &tls.Dialer{NetDialer: dialer, Config: b.opts.TLSConfig}
+		return tlsDialer.DialContext(ctx, "tcp", b.opts.Address)
+	}
+	return dialer.DialContext(ctx, "tcp", b.opts.Address)
+}
+
+// format returns the RFC 5424 message for an audit log. The audit log is sent
+// as JSON in the message body.
+func (b *SyslogBackend) format(alog database.AuditLog) (string, error) {
+	data, err := json.Marshal(newExportedAuditLog(alog))
+	if err != nil {
+		return "", err
+	}
+	// <PRI>VERSION TIMESTAMP HOSTNAME APP-NAME PROCID MSGID STRUCTURED-DATA MSG
+	return fmt.Sprintf("<%d>1 %s %s %s %d audit - %s",
+		b.priority,
+		alog.Time.UTC().Format(syslogTimeFormat),
+		syslogHeaderValue(b.opts.Hostname, 255),
+		syslogHeaderValue(b.opts.AppName, 48),
+		os.Getpid(),
+		data,
+	), nil
+}
+
+// syslogHeaderValue makes a value safe for use as a header field, which must
+// be printable ASCII without spaces.
+func syslogHeaderValue(s string, maxLen int) string {
+	s = strings.Map(func(r rune) rune {
+		if r <= ' ' || r > '~' {
+			return -1
+		}
+		return r
+	}, s)
+	if len(s) > maxLen {
+		s = s[:maxLen]
+	}
+	if s == "" {
+		return "-"
+	}
+	return s
+}
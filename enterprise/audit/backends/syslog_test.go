@@ -0,0 +1,160 @@
+package backends_test
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"encoding/json"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+func TestSyslogBackend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("OK", func(t *testing.T) {
+		t.Parallel()
+
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = ln.Close()
+		})
+		messages := make(chan string, 10)
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				go readSyslogFrames(conn, messages)
+			}
+		}()
+
+		backend, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:  ln.Addr().String(),
+			Facility: "local3",
+			Hostname: "coder host",
+		})
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = backend.Close()
+		})
+
+		var (
+			ctx, cancel = context.WithCancel(context.Background())
+			alog        = audittest.RandomLog()
+		)
+		defer cancel()
+
+		err = backend.Export(ctx, alog)
+		require.NoError(t, err)
+
+		msg := <-messages
+		// local3 (19) * 8 + info (6) = 158
+		require.True(t, strings.HasPrefix(msg, "<158>1 "), msg)
+		fields := strings.SplitN(msg, " ", 8)
+		require.Len(t, fields, 8)
+		// Spaces aren't allowed in header fields.
+		require.Equal(t, "coderhost", fields[2])
+		require.Equal(t, "coder", fields[3])
+		require.Equal(t, "audit", fields[5])
+		require.Equal(t, "-", fields[6])
+
+		var body map[string]any
+		err = json.Unmarshal([]byte(fields[7]), &body)
+		require.NoError(t, err)
+		require.Equal(t, alog.ID.String(), body["id"])
+		require.Equal(t, string(alog.ResourceType), body["resource_type"])
+	})
+
+	t.Run("Unresponsive", func(t *testing.T) {
+		t.Parallel()
+
+		// The server accepts connections but never completes the TLS
+		// handshake, so every write blocks until the dial timeout.
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		t.Cleanup(func() {
+			_ = ln.Close()
+		})
+		go func() {
+			for {
+				conn, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				t.Cleanup(func() {
+					_ = conn.Close()
+				})
+			}
+		}()
+
+		backend, err := backends.NewSyslog(backends.SyslogOptions{
+			Logger:       slogtest.Make(t, nil),
+			Address:      ln.Addr().String(),
+			TLSConfig:    &tls.Config{MinVersion: tls.VersionTLS12},
+			DialTimeout:  testutil.WaitShort,
+			MaxQueueSize: 2,
+		})
+		require.NoError(t, err)
+
+		ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+		defer cancel()
+
+		// Exports return immediately, and are dropped once the queue is
+		// full.
+		start := time.Now()
+		for i := 0; i < 10; i++ {
+			err = backend.Export(ctx, audittest.RandomLog())
+			require.NoError(t, err)
+		}
+		require.Less(t, time.Since(start), testutil.WaitShort)
+
+		require.NoError(t, backend.Close())
+		require.Error(t, backend.Export(ctx, audittest.RandomLog()))
+	})
+
+	t.Run("UnknownFacility", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := backends.NewSyslog(backends.SyslogOptions{
+			Address:  "127.0.0.1:514",
+			Facility: "nope",
+		})
+		require.Error(t, err)
+	})
+}
+
+// readSyslogFrames reads octet-counted frames from the connection.
+func readSyslogFrames(conn net.Conn, messages chan<- string) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	for {
+		length, err := r.ReadString(' ')
+		if err != nil {
+			return
+		}
+		n, err := strconv.Atoi(strings.TrimSpace(length))
+		if err != nil {
+			return
+		}
+		buf := make([]byte, n)
+		_, err = io.ReadFull(r, buf)
+		if err != nil {
+			return
+		}
+		messages <- string(buf)
+	}
+}
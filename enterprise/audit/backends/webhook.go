@@ -0,0 +1,327 @@
+package backends
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/retry"
+)
+
+const (
+	// WebhookSignatureHeader contains the hex-encoded HMAC-SHA256 of the
+	// request body, keyed with the webhook secret and prefixed with "sha256=".
+	WebhookSignatureHeader = "X-Coder-Signature"
+
+	webhookQueueFile = "queue.ndjson"
+)
+
+// WebhookOptions configures the webhook audit backend.
+type WebhookOptions struct {
+	Logger slog.Logger
+	URL    *url.URL
+	// Secret signs each request body. Requests are unsigned when empty.
+	Secret []byte
+	// BatchSize is the maximum number of audit logs sent in a single request.
+	BatchSize int
+	// FlushInterval is how often queued audit logs are sent when a batch
+	// hasn't filled up.
+	FlushInterval time.Duration
+	// MaxQueueSize bounds the number of audit logs waiting to be delivered.
+	// Exports fail once the queue is full.
+	MaxQueueSize int
+	// QueueDir persists undelivered audit logs so they survive a restart.
+	// The queue is only kept in memory when empty.
+	QueueDir string
+	// MaxAttempts is the number of times a batch is sent before it's left in
+	// the queue until the next flush.
+	MaxAttempts int
+	HTTPClient  *http.Client
+}
+
+// WebhookPayload is the JSON body of requests sent by the webhook backend.
+type WebhookPayload struct {
+	AuditLogs []json.RawMessage `json:"audit_logs"`
+}
+
+// WebhookBackend sends batches of audit logs to an HTTP endpoint.
+type WebhookBackend struct {
+	opts WebhookOptions
+
+	mu    sync.Mutex
+	queue []json.RawMessage
+	file  *os.File
+
+	flush  chan struct{}
+	ctx    context.Context
+	cancel context.CancelFunc
+	closed chan struct{}
+}
+
+var _ audit.Backend = (*WebhookBackend)(nil)
+
+// NewWebhook starts a webhook backend that delivers audit logs in the
+// background. Close must be called to flush pending audit logs.
+func NewWebhook(opts WebhookOptions) (*WebhookBackend, error) {
+	if opts.URL == nil {
+		return nil, xerrors.New("webhook url is required")
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 100
+	}
+	if opts.FlushInterval <= 0 {
+		opts.FlushInterval = 5 * time.Second
+	}
+	if opts.MaxQueueSize <= 0 {
+		opts.MaxQueueSize = 10000
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.HTTPClient == nil {
+		opts.HTTPClient = &http.Client{Timeout: 30 * time.Second}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	b := &WebhookBackend{
+		opts:   opts,
+		flush:  make(chan struct{}, 1),
+		ctx:    ctx,
+		cancel: cancel,
+		closed: make(chan struct{}),
+	}
+	if opts.QueueDir != "" {
+		err := b.loadQueue()
+		if err != nil {
+			cancel()
+			return nil, xerrors.Errorf("load queue: %w", err)
+		}
+	}
+	go b.run()
+	return b, nil
+}
+
+func (*WebhookBackend) Decision() audit.FilterDecision {
+	return audit.FilterDecisionExport
+}
+
+// Export queues the audit log for delivery. It doesn't wait for the audit log
+// to be sent.
+func (b *WebhookBackend) Export(_ context.Context, alog database.AuditLog) error {
+	data, err := json.Marshal(newExportedAuditLog(alog))
+	if err != nil {
+		return xerrors.Errorf("marshal audit log: %w", err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.queue) >= b.opts.MaxQueueSize {
+		return xerrors.Errorf("webhook queue is full with %d audit logs", len(b.queue))
+	}
+	if b.file != nil {
+		_, err = b.file.Write(append(data, '\n'))
+		if err != nil {
+			return xerrors.Errorf("write queue: %w", err)
+		}
+	}
+	b.queue = append(b.queue, data)
+	if len(b.queue) >= b.opts.BatchSize {
+		select {
+		case b.flush <- struct{}{}:
+		default:
+		}
+	}
+	return nil
+}
+
+// Close stops the backend after attempting to deliver queued audit logs.
+func (b *WebhookBackend) Close() error {
+	b.cancel()
+	<-b.closed
+
+	// Give queued audit logs one last chance to be delivered.
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	b.sendAll(ctx)
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.file != nil {
+		return b.file.Close()
+	}
+	return nil
+}
+
+func (b *WebhookBackend) run() {
+	defer close(b.closed)
+
+	ticker := time.NewTicker(b.opts.FlushInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-b.ctx.Done():
+			return
+		case <-ticker.C:
+		case <-b.flush:
+		}
+		b.sendAll(b.ctx)
+	}
+}
+
+// sendAll keeps sending while full batches are available.
+func (b *WebhookBackend) sendAll(ctx context.Context) {
+	for {
+		if !b.send(ctx) {
+			return
+		}
+	}
+}
+
+// send delivers the oldest batch of queued audit logs. It returns true if a
+// full batch was delivered and more audit logs may be waiting.
+func (b *WebhookBackend) send(ctx context.Context) bool {
+	b.mu.Lock()
+	n := len(b.queue)
+	if n > b.opts.BatchSize {
+		n = b.opts.BatchSize
+	}
+	batch := b.queue[:n:n]
+	b.mu.Unlock()
+	if n == 0 {
+		return false
+	}
+
+	body, err := json.Marshal(WebhookPayload{AuditLogs: batch})
+	if err != nil {
+		b.opts.Logger.Error(ctx, "marshal audit log batch", slog.Error(err))
+		return false
+	}
+
+	delivered := false
+	attempt := 0
+	for r := retry.New(time.Second, 30*time.Second); r.Wait(ctx); {
+		attempt++
+		err = b.post(ctx, body)
+		if err == nil {
+			delivered = true
+			break
+		}
+		b.opts.Logger.Warn(ctx, "send audit logs to webhook",
+			slog.F("attempt", attempt),
+			slog.F("count", n),
+			slog.Error(err),
+		)
+		if attempt >= b.opts.MaxAttempts {
+			break
+		}
+	}
+	if !delivered {
+		return false
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.queue = b.queue[n:]
+	if b.file != nil {
+		err = b.rewriteQueue()
+		if err != nil {
+			b.opts.Logger.Error(ctx, "rewrite audit log webhook queue", slog.Error(err))
+		}
+	}
+	return n == b.opts.BatchSize
+}
+
+func (b *WebhookBackend) post(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, b.opts.URL.String(), bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(b.opts.Secret) > 0 {
+		req.Header.Set(WebhookSignatureHeader, "sha256="+SignWebhookPayload(b.opts.Secret, body))
+	}
+	res, err := b.opts.HTTPClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, res.Body)
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return xerrors.Errorf("unexpected status code %d", res.StatusCode)
+	}
+	return nil
+}
+
+// SignWebhookPayload returns the hex-encoded HMAC-SHA256 of the body.
+func SignWebhookPayload(secret, body []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	_, _ = mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// loadQueue reads audit logs left undelivered by a previous run.
+func (b *WebhookBackend) loadQueue() error {
+	err := os.MkdirAll(b.opts.QueueDir, 0o700)
+	if err != nil {
+		return err
+	}
+	path := filepath.Join(b.opts.QueueDir, webhookQueueFile)
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 || !json.Valid(line) {
+			continue
+		}
+		if len(b.queue) >= b.opts.MaxQueueSize {
+			break
+		}
+		b.queue = append(b.queue, append(json.RawMessage(nil), line...))
+	}
+	if err := scanner.Err(); err != nil {
+		_ = file.Close()
+		return err
+	}
+	b.file = file
+	// Drop anything that was invalid or over the limit.
+	return b.rewriteQueue()
+}
+
+// rewriteQueue replaces the contents of the queue file with the in-memory
+// queue. The caller must hold the lock.
+func (b *WebhookBackend) rewriteQueue() error {
+	err := b.file.Truncate(0)
+	if err != nil {
+		return err
+	}
+	_, err = b.file.Seek(0, io.SeekStart)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(b.file)
+	for _, data := range b.queue {
+		_, _ = w.Write(data)
+		_ = w.WriteByte('\n')
+	}
+	return w.Flush()
+}
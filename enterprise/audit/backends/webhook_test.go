@@ -0,0 +1,205 @@
+package backends_test
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/enterprise/audit/audittest"
+	"github.com/coder/coder/enterprise/audit/backends"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWebhookBackend(t *testing.T) {
+	t.Parallel()
+
+	t.Run("Batch", func(t *testing.T) {
+		t.Parallel()
+
+		secret := []byte("secret")
+		received := make(chan []map[string]any, 10)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			body, err := io.ReadAll(r.Body)
+			if !assert.NoError(t, err) {
+				return
+			}
+			if r.Header.Get(backends.WebhookSignatureHeader) != "sha256="+backends.SignWebhookPayload(secret, body) {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			var payload struct {
+				AuditLogs []map[string]any `json:"audit_logs"`
+			}
+			err = json.Unmarshal(body, &payload)
+			if !assert.NoError(t, err) {
+				return
+			}
+			received <- payload.AuditLogs
+		}))
+		t.Cleanup(srv.Close)
+
+		backend := newWebhook(t, backends.WebhookOptions{
+			URL:           mustURL(t, srv.URL),
+			Secret:        secret,
+			BatchSize:     2,
+			FlushInterval: time.Hour,
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		logs := []database.AuditLog{audittest.RandomLog(), audittest.RandomLog()}
+		for _, alog := range logs {
+			err := backend.Export(ctx, alog)
+			require.NoError(t, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for batch")
+		case batch := <-received:
+			require.Len(t, batch, 2)
+			require.Equal(t, logs[0].ID.String(), batch[0]["id"])
+			require.Equal(t, logs[1].ID.String(), batch[1]["id"])
+			require.Equal(t, "127.0.0.1", batch[0]["ip"])
+			require.Equal(t, string(logs[0].Action), batch[0]["action"])
+		}
+	})
+
+	t.Run("Retry", func(t *testing.T) {
+		t.Parallel()
+
+		var calls atomic.Int64
+		received := make(chan struct{}, 1)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if calls.Add(1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+				return
+			}
+			received <- struct{}{}
+		}))
+		t.Cleanup(srv.Close)
+
+		backend := newWebhook(t, backends.WebhookOptions{
+			URL:           mustURL(t, srv.URL),
+			BatchSize:     1,
+			FlushInterval: time.Hour,
+		})
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := backend.Export(ctx, audittest.RandomLog())
+		require.NoError(t, err)
+
+		select {
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for retry")
+		case <-received:
+		}
+		require.EqualValues(t, 2, calls.Load())
+	})
+
+	t.Run("QueueFull", func(t *testing.T) {
+		t.Parallel()
+
+		backend := newWebhook(t, backends.WebhookOptions{
+			URL:           mustURL(t, "http://127.0.0.1:0"),
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+			MaxQueueSize:  1,
+			MaxAttempts:   1,
+		})
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err := backend.Export(ctx, audittest.RandomLog())
+		require.NoError(t, err)
+		err = backend.Export(ctx, audittest.RandomLog())
+		require.Error(t, err)
+	})
+
+	t.Run("PersistQueue", func(t *testing.T) {
+		t.Parallel()
+
+		dir := t.TempDir()
+		alog := audittest.RandomLog()
+		failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		t.Cleanup(failing.Close)
+
+		backend, err := backends.NewWebhook(backends.WebhookOptions{
+			Logger:        slogtest.Make(t, &slogtest.Options{IgnoreErrors: true}),
+			URL:           mustURL(t, failing.URL),
+			BatchSize:     10,
+			FlushInterval: time.Hour,
+			MaxAttempts:   1,
+			QueueDir:      dir,
+		})
+		require.NoError(t, err)
+		ctx := testutil.Context(t, testutil.WaitLong)
+		err = backend.Export(ctx, alog)
+		require.NoError(t, err)
+		// Closing attempts delivery, which fails, so the audit log must
+		// remain on disk.
+		err = backend.Close()
+		require.NoError(t, err)
+
+		var (
+			mu  sync.Mutex
+			ids []string
+		)
+		srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var payload struct {
+				AuditLogs []map[string]any `json:"audit_logs"`
+			}
+			err := json.NewDecoder(r.Body).Decode(&payload)
+			if !assert.NoError(t, err) {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, alog := range payload.AuditLogs {
+				ids = append(ids, alog["id"].(string))
+			}
+		}))
+		t.Cleanup(srv.Close)
+
+		backend, err = backends.NewWebhook(backends.WebhookOptions{
+			Logger:        slogtest.Make(t, nil),
+			URL:           mustURL(t, srv.URL),
+			FlushInterval: time.Hour,
+			QueueDir:      dir,
+		})
+		require.NoError(t, err)
+		err = backend.Close()
+		require.NoError(t, err)
+
+		mu.Lock()
+		defer mu.Unlock()
+		require.Equal(t, []string{alog.ID.String()}, ids)
+	})
+}
+
+func newWebhook(t *testing.T, opts backends.WebhookOptions) *backends.WebhookBackend {
+	t.Helper()
+	opts.Logger = slogtest.Make(t, &slogtest.Options{IgnoreErrors: true})
+	backend, err := backends.NewWebhook(opts)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = backend.Close()
+	})
+	return backend
+}
+
+func mustURL(t *testing.T, raw string) *url.URL {
+	t.Helper()
+	u, err := url.Parse(raw)
+	require.NoError(t, err)
+	return u
+}
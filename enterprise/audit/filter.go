@@ -2,8 +2,14 @@ package audit
 
 import (
 	"context"
+	"encoding/json"
+	"sync"
+
+	"golang.org/x/exp/slices"
+	"golang.org/x/xerrors"
 
 	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
 )
 
 // FilterDecision is a bitwise flag describing the actions a given filter allows
@@ -40,3 +46,133 @@ type FilterFunc func(ctx context.Context, alog database.AuditLog) (FilterDecisio
 func (f FilterFunc) Check(ctx context.Context, alog database.AuditLog) (FilterDecision, error) {
 	return f(ctx, alog)
 }
+
+// RuleFilter decides using admin-configured rules. The first rule matching an
+// audit log decides, and audit logs matching no rule are stored and exported.
+// Changes to the rules themselves are always stored and exported.
+// Rules can be replaced at any time with SetRules.
+type RuleFilter struct {
+	mu    sync.RWMutex
+	rules []codersdk.AuditFilterRule
+}
+
+var _ Filter = (*RuleFilter)(nil)
+
+// NewRuleFilter creates a filter with the given rules.
+func NewRuleFilter(rules []codersdk.AuditFilterRule) *RuleFilter {
+	return &RuleFilter{rules: rules}
+}
+
+// Rules returns the current rules.
+func (f *RuleFilter) Rules() []codersdk.AuditFilterRule {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.rules
+}
+
+// SetRules replaces the current rules.
+func (f *RuleFilter) SetRules(rules []codersdk.AuditFilterRule) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rules = rules
+}
+
+func (f *RuleFilter) Check(ctx context.Context, alog database.AuditLog) (FilterDecision, error) {
+	// Changes to the rules are always kept, so the rules can't hide them.
+	if alog.ResourceType == database.ResourceTypeAuditFilterRules {
+		return DefaultFilter.Check(ctx, alog)
+	}
+	for _, rule := range f.Rules() {
+		matched, err := ruleMatches(rule, alog)
+		if err != nil {
+			return FilterDecisionDrop, err
+		}
+		if !matched {
+			continue
+		}
+		decision := FilterDecisionDrop
+		if rule.Store {
+			decision |= FilterDecisionStore
+		}
+		if rule.Export {
+			decision |= FilterDecisionExport
+		}
+		return decision, nil
+	}
+	return DefaultFilter.Check(ctx, alog)
+}
+
+// ValidateFilterRule returns an error if the rule refers to unknown resource
+// types, actions or build reasons, or has empty diff fields.
+func ValidateFilterRule(rule codersdk.AuditFilterRule) error {
+	for _, resourceType := range rule.ResourceTypes {
+		if !database.ResourceType(resourceType).Valid() {
+			return xerrors.Errorf("unknown resource type %q", resourceType)
+		}
+	}
+	for _, action := range rule.Actions {
+		if !database.AuditAction(action).Valid() {
+			return xerrors.Errorf("unknown action %q", action)
+		}
+	}
+	for _, reason := range rule.BuildReasons {
+		if !database.BuildReason(reason).Valid() {
+			return xerrors.Errorf("unknown build reason %q", reason)
+		}
+	}
+	for _, field := range rule.DiffFields {
+		if field == "" {
+			return xerrors.New("diff fields must not be empty")
+		}
+	}
+	return nil
+}
+
+func ruleMatches(rule codersdk.AuditFilterRule, alog database.AuditLog) (bool, error) {
+	if len(rule.ResourceTypes) > 0 && !slices.Contains(rule.ResourceTypes, codersdk.ResourceType(alog.ResourceType)) {
+		return false, nil
+	}
+	if len(rule.Actions) > 0 && !slices.Contains(rule.Actions, codersdk.AuditAction(alog.Action)) {
+		return false, nil
+	}
+	if len(rule.UserIDs) > 0 && !slices.Contains(rule.UserIDs, alog.UserID) {
+		return false, nil
+	}
+	if len(rule.StatusCodes) > 0 && !slices.Contains(rule.StatusCodes, alog.StatusCode) {
+		return false, nil
+	}
+	if len(rule.BuildReasons) > 0 {
+		var fields struct {
+			BuildReason codersdk.BuildReason `json:"build_reason"`
+		}
+		if len(alog.AdditionalFields) > 0 {
+			err := json.Unmarshal(alog.AdditionalFields, &fields)
+			if err != nil {
+				return false, xerrors.Errorf("unmarshal additional fields: %w", err)
+			}
+		}
+		if !slices.Contains(rule.BuildReasons, fields.BuildReason) {
+			return false, nil
+		}
+	}
+	if len(rule.DiffFields) > 0 {
+		var diff map[string]json.RawMessage
+		if len(alog.Diff) > 0 {
+			err := json.Unmarshal(alog.Diff, &diff)
+			if err != nil {
+				return false, xerrors.Errorf("unmarshal diff: %w", err)
+			}
+		}
+		changed := false
+		for _, field := range rule.DiffFields {
+			if _, ok := diff[field]; ok {
+				changed = true
+				break
+			}
+		}
+		if !changed {
+			return false, nil
+		}
+	}
+	return true, nil
+}
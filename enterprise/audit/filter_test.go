@@ -0,0 +1,151 @@
+package audit_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/audit"
+	"github.com/coder/coder/enterprise/audit/audittest"
+)
+
+func TestRuleFilter(t *testing.T) {
+	t.Parallel()
+
+	autostartBuild := audittest.RandomLog()
+	autostartBuild.ResourceType = database.ResourceTypeWorkspaceBuild
+	autostartBuild.Action = database.AuditActionStart
+	autostartBuild.AdditionalFields = []byte(`{"build_reason":"autostart"}`)
+
+	userUpdate := audittest.RandomLog()
+	userUpdate.ResourceType = database.ResourceTypeUser
+	userUpdate.Action = database.AuditActionWrite
+	userUpdate.Diff = []byte(`{"email":{"old":"a@coder.com","new":"b@coder.com"}}`)
+
+	rulesUpdate := audittest.RandomLog()
+	rulesUpdate.ResourceType = database.ResourceTypeAuditFilterRules
+	rulesUpdate.Action = database.AuditActionWrite
+
+	tests := []struct {
+		name     string
+		rules    []codersdk.AuditFilterRule
+		alog     database.AuditLog
+		decision audit.FilterDecision
+	}{
+		{
+			name:     "NoRules",
+			alog:     audittest.RandomLog(),
+			decision: audit.FilterDecisionStore | audit.FilterDecisionExport,
+		},
+		{
+			name: "NoMatch",
+			rules: []codersdk.AuditFilterRule{{
+				ResourceTypes: []codersdk.ResourceType{codersdk.ResourceTypeTemplate},
+			}},
+			alog:     audittest.RandomLog(),
+			decision: audit.FilterDecisionStore | audit.FilterDecisionExport,
+		},
+		{
+			name: "ExportAutostart",
+			rules: []codersdk.AuditFilterRule{{
+				ResourceTypes: []codersdk.ResourceType{codersdk.ResourceTypeWorkspaceBuild},
+				BuildReasons:  []codersdk.BuildReason{codersdk.BuildReasonAutostart},
+				Export:        true,
+			}},
+			alog:     autostartBuild,
+			decision: audit.FilterDecisionExport,
+		},
+		{
+			name: "StoreOnly",
+			rules: []codersdk.AuditFilterRule{{
+				Actions:     []codersdk.AuditAction{codersdk.AuditActionDelete},
+				StatusCodes: []int32{http.StatusNoContent},
+				Store:       true,
+			}},
+			alog:     audittest.RandomLog(),
+			decision: audit.FilterDecisionStore,
+		},
+		{
+			name: "DropUser",
+			rules: []codersdk.AuditFilterRule{{
+				UserIDs: []uuid.UUID{userUpdate.UserID},
+			}},
+			alog:     userUpdate,
+			decision: audit.FilterDecisionDrop,
+		},
+		{
+			name: "DiffField",
+			rules: []codersdk.AuditFilterRule{{
+				DiffFields: []string{"username"},
+			}, {
+				DiffFields: []string{"email"},
+				Export:     true,
+			}},
+			alog:     userUpdate,
+			decision: audit.FilterDecisionExport,
+		},
+		{
+			name: "FirstMatchWins",
+			rules: []codersdk.AuditFilterRule{{
+				ResourceTypes: []codersdk.ResourceType{codersdk.ResourceTypeUser},
+				Store:         true,
+			}, {
+				Export: true,
+			}},
+			alog:     userUpdate,
+			decision: audit.FilterDecisionStore,
+		},
+		{
+			name:     "RulesUpdateKept",
+			rules:    []codersdk.AuditFilterRule{{}},
+			alog:     rulesUpdate,
+			decision: audit.FilterDecisionStore | audit.FilterDecisionExport,
+		},
+	}
+
+	for _, test := range tests {
+		test := test
+		t.Run(test.name, func(t *testing.T) {
+			t.Parallel()
+
+			filter := audit.NewRuleFilter(test.rules)
+			decision, err := filter.Check(context.Background(), test.alog)
+			require.NoError(t, err)
+			require.Equal(t, test.decision, decision)
+		})
+	}
+
+	t.Run("SetRules", func(t *testing.T) {
+		t.Parallel()
+
+		filter := audit.NewRuleFilter(nil)
+		filter.SetRules([]codersdk.AuditFilterRule{{}})
+		decision, err := filter.Check(context.Background(), audittest.RandomLog())
+		require.NoError(t, err)
+		require.Equal(t, audit.FilterDecisionDrop, decision)
+	})
+}
+
+func TestValidateFilterRule(t *testing.T) {
+	t.Parallel()
+
+	require.NoError(t, audit.ValidateFilterRule(codersdk.AuditFilterRule{
+		ResourceTypes: []codersdk.ResourceType{codersdk.ResourceTypeWorkspaceBuild},
+		Actions:       []codersdk.AuditAction{codersdk.AuditActionStart},
+		BuildReasons:  []codersdk.BuildReason{codersdk.BuildReasonAutostart},
+	}))
+	require.Error(t, audit.ValidateFilterRule(codersdk.AuditFilterRule{
+		ResourceTypes: []codersdk.ResourceType{"nope"},
+	}))
+	require.Error(t, audit.ValidateFilterRule(codersdk.AuditFilterRule{
+		Actions: []codersdk.AuditAction{"nope"},
+	}))
+	require.Error(t, audit.ValidateFilterRule(codersdk.AuditFilterRule{
+		DiffFields: []string{""},
+	}))
+}
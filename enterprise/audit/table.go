@@ -14,15 +14,18 @@ import (
 // AuditableResources map (below) as our documentation - generated in scripts/auditdocgen/main.go -
 // depends upon it.
 var AuditActionMap = map[string][]codersdk.AuditAction{
-	"GitSSHKey":       {codersdk.AuditActionCreate},
-	"Template":        {codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"TemplateVersion": {codersdk.AuditActionCreate, codersdk.AuditActionWrite},
-	"User":            {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"Workspace":       {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"WorkspaceBuild":  {codersdk.AuditActionStart, codersdk.AuditActionStop},
-	"Group":           {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
-	"APIKey":          {codersdk.AuditActionLogin, codersdk.AuditActionLogout, codersdk.AuditActionCreate, codersdk.AuditActionDelete},
-	"License":         {codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"GitSSHKey":               {codersdk.AuditActionCreate},
+	"Template":                {codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"TemplateVersion":         {codersdk.AuditActionCreate, codersdk.AuditActionWrite},
+	"User":                    {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"Workspace":               {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"WorkspaceBuild":          {codersdk.AuditActionStart, codersdk.AuditActionStop},
+	"Group":                   {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
+	"APIKey":                  {codersdk.AuditActionLogin, codersdk.AuditActionLogout, codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"License":                 {codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"WorkspaceProxy":          {codersdk.AuditActionCreate, codersdk.AuditActionDelete},
+	"AuditFilterRules":        {codersdk.AuditActionWrite},
+	"WorkspaceAgentPortShare": {codersdk.AuditActionCreate, codersdk.AuditActionWrite, codersdk.AuditActionDelete},
 }
 
 type Action string
@@ -72,7 +75,12 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"group_acl":                        ActionTrack,
 		"user_acl":                         ActionTrack,
 		"allow_user_cancel_workspace_jobs": ActionTrack,
+		"record_terminal_sessions":         ActionTrack,
 		"max_ttl":                          ActionTrack,
+		"inactivity_ttl":                   ActionTrack,
+		"locked_ttl":                       ActionTrack,
+		"failure_ttl":                      ActionTrack,
+		"max_port_sharing_level":           ActionTrack,
 	},
 	&database.TemplateVersion{}: {
 		"id":                 ActionTrack,
@@ -85,6 +93,8 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"job_id":             ActionIgnore, // Not helpful in a diff because jobs aren't tracked in audit logs.
 		"created_by":         ActionTrack,
 		"git_auth_providers": ActionIgnore, // Not helpful because this can only change when new versions are added.
+		"archived":           ActionTrack,
+		"git_commit_sha":     ActionTrack,
 	},
 	&database.User{}: {
 		"id":              ActionTrack,
@@ -112,6 +122,7 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"autostart_schedule": ActionTrack,
 		"ttl":                ActionTrack,
 		"last_used_at":       ActionIgnore,
+		"locked_at":          ActionTrack,
 	},
 	&database.WorkspaceBuild{}: {
 		"id":                  ActionIgnore,
@@ -160,6 +171,30 @@ var auditableResourcesTypes = map[any]map[string]Action{
 		"exp":         ActionTrack,
 		"uuid":        ActionTrack,
 	},
+	&database.WorkspaceProxy{}: {
+		"id":                  ActionTrack,
+		"name":                ActionTrack,
+		"display_name":        ActionTrack,
+		"icon":                ActionTrack,
+		"url":                 ActionTrack,
+		"wildcard_hostname":   ActionTrack,
+		"created_at":          ActionTrack,
+		"updated_at":          ActionIgnore,
+		"deleted":             ActionIgnore,
+		"token_hashed_secret": ActionSecret,
+	},
+	&database.AuditFilterRules{}: {
+		"id":    ActionIgnore,
+		"rules": ActionTrack,
+	},
+	&database.WorkspaceAgentPortShare{}: {
+		"workspace_id": ActionTrack,
+		"agent_name":   ActionTrack,
+		"port":         ActionTrack,
+		"share_level":  ActionTrack,
+		"created_at":   ActionIgnore,
+		"updated_at":   ActionIgnore,
+	},
 }
 
 // auditMap converts a map of struct pointers to a map of struct names as
@@ -0,0 +1,130 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+func (r *RootCmd) audit() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Use:   "audit",
+		Short: "Manage audit logs",
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.auditExport(),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) auditExport() *clibase.Cmd {
+	var (
+		query  string
+		format string
+		after  string
+		before string
+		output string
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "export",
+		Short: "Export audit logs as CSV or NDJSON",
+		Long:  "Audit logs are streamed newest first, so exports of any size can be written to a file.",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			req := codersdk.AuditLogsExportRequest{
+				SearchQuery: query,
+				Format:      codersdk.AuditLogExportFormat(format),
+			}
+			var err error
+			if after != "" {
+				req.After, err = time.Parse(time.RFC3339, after)
+				if err != nil {
+					return xerrors.Errorf("parse --after: %w", err)
+				}
+			}
+			if before != "" {
+				req.Before, err = time.Parse(time.RFC3339, before)
+				if err != nil {
+					return xerrors.Errorf("parse --before: %w", err)
+				}
+			}
+
+			body, err := client.ExportAuditLogs(inv.Context(), req)
+			if err != nil {
+				return xerrors.Errorf("export audit logs: %w", err)
+			}
+			defer body.Close()
+
+			out := inv.Stdout
+			var file *os.File
+			if output != "" && output != "-" {
+				file, err = os.Create(output)
+				if err != nil {
+					return xerrors.Errorf("create output file: %w", err)
+				}
+				defer file.Close()
+				out = file
+			}
+			// Reading fails if the export failed partway, which is reported
+			// by the server after the audit logs that were written.
+			_, err = io.Copy(out, body)
+			if err != nil {
+				if file != nil {
+					// Don't leave an incomplete export that looks complete.
+					_ = file.Close()
+					_ = os.Remove(output)
+				}
+				return xerrors.Errorf("export audit logs: %w", err)
+			}
+			if output != "" && output != "-" {
+				cliui.Infof(inv.Stderr, "Exported audit logs to %s.\n", output)
+			}
+			return nil
+		},
+	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:          "query",
+			FlagShorthand: "q",
+			Description:   "Search query for audit logs, using the same syntax as the audit log page.",
+			Value:         clibase.StringOf(&query),
+		},
+		{
+			Flag:        "format",
+			Description: "Format of the exported audit logs.",
+			Default:     string(codersdk.AuditLogExportFormatCSV),
+			Value:       clibase.EnumOf(&format, string(codersdk.AuditLogExportFormatCSV), string(codersdk.AuditLogExportFormatNDJSON)),
+		},
+		{
+			Flag:        "after",
+			Description: "Only export audit logs from this time onwards, in RFC 3339 format.",
+			Value:       clibase.StringOf(&after),
+		},
+		{
+			Flag:        "before",
+			Description: "Only export audit logs up to this time, in RFC 3339 format.",
+			Value:       clibase.StringOf(&before),
+		},
+		{
+			Flag:          "output",
+			FlagShorthand: "o",
+			Description:   "File to write the audit logs to. Defaults to stdout.",
+			Value:         clibase.StringOf(&output),
+		},
+	}
+	return cmd
+}
@@ -0,0 +1,69 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/csv"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestAuditExport(t *testing.T) {
+	t.Parallel()
+
+	client := coderdenttest.New(t, nil)
+	admin := coderdtest.CreateFirstUser(t, client)
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	err := client.CreateTestAuditLog(ctx, codersdk.CreateTestAuditLogRequest{
+		Action:       codersdk.AuditActionDelete,
+		ResourceType: codersdk.ResourceTypeTemplate,
+		ResourceID:   admin.UserID,
+	})
+	require.NoError(t, err)
+	err = client.CreateTestAuditLog(ctx, codersdk.CreateTestAuditLogRequest{
+		Action:     codersdk.AuditActionCreate,
+		ResourceID: admin.UserID,
+	})
+	require.NoError(t, err)
+
+	t.Run("Stdout", func(t *testing.T) {
+		t.Parallel()
+
+		inv, conf := newCLI(t, "audit", "export", "--query", "action:delete")
+		var out bytes.Buffer
+		inv.Stdout = &out
+		clitest.SetupConfig(t, client, conf)
+
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		records, err := csv.NewReader(&out).ReadAll()
+		require.NoError(t, err)
+		require.Len(t, records, 2)
+		require.Equal(t, string(codersdk.AuditActionDelete), records[1][11])
+	})
+
+	t.Run("File", func(t *testing.T) {
+		t.Parallel()
+
+		path := filepath.Join(t.TempDir(), "audit.ndjson")
+		inv, conf := newCLI(t, "audit", "export", "--format", "ndjson", "-o", path)
+		clitest.SetupConfig(t, client, conf)
+
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		data, err := os.ReadFile(path)
+		require.NoError(t, err)
+		require.Len(t, bytes.Split(bytes.TrimSpace(data), []byte("\n")), 2)
+	})
+}
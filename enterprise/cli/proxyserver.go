@@ -0,0 +1,218 @@
+//go:build !slim
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os/signal"
+	"regexp"
+	"time"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"cdr.dev/slog/sloggers/sloghuman"
+	agpl "github.com/coder/coder/cli"
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/enterprise/wsproxy"
+)
+
+func (*RootCmd) proxyServer() *clibase.Cmd {
+	var (
+		primaryAccessURL    clibase.URL
+		accessURL           clibase.URL
+		wildcardAccessURL   string
+		httpAddress         string
+		proxySessionToken   string
+		secureAuthCookie    bool
+		disablePathApps     bool
+		apiRateLimit        int64
+		proxyTrustedHeaders []string
+		proxyTrustedOrigins []string
+		verbose             bool
+	)
+	cmd := &clibase.Cmd{
+		Use:   "server",
+		Short: "Start a workspace proxy server",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(0),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx, cancel := context.WithCancel(inv.Context())
+			defer cancel()
+
+			notifyCtx, notifyStop := signal.NotifyContext(ctx, agpl.InterruptSignals...)
+			defer notifyStop()
+
+			logger := slog.Make(sloghuman.Sink(inv.Stderr))
+			if verbose {
+				logger = logger.Leveled(slog.LevelDebug)
+			}
+
+			if primaryAccessURL.String() == "" {
+				return xerrors.New("--primary-access-url is required")
+			}
+			if accessURL.String() == "" {
+				return xerrors.New("--access-url is required")
+			}
+			if proxySessionToken == "" {
+				return xerrors.Errorf("--%s is required", proxySessionTokenFlag)
+			}
+
+			var appHostnameRegex *regexp.Regexp
+			if wildcardAccessURL != "" {
+				var err error
+				appHostnameRegex, err = httpapi.CompileHostnamePattern(wildcardAccessURL)
+				if err != nil {
+					return xerrors.Errorf("parse wildcard access URL %q: %w", wildcardAccessURL, err)
+				}
+			}
+
+			realIPConfig, err := httpmw.ParseRealIPConfig(proxyTrustedHeaders, proxyTrustedOrigins)
+			if err != nil {
+				return xerrors.Errorf("parse real ip config: %w", err)
+			}
+
+			proxy, err := wsproxy.New(ctx, &wsproxy.Options{
+				Logger:            logger,
+				DashboardURL:      (*url.URL)(&primaryAccessURL),
+				AccessURL:         (*url.URL)(&accessURL),
+				AppHostname:       wildcardAccessURL,
+				AppHostnameRegex:  appHostnameRegex,
+				RealIPConfig:      realIPConfig,
+				APIRateLimit:      int(apiRateLimit),
+				SecureAuthCookie:  secureAuthCookie,
+				DisablePathApps:   disablePathApps,
+				ProxySessionToken: proxySessionToken,
+			})
+			if err != nil {
+				return xerrors.Errorf("create workspace proxy: %w", err)
+			}
+			defer proxy.Close()
+
+			listener, err := net.Listen("tcp", httpAddress)
+			if err != nil {
+				return xerrors.Errorf("listen %q: %w", httpAddress, err)
+			}
+			defer listener.Close()
+
+			// ReadHeaderTimeout is purposefully not enabled, as in coderd.
+			//nolint:gosec
+			httpServer := &http.Server{
+				Handler: proxy.Handler,
+				BaseContext: func(_ net.Listener) context.Context {
+					return ctx
+				},
+			}
+			errCh := make(chan error, 1)
+			go func() {
+				errCh <- httpServer.Serve(listener)
+			}()
+
+			_, _ = fmt.Fprintf(inv.Stdout, "Started workspace proxy on %s, serving %s\n", listener.Addr(), cliui.Styles.Field.Render(accessURL.String()))
+
+			var exitErr error
+			select {
+			case <-notifyCtx.Done():
+				exitErr = notifyCtx.Err()
+				_, _ = fmt.Fprintln(inv.Stdout, cliui.Styles.Bold.Render(
+					"Interrupt caught, gracefully exiting. Use ctrl+\\ to force quit",
+				))
+			case exitErr = <-errCh:
+			}
+			if exitErr != nil && !xerrors.Is(exitErr, context.Canceled) {
+				cliui.Errorf(inv.Stderr, "Unexpected error, shutting down server: %s\n", exitErr)
+			}
+
+			shutdownCtx, shutdownCancel := context.WithTimeout(inv.Context(), 5*time.Second)
+			defer shutdownCancel()
+			_ = httpServer.Shutdown(shutdownCtx)
+
+			if xerrors.Is(exitErr, context.Canceled) {
+				return nil
+			}
+			return exitErr
+		},
+	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "primary-access-url",
+			Env:         "CODER_PRIMARY_ACCESS_URL",
+			Description: "URL of the primary Coder deployment the proxy registers with.",
+			Value:       &primaryAccessURL,
+		},
+		{
+			Flag:        "access-url",
+			Env:         "CODER_ACCESS_URL",
+			Description: "The URL that users will use to access the workspace proxy.",
+			Value:       &accessURL,
+		},
+		{
+			Flag:        "wildcard-access-url",
+			Env:         "CODER_WILDCARD_ACCESS_URL",
+			Description: `Specifies the wildcard hostname to use for workspace applications in the form "*.example.com".`,
+			Value:       clibase.StringOf(&wildcardAccessURL),
+		},
+		{
+			Flag:        "http-address",
+			Env:         "CODER_HTTP_ADDRESS",
+			Description: "HTTP bind address of the workspace proxy.",
+			Default:     "127.0.0.1:3001",
+			Value:       clibase.StringOf(&httpAddress),
+		},
+		{
+			Flag:        proxySessionTokenFlag,
+			Env:         proxySessionTokenEnv,
+			Description: "The token the proxy uses to authenticate with the primary deployment. It is printed by `coder workspace-proxy create`.",
+			Value:       clibase.StringOf(&proxySessionToken),
+		},
+		{
+			Flag:        "secure-auth-cookie",
+			Env:         "CODER_SECURE_AUTH_COOKIE",
+			Description: "Controls if the 'Secure' property is set on browser session cookies.",
+			Value:       clibase.BoolOf(&secureAuthCookie),
+		},
+		{
+			Flag:        "disable-path-apps",
+			Env:         "CODER_DISABLE_PATH_APPS",
+			Description: "Disable workspace apps that are not served from subdomains.",
+			Value:       clibase.BoolOf(&disablePathApps),
+		},
+		{
+			Flag:        "api-rate-limit",
+			Env:         "CODER_API_RATE_LIMIT",
+			Description: "Maximum number of requests per minute allowed to workspace apps per user or IP address. Set to -1 to disable.",
+			Default:     "512",
+			Value:       clibase.Int64Of(&apiRateLimit),
+		},
+		{
+			Flag:        "proxy-trusted-headers",
+			Env:         "CODER_PROXY_TRUSTED_HEADERS",
+			Description: "Headers to trust for forwarding IP addresses. e.g. Cf-Connecting-Ip, True-Client-Ip, X-Forwarded-For.",
+			Value:       clibase.StringArrayOf(&proxyTrustedHeaders),
+		},
+		{
+			Flag:        "proxy-trusted-origins",
+			Env:         "CODER_PROXY_TRUSTED_ORIGINS",
+			Description: "Origin addresses to respect \"proxy-trusted-headers\". e.g. 192.168.1.0/24.",
+			Value:       clibase.StringArrayOf(&proxyTrustedOrigins),
+		},
+		{
+			Flag:          "verbose",
+			FlagShorthand: "v",
+			Env:           "CODER_VERBOSE",
+			Description:   "Output debug-level logs.",
+			Value:         clibase.BoolOf(&verbose),
+		},
+	}
+
+	return cmd
+}
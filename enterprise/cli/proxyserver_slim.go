@@ -0,0 +1,29 @@
+//go:build slim
+
+package cli
+
+import (
+	"fmt"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+)
+
+func (*RootCmd) proxyServer() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Use:   "server",
+		Short: "Start a workspace proxy server",
+		// We accept RawArgs so all commands and flags are accepted.
+		RawArgs: true,
+		Hidden:  true,
+		Handler: func(inv *clibase.Invocation) error {
+			_, _ = fmt.Fprintf(inv.Stderr, "You are using a 'slim' build of Coder, which does not support the %s subcommand.\n", cliui.Styles.Code.Render("workspace-proxy server"))
+			_, _ = fmt.Fprintln(inv.Stderr, "")
+			_, _ = fmt.Fprintln(inv.Stderr, "Please use a build of Coder from GitHub releases:")
+			_, _ = fmt.Fprintln(inv.Stderr, "  https://github.com/coder/coder/releases")
+			return nil
+		},
+	}
+
+	return cmd
+}
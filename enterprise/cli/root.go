@@ -12,10 +12,12 @@ type RootCmd struct {
 func (r *RootCmd) enterpriseOnly() []*clibase.Cmd {
 	return []*clibase.Cmd{
 		r.server(),
+		r.audit(),
 		r.features(),
 		r.licenses(),
 		r.groups(),
 		r.provisionerDaemons(),
+		r.workspaceProxy(),
 	}
 }
 
@@ -4,10 +4,12 @@ package cli
 
 import (
 	"context"
+	"crypto/tls"
 	"database/sql"
 	"errors"
 	"io"
 	"net/url"
+	"path/filepath"
 
 	"golang.org/x/xerrors"
 	"tailscale.com/derp"
@@ -50,11 +52,21 @@ func (r *RootCmd) server() *clibase.Cmd {
 		}
 		options.DERPServer.SetMeshKey(meshKey)
 
+		var (
+			closers     []io.Closer
+			auditFilter = audit.NewRuleFilter(nil)
+		)
 		if options.DeploymentValues.AuditLogging.Value() {
-			options.Auditor = audit.NewAuditor(audit.DefaultFilter,
+			auditBackends := []audit.Backend{
 				backends.NewPostgres(options.Database, true),
 				backends.NewSlog(options.Logger),
-			)
+			}
+			exportBackends, exportClosers, err := auditExportBackends(options)
+			if err != nil {
+				return nil, nil, err
+			}
+			closers = append(closers, exportClosers...)
+			options.Auditor = audit.NewAuditor(auditFilter, append(auditBackends, exportBackends...)...)
 		}
 
 		options.TrialGenerator = trialer.New(options.Database, "https://v2-licensor.coder.com/trial", coderd.Keys)
@@ -67,13 +79,78 @@ func (r *RootCmd) server() *clibase.Cmd {
 			DERPServerRelayAddress: options.DeploymentValues.DERP.Server.RelayURL.String(),
 			DERPServerRegionID:     int(options.DeploymentValues.DERP.Server.RegionID.Value()),
 			Options:                options,
+			AuditFilter:            auditFilter,
 		}
 
 		api, err := coderd.New(ctx, o)
 		if err != nil {
+			closeAll(closers)
 			return nil, nil, err
 		}
-		return api.AGPL, api, nil
+		// The API is closed first so no audit logs are exported after the
+		// backends are closed.
+		return api.AGPL, closerFunc(func() error {
+			err := api.Close()
+			closeAll(closers)
+			return err
+		}), nil
 	})
 	return cmd
 }
+
+// auditExportBackends creates the backends that send audit logs to external
+// systems, as configured by the deployment.
+func auditExportBackends(options *agplcoderd.Options) ([]audit.Backend, []io.Closer, error) {
+	var (
+		cfg       = options.DeploymentValues.AuditExport
+		exporters []audit.Backend
+		closers   []io.Closer
+	)
+	if cfg.WebhookURL.String() != "" {
+		webhook, err := backends.NewWebhook(backends.WebhookOptions{
+			Logger:        options.Logger.Named("audit_webhook"),
+			URL:           cfg.WebhookURL.Value(),
+			Secret:        []byte(cfg.WebhookSecret.String()),
+			BatchSize:     int(cfg.WebhookBatchSize.Value()),
+			FlushInterval: cfg.WebhookFlushInterval.Value(),
+			MaxQueueSize:  int(cfg.WebhookQueueSize.Value()),
+			QueueDir:      filepath.Join(options.CacheDir, "audit-webhook"),
+		})
+		if err != nil {
+			return nil, nil, xerrors.Errorf("create audit webhook backend: %w", err)
+		}
+		exporters = append(exporters, webhook)
+		closers = append(closers, webhook)
+	}
+	if cfg.SyslogAddress.String() != "" {
+		var tlsConfig *tls.Config
+		if cfg.SyslogTLS.Value() {
+			tlsConfig = &tls.Config{MinVersion: tls.VersionTLS12}
+		}
+		syslog, err := backends.NewSyslog(backends.SyslogOptions{
+			Logger:    options.Logger.Named("audit_syslog"),
+			Address:   cfg.SyslogAddress.String(),
+			TLSConfig: tlsConfig,
+			Facility:  cfg.SyslogFacility.String(),
+		})
+		if err != nil {
+			closeAll(closers)
+			return nil, nil, xerrors.Errorf("create audit syslog backend: %w", err)
+		}
+		exporters = append(exporters, syslog)
+		closers = append(closers, syslog)
+	}
+	return exporters, closers, nil
+}
+
+type closerFunc func() error
+
+func (f closerFunc) Close() error {
+	return f()
+}
+
+func closeAll(closers []io.Closer) {
+	for _, c := range closers {
+		_ = c.Close()
+	}
+}
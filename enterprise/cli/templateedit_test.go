@@ -0,0 +1,91 @@
+package cli_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/testutil"
+)
+
+func TestTemplateEdit(t *testing.T) {
+	t.Parallel()
+
+	t.Run("InactivityTTL", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAdvancedTemplateScheduling: 1,
+			},
+		})
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		inv, conf := newCLI(t, "templates", "edit", template.Name, "--inactivity-ttl", "1h", "--locked-ttl", "2h")
+		clitest.SetupConfig(t, client, conf)
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour.Milliseconds(), template.InactivityTTLMillis)
+		require.Equal(t, 2*time.Hour.Milliseconds(), template.LockedTTLMillis)
+
+		// Editing another field keeps the TTLs.
+		inv, conf = newCLI(t, "templates", "edit", template.Name, "--description", "foo")
+		clitest.SetupConfig(t, client, conf)
+		err = inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, "foo", template.Description)
+		require.Equal(t, time.Hour.Milliseconds(), template.InactivityTTLMillis)
+		require.Equal(t, 2*time.Hour.Milliseconds(), template.LockedTTLMillis)
+	})
+
+	t.Run("FailureTTL", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAdvancedTemplateScheduling: 1,
+			},
+		})
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		inv, conf := newCLI(t, "templates", "edit", template.Name, "--failure-ttl", "1h")
+		clitest.SetupConfig(t, client, conf)
+		err := inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour.Milliseconds(), template.FailureTTLMillis)
+
+		// Editing another field keeps the TTL.
+		inv, conf = newCLI(t, "templates", "edit", template.Name, "--description", "foo")
+		clitest.SetupConfig(t, client, conf)
+		err = inv.WithContext(ctx).Run()
+		require.NoError(t, err)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, "foo", template.Description)
+		require.Equal(t, time.Hour.Milliseconds(), template.FailureTTLMillis)
+	})
+}
@@ -0,0 +1,148 @@
+package cli
+
+import (
+	"fmt"
+
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/cli/clibase"
+	"github.com/coder/coder/cli/cliui"
+	"github.com/coder/coder/codersdk"
+)
+
+const (
+	proxySessionTokenFlag = "proxy-session-token"
+	proxySessionTokenEnv  = "CODER_PROXY_SESSION_TOKEN"
+)
+
+func (r *RootCmd) workspaceProxy() *clibase.Cmd {
+	cmd := &clibase.Cmd{
+		Use:     "workspace-proxy",
+		Short:   "Manage workspace proxies",
+		Aliases: []string{"wsproxy"},
+		Handler: func(inv *clibase.Invocation) error {
+			return inv.Command.HelpHandler(inv)
+		},
+		Children: []*clibase.Cmd{
+			r.createWorkspaceProxy(),
+			r.listWorkspaceProxies(),
+			r.deleteWorkspaceProxy(),
+			r.proxyServer(),
+		},
+	}
+
+	return cmd
+}
+
+func (r *RootCmd) createWorkspaceProxy() *clibase.Cmd {
+	var (
+		displayName string
+		icon        string
+	)
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "create <name>",
+		Short: "Create a workspace proxy",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			resp, err := client.CreateWorkspaceProxy(inv.Context(), codersdk.CreateWorkspaceProxyRequest{
+				Name:        inv.Args[0],
+				DisplayName: displayName,
+				Icon:        icon,
+			})
+			if err != nil {
+				return xerrors.Errorf("create workspace proxy: %w", err)
+			}
+
+			cliui.Infof(
+				inv.Stdout,
+				"Created workspace proxy %s. Here is its token, which will not be shown again.\n\n",
+				cliui.Styles.Keyword.Render(resp.Proxy.Name),
+			)
+			cliui.Infof(inv.Stdout, cliui.Styles.Code.Render(resp.ProxyToken)+"\n\n")
+			cliui.Infof(inv.Stdout,
+				"Start the proxy with the --%s flag or the %s environment variable set to this token.", proxySessionTokenFlag, proxySessionTokenEnv,
+			)
+			return nil
+		},
+	}
+
+	cmd.Options = clibase.OptionSet{
+		{
+			Flag:        "display-name",
+			Description: "Display name of the proxy.",
+			Value:       clibase.StringOf(&displayName),
+		},
+		{
+			Flag:        "icon",
+			Description: "Display icon of the proxy.",
+			Value:       clibase.StringOf(&icon),
+		},
+	}
+	return cmd
+}
+
+func (r *RootCmd) listWorkspaceProxies() *clibase.Cmd {
+	formatter := cliui.NewOutputFormatter(
+		cliui.TableFormat([]codersdk.WorkspaceProxy{}, []string{"name", "url", "status status"}),
+		cliui.JSONFormat(),
+	)
+
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:     "ls",
+		Aliases: []string{"list"},
+		Short:   "List all workspace proxies",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(0),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+
+			proxies, err := client.WorkspaceProxies(ctx)
+			if err != nil {
+				return xerrors.Errorf("list workspace proxies: %w", err)
+			}
+
+			out, err := formatter.Format(ctx, proxies)
+			if err != nil {
+				return xerrors.Errorf("format proxies: %w", err)
+			}
+
+			_, _ = fmt.Fprintln(inv.Stdout, out)
+			return nil
+		},
+	}
+
+	formatter.AttachOptions(&cmd.Options)
+	return cmd
+}
+
+func (r *RootCmd) deleteWorkspaceProxy() *clibase.Cmd {
+	client := new(codersdk.Client)
+	cmd := &clibase.Cmd{
+		Use:   "delete <name|id>",
+		Short: "Delete a workspace proxy",
+		Middleware: clibase.Chain(
+			clibase.RequireNArgs(1),
+			r.InitClient(client),
+		),
+		Handler: func(inv *clibase.Invocation) error {
+			ctx := inv.Context()
+
+			err := client.DeleteWorkspaceProxyByName(ctx, inv.Args[0])
+			if err != nil {
+				return xerrors.Errorf("delete workspace proxy %q: %w", inv.Args[0], err)
+			}
+
+			_, _ = fmt.Fprintf(inv.Stdout, "Successfully deleted workspace proxy %s!\n", cliui.Styles.Keyword.Render(inv.Args[0]))
+			return nil
+		},
+	}
+
+	return cmd
+}
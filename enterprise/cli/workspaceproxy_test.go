@@ -0,0 +1,61 @@
+package cli_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/cli/clitest"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceProxy(t *testing.T) {
+	t.Parallel()
+
+	client := coderdenttest.New(t, nil)
+	_ = coderdtest.CreateFirstUser(t, client)
+	_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		Features: license.Features{
+			codersdk.FeatureWorkspaceProxy: 1,
+		},
+	})
+
+	inv, conf := newCLI(t, "workspace-proxy", "create", "us-west", "--display-name", "US West")
+	clitest.SetupConfig(t, client, conf)
+	var out bytes.Buffer
+	inv.Stdout = &out
+	err := inv.Run()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "us-west")
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	proxy, err := client.WorkspaceProxyByName(ctx, "us-west")
+	require.NoError(t, err)
+	require.Equal(t, "US West", proxy.DisplayName)
+	require.Contains(t, out.String(), proxy.ID.String()+":")
+
+	inv, conf = newCLI(t, "wsproxy", "ls")
+	clitest.SetupConfig(t, client, conf)
+	out.Reset()
+	inv.Stdout = &out
+	err = inv.Run()
+	require.NoError(t, err)
+	require.Contains(t, out.String(), "us-west")
+	require.Contains(t, out.String(), string(codersdk.ProxyUnregistered))
+
+	inv, conf = newCLI(t, "wsproxy", "delete", "us-west")
+	clitest.SetupConfig(t, client, conf)
+	out.Reset()
+	inv.Stdout = &out
+	err = inv.Run()
+	require.NoError(t, err)
+
+	proxies, err := client.WorkspaceProxies(ctx)
+	require.NoError(t, err)
+	require.Len(t, proxies, 0)
+}
@@ -0,0 +1,203 @@
+package coderd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	agplaudit "github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/rbac"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/audit"
+)
+
+// PubsubEventAuditFilterRules is published when the audit filter rules are
+// updated, so every replica reloads them.
+const PubsubEventAuditFilterRules = "audit_filter_rules"
+
+func (api *API) auditLogEnabledMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		api.entitlementsMu.RLock()
+		enabled := api.entitlements.Features[codersdk.FeatureAuditLog].Enabled
+		api.entitlementsMu.RUnlock()
+
+		if !enabled {
+			httpapi.Write(r.Context(), rw, http.StatusForbidden, codersdk.Response{
+				Message: "Audit logging is an Enterprise feature. Contact sales!",
+			})
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// @Summary Get audit filter rules
+// @ID get-audit-filter-rules
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Success 200 {object} codersdk.AuditFilterRules
+// @Router /audit/filter-rules [get]
+func (api *API) auditFilterRules(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	rules, err := getAuditFilterRules(ctx, api.Database)
+	if dbauthz.IsNotAuthorizedError(err) {
+		httpapi.Forbidden(rw)
+		return
+	}
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch audit filter rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.AuditFilterRules{
+		Rules: rules,
+	})
+}
+
+// @Summary Update audit filter rules
+// @ID update-audit-filter-rules
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param request body codersdk.AuditFilterRules true "Audit filter rules"
+// @Success 200 {object} codersdk.AuditFilterRules
+// @Router /audit/filter-rules [put]
+func (api *API) putAuditFilterRules(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = api.AGPL.Auditor.Load()
+		aReq, commitAudit = agplaudit.InitRequest[database.AuditFilterRules](rw, &agplaudit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionWrite,
+		})
+	)
+	defer commitAudit()
+
+	if !api.Authorize(r, rbac.ActionUpdate, rbac.ResourceDeploymentValues) {
+		httpapi.Write(ctx, rw, http.StatusForbidden, codersdk.Response{
+			Message: "Insufficient permissions to update audit filter rules.",
+		})
+		return
+	}
+
+	var req codersdk.AuditFilterRules
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.Rules == nil {
+		req.Rules = []codersdk.AuditFilterRule{}
+	}
+	for i, rule := range req.Rules {
+		err := audit.ValidateFilterRule(rule)
+		if err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: fmt.Sprintf("Invalid audit filter rule at index %d.", i),
+				Detail:  err.Error(),
+			})
+			return
+		}
+	}
+
+	oldRules, err := getAuditFilterRules(ctx, api.Database)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to fetch audit filter rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	oldData, err := json.Marshal(oldRules)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to marshal audit filter rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	aReq.Old = database.AuditFilterRules{ID: database.AuditFilterRulesID, Rules: string(oldData)}
+
+	data, err := json.Marshal(req.Rules)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to marshal audit filter rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+	err = api.Database.InsertOrUpdateAuditFilterRules(ctx, string(data))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusInternalServerError, codersdk.Response{
+			Message: "Failed to update audit filter rules.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	aReq.New = database.AuditFilterRules{ID: database.AuditFilterRulesID, Rules: string(data)}
+
+	api.AuditFilter.SetRules(req.Rules)
+	err = api.Pubsub.Publish(PubsubEventAuditFilterRules, []byte("update"))
+	if err != nil {
+		api.Logger.Error(ctx, "failed to publish audit filter rules update", slog.Error(err))
+		// don't fail the HTTP request, since we did write the rules to the DB
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, req)
+}
+
+// reloadAuditFilterRules reads the audit filter rules from the database into
+// the filter used by the auditor.
+func (api *API) reloadAuditFilterRules(ctx context.Context) error {
+	//nolint:gocritic // The auditor filters audit logs on behalf of the system.
+	rules, err := getAuditFilterRules(dbauthz.AsSystemRestricted(ctx), api.Database)
+	if err != nil {
+		return err
+	}
+	api.AuditFilter.SetRules(rules)
+	return nil
+}
+
+// subscribeAuditFilterRules reloads the audit filter rules whenever another
+// replica updates them.
+func (api *API) subscribeAuditFilterRules(ctx context.Context) (func(), error) {
+	return api.Pubsub.Subscribe(PubsubEventAuditFilterRules, func(_ context.Context, _ []byte) {
+		err := api.reloadAuditFilterRules(ctx)
+		if err != nil {
+			api.Logger.Warn(ctx, "failed to reload audit filter rules", slog.Error(err))
+		}
+	})
+}
+
+func getAuditFilterRules(ctx context.Context, db database.Store) ([]codersdk.AuditFilterRule, error) {
+	raw, err := db.GetAuditFilterRules(ctx)
+	if errors.Is(err, sql.ErrNoRows) {
+		return []codersdk.AuditFilterRule{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var rules []codersdk.AuditFilterRule
+	err = json.Unmarshal([]byte(raw), &rules)
+	if err != nil {
+		return nil, xerrors.Errorf("unmarshal audit filter rules: %w", err)
+	}
+	return rules, nil
+}
@@ -0,0 +1,160 @@
+package coderd_test
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/testutil"
+)
+
+func TestAuditFilterRules(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NotEntitled", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, &coderdenttest.Options{AuditLogging: true})
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, err := client.AuditFilterRules(ctx)
+		var sdkError *codersdk.Error
+		require.True(t, errors.As(err, &sdkError))
+		require.Equal(t, http.StatusForbidden, sdkError.StatusCode())
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		t.Parallel()
+
+		client, _, api := coderdenttest.NewWithAPI(t, &coderdenttest.Options{AuditLogging: true})
+		user := coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAuditLog: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		rules, err := client.AuditFilterRules(ctx)
+		require.NoError(t, err)
+		require.Empty(t, rules.Rules)
+
+		want := codersdk.AuditFilterRules{
+			Rules: []codersdk.AuditFilterRule{{
+				ResourceTypes: []codersdk.ResourceType{codersdk.ResourceTypeWorkspaceBuild},
+				BuildReasons:  []codersdk.BuildReason{codersdk.BuildReasonAutostart},
+				Export:        true,
+			}},
+		}
+
+		memberClient, _ := coderdtest.CreateAnotherUser(t, client, user.OrganizationID)
+		_, err = memberClient.UpdateAuditFilterRules(ctx, want)
+		var sdkError *codersdk.Error
+		require.True(t, errors.As(err, &sdkError))
+		require.Equal(t, http.StatusForbidden, sdkError.StatusCode())
+
+		got, err := client.UpdateAuditFilterRules(ctx, want)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+		require.Equal(t, want.Rules, api.AuditFilter.Rules())
+
+		got, err = client.AuditFilterRules(ctx)
+		require.NoError(t, err)
+		require.Equal(t, want, got)
+	})
+
+	t.Run("Audit", func(t *testing.T) {
+		t.Parallel()
+
+		auditor := audit.NewMock()
+		client := coderdenttest.New(t, &coderdenttest.Options{
+			AuditLogging: true,
+			Options: &coderdtest.Options{
+				Auditor: auditor,
+			},
+		})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAuditLog: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		numLogs := len(auditor.Logs())
+		_, err := client.UpdateAuditFilterRules(ctx, codersdk.AuditFilterRules{
+			Rules: []codersdk.AuditFilterRule{{Store: true}},
+		})
+		require.NoError(t, err)
+		_, err = client.UpdateAuditFilterRules(ctx, codersdk.AuditFilterRules{
+			Rules: []codersdk.AuditFilterRule{{Store: false}},
+		})
+		require.NoError(t, err)
+		logs := auditor.Logs()
+		require.Len(t, logs, numLogs+2)
+		// Every change is logged against the same resource.
+		for _, alog := range logs[numLogs:] {
+			require.Equal(t, database.ResourceTypeAuditFilterRules, alog.ResourceType)
+			require.Equal(t, database.AuditActionWrite, alog.Action)
+			require.Equal(t, database.AuditFilterRulesID, alog.ResourceID)
+		}
+	})
+
+	t.Run("Invalid", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, &coderdenttest.Options{AuditLogging: true})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAuditLog: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, err := client.UpdateAuditFilterRules(ctx, codersdk.AuditFilterRules{
+			Rules: []codersdk.AuditFilterRule{{
+				Actions: []codersdk.AuditAction{"explode"},
+			}},
+		})
+		var sdkError *codersdk.Error
+		require.True(t, errors.As(err, &sdkError))
+		require.Equal(t, http.StatusBadRequest, sdkError.StatusCode())
+	})
+
+	t.Run("Reload", func(t *testing.T) {
+		t.Parallel()
+
+		client, _, api := coderdenttest.NewWithAPI(t, &coderdenttest.Options{AuditLogging: true})
+		_ = coderdtest.CreateFirstUser(t, client)
+		coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAuditLog: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		want := []codersdk.AuditFilterRule{{Store: true}}
+		_, err := client.UpdateAuditFilterRules(ctx, codersdk.AuditFilterRules{Rules: want})
+		require.NoError(t, err)
+
+		// Simulate another replica having updated the rules.
+		api.AuditFilter.SetRules(nil)
+		err = api.Pubsub.Publish(coderd.PubsubEventAuditFilterRules, []byte("update"))
+		require.NoError(t, err)
+		require.Eventually(t, func() bool {
+			return len(api.AuditFilter.Rules()) == 1
+		}, testutil.WaitShort, testutil.IntervalFast)
+		require.Equal(t, want, api.AuditFilter.Rules())
+	})
+}
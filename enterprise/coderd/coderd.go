@@ -6,6 +6,7 @@ import (
 	"crypto/tls"
 	"crypto/x509"
 	"net/http"
+	"net/url"
 	"sync"
 	"time"
 
@@ -23,7 +24,9 @@ import (
 	"github.com/coder/coder/coderd/rbac"
 	"github.com/coder/coder/coderd/schedule"
 	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/audit"
 	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/enterprise/coderd/proxyhealth"
 	"github.com/coder/coder/enterprise/derpmesh"
 	"github.com/coder/coder/enterprise/replicasync"
 	"github.com/coder/coder/enterprise/tailnet"
@@ -50,6 +53,9 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	if options.Options.Authorizer == nil {
 		options.Options.Authorizer = rbac.NewCachingAuthorizer(options.PrometheusRegistry)
 	}
+	if options.AuditFilter == nil {
+		options.AuditFilter = audit.NewRuleFilter(nil)
+	}
 	ctx, cancelFunc := context.WithCancel(ctx)
 	api := &API{
 		AGPL:                   coderd.New(options.Options),
@@ -134,6 +140,14 @@ func New(ctx context.Context, options *Options) (*API, error) {
 				r.Get("/", api.workspaceQuota)
 			})
 		})
+		r.Route("/audit/filter-rules", func(r chi.Router) {
+			r.Use(
+				apiKeyMiddleware,
+				api.auditLogEnabledMW,
+			)
+			r.Get("/", api.auditFilterRules)
+			r.Put("/", api.putAuditFilterRules)
+		})
 		r.Route("/appearance", func(r chi.Router) {
 			r.Use(
 				apiKeyMiddleware,
@@ -141,6 +155,34 @@ func New(ctx context.Context, options *Options) (*API, error) {
 			r.Get("/", api.appearance)
 			r.Put("/", api.putAppearance)
 		})
+		r.Route("/workspaceproxies", func(r chi.Router) {
+			r.Use(
+				api.workspaceProxiesEnabledMW,
+			)
+			r.Route("/me", func(r chi.Router) {
+				r.Use(
+					httpmw.ExtractWorkspaceProxy(httpmw.ExtractWorkspaceProxyConfig{
+						DB:       options.Database,
+						Optional: false,
+					}),
+				)
+				r.Post("/register", api.workspaceProxyRegister)
+				r.Post("/issue-ticket", api.workspaceProxyIssueSignedAppTicket)
+				r.Post("/exchange-api-key", api.workspaceProxyExchangeAPIKey)
+			})
+			r.Group(func(r chi.Router) {
+				r.Use(apiKeyMiddleware)
+				r.Post("/", api.postWorkspaceProxy)
+				r.Get("/", api.workspaceProxies)
+				r.Route("/{workspaceproxy}", func(r chi.Router) {
+					r.Use(
+						httpmw.ExtractWorkspaceProxyParam(api.Database),
+					)
+					r.Get("/", api.workspaceProxy)
+					r.Delete("/", api.deleteWorkspaceProxy)
+				})
+			})
+		})
 	})
 
 	if len(options.SCIMAPIKey) != 0 {
@@ -192,6 +234,25 @@ func New(ctx context.Context, options *Options) (*API, error) {
 	}
 	api.derpMesh = derpmesh.New(options.Logger.Named("derpmesh"), api.DERPServer, meshTLSConfig)
 
+	api.ProxyHealth, err = proxyhealth.New(&proxyhealth.Options{
+		Interval: options.ProxyHealthInterval,
+		DB:       api.Database,
+		Logger:   options.Logger.Named("proxyhealth"),
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("initialize proxy health: %w", err)
+	}
+	go api.ProxyHealth.Run(ctx)
+
+	err = api.reloadAuditFilterRules(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("load audit filter rules: %w", err)
+	}
+	api.cancelAuditFilterRules, err = api.subscribeAuditFilterRules(ctx)
+	if err != nil {
+		return nil, xerrors.Errorf("subscribe to audit filter rules: %w", err)
+	}
+
 	err = api.updateEntitlements(ctx)
 	if err != nil {
 		return nil, xerrors.Errorf("update entitlements: %w", err)
@@ -215,7 +276,11 @@ type Options struct {
 	DERPServerRegionID     int
 
 	EntitlementsUpdateInterval time.Duration
+	ProxyHealthInterval        time.Duration
 	Keys                       map[string]ed25519.PublicKey
+	// AuditFilter decides which audit logs are stored and exported. It's
+	// updated when admins change the audit filter rules.
+	AuditFilter *audit.RuleFilter
 }
 
 type API struct {
@@ -226,14 +291,20 @@ type API struct {
 	replicaManager *replicasync.Manager
 	// Meshes DERP connections from multiple replicas.
 	derpMesh *derpmesh.Mesh
+	// ProxyHealth checks the reachability of all workspace proxies.
+	ProxyHealth *proxyhealth.ProxyHealth
 
 	cancelEntitlementsLoop func()
+	cancelAuditFilterRules func()
 	entitlementsMu         sync.RWMutex
 	entitlements           codersdk.Entitlements
 }
 
 func (api *API) Close() error {
 	api.cancelEntitlementsLoop()
+	if api.cancelAuditFilterRules != nil {
+		api.cancelAuditFilterRules()
+	}
 	_ = api.replicaManager.Close()
 	_ = api.derpMesh.Close()
 	return api.AGPL.Close()
@@ -254,6 +325,7 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 			codersdk.FeatureTemplateRBAC:               api.RBAC,
 			codersdk.FeatureExternalProvisionerDaemons: true,
 			codersdk.FeatureAdvancedTemplateScheduling: true,
+			codersdk.FeatureWorkspaceProxy:             true,
 		})
 	if err != nil {
 		return err
@@ -321,6 +393,19 @@ func (api *API) updateEntitlements(ctx context.Context) error {
 		}
 	}
 
+	if changed, enabled := featureChanged(codersdk.FeatureWorkspaceProxy); changed {
+		var (
+			fetchRegions func(ctx context.Context) ([]codersdk.Region, error)
+			proxyForHost func(ctx context.Context, host string) (*url.URL, bool, error)
+		)
+		if enabled {
+			fetchRegions = api.fetchRegions
+			proxyForHost = api.workspaceProxyForHost
+		}
+		api.AGPL.RegionsFetcher.Store(&fetchRegions)
+		api.AGPL.WorkspaceProxyForHost.Store(&proxyForHost)
+	}
+
 	if changed, enabled := featureChanged(codersdk.FeatureHighAvailability); changed {
 		coordinator := agpltailnet.NewCoordinator()
 		if enabled {
@@ -76,7 +76,7 @@ func NewWithAPI(t *testing.T, options *Options) (*codersdk.Client, io.Closer, *c
 		Keys:                       Keys,
 	})
 	assert.NoError(t, err)
-	setHandler(coderAPI.AGPL.RootHandler)
+	setHandler(coderAPI.AGPL)
 	var provisionerCloser io.Closer = nopcloser{}
 	if options.IncludeProvisionerDaemon {
 		provisionerCloser = coderdtest.NewProvisionerDaemon(t, coderAPI.AGPL)
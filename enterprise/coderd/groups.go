@@ -427,7 +427,11 @@ func convertUser(user database.User, organizationIDs []uuid.UUID) codersdk.User
 	}
 
 	for _, roleName := range user.RBACRoles {
-		rbacRole, _ := rbac.RoleByName(roleName)
+		rbacRole, err := rbac.RoleByName(roleName)
+		if err != nil {
+			// Custom roles aren't built in, so only their name is known.
+			rbacRole = rbac.Role{Name: roleName, DisplayName: roleName}
+		}
 		convertedUser.Roles = append(convertedUser.Roles, convertRole(rbacRole))
 	}
 
@@ -137,6 +137,11 @@ func Entitlements(
 			if featureName == codersdk.FeatureMultipleGitAuth {
 				continue
 			}
+			// Workspace proxies can't be used without the entitlement, so
+			// there's nothing to warn about.
+			if featureName == codersdk.FeatureWorkspaceProxy {
+				continue
+			}
 			feature := entitlements.Features[featureName]
 			if !feature.Enabled {
 				continue
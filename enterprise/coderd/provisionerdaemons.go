@@ -322,15 +322,21 @@ func (*enterpriseTemplateScheduleStore) GetTemplateScheduleOptions(ctx context.C
 		UserSchedulingEnabled: true,
 		DefaultTTL:            time.Duration(tpl.DefaultTTL),
 		MaxTTL:                time.Duration(tpl.MaxTTL),
+		InactivityTTL:         time.Duration(tpl.InactivityTTL),
+		LockedTTL:             time.Duration(tpl.LockedTTL),
+		FailureTTL:            time.Duration(tpl.FailureTTL),
 	}, nil
 }
 
 func (*enterpriseTemplateScheduleStore) SetTemplateScheduleOptions(ctx context.Context, db database.Store, tpl database.Template, opts schedule.TemplateScheduleOptions) (database.Template, error) {
 	template, err := db.UpdateTemplateScheduleByID(ctx, database.UpdateTemplateScheduleByIDParams{
-		ID:         tpl.ID,
-		UpdatedAt:  database.Now(),
-		DefaultTTL: int64(opts.DefaultTTL),
-		MaxTTL:     int64(opts.MaxTTL),
+		ID:            tpl.ID,
+		UpdatedAt:     database.Now(),
+		DefaultTTL:    int64(opts.DefaultTTL),
+		MaxTTL:        int64(opts.MaxTTL),
+		InactivityTTL: int64(opts.InactivityTTL),
+		LockedTTL:     int64(opts.LockedTTL),
+		FailureTTL:    int64(opts.FailureTTL),
 	})
 	if err != nil {
 		return database.Template{}, xerrors.Errorf("update template schedule: %w", err)
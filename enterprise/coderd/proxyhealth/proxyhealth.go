@@ -0,0 +1,188 @@
+package proxyhealth
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/codersdk"
+)
+
+type Options struct {
+	// Interval is the interval at which the proxy health is checked.
+	Interval time.Duration
+	DB       database.Store
+	Logger   slog.Logger
+	// Client is used to make the health check requests. If not set, a client
+	// with a short timeout is used.
+	Client *http.Client
+}
+
+// ProxyHealth periodically checks the health of all registered workspace
+// proxies and caches the results. The results are used by the API to report
+// the status of each proxy without making requests on the hot path.
+type ProxyHealth struct {
+	db       database.Store
+	interval time.Duration
+	logger   slog.Logger
+	client   *http.Client
+
+	cache *atomic.Pointer[map[uuid.UUID]ProxyStatus]
+}
+
+func New(opts *Options) (*ProxyHealth, error) {
+	if opts.Interval <= 0 {
+		opts.Interval = time.Minute
+	}
+	if opts.DB == nil {
+		return nil, xerrors.Errorf("db is required")
+	}
+
+	client := opts.Client
+	if client == nil {
+		client = &http.Client{
+			Timeout: 5 * time.Second,
+		}
+	}
+
+	return &ProxyHealth{
+		db:       opts.DB,
+		interval: opts.Interval,
+		logger:   opts.Logger,
+		client:   client,
+		cache:    &atomic.Pointer[map[uuid.UUID]ProxyStatus]{},
+	}, nil
+}
+
+// Run will block until the context is canceled. It checks the health of all
+// proxies immediately and then periodically, storing the results in the
+// cache.
+func (p *ProxyHealth) Run(ctx context.Context) {
+	ticker := time.NewTicker(p.interval)
+	defer ticker.Stop()
+
+	for {
+		err := p.ForceUpdate(ctx)
+		if err != nil && ctx.Err() == nil {
+			p.logger.Error(ctx, "proxy health check failed", slog.Error(err))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// ForceUpdate checks the health of all proxies immediately and updates the
+// cache.
+func (p *ProxyHealth) ForceUpdate(ctx context.Context) error {
+	statuses, err := p.runOnce(ctx, time.Now())
+	if err != nil {
+		return err
+	}
+
+	// Always swap the whole map so readers never see a partial update.
+	p.cache.Store(&statuses)
+	return nil
+}
+
+// HealthStatus returns the current health status of all proxies stored in the
+// cache.
+func (p *ProxyHealth) HealthStatus() map[uuid.UUID]ProxyStatus {
+	ptr := p.cache.Load()
+	if ptr == nil {
+		return map[uuid.UUID]ProxyStatus{}
+	}
+	return *ptr
+}
+
+type ProxyStatus struct {
+	// ProxyStatus includes the value of the proxy at the time of checking.
+	// This is useful to know as it helps determine if the proxy checked has
+	// different values than the proxy in hand, e.g. the URL changed since
+	// the last check.
+	Proxy database.WorkspaceProxy
+	// CheckedAt is when the proxy was last checked.
+	CheckedAt time.Time
+	Status    codersdk.ProxyHealthStatus
+	// Error is the reason the proxy is not reachable or healthy, if any.
+	Error string
+}
+
+// runOnce checks the health of all proxies concurrently and returns the
+// results.
+func (p *ProxyHealth) runOnce(ctx context.Context, now time.Time) (map[uuid.UUID]ProxyStatus, error) {
+	//nolint:gocritic // The health checker is a system process.
+	proxies, err := p.db.GetWorkspaceProxies(dbauthz.AsSystemRestricted(ctx))
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace proxies: %w", err)
+	}
+
+	var (
+		mu       sync.Mutex
+		wg       sync.WaitGroup
+		statuses = make(map[uuid.UUID]ProxyStatus, len(proxies))
+	)
+	for _, proxy := range proxies {
+		proxy := proxy
+		if proxy.Url == "" {
+			// Proxies that have not registered yet have no URL to check.
+			statuses[proxy.ID] = ProxyStatus{
+				Proxy:     proxy,
+				CheckedAt: now,
+				Status:    codersdk.ProxyUnregistered,
+			}
+			continue
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			status := ProxyStatus{
+				Proxy:     proxy,
+				CheckedAt: now,
+			}
+			status.Status, status.Error = p.checkProxy(ctx, proxy)
+
+			mu.Lock()
+			defer mu.Unlock()
+			statuses[proxy.ID] = status
+		}()
+	}
+	wg.Wait()
+
+	return statuses, nil
+}
+
+func (p *ProxyHealth) checkProxy(ctx context.Context, proxy database.WorkspaceProxy) (codersdk.ProxyHealthStatus, string) {
+	u, err := url.Parse(proxy.Url)
+	if err != nil {
+		return codersdk.ProxyUnhealthy, fmt.Sprintf("invalid proxy URL %q: %s", proxy.Url, err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.JoinPath("/healthz").String(), nil)
+	if err != nil {
+		return codersdk.ProxyUnhealthy, fmt.Sprintf("create request: %s", err)
+	}
+
+	res, err := p.client.Do(req)
+	if err != nil {
+		return codersdk.ProxyUnreachable, err.Error()
+	}
+	_ = res.Body.Close()
+	if res.StatusCode != http.StatusOK {
+		return codersdk.ProxyUnhealthy, fmt.Sprintf("unexpected status code %d from health check", res.StatusCode)
+	}
+	return codersdk.ProxyReachable, ""
+}
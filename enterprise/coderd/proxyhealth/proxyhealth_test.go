@@ -0,0 +1,143 @@
+package proxyhealth_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbfake"
+	"github.com/coder/coder/coderd/database/dbgen"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/proxyhealth"
+	"github.com/coder/coder/testutil"
+)
+
+func insertProxy(t *testing.T, db database.Store, url string) database.WorkspaceProxy {
+	t.Helper()
+
+	proxy, _ := dbgen.WorkspaceProxy(t, db, database.WorkspaceProxy{})
+	if url == "" {
+		return proxy
+	}
+	proxy, err := db.RegisterWorkspaceProxy(context.Background(), database.RegisterWorkspaceProxyParams{
+		ID:               proxy.ID,
+		Url:              url,
+		WildcardHostname: "",
+	})
+	require.NoError(t, err, "register proxy")
+	return proxy
+}
+
+func TestProxyHealth_Unregistered(t *testing.T) {
+	t.Parallel()
+	db := dbfake.New()
+
+	proxy := insertProxy(t, db, "")
+
+	ph, err := proxyhealth.New(&proxyhealth.Options{
+		DB:     db,
+		Logger: slogtest.Make(t, nil),
+	})
+	require.NoError(t, err, "create proxy health")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+	err = ph.ForceUpdate(ctx)
+	require.NoError(t, err, "force update")
+
+	statuses := ph.HealthStatus()
+	require.Len(t, statuses, 1)
+	require.Equal(t, codersdk.ProxyUnregistered, statuses[proxy.ID].Status)
+}
+
+func TestProxyHealth_Reachable(t *testing.T) {
+	t.Parallel()
+	db := dbfake.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/healthz" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	proxy := insertProxy(t, db, srv.URL)
+
+	ph, err := proxyhealth.New(&proxyhealth.Options{
+		DB:     db,
+		Logger: slogtest.Make(t, nil),
+		Client: srv.Client(),
+	})
+	require.NoError(t, err, "create proxy health")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+	err = ph.ForceUpdate(ctx)
+	require.NoError(t, err, "force update")
+
+	status := ph.HealthStatus()[proxy.ID]
+	require.Equal(t, codersdk.ProxyReachable, status.Status)
+	require.Empty(t, status.Error)
+}
+
+func TestProxyHealth_Unhealthy(t *testing.T) {
+	t.Parallel()
+	db := dbfake.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer srv.Close()
+
+	proxy := insertProxy(t, db, srv.URL)
+
+	ph, err := proxyhealth.New(&proxyhealth.Options{
+		DB:     db,
+		Logger: slogtest.Make(t, nil),
+		Client: srv.Client(),
+	})
+	require.NoError(t, err, "create proxy health")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+	err = ph.ForceUpdate(ctx)
+	require.NoError(t, err, "force update")
+
+	status := ph.HealthStatus()[proxy.ID]
+	require.Equal(t, codersdk.ProxyUnhealthy, status.Status)
+	require.NotEmpty(t, status.Error)
+}
+
+func TestProxyHealth_Unreachable(t *testing.T) {
+	t.Parallel()
+	db := dbfake.New()
+
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	srvURL := srv.URL
+	// Close the server so the proxy can't be reached.
+	srv.Close()
+
+	proxy := insertProxy(t, db, srvURL)
+
+	ph, err := proxyhealth.New(&proxyhealth.Options{
+		DB:     db,
+		Logger: slogtest.Make(t, nil),
+	})
+	require.NoError(t, err, "create proxy health")
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitShort)
+	defer cancel()
+	err = ph.ForceUpdate(ctx)
+	require.NoError(t, err, "force update")
+
+	status := ph.HealthStatus()[proxy.ID]
+	require.Equal(t, codersdk.ProxyUnreachable, status.Status)
+	require.NotEmpty(t, status.Error)
+}
@@ -93,6 +93,39 @@ func TestTemplates(t *testing.T) {
 		require.Nil(t, workspace3.TTLMillis)
 	})
 
+	t.Run("SetFailureTTL", func(t *testing.T) {
+		t.Parallel()
+
+		client := coderdenttest.New(t, nil)
+		user := coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureAdvancedTemplateScheduling: 1,
+			},
+		})
+
+		version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, nil)
+		template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+		require.EqualValues(t, 0, template.FailureTTLMillis)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		updated, err := client.UpdateTemplateMeta(ctx, template.ID, codersdk.UpdateTemplateMeta{
+			Name:                         template.Name,
+			DisplayName:                  template.DisplayName,
+			Description:                  template.Description,
+			Icon:                         template.Icon,
+			AllowUserCancelWorkspaceJobs: template.AllowUserCancelWorkspaceJobs,
+			DefaultTTLMillis:             template.DefaultTTLMillis,
+			FailureTTLMillis:             time.Hour.Milliseconds(),
+		})
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, time.Duration(updated.FailureTTLMillis)*time.Millisecond)
+
+		template, err = client.Template(ctx, template.ID)
+		require.NoError(t, err)
+		require.Equal(t, time.Hour, time.Duration(template.FailureTTLMillis)*time.Millisecond)
+	})
+
 	t.Run("CreateUpdateWorkspaceMaxTTL", func(t *testing.T) {
 		t.Parallel()
 		client := coderdenttest.New(t, &coderdenttest.Options{
@@ -0,0 +1,450 @@
+package coderd
+
+import (
+	"context"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/audit"
+	"github.com/coder/coder/coderd/database"
+	"github.com/coder/coder/coderd/database/dbauthz"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/cryptorand"
+	"github.com/coder/coder/enterprise/coderd/proxyhealth"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+)
+
+func (api *API) workspaceProxiesEnabledMW(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+		api.entitlementsMu.RLock()
+		enabled := api.entitlements.Features[codersdk.FeatureWorkspaceProxy].Enabled
+		api.entitlementsMu.RUnlock()
+
+		if !enabled {
+			httpapi.Write(r.Context(), rw, http.StatusForbidden, codersdk.Response{
+				Message: "Workspace proxies is an Enterprise feature. Contact sales!",
+			})
+			return
+		}
+
+		next.ServeHTTP(rw, r)
+	})
+}
+
+// @Summary Create workspace proxy
+// @ID create-workspace-proxy
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param request body codersdk.CreateWorkspaceProxyRequest true "Create workspace proxy request"
+// @Success 201 {object} codersdk.CreateWorkspaceProxyResponse
+// @Router /workspaceproxies [post]
+func (api *API) postWorkspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		auditor           = api.AGPL.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceProxy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionCreate,
+		})
+	)
+	defer commitAudit()
+
+	var req codersdk.CreateWorkspaceProxyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	if req.DisplayName == "" {
+		req.DisplayName = req.Name
+	}
+
+	id := uuid.New()
+	secret, err := cryptorand.HexString(64)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	hashedSecret := sha256.Sum256([]byte(secret))
+	fullToken := fmt.Sprintf("%s:%s", id, secret)
+
+	proxy, err := api.Database.InsertWorkspaceProxy(ctx, database.InsertWorkspaceProxyParams{
+		ID:                id,
+		Name:              req.Name,
+		DisplayName:       req.DisplayName,
+		Icon:              req.Icon,
+		TokenHashedSecret: hashedSecret[:],
+		CreatedAt:         database.Now(),
+		UpdatedAt:         database.Now(),
+	})
+	if database.IsUniqueViolation(err) {
+		httpapi.Write(ctx, rw, http.StatusConflict, codersdk.Response{
+			Message: fmt.Sprintf("Workspace proxy with name %q already exists.", req.Name),
+		})
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	aReq.New = proxy
+	httpapi.Write(ctx, rw, http.StatusCreated, codersdk.CreateWorkspaceProxyResponse{
+		Proxy: convertProxy(proxy, api.proxyHealthStatus(proxy)),
+		// Return the full token so the user can use it to start the proxy.
+		ProxyToken: fullToken,
+	})
+}
+
+// @Summary Get workspace proxies
+// @ID get-workspace-proxies
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Success 200 {array} codersdk.WorkspaceProxy
+// @Router /workspaceproxies [get]
+func (api *API) workspaceProxies(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	proxies, err := api.Database.GetWorkspaceProxies(ctx)
+	if err != nil && !xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	converted := make([]codersdk.WorkspaceProxy, 0, len(proxies))
+	for _, proxy := range proxies {
+		converted = append(converted, convertProxy(proxy, api.proxyHealthStatus(proxy)))
+	}
+	httpapi.Write(ctx, rw, http.StatusOK, converted)
+}
+
+// @Summary Get workspace proxy
+// @ID get-workspace-proxy
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param workspaceproxy path string true "Proxy ID or name" format(uuid)
+// @Success 200 {object} codersdk.WorkspaceProxy
+// @Router /workspaceproxies/{workspaceproxy} [get]
+func (api *API) workspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		proxy = httpmw.WorkspaceProxyParam(r)
+	)
+
+	httpapi.Write(ctx, rw, http.StatusOK, convertProxy(proxy, api.proxyHealthStatus(proxy)))
+}
+
+// @Summary Delete workspace proxy
+// @ID delete-workspace-proxy
+// @Security CoderSessionToken
+// @Produce json
+// @Tags Enterprise
+// @Param workspaceproxy path string true "Proxy ID or name" format(uuid)
+// @Success 200 {object} codersdk.Response
+// @Router /workspaceproxies/{workspaceproxy} [delete]
+func (api *API) deleteWorkspaceProxy(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx               = r.Context()
+		proxy             = httpmw.WorkspaceProxyParam(r)
+		auditor           = api.AGPL.Auditor.Load()
+		aReq, commitAudit = audit.InitRequest[database.WorkspaceProxy](rw, &audit.RequestParams{
+			Audit:   *auditor,
+			Log:     api.Logger,
+			Request: r,
+			Action:  database.AuditActionDelete,
+		})
+	)
+	aReq.Old = proxy
+	defer commitAudit()
+
+	err := api.Database.UpdateWorkspaceProxyDeleted(ctx, database.UpdateWorkspaceProxyDeletedParams{
+		ID:      proxy.ID,
+		Deleted: true,
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, codersdk.Response{
+		Message: "Proxy has been deleted!",
+	})
+}
+
+// @Summary Register workspace proxy
+// @ID register-workspace-proxy
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param request body wsproxysdk.RegisterWorkspaceProxyRequest true "Register workspace proxy request"
+// @Success 201 {object} wsproxysdk.RegisterWorkspaceProxyResponse
+// @Router /workspaceproxies/me/register [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceProxyRegister(rw http.ResponseWriter, r *http.Request) {
+	var (
+		ctx   = r.Context()
+		proxy = httpmw.WorkspaceProxy(r)
+	)
+
+	var req wsproxysdk.RegisterWorkspaceProxyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	accessURL, err := url.Parse(req.AccessURL)
+	if err != nil || accessURL.Host == "" || (accessURL.Scheme != "http" && accessURL.Scheme != "https") {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Access URL must be an absolute http or https URL.",
+			Validations: []codersdk.ValidationError{
+				{Field: "access_url", Detail: fmt.Sprintf("invalid URL %q", req.AccessURL)},
+			},
+		})
+		return
+	}
+	if req.WildcardHostname != "" {
+		if _, err := httpapi.CompileHostnamePattern(req.WildcardHostname); err != nil {
+			httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+				Message: "Wildcard hostname must be a valid hostname pattern.",
+				Validations: []codersdk.ValidationError{
+					{Field: "wildcard_hostname", Detail: err.Error()},
+				},
+			})
+			return
+		}
+	}
+
+	_, err = api.Database.RegisterWorkspaceProxy(ctx, database.RegisterWorkspaceProxyParams{
+		ID:               proxy.ID,
+		Url:              req.AccessURL,
+		WildcardHostname: req.WildcardHostname,
+	})
+	if xerrors.Is(err, sql.ErrNoRows) {
+		httpapi.ResourceNotFound(rw)
+		return
+	}
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, wsproxysdk.RegisterWorkspaceProxyResponse{
+		AppSecurityKey: hex.EncodeToString(api.AppSigningKey),
+	})
+}
+
+// workspaceProxyIssueSignedAppTicket issues a signed app ticket for a user
+// accessing an app through an external workspace proxy. The user's session
+// token is passed in the request body, and the request is resolved as if it
+// was made directly to the proxy URL. If the user is not authorized, the
+// error page or login redirect is returned to the proxy to be relayed to the
+// user.
+//
+// @Summary Issue signed workspace app ticket
+// @ID issue-signed-workspace-app-ticket
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param request body wsproxysdk.IssueSignedAppTicketRequest true "Issue signed app ticket request"
+// @Success 201 {object} wsproxysdk.IssueSignedAppTicketResponse
+// @Router /workspaceproxies/me/issue-ticket [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceProxyIssueSignedAppTicket(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req wsproxysdk.IssueSignedAppTicketRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+	requestURL, err := url.Parse(req.RequestURL)
+	if err != nil || requestURL.Host == "" {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Request URL must be an absolute URL.",
+			Validations: []codersdk.ValidationError{
+				{Field: "request_url", Detail: fmt.Sprintf("invalid URL %q", req.RequestURL)},
+			},
+		})
+		return
+	}
+
+	// Build a request that looks like the user's request to the proxy, so the
+	// ticket is resolved exactly like it would be on the primary.
+	userReq, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL.String(), nil)
+	if err != nil {
+		httpapi.InternalServerError(rw, err)
+		return
+	}
+	userReq.RemoteAddr = r.RemoteAddr
+	if req.SessionToken != "" {
+		userReq.Header.Set(codersdk.SessionTokenHeader, req.SessionToken)
+	}
+
+	_, ticketStr, ok := api.AGPL.WorkspaceAppsProvider.CreateTicket(rw, userReq, req.AppRequest)
+	if !ok {
+		// CreateTicket has already written the response.
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusCreated, wsproxysdk.IssueSignedAppTicketResponse{
+		SignedTicket: ticketStr,
+	})
+}
+
+// @Summary Exchange encrypted API key for workspace proxy
+// @ID exchange-encrypted-api-key-for-workspace-proxy
+// @Security CoderSessionToken
+// @Accept json
+// @Produce json
+// @Tags Enterprise
+// @Param request body wsproxysdk.ExchangeAPIKeyRequest true "Exchange API key request"
+// @Success 200 {object} wsproxysdk.ExchangeAPIKeyResponse
+// @Router /workspaceproxies/me/exchange-api-key [post]
+// @x-apidocgen {"skip": true}
+func (api *API) workspaceProxyExchangeAPIKey(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	var req wsproxysdk.ExchangeAPIKeyRequest
+	if !httpapi.Read(ctx, rw, r, &req) {
+		return
+	}
+
+	_, token, err := workspaceapps.DecryptAPIKey(ctx, api.Database, req.EncryptedAPIKey)
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Could not decrypt API key.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	httpapi.Write(ctx, rw, http.StatusOK, wsproxysdk.ExchangeAPIKeyResponse{
+		APIKey: token,
+	})
+}
+
+// fetchRegions returns a region for each registered workspace proxy. It is
+// stored in the AGPL API to extend the regions endpoint.
+func (api *API) fetchRegions(ctx context.Context) ([]codersdk.Region, error) {
+	//nolint:gocritic // Regions are visible to all authenticated users.
+	proxies, err := api.Database.GetWorkspaceProxies(dbauthz.AsSystemRestricted(ctx))
+	if err != nil {
+		return nil, xerrors.Errorf("get workspace proxies: %w", err)
+	}
+
+	regions := make([]codersdk.Region, 0, len(proxies))
+	for _, proxy := range proxies {
+		status := api.proxyHealthStatus(proxy)
+		regions = append(regions, codersdk.Region{
+			ID:               proxy.ID,
+			Name:             proxy.Name,
+			DisplayName:      proxy.DisplayName,
+			IconURL:          proxy.Icon,
+			Healthy:          status.Status == codersdk.ProxyReachable,
+			PathAppURL:       proxy.Url,
+			WildcardHostname: proxy.WildcardHostname,
+		})
+	}
+	return regions, nil
+}
+
+// workspaceProxyForHost returns the access URL of the workspace proxy that
+// serves the given host, either as its access URL or as a subdomain app on its
+// wildcard hostname.
+func (api *API) workspaceProxyForHost(ctx context.Context, host string) (*url.URL, bool, error) {
+	//nolint:gocritic // Only used to validate redirect URIs.
+	proxies, err := api.Database.GetWorkspaceProxies(dbauthz.AsSystemRestricted(ctx))
+	if err != nil {
+		return nil, false, xerrors.Errorf("get workspace proxies: %w", err)
+	}
+
+	for _, proxy := range proxies {
+		if proxy.Url == "" {
+			continue
+		}
+		proxyURL, err := url.Parse(proxy.Url)
+		if err != nil {
+			continue
+		}
+		if httpapi.HostnamesMatch(proxyURL.Host, host) {
+			return proxyURL, true, nil
+		}
+		if proxy.WildcardHostname == "" {
+			continue
+		}
+		pattern, err := httpapi.CompileHostnamePattern(proxy.WildcardHostname)
+		if err != nil {
+			continue
+		}
+		subdomain, ok := httpapi.ExecuteHostnamePattern(pattern, host)
+		if !ok {
+			continue
+		}
+		if _, err := httpapi.ParseSubdomainAppURL(subdomain); err == nil {
+			return proxyURL, true, nil
+		}
+	}
+	return nil, false, nil
+}
+
+// proxyHealthStatus returns the last known health status of the proxy.
+func (api *API) proxyHealthStatus(proxy database.WorkspaceProxy) codersdk.WorkspaceProxyStatus {
+	if proxy.Url == "" {
+		return codersdk.WorkspaceProxyStatus{
+			Status:    codersdk.ProxyUnregistered,
+			CheckedAt: database.Now(),
+		}
+	}
+
+	status, ok := api.ProxyHealth.HealthStatus()[proxy.ID]
+	// If the URL changed since the last check, the status is stale.
+	if !ok || status.Proxy.Url != proxy.Url {
+		return codersdk.WorkspaceProxyStatus{
+			Status:    codersdk.ProxyUnreachable,
+			Error:     "Proxy health has not been checked yet.",
+			CheckedAt: database.Now(),
+		}
+	}
+	return convertProxyStatus(status)
+}
+
+func convertProxyStatus(status proxyhealth.ProxyStatus) codersdk.WorkspaceProxyStatus {
+	return codersdk.WorkspaceProxyStatus{
+		Status:    status.Status,
+		Error:     status.Error,
+		CheckedAt: status.CheckedAt,
+	}
+}
+
+func convertProxy(p database.WorkspaceProxy, status codersdk.WorkspaceProxyStatus) codersdk.WorkspaceProxy {
+	return codersdk.WorkspaceProxy{
+		ID:               p.ID,
+		Name:             p.Name,
+		DisplayName:      p.DisplayName,
+		Icon:             p.Icon,
+		URL:              p.Url,
+		WildcardHostname: p.WildcardHostname,
+		CreatedAt:        p.CreatedAt,
+		UpdatedAt:        p.UpdatedAt,
+		Deleted:          p.Deleted,
+		Status:           status,
+	}
+}
@@ -0,0 +1,140 @@
+package coderd_test
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+	"github.com/coder/coder/testutil"
+)
+
+func TestWorkspaceProxyCRUD(t *testing.T) {
+	t.Parallel()
+
+	t.Run("NoLicense", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		_, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "proxy",
+		})
+		var sdkErr *codersdk.Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusForbidden, sdkErr.StatusCode())
+	})
+
+	t.Run("CreateListDelete", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureWorkspaceProxy: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		resp, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name:        "us-east",
+			DisplayName: "US East",
+			Icon:        "/emojis/flag.png",
+		})
+		require.NoError(t, err)
+		require.NotEmpty(t, resp.ProxyToken)
+		require.Equal(t, codersdk.ProxyUnregistered, resp.Proxy.Status.Status)
+
+		// Names are unique.
+		_, err = client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "us-east",
+		})
+		var sdkErr *codersdk.Error
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusConflict, sdkErr.StatusCode())
+
+		proxies, err := client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 1)
+		require.Equal(t, resp.Proxy.ID, proxies[0].ID)
+
+		proxy, err := client.WorkspaceProxyByName(ctx, "us-east")
+		require.NoError(t, err)
+		require.Equal(t, "US East", proxy.DisplayName)
+
+		// Unregistered proxies are listed as unhealthy regions.
+		regions, err := client.Regions(ctx)
+		require.NoError(t, err)
+		require.Len(t, regions, 2)
+		require.Equal(t, "primary", regions[0].Name)
+		require.False(t, regions[1].Healthy)
+
+		err = client.DeleteWorkspaceProxyByName(ctx, "us-east")
+		require.NoError(t, err)
+
+		proxies, err = client.WorkspaceProxies(ctx)
+		require.NoError(t, err)
+		require.Len(t, proxies, 0)
+
+		_, err = client.WorkspaceProxyByName(ctx, "us-east")
+		require.ErrorAs(t, err, &sdkErr)
+		require.Equal(t, http.StatusNotFound, sdkErr.StatusCode())
+	})
+
+	t.Run("Register", func(t *testing.T) {
+		t.Parallel()
+		client := coderdenttest.New(t, nil)
+		_ = coderdtest.CreateFirstUser(t, client)
+		_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+			Features: license.Features{
+				codersdk.FeatureWorkspaceProxy: 1,
+			},
+		})
+
+		ctx := testutil.Context(t, testutil.WaitLong)
+		resp, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+			Name: "eu",
+		})
+		require.NoError(t, err)
+
+		proxyClient := wsproxysdk.New(client.URL)
+		proxyClient.SDKClient.HTTPClient = client.HTTPClient
+
+		// An invalid token is rejected.
+		proxyClient.SetSessionToken(resp.Proxy.ID.String() + ":invalid")
+		_, err = proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL: "https://eu.example.com",
+		})
+		require.Error(t, err)
+
+		proxyClient.SetSessionToken(resp.ProxyToken)
+		_, err = proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL: "not a url",
+		})
+		require.Error(t, err)
+
+		registered, err := proxyClient.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL:        "https://eu.example.com",
+			WildcardHostname: "*.eu.example.com",
+		})
+		require.NoError(t, err)
+		require.Len(t, registered.AppSecurityKey, 128)
+
+		proxy, err := client.WorkspaceProxyByName(ctx, "eu")
+		require.NoError(t, err)
+		require.Equal(t, "https://eu.example.com", proxy.URL)
+		require.Equal(t, "*.eu.example.com", proxy.WildcardHostname)
+
+		// Users can't use proxy routes.
+		_, err = wsproxysdk.New(client.URL).RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+			AccessURL: "https://eu.example.com",
+		})
+		require.Error(t, err)
+	})
+}
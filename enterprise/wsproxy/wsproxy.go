@@ -0,0 +1,476 @@
+package wsproxy
+
+import (
+	"context"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/xerrors"
+
+	"cdr.dev/slog"
+	"github.com/coder/coder/coderd/httpapi"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/tracing"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/coderd/wsconncache"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/enterprise/wsproxy/wsproxysdk"
+	"github.com/coder/coder/site"
+)
+
+type Options struct {
+	Logger slog.Logger
+
+	// DashboardURL is the URL of the primary coderd instance.
+	DashboardURL *url.URL
+	// AccessURL is the URL of the workspace proxy.
+	AccessURL *url.URL
+
+	// AppHostname should be the wildcard hostname to use for workspace
+	// applications INCLUDING the asterisk, (optional) suffix and leading dot.
+	// It will use the same scheme and port number as the access URL.
+	// E.g. "*.apps.coder.com" or "*-apps.coder.com".
+	AppHostname string
+	// AppHostnameRegex contains the regex version of options.AppHostname as
+	// generated by httpapi.CompileHostnamePattern(). It MUST be set if
+	// options.AppHostname is set.
+	AppHostnameRegex *regexp.Regexp
+
+	RealIPConfig       *httpmw.RealIPConfig
+	Tracing            trace.TracerProvider
+	PrometheusRegistry *prometheus.Registry
+
+	APIRateLimit     int
+	SecureAuthCookie bool
+	DisablePathApps  bool
+
+	// ProxySessionToken is the token the proxy uses to authenticate with the
+	// primary deployment, as returned when the proxy was created.
+	ProxySessionToken string
+}
+
+func (o *Options) Validate() error {
+	if o.DashboardURL == nil {
+		return xerrors.New("dashboard URL is required")
+	}
+	if o.AccessURL == nil {
+		return xerrors.New("access URL is required")
+	}
+	if o.RealIPConfig == nil {
+		return xerrors.New("real IP config is required")
+	}
+	if o.ProxySessionToken == "" {
+		return xerrors.New("proxy session token is required")
+	}
+	if (o.AppHostname == "") != (o.AppHostnameRegex == nil) {
+		return xerrors.New("app hostname and app hostname regex must be set together")
+	}
+	return nil
+}
+
+// Server is an external workspace proxy server. It serves workspace app and
+// reconnecting PTY traffic using its own agent connections, and relies on the
+// primary deployment to authenticate and authorize users by issuing signed
+// tickets.
+type Server struct {
+	Options *Options
+	Handler chi.Router
+
+	DashboardURL *url.URL
+	AppServer    *workspaceapps.Server
+
+	// SDKClient is a client to the primary coderd instance authenticated with
+	// the proxy token.
+	SDKClient *wsproxysdk.Client
+	// TicketSigningKey is used to verify tickets issued by the primary
+	// deployment.
+	TicketSigningKey []byte
+
+	// Used for graceful shutdown. Required for the dialer.
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// New creates a new workspace proxy server and registers it with the primary
+// deployment. The caller must call Close on the returned server.
+func New(ctx context.Context, opts *Options) (*Server, error) {
+	if opts.PrometheusRegistry == nil {
+		opts.PrometheusRegistry = prometheus.NewRegistry()
+	}
+	if err := opts.Validate(); err != nil {
+		return nil, err
+	}
+
+	client := wsproxysdk.New(opts.DashboardURL)
+	client.SetSessionToken(opts.ProxySessionToken)
+
+	wildcardHostname := opts.AppHostname
+	if wildcardHostname != "" && opts.AccessURL.Port() != "" {
+		wildcardHostname += ":" + opts.AccessURL.Port()
+	}
+	resp, err := client.RegisterWorkspaceProxy(ctx, wsproxysdk.RegisterWorkspaceProxyRequest{
+		AccessURL:        opts.AccessURL.String(),
+		WildcardHostname: wildcardHostname,
+	})
+	if err != nil {
+		return nil, xerrors.Errorf("register proxy: %w", err)
+	}
+	signingKey, err := hex.DecodeString(resp.AppSecurityKey)
+	if err != nil {
+		return nil, xerrors.Errorf("decode app security key: %w", err)
+	}
+	if len(signingKey) != 64 {
+		return nil, xerrors.Errorf("app security key must be 64 bytes, got %d", len(signingKey))
+	}
+
+	r := chi.NewRouter()
+	ctx, cancel := context.WithCancel(context.Background())
+	s := &Server{
+		Options:          opts,
+		Handler:          r,
+		DashboardURL:     opts.DashboardURL,
+		SDKClient:        client,
+		TicketSigningKey: signingKey,
+		ctx:              ctx,
+		cancel:           cancel,
+	}
+	s.AppServer = &workspaceapps.Server{
+		Logger:             opts.Logger.Named("workspaceapps"),
+		DashboardURL:       opts.DashboardURL,
+		RealIPConfig:       opts.RealIPConfig,
+		WorkspaceConnCache: wsconncache.New(s.dialWorkspaceAgent, 0),
+	}
+
+	apiRateLimiter := httpmw.RateLimit(opts.APIRateLimit, time.Minute)
+	r.Use(
+		httpmw.Recover(s.Options.Logger),
+		tracing.StatusWriterMiddleware,
+		tracing.Middleware(s.Options.Tracing),
+		httpmw.AttachRequestID,
+		httpmw.ExtractRealIP(s.Options.RealIPConfig),
+		httpmw.Logger(s.Options.Logger),
+		httpmw.Prometheus(s.Options.PrometheusRegistry),
+		// Subdomain apps are served before any other routes.
+		s.handleSubdomainApplications(apiRateLimiter),
+		// This header stops a browser from trying to MIME-sniff the content
+		// type and forces it to stick with the declared content-type.
+		func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Add("X-Content-Type-Options", "nosniff")
+				next.ServeHTTP(w, r)
+			})
+		},
+	)
+
+	r.Get("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("OK"))
+	})
+	r.Get("/latency-check", func(w http.ResponseWriter, r *http.Request) {
+		// Allow the dashboard to read the timing of this request.
+		w.Header().Set("Timing-Allow-Origin", "*")
+		w.WriteHeader(http.StatusOK)
+	})
+
+	apps := func(r chi.Router) {
+		r.Use(apiRateLimiter)
+		r.HandleFunc("/*", s.workspaceAppsProxyPath)
+	}
+	// %40 is the encoded character of the @ symbol. VS Code Web does
+	// not handle character encoding properly, so it's safe to assume
+	// other applications might not as well.
+	r.Route("/%40{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
+	r.Route("/@{user}/{workspace_and_agent}/apps/{workspaceapp}", apps)
+
+	r.Route("/api/v2", func(r chi.Router) {
+		r.NotFound(func(rw http.ResponseWriter, r *http.Request) { httpapi.RouteNotFound(rw) })
+		r.Use(apiRateLimiter)
+		r.Get("/workspaceagents/{workspaceagent}/pty", s.workspaceAgentPTY)
+	})
+
+	r.NotFound(func(rw http.ResponseWriter, r *http.Request) {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusNotFound,
+			Title:        "Not Found",
+			Description:  "This workspace proxy only serves workspace applications and terminals.",
+			RetryEnabled: false,
+			DashboardURL: s.DashboardURL.String(),
+		})
+	})
+
+	return s, nil
+}
+
+// Close stops the proxy and closes all agent connections.
+func (s *Server) Close() error {
+	s.cancel()
+	return s.AppServer.WorkspaceConnCache.Close()
+}
+
+func (s *Server) dialWorkspaceAgent(id uuid.UUID) (*codersdk.WorkspaceAgentConn, error) {
+	return s.SDKClient.DialWorkspaceAgent(s.ctx, id, nil)
+}
+
+// workspaceAppsProxyPath proxies requests to a workspace application through
+// a relative URL path.
+func (s *Server) workspaceAppsProxyPath(rw http.ResponseWriter, r *http.Request) {
+	if s.Options.DisablePathApps {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusUnauthorized,
+			Title:        "Unauthorized",
+			Description:  "Path-based applications are disabled on this Coder deployment by the administrator.",
+			RetryEnabled: false,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return
+	}
+
+	// The proxy can't resolve the current user without a round trip to the
+	// primary, so @me is not supported.
+	if chi.URLParam(r, "user") == codersdk.Me {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadRequest,
+			Title:        "Bad Request",
+			Description:  "The @me username is not supported on workspace proxies. Use your username instead.",
+			RetryEnabled: false,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return
+	}
+
+	if s.handleAPIKeySmuggling(rw, r, false) {
+		return
+	}
+
+	// Determine the real path that was hit. The * URL parameter in Chi will not
+	// include the leading slash if it was present, so we need to add it back.
+	chiPath := chi.URLParam(r, "*")
+	basePath := strings.TrimSuffix(r.URL.Path, chiPath)
+	if strings.HasSuffix(basePath, "/") {
+		chiPath = "/" + chiPath
+	}
+
+	ticket, ok := s.resolveRequest(rw, r, workspaceapps.Request{
+		AccessMethod:      workspaceapps.AccessMethodPath,
+		BasePath:          basePath,
+		UsernameOrID:      chi.URLParam(r, "user"),
+		WorkspaceAndAgent: chi.URLParam(r, "workspace_and_agent"),
+		AppSlugOrPort:     chi.URLParam(r, "workspaceapp"),
+	})
+	if !ok {
+		return
+	}
+
+	s.AppServer.ProxyApp(rw, r, *ticket, chiPath)
+}
+
+// handleSubdomainApplications handles subdomain-based application proxy
+// requests. Requests that don't match the app hostname are passed on.
+func (s *Server) handleSubdomainApplications(middlewares ...func(http.Handler) http.Handler) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+			if s.Options.AppHostnameRegex == nil {
+				next.ServeHTTP(rw, r)
+				return
+			}
+
+			host := httpapi.RequestHost(r)
+			if host == "" || httpapi.HostnamesMatch(s.Options.AccessURL.Hostname(), host) {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			subdomain, ok := httpapi.ExecuteHostnamePattern(s.Options.AppHostnameRegex, host)
+			if !ok {
+				next.ServeHTTP(rw, r)
+				return
+			}
+			app, err := httpapi.ParseSubdomainAppURL(subdomain)
+			if err != nil {
+				site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+					Status:       http.StatusBadRequest,
+					Title:        "Invalid application URL",
+					Description:  fmt.Sprintf("Could not parse subdomain application URL %q: %s", subdomain, err.Error()),
+					RetryEnabled: false,
+					DashboardURL: s.DashboardURL.String(),
+				})
+				return
+			}
+
+			if s.handleAPIKeySmuggling(rw, r, true) {
+				return
+			}
+
+			ticket, ok := s.resolveRequest(rw, r, workspaceapps.Request{
+				AccessMethod:      workspaceapps.AccessMethodSubdomain,
+				BasePath:          "/",
+				UsernameOrID:      app.Username,
+				WorkspaceNameOrID: app.WorkspaceName,
+				AgentNameOrID:     app.AgentName,
+				AppSlugOrPort:     app.AppSlugOrPort,
+			})
+			if !ok {
+				return
+			}
+
+			mws := chi.Middlewares(middlewares)
+			mws.Handler(http.HandlerFunc(func(rw http.ResponseWriter, r *http.Request) {
+				s.AppServer.ProxyApp(rw, r, *ticket, r.URL.Path)
+			})).ServeHTTP(rw, r)
+		})
+	}
+}
+
+// handleAPIKeySmuggling exchanges the encrypted API key that the primary
+// deployment appends to the URL after authenticating the user for a real API
+// key, stores it in a cookie and redirects to the URL without the query
+// parameter. It returns true if the request was handled.
+func (s *Server) handleAPIKeySmuggling(rw http.ResponseWriter, r *http.Request, subdomain bool) bool {
+	encryptedAPIKey := r.URL.Query().Get(workspaceapps.SubdomainProxyAPIKeyParam)
+	if encryptedAPIKey == "" {
+		return false
+	}
+
+	resp, err := s.SDKClient.ExchangeAPIKey(r.Context(), wsproxysdk.ExchangeAPIKeyRequest{
+		EncryptedAPIKey: encryptedAPIKey,
+	})
+	if err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:      http.StatusBadRequest,
+			Title:       "Bad Request",
+			Description: "Could not decrypt API key. Please remove the query parameter and try again.",
+			// Retry is disabled because the user needs to remove the query
+			// parameter before they try again.
+			RetryEnabled: false,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return true
+	}
+
+	// Subdomain app cookies are shared by all apps on the wildcard hostname.
+	// Path app cookies only apply to the proxy hostname.
+	var cookieDomain string
+	if subdomain {
+		hostSplit := strings.SplitN(s.Options.AppHostname, ".", 2)
+		if len(hostSplit) == 2 {
+			cookieDomain = "." + hostSplit[1]
+		}
+	}
+	// We don't set an expiration because the key in the database already has
+	// an expiration.
+	http.SetCookie(rw, &http.Cookie{
+		Name:     codersdk.DevURLSessionTokenCookie,
+		Value:    resp.APIKey,
+		Domain:   cookieDomain,
+		Path:     "/",
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+		Secure:   s.Options.SecureAuthCookie,
+	})
+
+	// Strip the query parameter.
+	u := *r.URL
+	q := u.Query()
+	q.Del(workspaceapps.SubdomainProxyAPIKeyParam)
+	u.RawQuery = q.Encode()
+	http.Redirect(rw, r, u.RequestURI(), http.StatusTemporaryRedirect)
+	return true
+}
+
+// resolveRequest returns the ticket from the request cookie if it is valid
+// for the app, or asks the primary deployment to issue a new one.
+func (s *Server) resolveRequest(rw http.ResponseWriter, r *http.Request, appReq workspaceapps.Request) (*workspaceapps.Ticket, bool) {
+	if err := appReq.Validate(); err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusBadRequest,
+			Title:        "Invalid application request",
+			Description:  err.Error(),
+			RetryEnabled: false,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return nil, false
+	}
+	appReq = appReq.Normalize()
+
+	ticketCookie, err := r.Cookie(codersdk.DevURLSessionTicketCookie)
+	if err == nil {
+		ticket, err := workspaceapps.ParseTicketWithKey(s.TicketSigningKey, ticketCookie.Value)
+		if err == nil && ticket.MatchesRequest(appReq) {
+			return &ticket, true
+		}
+	}
+
+	var sessionToken string
+	if cookie, err := r.Cookie(codersdk.DevURLSessionTokenCookie); err == nil {
+		sessionToken = cookie.Value
+	}
+	requestURL := *r.URL
+	requestURL.Scheme = s.Options.AccessURL.Scheme
+	requestURL.Host = httpapi.RequestHost(r)
+
+	resp, ok := s.SDKClient.IssueSignedAppTicketHTML(r.Context(), rw, wsproxysdk.IssueSignedAppTicketRequest{
+		AppRequest:   appReq,
+		SessionToken: sessionToken,
+		RequestURL:   requestURL.String(),
+	})
+	if !ok {
+		return nil, false
+	}
+
+	ticket, err := workspaceapps.ParseTicketWithKey(s.TicketSigningKey, resp.SignedTicket)
+	if err != nil {
+		site.RenderStaticErrorPage(rw, r, site.ErrorPageData{
+			Status:       http.StatusInternalServerError,
+			Title:        "Internal Server Error",
+			Description:  "The primary deployment issued an invalid ticket: " + err.Error(),
+			RetryEnabled: true,
+			DashboardURL: s.DashboardURL.String(),
+		})
+		return nil, false
+	}
+
+	http.SetCookie(rw, &http.Cookie{
+		Name:    codersdk.DevURLSessionTicketCookie,
+		Value:   resp.SignedTicket,
+		Path:    appReq.BasePath,
+		Expires: time.Unix(ticket.Expiry, 0),
+	})
+	return &ticket, true
+}
+
+// workspaceAgentPTY serves reconnecting PTYs. The user is authorized with a
+// signed ticket issued by the primary deployment in the query parameters, as
+// browsers can't send cookies for this host from the dashboard.
+func (s *Server) workspaceAgentPTY(rw http.ResponseWriter, r *http.Request) {
+	ctx := r.Context()
+
+	agentID, err := uuid.Parse(chi.URLParam(r, "workspaceagent"))
+	if err != nil {
+		httpapi.Write(ctx, rw, http.StatusBadRequest, codersdk.Response{
+			Message: "Invalid workspace agent ID.",
+			Detail:  err.Error(),
+		})
+		return
+	}
+
+	ticket, err := workspaceapps.ParseTicketWithKey(s.TicketSigningKey, r.URL.Query().Get(workspaceapps.TicketQueryParam))
+	if err != nil || !ticket.MatchesRequest(workspaceapps.Request{
+		AccessMethod:  workspaceapps.AccessMethodTerminal,
+		BasePath:      r.URL.Path,
+		AgentNameOrID: agentID.String(),
+	}.Normalize()) {
+		httpapi.Write(ctx, rw, http.StatusUnauthorized, codersdk.Response{
+			Message: "Invalid or missing reconnecting PTY ticket.",
+		})
+		return
+	}
+
+	s.AppServer.ProxyReconnectingPTY(rw, r, agentID, ticket.UserID)
+}
@@ -0,0 +1,309 @@
+package wsproxy_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/require"
+	"nhooyr.io/websocket"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/agent"
+	"github.com/coder/coder/coderd/coderdtest"
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+	"github.com/coder/coder/codersdk/agentsdk"
+	"github.com/coder/coder/enterprise/coderd/coderdenttest"
+	"github.com/coder/coder/enterprise/coderd/license"
+	"github.com/coder/coder/enterprise/wsproxy"
+	"github.com/coder/coder/provisioner/echo"
+	"github.com/coder/coder/provisionersdk/proto"
+	"github.com/coder/coder/testutil"
+)
+
+const (
+	testAgentName = "agent-name"
+	testAppSlug   = "test-app"
+	testAppBody   = "hello world from wsproxy test"
+)
+
+func TestWorkspaceProxy(t *testing.T) {
+	t.Parallel()
+
+	client, proxyURL, agentID := setupProxyTest(t)
+
+	// Don't follow redirects, as they point to the login flow on the primary.
+	proxyClient := &http.Client{
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+	user, err := client.User(context.Background(), codersdk.Me)
+	require.NoError(t, err)
+	appPath := fmt.Sprintf("/@%s/%s.%s/apps/%s/", user.Username, "ws", testAgentName, testAppSlug)
+
+	t.Run("Healthz", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL.JoinPath("/healthz").String(), nil)
+		require.NoError(t, err)
+		res, err := proxyClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusOK, res.StatusCode)
+	})
+
+	t.Run("Regions", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		regions, err := client.Regions(ctx)
+		require.NoError(t, err)
+		require.Len(t, regions, 2)
+		require.Equal(t, proxyURL.String(), regions[1].PathAppURL)
+	})
+
+	t.Run("PathAppUnauthenticated", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL.JoinPath(appPath).String(), nil)
+		require.NoError(t, err)
+		res, err := proxyClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+
+		// The user is sent to the primary to log in, which will send them
+		// back to the proxy with an encrypted API key.
+		require.Equal(t, http.StatusTemporaryRedirect, res.StatusCode)
+		loc, err := url.Parse(res.Header.Get("Location"))
+		require.NoError(t, err)
+		require.Equal(t, client.URL.Host, loc.Host)
+		require.Equal(t, "/api/v2/applications/auth-redirect", loc.Path)
+	})
+
+	t.Run("PathApp", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, proxyURL.JoinPath(appPath).String(), nil)
+		require.NoError(t, err)
+		req.AddCookie(&http.Cookie{
+			Name:  codersdk.DevURLSessionTokenCookie,
+			Value: client.SessionToken(),
+		})
+		res, err := proxyClient.Do(req)
+		require.NoError(t, err)
+		defer res.Body.Close()
+		body, err := io.ReadAll(res.Body)
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, res.StatusCode, string(body))
+		require.Equal(t, testAppBody, string(body))
+
+		// The ticket cookie is set so the next request doesn't need the
+		// primary.
+		var hasTicket bool
+		for _, cookie := range res.Cookies() {
+			if cookie.Name == codersdk.DevURLSessionTicketCookie {
+				hasTicket = true
+			}
+		}
+		require.True(t, hasTicket)
+	})
+
+	t.Run("PTYNoTicket", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		u := ptyURL(proxyURL, agentID, "")
+		_, res, err := websocket.Dial(ctx, u.String(), nil)
+		require.Error(t, err)
+		require.NotNil(t, res)
+		defer res.Body.Close()
+		require.Equal(t, http.StatusUnauthorized, res.StatusCode)
+	})
+
+	t.Run("PTY", func(t *testing.T) {
+		t.Parallel()
+		ctx := testutil.Context(t, testutil.WaitLong)
+
+		ticket, err := client.IssueReconnectingPTYTicket(ctx, codersdk.IssueReconnectingPTYTicketRequest{
+			AgentID: agentID,
+		})
+		require.NoError(t, err)
+
+		wsConn, res, err := websocket.Dial(ctx, ptyURL(proxyURL, agentID, ticket.Ticket).String(), nil)
+		require.NoError(t, err)
+		if res != nil && res.Body != nil {
+			defer res.Body.Close()
+		}
+		conn := websocket.NetConn(ctx, wsConn, websocket.MessageBinary)
+		defer conn.Close()
+
+		// Brief pause to reduce the likelihood that we send keystrokes while
+		// the shell is simultaneously sending a prompt.
+		time.Sleep(100 * time.Millisecond)
+
+		data, err := json.Marshal(codersdk.ReconnectingPTYRequest{
+			Data: "echo test\r\n",
+		})
+		require.NoError(t, err)
+		_, err = conn.Write(data)
+		require.NoError(t, err)
+
+		bufRead := bufio.NewReader(conn)
+		for {
+			line, err := bufRead.ReadString('\n')
+			require.NoError(t, err)
+			if strings.Contains(line, "test") && !strings.Contains(line, "echo") {
+				break
+			}
+		}
+	})
+}
+
+func ptyURL(proxyURL *url.URL, agentID uuid.UUID, ticket string) *url.URL {
+	u := proxyURL.JoinPath(fmt.Sprintf("/api/v2/workspaceagents/%s/pty", agentID))
+	q := u.Query()
+	q.Set("reconnect", uuid.NewString())
+	q.Set("height", "80")
+	q.Set("width", "80")
+	q.Set("command", "/bin/bash")
+	if ticket != "" {
+		q.Set(workspaceapps.TicketQueryParam, ticket)
+	}
+	u.RawQuery = q.Encode()
+	return u
+}
+
+// setupProxyTest starts a primary deployment with a workspace that has an app,
+// and a workspace proxy registered with the primary. It returns a client for
+// the primary, the access URL of the proxy and the ID of the agent.
+func setupProxyTest(t *testing.T) (*codersdk.Client, *url.URL, uuid.UUID) {
+	t.Helper()
+
+	// Start the app on a port above the minimum listening port.
+	var ln net.Listener
+	for i := 0; i < 10; i++ {
+		var err error
+		ln, err = net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		tcpAddr, ok := ln.Addr().(*net.TCPAddr)
+		require.True(t, ok)
+		if tcpAddr.Port >= codersdk.WorkspaceAgentMinimumListeningPort {
+			break
+		}
+		_ = ln.Close()
+	}
+	appServer := &http.Server{
+		ReadHeaderTimeout: time.Minute,
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(testAppBody))
+		}),
+	}
+	go appServer.Serve(ln)
+	t.Cleanup(func() {
+		_ = appServer.Close()
+	})
+
+	client := coderdenttest.New(t, &coderdenttest.Options{
+		Options: &coderdtest.Options{
+			IncludeProvisionerDaemon: true,
+		},
+	})
+	user := coderdtest.CreateFirstUser(t, client)
+	_ = coderdenttest.AddLicense(t, client, coderdenttest.LicenseOptions{
+		Features: license.Features{
+			codersdk.FeatureWorkspaceProxy: 1,
+		},
+	})
+
+	authToken := uuid.NewString()
+	version := coderdtest.CreateTemplateVersion(t, client, user.OrganizationID, &echo.Responses{
+		Parse:         echo.ParseComplete,
+		ProvisionPlan: echo.ProvisionComplete,
+		ProvisionApply: []*proto.Provision_Response{{
+			Type: &proto.Provision_Response_Complete{
+				Complete: &proto.Provision_Complete{
+					Resources: []*proto.Resource{{
+						Name: "example",
+						Type: "aws_instance",
+						Agents: []*proto.Agent{{
+							Id:   uuid.NewString(),
+							Name: testAgentName,
+							Auth: &proto.Agent_Token{
+								Token: authToken,
+							},
+							Apps: []*proto.App{{
+								Slug:         testAppSlug,
+								DisplayName:  testAppSlug,
+								SharingLevel: proto.AppSharingLevel_OWNER,
+								Url:          "http://" + ln.Addr().String(),
+							}},
+						}},
+					}},
+				},
+			},
+		}},
+	})
+	template := coderdtest.CreateTemplate(t, client, user.OrganizationID, version.ID)
+	coderdtest.AwaitTemplateVersionJob(t, client, version.ID)
+	workspace := coderdtest.CreateWorkspace(t, client, user.OrganizationID, template.ID, func(cwr *codersdk.CreateWorkspaceRequest) {
+		cwr.Name = "ws"
+	})
+	coderdtest.AwaitWorkspaceBuildJob(t, client, workspace.LatestBuild.ID)
+
+	agentClient := agentsdk.New(client.URL)
+	agentClient.SetSessionToken(authToken)
+	agentCloser := agent.New(agent.Options{
+		Client: agentClient,
+		Logger: slogtest.Make(t, nil).Named("agent"),
+	})
+	t.Cleanup(func() {
+		_ = agentCloser.Close()
+	})
+	resources := coderdtest.AwaitWorkspaceAgents(t, client, workspace.ID)
+
+	ctx := testutil.Context(t, testutil.WaitLong)
+	proxyRes, err := client.CreateWorkspaceProxy(ctx, codersdk.CreateWorkspaceProxyRequest{
+		Name: "proxy",
+	})
+	require.NoError(t, err)
+
+	// The access URL must be known before the proxy registers, so the
+	// listener is created before the proxy handler exists.
+	srv := httptest.NewUnstartedServer(nil)
+	t.Cleanup(srv.Close)
+	proxyURL, err := url.Parse("http://" + srv.Listener.Addr().String())
+	require.NoError(t, err)
+
+	proxy, err := wsproxy.New(ctx, &wsproxy.Options{
+		Logger:            slogtest.Make(t, nil).Named("wsproxy"),
+		DashboardURL:      client.URL,
+		AccessURL:         proxyURL,
+		RealIPConfig:      &httpmw.RealIPConfig{},
+		ProxySessionToken: proxyRes.ProxyToken,
+	})
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = proxy.Close()
+	})
+	srv.Config.Handler = proxy.Handler
+	srv.Start()
+
+	return client, proxyURL, resources[0].Agents[0].ID
+}
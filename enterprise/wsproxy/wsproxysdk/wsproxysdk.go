@@ -0,0 +1,214 @@
+package wsproxysdk
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/url"
+
+	"github.com/google/uuid"
+	"golang.org/x/xerrors"
+
+	"github.com/coder/coder/coderd/httpmw"
+	"github.com/coder/coder/coderd/workspaceapps"
+	"github.com/coder/coder/codersdk"
+)
+
+// Client is a HTTP client for a subset of Coder API routes that external
+// workspace proxies need.
+type Client struct {
+	SDKClient *codersdk.Client
+	// sdkClientIgnoreRedirects is used for requests whose redirects are meant
+	// for the user's browser rather than for the proxy, such as issuing app
+	// tickets.
+	sdkClientIgnoreRedirects *codersdk.Client
+}
+
+// New creates a external proxy client for the provided primary coder server
+// URL.
+func New(serverURL *url.URL) *Client {
+	sdkClient := codersdk.New(serverURL)
+	sdkClient.SessionTokenHeader = httpmw.WorkspaceProxyAuthTokenHeader
+
+	sdkClientIgnoreRedirects := codersdk.New(serverURL)
+	sdkClientIgnoreRedirects.HTTPClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	sdkClientIgnoreRedirects.SessionTokenHeader = httpmw.WorkspaceProxyAuthTokenHeader
+
+	return &Client{
+		SDKClient:                sdkClient,
+		sdkClientIgnoreRedirects: sdkClientIgnoreRedirects,
+	}
+}
+
+// SetSessionToken sets the proxy token for the client.
+func (c *Client) SetSessionToken(token string) {
+	c.SDKClient.SetSessionToken(token)
+	c.sdkClientIgnoreRedirects.SetSessionToken(token)
+}
+
+// SessionToken returns the currently set token for the client.
+func (c *Client) SessionToken() string {
+	return c.SDKClient.SessionToken()
+}
+
+// Request wraps the underlying codersdk.Client's Request method.
+func (c *Client) Request(ctx context.Context, method, path string, body interface{}, opts ...codersdk.RequestOption) (*http.Response, error) {
+	return c.SDKClient.Request(ctx, method, path, body, opts...)
+}
+
+// RequestIgnoreRedirects wraps the underlying codersdk.Client's Request method
+// on the client that ignores redirects.
+func (c *Client) RequestIgnoreRedirects(ctx context.Context, method, path string, body interface{}, opts ...codersdk.RequestOption) (*http.Response, error) {
+	return c.sdkClientIgnoreRedirects.Request(ctx, method, path, body, opts...)
+}
+
+// DialWorkspaceAgent calls the underlying codersdk.Client's DialWorkspaceAgent
+// method.
+func (c *Client) DialWorkspaceAgent(ctx context.Context, agentID uuid.UUID, options *codersdk.DialWorkspaceAgentOptions) (agentConn *codersdk.WorkspaceAgentConn, err error) {
+	return c.SDKClient.DialWorkspaceAgent(ctx, agentID, options)
+}
+
+type RegisterWorkspaceProxyRequest struct {
+	// AccessURL that hits the workspace proxy api.
+	AccessURL string `json:"access_url"`
+	// WildcardHostname that the workspace proxy api is serving for subdomain
+	// apps.
+	WildcardHostname string `json:"wildcard_hostname"`
+}
+
+type RegisterWorkspaceProxyResponse struct {
+	// AppSecurityKey is the hex encoded key used to verify signed app
+	// tickets issued by the primary deployment.
+	AppSecurityKey string `json:"app_security_key"`
+}
+
+// RegisterWorkspaceProxy registers the access URL of the proxy with the
+// primary deployment and returns the key used to verify app tickets.
+func (c *Client) RegisterWorkspaceProxy(ctx context.Context, req RegisterWorkspaceProxyRequest) (RegisterWorkspaceProxyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost,
+		"/api/v2/workspaceproxies/me/register",
+		req,
+	)
+	if err != nil {
+		return RegisterWorkspaceProxyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusCreated {
+		return RegisterWorkspaceProxyResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp RegisterWorkspaceProxyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
+
+type IssueSignedAppTicketRequest struct {
+	AppRequest workspaceapps.Request `json:"app_request"`
+	// SessionToken is the session token of the user accessing the app, if
+	// any. It is read from the app cookie on the proxy.
+	SessionToken string `json:"session_token"`
+	// RequestURL is the absolute URL of the request on the proxy. It is used
+	// to redirect the user back to the proxy after logging in.
+	RequestURL string `json:"request_url"`
+}
+
+type IssueSignedAppTicketResponse struct {
+	// SignedTicket is a signed ticket that can be verified with the app
+	// security key.
+	SignedTicket string `json:"signed_ticket"`
+}
+
+// IssueSignedAppTicket issues a new signed app ticket for the provided app
+// request. Errors from the primary deployment may be HTML error pages or
+// redirects meant for the user's browser. To relay those to the user, use
+// IssueSignedAppTicketHTML instead.
+func (c *Client) IssueSignedAppTicket(ctx context.Context, req IssueSignedAppTicketRequest) (IssueSignedAppTicketResponse, error) {
+	resp, err := c.RequestIgnoreRedirects(ctx, http.MethodPost, "/api/v2/workspaceproxies/me/issue-ticket", req)
+	if err != nil {
+		return IssueSignedAppTicketResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		return IssueSignedAppTicketResponse{}, codersdk.ReadBodyAsError(resp)
+	}
+
+	var res IssueSignedAppTicketResponse
+	return res, json.NewDecoder(resp.Body).Decode(&res)
+}
+
+// IssueSignedAppTicketHTML issues a new signed app ticket for the provided app
+// request. If the ticket could not be issued, the response from the primary
+// deployment (an HTML error page or a redirect to the login flow) is copied
+// to rw and false is returned.
+func (c *Client) IssueSignedAppTicketHTML(ctx context.Context, rw http.ResponseWriter, req IssueSignedAppTicketRequest) (IssueSignedAppTicketResponse, bool) {
+	writeError := func(rw http.ResponseWriter, err error) {
+		res := codersdk.Response{
+			Message: "Internal server error",
+			Detail:  err.Error(),
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		rw.WriteHeader(http.StatusBadGateway)
+		_ = json.NewEncoder(rw).Encode(res)
+	}
+
+	resp, err := c.RequestIgnoreRedirects(ctx, http.MethodPost, "/api/v2/workspaceproxies/me/issue-ticket", req)
+	if err != nil {
+		writeError(rw, xerrors.Errorf("perform issue signed app ticket request: %w", err))
+		return IssueSignedAppTicketResponse{}, false
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusCreated {
+		// Copy the response to the ResponseWriter.
+		for k, v := range resp.Header {
+			rw.Header()[k] = v
+		}
+		rw.WriteHeader(resp.StatusCode)
+		_, err = io.Copy(rw, resp.Body)
+		if err != nil {
+			writeError(rw, xerrors.Errorf("copy response body: %w", err))
+		}
+		return IssueSignedAppTicketResponse{}, false
+	}
+
+	var res IssueSignedAppTicketResponse
+	err = json.NewDecoder(resp.Body).Decode(&res)
+	if err != nil {
+		writeError(rw, xerrors.Errorf("decode response body: %w", err))
+		return IssueSignedAppTicketResponse{}, false
+	}
+	return res, true
+}
+
+type ExchangeAPIKeyRequest struct {
+	// EncryptedAPIKey is the API key smuggled to the proxy by the
+	// application auth redirect flow.
+	EncryptedAPIKey string `json:"encrypted_api_key"`
+}
+
+type ExchangeAPIKeyResponse struct {
+	APIKey string `json:"api_key"`
+}
+
+// ExchangeAPIKey exchanges an encrypted API key for the API key it contains.
+// Only the primary deployment can decrypt the API key as it requires access
+// to the database.
+func (c *Client) ExchangeAPIKey(ctx context.Context, req ExchangeAPIKeyRequest) (ExchangeAPIKeyResponse, error) {
+	res, err := c.Request(ctx, http.MethodPost,
+		"/api/v2/workspaceproxies/me/exchange-api-key",
+		req,
+	)
+	if err != nil {
+		return ExchangeAPIKeyResponse{}, xerrors.Errorf("make request: %w", err)
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return ExchangeAPIKeyResponse{}, codersdk.ReadBodyAsError(res)
+	}
+	var resp ExchangeAPIKeyResponse
+	return resp, json.NewDecoder(res.Body).Decode(&resp)
+}
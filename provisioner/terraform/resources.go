@@ -31,6 +31,16 @@ type agentAttributes struct {
 	StartupScriptTimeoutSeconds  int32             `mapstructure:"startup_script_timeout"`
 	ShutdownScript               string            `mapstructure:"shutdown_script"`
 	ShutdownScriptTimeoutSeconds int32             `mapstructure:"shutdown_script_timeout"`
+	Metadata                     []agentMetadata   `mapstructure:"metadata"`
+}
+
+// A mapping of attributes on the "metadata" block of a "coder_agent" resource.
+type agentMetadata struct {
+	Key         string `mapstructure:"key"`
+	DisplayName string `mapstructure:"display_name"`
+	Script      string `mapstructure:"script"`
+	Interval    int64  `mapstructure:"interval"`
+	Timeout     int64  `mapstructure:"timeout"`
 }
 
 // A mapping of attributes on the "coder_app" resource.
@@ -148,6 +158,17 @@ func ConvertState(modules []*tfjson.StateModule, rawGraph string, rawParameterNa
 				loginBeforeReady = attrs.LoginBeforeReady
 			}
 
+			var metadata []*proto.Agent_Metadata
+			for _, item := range attrs.Metadata {
+				metadata = append(metadata, &proto.Agent_Metadata{
+					Key:         item.Key,
+					DisplayName: item.DisplayName,
+					Script:      item.Script,
+					Interval:    item.Interval,
+					Timeout:     item.Timeout,
+				})
+			}
+
 			agent := &proto.Agent{
 				Name:                         tfResource.Name,
 				Id:                           attrs.ID,
@@ -163,6 +184,7 @@ func ConvertState(modules []*tfjson.StateModule, rawGraph string, rawParameterNa
 				StartupScriptTimeoutSeconds:  attrs.StartupScriptTimeoutSeconds,
 				ShutdownScript:               attrs.ShutdownScript,
 				ShutdownScriptTimeoutSeconds: attrs.ShutdownScriptTimeoutSeconds,
+				Metadata:                     metadata,
 			}
 			switch attrs.Auth {
 			case "token":
@@ -448,6 +470,9 @@ func ConvertState(modules []*tfjson.StateModule, rawGraph string, rawParameterNa
 		if err != nil {
 			return nil, xerrors.Errorf("decode map values for coder_parameter.%s: %w", resource.Name, err)
 		}
+		// Newer provider versions dropped legacy_variable_name, so it's read
+		// from the raw attributes for templates pinned to older versions.
+		legacyVariableName, _ := resource.AttributeValues["legacy_variable_name"].(string)
 		protoParam := &proto.RichParameter{
 			Name:               param.Name,
 			Description:        param.Description,
@@ -456,7 +481,16 @@ func ConvertState(modules []*tfjson.StateModule, rawGraph string, rawParameterNa
 			DefaultValue:       param.Default,
 			Icon:               param.Icon,
 			Required:           !param.Optional,
-			LegacyVariableName: param.LegacyVariableName,
+			Ephemeral:          param.Ephemeral,
+			LegacyVariableName: legacyVariableName,
+		}
+		if param.Ephemeral {
+			if !param.Mutable {
+				return nil, xerrors.Errorf("coder_parameter.%s: ephemeral parameters must be mutable", resource.Name)
+			}
+			if !param.Optional {
+				return nil, xerrors.Errorf("coder_parameter.%s: ephemeral parameters must have a default value", resource.Name)
+			}
 		}
 		if len(param.Validation) == 1 {
 			protoParam.ValidationRegex = param.Validation[0].Regex
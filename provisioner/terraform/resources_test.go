@@ -221,6 +221,21 @@ func TestConvertResources(t *testing.T) {
 					Value:     "squirrel",
 					Sensitive: true,
 				}},
+				Agents: []*proto.Agent{{
+					Name:                     "main",
+					Auth:                     &proto.Agent_Token{},
+					OperatingSystem:          "linux",
+					Architecture:             "amd64",
+					LoginBeforeReady:         true,
+					ConnectionTimeoutSeconds: 120,
+					Metadata: []*proto.Agent_Metadata{{
+						Key:         "process_count",
+						DisplayName: "Process Count",
+						Script:      "ps -ef | wc -l",
+						Interval:    5,
+						Timeout:     1,
+					}},
+				}},
 			}},
 		},
 		// Tests that resources with the same id correctly get metadata applied
@@ -480,6 +495,53 @@ func TestAppSlugValidation(t *testing.T) {
 	require.ErrorContains(t, err, "duplicate app slug")
 }
 
+func TestParameterEphemeralValidation(t *testing.T) {
+	t.Parallel()
+
+	// nolint:dogsled
+	_, filename, _, _ := runtime.Caller(0)
+
+	// Load the rich-parameters plan file and edit it.
+	dir := filepath.Join(filepath.Dir(filename), "testdata", "rich-parameters")
+	tfPlanRaw, err := os.ReadFile(filepath.Join(dir, "rich-parameters.tfplan.json"))
+	require.NoError(t, err)
+	var tfPlan tfjson.Plan
+	err = json.Unmarshal(tfPlanRaw, &tfPlan)
+	require.NoError(t, err)
+	tfPlanGraph, err := os.ReadFile(filepath.Join(dir, "rich-parameters.tfplan.dot"))
+	require.NoError(t, err)
+	modules := []*tfjson.StateModule{tfPlan.PriorState.Values.RootModule}
+	parameters := func(name string) map[string]interface{} {
+		for _, resource := range tfPlan.PriorState.Values.RootModule.Resources {
+			if resource.Type == "coder_parameter" && resource.AttributeValues["name"] == name {
+				return resource.AttributeValues
+			}
+		}
+		t.Fatalf("parameter %q not found", name)
+		return nil
+	}
+
+	// Ephemeral parameters must be mutable.
+	parameters("Sample")["ephemeral"] = true
+	state, err := terraform.ConvertState(modules, string(tfPlanGraph), nil)
+	require.Nil(t, state)
+	require.ErrorContains(t, err, "ephemeral parameters must be mutable")
+
+	parameters("Sample")["mutable"] = true
+	state, err = terraform.ConvertState(modules, string(tfPlanGraph), nil)
+	require.NoError(t, err)
+	for _, param := range state.Parameters {
+		require.Equal(t, param.Name == "Sample", param.Ephemeral, param.Name)
+	}
+
+	// Ephemeral parameters must have a default value.
+	parameters("Example")["ephemeral"] = true
+	parameters("Example")["mutable"] = true
+	state, err = terraform.ConvertState(modules, string(tfPlanGraph), nil)
+	require.Nil(t, state)
+	require.ErrorContains(t, err, "ephemeral parameters must have a default value")
+}
+
 func TestInstanceTypeAssociation(t *testing.T) {
 	t.Parallel()
 	type tc struct {
@@ -12,6 +12,14 @@ export interface APIKey {
   readonly scope: APIKeyScope
   readonly token_name: string
   readonly lifetime_seconds: number
+  readonly resources?: APIKeyScopeResource[]
+}
+
+// From codersdk/apikey.go
+export interface APIKeyScopeResource {
+  readonly resource_type: string
+  readonly resource_id: string
+  readonly action: string
 }
 
 // From codersdk/apikey.go
@@ -62,6 +70,35 @@ export interface AuditDiffField {
   readonly secret: boolean
 }
 
+// From codersdk/deployment.go
+export interface AuditExportConfig {
+  readonly webhook_url: string
+  readonly webhook_secret: string
+  readonly webhook_batch_size: number
+  readonly webhook_flush_interval: number
+  readonly webhook_queue_size: number
+  readonly syslog_address: string
+  readonly syslog_tls: boolean
+  readonly syslog_facility: string
+}
+
+// From codersdk/audit.go
+export interface AuditFilterRule {
+  readonly resource_types?: ResourceType[]
+  readonly actions?: AuditAction[]
+  readonly user_ids?: string[]
+  readonly status_codes?: number[]
+  readonly build_reasons?: BuildReason[]
+  readonly diff_fields?: string[]
+  readonly store: boolean
+  readonly export: boolean
+}
+
+// From codersdk/audit.go
+export interface AuditFilterRules {
+  readonly rules: AuditFilterRule[]
+}
+
 // From codersdk/audit.go
 export interface AuditLog {
   readonly id: string
@@ -92,6 +129,14 @@ export interface AuditLogResponse {
   readonly count: number
 }
 
+// From codersdk/audit.go
+export interface AuditLogsExportRequest {
+  readonly q?: string
+  readonly format?: AuditLogExportFormat
+  readonly after?: string
+  readonly before?: string
+}
+
 // From codersdk/audit.go
 export interface AuditLogsRequest extends Pagination {
   readonly q?: string
@@ -209,6 +254,7 @@ export interface CreateTemplateVersionRequest {
   readonly example_id?: string
   readonly provisioner: ProvisionerType
   readonly tags: Record<string, string>
+  readonly git_source?: TemplateVersionGitSource
   readonly parameter_values?: CreateParameterRequest[]
   readonly user_variable_values?: VariableValue[]
 }
@@ -229,6 +275,7 @@ export interface CreateTokenRequest {
   readonly lifetime: number
   readonly scope: APIKeyScope
   readonly token_name: string
+  readonly resources?: APIKeyScopeResource[]
 }
 
 // From codersdk/users.go
@@ -250,6 +297,19 @@ export interface CreateWorkspaceBuildRequest {
   readonly rich_parameter_values?: WorkspaceBuildParameter[]
 }
 
+// From codersdk/workspaceproxy.go
+export interface CreateWorkspaceProxyRequest {
+  readonly name: string
+  readonly display_name: string
+  readonly icon: string
+}
+
+// From codersdk/workspaceproxy.go
+export interface CreateWorkspaceProxyResponse {
+  readonly proxy: WorkspaceProxy
+  readonly proxy_token: string
+}
+
 // From codersdk/organizations.go
 export interface CreateWorkspaceRequest {
   readonly template_id: string
@@ -260,6 +320,16 @@ export interface CreateWorkspaceRequest {
   readonly rich_parameter_values?: WorkspaceBuildParameter[]
 }
 
+// From codersdk/roles.go
+export interface CustomRole {
+  readonly name: string
+  readonly display_name: string
+  readonly organization_id?: string
+  readonly site_permissions: Permission[]
+  readonly organization_permissions: Permission[]
+  readonly user_permissions: Permission[]
+}
+
 // From codersdk/templates.go
 export interface DAUEntry {
   readonly date: string
@@ -301,6 +371,12 @@ export interface DangerousConfig {
   readonly allow_path_app_site_owner_access: boolean
 }
 
+// From codersdk/workspaceportshares.go
+export interface DeleteWorkspaceAgentPortShareRequest {
+  readonly agent_name: string
+  readonly port: number
+}
+
 // From codersdk/deployment.go
 export interface DeploymentDAUsResponse {
   readonly entries: DAUEntry[]
@@ -347,6 +423,11 @@ export interface DeploymentValues {
   readonly agent_stat_refresh_interval?: number
   readonly agent_fallback_troubleshooting_url?: string
   readonly audit_logging?: boolean
+  readonly audit_export?: AuditExportConfig
+  readonly retention?: RetentionConfig
+  readonly record_terminal_sessions?: boolean
+  // This is likely an enum in an external package ("github.com/coder/coder/cli/clibase.StringArray")
+  readonly template_git_allowed_hosts?: string[]
   readonly browser_only?: boolean
   readonly scim_api_key?: string
   readonly provisioner?: ProvisionerConfig
@@ -445,6 +526,16 @@ export interface Healthcheck {
   readonly threshold: number
 }
 
+// From codersdk/workspaceagents.go
+export interface IssueReconnectingPTYTicketRequest {
+  readonly agent_id: string
+}
+
+// From codersdk/workspaceagents.go
+export interface IssueReconnectingPTYTicketResponse {
+  readonly ticket: string
+}
+
 // From codersdk/licenses.go
 export interface License {
   readonly id: number
@@ -595,6 +686,13 @@ export interface PatchTemplateVersionRequest {
   readonly name: string
 }
 
+// From codersdk/roles.go
+export interface Permission {
+  readonly negate: boolean
+  readonly resource_type: string
+  readonly action: string
+}
+
 // From codersdk/deployment.go
 export interface PprofConfig {
   readonly enable: boolean
@@ -665,6 +763,22 @@ export interface RateLimitConfig {
   readonly api: number
 }
 
+// From codersdk/workspaceproxy.go
+export interface Region {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly icon_url: string
+  readonly healthy: boolean
+  readonly path_app_url: string
+  readonly wildcard_hostname: string
+}
+
+// From codersdk/workspaceproxy.go
+export interface RegionsResponse {
+  readonly regions: Region[]
+}
+
 // From codersdk/replicas.go
 export interface Replica {
   readonly id: string
@@ -683,6 +797,14 @@ export interface Response {
   readonly validations?: ValidationError[]
 }
 
+// From codersdk/deployment.go
+export interface RetentionConfig {
+  readonly audit_logs: number
+  readonly provisioner_job_logs: number
+  readonly workspace_build_state: number
+  readonly archive_dir: string
+}
+
 // From codersdk/roles.go
 export interface Role {
   readonly name: string
@@ -778,9 +900,14 @@ export interface Template {
   readonly icon: string
   readonly default_ttl_ms: number
   readonly max_ttl_ms: number
+  readonly inactivity_ttl_ms: number
+  readonly locked_ttl_ms: number
+  readonly failure_ttl_ms: number
   readonly created_by_id: string
   readonly created_by_name: string
   readonly allow_user_cancel_workspace_jobs: boolean
+  readonly record_terminal_sessions: boolean
+  readonly max_port_share_level: WorkspaceAppSharingLevel
 }
 
 // From codersdk/templates.go
@@ -832,6 +959,28 @@ export interface TemplateVersion {
   readonly job: ProvisionerJob
   readonly readme: string
   readonly created_by: User
+  readonly archived: boolean
+  readonly git_commit_sha?: string
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionDiff {
+  readonly base_id: string
+  readonly head_id: string
+  readonly files: TemplateVersionFileDiff[]
+  readonly parameter_schemas: TemplateVersionParameterSchemaDiff[]
+  readonly rich_parameters: TemplateVersionRichParameterDiff[]
+  readonly variables: TemplateVersionVariableDiff[]
+  readonly git_auth_providers: TemplateVersionGitAuthProviderDiff[]
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionFileDiff {
+  readonly path: string
+  readonly change: TemplateVersionDiffChange
+  readonly binary: boolean
+  readonly too_large: boolean
+  readonly diff: string
 }
 
 // From codersdk/templateversions.go
@@ -842,6 +991,19 @@ export interface TemplateVersionGitAuth {
   readonly authenticated: boolean
 }
 
+// From codersdk/templateversions.go
+export interface TemplateVersionGitAuthProviderDiff {
+  readonly id: string
+  readonly change: TemplateVersionDiffChange
+}
+
+// From codersdk/organizations.go
+export interface TemplateVersionGitSource {
+  readonly url: string
+  readonly ref?: string
+  readonly directory?: string
+}
+
 // From codersdk/templateversions.go
 export interface TemplateVersionParameter {
   readonly name: string
@@ -859,6 +1021,7 @@ export interface TemplateVersionParameter {
   readonly validation_monotonic?: ValidationMonotonicOrder
   readonly required: boolean
   readonly legacy_variable_name?: string
+  readonly ephemeral: boolean
 }
 
 // From codersdk/templateversions.go
@@ -869,6 +1032,22 @@ export interface TemplateVersionParameterOption {
   readonly icon: string
 }
 
+// From codersdk/templateversions.go
+export interface TemplateVersionParameterSchemaDiff {
+  readonly name: string
+  readonly change: TemplateVersionDiffChange
+  readonly old?: ParameterSchema
+  readonly new?: ParameterSchema
+}
+
+// From codersdk/templateversions.go
+export interface TemplateVersionRichParameterDiff {
+  readonly name: string
+  readonly change: TemplateVersionDiffChange
+  readonly old?: TemplateVersionParameter
+  readonly new?: TemplateVersionParameter
+}
+
 // From codersdk/templateversions.go
 export interface TemplateVersionVariable {
   readonly name: string
@@ -880,9 +1059,40 @@ export interface TemplateVersionVariable {
   readonly sensitive: boolean
 }
 
+// From codersdk/templateversions.go
+export interface TemplateVersionVariableDiff {
+  readonly name: string
+  readonly change: TemplateVersionDiffChange
+  readonly old?: TemplateVersionVariable
+  readonly new?: TemplateVersionVariable
+}
+
 // From codersdk/templates.go
 export interface TemplateVersionsByTemplateRequest extends Pagination {
   readonly template_id: string
+  readonly include_archived: boolean
+}
+
+// From codersdk/terminalrecordings.go
+export interface TerminalRecording {
+  readonly id: string
+  readonly created_at: string
+  readonly started_at: string
+  readonly ended_at: string
+  readonly organization_id: string
+  readonly workspace_id: string
+  readonly agent_id: string
+  readonly user_id: string
+  readonly client_reported_user_id?: string
+  readonly type: TerminalRecordingType
+  readonly size: number
+}
+
+// From codersdk/terminalrecordings.go
+export interface TerminalRecordingsRequest extends Pagination {
+  readonly workspace_id?: string
+  readonly agent_id?: string
+  readonly user_id?: string
 }
 
 // From codersdk/apikey.go
@@ -946,7 +1156,12 @@ export interface UpdateTemplateMeta {
   readonly icon?: string
   readonly default_ttl_ms?: number
   readonly max_ttl_ms?: number
+  readonly inactivity_ttl_ms?: number
+  readonly locked_ttl_ms?: number
+  readonly failure_ttl_ms?: number
   readonly allow_user_cancel_workspace_jobs?: boolean
+  readonly record_terminal_sessions?: boolean
+  readonly max_port_share_level?: WorkspaceAppSharingLevel
 }
 
 // From codersdk/users.go
@@ -965,6 +1180,11 @@ export interface UpdateWorkspaceAutostartRequest {
   readonly schedule?: string
 }
 
+// From codersdk/workspaces.go
+export interface UpdateWorkspaceLock {
+  readonly lock: boolean
+}
+
 // From codersdk/workspaces.go
 export interface UpdateWorkspaceRequest {
   readonly name?: string
@@ -980,6 +1200,13 @@ export interface UploadResponse {
   readonly hash: string
 }
 
+// From codersdk/workspaceportshares.go
+export interface UpsertWorkspaceAgentPortShareRequest {
+  readonly agent_name: string
+  readonly port: number
+  readonly share_level: WorkspaceAppSharingLevel
+}
+
 // From codersdk/users.go
 export interface User {
   readonly id: string
@@ -1035,6 +1262,7 @@ export interface Workspace {
   readonly autostart_schedule?: string
   readonly ttl_ms?: number
   readonly last_used_at: string
+  readonly locked_at?: string
 }
 
 // From codersdk/workspaceagents.go
@@ -1067,6 +1295,48 @@ export interface WorkspaceAgent {
   readonly startup_script_timeout_seconds: number
   readonly shutdown_script?: string
   readonly shutdown_script_timeout_seconds: number
+  readonly netcheck?: WorkspaceAgentNetcheck
+}
+
+// From codersdk/workspaceagentexec.go
+export interface WorkspaceAgentExecFrame {
+  readonly type: WorkspaceAgentExecFrameType
+  readonly data?: string
+  readonly exit_code?: number
+  readonly duration_ms?: number
+  readonly timed_out?: boolean
+  readonly error?: string
+}
+
+// From codersdk/workspaceagentexec.go
+export interface WorkspaceAgentExecRequest {
+  readonly command: string
+  readonly env?: Record<string, string>
+  readonly timeout_ms?: number
+}
+
+// From codersdk/workspaceagentexec.go
+export interface WorkspaceAgentExecResult {
+  readonly exit_code: number
+  // This is likely an enum in an external package ("time.Duration")
+  readonly duration: number
+  readonly timed_out: boolean
+}
+
+// From codersdk/workspaceagentfiles.go
+export interface WorkspaceAgentFileInfo {
+  readonly name: string
+  readonly path: string
+  readonly size: number
+  readonly mode: number
+  readonly is_dir: boolean
+  readonly mod_time: string
+}
+
+// From codersdk/workspaceagentfiles.go
+export interface WorkspaceAgentListFilesResponse {
+  readonly path: string
+  readonly files: WorkspaceAgentFileInfo[]
 }
 
 // From codersdk/workspaceagentconn.go
@@ -1074,6 +1344,7 @@ export interface WorkspaceAgentListeningPort {
   readonly process_name: string
   readonly network: string
   readonly port: number
+  readonly path?: string
 }
 
 // From codersdk/workspaceagentconn.go
@@ -1081,6 +1352,73 @@ export interface WorkspaceAgentListeningPortsResponse {
   readonly ports: WorkspaceAgentListeningPort[]
 }
 
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadata {
+  readonly result: WorkspaceAgentMetadataResult
+  readonly description: WorkspaceAgentMetadataDescription
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataDescription {
+  readonly display_name: string
+  readonly key: string
+  readonly script: string
+  readonly interval: number
+  readonly timeout: number
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentMetadataResult {
+  readonly collected_at: string
+  readonly age: number
+  readonly value: string
+  readonly error: string
+}
+
+// From codersdk/workspaceagents.go
+export interface WorkspaceAgentNetcheck {
+  readonly udp: boolean
+  readonly ipv4: boolean
+  readonly ipv6: boolean
+  readonly global_v4?: string
+  readonly global_v6?: string
+  readonly mapping_varies_by_dest_ip?: boolean
+  readonly nat_type: string
+  readonly preferred_derp: number
+  readonly region_latency_ms: Record<number, number>
+  readonly error?: string
+  readonly checked_at: string
+}
+
+// From codersdk/workspaceportshares.go
+export interface WorkspaceAgentPortShare {
+  readonly workspace_id: string
+  readonly agent_name: string
+  readonly port: number
+  readonly share_level: WorkspaceAppSharingLevel
+  readonly created_at: string
+  readonly updated_at: string
+}
+
+// From codersdk/workspaceportshares.go
+export interface WorkspaceAgentPortSharesResponse {
+  readonly shares: WorkspaceAgentPortShare[]
+}
+
+// From codersdk/workspaceagentconn.go
+export interface WorkspaceAgentReconnectingPTYSession {
+  readonly id: string
+  readonly command: string
+  readonly started_at: string
+  readonly last_activity_at: string
+  readonly connections: number
+}
+
+// From codersdk/workspaceagentconn.go
+export interface WorkspaceAgentReconnectingPTYSessionsResponse {
+  readonly sessions: WorkspaceAgentReconnectingPTYSession[]
+}
+
 // From codersdk/workspaceagents.go
 export interface WorkspaceAgentStartupLog {
   readonly id: number
@@ -1167,6 +1505,27 @@ export interface WorkspaceOptions {
   readonly include_deleted?: boolean
 }
 
+// From codersdk/workspaceproxy.go
+export interface WorkspaceProxy {
+  readonly id: string
+  readonly name: string
+  readonly display_name: string
+  readonly icon: string
+  readonly url: string
+  readonly wildcard_hostname: string
+  readonly created_at: string
+  readonly updated_at: string
+  readonly deleted: boolean
+  readonly status?: WorkspaceProxyStatus
+}
+
+// From codersdk/workspaceproxy.go
+export interface WorkspaceProxyStatus {
+  readonly status: ProxyHealthStatus
+  readonly error?: string
+  readonly checked_at: string
+}
+
 // From codersdk/workspaces.go
 export interface WorkspaceQuota {
   readonly credits_consumed: number
@@ -1207,8 +1566,12 @@ export interface WorkspacesResponse {
 }
 
 // From codersdk/apikey.go
-export type APIKeyScope = "all" | "application_connect"
-export const APIKeyScopes: APIKeyScope[] = ["all", "application_connect"]
+export type APIKeyScope = "all" | "application_connect" | "resources"
+export const APIKeyScopes: APIKeyScope[] = [
+  "all",
+  "application_connect",
+  "resources",
+]
 
 // From codersdk/audit.go
 export type AuditAction =
@@ -1229,11 +1592,24 @@ export const AuditActions: AuditAction[] = [
   "write",
 ]
 
+// From codersdk/audit.go
+export type AuditLogExportFormat = "csv" | "ndjson"
+export const AuditLogExportFormats: AuditLogExportFormat[] = ["csv", "ndjson"]
+
 // From codersdk/workspacebuilds.go
-export type BuildReason = "autostart" | "autostop" | "initiator"
+export type BuildReason =
+  | "autodelete"
+  | "autolock"
+  | "autostart"
+  | "autostop"
+  | "failedstop"
+  | "initiator"
 export const BuildReasons: BuildReason[] = [
+  "autodelete",
+  "autolock",
   "autostart",
   "autostop",
+  "failedstop",
   "initiator",
 ]
 
@@ -1261,6 +1637,7 @@ export type FeatureName =
   | "scim"
   | "template_rbac"
   | "user_limit"
+  | "workspace_proxy"
 export const FeatureNames: FeatureName[] = [
   "advanced_template_scheduling",
   "appearance",
@@ -1272,6 +1649,7 @@ export const FeatureNames: FeatureName[] = [
   "scim",
   "template_rbac",
   "user_limit",
+  "workspace_proxy",
 ]
 
 // From codersdk/workspaceagents.go
@@ -1356,9 +1734,23 @@ export const ProvisionerStorageMethods: ProvisionerStorageMethod[] = ["file"]
 export type ProvisionerType = "echo" | "terraform"
 export const ProvisionerTypes: ProvisionerType[] = ["echo", "terraform"]
 
+// From codersdk/workspaceproxy.go
+export type ProxyHealthStatus =
+  | "reachable"
+  | "unhealthy"
+  | "unreachable"
+  | "unregistered"
+export const ProxyHealthStatuses: ProxyHealthStatus[] = [
+  "reachable",
+  "unhealthy",
+  "unreachable",
+  "unregistered",
+]
+
 // From codersdk/audit.go
 export type ResourceType =
   | "api_key"
+  | "audit_filter_rules"
   | "git_ssh_key"
   | "group"
   | "license"
@@ -1366,9 +1758,12 @@ export type ResourceType =
   | "template_version"
   | "user"
   | "workspace"
+  | "workspace_agent_port_share"
   | "workspace_build"
+  | "workspace_proxy"
 export const ResourceTypes: ResourceType[] = [
   "api_key",
+  "audit_filter_rules",
   "git_ssh_key",
   "group",
   "license",
@@ -1376,7 +1771,9 @@ export const ResourceTypes: ResourceType[] = [
   "template_version",
   "user",
   "workspace",
+  "workspace_agent_port_share",
   "workspace_build",
+  "workspace_proxy",
 ]
 
 // From codersdk/serversentevents.go
@@ -1391,6 +1788,21 @@ export const ServerSentEventTypes: ServerSentEventType[] = [
 export type TemplateRole = "" | "admin" | "use"
 export const TemplateRoles: TemplateRole[] = ["", "admin", "use"]
 
+// From codersdk/templateversions.go
+export type TemplateVersionDiffChange = "added" | "modified" | "removed"
+export const TemplateVersionDiffChanges: TemplateVersionDiffChange[] = [
+  "added",
+  "modified",
+  "removed",
+]
+
+// From codersdk/terminalrecordings.go
+export type TerminalRecordingType = "reconnecting_pty" | "ssh"
+export const TerminalRecordingTypes: TerminalRecordingType[] = [
+  "reconnecting_pty",
+  "ssh",
+]
+
 // From codersdk/users.go
 export type UserStatus = "active" | "suspended"
 export const UserStatuses: UserStatus[] = ["active", "suspended"]
@@ -1402,6 +1814,21 @@ export const ValidationMonotonicOrders: ValidationMonotonicOrder[] = [
   "increasing",
 ]
 
+// From codersdk/workspaceagentexec.go
+export type WorkspaceAgentExecFrameType =
+  | "exit"
+  | "stderr"
+  | "stdin"
+  | "stdin_close"
+  | "stdout"
+export const WorkspaceAgentExecFrameTypes: WorkspaceAgentExecFrameType[] = [
+  "exit",
+  "stderr",
+  "stdin",
+  "stdin_close",
+  "stdout",
+]
+
 // From codersdk/workspaceagents.go
 export type WorkspaceAgentLifecycle =
   | "created"
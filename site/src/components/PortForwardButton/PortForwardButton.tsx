@@ -56,7 +56,10 @@ const TooltipView: React.FC<PortForwardButtonProps> = (props) => {
   const [state] = useMachine(portForwardMachine, {
     context: { agentId: agentId },
   })
-  const ports = state.context.listeningPorts?.ports
+  // Only TCP ports can be accessed with port URLs.
+  const ports = state.context.listeningPorts?.ports.filter(
+    (p) => p.network === "tcp",
+  )
 
   return (
     <>
@@ -246,6 +246,7 @@ You can add instructions here
 
 [Some link info](https://coder.com)`,
   created_by: MockUser,
+  archived: false,
 }
 
 export const MockTemplateVersion2: TypesGen.TemplateVersion = {
@@ -263,6 +264,7 @@ You can add instructions here
 
 [Some link info](https://coder.com)`,
   created_by: MockUser,
+  archived: false,
 }
 
 export const MockTemplate: TypesGen.Template = {
@@ -292,10 +294,15 @@ export const MockTemplate: TypesGen.Template = {
   description: "This is a test description.",
   default_ttl_ms: 24 * 60 * 60 * 1000,
   max_ttl_ms: 2 * 24 * 60 * 60 * 1000,
+  inactivity_ttl_ms: 0,
+  locked_ttl_ms: 0,
+  failure_ttl_ms: 0,
   created_by_id: "test-creator-id",
   created_by_name: "test_creator",
   icon: "/icon/code.svg",
   allow_user_cancel_workspace_jobs: true,
+  record_terminal_sessions: false,
+  max_port_share_level: "owner",
 }
 
 export const MockTemplateVersionFiles: TemplateVersionFiles = {
@@ -777,6 +784,7 @@ export const MockTemplateVersionParameter1: TypesGen.TemplateVersionParameter =
     icon: "/icon/folder.svg",
     options: [],
     required: true,
+    ephemeral: false,
   }
 
 export const MockTemplateVersionParameter2: TypesGen.TemplateVersionParameter =
@@ -793,6 +801,7 @@ export const MockTemplateVersionParameter2: TypesGen.TemplateVersionParameter =
     validation_max: 3,
     validation_monotonic: "increasing",
     required: true,
+    ephemeral: false,
   }
 
 export const MockTemplateVersionParameter3: TypesGen.TemplateVersionParameter =
@@ -808,6 +817,7 @@ export const MockTemplateVersionParameter3: TypesGen.TemplateVersionParameter =
     validation_error: "No way!",
     validation_regex: "^[a-z]{3}$",
     required: true,
+    ephemeral: false,
   }
 
 export const MockTemplateVersionParameter4: TypesGen.TemplateVersionParameter =
@@ -821,6 +831,7 @@ export const MockTemplateVersionParameter4: TypesGen.TemplateVersionParameter =
     icon: "/icon/database.svg",
     options: [],
     required: true,
+    ephemeral: false,
   }
 
 export const MockTemplateVersionParameter5: TypesGen.TemplateVersionParameter =
@@ -837,6 +848,7 @@ export const MockTemplateVersionParameter5: TypesGen.TemplateVersionParameter =
     validation_max: 10,
     validation_monotonic: "decreasing",
     required: true,
+    ephemeral: false,
   }
 
 export const MockTemplateVersionVariable1: TypesGen.TemplateVersionVariable = {
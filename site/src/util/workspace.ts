@@ -80,6 +80,9 @@ export const getDisplayWorkspaceBuildInitiatedBy = (
       return build.initiator_name
     case "autostart":
     case "autostop":
+    case "autolock":
+    case "autodelete":
+    case "failedstop":
       return "Coder"
   }
 }
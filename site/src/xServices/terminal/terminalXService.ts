@@ -222,7 +222,7 @@ export const terminalMachine =
             const commandQuery = context.command
               ? `&command=${encodeURIComponent(context.command)}`
               : ""
-            const url = `${proto}//${location.host}/api/v2/workspaceagents/${context.workspaceAgent.id}/pty?reconnect=${context.reconnection}${commandQuery}`
+            const url = `${proto}//${location.host}/api/v2/workspaceagents/${context.workspaceAgent.id}/pty?reconnect=${context.reconnection}&scrollback=true${commandQuery}`
             const socket = new WebSocket(url)
             socket.binaryType = "arraybuffer"
             socket.addEventListener("open", () => {
@@ -206,6 +206,7 @@ func NewConn(options *Options) (conn *Conn, err error) {
 		dialer:                   dialer,
 		listeners:                map[listenKey]*listener{},
 		peerMap:                  map[tailcfg.NodeID]*tailcfg.Node{},
+		peerNodes:                map[tailcfg.NodeID]*Node{},
 		lastDERPForcedWebsockets: map[int]string{},
 		tunDevice:                tunDevice,
 		netMap:                   netMap,
@@ -302,6 +303,7 @@ type Conn struct {
 	dialer             *tsdial.Dialer
 	tunDevice          *tstun.Wrapper
 	peerMap            map[tailcfg.NodeID]*tailcfg.Node
+	peerNodes          map[tailcfg.NodeID]*Node
 	netMap             *netmap.NetworkMap
 	netStack           *netstack.Impl
 	magicConn          *magicsock.Conn
@@ -366,6 +368,7 @@ func (c *Conn) RemoveAllPeers() error {
 
 	c.netMap.Peers = []*tailcfg.Node{}
 	c.peerMap = map[tailcfg.NodeID]*tailcfg.Node{}
+	c.peerNodes = map[tailcfg.NodeID]*Node{}
 	netMapCopy := *c.netMap
 	c.logger.Debug(context.Background(), "updating network map")
 	c.wireguardEngine.SetNetworkMap(&netMapCopy)
@@ -398,6 +401,7 @@ func (c *Conn) UpdateNodes(nodes []*Node, replacePeers bool) error {
 	if replacePeers {
 		c.netMap.Peers = []*tailcfg.Node{}
 		c.peerMap = map[tailcfg.NodeID]*tailcfg.Node{}
+		c.peerNodes = map[tailcfg.NodeID]*Node{}
 	}
 	for _, peer := range c.netMap.Peers {
 		peerStatus, ok := status.Peer[peer.Key]
@@ -416,6 +420,7 @@ func (c *Conn) UpdateNodes(nodes []*Node, replacePeers bool) error {
 			continue
 		}
 		delete(c.peerMap, peer.ID)
+		delete(c.peerNodes, peer.ID)
 	}
 	for _, node := range nodes {
 		// If no preferred DERP is provided, we can't reach the node.
@@ -445,6 +450,7 @@ func (c *Conn) UpdateNodes(nodes []*Node, replacePeers bool) error {
 			peerNode.Endpoints = nil
 		}
 		c.peerMap[node.ID] = peerNode
+		c.peerNodes[node.ID] = node
 	}
 	c.netMap.Peers = make([]*tailcfg.Node, 0, len(c.peerMap))
 	for _, peer := range c.peerMap {
@@ -482,6 +488,27 @@ func (c *Conn) NodeAddresses(publicKey key.NodePublic) ([]netip.Prefix, bool) {
 	return nil, false
 }
 
+// PeerNode returns the last node received for the peer with the IP
+// address. Endpoints are included even if they are blocked.
+func (c *Conn) PeerNode(ip netip.Addr) (*Node, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	for _, node := range c.peerNodes {
+		for _, prefix := range node.Addresses {
+			if prefix.Contains(ip) {
+				return node, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// BlockEndpoints returns whether direct connections are disabled, forcing
+// traffic through DERP.
+func (c *Conn) BlockEndpoints() bool {
+	return c.blockEndpoints
+}
+
 // Status returns the current ipnstate of a connection.
 func (c *Conn) Status() *ipnstate.Status {
 	sb := &ipnstate.StatusBuilder{WantPeers: true}
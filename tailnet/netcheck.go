@@ -0,0 +1,85 @@
+package tailnet
+
+import (
+	"context"
+	"net"
+	"net/netip"
+
+	"golang.org/x/xerrors"
+	"tailscale.com/net/netcheck"
+	"tailscale.com/tailcfg"
+
+	"cdr.dev/slog"
+)
+
+// NAT types returned by NATType.
+const (
+	NATTypeUnknown    = "unknown"
+	NATTypeUDPBlocked = "udp_blocked"
+	NATTypeNone       = "none"
+	NATTypeEasy       = "easy"
+	NATTypeHard       = "hard"
+)
+
+// Netcheck probes the STUN servers and DERP regions in the DERP map to find
+// out whether UDP works, the public addresses of this machine and the
+// latency to each region. A new socket is used, so it's independent of any
+// connection.
+func Netcheck(ctx context.Context, logger slog.Logger, derpMap *tailcfg.DERPMap) (*netcheck.Report, error) {
+	if derpMap == nil {
+		return nil, xerrors.New("no DERP map")
+	}
+	client := &netcheck.Client{
+		Logf: Logger(logger),
+	}
+	report, err := client.GetReport(ctx, derpMap)
+	if err != nil {
+		return nil, xerrors.Errorf("get netcheck report: %w", err)
+	}
+	return report, nil
+}
+
+// NATType classifies the NAT in front of this machine from a netcheck
+// report. Direct connections usually work through an easy NAT, which maps a
+// local port to the same public port for every destination. A hard NAT
+// picks a new public port per destination, so direct connections only work
+// if the other side is reachable.
+func NATType(report *netcheck.Report) string {
+	switch {
+	case report == nil:
+		return NATTypeUnknown
+	case !report.UDP:
+		return NATTypeUDPBlocked
+	case report.MappingVariesByDestIP.EqualBool(true):
+		return NATTypeHard
+	case report.GlobalV4 != "" && isLocalAddr(report.GlobalV4):
+		return NATTypeNone
+	case report.MappingVariesByDestIP.EqualBool(false):
+		return NATTypeEasy
+	default:
+		return NATTypeUnknown
+	}
+}
+
+// isLocalAddr returns whether the IP of the ip:port is assigned to an
+// interface of this machine.
+func isLocalAddr(addrPort string) bool {
+	ipp, err := netip.ParseAddrPort(addrPort)
+	if err != nil {
+		return false
+	}
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return false
+	}
+	for _, addr := range addrs {
+		prefix, err := netip.ParsePrefix(addr.String())
+		if err != nil {
+			continue
+		}
+		if prefix.Addr().Unmap() == ipp.Addr().Unmap() {
+			return true
+		}
+	}
+	return false
+}
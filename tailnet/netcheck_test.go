@@ -0,0 +1,81 @@
+package tailnet_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"tailscale.com/net/netcheck"
+
+	"cdr.dev/slog/sloggers/slogtest"
+	"github.com/coder/coder/tailnet"
+	"github.com/coder/coder/tailnet/tailnettest"
+	"github.com/coder/coder/testutil"
+)
+
+func TestNetcheck(t *testing.T) {
+	t.Parallel()
+	derpMap := tailnettest.RunDERPAndSTUN(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), testutil.WaitLong)
+	defer cancel()
+	report, err := tailnet.Netcheck(ctx, slogtest.Make(t, nil), derpMap)
+	require.NoError(t, err)
+	require.True(t, report.UDP)
+	require.Equal(t, 1, report.PreferredDERP)
+	require.Contains(t, report.RegionLatency, 1)
+	// The STUN server sees the loopback address of this machine.
+	require.Equal(t, tailnet.NATTypeNone, tailnet.NATType(report))
+}
+
+func TestNATType(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name   string
+		report *netcheck.Report
+		want   string
+	}{
+		{
+			name: "NoReport",
+			want: tailnet.NATTypeUnknown,
+		},
+		{
+			name:   "UDPBlocked",
+			report: &netcheck.Report{},
+			want:   tailnet.NATTypeUDPBlocked,
+		},
+		{
+			name: "Hard",
+			report: &netcheck.Report{
+				UDP:                   true,
+				GlobalV4:              "203.0.113.1:41641",
+				MappingVariesByDestIP: "true",
+			},
+			want: tailnet.NATTypeHard,
+		},
+		{
+			name: "Easy",
+			report: &netcheck.Report{
+				UDP:                   true,
+				GlobalV4:              "203.0.113.1:41641",
+				MappingVariesByDestIP: "false",
+			},
+			want: tailnet.NATTypeEasy,
+		},
+		{
+			name: "Unknown",
+			report: &netcheck.Report{
+				UDP: true,
+			},
+			want: tailnet.NATTypeUnknown,
+		},
+	}
+	for _, tt := range tests {
+		tt := tt
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			require.Equal(t, tt.want, tailnet.NATType(tt.report))
+		})
+	}
+}